@@ -35,7 +35,7 @@ export const api = {
   },
 
   /** Get current YES/NO prices for a market. */
-  getPrice(marketID: string): Promise<{ yes: string; no: string }> {
+  getPrice(marketID: string): Promise<{ yes: string; no: string; spread: string }> {
     return fetchJSON(`${API_BASE}/markets/${marketID}/price`);
   },
 
@@ -19,6 +19,8 @@ export interface TradeRequest {
   contract_id: string;
   side: "YES" | "NO";
   quantity: string;
+  max_fill_price?: string; // buys: reject if the fill is above this
+  min_fill_price?: string; // sells: reject if the fill is below this
 }
 
 export interface TradeResponse {
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/atmx/market-engine/internal/version"
+)
+
+// healthProbeTimeout bounds each dependency probe, so one hung dependency
+// can't hold up the report.
+const healthProbeTimeout = 2 * time.Second
+
+// Dependency statuses, from best to worst.
+const (
+	statusOK       = "ok"
+	statusDegraded = "degraded"
+)
+
+// depProbe checks one dependency. Probe makes a round trip and returns the
+// dependency's server version.
+type depProbe struct {
+	Name  string
+	Probe func(context.Context) (version string, err error)
+}
+
+// DepStatus is one dependency's entry in the /health/deps report.
+type DepStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Version   string  `json:"version,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// DepsHealth is the JSON body returned from GET /health/deps.
+type DepsHealth struct {
+	Status       string       `json:"status"` // worst of the dependencies
+	Service      string       `json:"service"`
+	Build        version.Info `json:"build"`
+	Dependencies []DepStatus  `json:"dependencies"`
+}
+
+// depsHealthHandler serves GET /health/deps: every probe run concurrently,
+// each under its own healthProbeTimeout, reported in the order given. The
+// response is 503 when any dependency is degraded, so it can back an alert
+// as well as be read; /health stays the plain liveness check.
+func depsHealthHandler(probes []depProbe, build version.Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := DepsHealth{
+			Status:       statusOK,
+			Service:      "market-engine",
+			Build:        build,
+			Dependencies: make([]DepStatus, len(probes)),
+		}
+
+		var wg sync.WaitGroup
+		for i, p := range probes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				report.Dependencies[i] = runProbe(r.Context(), p)
+			}()
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		for _, d := range report.Dependencies {
+			if d.Status != statusOK {
+				report.Status = statusDegraded
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// runProbe runs p with a bounded context and times it.
+func runProbe(ctx context.Context, p depProbe) DepStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	v, err := p.Probe(ctx)
+	d := DepStatus{
+		Name:      p.Name,
+		Status:    statusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Version:   v,
+	}
+	if err != nil {
+		d.Status = statusDegraded
+		d.Error = err.Error()
+	}
+	return d
+}
+
+// pgQuerier is satisfied by *pgxpool.Pool.
+type pgQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// postgresProbe reads the server version, which doubles as the ping.
+func postgresProbe(name string, db pgQuerier) depProbe {
+	return depProbe{Name: name, Probe: func(ctx context.Context) (string, error) {
+		var v string
+		err := db.QueryRow(ctx, "SHOW server_version").Scan(&v)
+		return v, err
+	}}
+}
+
+// redisProbe reads redis_version from INFO server, which doubles as the
+// ping.
+func redisProbe(rdb redis.Cmdable) depProbe {
+	return depProbe{Name: "redis", Probe: func(ctx context.Context) (string, error) {
+		info, err := rdb.Info(ctx, "server").Result()
+		if err != nil {
+			return "", err
+		}
+		for _, line := range strings.Split(info, "\n") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+				return v, nil
+			}
+		}
+		return "", errors.New("redis_version missing from INFO server")
+	}}
+}
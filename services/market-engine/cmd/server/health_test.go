@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/atmx/market-engine/internal/version"
+)
+
+// fakePostgres answers SHOW server_version with version.
+type fakePostgres struct{ version string }
+
+func (f fakePostgres) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return fakeRow{f.version}
+}
+
+type fakeRow struct{ value string }
+
+func (r fakeRow) Scan(dest ...any) error {
+	*dest[0].(*string) = r.value
+	return nil
+}
+
+// fakeRedis answers INFO with err, or with info when err is nil.
+type fakeRedis struct {
+	redis.Cmdable
+	info string
+	err  error
+}
+
+func (f fakeRedis) Info(ctx context.Context, sections ...string) *redis.StringCmd {
+	return redis.NewStringResult(f.info, f.err)
+}
+
+func getDepsHealth(t *testing.T, probes []depProbe) (int, DepsHealth) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	depsHealthHandler(probes, version.Get()).ServeHTTP(w, httptest.NewRequest("GET", "/health/deps", nil))
+	var resp DepsHealth
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v: %s", err, w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func TestDepsHealth_FailingRedisDegrades(t *testing.T) {
+	code, resp := getDepsHealth(t, []depProbe{
+		postgresProbe("postgres", fakePostgres{"16.2"}),
+		redisProbe(fakeRedis{err: errors.New("dial tcp: connection refused")}),
+	})
+
+	if code != http.StatusServiceUnavailable || resp.Status != statusDegraded {
+		t.Errorf("overall = %d %q, want 503 degraded", code, resp.Status)
+	}
+	if len(resp.Dependencies) != 2 {
+		t.Fatalf("got %d dependencies, want 2", len(resp.Dependencies))
+	}
+	pg, rd := resp.Dependencies[0], resp.Dependencies[1]
+	if pg.Name != "postgres" || pg.Status != statusOK || pg.Version != "16.2" || pg.Error != "" {
+		t.Errorf("postgres = %+v, want ok at 16.2", pg)
+	}
+	if rd.Name != "redis" || rd.Status != statusDegraded || rd.Error != "dial tcp: connection refused" {
+		t.Errorf("redis = %+v, want degraded with the error", rd)
+	}
+}
+
+func TestDepsHealth_AllHealthy(t *testing.T) {
+	code, resp := getDepsHealth(t, []depProbe{
+		postgresProbe("postgres", fakePostgres{"16.2"}),
+		redisProbe(fakeRedis{info: "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n"}),
+	})
+	if code != http.StatusOK || resp.Status != statusOK {
+		t.Errorf("overall = %d %q, want 200 ok", code, resp.Status)
+	}
+	if v := resp.Dependencies[1].Version; v != "7.2.4" {
+		t.Errorf("redis version = %q, want 7.2.4", v)
+	}
+	if resp.Build != version.Get() {
+		t.Errorf("build = %+v, want %+v", resp.Build, version.Get())
+	}
+
+	// The in-memory store has no dependencies to report.
+	if code, resp := getDepsHealth(t, nil); code != http.StatusOK || resp.Status != statusOK || len(resp.Dependencies) != 0 {
+		t.Errorf("no dependencies: %d %+v", code, resp)
+	}
+}
+
+func TestDepsHealth_ProbesRunConcurrentlyWithTimeout(t *testing.T) {
+	hang := func(ctx context.Context) (string, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("probe context has no deadline")
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+			return "1", nil
+		}
+	}
+	probes := []depProbe{{"a", hang}, {"b", hang}, {"c", hang}}
+
+	start := time.Now()
+	code, resp := getDepsHealth(t, probes)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("three 200ms probes took %v; want them concurrent", elapsed)
+	}
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %+v", code, resp)
+	}
+	for _, d := range resp.Dependencies {
+		if d.LatencyMS < 200 {
+			t.Errorf("%s: latency %vms, want at least 200", d.Name, d.LatencyMS)
+		}
+	}
+}
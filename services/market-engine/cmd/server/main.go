@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,9 +20,12 @@ import (
 	"github.com/shopspring/decimal"
 
 	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/events"
+	"github.com/atmx/market-engine/internal/lmsr"
 	"github.com/atmx/market-engine/internal/metrics"
 	"github.com/atmx/market-engine/internal/store"
 	"github.com/atmx/market-engine/internal/trade"
+	"github.com/atmx/market-engine/internal/version"
 )
 
 func main() {
@@ -33,7 +39,9 @@ func main() {
 
 	// --- Initialize store ---
 	var st store.Store
-	var cleanup []func()
+	var cleanup []func() // store connections, closed last on shutdown
+	var tradeOpts []trade.Option
+	var healthProbes []depProbe // dependencies reported by /health/deps
 
 	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
 		pool, err := pgxpool.New(context.Background(), dbURL)
@@ -42,9 +50,32 @@ func main() {
 			os.Exit(1)
 		}
 		cleanup = append(cleanup, pool.Close)
-		st = store.NewPostgresStore(pool)
+		healthProbes = append(healthProbes, postgresProbe("postgres", pool))
 		slog.Info("connected to PostgreSQL")
 
+		// Apply embedded schema migrations before serving.
+		if os.Getenv("RUN_MIGRATIONS") == "true" {
+			if err := store.Migrate(context.Background(), pool); err != nil {
+				slog.Error("database migration failed", "err", err)
+				os.Exit(1)
+			}
+			slog.Info("database migrations complete")
+		}
+		// Optional read replica for read-only requests (see
+		// trade.ReplicaReads); writes and the trade path use the primary.
+		var replica *pgxpool.Pool
+		if readURL := os.Getenv("READ_DATABASE_URL"); readURL != "" {
+			replica, err = pgxpool.New(context.Background(), readURL)
+			if err != nil {
+				slog.Error("read replica connection failed", "err", err)
+				os.Exit(1)
+			}
+			cleanup = append(cleanup, replica.Close)
+			healthProbes = append(healthProbes, postgresProbe("postgres_replica", replica))
+			slog.Info("read replica enabled")
+		}
+		st = store.NewPostgresStoreWithReplica(pool, replica)
+
 		// Wrap with Redis read-through cache if configured.
 		if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
 			opt, err := redis.ParseURL(redisURL)
@@ -54,32 +85,351 @@ func main() {
 			}
 			rdb := redis.NewClient(opt)
 			cleanup = append(cleanup, func() { rdb.Close() })
-			st = store.NewCachedStore(st, rdb, 30*time.Second)
+			healthProbes = append(healthProbes, redisProbe(rdb))
+			cached := store.NewCachedStoreWithTTLs(st, rdb, store.CacheTTLs{
+				Market:    30 * time.Second,
+				Contract:  time.Hour, // ticker → ID never changes
+				Positions: 10 * time.Second,
+			})
+			st = cached
 			slog.Info("Redis cache enabled")
+
+			// Pre-load the most recently traded markets in the background.
+			if n, _ := strconv.Atoi(os.Getenv("CACHE_WARM_MARKETS")); n > 0 {
+				warmCtx, stopWarm := context.WithCancel(context.Background())
+				cleanup = append(cleanup, stopWarm)
+				cached.WarmAsync(warmCtx, n)
+				slog.Info("Redis cache warmer started", "markets", n)
+			}
+
+			// Per-market distributed lock so multiple instances can serve trades.
+			tradeOpts = append(tradeOpts, trade.WithLocker(trade.NewRedisLocker(rdb, 10*time.Second)))
+			slog.Info("Redis market locks enabled")
+
+			// Pending trade quotes, so any instance can execute a quote.
+			tradeOpts = append(tradeOpts, trade.WithQuoteStore(trade.NewRedisQuoteStore(rdb)))
 		}
 	} else {
 		slog.Warn("DATABASE_URL not set, using in-memory store (data will not persist)")
 		st = store.NewMemoryStore()
 	}
 
-	defer func() {
-		for _, fn := range cleanup {
-			fn()
+	// Optional write-behind ledger: trades enqueue their ledger entry and a
+	// background writer batches the inserts. Flushed on shutdown below.
+	var ledgerQueue *store.WriteBehindStore
+	if os.Getenv("LEDGER_WRITE_BEHIND") == "true" {
+		cfg := store.DefaultWriteBehindConfig
+		if n, _ := strconv.Atoi(os.Getenv("LEDGER_QUEUE_SIZE")); n > 0 {
+			cfg.QueueSize = n
 		}
-	}()
+		if n, _ := strconv.Atoi(os.Getenv("LEDGER_BATCH_SIZE")); n > 0 {
+			cfg.BatchSize = n
+		}
+		ledgerQueue = store.NewWriteBehindStore(st, cfg)
+		st = ledgerQueue
+		slog.Info("ledger write-behind enabled", "queue_size", cfg.QueueSize, "batch_size", cfg.BatchSize)
+	}
+
+	// Optional event stream: trades, market creation and settlement are
+	// published to NATS for downstream consumers.
+	var emitter *events.NATSEmitter
+	if natsURL := os.Getenv("EVENTS_NATS_URL"); natsURL != "" {
+		var err error
+		emitter, err = events.DialNATS(natsURL, os.Getenv("EVENTS_SUBJECT_PREFIX"))
+		if err != nil {
+			slog.Error("event stream connection failed", "err", err)
+			os.Exit(1)
+		}
+		tradeOpts = append(tradeOpts, trade.WithEmitter(emitter))
+		slog.Info("event stream enabled", "nats", natsURL)
+	}
 
 	// --- Position limits ---
 	maxPerCell := decimal.NewFromInt(1000)
 	maxCorrelated := decimal.NewFromInt(5000)
 	prefixLen := 5 // hurricane-scale correlation radius
 	limiter := correlation.NewPositionLimiter(maxPerCell, maxCorrelated, prefixLen)
+	if n, _ := strconv.Atoi(os.Getenv("MAX_CORRELATED_CELLS")); n > 0 {
+		limiter.MaxCorrelatedCells = n
+		slog.Info("correlated cell cap enabled", "max_cells", n)
+	}
+	// POSITION_LIMIT_UNIT=notional measures the limits above in shares ×
+	// price rather than shares.
+	unit, err := correlation.ParseExposureUnit(os.Getenv("POSITION_LIMIT_UNIT"))
+	if err != nil {
+		slog.Error("invalid POSITION_LIMIT_UNIT", "err", err)
+		os.Exit(1)
+	}
+	limiter.Unit = unit
+	if unit == correlation.UnitNotional {
+		slog.Info("position limits measured in notional")
+	}
+
+	// POSITION_LIMIT_EXEMPT_USERS is a comma-separated list of accounts,
+	// such as designated market makers, that skip position limits.
+	if spec := os.Getenv("POSITION_LIMIT_EXEMPT_USERS"); spec != "" {
+		var exempt []string
+		for _, id := range strings.Split(spec, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				exempt = append(exempt, id)
+			}
+		}
+		tradeOpts = append(tradeOpts, trade.WithLimitExemptUsers(exempt...))
+		slog.Info("position limit exemptions enabled", "users", len(exempt))
+	}
+
+	// MAX_TRADE_QUANTITY caps |quantity| per trade or quote (default 1e12).
+	if spec := os.Getenv("MAX_TRADE_QUANTITY"); spec != "" {
+		max, err := decimal.NewFromString(spec)
+		if err != nil || !max.IsPositive() {
+			slog.Error("invalid MAX_TRADE_QUANTITY", "value", spec)
+			os.Exit(1)
+		}
+		lmsr.MaxQuantity = max
+	}
 
 	// --- WebSocket hub ---
-	wsHub := trade.NewWSHub()
+	var wsOpts []trade.WSHubOption
+	if os.Getenv("WS_COMPRESSION") == "true" {
+		wsOpts = append(wsOpts, trade.WithCompression(true))
+		slog.Info("WebSocket permessage-deflate enabled")
+	}
+	// WS_COALESCE_INTERVAL (e.g. "250ms") sends at most one trade update
+	// per market per interval, with the latest price.
+	if v := os.Getenv("WS_COALESCE_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			slog.Error("invalid WS_COALESCE_INTERVAL", "value", v)
+			os.Exit(1)
+		}
+		wsOpts = append(wsOpts, trade.WithCoalescing(interval))
+		slog.Info("WebSocket trade coalescing enabled", "interval", interval)
+	}
+	wsHub := trade.NewWSHub(wsOpts...)
 	go wsHub.Run()
 
 	// --- Trade service ---
-	tradeSvc := trade.NewService(st, limiter, wsHub)
+	// Per-type default b, e.g. DEFAULT_LIQUIDITY_BY_TYPE="WIND=250,TEMP=60",
+	// overrides the built-in defaults for the types it names.
+	if spec := os.Getenv("DEFAULT_LIQUIDITY_BY_TYPE"); spec != "" {
+		overrides, err := trade.ParseDefaultLiquidity(spec)
+		if err != nil {
+			slog.Error("invalid DEFAULT_LIQUIDITY_BY_TYPE", "err", err)
+			os.Exit(1)
+		}
+		byType := maps.Clone(trade.DefaultLiquidityByType)
+		maps.Copy(byType, overrides)
+		tradeOpts = append(tradeOpts, trade.WithDefaultLiquidity(byType))
+		slog.Info("default liquidity by type", "spec", spec)
+	}
+	// Correlated-limit utilizations that trigger a risk alert, e.g.
+	// RISK_ALERT_THRESHOLDS="0.8,0.95"; "off" disables the alerts.
+	if spec := os.Getenv("RISK_ALERT_THRESHOLDS"); spec != "" {
+		var thresholds []decimal.Decimal
+		if spec != "off" {
+			for _, v := range strings.Split(spec, ",") {
+				th, err := decimal.NewFromString(strings.TrimSpace(v))
+				if err != nil || !th.IsPositive() {
+					slog.Error("invalid RISK_ALERT_THRESHOLDS", "value", v)
+					os.Exit(1)
+				}
+				thresholds = append(thresholds, th)
+			}
+		}
+		tradeOpts = append(tradeOpts, trade.WithRiskAlertThresholds(thresholds...))
+	}
+	// Trades with |cost| below MIN_TRADE_NOTIONAL are rejected.
+	if spec := os.Getenv("MIN_TRADE_NOTIONAL"); spec != "" {
+		min, err := decimal.NewFromString(spec)
+		if err != nil || !min.IsPositive() {
+			slog.Error("invalid MIN_TRADE_NOTIONAL", "value", spec)
+			os.Exit(1)
+		}
+		tradeOpts = append(tradeOpts, trade.WithMinNotional(min))
+		slog.Info("minimum trade notional", "min", min.String())
+	}
+	// Trades with |cost| >= TRADE_CONFIRM_NOTIONAL are quoted and must be
+	// confirmed with the quote's token within TRADE_CONFIRM_TTL, at a fill
+	// price within TRADE_CONFIRM_TOLERANCE of the quote.
+	if spec := os.Getenv("TRADE_CONFIRM_NOTIONAL"); spec != "" {
+		notional, err := decimal.NewFromString(spec)
+		if err != nil || !notional.IsPositive() {
+			slog.Error("invalid TRADE_CONFIRM_NOTIONAL", "value", spec)
+			os.Exit(1)
+		}
+		ttl := trade.DefaultQuoteTTL
+		if v := os.Getenv("TRADE_CONFIRM_TTL"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+				slog.Error("invalid TRADE_CONFIRM_TTL", "value", v)
+				os.Exit(1)
+			}
+		}
+		tolerance := trade.DefaultQuoteTolerance
+		if v := os.Getenv("TRADE_CONFIRM_TOLERANCE"); v != "" {
+			if tolerance, err = decimal.NewFromString(v); err != nil || tolerance.IsNegative() {
+				slog.Error("invalid TRADE_CONFIRM_TOLERANCE", "value", v)
+				os.Exit(1)
+			}
+		}
+		tradeOpts = append(tradeOpts, trade.WithTradeConfirmation(notional, ttl, tolerance))
+		slog.Info("large trade confirmation enabled",
+			"notional", notional.String(), "ttl", ttl, "tolerance", tolerance.String())
+	}
+	// SYSTEMIC_EXPOSURE_THRESHOLD flags correlated groups whose exposure
+	// across all users exceeds it in GET /api/v1/admin/exposure.
+	if spec := os.Getenv("SYSTEMIC_EXPOSURE_THRESHOLD"); spec != "" {
+		threshold, err := decimal.NewFromString(spec)
+		if err != nil || !threshold.IsPositive() {
+			slog.Error("invalid SYSTEMIC_EXPOSURE_THRESHOLD", "value", spec)
+			os.Exit(1)
+		}
+		tradeOpts = append(tradeOpts, trade.WithSystemicThreshold(threshold))
+	}
+
+	// CIRCUIT_BREAKER_MOVE_PCT halts a market's trading for
+	// CIRCUIT_BREAKER_COOLDOWN once its YES price moves more than that
+	// percentage within CIRCUIT_BREAKER_WINDOW.
+	if spec := os.Getenv("CIRCUIT_BREAKER_MOVE_PCT"); spec != "" {
+		move, err := decimal.NewFromString(spec)
+		if err != nil || !move.IsPositive() {
+			slog.Error("invalid CIRCUIT_BREAKER_MOVE_PCT", "value", spec)
+			os.Exit(1)
+		}
+		window, cooldown := time.Minute, 5*time.Minute
+		for name, v := range map[string]*time.Duration{"CIRCUIT_BREAKER_WINDOW": &window, "CIRCUIT_BREAKER_COOLDOWN": &cooldown} {
+			if spec := os.Getenv(name); spec != "" {
+				if *v, err = time.ParseDuration(spec); err != nil || *v <= 0 {
+					slog.Error("invalid "+name, "value", spec)
+					os.Exit(1)
+				}
+			}
+		}
+		tradeOpts = append(tradeOpts, trade.WithCircuitBreaker(move, window, cooldown))
+		slog.Info("circuit breaker enabled", "move_pct", move.String(), "window", window, "cooldown", cooldown)
+	}
+
+	// PRICE_BOUND_SUGGESTION_SCALE sets the decimal places of the
+	// max_allowed_quantity suggested when a trade is rejected at the LMSR
+	// price bounds (default 2; 0 suggests whole shares).
+	if spec := os.Getenv("PRICE_BOUND_SUGGESTION_SCALE"); spec != "" {
+		places, err := strconv.Atoi(spec)
+		if err != nil || places < 0 || places > int(lmsr.PriceScale) {
+			slog.Error("invalid PRICE_BOUND_SUGGESTION_SCALE", "value", spec)
+			os.Exit(1)
+		}
+		tradeOpts = append(tradeOpts, trade.WithSuggestedQuantityScale(int32(places)))
+	}
+
+	// NWS_LIQUIDITY_MIN / NWS_LIQUIDITY_MAX bound the b derived from an NWS
+	// forecast's confidence interval (defaults 10 and 100000).
+	bounds := lmsr.DefaultLiquidityBounds
+	for name, v := range map[string]*decimal.Decimal{"NWS_LIQUIDITY_MIN": &bounds.Min, "NWS_LIQUIDITY_MAX": &bounds.Max} {
+		if spec := os.Getenv(name); spec != "" {
+			parsed, err := decimal.NewFromString(spec)
+			if err != nil {
+				slog.Error("invalid "+name, "value", spec)
+				os.Exit(1)
+			}
+			*v = parsed
+		}
+	}
+	if err := bounds.Validate(); err != nil {
+		slog.Error("invalid NWS liquidity bounds", "min", bounds.Min, "max", bounds.Max, "error", err)
+		os.Exit(1)
+	}
+	tradeOpts = append(tradeOpts, trade.WithForecastLiquidityBounds(bounds))
+
+	// AUTO_LIQUIDITY_HIGH_VOLUME enables volume-driven b on markets created
+	// with auto_liquidity: b rises by AUTO_LIQUIDITY_STEP (default 0.1) per
+	// trade while volume in AUTO_LIQUIDITY_WINDOW (default 1h) is at least
+	// this, and falls while it is below AUTO_LIQUIDITY_LOW_VOLUME (default
+	// 0, never), within AUTO_LIQUIDITY_MIN_B..AUTO_LIQUIDITY_MAX_B (default
+	// the NWS liquidity bounds).
+	if spec := os.Getenv("AUTO_LIQUIDITY_HIGH_VOLUME"); spec != "" {
+		policy := trade.AutoLiquidityPolicy{
+			Window: time.Hour,
+			Step:   decimal.NewFromFloat(0.1),
+			MinB:   bounds.Min,
+			MaxB:   bounds.Max,
+		}
+		var err error
+		if policy.HighVolume, err = decimal.NewFromString(spec); err != nil {
+			slog.Error("invalid AUTO_LIQUIDITY_HIGH_VOLUME", "value", spec)
+			os.Exit(1)
+		}
+		if spec := os.Getenv("AUTO_LIQUIDITY_WINDOW"); spec != "" {
+			if policy.Window, err = time.ParseDuration(spec); err != nil {
+				slog.Error("invalid AUTO_LIQUIDITY_WINDOW", "value", spec)
+				os.Exit(1)
+			}
+		}
+		for name, v := range map[string]*decimal.Decimal{
+			"AUTO_LIQUIDITY_LOW_VOLUME": &policy.LowVolume,
+			"AUTO_LIQUIDITY_STEP":       &policy.Step,
+			"AUTO_LIQUIDITY_MIN_B":      &policy.MinB,
+			"AUTO_LIQUIDITY_MAX_B":      &policy.MaxB,
+		} {
+			if spec := os.Getenv(name); spec != "" {
+				if *v, err = decimal.NewFromString(spec); err != nil {
+					slog.Error("invalid "+name, "value", spec)
+					os.Exit(1)
+				}
+			}
+		}
+		if err := policy.Validate(); err != nil {
+			slog.Error("invalid auto liquidity policy", "error", err)
+			os.Exit(1)
+		}
+		tradeOpts = append(tradeOpts, trade.WithAutoLiquidity(policy))
+		slog.Info("auto liquidity enabled",
+			"high_volume", policy.HighVolume.String(), "low_volume", policy.LowVolume.String(),
+			"window", policy.Window, "step", policy.Step.String(),
+			"min_b", policy.MinB.String(), "max_b", policy.MaxB.String())
+	}
+
+	// MAX_OPEN_MARKETS_PER_CREATOR caps the open markets one created_by
+	// user may have; unset or 0 = unlimited.
+	if spec := os.Getenv("MAX_OPEN_MARKETS_PER_CREATOR"); spec != "" {
+		n, err := strconv.Atoi(spec)
+		if err != nil || n < 0 {
+			slog.Error("invalid MAX_OPEN_MARKETS_PER_CREATOR", "value", spec)
+			os.Exit(1)
+		}
+		tradeOpts = append(tradeOpts, trade.WithMaxOpenMarketsPerCreator(n))
+		if n > 0 {
+			slog.Info("open market limit per creator enabled", "max_open_markets", n)
+		}
+	}
+
+	// COST_BASIS_METHOD=fifo books realized P&L against the oldest lots
+	// rather than the average cost.
+	switch v := os.Getenv("COST_BASIS_METHOD"); v {
+	case "", "average":
+	case "fifo":
+		tradeOpts = append(tradeOpts, trade.WithCostBasisMethod(trade.CostBasisFIFO))
+	default:
+		slog.Error("invalid COST_BASIS_METHOD", "value", v)
+		os.Exit(1)
+	}
+	// ERROR_VERBOSITY=development returns the underlying error with 5xx
+	// responses; anything else keeps it in the server log only.
+	switch v := os.Getenv("ERROR_VERBOSITY"); v {
+	case "", "production":
+	case "development":
+		tradeOpts = append(tradeOpts, trade.WithErrorVerbosity(trade.ErrorsDevelopment))
+		slog.Warn("internal error detail is returned to clients")
+	default:
+		slog.Error("invalid ERROR_VERBOSITY", "value", v)
+		os.Exit(1)
+	}
+	// Admin actions are recorded in the audit_log table, attributed to the
+	// actor whose admin token made the request; AUDIT_LOG=false turns this
+	// off.
+	if os.Getenv("AUDIT_LOG") != "false" {
+		tradeOpts = append(tradeOpts, trade.WithAuditLog())
+	}
+	tradeSvc := trade.NewService(st, limiter, wsHub, tradeOpts...)
+	wsHub.SetSnapshot(tradeSvc.WSSnapshot)
 
 	// --- HTTP router ---
 	r := chi.NewRouter()
@@ -87,15 +437,17 @@ func main() {
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Timeout(30 * time.Second))
 	r.Use(metrics.Middleware)
+	r.Use(trade.ReplicaReads)
+	// Every route but the history stream answers within requestTimeout.
+	requestTimeout := middleware.Timeout(30 * time.Second)
 
 	// CORS middleware for frontend cross-origin requests.
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusNoContent)
 				return
@@ -104,43 +456,141 @@ func main() {
 		})
 	})
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	r.With(requestTimeout).Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{"status":"ok","service":"market-engine"}`))
 	})
 
+	// Per-dependency status, latency and version for diagnostics.
+	build := version.Get()
+	r.With(requestTimeout).Get("/health/deps", depsHealthHandler(healthProbes, build))
+
 	// Prometheus metrics endpoint.
-	r.Handle("/metrics", metrics.Handler())
+	metrics.BuildInfo.WithLabelValues(build.Version, build.Commit, build.BuildTime).Set(1)
+	r.With(requestTimeout).Handle("/metrics", metrics.Handler())
+
+	// Operations endpoints, and the market routes that settle, hide,
+	// reprice or reconfigure markets or record settlement data, require
+	// "Authorization: Bearer <token>" with an admin token. ADMIN_TOKEN is
+	// a shared token, audited as "admin"; ADMIN_TOKENS gives each operator
+	// their own, e.g. ADMIN_TOKENS="alice=<token>,bob=<token>", audited
+	// under their name. They are disabled when neither is set. Each actor
+	// and token appears once, so every request is attributed to one actor.
+	adminTokens := make(map[string]string)
+	usedTokens := make(map[string]bool)
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		adminTokens[trade.DefaultAdminActor] = token
+		usedTokens[token] = true
+	}
+	if spec := os.Getenv("ADMIN_TOKENS"); spec != "" {
+		for _, pair := range strings.Split(spec, ",") {
+			actor, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			actor = strings.TrimSpace(actor)
+			if !ok || actor == "" || token == "" || adminTokens[actor] != "" || usedTokens[token] {
+				slog.Error("invalid ADMIN_TOKENS entry", "actor", actor)
+				os.Exit(1)
+			}
+			adminTokens[actor] = token
+			usedTokens[token] = true
+		}
+	}
+	requireAdmin := trade.RequireAdminTokens(adminTokens)
 
 	r.Route("/api/v1", func(r chi.Router) {
-		// WebSocket endpoint for real-time price updates.
-		r.Get("/ws", wsHub.HandleWS)
+		// History streams take as long as the history does to send and
+		// extend their own write deadline as they go, so they sit outside
+		// the request timeout.
+		r.Get("/markets/{marketID}/history/stream", tradeSvc.StreamMarketHistory)
 
-		// Market management.
-		r.Get("/markets", tradeSvc.ListMarkets)
-		r.Post("/markets", tradeSvc.CreateMarket)
-		r.Get("/markets/{marketID}", tradeSvc.GetMarket)
-		r.Get("/markets/{marketID}/price", tradeSvc.GetPrice)
-		r.Get("/markets/{marketID}/history", tradeSvc.GetMarketHistory)
+		r.Group(func(r chi.Router) {
+			r.Use(requestTimeout)
 
-		// Trade execution.
-		r.Post("/trade", tradeSvc.ExecuteTrade)
+			r.Get("/version", version.Handler(build))
 
-		// Portfolio queries.
-		r.Get("/portfolio/{userID}", tradeSvc.GetPortfolio)
+			// WebSocket endpoints for real-time price updates: every market,
+			// or only the one in the path.
+			r.Get("/ws", wsHub.HandleWS)
+			r.Get("/markets/{marketID}/ws", wsHub.HandleMarketWS)
+
+			// Market management.
+			r.Get("/cells", tradeSvc.ListCells)
+			r.Get("/cells/correlation", tradeSvc.GetCellCorrelation)
+			r.Get("/markets", tradeSvc.ListMarkets)
+			r.Post("/markets", tradeSvc.CreateMarket)
+			r.Get("/markets/search", tradeSvc.SearchMarkets)
+			r.Get("/markets/{marketID}", tradeSvc.GetMarket)
+			r.With(requireAdmin).Delete("/markets/{marketID}", tradeSvc.HideMarket)
+			r.Get("/markets/{marketID}/price", tradeSvc.GetPrice)
+			r.Get("/markets/{marketID}/liquidity-source", tradeSvc.GetLiquiditySource)
+			r.Get("/prices", tradeSvc.GetPrices)
+			r.Post("/prices", tradeSvc.GetPrices)
+			r.Get("/markets/{marketID}/history", tradeSvc.GetMarketHistory)
+			r.Get("/markets/{marketID}/depth", tradeSvc.GetDepth)
+			r.Get("/markets/{marketID}/quote", tradeSvc.GetQuote)
+			r.Post("/quote/roundtrip", tradeSvc.QuoteRoundTrip)
+			r.Get("/markets/{marketID}/implied", tradeSvc.GetImplied)
+			r.Get("/markets/{marketID}/open-interest", tradeSvc.GetOpenInterest)
+			r.Get("/markets/{marketID}/maker", tradeSvc.GetMakerReport)
+			r.Post("/markets/{marketID}/verify", tradeSvc.VerifyMarket)
+			r.Get("/markets/{marketID}/verify-chain", tradeSvc.VerifyChain)
+			r.Get("/markets/{marketID}/trading-hours", tradeSvc.GetTradingHours)
+			r.With(requireAdmin).Patch("/markets/{marketID}/trading-hours", tradeSvc.UpdateTradingHours)
+			r.Get("/markets/{marketID}/trade-size", tradeSvc.GetTradeSize)
+			r.With(requireAdmin).Patch("/markets/{marketID}/trade-size", tradeSvc.UpdateTradeSize)
+			r.With(requireAdmin).Post("/markets/{marketID}/reliquify", tradeSvc.Reliquify)
+			r.With(requireAdmin).Post("/markets/{marketID}/reprice", tradeSvc.Reprice)
+
+			// Products: one template materialized across many cells.
+			r.Post("/products", tradeSvc.CreateProduct)
+			r.Get("/products/{productID}", tradeSvc.GetProduct)
+
+			// Settlement.
+			r.Get("/markets/{marketID}/settle/preview", tradeSvc.PreviewSettlement)
+			r.With(requireAdmin).Post("/markets/{marketID}/settle", tradeSvc.SettleMarket)
+			r.With(requireAdmin).Post("/markets/{marketID}/unsettle", tradeSvc.UnsettleMarket)
+
+			// Trade execution.
+			r.Post("/trade", tradeSvc.ExecuteTrade)
+
+			// Portfolio queries.
+			r.Get("/portfolio/{userID}", tradeSvc.GetPortfolio)
+			r.Get("/portfolio/{userID}/markets/{marketID}", tradeSvc.GetPosition)
+			r.Post("/portfolio/{userID}/markets/{marketID}/close", tradeSvc.ClosePosition)
+			r.Post("/portfolios", tradeSvc.GetPortfolios)
+			r.Post("/margin/estimate", tradeSvc.EstimateMargin)
+			r.Get("/leaderboard", tradeSvc.GetLeaderboard)
+
+			// Settlement reference data.
+			r.With(requireAdmin).Post("/observations", tradeSvc.CreateObservation)
+			r.Get("/observations", tradeSvc.GetObservation)
+
+			// Operations.
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/snapshot", tradeSvc.Snapshot)
+				r.Post("/restore", tradeSvc.Restore)
+				r.Get("/exposure", tradeSvc.GetSystemExposure)
+				r.Post("/users/{userID}/anonymize", tradeSvc.AnonymizeUser)
+				r.Post("/users/{userID}/rebuild-positions", tradeSvc.RebuildPositions)
+				r.Get("/ws-stats", tradeSvc.GetWSStats)
+				r.Get("/audit", tradeSvc.GetAuditLog)
+			})
+		})
 	})
 
 	// --- Server ---
+	// ENABLE_PPROF=true serves net/http/pprof under /debug/pprof; off by
+	// default, since profiles expose internals and cost CPU.
 	srv := &http.Server{
 		Addr:         ":" + port,
-		Handler:      r,
+		Handler:      withProfiler(r, os.Getenv("ENABLE_PPROF") == "true"),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
 	go func() {
-		slog.Info("market-engine listening", "port", port)
+		slog.Info("market-engine listening", "port", port, "version", build.Version, "commit", build.Commit)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("server error", "err", err)
 			os.Exit(1)
@@ -152,12 +602,29 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	slog.Info("shutting down market-engine...")
-	if err := srv.Shutdown(ctx); err != nil {
-		slog.Error("shutdown error", "err", err)
-	}
+	shutdown(context.Background(), shutdownDeps{
+		StopServer: srv.Shutdown,
+		DrainHub:   wsHub.Drain,
+		FlushEvents: func(context.Context) error {
+			// Emit writes through, so closing (which waits for an
+			// in-flight Emit) is the flush.
+			if emitter == nil {
+				return nil
+			}
+			return emitter.Close()
+		},
+		CloseStore: func(ctx context.Context) error {
+			// Drain queued ledger entries before the database pool closes.
+			var err error
+			if ledgerQueue != nil {
+				err = ledgerQueue.Close(ctx)
+			}
+			for i := len(cleanup) - 1; i >= 0; i-- {
+				cleanup[i]()
+			}
+			return err
+		},
+	})
 	fmt.Println("market-engine stopped")
 }
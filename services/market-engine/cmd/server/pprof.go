@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// withProfiler serves the net/http/pprof handlers under /debug/pprof in
+// front of h when enabled, and returns h unchanged otherwise. The profiler
+// sits outside h's middleware, so profile names never become metrics path
+// labels and the request timeout doesn't cut a CPU profile short. A
+// profile still can't run longer than the server's WriteTimeout, so ask
+// for one with ?seconds= below it.
+func withProfiler(h http.Handler, enabled bool) http.Handler {
+	if !enabled {
+		return h
+	}
+	r := chi.NewRouter()
+	r.Mount("/debug", middleware.Profiler())
+	r.Mount("/", h)
+	return r
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/atmx/market-engine/internal/metrics"
+)
+
+func TestWithProfiler(t *testing.T) {
+	app := chi.NewRouter()
+	app.Use(metrics.Middleware)
+	app.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	get := func(h http.Handler, path string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+
+	off := withProfiler(app, false)
+	if code := get(off, "/debug/pprof/"); code != http.StatusNotFound {
+		t.Errorf("disabled: /debug/pprof/ = %d, want 404", code)
+	}
+
+	on := withProfiler(app, true)
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/goroutine?debug=1"} {
+		if code := get(on, path); code != http.StatusOK {
+			t.Errorf("enabled: %s = %d, want 200", path, code)
+		}
+	}
+	if code := get(on, "/health"); code != http.StatusOK {
+		t.Errorf("enabled: /health = %d, want 200", code)
+	}
+
+	// Only the disabled 404 went through the metrics middleware.
+	if n := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("GET", "/debug/pprof/heap", "200")); n != 0 {
+		t.Errorf("pprof request counted in metrics %v times, want 0", n)
+	}
+	if n := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("GET", "/health", "200")); n != 1 {
+		t.Errorf("/health counted %v times, want 1", n)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// shutdownStepTimeout bounds each step of the shutdown sequence, so one
+// stuck dependency can't hold up the ones after it indefinitely.
+const shutdownStepTimeout = 5 * time.Second
+
+// shutdownDeps are the steps of a graceful shutdown. A nil step is
+// skipped.
+type shutdownDeps struct {
+	// StopServer stops accepting requests and waits for in-flight ones,
+	// so nothing produces new trades, events or writes afterwards.
+	StopServer func(context.Context) error
+	// DrainHub sends queued WebSocket messages to clients.
+	DrainHub func(context.Context) error
+	// FlushEvents hands any pending events to the broker.
+	FlushEvents func(context.Context) error
+	// CloseStore flushes queued ledger writes and closes the database
+	// pool and cache connections.
+	CloseStore func(context.Context) error
+
+	// StepTimeout bounds each step; zero means shutdownStepTimeout.
+	StepTimeout time.Duration
+}
+
+// shutdown runs the shutdown steps in order: stop the server, drain the
+// WebSocket hub, flush the event emitter, close the store. Each step gets
+// its own timeout derived from ctx. A failing step is logged and the
+// sequence carries on, since skipping the rest would only lose more.
+func shutdown(ctx context.Context, deps shutdownDeps) {
+	timeout := deps.StepTimeout
+	if timeout <= 0 {
+		timeout = shutdownStepTimeout
+	}
+	steps := []struct {
+		name string
+		fn   func(context.Context) error
+	}{
+		{"server", deps.StopServer},
+		{"ws_hub", deps.DrainHub},
+		{"events", deps.FlushEvents},
+		{"store", deps.CloseStore},
+	}
+	for _, step := range steps {
+		if step.fn == nil {
+			continue
+		}
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := step.fn(stepCtx)
+		cancel()
+		if err != nil {
+			slog.Error("shutdown step failed", "step", step.name, "err", err)
+		}
+	}
+}
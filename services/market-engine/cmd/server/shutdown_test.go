@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdown_RunsStepsInOrder(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	var calls []string
+	step := func(name string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("%s: context has no deadline", name)
+			}
+			calls = append(calls, name)
+			return err
+		}
+	}
+	shutdown(context.Background(), shutdownDeps{
+		StopServer:  step("server", nil),
+		DrainHub:    step("ws_hub", errors.New("hub stuck")),
+		FlushEvents: step("events", nil),
+		CloseStore:  step("store", nil),
+	})
+
+	if want := []string{"server", "ws_hub", "events", "store"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("steps ran as %v, want %v", calls, want)
+	}
+	if out := buf.String(); !strings.Contains(out, `"step":"ws_hub"`) || !strings.Contains(out, "hub stuck") {
+		t.Errorf("failed step not logged: %s", out)
+	}
+}
+
+func TestShutdown_BoundsEachStep(t *testing.T) {
+	var storeErr error
+	shutdown(context.Background(), shutdownDeps{
+		DrainHub: func(ctx context.Context) error {
+			<-ctx.Done() // a hub that never drains
+			return ctx.Err()
+		},
+		CloseStore: func(ctx context.Context) error {
+			storeErr = ctx.Err()
+			return nil
+		},
+		StepTimeout: 20 * time.Millisecond,
+	})
+	if storeErr != nil {
+		t.Errorf("store step started with an expired context: %v", storeErr)
+	}
+}
@@ -2,11 +2,15 @@
 set -e
 
 # Run database migrations before starting the server.
-# Uses psql to execute the SQL migration file against the DATABASE_URL.
-if [ -n "$DATABASE_URL" ] && [ -f /migrations/001_initial.sql ]; then
+# Applies every /migrations/*.sql file in lexical order; each migration is
+# idempotent (IF NOT EXISTS), so re-running on restart is safe. With
+# RUN_MIGRATIONS=true the server applies its embedded copy instead.
+if [ -n "$DATABASE_URL" ] && [ "$RUN_MIGRATIONS" != "true" ] && [ -f /migrations/001_initial.sql ]; then
   echo "Running market-engine database migrations..."
-  # Convert Go-style postgres:// URL to psql-compatible format.
-  psql "$DATABASE_URL" -f /migrations/001_initial.sql
+  for f in /migrations/*.sql; do
+    echo "  applying $(basename "$f")"
+    psql "$DATABASE_URL" -v ON_ERROR_STOP=1 -q -f "$f"
+  done
   echo "Migrations complete."
 fi
 
@@ -0,0 +1,191 @@
+// Package backtest replays a market's immutable ledger through the LMSR to
+// audit it: every recorded cost and fill price is recomputed from the state
+// implied by the preceding entries, and any divergence is flagged.
+//
+// This is a correctness tool, not a simulator for new strategies — it
+// trusts nothing but the ordered (side, quantity) sequence and b.
+package backtest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// DefaultTolerance is the maximum absolute difference between a recorded
+// and recomputed value before it is flagged. Costs are rounded to
+// lmsr.PriceScale (8dp) so honest entries replay exactly; the slack only
+// absorbs rounding if PriceScale changes between recording and replay.
+var DefaultTolerance = decimal.New(1, -6)
+
+// Divergence describes a ledger entry whose recorded values do not match
+// the LMSR replay.
+type Divergence struct {
+	Index         int             `json:"index"`
+	EntryID       string          `json:"entry_id"`
+	Field         string          `json:"field"` // "cost", "price" or "side"
+	Recorded      decimal.Decimal `json:"recorded"`
+	Expected      decimal.Decimal `json:"expected"`
+	RecordedValue string          `json:"recorded_value,omitempty"` // non-numeric fields
+}
+
+// Report summarizes a ledger replay.
+type Report struct {
+	Entries     int             `json:"entries"`      // trades replayed
+	TotalVolume decimal.Decimal `json:"total_volume"` // Σ|quantity|
+	MaxPriceYes decimal.Decimal `json:"max_price_yes"`
+	MinPriceYes decimal.Decimal `json:"min_price_yes"`
+	FinalQYes   decimal.Decimal `json:"final_q_yes"`
+	FinalQNo    decimal.Decimal `json:"final_q_no"`
+	FinalPrice  decimal.Decimal `json:"final_price_yes"`
+
+	// MakerRevenue is Σcost: cash the market maker has collected.
+	MakerRevenue decimal.Decimal `json:"maker_revenue"`
+	// MakerPnLIfYes / MakerPnLIfNo are the maker's final P&L if the market
+	// resolves that way: revenue minus the winning shares it must pay out.
+	MakerPnLIfYes decimal.Decimal `json:"maker_pnl_if_yes"`
+	MakerPnLIfNo  decimal.Decimal `json:"maker_pnl_if_no"`
+
+	Divergences []Divergence `json:"divergences"`
+	// Violations lists LMSR invariants (lmsr.Check*) that fail at the final
+	// replayed state when fed the ledger's recorded costs.
+	Violations []string `json:"invariant_violations"`
+}
+
+// OK reports whether the replay found no divergences or violations.
+func (r *Report) OK() bool { return len(r.Divergences) == 0 && len(r.Violations) == 0 }
+
+// Run replays entries (in ledger order) against a fresh LMSR market with
+// liquidity b, comparing each recorded cost and fill price with the
+// recomputed value within tol.
+func Run(entries []model.LedgerEntry, b, tol decimal.Decimal) (*Report, error) {
+	return RunSchedule(entries, func(time.Time) decimal.Decimal { return b }, tol)
+}
+
+// RunSchedule is Run for markets whose liquidity varies over time: each
+// entry is priced with bAt(entry.Timestamp). Path independence and bounded
+// loss only hold for a fixed cost function, so those invariants are
+// checked only when every entry saw the same b.
+func RunSchedule(entries []model.LedgerEntry, bAt func(time.Time) decimal.Decimal, tol decimal.Decimal) (*Report, error) {
+	return RunScheduleFrom(entries, decimal.Zero, decimal.Zero, bAt, tol)
+}
+
+// RunScheduleFrom is RunSchedule for a market seeded at (qYes, qNo) to
+// open at a price other than 0.5. The seed is no trader's, so maker P&L
+// counts only the shares traded on top of it. Path independence is stated
+// from (0, 0), so it is checked only for an unseeded market; bounded loss
+// is measured from the seed.
+func RunScheduleFrom(entries []model.LedgerEntry, qYes, qNo decimal.Decimal, bAt func(time.Time) decimal.Decimal, tol decimal.Decimal) (*Report, error) {
+	if tol.IsNegative() {
+		return nil, errors.New("backtest: tolerance must be non-negative")
+	}
+	b := bAt(time.Time{})
+	for _, e := range entries {
+		if e.IsTrade() {
+			b = bAt(e.Timestamp)
+			break
+		}
+	}
+	mm, err := lmsr.NewMarketMaker(b)
+	if err != nil {
+		return nil, err
+	}
+	fixedB := true
+	seedYes, seedNo := qYes, qNo
+	seeded := !qYes.IsZero() || !qNo.IsZero()
+
+	start := mm.Price(qYes, qNo)
+	rep := &Report{
+		TotalVolume:  decimal.Zero,
+		MaxPriceYes:  start,
+		MinPriceYes:  start,
+		MakerRevenue: decimal.Zero,
+		Divergences:  []Divergence{},
+		Violations:   []string{},
+	}
+	recorded := decimal.Zero // Σ recorded cost, as opposed to recomputed
+
+	for i, e := range entries {
+		// Settlement entries pay out at 0 or 1 outside the LMSR.
+		if !e.IsTrade() {
+			continue
+		}
+		rep.Entries++
+
+		if eb := bAt(e.Timestamp); !eb.Equal(mm.B()) {
+			if mm, err = lmsr.NewMarketMaker(eb); err != nil {
+				return nil, err
+			}
+			fixedB = false
+		}
+
+		var cost, fill decimal.Decimal
+		switch e.Side {
+		case "YES":
+			cost = mm.TradeCost(qYes, qNo, e.Quantity)
+			fill = mm.FillPrice(qYes, qNo, e.Quantity)
+			qYes = qYes.Add(e.Quantity)
+		case "NO":
+			cost = mm.TradeCostNo(qYes, qNo, e.Quantity)
+			fill = mm.FillPrice(qNo, qYes, e.Quantity)
+			qNo = qNo.Add(e.Quantity)
+		default:
+			rep.Divergences = append(rep.Divergences, Divergence{
+				Index: i, EntryID: e.ID, Field: "side", RecordedValue: e.Side,
+			})
+			continue
+		}
+
+		if e.Cost.Sub(cost).Abs().GreaterThan(tol) {
+			rep.Divergences = append(rep.Divergences, Divergence{
+				Index: i, EntryID: e.ID, Field: "cost", Recorded: e.Cost, Expected: cost,
+			})
+		}
+		if e.Price.Sub(fill).Abs().GreaterThan(tol) {
+			rep.Divergences = append(rep.Divergences, Divergence{
+				Index: i, EntryID: e.ID, Field: "price", Recorded: e.Price, Expected: fill,
+			})
+		}
+
+		rep.TotalVolume = rep.TotalVolume.Add(e.Quantity.Abs())
+		rep.MakerRevenue = rep.MakerRevenue.Add(cost)
+		recorded = recorded.Add(e.Cost)
+
+		p := mm.Price(qYes, qNo)
+		if p.GreaterThan(rep.MaxPriceYes) {
+			rep.MaxPriceYes = p
+		}
+		if p.LessThan(rep.MinPriceYes) {
+			rep.MinPriceYes = p
+		}
+	}
+
+	rep.FinalQYes = qYes
+	rep.FinalQNo = qNo
+	rep.FinalPrice = mm.Price(qYes, qNo)
+	rep.MakerPnLIfYes = rep.MakerRevenue.Sub(qYes.Sub(seedYes))
+	rep.MakerPnLIfNo = rep.MakerRevenue.Sub(qNo.Sub(seedNo))
+
+	// Per-entry tolerance accumulates across the ledger for sums.
+	sumTol := tol.Mul(decimal.NewFromInt(int64(len(entries) + 1)))
+	checks := []error{
+		lmsr.CheckSumToOne(mm, qYes, qNo, tol),
+		lmsr.CheckConvexity(mm, qYes, qNo, mm.B().Div(decimal.NewFromInt(10)), tol),
+	}
+	if fixedB && !seeded {
+		checks = append(checks, lmsr.CheckPathIndependence(mm, qYes, qNo, recorded, sumTol))
+	}
+	if fixedB {
+		checks = append(checks, lmsr.CheckBoundedLoss(mm, seedYes, seedNo, qYes, qNo, recorded, sumTol))
+	}
+	for _, err := range checks {
+		if err != nil {
+			rep.Violations = append(rep.Violations, err.Error())
+		}
+	}
+	return rep, nil
+}
@@ -0,0 +1,153 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+func d(v float64) decimal.Decimal { return decimal.NewFromFloat(v) }
+
+// honestLedger builds a ledger by pricing each trade exactly as the trade
+// service does.
+func honestLedger(t *testing.T, b decimal.Decimal) []model.LedgerEntry {
+	t.Helper()
+	return honestLedgerFrom(t, b, decimal.Zero, decimal.Zero)
+}
+
+// honestLedgerFrom is honestLedger for a market seeded at (qYes, qNo).
+func honestLedgerFrom(t *testing.T, b, qYes, qNo decimal.Decimal) []model.LedgerEntry {
+	t.Helper()
+	mm, err := lmsr.NewMarketMaker(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []model.LedgerEntry
+	for i, tr := range []struct {
+		side string
+		qty  float64
+	}{{"YES", 50}, {"NO", 20}, {"YES", -10}, {"NO", 80}} {
+		q := d(tr.qty)
+		e := model.LedgerEntry{ID: string(rune('a' + i)), Side: tr.side, Quantity: q}
+		if tr.side == "YES" {
+			e.Cost = mm.TradeCost(qYes, qNo, q)
+			e.Price = mm.FillPrice(qYes, qNo, q)
+			qYes = qYes.Add(q)
+		} else {
+			e.Cost = mm.TradeCostNo(qYes, qNo, q)
+			e.Price = mm.FillPrice(qNo, qYes, q)
+			qNo = qNo.Add(q)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestRun_HonestLedger(t *testing.T) {
+	entries := honestLedger(t, d(100))
+
+	rep, err := Run(entries, d(100), DefaultTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Fatalf("expected no divergences, got %+v", rep.Divergences)
+	}
+	if !rep.FinalQYes.Equal(d(40)) || !rep.FinalQNo.Equal(d(100)) {
+		t.Errorf("expected final q=(40,100), got (%s,%s)", rep.FinalQYes, rep.FinalQNo)
+	}
+	if !rep.TotalVolume.Equal(d(160)) {
+		t.Errorf("expected volume 160, got %s", rep.TotalVolume)
+	}
+	if !rep.MaxPriceYes.GreaterThan(d(0.5)) || !rep.MinPriceYes.LessThan(d(0.5)) {
+		t.Errorf("price range [%s,%s] should straddle 0.5", rep.MinPriceYes, rep.MaxPriceYes)
+	}
+
+	// Maker P&L is bounded below by -b·ln2 whichever way the market resolves.
+	floor := d(100).Mul(d(0.69314719)).Neg()
+	for _, pnl := range []decimal.Decimal{rep.MakerPnLIfYes, rep.MakerPnLIfNo} {
+		if pnl.LessThan(floor) {
+			t.Errorf("maker P&L %s below LMSR bound %s", pnl, floor)
+		}
+	}
+}
+
+func TestRun_FlagsTamperedCost(t *testing.T) {
+	entries := honestLedger(t, d(100))
+	entries[2].Cost = entries[2].Cost.Add(d(0.01))
+
+	rep, err := Run(entries, d(100), DefaultTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Divergences) != 1 {
+		t.Fatalf("expected exactly 1 divergence, got %+v", rep.Divergences)
+	}
+	div := rep.Divergences[0]
+	if div.Index != 2 || div.EntryID != "c" || div.Field != "cost" {
+		t.Errorf("unexpected divergence %+v", div)
+	}
+	if !div.Expected.Equal(entries[2].Cost.Sub(d(0.01))) {
+		t.Errorf("expected recomputed cost %s, got %s", entries[2].Cost.Sub(d(0.01)), div.Expected)
+	}
+}
+
+func TestRun_FlagsUnknownSide(t *testing.T) {
+	entries := honestLedger(t, d(100))
+	entries[0].Side = "MAYBE"
+
+	rep, _ := Run(entries, d(100), DefaultTolerance)
+	if rep.OK() || rep.Divergences[0].Field != "side" {
+		t.Errorf("expected side divergence first, got %+v", rep.Divergences)
+	}
+}
+
+func TestRun_InvalidLiquidity(t *testing.T) {
+	if _, err := Run(nil, decimal.Zero, DefaultTolerance); err == nil {
+		t.Error("expected error for b=0")
+	}
+}
+
+func TestRun_TamperedCostBreaksPathIndependence(t *testing.T) {
+	entries := honestLedger(t, d(100))
+	entries[0].Cost = entries[0].Cost.Sub(d(1))
+
+	rep, _ := Run(entries, d(100), DefaultTolerance)
+	if len(rep.Violations) == 0 {
+		t.Fatal("expected an invariant violation for undercharged ledger")
+	}
+	if rep.OK() {
+		t.Error("report with violations must not be OK")
+	}
+}
+
+func TestRunScheduleFrom_SeededMarket(t *testing.T) {
+	b := d(100)
+	seed := d(84.72978603) // b·ln(0.7/0.3): opens at YES 0.7
+	entries := honestLedgerFrom(t, b, seed, decimal.Zero)
+	fixed := func(time.Time) decimal.Decimal { return b }
+
+	rep, err := RunScheduleFrom(entries, seed, decimal.Zero, fixed, DefaultTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Fatalf("expected a clean replay from the seed, got %+v %v", rep.Divergences, rep.Violations)
+	}
+	if !rep.FinalQYes.Equal(seed.Add(d(40))) || !rep.FinalQNo.Equal(d(100)) {
+		t.Errorf("expected final q=(seed+40,100), got (%s,%s)", rep.FinalQYes, rep.FinalQNo)
+	}
+	// The maker redeems only traded shares, not the seed.
+	if want := rep.MakerRevenue.Sub(d(40)); !rep.MakerPnLIfYes.Equal(want) {
+		t.Errorf("maker P&L if YES = %s, want %s", rep.MakerPnLIfYes, want)
+	}
+
+	// Replayed from (0, 0), the same ledger diverges.
+	if rep, _ := Run(entries, b, DefaultTolerance); len(rep.Divergences) == 0 {
+		t.Error("expected divergences replaying a seeded ledger from (0, 0)")
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
 )
 
 // Supported contract types.
@@ -26,29 +29,62 @@ var validTypes = map[string]bool{
 	TypeSnow:   true,
 }
 
+// validUnits lists the threshold units each contract type accepts.
+// WIND includes MS (m/s), which the settlement oracle's contracts use.
+var validUnits = map[string][]string{
+	TypePrecip: {"MM", "IN"},
+	TypeTemp:   {"F", "C"},
+	TypeWind:   {"MPH", "KPH", "MS"},
+	TypeSnow:   {"MM", "IN", "CM"},
+}
+
 // tickerRegex matches: ATMX-{h3CellID}-{type}-{threshold}-{YYYYMMDD}
 // Example: ATMX-872a1070b-PRECIP-25MM-20250815
 var tickerRegex = regexp.MustCompile(
-	`^ATMX-([0-9a-f]+)-([A-Z]+)-([0-9]+[A-Z]*)-(\d{8})$`,
+	`^ATMX-([0-9a-f]+)-([A-Z]+)-(([0-9]+)([A-Z]*))-(\d{8})$`,
 )
 
 var (
-	ErrInvalidTicker = errors.New("contract: invalid ticker format")
-	ErrInvalidType   = errors.New("contract: unsupported contract type")
+	ErrInvalidTicker    = errors.New("contract: invalid ticker format")
+	ErrInvalidType      = errors.New("contract: unsupported contract type")
+	ErrInvalidThreshold = errors.New("contract: threshold unit not valid for contract type")
 )
 
 // Contract represents a parsed weather derivative contract.
 type Contract struct {
-	Ticker     string    `json:"ticker"`
-	H3CellID   string    `json:"h3_cell_id"`
-	Type       string    `json:"type"`
-	Threshold  string    `json:"threshold"`
-	ExpiryDate time.Time `json:"expiry_date"`
+	Ticker         string          `json:"ticker"`
+	H3CellID       string          `json:"h3_cell_id"`
+	Type           string          `json:"type"`
+	Threshold      string          `json:"threshold"`       // e.g. "25MM"
+	ThresholdValue decimal.Decimal `json:"threshold_value"` // e.g. 25
+	Unit           string          `json:"unit"`            // e.g. "MM"
+	ExpiryDate     time.Time       `json:"expiry_date"`
+}
+
+// NormalizeTicker trims surrounding whitespace and upper-cases every
+// segment except the H3 cell, so " atmx-872a1070b-precip-25mm-20250815 "
+// becomes "ATMX-872a1070b-PRECIP-25MM-20250815". The H3 cell must already
+// be lowercase hex: it is left untouched, so ParseTicker still rejects
+// upper-case or non-hex cells. Input without five segments is only
+// trimmed.
+func NormalizeTicker(ticker string) string {
+	t := strings.TrimSpace(ticker)
+	parts := strings.Split(t, "-")
+	if len(parts) != 5 {
+		return t
+	}
+	for _, i := range []int{0, 2, 3} { // prefix, type, threshold
+		parts[i] = strings.ToUpper(parts[i])
+	}
+	return strings.Join(parts, "-")
 }
 
-// ParseTicker parses and validates a contract ticker string.
+// ParseTicker normalizes (see NormalizeTicker), parses and validates a
+// contract ticker string. The returned Contract's Ticker is the canonical
+// form.
 // Format: ATMX-{h3CellID}-{type}-{threshold}-{YYYYMMDD}
 func ParseTicker(ticker string) (*Contract, error) {
+	ticker = NormalizeTicker(ticker)
 	matches := tickerRegex.FindStringSubmatch(ticker)
 	if matches == nil {
 		return nil, fmt.Errorf("%w: %s (expected ATMX-{h3cell}-{type}-{threshold}-{YYYYMMDD})",
@@ -58,26 +94,51 @@ func ParseTicker(ticker string) (*Contract, error) {
 	h3Cell := matches[1]
 	contractType := matches[2]
 	threshold := matches[3]
-	dateStr := matches[4]
+	value, _ := decimal.NewFromString(matches[4])
+	unit := matches[5]
+	dateStr := matches[6]
 
 	if !validTypes[contractType] {
 		return nil, fmt.Errorf("%w: %s", ErrInvalidType, contractType)
 	}
 
+	if !ValidUnit(contractType, unit) {
+		return nil, fmt.Errorf("%w: %s %s (expected one of %v)",
+			ErrInvalidThreshold, contractType, threshold, validUnits[contractType])
+	}
+
 	expiry, err := time.Parse("20060102", dateStr)
 	if err != nil {
 		return nil, fmt.Errorf("%w: invalid date %s", ErrInvalidTicker, dateStr)
 	}
 
 	return &Contract{
-		Ticker:     ticker,
-		H3CellID:   h3Cell,
-		Type:       contractType,
-		Threshold:  threshold,
-		ExpiryDate: expiry,
+		Ticker:         ticker,
+		H3CellID:       h3Cell,
+		Type:           contractType,
+		Threshold:      threshold,
+		ThresholdValue: value,
+		Unit:           unit,
+		ExpiryDate:     expiry,
 	}, nil
 }
 
+// ValidType reports whether contractType is a supported contract type.
+func ValidType(contractType string) bool {
+	return validTypes[contractType]
+}
+
+// ValidUnit reports whether unit is an accepted threshold unit for the
+// given contract type.
+func ValidUnit(contractType, unit string) bool {
+	for _, u := range validUnits[contractType] {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
 // NWSForecastData holds machine-readable NWS probabilistic forecast data.
 // These values are published by the NWS NDFD (National Digital Forecast
 // Database) in GRIB2 format and via the weather.gov API.
@@ -92,38 +153,44 @@ type NWSForecastData struct {
 
 // DeriveLiquidity computes the LMSR b parameter from NWS forecast data.
 // Uses the interquartile range (IQR = P75 - P25) relative to the median
-// as a measure of forecast uncertainty, scaled by baseVolume.
+// as a measure of forecast uncertainty, scaled by baseVolume and clamped
+// into bounds (lmsr.DefaultLiquidityBounds unless configured otherwise).
 //
 // Data sources (all machine-readable, no LLM needed):
 //   - NDFD GRIB2 files via NOAA NOMADS
 //   - weather.gov API /gridpoints/{office}/{x},{y}
 //   - HREF ensemble products
 //   - Probabilistic QPF exceedance probabilities
-func DeriveLiquidity(nws NWSForecastData, baseVolume decimal.Decimal) (decimal.Decimal, error) {
+func DeriveLiquidity(nws NWSForecastData, baseVolume decimal.Decimal, bounds lmsr.LiquidityBounds) (decimal.Decimal, error) {
+	return deriveLiquidity(nws, baseVolume, bounds, decimal.NewFromInt(1))
+}
+
+// DeriveLiquidityAt is DeriveLiquidity for a contract expiring at expiry
+// (usually its ExpiryDate), as of now. The forecast-derived b is scaled by
+// lmsr.ExpiryFactor before clamping, so the same forecast gives a
+// contract near expiry less liquidity than one further out.
+func DeriveLiquidityAt(nws NWSForecastData, baseVolume decimal.Decimal, bounds lmsr.LiquidityBounds, expiry, now time.Time) (decimal.Decimal, error) {
+	return deriveLiquidity(nws, baseVolume, bounds, lmsr.ExpiryFactor(expiry, now))
+}
+
+func deriveLiquidity(nws NWSForecastData, baseVolume decimal.Decimal, bounds lmsr.LiquidityBounds, factor decimal.Decimal) (decimal.Decimal, error) {
+	if err := bounds.Validate(); err != nil {
+		return decimal.Zero, err
+	}
 	iqr := nws.Percentile75.Sub(nws.Percentile25)
 	median := nws.Percentile50
 
 	if median.LessThanOrEqual(decimal.Zero) {
 		// For dry conditions (median = 0), use absolute IQR.
 		if iqr.LessThanOrEqual(decimal.Zero) {
-			return decimal.NewFromInt(10), nil // minimum b
-		}
-		b := baseVolume.Mul(iqr)
-		minB := decimal.NewFromInt(10)
-		if b.LessThan(minB) {
-			return minB, nil
+			return bounds.Min, nil
 		}
-		return b.Round(2), nil
+		return bounds.Clamp(baseVolume.Mul(iqr).Mul(factor)).Round(2), nil
 	}
 
-	// Coefficient of variation: IQR / median.
+	// Coefficient of variation: IQR / median. The floor keeps a narrow
+	// forecast from producing a degenerate market, the ceiling a wide one
+	// from producing an absurdly deep one.
 	cv := iqr.Div(median)
-	b := baseVolume.Mul(cv)
-
-	// Enforce minimum b to prevent degenerate markets.
-	minB := decimal.NewFromInt(10)
-	if b.LessThan(minB) {
-		return minB, nil
-	}
-	return b.Round(2), nil
+	return bounds.Clamp(baseVolume.Mul(cv).Mul(factor)).Round(2), nil
 }
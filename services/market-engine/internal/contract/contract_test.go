@@ -1,10 +1,13 @@
 package contract
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
 )
 
 func d(f float64) decimal.Decimal {
@@ -58,12 +61,18 @@ func TestParseTicker_InvalidType(t *testing.T) {
 }
 
 func TestParseTicker_AllTypes(t *testing.T) {
-	types := []string{"PRECIP", "TEMP", "WIND", "SNOW"}
-	for _, typ := range types {
-		ticker := "ATMX-872a1070b-" + typ + "-25MM-20250815"
+	thresholds := map[string]string{
+		"PRECIP": "25MM",
+		"TEMP":   "35C",
+		"WIND":   "60MPH",
+		"SNOW":   "15IN",
+	}
+	for typ, threshold := range thresholds {
+		ticker := "ATMX-872a1070b-" + typ + "-" + threshold + "-20250815"
 		c, err := ParseTicker(ticker)
 		if err != nil {
 			t.Errorf("unexpected error for type %s: %v", typ, err)
+			continue
 		}
 		if c.Type != typ {
 			t.Errorf("expected type=%s, got %s", typ, c.Type)
@@ -71,6 +80,58 @@ func TestParseTicker_AllTypes(t *testing.T) {
 	}
 }
 
+func TestParseTicker_ValidUnits(t *testing.T) {
+	tests := []struct {
+		threshold string
+		typ       string
+		value     float64
+		unit      string
+	}{
+		{"25MM", "PRECIP", 25, "MM"},
+		{"2IN", "PRECIP", 2, "IN"},
+		{"95F", "TEMP", 95, "F"},
+		{"35C", "TEMP", 35, "C"},
+		{"60MPH", "WIND", 60, "MPH"},
+		{"100KPH", "WIND", 100, "KPH"},
+		{"15MS", "WIND", 15, "MS"},
+		{"300MM", "SNOW", 300, "MM"},
+		{"15IN", "SNOW", 15, "IN"},
+		{"30CM", "SNOW", 30, "CM"},
+	}
+	for _, tt := range tests {
+		ticker := "ATMX-872a1070b-" + tt.typ + "-" + tt.threshold + "-20250815"
+		c, err := ParseTicker(ticker)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", ticker, err)
+			continue
+		}
+		if c.Unit != tt.unit || !c.ThresholdValue.Equal(d(tt.value)) {
+			t.Errorf("%s: expected %v %s, got %s %s", ticker, tt.value, tt.unit, c.ThresholdValue, c.Unit)
+		}
+		if c.Threshold != tt.threshold {
+			t.Errorf("%s: expected threshold=%s, got %s", ticker, tt.threshold, c.Threshold)
+		}
+	}
+}
+
+func TestParseTicker_InvalidUnits(t *testing.T) {
+	tests := []string{
+		"ATMX-872a1070b-TEMP-25MM-20250815",   // precip unit on temp
+		"ATMX-872a1070b-PRECIP-35C-20250815",  // temp unit on precip
+		"ATMX-872a1070b-WIND-25MM-20250815",   // precip unit on wind
+		"ATMX-872a1070b-SNOW-60MPH-20250815",  // wind unit on snow
+		"ATMX-872a1070b-PRECIP-30CM-20250815", // CM is snow-only
+		"ATMX-872a1070b-TEMP-95-20250815",     // missing unit
+		"ATMX-872a1070b-WIND-60KNOTS-20250815",
+	}
+	for _, ticker := range tests {
+		_, err := ParseTicker(ticker)
+		if !errors.Is(err, ErrInvalidThreshold) {
+			t.Errorf("%s: expected ErrInvalidThreshold, got %v", ticker, err)
+		}
+	}
+}
+
 func TestDeriveLiquidity_WiderCIHigherB(t *testing.T) {
 	base := d(100)
 
@@ -85,11 +146,11 @@ func TestDeriveLiquidity_WiderCIHigherB(t *testing.T) {
 		Percentile75: d(30),
 	}
 
-	bWide, err := DeriveLiquidity(wide, base)
+	bWide, err := DeriveLiquidity(wide, base, lmsr.DefaultLiquidityBounds)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	bNarrow, err := DeriveLiquidity(narrow, base)
+	bNarrow, err := DeriveLiquidity(narrow, base, lmsr.DefaultLiquidityBounds)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -106,7 +167,7 @@ func TestDeriveLiquidity_ZeroMedian(t *testing.T) {
 		Percentile50: d(0),
 		Percentile75: d(5),
 	}
-	b, err := DeriveLiquidity(nws, d(100))
+	b, err := DeriveLiquidity(nws, d(100), lmsr.DefaultLiquidityBounds)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -122,7 +183,7 @@ func TestDeriveLiquidity_MinimumB(t *testing.T) {
 		Percentile50: d(25),
 		Percentile75: d(25.1),
 	}
-	b, err := DeriveLiquidity(nws, d(1))
+	b, err := DeriveLiquidity(nws, d(1), lmsr.DefaultLiquidityBounds)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -130,3 +191,101 @@ func TestDeriveLiquidity_MinimumB(t *testing.T) {
 		t.Errorf("b should be at least 10, got %s", b)
 	}
 }
+
+func TestDeriveLiquidity_Bounds(t *testing.T) {
+	bounds := lmsr.LiquidityBounds{Min: d(25), Max: d(500)}
+	tests := []struct {
+		name string
+		nws  NWSForecastData
+		want decimal.Decimal
+	}{
+		// IQR/median = 40/1 → b = 4000, above the ceiling.
+		{"wide CI hits ceiling", NWSForecastData{Percentile25: d(0.5), Percentile50: d(1), Percentile75: d(40.5)}, d(500)},
+		// IQR/median = 0.2/25 → b = 0.8, below the floor.
+		{"narrow CI hits floor", NWSForecastData{Percentile25: d(24.9), Percentile50: d(25), Percentile75: d(25.1)}, d(25)},
+		// IQR/median = 30/25 → b = 120, inside the bounds.
+		{"inside bounds", NWSForecastData{Percentile25: d(10), Percentile50: d(25), Percentile75: d(40)}, d(120)},
+		// Dry: median 0 uses the absolute IQR, 50 → b = 5000.
+		{"dry wide CI hits ceiling", NWSForecastData{Percentile75: d(50)}, d(500)},
+		{"dry no spread is the floor", NWSForecastData{}, d(25)},
+	}
+	for _, tt := range tests {
+		b, err := DeriveLiquidity(tt.nws, d(100), bounds)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !b.Equal(tt.want) {
+			t.Errorf("%s: b = %s, want %s", tt.name, b, tt.want)
+		}
+	}
+
+	if _, err := DeriveLiquidity(NWSForecastData{}, d(100), lmsr.LiquidityBounds{Min: d(50), Max: d(10)}); !errors.Is(err, lmsr.ErrInvalidBounds) {
+		t.Errorf("inverted bounds: expected ErrInvalidBounds, got %v", err)
+	}
+}
+
+func TestNormalizeTicker(t *testing.T) {
+	tests := map[string]string{
+		" atmx-872a1070b-precip-25MM-20250815 ": "ATMX-872a1070b-PRECIP-25MM-20250815",
+		"ATMX-872a1070b-temp-95f-20250815\n":    "ATMX-872a1070b-TEMP-95F-20250815",
+		"ATMX-872a1070b-PRECIP-25MM-20250815":   "ATMX-872a1070b-PRECIP-25MM-20250815",
+		"  not-a-ticker ":                       "not-a-ticker",
+	}
+	for in, want := range tests {
+		if got := NormalizeTicker(in); got != want {
+			t.Errorf("NormalizeTicker(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	c, err := ParseTicker(" atmx-872a1070b-precip-25MM-20250815 ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Ticker != "ATMX-872a1070b-PRECIP-25MM-20250815" || c.Type != TypePrecip {
+		t.Errorf("got ticker %q type %q", c.Ticker, c.Type)
+	}
+}
+
+func TestParseTicker_CellMustStayLowercaseHex(t *testing.T) {
+	for _, ticker := range []string{
+		"ATMX-872A1070B-PRECIP-25MM-20250815",
+		" atmx-872a 1070b-precip-25MM-20250815",
+		"atmx-872a1070b-precip-25MM-2025-08-15",
+	} {
+		if _, err := ParseTicker(ticker); err == nil {
+			t.Errorf("expected error for ticker %q", ticker)
+		}
+	}
+}
+
+func TestDeriveLiquidityAt_ExpiryProximity(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	// IQR/median = 30/25 → b = 120 with the full forecast uncertainty.
+	nws := NWSForecastData{Percentile25: d(10), Percentile50: d(25), Percentile75: d(40)}
+
+	month, err := DeriveLiquidityAt(nws, d(100), lmsr.DefaultLiquidityBounds, now.AddDate(0, 1, 0), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tomorrow, err := DeriveLiquidityAt(nws, d(100), lmsr.DefaultLiquidityBounds, now.AddDate(0, 0, 1), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !month.Equal(d(120)) {
+		t.Errorf("a month out: b = %s, want the undamped 120", month)
+	}
+	// A day out of a seven-day horizon: 120 × sqrt(1/7) = 120 × 0.378.
+	if !tomorrow.Equal(d(45.36)) {
+		t.Errorf("tomorrow: b = %s, want 45.36", tomorrow)
+	}
+
+	// Without a time the factor doesn't apply.
+	if b, _ := DeriveLiquidity(nws, d(100), lmsr.DefaultLiquidityBounds); !b.Equal(month) {
+		t.Errorf("DeriveLiquidity = %s, want %s", b, month)
+	}
+	// The scaled b is still clamped into the bounds.
+	bounds := lmsr.LiquidityBounds{Min: d(100), Max: d(500)}
+	if b, _ := DeriveLiquidityAt(nws, d(100), bounds, now.AddDate(0, 0, 1), now); !b.Equal(d(100)) {
+		t.Errorf("clamped: b = %s, want 100", b)
+	}
+}
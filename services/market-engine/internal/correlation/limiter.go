@@ -21,6 +21,11 @@ var (
 	// aggregate exposure across geographically correlated cells beyond the
 	// correlated maximum.
 	ErrCorrelatedLimitExceeded = errors.New("correlation: correlated exposure limit exceeded")
+
+	// ErrCorrelatedCellsExceeded is returned when a trade would open a
+	// position in a new cell while the correlated group already holds the
+	// maximum number of cells.
+	ErrCorrelatedCellsExceeded = errors.New("correlation: correlated cell count limit exceeded")
 )
 
 // PositionLimiter enforces position limits with correlation awareness.
@@ -44,9 +49,55 @@ type PositionLimiter struct {
 	// all cells that share the same H3 prefix (correlated group).
 	MaxCorrelated decimal.Decimal
 
+	// MaxCorrelatedCells is the maximum number of cells in one correlated
+	// group that may hold a nonzero position. It stops exposure being
+	// spread thinly across many cells to stay under MaxCorrelated.
+	// 0 means unlimited.
+	MaxCorrelatedCells int
+
 	// PrefixLen determines how many leading hex characters of the H3
 	// index must match for two cells to be considered correlated.
 	PrefixLen int
+
+	// Unit is what MaxPerCell and MaxCorrelated are measured in. The
+	// limiter compares whatever it is given; callers convert exposures to
+	// this unit first (see Notional). Empty means UnitShares.
+	Unit ExposureUnit
+}
+
+// ExposureUnit is the unit position limits are measured in.
+type ExposureUnit string
+
+const (
+	// UnitShares measures exposure as net shares, whatever they cost.
+	UnitShares ExposureUnit = "shares"
+	// UnitNotional measures exposure as shares × price, so a position
+	// near certain uses more of the limit than the same shares near 0.5.
+	UnitNotional ExposureUnit = "notional"
+)
+
+// ErrUnknownExposureUnit is returned by ParseExposureUnit.
+var ErrUnknownExposureUnit = errors.New("correlation: exposure unit must be shares or notional")
+
+// ParseExposureUnit parses "shares" or "notional"; empty is UnitShares.
+func ParseExposureUnit(s string) (ExposureUnit, error) {
+	switch u := ExposureUnit(s); u {
+	case "":
+		return UnitShares, nil
+	case UnitShares, UnitNotional:
+		return u, nil
+	}
+	return "", ErrUnknownExposureUnit
+}
+
+// Notional values a net directional exposure (+YES / -NO) at YES price
+// priceYes: net YES shares at priceYes each, net NO shares at
+// 1 - priceYes. The sign is kept, so notional exposures net like shares.
+func Notional(net, priceYes decimal.Decimal) decimal.Decimal {
+	if net.IsNegative() {
+		return net.Mul(decimal.NewFromInt(1).Sub(priceYes))
+	}
+	return net.Mul(priceYes)
 }
 
 // NewPositionLimiter creates a limiter with the given per-cell and
@@ -59,6 +110,7 @@ func NewPositionLimiter(maxPerCell, maxCorrelated decimal.Decimal, prefixLen int
 		MaxPerCell:    maxPerCell,
 		MaxCorrelated: maxCorrelated,
 		PrefixLen:     prefixLen,
+		Unit:          UnitShares,
 	}
 }
 
@@ -86,6 +138,10 @@ func (l *PositionLimiter) CheckLimit(
 	// 2. Correlated exposure: sum |exposure| across cells sharing prefix.
 	targetPrefix := cellPrefix(targetCell, l.PrefixLen)
 	totalCorrelated := newPosition.Abs()
+	heldCells := 0
+	if !newPosition.IsZero() {
+		heldCells = 1
+	}
 
 	for cellID, exposure := range existingExposures {
 		if cellID == targetCell {
@@ -93,6 +149,9 @@ func (l *PositionLimiter) CheckLimit(
 		}
 		if cellPrefix(cellID, l.PrefixLen) == targetPrefix {
 			totalCorrelated = totalCorrelated.Add(exposure.Abs())
+			if !exposure.IsZero() {
+				heldCells++
+			}
 		}
 	}
 
@@ -100,9 +159,45 @@ func (l *PositionLimiter) CheckLimit(
 		return ErrCorrelatedLimitExceeded
 	}
 
+	// 3. Correlated cell count: only opening a new cell can breach it, so
+	// trading within cells already held is never blocked.
+	opensCell := currentInCell.IsZero() && !newPosition.IsZero()
+	if l.MaxCorrelatedCells > 0 && opensCell && heldCells > l.MaxCorrelatedCells {
+		return ErrCorrelatedCellsExceeded
+	}
+
 	return nil
 }
 
+// CorrelatedExposure returns the aggregate absolute exposure across all
+// cells correlated with targetCell, targetCell included.
+func (l *PositionLimiter) CorrelatedExposure(targetCell string, exposures map[string]decimal.Decimal) decimal.Decimal {
+	targetPrefix := cellPrefix(targetCell, l.PrefixLen)
+	total := decimal.Zero
+	for cellID, exposure := range exposures {
+		if cellPrefix(cellID, l.PrefixLen) == targetPrefix {
+			total = total.Add(exposure.Abs())
+		}
+	}
+	return total
+}
+
+// Utilization returns the correlated exposure around targetCell as a
+// fraction of MaxCorrelated (1 = at the limit), or zero when there is no
+// positive limit.
+func (l *PositionLimiter) Utilization(targetCell string, exposures map[string]decimal.Decimal) decimal.Decimal {
+	if !l.MaxCorrelated.IsPositive() {
+		return decimal.Zero
+	}
+	return l.CorrelatedExposure(targetCell, exposures).DivRound(l.MaxCorrelated, 4)
+}
+
+// Group returns the key of the correlated group cellID belongs to: its
+// first PrefixLen characters.
+func (l *PositionLimiter) Group(cellID string) string {
+	return cellPrefix(cellID, l.PrefixLen)
+}
+
 // cellPrefix returns the first `length` characters of an H3 cell ID.
 func cellPrefix(cellID string, length int) string {
 	if length >= len(cellID) {
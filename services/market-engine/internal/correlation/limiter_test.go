@@ -122,3 +122,126 @@ func TestCheckLimit_NilExposures(t *testing.T) {
 		t.Errorf("nil exposures should be treated as empty, got %v", err)
 	}
 }
+
+func TestCheckLimit_CorrelatedCellCapReached(t *testing.T) {
+	// Value limits are generous; only the cell count can bind.
+	limiter := NewPositionLimiter(d(1000), d(100000), 5)
+	limiter.MaxCorrelatedCells = 3
+
+	existing := map[string]decimal.Decimal{
+		"872a1070b": d(10),
+		"872a1070c": d(-10), // NO exposure counts as a held cell too
+		"872a1070d": d(10),
+		"872a1070e": d(0),   // flat, not held
+		"882b2070a": d(10),  // NOT correlated
+	}
+
+	// A fourth correlated cell would exceed the cap of 3.
+	err := limiter.CheckLimit("872a1070f", d(1), existing)
+	if err != ErrCorrelatedCellsExceeded {
+		t.Errorf("expected ErrCorrelatedCellsExceeded, got %v", err)
+	}
+
+	// Reopening the flat cell is a new cell as well.
+	err = limiter.CheckLimit("872a1070e", d(1), existing)
+	if err != ErrCorrelatedCellsExceeded {
+		t.Errorf("expected ErrCorrelatedCellsExceeded for flat cell, got %v", err)
+	}
+
+	// An uncorrelated cell is in a different group.
+	err = limiter.CheckLimit("882b2070b", d(1), existing)
+	if err != nil {
+		t.Errorf("uncorrelated cell should not count, got %v", err)
+	}
+}
+
+func TestCheckLimit_CorrelatedCellCapAllowsHeldCells(t *testing.T) {
+	limiter := NewPositionLimiter(d(1000), d(100000), 5)
+	limiter.MaxCorrelatedCells = 2
+
+	existing := map[string]decimal.Decimal{
+		"872a1070b": d(10),
+		"872a1070c": d(10),
+	}
+
+	// Adding to or reducing a held cell is allowed at the cap.
+	if err := limiter.CheckLimit("872a1070b", d(50), existing); err != nil {
+		t.Errorf("adding to a held cell should pass, got %v", err)
+	}
+	if err := limiter.CheckLimit("872a1070c", d(-10), existing); err != nil {
+		t.Errorf("closing a held cell should pass, got %v", err)
+	}
+
+	// Below the cap a new cell is fine.
+	delete(existing, "872a1070c")
+	if err := limiter.CheckLimit("872a1070c", d(10), existing); err != nil {
+		t.Errorf("new cell under the cap should pass, got %v", err)
+	}
+}
+
+func TestCheckLimit_CorrelatedCellCapUnlimitedByDefault(t *testing.T) {
+	limiter := NewPositionLimiter(d(1000), d(100000), 5)
+
+	existing := make(map[string]decimal.Decimal)
+	for i := 0; i < 20; i++ {
+		existing["872a1070"+string(rune('a'+i))] = d(1)
+	}
+
+	if err := limiter.CheckLimit("872a1070z", d(1), existing); err != nil {
+		t.Errorf("MaxCorrelatedCells=0 should be unlimited, got %v", err)
+	}
+}
+
+func TestUtilization_CorrelatedGroupOnly(t *testing.T) {
+	limiter := NewPositionLimiter(d(1000), d(2000), 5)
+
+	existing := map[string]decimal.Decimal{
+		"872a1070b": d(800),
+		"872a1070c": d(-600), // NO exposure counts by magnitude
+		"882b2070a": d(900),  // NOT correlated
+	}
+
+	if got := limiter.Utilization("872a1070d", existing); !got.Equal(d(0.7)) {
+		t.Errorf("expected utilization 0.7, got %s", got)
+	}
+	if got := NewPositionLimiter(d(1000), d(0), 5).Utilization("872a1070b", existing); !got.IsZero() {
+		t.Errorf("expected zero utilization without a limit, got %s", got)
+	}
+}
+
+func TestNotional_ValuesEachSideAtItsPrice(t *testing.T) {
+	if got := Notional(d(100), d(0.8)); !got.Equal(d(80)) {
+		t.Errorf("100 YES at 0.8 = %s, want 80", got)
+	}
+	if got := Notional(d(-100), d(0.8)); !got.Equal(d(-20)) {
+		t.Errorf("100 NO at YES 0.8 = %s, want -20", got)
+	}
+}
+
+func TestCheckLimit_NotionalNearOneUsesMoreLimit(t *testing.T) {
+	limiter := NewPositionLimiter(d(100), d(5000), 5)
+	limiter.Unit = UnitNotional
+
+	// 150 YES shares: 75 notional at 0.5, 142.5 near 1.
+	if err := limiter.CheckLimit("872a1070b", Notional(d(150), d(0.5)), nil); err != nil {
+		t.Errorf("at 0.5: expected within limit, got %v", err)
+	}
+	if err := limiter.CheckLimit("872a1070b", Notional(d(150), d(0.95)), nil); err != ErrPerCellLimitExceeded {
+		t.Errorf("at 0.95: expected ErrPerCellLimitExceeded, got %v", err)
+	}
+	if got, want := limiter.Utilization("872a1070b", map[string]decimal.Decimal{"872a1070b": Notional(d(150), d(0.95))}),
+		limiter.Utilization("872a1070b", map[string]decimal.Decimal{"872a1070b": Notional(d(150), d(0.5))}); !got.GreaterThan(want) {
+		t.Errorf("utilization near 1 = %s, want more than %s at 0.5", got, want)
+	}
+}
+
+func TestParseExposureUnit(t *testing.T) {
+	for in, want := range map[string]ExposureUnit{"": UnitShares, "shares": UnitShares, "notional": UnitNotional} {
+		if got, err := ParseExposureUnit(in); err != nil || got != want {
+			t.Errorf("ParseExposureUnit(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseExposureUnit("dollars"); err != ErrUnknownExposureUnit {
+		t.Errorf("expected ErrUnknownExposureUnit, got %v", err)
+	}
+}
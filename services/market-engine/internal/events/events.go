@@ -0,0 +1,100 @@
+// Package events publishes domain events (trades, market creation and
+// settlement) to downstream consumers such as analytics and settlement
+// services, so they don't have to poll the ledger.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Event types.
+const (
+	TypeTradeExecuted = "trade.executed"
+	TypeMarketCreated = "market.created"
+	TypeMarketSettled = "market.settled"
+)
+
+// Event is the envelope every event is published in. Data is one of the
+// payload types below, matching Type.
+type Event struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// TradeExecuted is the payload of a trade.executed event: the ledger entry
+// written and the market prices after it.
+type TradeExecuted struct {
+	TradeID     string          `json:"trade_id"`
+	UserID      string          `json:"user_id"`
+	MarketID    string          `json:"market_id"`
+	ContractID  string          `json:"contract_id"`
+	H3CellID    string          `json:"h3_cell_id"`
+	Side        string          `json:"side"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	FillPrice   decimal.Decimal `json:"fill_price"`
+	Cost        decimal.Decimal `json:"cost"`
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	PriceYes    decimal.Decimal `json:"price_yes"`
+	PriceNo     decimal.Decimal `json:"price_no"`
+}
+
+// MarketCreated is the payload of a market.created event.
+type MarketCreated struct {
+	MarketID   string          `json:"market_id"`
+	ContractID string          `json:"contract_id"`
+	H3CellID   string          `json:"h3_cell_id"`
+	B          decimal.Decimal `json:"b"`
+	ProductID  *string         `json:"product_id,omitempty"`
+}
+
+// MarketSettled is the payload of a market.settled event.
+type MarketSettled struct {
+	MarketID    string          `json:"market_id"`
+	ContractID  string          `json:"contract_id"`
+	H3CellID    string          `json:"h3_cell_id"`
+	Outcome     string          `json:"outcome"`
+	SettledAt   time.Time       `json:"settled_at"`
+	Users       int             `json:"users"`        // holders paid out
+	TotalPayout decimal.Decimal `json:"total_payout"` // Σ payouts
+}
+
+// Emitter publishes events. Emit is called after the store write the
+// event describes has succeeded; implementations must be safe for
+// concurrent use.
+type Emitter interface {
+	Emit(ctx context.Context, e Event) error
+}
+
+// Nop discards every event. It is the default when no broker is
+// configured.
+type Nop struct{}
+
+// Emit implements Emitter.
+func (Nop) Emit(context.Context, Event) error { return nil }
+
+// Recorder keeps every event in memory, for tests and local development.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Emit implements Emitter.
+func (r *Recorder) Emit(_ context.Context, e Event) error {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+	return nil
+}
+
+// Events returns the recorded events, oldest first.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
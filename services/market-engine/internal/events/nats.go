@@ -0,0 +1,163 @@
+// NATS publisher.
+
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSubjectPrefix is prepended to the event type to form the NATS
+// subject, e.g. "atmx.trade.executed".
+const DefaultSubjectPrefix = "atmx"
+
+// natsTimeout bounds connecting and each publish.
+const natsTimeout = 5 * time.Second
+
+// NATSEmitter publishes events as JSON on <prefix>.<type> using the NATS
+// core text protocol. Events are replayable when the subjects are captured
+// by a JetStream stream (e.g. one on "atmx.>"); the emitter itself is
+// fire-and-forget. A broken connection is redialled on the next Emit.
+type NATSEmitter struct {
+	addr   string
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// DialNATS connects to the NATS server at addr ("host:port", optionally
+// with a nats:// scheme). An empty prefix means DefaultSubjectPrefix.
+func DialNATS(addr, prefix string) (*NATSEmitter, error) {
+	if prefix == "" {
+		prefix = DefaultSubjectPrefix
+	}
+	e := &NATSEmitter{addr: strings.TrimPrefix(addr, "nats://"), prefix: prefix}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.connect(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// connect dials the server and completes the handshake: read INFO, send
+// CONNECT and a PING, and wait for the PONG. Callers hold e.mu.
+func (e *NATSEmitter) connect() error {
+	conn, err := net.DialTimeout("tcp", e.addr, natsTimeout)
+	if err != nil {
+		return fmt.Errorf("events: dial nats %s: %w", e.addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	fail := func(err error) error {
+		conn.Close()
+		return fmt.Errorf("events: nats handshake with %s: %w", e.addr, err)
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fail(err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fail(fmt.Errorf("expected INFO, got %q", strings.TrimSpace(line)))
+	}
+	w.WriteString(`CONNECT {"verbose":false,"pedantic":false,"name":"market-engine","lang":"go"}` + "\r\n")
+	w.WriteString("PING\r\n")
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if line, err = r.ReadString('\n'); err != nil {
+		return fail(err)
+	}
+	if strings.TrimSpace(line) != "PONG" {
+		return fail(errors.New(strings.TrimSpace(line)))
+	}
+	conn.SetDeadline(time.Time{})
+
+	e.conn, e.w = conn, w
+	go e.readLoop(conn, r)
+	return nil
+}
+
+// readLoop answers the server's keepalive PINGs and logs protocol errors
+// until conn fails, then drops it so the next Emit redials.
+func (e *NATSEmitter) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			e.mu.Lock()
+			if e.conn == conn {
+				e.conn, e.w = nil, nil
+			}
+			e.mu.Unlock()
+			conn.Close()
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			e.mu.Lock()
+			if e.conn == conn {
+				e.w.WriteString("PONG\r\n")
+				e.w.Flush()
+			}
+			e.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			slog.Warn("nats error", "addr", e.addr, "err", line)
+		}
+	}
+}
+
+// Emit implements Emitter.
+func (e *NATSEmitter) Emit(ctx context.Context, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("events: encode %s: %w", ev.Type, err)
+	}
+	subject := e.prefix + "." + ev.Type
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn == nil {
+		if err := e.connect(); err != nil {
+			return err
+		}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(natsTimeout)
+	}
+	e.conn.SetWriteDeadline(deadline)
+	fmt.Fprintf(e.w, "PUB %s %d\r\n", subject, len(data))
+	e.w.Write(data)
+	e.w.WriteString("\r\n")
+	if err := e.w.Flush(); err != nil {
+		e.conn.Close()
+		e.conn, e.w = nil, nil
+		return fmt.Errorf("events: publish %s: %w", subject, err)
+	}
+	return nil
+}
+
+// Close closes the connection. Events emitted afterwards redial.
+func (e *NATSEmitter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn == nil {
+		return nil
+	}
+	err := e.conn.Close()
+	e.conn, e.w = nil, nil
+	return err
+}
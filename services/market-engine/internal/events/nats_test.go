@@ -0,0 +1,134 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// published is one PUB received by fakeNATS.
+type published struct {
+	subject string
+	payload []byte
+}
+
+// fakeNATS accepts connections, completes the handshake and forwards every
+// PUB it receives.
+func fakeNATS(t *testing.T) (addr string, pubs <-chan published) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan published, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveNATS(conn, ch)
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func serveNATS(conn net.Conn, ch chan<- published) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte(`INFO {"server_id":"fake","max_payload":1048576}` + "\r\n"))
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			conn.Write([]byte("PONG\r\n"))
+		case "PUB":
+			n, _ := strconv.Atoi(fields[len(fields)-1])
+			buf := make([]byte, n+2) // payload + CRLF
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			ch <- published{subject: fields[1], payload: buf[:n]}
+		}
+	}
+}
+
+func TestNATSEmitter_PublishesOnTypedSubject(t *testing.T) {
+	addr, pubs := fakeNATS(t)
+	e, err := DialNATS("nats://"+addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	ev := Event{ID: "ev1", Type: TypeMarketCreated, Time: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+		Data: MarketCreated{MarketID: "m1", ContractID: "c1"}}
+	if err := e.Emit(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case p := <-pubs:
+		if p.subject != "atmx.market.created" {
+			t.Errorf("subject = %q, want atmx.market.created", p.subject)
+		}
+		var got struct {
+			ID   string        `json:"id"`
+			Type string        `json:"type"`
+			Data MarketCreated `json:"data"`
+		}
+		if err := json.Unmarshal(p.payload, &got); err != nil {
+			t.Fatalf("bad payload %s: %v", p.payload, err)
+		}
+		if got.ID != "ev1" || got.Type != TypeMarketCreated || got.Data.MarketID != "m1" {
+			t.Errorf("unexpected event %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no PUB received")
+	}
+}
+
+func TestNATSEmitter_RedialsAfterClose(t *testing.T) {
+	addr, pubs := fakeNATS(t)
+	e, err := DialNATS(addr, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+
+	if err := e.Emit(context.Background(), Event{ID: "ev2", Type: TypeTradeExecuted}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case p := <-pubs:
+		if p.subject != "test.trade.executed" {
+			t.Errorf("subject = %q", p.subject)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no PUB received after redial")
+	}
+}
+
+func TestDialNATS_Unreachable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	if _, err := DialNATS(addr, ""); err == nil {
+		t.Error("expected an error dialling a closed port")
+	}
+}
@@ -16,6 +16,7 @@ package lmsr
 import (
 	"errors"
 	"math"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -24,10 +25,21 @@ var (
 	// ErrInvalidLiquidity is returned when b <= 0.
 	ErrInvalidLiquidity = errors.New("lmsr: liquidity parameter b must be positive")
 
+	// ErrLiquidityTooLarge is returned when b > MaxLiquidity.
+	ErrLiquidityTooLarge = errors.New("lmsr: liquidity parameter b exceeds the maximum")
+
+	// ErrInvalidBounds is returned for LiquidityBounds without
+	// 0 < Min <= Max.
+	ErrInvalidBounds = errors.New("lmsr: liquidity bounds need 0 < min <= max")
+
 	// ErrPriceBoundExceeded is returned when a trade would push prices
 	// beyond the allowed bounds [MinPrice, MaxPrice].
 	ErrPriceBoundExceeded = errors.New("lmsr: trade would push price beyond allowed bounds")
 
+	// ErrInvalidTargetPrice is returned by SharesForTargetPrice for a
+	// target outside (0, 1), which no finite trade reaches.
+	ErrInvalidTargetPrice = errors.New("lmsr: target price must be strictly between 0 and 1")
+
 	// MinPrice is the lowest allowed price (probability floor).
 	// Prevents degenerate markets where shares become worthless.
 	MinPrice = decimal.NewFromFloat(0.001)
@@ -38,6 +50,24 @@ var (
 
 	// PriceScale is the number of decimal places for price/cost rounding.
 	PriceScale int32 = 8
+
+	// MaxLiquidity is the largest b a MarketMaker accepts. Cost and Price
+	// go through float64, whose ~15.9 significant digits must cover both
+	// the cost's integer part (of order b) and PriceScale decimals, so
+	// costs lose precision once b approaches 10^7. Lower it to be stricter;
+	// raising it trades away cost accuracy.
+	MaxLiquidity = decimal.NewFromInt(1_000_000)
+
+	// MaxQuantity is the largest |quantity| a trade or quote may have.
+	// Quantities reach Cost and Price through float64, and a large enough
+	// one would turn a cost into Inf; well below that, costs of order
+	// MaxQuantity keep the integer part inside float64 precision. Lower it
+	// to be stricter.
+	MaxQuantity = decimal.New(1, 12)
+
+	// ln2 is ln(2) to 32 places, so MaxLoss is exact decimal arithmetic
+	// whatever the size of b.
+	ln2 = decimal.RequireFromString("0.69314718055994530941723212145818")
 )
 
 // MarketMaker implements the LMSR cost function for binary outcome markets.
@@ -49,10 +79,14 @@ type MarketMaker struct {
 // NewMarketMaker creates a new LMSR market maker with the given liquidity
 // parameter b. Higher b → more liquidity, lower price impact per trade.
 // Maximum market-maker loss is bounded by b * ln(2) for binary markets.
+// b must be in (0, MaxLiquidity].
 func NewMarketMaker(b decimal.Decimal) (*MarketMaker, error) {
 	if b.LessThanOrEqual(decimal.Zero) {
 		return nil, ErrInvalidLiquidity
 	}
+	if b.GreaterThan(MaxLiquidity) {
+		return nil, ErrLiquidityTooLarge
+	}
 	return &MarketMaker{b: b}, nil
 }
 
@@ -171,12 +205,31 @@ func (m *MarketMaker) TradeCostNo(qYes, qNo, deltaNo decimal.Decimal) decimal.De
 //	fillPrice = cost / delta
 //
 // Positive for both buys (cost>0, delta>0) and sells (cost<0, delta<0).
+// The division is rounded once, half away from zero, to PriceScale places.
+//
+// The average price of a trade always lies between the marginal prices
+// before and after it, and the result is clamped to that range. This
+// matters for sub-unit deltas: cost is the difference of two values each
+// rounded to PriceScale, so for |delta| well below 1 that rounding (up to
+// 10^-PriceScale) divided by delta would otherwise swamp the price — at
+// delta=0.0001 by as much as 10^-4. A zero delta returns the marginal price.
 func (m *MarketMaker) FillPrice(qFirst, qSecond, delta decimal.Decimal) decimal.Decimal {
+	before := m.Price(qFirst, qSecond)
 	if delta.IsZero() {
-		return m.Price(qFirst, qSecond)
+		return before
 	}
 	cost := m.TradeCost(qFirst, qSecond, delta)
-	return cost.Div(delta).Round(PriceScale)
+	fill := cost.DivRound(delta, PriceScale)
+
+	after := m.Price(qFirst.Add(delta), qSecond)
+	lo, hi := decimal.Min(before, after), decimal.Max(before, after)
+	if fill.LessThan(lo) {
+		return lo
+	}
+	if fill.GreaterThan(hi) {
+		return hi
+	}
+	return fill
 }
 
 // validatePriceAfterTrade checks whether the resulting YES price is within
@@ -209,12 +262,84 @@ func (m *MarketMaker) ValidateTradeNo(qYes, qNo, deltaNo decimal.Decimal) error
 	return m.validatePriceAfterTrade(qYes, qNo.Add(deltaNo))
 }
 
+// SharesForTargetPrice is the inverse of Price: the YES delta that moves
+// the YES price from (qYes, qNo) to target,
+//
+//	deltaYes = qNo + b * ln(target / (1 - target)) - qYes
+//
+// Positive when target is above the current price (a buy), negative when
+// below (a sell). The result is truncated toward zero to PriceScale places
+// so the trade stops short of target rather than past it. For the NO side,
+// swap the quantities and pass the NO target.
+func (m *MarketMaker) SharesForTargetPrice(qYes, qNo, target decimal.Decimal) (decimal.Decimal, error) {
+	t := target.InexactFloat64()
+	if t <= 0 || t >= 1 {
+		return decimal.Zero, ErrInvalidTargetPrice
+	}
+	bf := m.b.InexactFloat64()
+	qy := qYes.InexactFloat64()
+	qn := qNo.InexactFloat64()
+
+	delta := qn + bf*math.Log(t/(1-t)) - qy
+	return decimal.NewFromFloat(delta).Truncate(PriceScale), nil
+}
+
 // MaxLoss returns the maximum possible loss for the market maker: b * ln(n),
-// where n = 2 for binary markets.
+// where n = 2 for binary markets. It is computed in decimal, so it stays
+// exact for any b.
 func (m *MarketMaker) MaxLoss() decimal.Decimal {
-	bf := m.b.InexactFloat64()
-	loss := bf * math.Log(2)
-	return decimal.NewFromFloat(loss).Round(PriceScale)
+	return m.b.Mul(ln2).Round(PriceScale)
+}
+
+// LiquidityBounds is the range a liquidity parameter derived from a
+// forecast is clamped into.
+type LiquidityBounds struct {
+	Min decimal.Decimal `json:"min"`
+	Max decimal.Decimal `json:"max"`
+}
+
+// DefaultLiquidityBounds keeps derived b in [10, 100000]: below 10 a few
+// shares swing the price, and a forecast wide enough to go past 100000
+// (such as a near-zero median) says little about how deep the market
+// should be.
+var DefaultLiquidityBounds = LiquidityBounds{
+	Min: decimal.NewFromInt(10),
+	Max: decimal.NewFromInt(100_000),
+}
+
+// Validate returns ErrInvalidBounds unless 0 < Min <= Max.
+func (lb LiquidityBounds) Validate() error {
+	if !lb.Min.IsPositive() || lb.Max.LessThan(lb.Min) {
+		return ErrInvalidBounds
+	}
+	return nil
+}
+
+// Clamp returns b limited to [Min, Max].
+func (lb LiquidityBounds) Clamp(b decimal.Decimal) decimal.Decimal {
+	return decimal.Min(decimal.Max(b, lb.Min), lb.Max)
+}
+
+// ExpiryHorizon is the time to expiry at and beyond which a forecast is
+// taken to carry its full uncertainty, so ExpiryFactor is 1.
+const ExpiryHorizon = 7 * 24 * time.Hour
+
+// MinExpiryFactor is the floor on ExpiryFactor, so a market about to
+// expire keeps some depth rather than b collapsing to the bound.
+var MinExpiryFactor = decimal.NewFromFloat(0.1)
+
+// ExpiryFactor scales a forecast-derived b for how close the contract is to
+// expiry at now: sqrt(timeToExpiry / ExpiryHorizon), within
+// [MinExpiryFactor, 1]. Forecast error grows roughly with the square root of
+// lead time, so a contract expiring tomorrow is priced from a much surer
+// forecast than one a month out and needs less liquidity.
+func ExpiryFactor(expiry, now time.Time) decimal.Decimal {
+	remaining := expiry.Sub(now)
+	if remaining >= ExpiryHorizon {
+		return decimal.NewFromInt(1)
+	}
+	f := decimal.NewFromFloat(math.Sqrt(math.Max(remaining.Hours(), 0) / ExpiryHorizon.Hours())).Round(4)
+	return decimal.Max(f, MinExpiryFactor)
 }
 
 // NewMarketMakerFromNWSConfidence derives the liquidity parameter b from
@@ -230,10 +355,33 @@ func (m *MarketMaker) MaxLoss() decimal.Decimal {
 // Wider IQR → higher b → more liquidity → encourages price discovery.
 // Narrower IQR → lower b → less subsidy → market converges quickly.
 //
-// Formula: b = baseVolume × (IQR / median)
+// Formula: b = baseVolume × (IQR / median), clamped into bounds. Bounds
+// reaching past MaxLiquidity can still derive a b that is rejected with
+// ErrLiquidityTooLarge.
 func NewMarketMakerFromNWSConfidence(
 	percentile25, percentile75, median, baseVolume decimal.Decimal,
+	bounds LiquidityBounds,
+) (*MarketMaker, error) {
+	return newMarketMakerFromNWSConfidence(percentile25, percentile75, median, baseVolume, bounds, decimal.NewFromInt(1))
+}
+
+// NewMarketMakerFromNWSConfidenceAt is NewMarketMakerFromNWSConfidence for
+// a contract expiring at expiry, as of now: b is scaled by
+// ExpiryFactor(expiry, now) before being clamped into bounds.
+func NewMarketMakerFromNWSConfidenceAt(
+	percentile25, percentile75, median, baseVolume decimal.Decimal,
+	bounds LiquidityBounds, expiry, now time.Time,
+) (*MarketMaker, error) {
+	return newMarketMakerFromNWSConfidence(percentile25, percentile75, median, baseVolume, bounds, ExpiryFactor(expiry, now))
+}
+
+func newMarketMakerFromNWSConfidence(
+	percentile25, percentile75, median, baseVolume decimal.Decimal,
+	bounds LiquidityBounds, factor decimal.Decimal,
 ) (*MarketMaker, error) {
+	if err := bounds.Validate(); err != nil {
+		return nil, err
+	}
 	if median.LessThanOrEqual(decimal.Zero) {
 		return nil, errors.New("lmsr: median must be positive")
 	}
@@ -243,13 +391,6 @@ func NewMarketMakerFromNWSConfidence(
 		return nil, errors.New("lmsr: 75th percentile must exceed 25th percentile")
 	}
 
-	b := baseVolume.Mul(iqr).Div(median)
-
-	// Enforce minimum b to prevent degenerate markets.
-	minB := decimal.NewFromInt(10)
-	if b.LessThan(minB) {
-		b = minB
-	}
-
-	return &MarketMaker{b: b}, nil
+	b := bounds.Clamp(baseVolume.Mul(iqr).Div(median).Mul(factor))
+	return NewMarketMaker(b)
 }
@@ -3,6 +3,7 @@ package lmsr
 import (
 	"math"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -292,19 +293,63 @@ func TestFillPrice_PositiveForBothBuyAndSell(t *testing.T) {
 	}
 }
 
+func TestFillPrice_SubUnitDelta(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+
+	for _, q := range []decimal.Decimal{d(0), d(37.5), d(-120)} {
+		delta := d(0.0001)
+		before := mm.Price(q, d(0))
+		after := mm.Price(q.Add(delta), d(0))
+
+		fill := mm.FillPrice(q, d(0), delta)
+		if fill.LessThan(decimal.Min(before, after)) || fill.GreaterThan(decimal.Max(before, after)) {
+			t.Errorf("q=%s: fill %s outside marginal prices [%s, %s]", q, fill, before, after)
+		}
+		// Stable: the same inputs give the same price, and a sell of the
+		// same size fills at essentially the same level.
+		if again := mm.FillPrice(q, d(0), delta); !again.Equal(fill) {
+			t.Errorf("q=%s: fill not deterministic: %s then %s", q, fill, again)
+		}
+		sell := mm.FillPrice(q, d(0), delta.Neg())
+		if sell.Sub(fill).Abs().GreaterThan(d(0.000001)) {
+			t.Errorf("q=%s: buy fill %s and sell fill %s diverge", q, fill, sell)
+		}
+	}
+}
+
+func TestFillPrice_LargeCost(t *testing.T) {
+	mm, _ := NewMarketMaker(d(10000))
+	qYes, qNo, delta := d(2000), d(500), d(8000)
+
+	cost := mm.TradeCost(qYes, qNo, delta)
+	if cost.LessThan(d(1000)) {
+		t.Fatalf("expected a large cost, got %s", cost)
+	}
+	fill := mm.FillPrice(qYes, qNo, delta)
+	if want := cost.DivRound(delta, PriceScale); !fill.Equal(want) {
+		t.Errorf("fill = %s, want cost/delta = %s", fill, want)
+	}
+	if fill.LessThan(mm.Price(qYes, qNo)) || fill.GreaterThan(mm.Price(qYes.Add(delta), qNo)) {
+		t.Errorf("fill %s outside marginal prices", fill)
+	}
+	if fill.Exponent() < -PriceScale {
+		t.Errorf("fill %s has more than %d places", fill, PriceScale)
+	}
+}
+
 // --- NWS confidence interval tests ---
 
 func TestNewMarketMakerFromNWSConfidence_WiderCIHigherB(t *testing.T) {
 	// Wider confidence interval → more uncertainty → higher b.
 	mmWide, err := NewMarketMakerFromNWSConfidence(
-		d(10), d(40), d(25), d(100),
+		d(10), d(40), d(25), d(100), DefaultLiquidityBounds,
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
 	mmNarrow, err := NewMarketMakerFromNWSConfidence(
-		d(20), d(30), d(25), d(100),
+		d(20), d(30), d(25), d(100), DefaultLiquidityBounds,
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -318,13 +363,13 @@ func TestNewMarketMakerFromNWSConfidence_WiderCIHigherB(t *testing.T) {
 
 func TestNewMarketMakerFromNWSConfidence_InvalidInputs(t *testing.T) {
 	// Zero median.
-	_, err := NewMarketMakerFromNWSConfidence(d(10), d(40), d(0), d(100))
+	_, err := NewMarketMakerFromNWSConfidence(d(10), d(40), d(0), d(100), DefaultLiquidityBounds)
 	if err == nil {
 		t.Error("expected error for zero median")
 	}
 
 	// Inverted percentiles.
-	_, err = NewMarketMakerFromNWSConfidence(d(40), d(10), d(25), d(100))
+	_, err = NewMarketMakerFromNWSConfidence(d(40), d(10), d(25), d(100), DefaultLiquidityBounds)
 	if err == nil {
 		t.Error("expected error for inverted percentiles")
 	}
@@ -332,7 +377,7 @@ func TestNewMarketMakerFromNWSConfidence_InvalidInputs(t *testing.T) {
 
 func TestNewMarketMakerFromNWSConfidence_MinimumB(t *testing.T) {
 	// Very narrow CI with small base volume should still get minimum b.
-	mm, err := NewMarketMakerFromNWSConfidence(d(24), d(26), d(25), d(1))
+	mm, err := NewMarketMakerFromNWSConfidence(d(24), d(26), d(25), d(1), DefaultLiquidityBounds)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -377,3 +422,188 @@ func TestLogSumExp_EqualValues(t *testing.T) {
 		t.Errorf("logSumExp([3,3]) should be %f, got %f", expected, result)
 	}
 }
+
+// --- Large b tests ---
+
+func TestNewMarketMaker_MaxLiquidity(t *testing.T) {
+	if _, err := NewMarketMaker(MaxLiquidity); err != nil {
+		t.Fatalf("b = MaxLiquidity should be accepted, got %v", err)
+	}
+	over := MaxLiquidity.Add(decimal.New(1, -PriceScale))
+	if _, err := NewMarketMaker(over); err != ErrLiquidityTooLarge {
+		t.Errorf("expected ErrLiquidityTooLarge for b=%s, got %v", over, err)
+	}
+}
+
+func TestNewMarketMakerFromNWSConfidence_RejectsHugeB(t *testing.T) {
+	// IQR/median = 1.2, so a base volume of 10^7 derives b = 1.2×10^7,
+	// which a ceiling beyond MaxLiquidity lets through.
+	bounds := LiquidityBounds{Min: d(10), Max: d(1e8)}
+	_, err := NewMarketMakerFromNWSConfidence(d(10), d(40), d(25), d(1e7), bounds)
+	if err != ErrLiquidityTooLarge {
+		t.Errorf("expected ErrLiquidityTooLarge, got %v", err)
+	}
+}
+
+func TestNewMarketMakerFromNWSConfidence_Bounds(t *testing.T) {
+	bounds := LiquidityBounds{Min: d(20), Max: d(1000)}
+
+	// Very wide CI: IQR/median = 90/1 → b = 9000, clamped to the ceiling.
+	mm, err := NewMarketMakerFromNWSConfidence(d(5), d(95), d(1), d(100), bounds)
+	if err != nil {
+		t.Fatalf("wide: %v", err)
+	}
+	if !mm.B().Equal(d(1000)) {
+		t.Errorf("wide CI: b = %s, want the ceiling 1000", mm.B())
+	}
+
+	// Very narrow CI: IQR/median = 0.2/25 → b = 0.8, raised to the floor.
+	mm, err = NewMarketMakerFromNWSConfidence(d(24.9), d(25.1), d(25), d(100), bounds)
+	if err != nil {
+		t.Fatalf("narrow: %v", err)
+	}
+	if !mm.B().Equal(d(20)) {
+		t.Errorf("narrow CI: b = %s, want the floor 20", mm.B())
+	}
+
+	// The default ceiling also applies.
+	mm, _ = NewMarketMakerFromNWSConfidence(d(10), d(40), d(25), d(1e7), DefaultLiquidityBounds)
+	if !mm.B().Equal(DefaultLiquidityBounds.Max) {
+		t.Errorf("default bounds: b = %s, want %s", mm.B(), DefaultLiquidityBounds.Max)
+	}
+
+	for _, bad := range []LiquidityBounds{{}, {Min: d(0), Max: d(10)}, {Min: d(50), Max: d(10)}} {
+		if _, err := NewMarketMakerFromNWSConfidence(d(10), d(40), d(25), d(100), bad); err != ErrInvalidBounds {
+			t.Errorf("bounds %+v: expected ErrInvalidBounds, got %v", bad, err)
+		}
+	}
+}
+
+func TestMaxLoss_ExactForLargeB(t *testing.T) {
+	tests := []struct {
+		b    string
+		want string
+	}{
+		{"100", "69.31471806"},
+		{"1000000", "693147.18055995"},
+		{"999999.99999999", "693147.18055994"},
+	}
+	for _, tt := range tests {
+		mm, err := NewMarketMaker(decimal.RequireFromString(tt.b))
+		if err != nil {
+			t.Fatalf("b=%s: %v", tt.b, err)
+		}
+		if got := mm.MaxLoss(); !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("b=%s: MaxLoss = %s, want %s", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTradeCost_AccurateAtMaxLiquidity(t *testing.T) {
+	mm, _ := NewMarketMaker(MaxLiquidity)
+
+	// Buying one share from 50/50 costs b·ln((e^(1/b)+1)/2) ≈ 1/2 + 1/(8b).
+	want := d(0.5 + 1/(8*MaxLiquidity.InexactFloat64()))
+	tol := decimal.New(2, -PriceScale) // one rounding step in each Cost
+	for _, q := range []float64{0, 1e6, -1e6} {
+		got := mm.TradeCost(d(q), d(q), d(1))
+		if got.Sub(want).Abs().GreaterThan(tol) {
+			t.Errorf("q=%v: cost of one share = %s, want %s ± %s", q, got, want, tol)
+		}
+	}
+}
+
+func TestSharesForTargetPrice_InvertsPrice(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	for _, tc := range []struct{ qYes, qNo, target float64 }{
+		{0, 0, 0.75},
+		{0, 0, 0.25},
+		{40, -10, 0.999},
+		{40, -10, 0.001},
+	} {
+		delta, err := mm.SharesForTargetPrice(d(tc.qYes), d(tc.qNo), d(tc.target))
+		if err != nil {
+			t.Fatalf("target %v: %v", tc.target, err)
+		}
+		got := mm.Price(d(tc.qYes).Add(delta), d(tc.qNo))
+		if got.Sub(d(tc.target)).Abs().GreaterThan(d(1e-7)) {
+			t.Errorf("q=(%v, %v) target %v: price after %s shares is %s", tc.qYes, tc.qNo, tc.target, delta, got)
+		}
+	}
+
+	// From 50/50, reaching 0.999 takes b·ln(999) shares.
+	delta, _ := mm.SharesForTargetPrice(d(0), d(0), MaxPrice)
+	if !delta.Equal(d(690.67547786)) {
+		t.Errorf("delta to MaxPrice = %s, want 690.67547786", delta)
+	}
+
+	for _, target := range []float64{0, 1, -0.5} {
+		if _, err := mm.SharesForTargetPrice(d(0), d(0), d(target)); err != ErrInvalidTargetPrice {
+			t.Errorf("target %v: expected ErrInvalidTargetPrice, got %v", target, err)
+		}
+	}
+}
+
+func TestExpiryFactor(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		expiry time.Time
+		want   decimal.Decimal
+	}{
+		{"beyond the horizon", now.AddDate(0, 1, 0), d(1)},
+		{"at the horizon", now.Add(ExpiryHorizon), d(1)},
+		{"a day out", now.AddDate(0, 0, 1), d(0.378)},
+		{"an hour out is floored", now.Add(time.Hour), MinExpiryFactor},
+		{"expired is floored", now.Add(-time.Hour), MinExpiryFactor},
+	}
+	for _, tt := range tests {
+		if got := ExpiryFactor(tt.expiry, now); !got.Equal(tt.want) {
+			t.Errorf("%s: factor = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewMarketMakerFromNWSConfidenceAt_LowerBNearExpiry(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	derive := func(expiry time.Time) decimal.Decimal {
+		t.Helper()
+		mm, err := NewMarketMakerFromNWSConfidenceAt(d(10), d(40), d(25), d(100), DefaultLiquidityBounds, expiry, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mm.B()
+	}
+	month, tomorrow := derive(now.AddDate(0, 1, 0)), derive(now.AddDate(0, 0, 1))
+	if !tomorrow.LessThan(month) {
+		t.Errorf("b expiring tomorrow = %s, want less than a month out (%s)", tomorrow, month)
+	}
+	undamped, _ := NewMarketMakerFromNWSConfidence(d(10), d(40), d(25), d(100), DefaultLiquidityBounds)
+	if !month.Equal(undamped.B()) {
+		t.Errorf("a month out: b = %s, want the undamped %s", month, undamped.B())
+	}
+}
+
+func TestTradeCost_FiniteAtMaxQuantity(t *testing.T) {
+	// Even against a tiny b, where q/b is 10^16, a trade of MaxQuantity
+	// keeps every float64 in the cost path finite.
+	for _, b := range []float64{0.0001, 1, 100} {
+		mm, _ := NewMarketMaker(d(b))
+		// Buying q shares from 50/50 costs between q/2 and q; selling them
+		// pays out at most b·ln2.
+		if cost := mm.TradeCost(d(0), d(0), MaxQuantity); cost.GreaterThan(MaxQuantity) || cost.LessThan(MaxQuantity.Div(d(2))) {
+			t.Errorf("b=%v: buy cost %s out of range", b, cost)
+		}
+		if cost := mm.TradeCost(d(0), d(0), MaxQuantity.Neg()); cost.Abs().GreaterThan(mm.MaxLoss()) {
+			t.Errorf("b=%v: sell proceeds %s exceed %s", b, cost.Neg(), mm.MaxLoss())
+		}
+		for _, delta := range []decimal.Decimal{MaxQuantity, MaxQuantity.Neg()} {
+			if p := mm.Price(delta, d(0)); !p.Equal(MaxPrice) && !p.Equal(MinPrice) {
+				t.Errorf("b=%v delta=%s: price %s, want clamped to a bound", b, delta, p)
+			}
+			if err := mm.ValidateTrade(d(0), d(0), delta); err != ErrPriceBoundExceeded {
+				t.Errorf("b=%v delta=%s: expected ErrPriceBoundExceeded, got %v", b, delta, err)
+			}
+		}
+	}
+}
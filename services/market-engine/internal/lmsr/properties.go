@@ -0,0 +1,85 @@
+package lmsr
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// CostFunction is the subset of MarketMaker the property checks rely on.
+// *MarketMaker satisfies it; tests may substitute a deliberately broken
+// implementation to confirm a check actually fails.
+type CostFunction interface {
+	B() decimal.Decimal
+	Cost(qYes, qNo decimal.Decimal) decimal.Decimal
+	Price(qYes, qNo decimal.Decimal) decimal.Decimal
+	MaxLoss() decimal.Decimal
+}
+
+// The Check* functions assert LMSR invariants at a given market state and
+// return a descriptive error when one is violated by more than tol. They
+// are pure: nothing is mutated and no I/O is performed, so they are safe
+// to call from tests, audits and request handlers alike.
+
+// CheckSumToOne verifies that the YES and NO prices sum to one. The NO
+// price is derived independently via symmetry, Price(qNo, qYes), rather
+// than as 1 - Price(qYes, qNo), which would make the check vacuous.
+func CheckSumToOne(mm CostFunction, qYes, qNo, tol decimal.Decimal) error {
+	pYes := mm.Price(qYes, qNo)
+	pNo := mm.Price(qNo, qYes)
+	sum := pYes.Add(pNo)
+	if sum.Sub(decimal.NewFromInt(1)).Abs().GreaterThan(tol) {
+		return fmt.Errorf("lmsr: prices sum to %s at q=(%s,%s), want 1", sum, qYes, qNo)
+	}
+	return nil
+}
+
+// CheckPathIndependence verifies that totalCost — the sum of trade costs
+// paid along whatever path led from (0,0) to (qYes,qNo) — equals
+// C(qYes,qNo) - C(0,0). Any discrepancy means some trade was not priced
+// by the cost function.
+func CheckPathIndependence(mm CostFunction, qYes, qNo, totalCost, tol decimal.Decimal) error {
+	want := mm.Cost(qYes, qNo).Sub(mm.Cost(decimal.Zero, decimal.Zero))
+	if totalCost.Sub(want).Abs().GreaterThan(tol) {
+		return fmt.Errorf("lmsr: path cost %s != C(q)-C(0) = %s at q=(%s,%s)", totalCost, want, qYes, qNo)
+	}
+	return nil
+}
+
+// CheckConvexity verifies midpoint convexity of the cost function along
+// each axis at (qYes,qNo): C(q+δ) + C(q-δ) >= 2·C(q). Convexity is what
+// makes each additional share cost more than the last.
+func CheckConvexity(mm CostFunction, qYes, qNo, delta, tol decimal.Decimal) error {
+	two := decimal.NewFromInt(2)
+	mid := mm.Cost(qYes, qNo).Mul(two)
+
+	yes := mm.Cost(qYes.Add(delta), qNo).Add(mm.Cost(qYes.Sub(delta), qNo))
+	if yes.Add(tol).LessThan(mid) {
+		return fmt.Errorf("lmsr: cost not convex in q_yes at q=(%s,%s), δ=%s", qYes, qNo, delta)
+	}
+	no := mm.Cost(qYes, qNo.Add(delta)).Add(mm.Cost(qYes, qNo.Sub(delta)))
+	if no.Add(tol).LessThan(mid) {
+		return fmt.Errorf("lmsr: cost not convex in q_no at q=(%s,%s), δ=%s", qYes, qNo, delta)
+	}
+	return nil
+}
+
+// CheckBoundedLoss verifies that, having collected revenue to reach
+// (qYes,qNo) from a market seeded at (seedYes,seedNo), the market maker's
+// loss on the shares traders hold under either outcome is within its
+// bound. From (0,0) that is MaxLoss, b·ln(2). A seed opening the market
+// away from 0.5 moves the bound to C(seed) - min(seed), which is MaxLoss
+// plus the seed's cost over C(0,0), less the shares it fixes.
+func CheckBoundedLoss(mm CostFunction, seedYes, seedNo, qYes, qNo, revenue, tol decimal.Decimal) error {
+	bound := mm.MaxLoss()
+	if !seedYes.IsZero() || !seedNo.IsZero() {
+		opened := mm.Cost(seedYes, seedNo).Sub(mm.Cost(decimal.Zero, decimal.Zero))
+		bound = bound.Add(opened).Sub(decimal.Min(seedYes, seedNo))
+	}
+	worst := decimal.Max(qYes.Sub(seedYes), qNo.Sub(seedNo)).Sub(revenue)
+	if worst.GreaterThan(bound.Add(tol)) {
+		return fmt.Errorf("lmsr: worst-case maker loss %s exceeds its bound %s at q=(%s,%s) from seed (%s,%s)",
+			worst, bound, qYes, qNo, seedYes, seedNo)
+	}
+	return nil
+}
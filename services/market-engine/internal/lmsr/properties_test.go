@@ -0,0 +1,124 @@
+package lmsr
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// brokenMaker wraps a real MarketMaker and corrupts one behaviour.
+type brokenMaker struct {
+	*MarketMaker
+	price func(qYes, qNo decimal.Decimal) decimal.Decimal
+	cost  func(qYes, qNo decimal.Decimal) decimal.Decimal
+}
+
+func (b brokenMaker) Price(qYes, qNo decimal.Decimal) decimal.Decimal {
+	if b.price != nil {
+		return b.price(qYes, qNo)
+	}
+	return b.MarketMaker.Price(qYes, qNo)
+}
+
+func (b brokenMaker) Cost(qYes, qNo decimal.Decimal) decimal.Decimal {
+	if b.cost != nil {
+		return b.cost(qYes, qNo)
+	}
+	return b.MarketMaker.Cost(qYes, qNo)
+}
+
+var propertyStates = [][2]float64{{0, 0}, {50, 0}, {0, 120}, {300, 280}, {-40, 10}}
+
+func TestCheckProperties_PassForValidStates(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	tol := d(0.000001)
+
+	for _, st := range propertyStates {
+		qYes, qNo := d(st[0]), d(st[1])
+		revenue := mm.Cost(qYes, qNo).Sub(mm.Cost(d(0), d(0)))
+
+		if err := CheckSumToOne(mm, qYes, qNo, tol); err != nil {
+			t.Errorf("sum-to-one: %v", err)
+		}
+		if err := CheckPathIndependence(mm, qYes, qNo, revenue, tol); err != nil {
+			t.Errorf("path independence: %v", err)
+		}
+		if err := CheckConvexity(mm, qYes, qNo, d(10), tol); err != nil {
+			t.Errorf("convexity: %v", err)
+		}
+		if err := CheckBoundedLoss(mm, d(0), d(0), qYes, qNo, revenue, tol); err != nil {
+			t.Errorf("bounded loss: %v", err)
+		}
+	}
+}
+
+func TestCheckPathIndependence_SequentialTrades(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	total := mm.TradeCost(d(0), d(0), d(30)).
+		Add(mm.TradeCostNo(d(30), d(0), d(20))).
+		Add(mm.TradeCost(d(30), d(20), d(-10)))
+	if err := CheckPathIndependence(mm, d(20), d(20), total, d(0.000001)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckSumToOne_FailsForSkewedPricer(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	broken := brokenMaker{MarketMaker: mm, price: func(qYes, qNo decimal.Decimal) decimal.Decimal {
+		return mm.Price(qYes, qNo).Add(d(0.01)) // overround
+	}}
+	if err := CheckSumToOne(broken, d(10), d(0), d(0.000001)); err == nil {
+		t.Error("expected sum-to-one violation")
+	}
+}
+
+func TestCheckPathIndependence_FailsForMispricedTrade(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	revenue := mm.Cost(d(50), d(0)).Sub(mm.Cost(d(0), d(0)))
+	if err := CheckPathIndependence(mm, d(50), d(0), revenue.Sub(d(0.5)), d(0.000001)); err == nil {
+		t.Error("expected path-independence violation for undercharged total")
+	}
+}
+
+func TestCheckConvexity_FailsForConcaveCost(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	broken := brokenMaker{MarketMaker: mm, cost: func(qYes, qNo decimal.Decimal) decimal.Decimal {
+		return mm.Cost(qYes, qNo).Neg()
+	}}
+	if err := CheckConvexity(broken, d(0), d(0), d(10), d(0.000001)); err == nil {
+		t.Error("expected convexity violation for concave cost")
+	}
+}
+
+func TestCheckBoundedLoss_FailsWhenUndercharged(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	// Traders hold 500 YES but paid only 100: the maker would lose 400 > b·ln2.
+	if err := CheckBoundedLoss(mm, d(0), d(0), d(500), d(0), d(100), d(0.000001)); err == nil {
+		t.Error("expected bounded-loss violation")
+	}
+}
+
+func TestCheckBoundedLoss_FromSeed(t *testing.T) {
+	mm, _ := NewMarketMaker(d(100))
+	tol := d(0.000001)
+	// Seeded to open YES at about 0.2. Traders then buy NO cheaply and YES
+	// heavily; the maker's exposure is only on their shares, and its bound
+	// is -b·ln(0.2) ≈ 160.9 rather than b·ln2.
+	seedYes, seedNo := d(0), d(138.6294)
+	for _, st := range [][2]float64{{0, 138.6294}, {0, 400}, {2000, 138.6294}, {-50, 200}} {
+		qYes, qNo := d(st[0]), d(st[1])
+		revenue := mm.Cost(qYes, qNo).Sub(mm.Cost(seedYes, seedNo))
+		if err := CheckBoundedLoss(mm, seedYes, seedNo, qYes, qNo, revenue, tol); err != nil {
+			t.Errorf("q=(%s,%s): %v", qYes, qNo, err)
+		}
+	}
+
+	// The seed is no trader's: counting its NO shares as owed would breach.
+	if err := CheckBoundedLoss(mm, d(0), d(0), d(0), d(400), mm.Cost(d(0), d(400)).Sub(mm.Cost(seedYes, seedNo)), tol); err == nil {
+		t.Error("expected a violation when the seed is not taken into account")
+	}
+	// Undercharged from the seed is still caught.
+	if err := CheckBoundedLoss(mm, seedYes, seedNo, d(2000), seedNo, d(100), tol); err == nil {
+		t.Error("expected bounded-loss violation from the seed")
+	}
+}
@@ -37,6 +37,13 @@ var (
 		Help: "Number of connected WebSocket clients",
 	})
 
+	// WebSocketDroppedMessages counts messages the WebSocket hub discarded
+	// because its broadcast or direct queue was full.
+	WebSocketDroppedMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "atmx_websocket_dropped_messages_total",
+		Help: "WebSocket messages dropped on a full hub queue",
+	})
+
 	// HTTPRequestsTotal counts HTTP requests by method, path, and status.
 	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "atmx_http_requests_total",
@@ -50,12 +57,28 @@ var (
 		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0},
 	}, []string{"method", "path"})
 
-	// PositionLimitRejections counts trades rejected by the position limiter.
-	PositionLimitRejections = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "atmx_position_limit_rejections_total",
-		Help: "Trades rejected by position limiter",
+	// TradeRejections counts trades turned away, partitioned by reason:
+	// per_cell and correlated position limits, price_bound, market_closed,
+	// slippage, and the other codes in trade/rejections.go.
+	TradeRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "atmx_trade_rejections_total",
+		Help: "Trades rejected before execution, by reason",
+	}, []string{"reason"})
+
+	// PositionLimitBypasses counts trades by allowlisted accounts that
+	// skipped the position limit check.
+	PositionLimitBypasses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "atmx_position_limit_bypasses_total",
+		Help: "Trades that skipped position limits for an allowlisted account",
 	})
 
+	// BuildInfo is always 1; its labels say which build is running (see
+	// package version).
+	BuildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "atmx_build_info",
+		Help: "Build information of the running market engine, value 1",
+	}, []string{"version", "commit", "build_time"})
+
 	// MarketVolume tracks cumulative trade volume (quantity) per market.
 	MarketVolume = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "atmx_market_volume_total",
@@ -21,6 +21,44 @@ type LedgerEntry struct {
 	Price      decimal.Decimal `json:"price" db:"price"`       // average fill price
 	Cost       decimal.Decimal `json:"cost" db:"cost"`         // total cost (signed)
 	Timestamp  time.Time       `json:"timestamp" db:"timestamp"`
+
+	// RealizedPnL is the P&L this entry locked in against the user's
+	// average cost on the same side; zero for trades that only add.
+	RealizedPnL decimal.Decimal `json:"realized_pnl" db:"realized_pnl"`
+
+	// Kind is EntryKindTrade for LMSR trades and EntryKindSettlement for
+	// the entries that close positions at the payout when a market settles.
+	// Empty is treated as a trade.
+	Kind string `json:"kind" db:"kind"`
+
+	// Reverses is the ID of the entry this one cancels, for
+	// EntryKindSettlementReversal entries; empty otherwise.
+	Reverses string `json:"reverses,omitempty" db:"reverses"`
+
+	// PrevHash and Hash chain a market's entries for tamper evidence:
+	// Hash covers this entry and PrevHash, the Hash of the market's
+	// previous entry ("" for its first). The store sets both on insert.
+	PrevHash string `json:"prev_hash,omitempty" db:"prev_hash"`
+	Hash     string `json:"hash,omitempty" db:"hash"`
+
+	// Metadata holds the caller's tags from the trade request, such as
+	// its source or a strategy ID. Nil when none were given.
+	Metadata map[string]string `json:"metadata,omitempty" db:"metadata"`
+}
+
+// Ledger entry kinds.
+const (
+	EntryKindTrade      = "trade"
+	EntryKindSettlement = "settlement"
+	// EntryKindSettlementReversal undoes a settlement entry when a market
+	// is unsettled: the same shares, cost and P&L with the opposite sign.
+	EntryKindSettlementReversal = "settlement_reversal"
+)
+
+// IsTrade reports whether e was executed against the LMSR, as opposed to
+// written by settlement.
+func (e LedgerEntry) IsTrade() bool {
+	return e.Kind == "" || e.Kind == EntryKindTrade
 }
 
 // Market represents the state of a binary prediction market tied to one
@@ -36,6 +74,93 @@ type Market struct {
 	PriceNo    decimal.Decimal `json:"price_no" db:"price_no"`
 	Status     string          `json:"status" db:"status"` // "open", "settled"
 	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+
+	// Optional daily trading window, "HH:MM" in UTC. Trades are accepted
+	// from TradingOpen (inclusive) to TradingClose (exclusive); a window
+	// whose close is earlier than its open spans midnight. Nil = always open.
+	TradingOpen  *string `json:"trading_open" db:"trading_open"`
+	TradingClose *string `json:"trading_close" db:"trading_close"`
+
+	// Optional liquidity schedule: the effective b interpolates linearly
+	// from BStart at CreatedAt to BEnd at contract expiry. Nil = fixed B.
+	BStart *decimal.Decimal `json:"b_start" db:"b_start"`
+	BEnd   *decimal.Decimal `json:"b_end" db:"b_end"`
+
+	// Optional bounds on |quantity| per trade. Nil = unenforced.
+	MinQuantity *decimal.Decimal `json:"min_quantity" db:"min_quantity"`
+	MaxQuantity *decimal.Decimal `json:"max_quantity" db:"max_quantity"`
+
+	// Outcome ("YES" or "NO") and SettledAt are set when the market settles.
+	Outcome   *string    `json:"outcome" db:"outcome"`
+	SettledAt *time.Time `json:"settled_at" db:"settled_at"`
+
+	// HiddenAt is set when the market is soft-deleted: it is left out of
+	// listings and closed to trading, but still readable by ID.
+	HiddenAt *time.Time `json:"hidden_at" db:"hidden_at"`
+
+	// ProductID links markets materialized together from one Product.
+	// Nil for markets created individually.
+	ProductID *string `json:"product_id" db:"product_id"`
+
+	// LiquidityInputs are the forecast inputs B was derived from. Nil when
+	// B was set directly.
+	LiquidityInputs *LiquidityInputs `json:"liquidity_inputs" db:"liquidity_inputs"`
+
+	// InitialQYes and InitialQNo are the quantities the market was seeded
+	// with to open at a prior price other than 0.5; zero otherwise. No
+	// trader holds them, so traders' shares are QYes/QNo less these.
+	InitialQYes decimal.Decimal `json:"initial_q_yes" db:"initial_q_yes"`
+	InitialQNo  decimal.Decimal `json:"initial_q_no" db:"initial_q_no"`
+
+	// AutoLiquidity opts the market into the service's volume-driven b
+	// adjustment, if one is configured. Each adjustment is recorded as a
+	// LiquidityChange.
+	AutoLiquidity bool `json:"auto_liquidity" db:"auto_liquidity"`
+
+	// CreatedBy is the user who created the market, counted against the
+	// per-creator open market limit. Empty for markets created by an
+	// operator or materialized from a product.
+	CreatedBy string `json:"created_by,omitempty" db:"created_by"`
+
+	// SubsidyBudget caps the maker's worst-case loss in this market: trades
+	// that would push it past the budget are rejected. Nil = unenforced.
+	SubsidyBudget *decimal.Decimal `json:"subsidy_budget" db:"subsidy_budget"`
+
+	// Collected is the net cash traders have paid the maker: the sum of
+	// the market's trade ledger costs, sells counting negative. Each trade
+	// sets it with the quantities, so it is read without replaying the
+	// ledger.
+	Collected decimal.Decimal `json:"collected" db:"collected"`
+}
+
+// LiquidityInputs are the NWS forecast percentiles and base volume a
+// market's b was derived from (see contract.DeriveLiquidity).
+type LiquidityInputs struct {
+	Percentile10 decimal.Decimal `json:"percentile_10"`
+	Percentile25 decimal.Decimal `json:"percentile_25"`
+	Percentile50 decimal.Decimal `json:"percentile_50"`
+	Percentile75 decimal.Decimal `json:"percentile_75"`
+	Percentile90 decimal.Decimal `json:"percentile_90"`
+	BaseVolume   decimal.Decimal `json:"base_volume"`
+}
+
+// MarkPriceYes is the value of one YES share used to mark positions: the
+// payout (1 or 0) once the market has settled with an outcome, otherwise
+// the current LMSR price. A NO share is worth 1 - MarkPriceYes.
+func (m Market) MarkPriceYes() decimal.Decimal {
+	if m.Status == "settled" && m.Outcome != nil {
+		if *m.Outcome == "YES" {
+			return decimal.NewFromInt(1)
+		}
+		return decimal.Zero
+	}
+	return m.PriceYes
+}
+
+// TradedQuantities returns the YES and NO shares held by traders: QYes and
+// QNo less the quantities the market was seeded with.
+func (m Market) TradedQuantities() (yes, no decimal.Decimal) {
+	return m.QYes.Sub(m.InitialQYes), m.QNo.Sub(m.InitialQNo)
 }
 
 // Position represents a trader's aggregate holdings in one market.
@@ -48,8 +173,11 @@ type Position struct {
 	NoQty         decimal.Decimal `json:"no_qty"`
 	NetQty        decimal.Decimal `json:"net_qty"`          // yes - no
 	CostBasis     decimal.Decimal `json:"cost_basis"`       // net cash outflow
+	YesCostBasis  decimal.Decimal `json:"yes_cost_basis"`   // cost carried by the open YES shares
+	NoCostBasis   decimal.Decimal `json:"no_cost_basis"`    // cost carried by the open NO shares
 	CurrentValue  decimal.Decimal `json:"current_value"`    // mark-to-market
 	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`   // currentValue - costBasis
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"`     // Σ ledger realized_pnl
 }
 
 // Portfolio aggregates all positions for a user with P&L and risk metrics.
@@ -60,4 +188,77 @@ type Portfolio struct {
 	TotalExposure     decimal.Decimal            `json:"total_exposure"`     // Σ |netQty|
 	MarginUtilization decimal.Decimal            `json:"margin_utilization"` // % of margin used
 	ExposureByCell    map[string]decimal.Decimal `json:"exposure_by_cell"`   // h3CellID → net
+
+	// AsOf is set when the portfolio was reconstructed as of a past time.
+	AsOf *time.Time `json:"as_of,omitempty"`
+}
+
+// LeaderboardEntry ranks one user by realized P&L over a period.
+type LeaderboardEntry struct {
+	Rank        int             `json:"rank"`
+	UserID      string          `json:"user_id"`
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	TradeCount  int             `json:"trade_count"`
+}
+
+// CellSummary describes one H3 cell with at least one open market.
+type CellSummary struct {
+	H3CellID    string          `json:"h3_cell_id"`
+	MarketCount int             `json:"market_count"` // open markets in the cell
+	Volume      decimal.Decimal `json:"volume"`       // Σ|quantity| traded in those markets
+}
+
+// Observation is the officially observed value of a weather variable for
+// one H3 cell and day, used as settlement reference data. Each
+// (H3CellID, Type, Date) is recorded at most once.
+type Observation struct {
+	H3CellID   string          `json:"h3_cell"`
+	Type       string          `json:"type"` // contract type, e.g. "PRECIP"
+	Date       string          `json:"date"` // YYYY-MM-DD (UTC)
+	Value      decimal.Decimal `json:"value"`
+	Unit       string          `json:"unit"`   // one of contract.ValidUnit for Type
+	Source     string          `json:"source"` // e.g. "ASOS:KMIA"
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// AuditEvent records one admin action, such as a settlement or a user
+// anonymization, for later review. Kept apart from the ledger: it has no
+// bearing on balances and isn't covered by the hash chain.
+type AuditEvent struct {
+	ID        string            `json:"id"`
+	Actor     string            `json:"actor"`  // who acted, per their admin token
+	Action    string            `json:"action"` // e.g. "settle", "anonymize"
+	Target    string            `json:"target"` // the market or user acted on
+	Params    map[string]string `json:"params,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// LiquidityChange records a manual change of a market's b parameter, so
+// the ledger can still be replayed with the b each trade saw.
+type LiquidityChange struct {
+	MarketID  string          `json:"market_id"`
+	OldB      decimal.Decimal `json:"old_b"`
+	NewB      decimal.Decimal `json:"new_b"`
+	ChangedAt time.Time       `json:"changed_at"`
+
+	// Inputs are the forecast inputs NewB was derived from; they replace
+	// the market's LiquidityInputs. Nil when NewB was set directly.
+	Inputs *LiquidityInputs `json:"inputs,omitempty"`
+}
+
+// Product is a template that materializes one market per H3 cell for the
+// same contract type, threshold and date, e.g. every cell along a
+// hurricane's forecast path. Member markets carry its ID.
+type Product struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`      // contract type, e.g. "WIND"
+	Threshold string   `json:"threshold"` // value and unit, e.g. "25MM"
+	Date      string   `json:"date"`      // YYYYMMDD
+	Cells     []string `json:"cells"`
+
+	// LiquiditySource is "fixed" or "forecast"; B is the liquidity every
+	// member market was created with.
+	LiquiditySource string          `json:"liquidity_source"`
+	B               decimal.Decimal `json:"b"`
+	CreatedAt       time.Time       `json:"created_at"`
 }
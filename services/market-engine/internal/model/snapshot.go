@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// SnapshotVersion is the format version of Snapshot; a restore rejects
+// any other.
+const SnapshotVersion = 1
+
+// Snapshot is a point-in-time export of the market state for disaster
+// recovery: every product, market, ledger entry and liquidity change.
+// Observations are not included.
+type Snapshot struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	Products  []Product     `json:"products"`
+	Markets   []Market      `json:"markets"`
+	Ledger    []LedgerEntry `json:"ledger"`
+
+	// LiquidityChanges is every market's b history, ordered by time.
+	// Snapshots taken before it was exported restore with none.
+	LiquidityChanges []LiquidityChange `json:"liquidity_changes"`
+}
@@ -0,0 +1,426 @@
+// Package grib reads NWS percentile forecasts from GRIB2 files, such as
+// the NDFD and NBM probabilistic QPF grids on NOAA NOMADS, and extracts the
+// values at one point as contract.NWSForecastData.
+//
+// It is a small pure-Go decoder covering what those products use for
+// percentile fields: regular lat/lon (template 3.0) and Lambert conformal
+// (3.30) grids, percentile product templates 4.6 and 4.10, and simple
+// packing (5.0) with or without a bitmap. Anything else is skipped, or
+// reported as ErrUnsupported if it is a percentile field we need.
+package grib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+)
+
+var (
+	// ErrNoPercentiles is returned when a file holds no QPF percentile
+	// field covering the requested point.
+	ErrNoPercentiles = errors.New("grib: no QPF percentile fields at point")
+
+	// ErrOutsideGrid is returned when the point is outside every grid.
+	ErrOutsideGrid = errors.New("grib: point outside grid")
+
+	// ErrUnsupported is returned for a QPF percentile field whose grid or
+	// packing this package can't decode.
+	ErrUnsupported = errors.New("grib: unsupported template")
+)
+
+// earthRadius is the spherical earth radius in metres GRIB2 shape 6 uses,
+// which NDFD grids specify.
+const earthRadius = 6371229.0
+
+// percentiles are the bands NWSForecastData holds.
+var percentiles = []int{10, 25, 50, 75, 90}
+
+// valueScale is the number of decimal places extracted values keep.
+const valueScale = 4
+
+// ParseGRIB2 reads every GRIB2 message in r and returns the total
+// precipitation (QPF, discipline 0 / category 1 / parameter 8, in mm)
+// percentiles at lat/lon, taking the nearest grid point.
+//
+// Missing bands are filled from the ones present: by linear interpolation
+// between the nearest percentiles either side, or by the nearest one at
+// the ends (a file with only the median yields it for every band). A band
+// whose grid point is masked out by the bitmap counts as missing. At least
+// one band must be present.
+func ParseGRIB2(r io.Reader, lat, lon float64) (contract.NWSForecastData, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return contract.NWSForecastData{}, fmt.Errorf("grib: read: %w", err)
+	}
+
+	found := make(map[int]float64)
+	outside := false
+	for len(data) > 0 {
+		msg, rest, err := nextMessage(data)
+		if err != nil {
+			return contract.NWSForecastData{}, err
+		}
+		data = rest
+		if err := readMessage(msg, lat, lon, found, &outside); err != nil {
+			return contract.NWSForecastData{}, err
+		}
+	}
+
+	if len(found) == 0 {
+		if outside {
+			return contract.NWSForecastData{}, ErrOutsideGrid
+		}
+		return contract.NWSForecastData{}, ErrNoPercentiles
+	}
+	v := fillPercentiles(found)
+	return contract.NWSForecastData{
+		Percentile10: v[10],
+		Percentile25: v[25],
+		Percentile50: v[50],
+		Percentile75: v[75],
+		Percentile90: v[90],
+	}, nil
+}
+
+// nextMessage splits the first GRIB2 message off data, skipping any bytes
+// before its "GRIB" indicator.
+func nextMessage(data []byte) (msg, rest []byte, err error) {
+	start := indexGRIB(data)
+	if start < 0 {
+		return nil, nil, nil // trailing padding
+	}
+	data = data[start:]
+	if len(data) < 16 {
+		return nil, nil, errors.New("grib: truncated indicator section")
+	}
+	if data[7] != 2 {
+		return nil, nil, fmt.Errorf("grib: edition %d, want 2", data[7])
+	}
+	n := binary.BigEndian.Uint64(data[8:16])
+	if n < 16 || n > uint64(len(data)) {
+		return nil, nil, fmt.Errorf("grib: message length %d exceeds file", n)
+	}
+	return data[:n], data[n:], nil
+}
+
+func indexGRIB(data []byte) int {
+	for i := 0; i+4 <= len(data); i++ {
+		if data[i] == 'G' && data[i+1] == 'R' && data[i+2] == 'I' && data[i+3] == 'B' {
+			return i
+		}
+	}
+	return -1
+}
+
+// field collects the sections describing the field currently being read.
+// GRIB2 lets sections 2–7 repeat within a message; each section replaces
+// its predecessor and section 7 completes a field.
+type field struct {
+	discipline byte
+	grid       []byte // section 3
+	product    []byte // section 4
+	repr       []byte // section 5
+	bitmap     []byte // section 6 bitmap bits, nil if none
+}
+
+// readMessage decodes the fields in one message, recording QPF percentile
+// values at lat/lon in found.
+func readMessage(msg []byte, lat, lon float64, found map[int]float64, outside *bool) error {
+	f := field{discipline: msg[6]}
+	pos := 16
+	for pos < len(msg) {
+		if len(msg)-pos >= 4 && string(msg[pos:pos+4]) == "7777" {
+			return nil
+		}
+		if len(msg)-pos < 5 {
+			return errors.New("grib: truncated section header")
+		}
+		n := int(binary.BigEndian.Uint32(msg[pos:]))
+		if n < 5 || pos+n > len(msg) {
+			return fmt.Errorf("grib: section length %d out of range", n)
+		}
+		sec := msg[pos : pos+n]
+		pos += n
+
+		switch sec[4] {
+		case 3:
+			f.grid = sec
+		case 4:
+			f.product = sec
+		case 5:
+			f.repr = sec
+		case 6:
+			switch ind := sec[5]; ind {
+			case 255:
+				f.bitmap = nil
+			case 0:
+				f.bitmap = sec[6:]
+			case 254:
+				// Reuse the previous bitmap.
+			default:
+				return fmt.Errorf("%w: bitmap indicator %d", ErrUnsupported, ind)
+			}
+		case 7:
+			pct, ok := qpfPercentile(f)
+			if !ok {
+				continue
+			}
+			v, present, err := f.valueAt(sec[5:], lat, lon)
+			if errors.Is(err, ErrOutsideGrid) {
+				*outside = true
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("grib: p%d: %w", pct, err)
+			}
+			if present {
+				found[pct] = v
+			}
+		}
+	}
+	return errors.New("grib: message missing end section")
+}
+
+// qpfPercentile reports the percentile of f if it is a total
+// precipitation percentile field.
+func qpfPercentile(f field) (int, bool) {
+	p := f.product
+	if f.discipline != 0 || len(p) < 35 {
+		return 0, false
+	}
+	tmpl := binary.BigEndian.Uint16(p[7:9])
+	if tmpl != 6 && tmpl != 10 {
+		return 0, false
+	}
+	if p[9] != 1 || p[10] != 8 { // moisture / total precipitation
+		return 0, false
+	}
+	pct := int(p[34])
+	for _, want := range percentiles {
+		if pct == want {
+			return pct, true
+		}
+	}
+	return 0, false
+}
+
+// valueAt decodes the field's value at the grid point nearest lat/lon.
+// present is false if the bitmap masks that point.
+func (f field) valueAt(packed []byte, lat, lon float64) (v float64, present bool, err error) {
+	if f.grid == nil || f.repr == nil {
+		return 0, false, errors.New("missing grid or data representation section")
+	}
+	idx, err := gridIndex(f.grid, lat, lon)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// With a bitmap, only points whose bit is set are packed.
+	if f.bitmap != nil {
+		if idx/8 >= len(f.bitmap) {
+			return 0, false, errors.New("bitmap shorter than grid")
+		}
+		if f.bitmap[idx/8]&(0x80>>(idx%8)) == 0 {
+			return 0, false, nil
+		}
+		idx = countBits(f.bitmap, idx)
+	}
+	v, err = unpackSimple(f.repr, packed, idx)
+	return v, err == nil, err
+}
+
+// countBits returns how many bits are set before bit n of bitmap.
+func countBits(bitmap []byte, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if bitmap[i/8]&(0x80>>(i%8)) != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// unpackSimple decodes value idx of a simple-packed (template 5.0) field:
+// Y = (R + X·2^E) / 10^D.
+func unpackSimple(repr, packed []byte, idx int) (float64, error) {
+	if len(repr) < 21 {
+		return 0, errors.New("short data representation section")
+	}
+	if tmpl := binary.BigEndian.Uint16(repr[9:11]); tmpl != 0 {
+		return 0, fmt.Errorf("%w: data representation 5.%d", ErrUnsupported, tmpl)
+	}
+	ref := float64(math.Float32frombits(binary.BigEndian.Uint32(repr[11:15])))
+	e := float64(signed16(repr[15:17]))
+	d := float64(signed16(repr[17:19]))
+	bits := int(repr[19])
+
+	x := uint64(0)
+	if bits > 0 {
+		bit := idx * bits
+		if (bit+bits+7)/8 > len(packed) {
+			return 0, errors.New("packed data shorter than grid")
+		}
+		for i := 0; i < bits; i++ {
+			b := packed[(bit+i)/8] >> (7 - (bit+i)%8) & 1
+			x = x<<1 | uint64(b)
+		}
+	}
+	return (ref + float64(x)*math.Pow(2, e)) / math.Pow(10, d), nil
+}
+
+// gridIndex returns the data index of the grid point nearest lat/lon.
+func gridIndex(grid []byte, lat, lon float64) (int, error) {
+	if len(grid) < 14 {
+		return 0, errors.New("short grid definition section")
+	}
+	var (
+		ni, nj int
+		fi, fj float64 // fractional position along the scan axes
+		scan   byte
+	)
+	switch tmpl := binary.BigEndian.Uint16(grid[12:14]); tmpl {
+	case 0:
+		if len(grid) < 72 {
+			return 0, errors.New("short lat/lon grid definition")
+		}
+		ni, nj = int(be32(grid[30:34])), int(be32(grid[34:38]))
+		la1, lo1 := micro(grid[46:50]), micro(grid[50:54])
+		di, dj := float64(be32(grid[63:67]))/1e6, float64(be32(grid[67:71]))/1e6
+		scan = grid[71]
+
+		dLon := math.Mod(lon-lo1+720, 360)
+		if scan&0x80 != 0 { // i runs westward
+			dLon = math.Mod(lo1-lon+720, 360)
+		}
+		fi = dLon / di
+		if scan&0x40 != 0 { // j runs northward
+			fj = (lat - la1) / dj
+		} else {
+			fj = (la1 - lat) / dj
+		}
+	case 30:
+		if len(grid) < 81 {
+			return 0, errors.New("short Lambert conformal grid definition")
+		}
+		ni, nj = int(be32(grid[30:34])), int(be32(grid[34:38]))
+		la1, lo1 := micro(grid[38:42]), micro(grid[42:46])
+		lov := micro(grid[51:55])
+		dx, dy := float64(be32(grid[55:59]))/1e3, float64(be32(grid[59:63]))/1e3
+		scan = grid[64]
+		latin1, latin2 := micro(grid[65:69]), micro(grid[69:73])
+
+		p := newLambert(latin1, latin2, lov)
+		x1, y1 := p.project(la1, lo1)
+		x, y := p.project(lat, lon)
+		fi, fj = (x-x1)/dx, (y-y1)/dy
+		if scan&0x80 != 0 {
+			fi = -fi
+		}
+		if scan&0x40 == 0 {
+			fj = -fj
+		}
+	default:
+		return 0, fmt.Errorf("%w: grid definition 3.%d", ErrUnsupported, tmpl)
+	}
+
+	i, j := int(math.Round(fi)), int(math.Round(fj))
+	if i < 0 || j < 0 || i >= ni || j >= nj {
+		return 0, ErrOutsideGrid
+	}
+	if scan&0x10 != 0 && j%2 == 1 { // alternate rows scan in reverse
+		i = ni - 1 - i
+	}
+	if scan&0x20 != 0 { // columns are consecutive
+		return i*nj + j, nil
+	}
+	return j*ni + i, nil
+}
+
+// lambert is a spherical Lambert conformal conic projection.
+type lambert struct {
+	n, f, lov float64
+}
+
+func newLambert(latin1, latin2, lov float64) lambert {
+	p1, p2 := radians(latin1), radians(latin2)
+	var n float64
+	if math.Abs(p1-p2) < 1e-9 {
+		n = math.Sin(p1)
+	} else {
+		n = math.Log(math.Cos(p1)/math.Cos(p2)) /
+			math.Log(math.Tan(math.Pi/4+p2/2)/math.Tan(math.Pi/4+p1/2))
+	}
+	f := math.Cos(p1) * math.Pow(math.Tan(math.Pi/4+p1/2), n) / n
+	return lambert{n: n, f: f, lov: lov}
+}
+
+// project returns the position of lat/lon in metres, relative to the
+// cone's apex.
+func (p lambert) project(lat, lon float64) (x, y float64) {
+	rho := earthRadius * p.f / math.Pow(math.Tan(math.Pi/4+radians(lat)/2), p.n)
+	dLon := math.Mod(lon-p.lov+540, 360) - 180
+	theta := p.n * radians(dLon)
+	return rho * math.Sin(theta), -rho * math.Cos(theta)
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func be32(b []byte) uint32 { return binary.BigEndian.Uint32(b) }
+
+// micro decodes a GRIB2 sign-and-magnitude angle in millionths of a degree.
+func micro(b []byte) float64 {
+	v := be32(b)
+	deg := float64(v&0x7fffffff) / 1e6
+	if v&0x80000000 != 0 {
+		return -deg
+	}
+	return deg
+}
+
+// signed16 decodes a GRIB2 sign-and-magnitude 16-bit integer.
+func signed16(b []byte) int {
+	v := binary.BigEndian.Uint16(b)
+	if v&0x8000 != 0 {
+		return -int(v & 0x7fff)
+	}
+	return int(v)
+}
+
+// fillPercentiles returns every band in percentiles, filling the missing
+// ones from found as described on ParseGRIB2. found must not be empty.
+func fillPercentiles(found map[int]float64) map[int]decimal.Decimal {
+	out := make(map[int]decimal.Decimal, len(percentiles))
+	for _, p := range percentiles {
+		v, ok := found[p]
+		if !ok {
+			lo, hi := -1, -1
+			for _, q := range percentiles {
+				if _, ok := found[q]; !ok {
+					continue
+				}
+				if q < p {
+					lo = q
+				} else if hi < 0 {
+					hi = q
+				}
+			}
+			switch {
+			case lo < 0:
+				v = found[hi]
+			case hi < 0:
+				v = found[lo]
+			default:
+				t := float64(p-lo) / float64(hi-lo)
+				v = found[lo] + t*(found[hi]-found[lo])
+			}
+		}
+		out[p] = decimal.NewFromFloat(v).Round(valueScale)
+	}
+	return out
+}
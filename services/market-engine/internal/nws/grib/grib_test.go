@@ -0,0 +1,100 @@
+package grib_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/nws/grib"
+)
+
+// The fixtures are generated by testdata/gen_fixtures.py; see it for the
+// grids and values.
+
+func parseFixture(t *testing.T, name string, lat, lon float64) (contract.NWSForecastData, error) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return grib.ParseGRIB2(f, lat, lon)
+}
+
+func assertPercentiles(t *testing.T, got contract.NWSForecastData, want [5]string) {
+	t.Helper()
+	bands := [5]decimal.Decimal{got.Percentile10, got.Percentile25, got.Percentile50, got.Percentile75, got.Percentile90}
+	for i, name := range []string{"p10", "p25", "p50", "p75", "p90"} {
+		if !bands[i].Equal(decimal.RequireFromString(want[i])) {
+			t.Errorf("%s = %s, want %s", name, bands[i], want[i])
+		}
+	}
+}
+
+func TestParseGRIB2_Percentiles(t *testing.T) {
+	// 30.6N 91.1W is nearest grid point (2, 1), index 5. The p90 field's
+	// bitmap masks index 0, so its value is packed fifth, not sixth.
+	got, err := parseFixture(t, "qpf_percentiles.grib2", 30.6, -91.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPercentiles(t, got, [5]string{"1.05", "2.55", "5.05", "7.55", "9.05"})
+
+	// The north-west corner, 268E written as 92W.
+	got, err = parseFixture(t, "qpf_percentiles.grib2", 31.0, -92.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPercentiles(t, got, [5]string{"1", "2.5", "5", "7.5", "7.5"}) // p90 masked: takes p75
+}
+
+func TestParseGRIB2_MissingBands(t *testing.T) {
+	// Only p50, p75 and p90 are present, and p75 is masked at the point:
+	// p10 and p25 take the median, p75 is interpolated between p50 and p90.
+	got, err := parseFixture(t, "qpf_missing_bands.grib2", 30.6, -91.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPercentiles(t, got, [5]string{"5.05", "5.05", "5.05", "7.55", "9.05"})
+}
+
+func TestParseGRIB2_Lambert(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     string
+	}{
+		{"first point", 30.0, -94.0, "0"},
+		{"point (2, 1)", 30.022406, -93.947256, "0.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFixture(t, "qpf_lambert.grib2", tt.lat, tt.lon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertPercentiles(t, got, [5]string{tt.want, tt.want, tt.want, tt.want, tt.want})
+		})
+	}
+}
+
+func TestParseGRIB2_Errors(t *testing.T) {
+	if _, err := parseFixture(t, "qpf_percentiles.grib2", 40.0, -91.0); !errors.Is(err, grib.ErrOutsideGrid) {
+		t.Errorf("point north of grid: err = %v, want ErrOutsideGrid", err)
+	}
+	if _, err := grib.ParseGRIB2(bytes.NewReader(nil), 30.6, -91.1); !errors.Is(err, grib.ErrNoPercentiles) {
+		t.Errorf("empty input: err = %v, want ErrNoPercentiles", err)
+	}
+
+	data, err := os.ReadFile("testdata/qpf_percentiles.grib2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := grib.ParseGRIB2(bytes.NewReader(data[:len(data)-10]), 30.6, -91.1); err == nil {
+		t.Error("truncated message: expected an error")
+	}
+}
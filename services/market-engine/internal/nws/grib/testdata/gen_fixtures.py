@@ -0,0 +1,149 @@
+"""Generates the GRIB2 fixtures for grib_test.go.
+
+    python3 gen_fixtures.py
+
+Each fixture is a single GRIB2 message with simple-packed (5.0) QPF
+percentile fields (product template 4.10, discipline 0 / category 1 /
+parameter 8). Values are 16-bit with decimal scale 2, so they round-trip
+to two places.
+"""
+
+import math
+import struct
+
+R = 6371229.0
+
+
+def sm32(v):
+    """Sign-and-magnitude 32-bit integer."""
+    return struct.pack(">I", (0x80000000 | -v) if v < 0 else v)
+
+
+def micro(deg):
+    return sm32(int(round(deg * 1e6)))
+
+
+def section(num, body):
+    return struct.pack(">IB", 5 + len(body), num) + body
+
+
+def sec1():
+    # Centre 8 (NWS), ref time 2025-08-15 00Z, operational forecast.
+    return section(1, struct.pack(">HHBBBHBBBBBBB", 8, 0, 2, 1, 1, 2025, 8, 15, 0, 0, 0, 0, 1))
+
+
+def grid_latlon(ni, nj, la1, lo1, step, scan):
+    body = struct.pack(">B", 0) + struct.pack(">I", ni * nj) + struct.pack(">BB", 0, 0) + struct.pack(">H", 0)
+    body += struct.pack(">B", 6) + b"\x00" * 15  # shape of earth: sphere 6371229 m
+    body += struct.pack(">II", ni, nj) + struct.pack(">II", 0, 0xFFFFFFFF)
+    la2 = la1 - (nj - 1) * step if not scan & 0x40 else la1 + (nj - 1) * step
+    lo2 = lo1 + (ni - 1) * step
+    body += micro(la1) + micro(lo1) + struct.pack(">B", 0x30) + micro(la2) + micro(lo2)
+    body += struct.pack(">II", int(step * 1e6), int(step * 1e6)) + struct.pack(">B", scan)
+    return section(3, body)
+
+
+def grid_lambert(nx, ny, la1, lo1, lov, latin, d, scan):
+    body = struct.pack(">B", 0) + struct.pack(">I", nx * ny) + struct.pack(">BB", 0, 0) + struct.pack(">H", 30)
+    body += struct.pack(">B", 6) + b"\x00" * 15
+    body += struct.pack(">II", nx, ny) + micro(la1) + micro(lo1) + struct.pack(">B", 0x30)
+    body += micro(latin) + micro(lov) + struct.pack(">II", int(d * 1e3), int(d * 1e3))
+    body += struct.pack(">BB", 0, scan) + micro(latin) + micro(latin) + micro(-90) + micro(0)
+    return section(3, body)
+
+
+def product(category, parameter, pct):
+    # Template 4.10: octets 10-34 as 4.0, then the percentile and a
+    # one-interval statistical block (24 h accumulation).
+    body = struct.pack(">HH", 0, 10)
+    body += struct.pack(">BBBBBHBBI", category, parameter, 2, 0, 0, 0, 0, 1, 0)
+    body += struct.pack(">BBIBBI", 1, 0, 0, 255, 0, 0)
+    body += struct.pack(">B", pct)
+    body += struct.pack(">HBBBBBBIB", 2025, 8, 16, 0, 0, 0, 1, 0, 1)
+    body += struct.pack(">BBBIBI", 1, 2, 1, 24, 255, 0)
+    return section(4, body)
+
+
+def packed(values):
+    """Sections 5, 6 (given bitmap or none) and 7 for values (None = masked)."""
+    present = [v for v in values if v is not None]
+    repr_ = struct.pack(">IH", len(present), 0) + struct.pack(">f", 0.0)
+    repr_ += struct.pack(">HH", 0, 2) + struct.pack(">BB", 16, 0)
+    if any(v is None for v in values):
+        bits = 0
+        bitmap = bytearray((len(values) + 7) // 8)
+        for i, v in enumerate(values):
+            if v is not None:
+                bitmap[i // 8] |= 0x80 >> (i % 8)
+        s6 = section(6, b"\x00" + bytes(bitmap))
+    else:
+        s6 = section(6, b"\xff")
+    data = b"".join(struct.pack(">H", int(round(v * 100))) for v in present)
+    return section(5, repr_) + s6 + section(7, data)
+
+
+def message(grid, fields):
+    body = sec1() + grid
+    for category, parameter, pct, values in fields:
+        body += product(category, parameter, pct) + packed(values)
+    body += b"7777"
+    return b"GRIB" + struct.pack(">HBBQ", 0, 0, 2, 16 + len(body)) + body
+
+
+def band(pct, n=9):
+    # Value at index i: pct/10 + i/100, e.g. p25 at index 5 is 2.55.
+    return [pct / 10 + i / 100 for i in range(n)]
+
+
+# 3x3 lat/lon grid, 31N..30N, 92W..91W (268E..269E), scanning north→south.
+latlon = grid_latlon(3, 3, 31.0, 268.0, 0.5, 0x00)
+
+p90 = band(90)
+p90[0] = None  # masked elsewhere: shifts the packed index of later points
+full = message(latlon, [
+    (0, 0, 50, band(99)),  # temperature: not QPF, skipped
+    (1, 8, 10, band(10)),
+    (1, 8, 25, band(25)),
+    (1, 8, 50, band(50)),
+    (1, 8, 75, band(75)),
+    (1, 8, 90, p90),
+])
+
+p75 = band(75)
+p75[5] = None  # masked at the test point: counts as missing
+missing = message(latlon, [
+    (1, 8, 50, band(50)),
+    (1, 8, 75, p75),
+    (1, 8, 90, band(90)),
+])
+
+
+def lcc(lat, lon, latin, lov):
+    n = math.sin(math.radians(latin))
+    f = math.cos(math.radians(latin)) * math.tan(math.pi / 4 + math.radians(latin) / 2) ** n / n
+    rho = R * f / math.tan(math.pi / 4 + math.radians(lat) / 2) ** n
+    theta = n * math.radians(lon - lov)
+    return rho * math.sin(theta), -rho * math.cos(theta)
+
+
+# 4x3 Lambert grid at 2.5 km, NDFD's projection parameters, scanning
+# south→north.
+lambert = message(grid_lambert(4, 3, 30.0, 266.0, 265.0, 25.0, 2539.703, 0x40),
+                  [(1, 8, 50, [i / 10 for i in range(12)])])
+
+with open("qpf_percentiles.grib2", "wb") as f:
+    f.write(full)
+with open("qpf_missing_bands.grib2", "wb") as f:
+    f.write(b"\x00" * 8 + missing)  # leading padding before "GRIB"
+with open("qpf_lambert.grib2", "wb") as f:
+    f.write(lambert)
+
+# Print the lat/lon of Lambert grid point (2, 1) for the test.
+x0, y0 = lcc(30.0, 266.0, 25.0, 265.0)
+x, y = x0 + 2 * 2539.703, y0 + 1 * 2539.703
+n = math.sin(math.radians(25.0))
+f = math.cos(math.radians(25.0)) * math.tan(math.pi / 4 + math.radians(25.0) / 2) ** n / n
+rho = math.hypot(x, y)
+lat = math.degrees(2 * math.atan((R * f / rho) ** (1 / n)) - math.pi / 2)
+lon = 265.0 + math.degrees(math.atan2(x, -y)) / n
+print("lambert point (2,1):", round(lat, 6), round(lon - 360, 6))
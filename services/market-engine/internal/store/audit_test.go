@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testAuditLog checks audit events round-trip and are listed newest
+// first, filtered by target and capped at limit.
+func testAuditLog(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	target := "market-" + uuid.New().String()
+	start := time.Now().UTC().Truncate(time.Microsecond)
+
+	actions := []string{"settle", "unsettle", "settle"}
+	for i, action := range actions {
+		e := &model.AuditEvent{
+			ID:        uuid.New().String(),
+			Actor:     "ops",
+			Action:    action,
+			Target:    target,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+		}
+		if i == 0 {
+			e.Params = map[string]string{"outcome": "NO"}
+		}
+		if err := st.InsertAuditEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.InsertAuditEvent(ctx, &model.AuditEvent{
+		ID: uuid.New().String(), Actor: "ops", Action: "hide", Target: "other-" + target, Timestamp: start,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := st.ListAuditEvents(ctx, target, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events for %s, want 3", len(events), target)
+	}
+	for i, e := range events {
+		want := start.Add(time.Duration(2-i) * time.Second)
+		if e.Target != target || e.Action != actions[2-i] || !e.Timestamp.Equal(want) {
+			t.Errorf("events[%d] = %+v, want %s at %s", i, e, actions[2-i], want)
+		}
+	}
+	if first := events[2]; first.Actor != "ops" || first.Params["outcome"] != "NO" {
+		t.Errorf("oldest event = %+v, want actor ops and outcome NO", first)
+	}
+
+	if events, err := st.ListAuditEvents(ctx, target, 1); err != nil || len(events) != 1 || events[0].Action != "settle" {
+		t.Errorf("limit 1: %+v, %v; want the latest settle", events, err)
+	}
+	if events, err := st.ListAuditEvents(ctx, "", 100); err != nil || len(events) < 4 {
+		t.Errorf("every target: %d events, %v; want at least 4", len(events), err)
+	}
+}
+
+func TestMemoryStore_AuditLog(t *testing.T) {
+	testAuditLog(t, NewMemoryStore())
+}
+
+// TestPostgresStore_AuditLog runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_AuditLog(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testAuditLog(t, NewPostgresStore(pool))
+}
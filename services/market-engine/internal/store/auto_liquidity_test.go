@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testAutoLiquidityRoundTrip checks the auto-liquidity flag survives
+// creation and a liquidity change.
+func testAutoLiquidityRoundTrip(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	m := &model.Market{
+		ID:            uuid.New().String(),
+		ContractID:    "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+		H3CellID:      "872a1070b",
+		B:             d(100),
+		PriceYes:      d(0.5),
+		PriceNo:       d(0.5),
+		Status:        "open",
+		CreatedAt:     time.Now().UTC(),
+		AutoLiquidity: true,
+	}
+	if err := st.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	change := &model.LiquidityChange{MarketID: m.ID, OldB: d(100), NewB: d(110), ChangedAt: time.Now().UTC()}
+	if err := st.UpdateLiquidity(ctx, change, d(0.5), d(0.5)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.GetMarket(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.AutoLiquidity || !got.B.Equal(d(110)) {
+		t.Errorf("auto_liquidity = %v, b = %s; want true, 110", got.AutoLiquidity, got.B)
+	}
+}
+
+func TestMemoryStore_AutoLiquidityRoundTrip(t *testing.T) {
+	testAutoLiquidityRoundTrip(t, NewMemoryStore())
+}
+
+// TestPostgresStore_AutoLiquidityRoundTrip runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_AutoLiquidityRoundTrip(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testAutoLiquidityRoundTrip(t, NewPostgresStore(pool))
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ChainBreak is one place where a market's ledger fails hash chain
+// verification.
+type ChainBreak struct {
+	EntryID string `json:"entry_id"`
+	Reason  string `json:"reason"`
+}
+
+// ChainReport is the result of verifying one market's ledger hash chain.
+type ChainReport struct {
+	OK        bool         `json:"ok"`
+	Entries   int          `json:"entries"`   // chained entries checked
+	Unchained int          `json:"unchained"` // written before chaining, not covered
+	Head      string       `json:"head"`      // hash the chain ends at
+	Breaks    []ChainBreak `json:"breaks,omitempty"`
+}
+
+// HashLedgerEntry returns e's chain hash: hex SHA-256 over its fields and
+// e.PrevHash. UserID is left out so that AnonymizeUser doesn't break the
+// chain, and Metadata because it is only the caller's tags; everything
+// that affects the market or a position's value is in.
+func HashLedgerEntry(e model.LedgerEntry) string {
+	kind := e.Kind
+	if kind == "" {
+		kind = model.EntryKindTrade
+	}
+	// Decimals in canonical form and time at microsecond precision, so an
+	// entry hashes the same after a round trip through PostgreSQL.
+	fields := []any{
+		e.ID, e.MarketID, e.ContractID, e.Side,
+		e.Quantity.String(), e.Price.String(), e.Cost.String(),
+		e.Timestamp.UnixMicro(), e.RealizedPnL.String(), kind,
+		e.PrevHash,
+	}
+	// Only reversals name another entry; leaving the field out otherwise
+	// keeps the hashes of entries written before it existed.
+	if e.Reverses != "" {
+		fields = append(fields, e.Reverses)
+	}
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainEntry links e to the market's chain at head, setting its PrevHash
+// and Hash, and returns the new head. The timestamp is truncated to what
+// PostgreSQL stores, which would otherwise round it.
+func chainEntry(e *model.LedgerEntry, head string) string {
+	e.Timestamp = e.Timestamp.Truncate(time.Microsecond)
+	e.PrevHash = head
+	e.Hash = HashLedgerEntry(*e)
+	return e.Hash
+}
+
+// chainHeads returns the hash each market's chain ends at in entries: the
+// hash no other entry of the market names as its PrevHash. If tampering
+// leaves several, the last in entries wins.
+func chainHeads(entries []model.LedgerEntry) map[string]string {
+	linked := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.PrevHash != "" {
+			linked[e.MarketID+"/"+e.PrevHash] = true
+		}
+	}
+	heads := make(map[string]string)
+	for _, e := range entries {
+		if e.Hash != "" && !linked[e.MarketID+"/"+e.Hash] {
+			heads[e.MarketID] = e.Hash
+		}
+	}
+	return heads
+}
+
+// verifyLedgerChain checks one market's entries against its chain and
+// the head the store recorded for it. Every chained entry must hash to its
+// Hash and be reached by following PrevHash links from the first entry to
+// head, with no forks. Entries without a hash predate chaining and are
+// only accepted before the chain starts.
+func verifyLedgerChain(entries []model.LedgerEntry, head string) *ChainReport {
+	report := &ChainReport{}
+	broken := func(id, reason string, args ...any) {
+		report.Breaks = append(report.Breaks, ChainBreak{EntryID: id, Reason: fmt.Sprintf(reason, args...)})
+	}
+
+	next := make(map[string][]model.LedgerEntry)
+	var chained []model.LedgerEntry
+	for _, e := range entries {
+		if e.Hash == "" {
+			report.Unchained++
+			continue
+		}
+		chained = append(chained, e)
+		next[e.PrevHash] = append(next[e.PrevHash], e)
+		if got := HashLedgerEntry(e); got != e.Hash {
+			broken(e.ID, "contents do not match hash %s", e.Hash)
+		}
+	}
+	report.Entries = len(chained)
+
+	// Follow the links from the genesis entry, whose PrevHash is empty.
+	reached := make(map[string]bool, len(chained))
+	var genesis *model.LedgerEntry
+	for cur := ""; ; {
+		following := next[cur]
+		if len(following) == 0 {
+			break
+		}
+		for _, fork := range following[1:] {
+			broken(fork.ID, "forks the chain after %q", cur)
+			reached[fork.ID] = true
+		}
+		e := following[0]
+		if reached[e.ID] {
+			broken(e.ID, "chain loops back to this entry")
+			break
+		}
+		reached[e.ID] = true
+		if genesis == nil {
+			genesis = &e
+		}
+		report.Head = e.Hash
+		cur = e.Hash
+	}
+	for _, e := range chained {
+		if !reached[e.ID] {
+			broken(e.ID, "not reachable from the start of the chain (prev_hash %q)", e.PrevHash)
+		}
+	}
+	if report.Head != head {
+		broken("", "chain ends at %q but the market's head is %q", report.Head, head)
+	}
+	if genesis != nil {
+		for _, e := range entries {
+			if e.Hash == "" && e.Timestamp.After(genesis.Timestamp) {
+				broken(e.ID, "unchained entry written after chaining began")
+			}
+		}
+	}
+
+	report.OK = len(report.Breaks) == 0
+	return report
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testCountMarketsByCreator checks only the creator's open, visible
+// markets are counted, and that created_by round-trips.
+func testCountMarketsByCreator(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	creator := "creator-" + uuid.New().String()
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		m := &model.Market{
+			ID:         uuid.New().String(),
+			ContractID: "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+			H3CellID:   "872a1070b",
+			B:          d(100),
+			PriceYes:   d(0.5),
+			PriceNo:    d(0.5),
+			Status:     "open",
+			CreatedAt:  time.Now().UTC(),
+			CreatedBy:  creator,
+		}
+		if err := st.CreateMarket(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, m.ID)
+	}
+	if err := st.SettleMarket(ctx, ids[0], "NO", time.Now().UTC(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.HideMarket(ctx, ids[1], time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := st.CountMarketsByCreator(ctx, creator); err != nil || n != 2 {
+		t.Errorf("CountMarketsByCreator = %d, %v; want 2", n, err)
+	}
+	if n, err := st.CountMarketsByCreator(ctx, "someone-else"); err != nil || n != 0 {
+		t.Errorf("another user: %d, %v; want 0", n, err)
+	}
+	got, err := st.GetMarket(ctx, ids[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CreatedBy != creator {
+		t.Errorf("created_by = %q, want %q", got.CreatedBy, creator)
+	}
+}
+
+func TestMemoryStore_CountMarketsByCreator(t *testing.T) {
+	testCountMarketsByCreator(t, NewMemoryStore())
+}
+
+// TestPostgresStore_CountMarketsByCreator runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_CountMarketsByCreator(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testCountMarketsByCreator(t, NewPostgresStore(pool))
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testInitialQuantitiesRoundTrip creates a market seeded to open at a
+// prior price and checks the seed survives trades that move QYes/QNo.
+func testInitialQuantitiesRoundTrip(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	m := &model.Market{
+		ID:          uuid.New().String(),
+		ContractID:  "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+		H3CellID:    "872a1070b",
+		QYes:        d(84.72978603),
+		B:           d(100),
+		PriceYes:    d(0.7),
+		PriceNo:     d(0.3),
+		Status:      "open",
+		CreatedAt:   time.Now().UTC(),
+		InitialQYes: d(84.72978603),
+	}
+	if err := st.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateMarketState(ctx, m.ID, d(94.72978603), d(5), d(0.71), d(0.29)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.GetMarket(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.InitialQYes.Equal(d(84.72978603)) || !got.InitialQNo.IsZero() {
+		t.Errorf("seed = (%s,%s), want (84.72978603,0)", got.InitialQYes, got.InitialQNo)
+	}
+	if yes, no := got.TradedQuantities(); !yes.Equal(d(10)) || !no.Equal(d(5)) {
+		t.Errorf("traded = (%s,%s), want (10,5)", yes, no)
+	}
+}
+
+func TestMemoryStore_InitialQuantitiesRoundTrip(t *testing.T) {
+	testInitialQuantitiesRoundTrip(t, NewMemoryStore())
+}
+
+// TestPostgresStore_InitialQuantitiesRoundTrip runs against the database
+// in TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_InitialQuantitiesRoundTrip(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testInitialQuantitiesRoundTrip(t, NewPostgresStore(pool))
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"maps"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testLedgerMetadataRoundTrip writes one tagged and one untagged entry
+// and checks both read back as written, with the chain intact.
+func testLedgerMetadataRoundTrip(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	m := &model.Market{
+		ID:         uuid.New().String(),
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+		H3CellID:   "872a1070b",
+		B:          d(100),
+		PriceYes:   d(0.5),
+		PriceNo:    d(0.5),
+		Status:     "open",
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := st.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := map[string]string{"source": "bot", "strategy": "s-42"}
+	now := time.Now().UTC()
+	entries := []*model.LedgerEntry{
+		{ID: uuid.New().String(), UserID: "user1", MarketID: m.ID, ContractID: m.ContractID, Side: "YES",
+			Quantity: d(10), Price: d(0.51), Cost: d(5.12), Timestamp: now, Metadata: tags},
+		{ID: uuid.New().String(), UserID: "user1", MarketID: m.ID, ContractID: m.ContractID, Side: "NO",
+			Quantity: d(5), Price: d(0.49), Cost: d(2.46), Timestamp: now.Add(time.Millisecond)},
+	}
+	if err := st.ApplyTrade(ctx, entries, m.ID, d(10), d(5), d(0.51), d(0.49), d(0), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.GetLedgerEntriesByMarket(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if !maps.Equal(got[0].Metadata, tags) {
+		t.Errorf("metadata = %v, want %v", got[0].Metadata, tags)
+	}
+	if got[1].Metadata != nil {
+		t.Errorf("untagged entry metadata = %v, want nil", got[1].Metadata)
+	}
+
+	rep, err := st.VerifyChain(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK {
+		t.Errorf("chain broken by metadata: %+v", rep.Breaks)
+	}
+}
+
+func TestMemoryStore_LedgerMetadataRoundTrip(t *testing.T) {
+	testLedgerMetadataRoundTrip(t, NewMemoryStore())
+}
+
+// TestPostgresStore_LedgerMetadataRoundTrip runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_LedgerMetadataRoundTrip(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testLedgerMetadataRoundTrip(t, NewPostgresStore(pool))
+}
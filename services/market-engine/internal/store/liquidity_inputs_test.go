@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testLiquidityInputsRoundTrip creates a forecast-derived market, checks
+// its inputs read back intact, then replaces them with a liquidity change.
+func testLiquidityInputsRoundTrip(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	inputs := &model.LiquidityInputs{
+		Percentile10: d(2), Percentile25: d(10), Percentile50: d(25),
+		Percentile75: d(40), Percentile90: d(61.5), BaseVolume: d(100),
+	}
+	m := &model.Market{
+		ID:              uuid.New().String(),
+		ContractID:      "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+		H3CellID:        "872a1070b",
+		B:               d(120),
+		PriceYes:        d(0.5),
+		PriceNo:         d(0.5),
+		Status:          "open",
+		CreatedAt:       time.Now().UTC(),
+		LiquidityInputs: inputs,
+	}
+	if err := st.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.GetMarket(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalInputs(got.LiquidityInputs, inputs) {
+		t.Fatalf("inputs = %+v, want %+v", got.LiquidityInputs, inputs)
+	}
+
+	// A change without inputs (b set directly) clears them.
+	change := &model.LiquidityChange{MarketID: m.ID, OldB: d(120), NewB: d(80), ChangedAt: time.Now().UTC()}
+	if err := st.UpdateLiquidity(ctx, change, d(0.5), d(0.5)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = st.GetMarket(ctx, m.ID); got.LiquidityInputs != nil {
+		t.Errorf("inputs = %+v after a manual change, want nil", got.LiquidityInputs)
+	}
+
+	// A forecast-derived change records its inputs on the market and the
+	// change.
+	next := &model.LiquidityInputs{Percentile25: d(5), Percentile50: d(20), Percentile75: d(35), BaseVolume: d(50)}
+	change = &model.LiquidityChange{MarketID: m.ID, OldB: d(80), NewB: d(75), ChangedAt: time.Now().UTC(), Inputs: next}
+	if err := st.UpdateLiquidity(ctx, change, d(0.5), d(0.5)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = st.GetMarket(ctx, m.ID); !equalInputs(got.LiquidityInputs, next) {
+		t.Errorf("inputs = %+v, want %+v", got.LiquidityInputs, next)
+	}
+	changes, err := st.GetLiquidityChanges(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Inputs != nil || !equalInputs(changes[1].Inputs, next) {
+		t.Errorf("changes = %+v, want the second to carry %+v", changes, next)
+	}
+}
+
+func equalInputs(a, b *model.LiquidityInputs) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Percentile10.Equal(b.Percentile10) && a.Percentile25.Equal(b.Percentile25) &&
+		a.Percentile50.Equal(b.Percentile50) && a.Percentile75.Equal(b.Percentile75) &&
+		a.Percentile90.Equal(b.Percentile90) && a.BaseVolume.Equal(b.BaseVolume)
+}
+
+func TestMemoryStore_LiquidityInputsRoundTrip(t *testing.T) {
+	testLiquidityInputsRoundTrip(t, NewMemoryStore())
+}
+
+// TestPostgresStore_LiquidityInputsRoundTrip runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_LiquidityInputsRoundTrip(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testLiquidityInputsRoundTrip(t, NewPostgresStore(pool))
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testListMarketsStableOrder creates markets sharing a creation time,
+// interleaved with older and newer ones, and checks that ListMarkets
+// returns them newest first with ties by ID, identically on every call.
+func testListMarketsStableOrder(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	// IDs are UUIDs (the Postgres column type) sharing a per-run prefix;
+	// the last group orders the tied markets a < b < c < d.
+	prefix := fmt.Sprintf("%08x-0000-4000-8000-", uint32(time.Now().UnixNano()))
+	tied := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	// Insertion order differs from the expected order.
+	specs := []struct {
+		id      string
+		created time.Time
+	}{
+		{"c", tied}, {"old", tied.Add(-time.Hour)}, {"a", tied},
+		{"new", tied.Add(time.Hour)}, {"d", tied}, {"b", tied},
+	}
+	labels := map[string]string{
+		"a": "000000000001", "b": "000000000002", "c": "000000000003",
+		"d": "000000000004", "old": "000000000005", "new": "000000000006",
+	}
+	byID := make(map[string]string, len(labels))
+	for label, suffix := range labels {
+		byID[prefix+suffix] = label
+	}
+	for i, sp := range specs {
+		m := &model.Market{
+			ID:         prefix + labels[sp.id],
+			ContractID: fmt.Sprintf("ATMX-872a1070b-PRECIP-%dMM-%s", i+1, prefix[:8]),
+			H3CellID:   "872a1070b",
+			B:          d(100),
+			PriceYes:   d(0.5),
+			PriceNo:    d(0.5),
+			Status:     "open",
+			CreatedAt:  sp.created,
+		}
+		if err := st.CreateMarket(ctx, m); err != nil {
+			t.Fatalf("create %s: %v", sp.id, err)
+		}
+	}
+
+	ours := func() []string {
+		markets, err := st.ListMarkets(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, m := range markets {
+			if label, ok := byID[m.ID]; ok {
+				ids = append(ids, label)
+			}
+		}
+		return ids
+	}
+
+	want := fmt.Sprint([]string{"new", "a", "b", "c", "d", "old"})
+	for i := 0; i < 20; i++ {
+		if got := fmt.Sprint(ours()); got != want {
+			t.Fatalf("call %d: order %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestMemoryStore_ListMarketsStableOrder(t *testing.T) {
+	testListMarketsStableOrder(t, NewMemoryStore())
+}
+
+// TestPostgresStore_ListMarketsStableOrder runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_ListMarketsStableOrder(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testListMarketsStableOrder(t, NewPostgresStore(pool))
+}
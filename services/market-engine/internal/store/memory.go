@@ -3,34 +3,69 @@ package store
 import (
 	"context"
 	"fmt"
+	"maps"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/atmx/market-engine/internal/contract"
 	"github.com/atmx/market-engine/internal/model"
 	"github.com/shopspring/decimal"
 )
 
 // MemoryStore implements Store with in-memory maps. Used for testing
 // and development. Not suitable for production (no persistence).
+//
+// Every method checks ctx at entry so a cancelled or timed-out request
+// returns the context error instead of doing work, matching the pgx-backed
+// store's behaviour.
 type MemoryStore struct {
-	mu      sync.RWMutex
-	markets map[string]*model.Market
-	ledger  []model.LedgerEntry
+	mu        sync.RWMutex
+	markets   map[string]*model.Market
+	ledger    []model.LedgerEntry
+	ledgerIDs map[string]struct{}
+
+	observations     map[string]model.Observation // keyed by observationKey
+	liquidityChanges map[string][]model.LiquidityChange
+	products         map[string]*model.Product
+	auditLog         []model.AuditEvent // insertion order
+
+	// positions indexes the ledger by user, updated as entries are
+	// appended, so position reads don't scan the whole ledger.
+	positions map[string]*userPositions
+
+	// heads holds the hash each market's ledger chain ends at.
+	heads map[string]string
 }
 
 // NewMemoryStore creates a new in-memory store.
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		markets: make(map[string]*model.Market),
+		markets:   make(map[string]*model.Market),
+		ledgerIDs: make(map[string]struct{}),
+
+		observations:     make(map[string]model.Observation),
+		liquidityChanges: make(map[string][]model.LiquidityChange),
+		products:         make(map[string]*model.Product),
+
+		positions: make(map[string]*userPositions),
+
+		heads: make(map[string]string),
 	}
 }
 
-func (s *MemoryStore) CreateMarket(_ context.Context, m *model.Market) error {
+func (s *MemoryStore) CreateMarket(ctx context.Context, m *model.Market) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for _, existing := range s.markets {
 		if existing.ContractID == m.ContractID {
-			return fmt.Errorf("market for contract %s already exists", m.ContractID)
+			return fmt.Errorf("%w: market for contract %s", ErrAlreadyExists, m.ContractID)
 		}
 	}
 
@@ -40,19 +75,27 @@ func (s *MemoryStore) CreateMarket(_ context.Context, m *model.Market) error {
 	return nil
 }
 
-func (s *MemoryStore) GetMarket(_ context.Context, id string) (*model.Market, error) {
+func (s *MemoryStore) GetMarket(ctx context.Context, id string) (*model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	m, ok := s.markets[id]
 	if !ok {
-		return nil, fmt.Errorf("market %s not found", id)
+		return nil, fmt.Errorf("%w: market %s", ErrNotFound, id)
 	}
 	copy := *m
 	return &copy, nil
 }
 
-func (s *MemoryStore) GetMarketByContract(_ context.Context, contractID string) (*model.Market, error) {
+func (s *MemoryStore) GetMarketByContract(ctx context.Context, contractID string) (*model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -62,27 +105,194 @@ func (s *MemoryStore) GetMarketByContract(_ context.Context, contractID string)
 			return &copy, nil
 		}
 	}
-	return nil, fmt.Errorf("market for contract %s not found", contractID)
+	return nil, fmt.Errorf("%w: market for contract %s", ErrNotFound, contractID)
 }
 
-func (s *MemoryStore) ListMarkets(_ context.Context) ([]model.Market, error) {
+func (s *MemoryStore) GetMarketsByContracts(ctx context.Context, contractIDs []string) ([]model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(contractIDs))
+	for _, id := range contractIDs {
+		wanted[id] = true
+	}
+	var markets []model.Market
+	for _, m := range s.markets {
+		if wanted[m.ContractID] {
+			markets = append(markets, *m)
+		}
+	}
+	return markets, nil
+}
+
+func (s *MemoryStore) ListMarkets(ctx context.Context) ([]model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	markets := make([]model.Market, 0, len(s.markets))
 	for _, m := range s.markets {
+		if m.HiddenAt != nil {
+			continue
+		}
+		markets = append(markets, *m)
+	}
+	// Same ordering as the Postgres query.
+	sort.Slice(markets, func(i, j int) bool {
+		a, b := markets[i], markets[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return markets, nil
+}
+
+func (s *MemoryStore) ListRecentlyTradedMarkets(ctx context.Context, limit int) ([]model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lastTrade := make(map[string]time.Time)
+	for _, e := range s.ledger {
+		if e.Timestamp.After(lastTrade[e.MarketID]) {
+			lastTrade[e.MarketID] = e.Timestamp
+		}
+	}
+
+	markets := make([]model.Market, 0, len(lastTrade))
+	for id := range lastTrade {
+		if m, ok := s.markets[id]; ok {
+			markets = append(markets, *m)
+		}
+	}
+	sort.Slice(markets, func(i, j int) bool {
+		return lastTrade[markets[i].ID].After(lastTrade[markets[j].ID])
+	})
+	if len(markets) > limit {
+		markets = markets[:limit]
+	}
+	return markets, nil
+}
+
+func (s *MemoryStore) SearchMarkets(ctx context.Context, f MarketFilter) ([]model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var markets []model.Market
+	for _, m := range s.markets {
+		if f.Status != "" && m.Status != f.Status {
+			continue
+		}
+		if m.HiddenAt != nil && !f.IncludeHidden {
+			continue
+		}
+		if !strings.HasPrefix(m.H3CellID, f.H3Prefix) {
+			continue
+		}
+		if f.Type != "" || !f.ExpiryBefore.IsZero() {
+			c, err := contract.ParseTicker(m.ContractID)
+			if err != nil {
+				continue
+			}
+			if f.Type != "" && c.Type != f.Type {
+				continue
+			}
+			if !f.ExpiryBefore.IsZero() && !c.ExpiryDate.Before(f.ExpiryBefore) {
+				continue
+			}
+		}
 		markets = append(markets, *m)
 	}
+	sort.Slice(markets, func(i, j int) bool {
+		if !markets[i].CreatedAt.Equal(markets[j].CreatedAt) {
+			return markets[i].CreatedAt.After(markets[j].CreatedAt)
+		}
+		return markets[i].ID < markets[j].ID
+	})
 	return markets, nil
 }
 
-func (s *MemoryStore) UpdateMarketState(_ context.Context, id string, qYes, qNo, priceYes, priceNo decimal.Decimal) error {
+func (s *MemoryStore) CountMarketsByCreator(ctx context.Context, userID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, m := range s.markets {
+		if m.CreatedBy == userID && m.Status == "open" && m.HiddenAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) ListOpenCells(ctx context.Context, prefix string) ([]model.CellSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byCell := make(map[string]*model.CellSummary)
+	for _, m := range s.markets {
+		if m.Status != "open" || m.HiddenAt != nil || !strings.HasPrefix(m.H3CellID, prefix) {
+			continue
+		}
+		c, ok := byCell[m.H3CellID]
+		if !ok {
+			c = &model.CellSummary{H3CellID: m.H3CellID}
+			byCell[m.H3CellID] = c
+		}
+		c.MarketCount++
+	}
+	for _, e := range s.ledger {
+		m := s.markets[e.MarketID]
+		if m == nil {
+			continue
+		}
+		if c, ok := byCell[m.H3CellID]; ok && m.Status == "open" {
+			c.Volume = c.Volume.Add(e.Quantity.Abs())
+		}
+	}
+
+	cells := make([]model.CellSummary, 0, len(byCell))
+	for _, c := range byCell {
+		cells = append(cells, *c)
+	}
+	sort.Slice(cells, func(i, j int) bool { return cells[i].H3CellID < cells[j].H3CellID })
+	return cells, nil
+}
+
+func (s *MemoryStore) UpdateMarketState(ctx context.Context, id string, qYes, qNo, priceYes, priceNo decimal.Decimal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	m, ok := s.markets[id]
 	if !ok {
-		return fmt.Errorf("market %s not found", id)
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
 	}
 	m.QYes = qYes
 	m.QNo = qNo
@@ -91,15 +301,323 @@ func (s *MemoryStore) UpdateMarketState(_ context.Context, id string, qYes, qNo,
 	return nil
 }
 
-func (s *MemoryStore) InsertLedgerEntry(_ context.Context, entry *model.LedgerEntry) error {
+func (s *MemoryStore) ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.markets[id]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	if err := s.checkLedgerIDs(entries); err != nil {
+		return err
+	}
+	s.appendLedger(entries)
+	if change != nil {
+		s.recordLiquidityChange(m, change)
+	}
+	m.QYes = qYes
+	m.QNo = qNo
+	m.PriceYes = priceYes
+	m.PriceNo = priceNo
+	m.Collected = collected
+	return nil
+}
+
+func (s *MemoryStore) UpdateTradeSizeLimits(ctx context.Context, id string, min, max *decimal.Decimal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.markets[id]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	// Copy the values so callers can't mutate stored state via the pointers.
+	m.MinQuantity, m.MaxQuantity = nil, nil
+	if min != nil {
+		v := *min
+		m.MinQuantity = &v
+	}
+	if max != nil {
+		v := *max
+		m.MaxQuantity = &v
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateLiquidity(ctx context.Context, change *model.LiquidityChange, priceYes, priceNo decimal.Decimal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.markets[change.MarketID]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, change.MarketID)
+	}
+	s.recordLiquidityChange(m, change)
+	m.PriceYes = priceYes
+	m.PriceNo = priceNo
+	return nil
+}
+
+// recordLiquidityChange sets m's b and liquidity inputs from change and
+// appends it to the market's history. Callers hold s.mu for writing.
+func (s *MemoryStore) recordLiquidityChange(m *model.Market, change *model.LiquidityChange) {
+	m.B = change.NewB
+	m.LiquidityInputs = change.Inputs
+	s.liquidityChanges[m.ID] = append(s.liquidityChanges[m.ID], *change)
+}
+
+func (s *MemoryStore) GetLiquidityChanges(ctx context.Context, marketID string) ([]model.LiquidityChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := make([]model.LiquidityChange, len(s.liquidityChanges[marketID]))
+	copy(changes, s.liquidityChanges[marketID])
+	return changes, nil
+}
+
+func (s *MemoryStore) SettleMarket(ctx context.Context, id, outcome string, settledAt time.Time, entries []*model.LedgerEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.markets[id]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	if m.Status != "open" {
+		return fmt.Errorf("%w: %s", ErrMarketNotOpen, id)
+	}
+	if err := s.checkLedgerIDs(entries); err != nil {
+		return err
+	}
+	s.appendLedger(entries)
+	m.Status = "settled"
+	m.Outcome = &outcome
+	m.SettledAt = &settledAt
+	return nil
+}
+
+func (s *MemoryStore) UnsettleMarket(ctx context.Context, id string, entries []*model.LedgerEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.markets[id]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	if m.Status != "settled" {
+		return fmt.Errorf("%w: %s", ErrMarketNotSettled, id)
+	}
+	if err := s.checkLedgerIDs(entries); err != nil {
+		return err
+	}
+	s.appendLedger(entries)
+	m.Status = "open"
+	m.Outcome = nil
+	m.SettledAt = nil
+	return nil
+}
+
+func (s *MemoryStore) HideMarket(ctx context.Context, id string, hiddenAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.markets[id]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	if m.HiddenAt == nil {
+		m.HiddenAt = &hiddenAt
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateTradingHours(ctx context.Context, id string, open, close *string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.ledger = append(s.ledger, *entry)
+	m, ok := s.markets[id]
+	if !ok {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	// Copy the values so callers can't mutate stored state via the pointers.
+	m.TradingOpen, m.TradingClose = nil, nil
+	if open != nil {
+		o := *open
+		m.TradingOpen = &o
+	}
+	if close != nil {
+		c := *close
+		m.TradingClose = &c
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateProduct(ctx context.Context, p *model.Product, markets []*model.Market) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.products[p.ID]; ok {
+		return fmt.Errorf("%w: product %s", ErrAlreadyExists, p.ID)
+	}
+	contracts := make(map[string]bool, len(s.markets)+len(markets))
+	for _, existing := range s.markets {
+		contracts[existing.ContractID] = true
+	}
+	for _, m := range markets {
+		if contracts[m.ContractID] {
+			return fmt.Errorf("%w: market for contract %s", ErrAlreadyExists, m.ContractID)
+		}
+		contracts[m.ContractID] = true
+	}
+
+	copy := *p
+	copy.Cells = append([]string(nil), p.Cells...)
+	s.products[p.ID] = &copy
+	for _, m := range markets {
+		mc := *m
+		s.markets[m.ID] = &mc
+	}
 	return nil
 }
 
-func (s *MemoryStore) GetLedgerEntriesByMarket(_ context.Context, marketID string) ([]model.LedgerEntry, error) {
+func (s *MemoryStore) GetProduct(ctx context.Context, id string) (*model.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.products[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: product %s", ErrNotFound, id)
+	}
+	copy := *p
+	copy.Cells = append([]string(nil), p.Cells...)
+	return &copy, nil
+}
+
+func (s *MemoryStore) ListProductMarkets(ctx context.Context, productID string) ([]model.Market, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var markets []model.Market
+	for _, m := range s.markets {
+		if m.ProductID != nil && *m.ProductID == productID {
+			markets = append(markets, *m)
+		}
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i].H3CellID < markets[j].H3CellID })
+	return markets, nil
+}
+
+func (s *MemoryStore) InsertLedgerEntry(ctx context.Context, entry *model.LedgerEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, dup := s.ledgerIDs[entry.ID]; dup {
+		return fmt.Errorf("%w: %s", ErrDuplicateLedgerEntry, entry.ID)
+	}
+	s.appendLedger([]*model.LedgerEntry{entry})
+	return nil
+}
+
+func (s *MemoryStore) InsertLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkLedgerIDs(entries); err != nil {
+		return err
+	}
+	s.appendLedger(entries)
+	return nil
+}
+
+// checkLedgerIDs returns ErrDuplicateLedgerEntry if any entry's ID is
+// already in the ledger or repeated within entries. Callers hold s.mu.
+func (s *MemoryStore) checkLedgerIDs(entries []*model.LedgerEntry) error {
+	seen := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		_, dup := s.ledgerIDs[e.ID]
+		_, repeated := seen[e.ID]
+		if dup || repeated {
+			return fmt.Errorf("%w: %s", ErrDuplicateLedgerEntry, e.ID)
+		}
+		seen[e.ID] = struct{}{}
+	}
+	return nil
+}
+
+// appendLedger appends entries that passed checkLedgerIDs, chains them and
+// adds them to the position index. Callers hold
+// s.mu.
+func (s *MemoryStore) appendLedger(entries []*model.LedgerEntry) {
+	for _, e := range entries {
+		s.ledgerIDs[e.ID] = struct{}{}
+		stored := *e
+		if stored.Kind == "" {
+			stored.Kind = model.EntryKindTrade
+		}
+		s.heads[stored.MarketID] = chainEntry(&stored, s.heads[stored.MarketID])
+		s.ledger = append(s.ledger, stored)
+		s.indexPosition(stored)
+	}
+}
+
+func (s *MemoryStore) GetLedgerEntriesByMarket(ctx context.Context, marketID string) ([]model.LedgerEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -112,7 +630,29 @@ func (s *MemoryStore) GetLedgerEntriesByMarket(_ context.Context, marketID strin
 	return result, nil
 }
 
-func (s *MemoryStore) GetLedgerEntriesByUser(_ context.Context, userID string) ([]model.LedgerEntry, error) {
+// StreamLedgerEntriesByMarket snapshots the market's entries under the
+// lock and calls fn outside it, so a slow consumer doesn't block writers.
+func (s *MemoryStore) StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error {
+	entries, err := s.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetLedgerEntriesByUser(ctx context.Context, userID string) ([]model.LedgerEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -125,78 +665,322 @@ func (s *MemoryStore) GetLedgerEntriesByUser(_ context.Context, userID string) (
 	return result, nil
 }
 
-// GetUserPositions aggregates ledger entries into positions per market.
-// Computes current value and unrealized P&L using live market prices.
-func (s *MemoryStore) GetUserPositions(_ context.Context, userID string) ([]model.Position, error) {
+func (s *MemoryStore) GetLedgerEntry(ctx context.Context, id string) (*model.LedgerEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	type posAgg struct {
-		marketID   string
-		contractID string
-		yesQty     decimal.Decimal
-		noQty      decimal.Decimal
-		costBasis  decimal.Decimal
+	for _, e := range s.ledger {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: ledger entry %s", ErrNotFound, id)
+}
+
+func (s *MemoryStore) VerifyChain(ctx context.Context, marketID string) (*ChainReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.markets[marketID]; !ok {
+		return nil, fmt.Errorf("%w: market %s", ErrNotFound, marketID)
+	}
+	var entries []model.LedgerEntry
+	for _, e := range s.ledger {
+		if e.MarketID == marketID {
+			entries = append(entries, e)
+		}
+	}
+	return verifyLedgerChain(entries, s.heads[marketID]), nil
+}
+
+func (s *MemoryStore) AnonymizeUser(ctx context.Context, userID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if isAnonymousUserID(userID) {
+		return 0, nil
+	}
+	anonID, err := newAnonymousUserID()
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for i := range s.ledger {
+		if s.ledger[i].UserID == userID {
+			s.ledger[i].UserID = anonID
+			n++
+		}
+	}
+	if up, ok := s.positions[userID]; ok {
+		for _, pa := range up.order {
+			pa.userID = anonID
+		}
+		s.positions[anonID] = up
+		delete(s.positions, userID)
+	}
+	return n, nil
+}
+
+// GetUserPositions aggregates ledger entries into positions per market.
+// Computes current value and unrealized P&L using live market prices.
+func (s *MemoryStore) GetUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
+	byUser, err := s.GetPositionsByUsers(ctx, []string{userID})
+	if err != nil {
+		return nil, err
 	}
+	return byUser[userID], nil
+}
 
-	agg := make(map[string]*posAgg)
+// GetUserPositionsAsOf aggregates the user's ledger entries up to asOf with
+// a scan, as the position index only holds current totals.
+func (s *MemoryStore) GetUserPositionsAsOf(ctx context.Context, userID string, asOf time.Time) ([]model.Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	// Aggregate from ledger (single lock, no re-entrant calls).
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byMarket := make(map[string]*positionAgg)
+	var order []*positionAgg
 	for _, e := range s.ledger {
-		if e.UserID != userID {
+		if e.UserID != userID || e.Timestamp.After(asOf) {
 			continue
 		}
-		pa, ok := agg[e.MarketID]
+		pa, ok := byMarket[e.MarketID]
 		if !ok {
-			pa = &posAgg{
-				marketID:   e.MarketID,
-				contractID: e.ContractID,
-			}
-			agg[e.MarketID] = pa
-		}
-		if e.Side == "YES" {
-			pa.yesQty = pa.yesQty.Add(e.Quantity)
-		} else {
-			pa.noQty = pa.noQty.Add(e.Quantity)
+			pa = &positionAgg{userID: e.UserID, marketID: e.MarketID, contractID: e.ContractID}
+			byMarket[e.MarketID] = pa
+			order = append(order, pa)
 		}
-		pa.costBasis = pa.costBasis.Add(e.Cost)
+		pa.add(e)
 	}
 
-	one := decimal.NewFromInt(1)
 	var positions []model.Position
+	for _, pa := range order {
+		positions = append(positions, s.valuePosition(pa))
+	}
+	return positions, nil
+}
+
+// GetPositionsByUsers reads positions for every requested user from the
+// position index.
+func (s *MemoryStore) GetPositionsByUsers(ctx context.Context, userIDs []string) (map[string][]model.Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions := make(map[string][]model.Position)
+	for _, id := range userIDs {
+		up, ok := s.positions[id]
+		if !ok || len(positions[id]) > 0 {
+			continue
+		}
+		for _, pa := range up.order {
+			positions[id] = append(positions[id], s.valuePosition(pa))
+		}
+	}
+	return positions, nil
+}
+
+func (s *MemoryStore) GetUserMarketPosition(ctx context.Context, userID, marketID string) (*model.Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	for _, pa := range agg {
-		m := s.markets[pa.marketID] // direct access, already under RLock
-		priceYes := decimal.NewFromFloat(0.5)
-		h3Cell := ""
-		if m != nil {
-			priceYes = m.PriceYes
-			h3Cell = m.H3CellID
-		}
-		priceNo := one.Sub(priceYes)
-
-		netQty := pa.yesQty.Sub(pa.noQty)
-		// Mark-to-market: expected value = priceYes * yesQty + priceNo * noQty
-		currentValue := priceYes.Mul(pa.yesQty).Add(priceNo.Mul(pa.noQty))
-		pnl := currentValue.Sub(pa.costBasis)
-
-		positions = append(positions, model.Position{
-			UserID:        userID,
-			MarketID:      pa.marketID,
-			ContractID:    pa.contractID,
-			H3CellID:      h3Cell,
-			YesQty:        pa.yesQty,
-			NoQty:         pa.noQty,
-			NetQty:        netQty,
-			CostBasis:     pa.costBasis,
-			CurrentValue:  currentValue,
-			UnrealizedPnL: pnl,
-		})
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if up, ok := s.positions[userID]; ok {
+		if pa, ok := up.byMarket[marketID]; ok {
+			p := s.valuePosition(pa)
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: position for %s in %s", ErrNotFound, userID, marketID)
+}
+
+// RebuildUserPositions re-indexes the user's positions from a scan of the
+// ledger.
+func (s *MemoryStore) RebuildUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.positions, userID)
+	for _, e := range s.ledger {
+		if e.UserID == userID {
+			s.indexPosition(e)
+		}
+	}
+
+	var positions []model.Position
+	if up, ok := s.positions[userID]; ok {
+		for _, pa := range up.order {
+			positions = append(positions, s.valuePosition(pa))
+		}
+	}
 	return positions, nil
 }
 
+// userPositions is one user's entry in the position index: running totals
+// per market, in the order the user first traded them.
+type userPositions struct {
+	byMarket map[string]*positionAgg
+	order    []*positionAgg
+}
+
+// positionAgg holds the running ledger totals for one user in one market.
+type positionAgg struct {
+	userID     string
+	marketID   string
+	contractID string
+	yesQty     decimal.Decimal
+	noQty      decimal.Decimal
+	costBasis  decimal.Decimal
+	realized   decimal.Decimal
+	// Per side, Σ(cost + realized P&L): each trade moves its side's basis
+	// by exactly that, so this is the cost the open shares still carry.
+	yesBasis decimal.Decimal
+	noBasis  decimal.Decimal
+}
+
+// indexPosition adds a newly appended ledger entry to the position index.
+// Callers hold s.mu for writing.
+func (s *MemoryStore) indexPosition(e model.LedgerEntry) {
+	up, ok := s.positions[e.UserID]
+	if !ok {
+		up = &userPositions{byMarket: make(map[string]*positionAgg)}
+		s.positions[e.UserID] = up
+	}
+	pa, ok := up.byMarket[e.MarketID]
+	if !ok {
+		pa = &positionAgg{
+			userID:     e.UserID,
+			marketID:   e.MarketID,
+			contractID: e.ContractID,
+		}
+		up.byMarket[e.MarketID] = pa
+		up.order = append(up.order, pa)
+	}
+	pa.add(e)
+}
+
+// add accumulates one of the position's ledger entries.
+func (pa *positionAgg) add(e model.LedgerEntry) {
+	if e.Side == "YES" {
+		pa.yesQty = pa.yesQty.Add(e.Quantity)
+		pa.yesBasis = pa.yesBasis.Add(e.Cost).Add(e.RealizedPnL)
+	} else {
+		pa.noQty = pa.noQty.Add(e.Quantity)
+		pa.noBasis = pa.noBasis.Add(e.Cost).Add(e.RealizedPnL)
+	}
+	pa.costBasis = pa.costBasis.Add(e.Cost)
+	pa.realized = pa.realized.Add(e.RealizedPnL)
+}
+
+// valuePosition marks an indexed position to its market's current price.
+// Callers must hold s.mu.
+func (s *MemoryStore) valuePosition(pa *positionAgg) model.Position {
+	m := s.markets[pa.marketID] // direct access, already under RLock
+	priceYes := decimal.NewFromFloat(0.5)
+	h3Cell := ""
+	if m != nil {
+		priceYes = m.MarkPriceYes()
+		h3Cell = m.H3CellID
+	}
+	priceNo := decimal.NewFromInt(1).Sub(priceYes)
+
+	netQty := pa.yesQty.Sub(pa.noQty)
+	// Mark-to-market: expected value = priceYes * yesQty + priceNo * noQty,
+	// which is the payout once the market has settled.
+	currentValue := priceYes.Mul(pa.yesQty).Add(priceNo.Mul(pa.noQty))
+	pnl := currentValue.Sub(pa.costBasis)
+
+	return model.Position{
+		UserID:        pa.userID,
+		MarketID:      pa.marketID,
+		ContractID:    pa.contractID,
+		H3CellID:      h3Cell,
+		YesQty:        pa.yesQty,
+		NoQty:         pa.noQty,
+		NetQty:        netQty,
+		CostBasis:     pa.costBasis,
+		YesCostBasis:  pa.yesBasis,
+		NoCostBasis:   pa.noBasis,
+		CurrentValue:  currentValue,
+		UnrealizedPnL: pnl,
+		RealizedPnL:   pa.realized,
+	}
+}
+
+// GetLeaderboard ranks users by realized P&L with a scan over the ledger.
+func (s *MemoryStore) GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byUser := make(map[string]*model.LeaderboardEntry)
+	for _, e := range s.ledger {
+		if e.Timestamp.Before(since) {
+			continue
+		}
+		le, ok := byUser[e.UserID]
+		if !ok {
+			le = &model.LeaderboardEntry{UserID: e.UserID}
+			byUser[e.UserID] = le
+		}
+		le.RealizedPnL = le.RealizedPnL.Add(e.RealizedPnL)
+		if e.IsTrade() {
+			le.TradeCount++
+		}
+	}
+
+	board := make([]model.LeaderboardEntry, 0, len(byUser))
+	for _, le := range byUser {
+		board = append(board, *le)
+	}
+	// Same ordering as the Postgres query.
+	sort.Slice(board, func(i, j int) bool {
+		a, b := board[i], board[j]
+		if c := a.RealizedPnL.Cmp(b.RealizedPnL); c != 0 {
+			return c > 0
+		}
+		if a.TradeCount != b.TradeCount {
+			return a.TradeCount > b.TradeCount
+		}
+		return a.UserID < b.UserID
+	})
+	if len(board) > limit {
+		board = board[:limit]
+	}
+	for i := range board {
+		board[i].Rank = i + 1
+	}
+	return board, nil
+}
+
 // GetUserCellExposures returns net directional exposure per H3 cell.
 func (s *MemoryStore) GetUserCellExposures(ctx context.Context, userID string) (map[string]decimal.Decimal, error) {
 	positions, err := s.GetUserPositions(ctx, userID)
@@ -210,5 +994,200 @@ func (s *MemoryStore) GetUserCellExposures(ctx context.Context, userID string) (
 			exposures[p.H3CellID] = exposures[p.H3CellID].Add(p.NetQty)
 		}
 	}
+	for cell, e := range exposures {
+		if e.IsZero() {
+			delete(exposures, cell)
+		}
+	}
 	return exposures, nil
 }
+
+// GetCellExposures sums each user's absolute net exposure per H3 cell.
+func (s *MemoryStore) GetCellExposures(ctx context.Context) (map[string]decimal.Decimal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exposures := make(map[string]decimal.Decimal)
+	for _, up := range s.positions {
+		net := make(map[string]decimal.Decimal)
+		for _, pa := range up.order {
+			m, ok := s.markets[pa.marketID]
+			if !ok || m.H3CellID == "" {
+				continue
+			}
+			net[m.H3CellID] = net[m.H3CellID].Add(pa.yesQty.Sub(pa.noQty))
+		}
+		for cell, n := range net {
+			if !n.IsZero() {
+				exposures[cell] = exposures[cell].Add(n.Abs())
+			}
+		}
+	}
+	return exposures, nil
+}
+
+func observationKey(h3CellID, obsType, date string) string {
+	return h3CellID + "|" + obsType + "|" + date
+}
+
+func (s *MemoryStore) InsertObservation(ctx context.Context, obs *model.Observation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := observationKey(obs.H3CellID, obs.Type, obs.Date)
+	if _, dup := s.observations[key]; dup {
+		return fmt.Errorf("%w: %s %s %s", ErrDuplicateObservation, obs.H3CellID, obs.Type, obs.Date)
+	}
+	s.observations[key] = *obs
+	return nil
+}
+
+func (s *MemoryStore) GetObservation(ctx context.Context, h3CellID, obsType, date string) (*model.Observation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obs, ok := s.observations[observationKey(h3CellID, obsType, date)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s %s", ErrObservationNotFound, h3CellID, obsType, date)
+	}
+	return &obs, nil
+}
+
+func (s *MemoryStore) InsertAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := *event
+	e.Params = maps.Clone(event.Params)
+	s.auditLog = append(s.auditLog, e)
+	return nil
+}
+
+func (s *MemoryStore) ListAuditEvents(ctx context.Context, target string, limit int) ([]model.AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []model.AuditEvent
+	for i := len(s.auditLog) - 1; i >= 0 && len(events) < limit; i-- {
+		if e := s.auditLog[i]; target == "" || e.Target == target {
+			e.Params = maps.Clone(e.Params)
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// Snapshot copies the state under the read lock. Products and markets are
+// ordered by creation time; the ledger keeps insertion order; liquidity
+// changes are ordered by time, each market's keeping insertion order.
+func (s *MemoryStore) Snapshot(ctx context.Context) (*model.Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := &model.Snapshot{
+		Products: make([]model.Product, 0, len(s.products)),
+		Markets:  make([]model.Market, 0, len(s.markets)),
+		Ledger:   append([]model.LedgerEntry{}, s.ledger...),
+	}
+	for _, p := range s.products {
+		cp := *p
+		cp.Cells = append([]string(nil), p.Cells...)
+		snap.Products = append(snap.Products, cp)
+	}
+	for _, m := range s.markets {
+		snap.Markets = append(snap.Markets, *m)
+	}
+	snap.LiquidityChanges = []model.LiquidityChange{}
+	for _, changes := range s.liquidityChanges {
+		snap.LiquidityChanges = append(snap.LiquidityChanges, changes...)
+	}
+	sort.SliceStable(snap.LiquidityChanges, func(i, j int) bool {
+		a, b := snap.LiquidityChanges[i], snap.LiquidityChanges[j]
+		return a.ChangedAt.Before(b.ChangedAt) || (a.ChangedAt.Equal(b.ChangedAt) && a.MarketID < b.MarketID)
+	})
+	sort.Slice(snap.Products, func(i, j int) bool {
+		a, b := snap.Products[i], snap.Products[j]
+		return a.CreatedAt.Before(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID < b.ID)
+	})
+	sort.Slice(snap.Markets, func(i, j int) bool {
+		a, b := snap.Markets[i], snap.Markets[j]
+		return a.CreatedAt.Before(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID < b.ID)
+	})
+	return snap, nil
+}
+
+// Restore builds the new state aside and swaps it in under the write
+// lock, so readers see either the old state or the restored one.
+func (s *MemoryStore) Restore(ctx context.Context, snap *model.Snapshot, force bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkSnapshot(snap); err != nil {
+		return err
+	}
+
+	products := make(map[string]*model.Product, len(snap.Products))
+	for _, p := range snap.Products {
+		p.Cells = append([]string(nil), p.Cells...)
+		products[p.ID] = &p
+	}
+	markets := make(map[string]*model.Market, len(snap.Markets))
+	for _, m := range snap.Markets {
+		markets[m.ID] = &m
+	}
+	ledger := make([]model.LedgerEntry, 0, len(snap.Ledger))
+	ledgerIDs := make(map[string]struct{}, len(snap.Ledger))
+	for _, e := range snap.Ledger {
+		if e.Kind == "" {
+			e.Kind = model.EntryKindTrade
+		}
+		ledger = append(ledger, e)
+		ledgerIDs[e.ID] = struct{}{}
+	}
+	liquidityChanges := make(map[string][]model.LiquidityChange)
+	for _, c := range snap.LiquidityChanges {
+		liquidityChanges[c.MarketID] = append(liquidityChanges[c.MarketID], c)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !force && (len(s.products) > 0 || len(s.markets) > 0 || len(s.ledger) > 0) {
+		return ErrStoreNotEmpty
+	}
+	s.products = products
+	s.markets = markets
+	s.ledger = ledger
+	s.ledgerIDs = ledgerIDs
+	s.liquidityChanges = liquidityChanges
+	s.positions = make(map[string]*userPositions)
+	for _, e := range ledger {
+		s.indexPosition(e)
+	}
+	s.heads = chainHeads(ledger)
+	return nil
+}
@@ -0,0 +1,710 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+func d(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+// seedMemoryStore returns a store with one market and one ledger entry.
+func seedMemoryStore(t *testing.T) *MemoryStore {
+	t.Helper()
+	ms := NewMemoryStore()
+	ctx := context.Background()
+	m := &model.Market{
+		ID:         "m1",
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815",
+		H3CellID:   "872a1070b",
+		B:          d(100),
+		PriceYes:   d(0.5),
+		PriceNo:    d(0.5),
+		Status:     "open",
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := ms.CreateMarket(ctx, m); err != nil {
+		t.Fatalf("seed market: %v", err)
+	}
+	e := &model.LedgerEntry{
+		ID: "e1", UserID: "user1", MarketID: "m1", ContractID: m.ContractID,
+		Side: "YES", Quantity: d(10), Price: d(0.5), Cost: d(5), Timestamp: time.Now().UTC(),
+	}
+	if err := ms.InsertLedgerEntry(ctx, e); err != nil {
+		t.Fatalf("seed ledger: %v", err)
+	}
+	return ms
+}
+
+func TestMemoryStore_CancelledContext(t *testing.T) {
+	ms := seedMemoryStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"CreateMarket": func() error {
+			return ms.CreateMarket(ctx, &model.Market{ID: "m2", ContractID: "other"})
+		},
+		"GetMarket": func() error {
+			_, err := ms.GetMarket(ctx, "m1")
+			return err
+		},
+		"GetMarketByContract": func() error {
+			_, err := ms.GetMarketByContract(ctx, "ATMX-872a1070b-PRECIP-25MM-20250815")
+			return err
+		},
+		"GetMarketsByContracts": func() error {
+			_, err := ms.GetMarketsByContracts(ctx, []string{"ATMX-872a1070b-PRECIP-25MM-20250815"})
+			return err
+		},
+		"ListMarkets": func() error {
+			_, err := ms.ListMarkets(ctx)
+			return err
+		},
+		"CountMarketsByCreator": func() error {
+			_, err := ms.CountMarketsByCreator(ctx, "user1")
+			return err
+		},
+		"ListRecentlyTradedMarkets": func() error {
+			_, err := ms.ListRecentlyTradedMarkets(ctx, 10)
+			return err
+		},
+		"UpdateMarketState": func() error {
+			return ms.UpdateMarketState(ctx, "m1", d(1), d(1), d(0.5), d(0.5))
+		},
+		"ApplyTrade": func() error {
+			return ms.ApplyTrade(ctx, []*model.LedgerEntry{{ID: "e4", UserID: "user1", MarketID: "m1"}}, "m1", d(1), d(1), d(0.5), d(0.5), d(0), nil)
+		},
+		"UpdateTradeSizeLimits": func() error {
+			return ms.UpdateTradeSizeLimits(ctx, "m1", nil, nil)
+		},
+		"UpdateLiquidity": func() error {
+			return ms.UpdateLiquidity(ctx, &model.LiquidityChange{MarketID: "m1", OldB: d(100), NewB: d(50)}, d(0.5), d(0.5))
+		},
+		"GetLiquidityChanges": func() error {
+			_, err := ms.GetLiquidityChanges(ctx, "m1")
+			return err
+		},
+		"HideMarket": func() error {
+			return ms.HideMarket(ctx, "m1", time.Now())
+		},
+		"SettleMarket": func() error {
+			return ms.SettleMarket(ctx, "m1", "YES", time.Now(), nil)
+		},
+		"UnsettleMarket": func() error {
+			return ms.UnsettleMarket(ctx, "m1", nil)
+		},
+		"CreateProduct": func() error {
+			return ms.CreateProduct(ctx, &model.Product{ID: "p1"}, nil)
+		},
+		"GetProduct": func() error {
+			_, err := ms.GetProduct(ctx, "p1")
+			return err
+		},
+		"ListProductMarkets": func() error {
+			_, err := ms.ListProductMarkets(ctx, "p1")
+			return err
+		},
+		"InsertLedgerEntries": func() error {
+			return ms.InsertLedgerEntries(ctx, []*model.LedgerEntry{{ID: "e3", UserID: "user1", MarketID: "m1"}})
+		},
+		"InsertLedgerEntry": func() error {
+			return ms.InsertLedgerEntry(ctx, &model.LedgerEntry{ID: "e2", UserID: "user1", MarketID: "m1"})
+		},
+		"GetLedgerEntriesByMarket": func() error {
+			_, err := ms.GetLedgerEntriesByMarket(ctx, "m1")
+			return err
+		},
+		"StreamLedgerEntriesByMarket": func() error {
+			return ms.StreamLedgerEntriesByMarket(ctx, "m1", func(model.LedgerEntry) error { return nil })
+		},
+		"GetLedgerEntriesByUser": func() error {
+			_, err := ms.GetLedgerEntriesByUser(ctx, "user1")
+			return err
+		},
+		"GetLedgerEntry": func() error {
+			_, err := ms.GetLedgerEntry(ctx, "entry1")
+			return err
+		},
+		"VerifyChain": func() error {
+			_, err := ms.VerifyChain(ctx, "m1")
+			return err
+		},
+		"AnonymizeUser": func() error {
+			_, err := ms.AnonymizeUser(ctx, "user1")
+			return err
+		},
+		"GetUserPositions": func() error {
+			_, err := ms.GetUserPositions(ctx, "user1")
+			return err
+		},
+		"GetUserPositionsAsOf": func() error {
+			_, err := ms.GetUserPositionsAsOf(ctx, "user1", time.Now())
+			return err
+		},
+		"GetPositionsByUsers": func() error {
+			_, err := ms.GetPositionsByUsers(ctx, []string{"user1"})
+			return err
+		},
+		"SearchMarkets": func() error {
+			_, err := ms.SearchMarkets(ctx, MarketFilter{})
+			return err
+		},
+		"GetUserMarketPosition": func() error {
+			_, err := ms.GetUserMarketPosition(ctx, "user1", "m1")
+			return err
+		},
+		"RebuildUserPositions": func() error {
+			_, err := ms.RebuildUserPositions(ctx, "user1")
+			return err
+		},
+		"ListOpenCells": func() error {
+			_, err := ms.ListOpenCells(ctx, "")
+			return err
+		},
+		"GetUserCellExposures": func() error {
+			_, err := ms.GetUserCellExposures(ctx, "user1")
+			return err
+		},
+		"GetCellExposures": func() error {
+			_, err := ms.GetCellExposures(ctx)
+			return err
+		},
+		"InsertObservation": func() error {
+			return ms.InsertObservation(ctx, &model.Observation{H3CellID: "872a1070bffffff", Type: "PRECIP", Date: "2025-08-15"})
+		},
+		"GetObservation": func() error {
+			_, err := ms.GetObservation(ctx, "872a1070bffffff", "PRECIP", "2025-08-15")
+			return err
+		},
+		"InsertAuditEvent": func() error {
+			return ms.InsertAuditEvent(ctx, &model.AuditEvent{ID: "a1", Action: "settle", Target: "m1"})
+		},
+		"ListAuditEvents": func() error {
+			_, err := ms.ListAuditEvents(ctx, "m1", 10)
+			return err
+		},
+		"Snapshot": func() error {
+			_, err := ms.Snapshot(ctx)
+			return err
+		},
+		"Restore": func() error {
+			return ms.Restore(ctx, &model.Snapshot{}, true)
+		},
+	}
+
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+
+	// None of the writes should have been applied.
+	bg := context.Background()
+	if _, err := ms.GetMarket(bg, "m2"); err == nil {
+		t.Error("CreateMarket must not persist with a cancelled context")
+	}
+	m, _ := ms.GetMarket(bg, "m1")
+	if !m.QYes.IsZero() {
+		t.Error("UpdateMarketState must not apply with a cancelled context")
+	}
+	entries, _ := ms.GetLedgerEntriesByUser(bg, "user1")
+	if len(entries) != 1 {
+		t.Errorf("InsertLedgerEntry must not append with a cancelled context, got %d entries", len(entries))
+	}
+}
+
+func TestMemoryStore_DeadlineExceeded(t *testing.T) {
+	ms := seedMemoryStore(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if _, err := ms.GetUserPositions(ctx, "user1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestMemoryStore_DuplicateLedgerEntry(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+
+	dup := &model.LedgerEntry{
+		ID: "e1", UserID: "user2", MarketID: "m1",
+		Side: "NO", Quantity: d(5), Price: d(0.5), Cost: d(2.5), Timestamp: time.Now().UTC(),
+	}
+	err := ms.InsertLedgerEntry(ctx, dup)
+	if !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Fatalf("expected ErrDuplicateLedgerEntry, got %v", err)
+	}
+
+	// The original entry is untouched and nothing was appended.
+	entries, _ := ms.GetLedgerEntriesByMarket(ctx, "m1")
+	if len(entries) != 1 || entries[0].UserID != "user1" {
+		t.Errorf("expected only the original entry, got %+v", entries)
+	}
+}
+
+func TestMemoryStore_Observations(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+
+	obs := &model.Observation{
+		H3CellID: "872a1070bffffff", Type: "PRECIP", Date: "2025-08-15",
+		Value: d(31.2), Unit: "MM", Source: "ASOS:KMIA", RecordedAt: time.Now().UTC(),
+	}
+	if err := ms.InsertObservation(ctx, obs); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	got, err := ms.GetObservation(ctx, "872a1070bffffff", "PRECIP", "2025-08-15")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !got.Value.Equal(d(31.2)) || got.Source != "ASOS:KMIA" {
+		t.Errorf("unexpected observation: %+v", got)
+	}
+
+	if err := ms.InsertObservation(ctx, obs); !errors.Is(err, ErrDuplicateObservation) {
+		t.Errorf("expected ErrDuplicateObservation, got %v", err)
+	}
+	if _, err := ms.GetObservation(ctx, "872a1070bffffff", "TEMP", "2025-08-15"); !errors.Is(err, ErrObservationNotFound) {
+		t.Errorf("expected ErrObservationNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_NotFound(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := ms.GetMarket(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMarket: expected ErrNotFound, got %v", err)
+	}
+	if _, err := ms.GetMarketByContract(ctx, "ATMX-872a1070b-PRECIP-25MM-20250815"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMarketByContract: expected ErrNotFound, got %v", err)
+	}
+	if err := ms.UpdateMarketState(ctx, "missing", d(0), d(0), d(0.5), d(0.5)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateMarketState: expected ErrNotFound, got %v", err)
+	}
+	if _, err := ms.GetObservation(ctx, "872a1070b", "PRECIP", "2025-08-15"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetObservation: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_SettledPositionValuation(t *testing.T) {
+	for _, tc := range []struct {
+		outcome   string
+		wantValue float64
+	}{
+		{"YES", 10}, // 10 winning shares × 1
+		{"NO", 0},
+	} {
+		t.Run(tc.outcome, func(t *testing.T) {
+			ms := seedMemoryStore(t)
+			ctx := context.Background()
+			if err := ms.SettleMarket(ctx, "m1", tc.outcome, time.Now().UTC(), nil); err != nil {
+				t.Fatal(err)
+			}
+
+			positions, err := ms.GetUserPositions(ctx, "user1")
+			if err != nil || len(positions) != 1 {
+				t.Fatalf("expected one position, got %v (%v)", positions, err)
+			}
+			p := positions[0]
+			if !p.CurrentValue.Equal(d(tc.wantValue)) {
+				t.Errorf("current value = %s, want %v", p.CurrentValue, tc.wantValue)
+			}
+			// Cost basis was 5.
+			if !p.UnrealizedPnL.Equal(d(tc.wantValue - 5)) {
+				t.Errorf("unrealized P&L = %s, want %v", p.UnrealizedPnL, tc.wantValue-5)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_HideMarket(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+	at := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := ms.HideMarket(ctx, "m1", at); err != nil {
+		t.Fatal(err)
+	}
+	// A second hide keeps the original timestamp.
+	if err := ms.HideMarket(ctx, "m1", at.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if markets, _ := ms.ListMarkets(ctx); len(markets) != 0 {
+		t.Errorf("expected hidden market left out of ListMarkets, got %d", len(markets))
+	}
+	if cells, _ := ms.ListOpenCells(ctx, ""); len(cells) != 0 {
+		t.Errorf("expected hidden market left out of ListOpenCells, got %v", cells)
+	}
+	if markets, _ := ms.SearchMarkets(ctx, MarketFilter{}); len(markets) != 0 {
+		t.Errorf("expected hidden market left out of search, got %d", len(markets))
+	}
+	if markets, _ := ms.SearchMarkets(ctx, MarketFilter{IncludeHidden: true}); len(markets) != 1 {
+		t.Errorf("expected hidden market with IncludeHidden, got %d", len(markets))
+	}
+
+	m, err := ms.GetMarket(ctx, "m1")
+	if err != nil || m.HiddenAt == nil || !m.HiddenAt.Equal(at) {
+		t.Errorf("expected market readable with hidden_at %v, got %+v (%v)", at, m, err)
+	}
+	if entries, _ := ms.GetLedgerEntriesByMarket(ctx, "m1"); len(entries) != 1 {
+		t.Errorf("expected ledger preserved, got %d entries", len(entries))
+	}
+
+	if err := ms.HideMarket(ctx, "missing", at); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_CreateProductIsAtomic(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+	if err := ms.CreateMarket(ctx, &model.Market{ID: "m0", ContractID: "c2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pid := "p1"
+	markets := []*model.Market{
+		{ID: "m1", ContractID: "c1", H3CellID: "a", ProductID: &pid},
+		{ID: "m2", ContractID: "c2", H3CellID: "b", ProductID: &pid}, // contract taken
+	}
+	if err := ms.CreateProduct(ctx, &model.Product{ID: pid}, markets); err == nil {
+		t.Fatal("expected an error for a contract that already has a market")
+	}
+	if _, err := ms.GetProduct(ctx, pid); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected no product after a failed create, got %v", err)
+	}
+	if _, err := ms.GetMarket(ctx, "m1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected no member market after a failed create, got %v", err)
+	}
+
+	markets[1].ContractID = "c3"
+	if err := ms.CreateProduct(ctx, &model.Product{ID: pid}, markets); err != nil {
+		t.Fatal(err)
+	}
+	members, err := ms.ListProductMarkets(ctx, pid)
+	if err != nil || len(members) != 2 || members[0].ID != "m1" || members[1].ID != "m2" {
+		t.Errorf("expected m1 and m2 in cell order, got %+v (%v)", members, err)
+	}
+}
+
+func TestMemoryStore_ApplyTradeDuplicateChangesNothing(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+	if err := ms.CreateMarket(ctx, &model.Market{ID: "m1", ContractID: "c1", QYes: d(0), QNo: d(0)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.InsertLedgerEntry(ctx, &model.LedgerEntry{ID: "e1", UserID: "user1", MarketID: "m1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []*model.LedgerEntry{
+		{ID: "e2", UserID: "user1", MarketID: "m1"},
+		{ID: "e1", UserID: "user1", MarketID: "m1"},
+	}
+	if err := ms.ApplyTrade(ctx, entries, "m1", d(5), d(0), d(0.6), d(0.4), d(3), nil); !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Fatalf("expected ErrDuplicateLedgerEntry, got %v", err)
+	}
+	m, _ := ms.GetMarket(ctx, "m1")
+	ledger, _ := ms.GetLedgerEntriesByMarket(ctx, "m1")
+	if !m.QYes.IsZero() || !m.Collected.IsZero() || len(ledger) != 1 {
+		t.Errorf("expected no change, got q_yes=%s, collected=%s and %d entries", m.QYes, m.Collected, len(ledger))
+	}
+
+	if err := ms.ApplyTrade(ctx, entries[:1], "m1", d(5), d(0), d(0.6), d(0.4), d(3), nil); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = ms.GetMarket(ctx, "m1")
+	ledger, _ = ms.GetLedgerEntriesByMarket(ctx, "m1")
+	if !m.QYes.Equal(d(5)) || !m.Collected.Equal(d(3)) || len(ledger) != 2 {
+		t.Errorf("expected the trade applied, got q_yes=%s, collected=%s and %d entries", m.QYes, m.Collected, len(ledger))
+	}
+}
+
+func TestMemoryStore_InsertLedgerEntries(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+
+	// One realized entry per user, as settlement writes them, all at the
+	// same instant: insertion order is the only order.
+	now := time.Now().UTC()
+	batch := make([]*model.LedgerEntry, 100)
+	for i := range batch {
+		batch[i] = &model.LedgerEntry{
+			ID: fmt.Sprintf("s%03d", i), UserID: fmt.Sprintf("user%03d", i), MarketID: "m1",
+			Side: "YES", Quantity: d(-1), Price: d(1), Cost: d(-1), Timestamp: now,
+			Kind: model.EntryKindSettlement,
+		}
+	}
+	if err := ms.InsertLedgerEntries(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := ms.GetLedgerEntriesByMarket(ctx, "m1")
+	if len(entries) != 101 {
+		t.Fatalf("expected the seed entry plus 100, got %d", len(entries))
+	}
+	for i, e := range entries[1:] {
+		if e.ID != batch[i].ID {
+			t.Fatalf("entry %d = %s, want %s", i, e.ID, batch[i].ID)
+		}
+	}
+
+	// A duplicate anywhere in a batch rejects all of it.
+	retry := []*model.LedgerEntry{
+		{ID: "s100", UserID: "user100", MarketID: "m1", Timestamp: now},
+		{ID: "s050", UserID: "user050", MarketID: "m1", Timestamp: now},
+	}
+	if err := ms.InsertLedgerEntries(ctx, retry); !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Fatalf("expected ErrDuplicateLedgerEntry, got %v", err)
+	}
+	if entries, _ := ms.GetLedgerEntriesByMarket(ctx, "m1"); len(entries) != 101 {
+		t.Errorf("rejected batch wrote %d entries", len(entries)-101)
+	}
+}
+
+func TestMemoryStore_RestoreChecksSnapshot(t *testing.T) {
+	ctx := context.Background()
+	snap, err := seedMemoryStore(t).Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restoring into a non-empty store needs force.
+	target := seedMemoryStore(t)
+	if err := target.Restore(ctx, snap, false); !errors.Is(err, ErrStoreNotEmpty) {
+		t.Fatalf("expected ErrStoreNotEmpty, got %v", err)
+	}
+
+	// An entry for a market the snapshot lacks is rejected and nothing
+	// changes, even with force.
+	orphan := *snap
+	orphan.Ledger = append(append([]model.LedgerEntry{}, snap.Ledger...), model.LedgerEntry{ID: "e9", UserID: "user9", MarketID: "gone"})
+	if err := target.Restore(ctx, &orphan, true); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot, got %v", err)
+	}
+	if entries, _ := target.GetLedgerEntriesByUser(ctx, "user1"); len(entries) != 1 {
+		t.Errorf("rejected restore changed the ledger: %d entries", len(entries))
+	}
+	orphan = *snap
+	orphan.LiquidityChanges = []model.LiquidityChange{{MarketID: "gone", OldB: decimal.NewFromInt(100), NewB: decimal.NewFromInt(120)}}
+	if err := target.Restore(ctx, &orphan, true); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot for an orphan liquidity change, got %v", err)
+	}
+
+	// Forced, the snapshot replaces the state and keeps its indexes: the
+	// restored entry's ID is a duplicate again.
+	if err := target.Restore(ctx, snap, true); err != nil {
+		t.Fatal(err)
+	}
+	err = target.InsertLedgerEntry(ctx, &model.LedgerEntry{ID: "e1", UserID: "user2", MarketID: "m1"})
+	if !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Errorf("expected ErrDuplicateLedgerEntry after restore, got %v", err)
+	}
+}
+
+// recomputePositions aggregates positions with a full scan of the ledger,
+// the way the store did before it kept a position index.
+func recomputePositions(ms *MemoryStore) map[string][]model.Position {
+	type key struct{ user, market string }
+	sums := make(map[key]*model.Position)
+	positions := make(map[string][]model.Position)
+	var order []key
+	for _, e := range ms.ledger {
+		k := key{e.UserID, e.MarketID}
+		p, ok := sums[k]
+		if !ok {
+			p = &model.Position{UserID: e.UserID, MarketID: e.MarketID, ContractID: e.ContractID}
+			sums[k] = p
+			order = append(order, k)
+		}
+		if e.Side == "YES" {
+			p.YesQty = p.YesQty.Add(e.Quantity)
+		} else {
+			p.NoQty = p.NoQty.Add(e.Quantity)
+		}
+		p.CostBasis = p.CostBasis.Add(e.Cost)
+		p.RealizedPnL = p.RealizedPnL.Add(e.RealizedPnL)
+	}
+	for _, k := range order {
+		p := sums[k]
+		m := ms.markets[p.MarketID]
+		priceYes := m.MarkPriceYes()
+		p.H3CellID = m.H3CellID
+		p.NetQty = p.YesQty.Sub(p.NoQty)
+		p.CurrentValue = priceYes.Mul(p.YesQty).Add(d(1).Sub(priceYes).Mul(p.NoQty))
+		p.UnrealizedPnL = p.CurrentValue.Sub(p.CostBasis)
+		positions[p.UserID] = append(positions[p.UserID], *p)
+	}
+	return positions
+}
+
+func TestMemoryStore_PositionIndexMatchesFullScan(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+	for _, id := range []string{"m2", "m3"} {
+		m := &model.Market{ID: id, ContractID: "ATMX-872a1070c-PRECIP-25MM-" + id, H3CellID: "872a1070c",
+			B: d(100), PriceYes: d(0.5), PriceNo: d(0.5), Status: "open"}
+		if err := ms.CreateMarket(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Many entries through every write path, spread over users, markets
+	// and sides, with prices moving as they go.
+	rng := rand.New(rand.NewSource(1))
+	users := []string{"user1", "user2", "user3", "user4"}
+	markets := []string{"m1", "m2", "m3"}
+	entry := func(i int) *model.LedgerEntry {
+		side := "YES"
+		if rng.Intn(2) == 0 {
+			side = "NO"
+		}
+		return &model.LedgerEntry{
+			ID: fmt.Sprintf("e%d-%d", i, rng.Int()), UserID: users[rng.Intn(len(users))],
+			MarketID: markets[rng.Intn(len(markets))], Side: side,
+			Quantity: d(float64(rng.Intn(21) - 10)), Cost: d(float64(rng.Intn(1000)) / 100),
+			RealizedPnL: d(float64(rng.Intn(200)-100) / 100), Timestamp: time.Now().UTC(),
+		}
+	}
+	for i := 0; i < 300; i++ {
+		var err error
+		switch i % 3 {
+		case 0:
+			err = ms.InsertLedgerEntry(ctx, entry(i))
+		case 1:
+			err = ms.InsertLedgerEntries(ctx, []*model.LedgerEntry{entry(i), entry(i)})
+		case 2:
+			p := d(float64(rng.Intn(99)+1) / 100)
+			e := entry(i)
+			err = ms.ApplyTrade(ctx, []*model.LedgerEntry{e}, e.MarketID, d(0), d(0), p, d(1).Sub(p), d(0), nil)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ms.SettleMarket(ctx, "m3", "YES", time.Now().UTC(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertIndexed := func(t *testing.T) {
+		t.Helper()
+		want := recomputePositions(ms)
+		got, err := ms.GetPositionsByUsers(ctx, append(users, "nobody"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got positions for %d users, want %d", len(got), len(want))
+		}
+		for user, ps := range want {
+			if len(got[user]) != len(ps) {
+				t.Fatalf("%s: got %d positions, want %d", user, len(got[user]), len(ps))
+			}
+			for i, p := range ps {
+				g := got[user][i]
+				if g.MarketID != p.MarketID || g.H3CellID != p.H3CellID || !g.YesQty.Equal(p.YesQty) ||
+					!g.NoQty.Equal(p.NoQty) || !g.NetQty.Equal(p.NetQty) || !g.CostBasis.Equal(p.CostBasis) ||
+					!g.CurrentValue.Equal(p.CurrentValue) || !g.UnrealizedPnL.Equal(p.UnrealizedPnL) ||
+					!g.RealizedPnL.Equal(p.RealizedPnL) {
+					t.Errorf("%s position %d:\n got %+v\nwant %+v", user, i, g, p)
+				}
+				one, err := ms.GetUserMarketPosition(ctx, user, p.MarketID)
+				if err != nil || !one.CostBasis.Equal(p.CostBasis) {
+					t.Errorf("%s in %s: single position %+v, %v", user, p.MarketID, one, err)
+				}
+			}
+		}
+	}
+	assertIndexed(t)
+
+	// A restore rebuilds the index from the restored ledger.
+	snap, err := ms.Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap.Ledger = snap.Ledger[:len(snap.Ledger)/2]
+	if err := ms.Restore(ctx, snap, true); err != nil {
+		t.Fatal(err)
+	}
+	assertIndexed(t)
+}
+
+func TestMemoryStore_UserCellExposuresDropOffsetCells(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+	m2 := &model.Market{
+		ID: "m2", ContractID: "ATMX-872a1070c-PRECIP-25MM-20250815", H3CellID: "872a1070c",
+		B: d(100), PriceYes: d(0.5), PriceNo: d(0.5), Status: "open", CreatedAt: time.Now().UTC(),
+	}
+	if err := ms.CreateMarket(ctx, m2); err != nil {
+		t.Fatal(err)
+	}
+	// 5 YES and 5 NO in the second cell net to zero.
+	for i, side := range []string{"YES", "NO"} {
+		e := &model.LedgerEntry{
+			ID: fmt.Sprintf("m2-%d", i), UserID: "user1", MarketID: "m2", ContractID: m2.ContractID,
+			Side: side, Quantity: d(5), Price: d(0.5), Cost: d(2.5), Timestamp: time.Now().UTC(),
+		}
+		if err := ms.InsertLedgerEntry(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exposures, err := ms.GetUserCellExposures(ctx, "user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := exposures["872a1070c"]; ok {
+		t.Errorf("fully offset cell should be absent, got %v", exposures)
+	}
+	if len(exposures) != 1 || !exposures["872a1070b"].Equal(d(10)) {
+		t.Errorf("exposures = %v, want only 872a1070b = 10", exposures)
+	}
+}
+
+func TestMemoryStore_RebuildUserPositions(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+
+	// Drift the index away from the ledger: wrong totals in m1 and a
+	// position in a market the user never traded.
+	ms.mu.Lock()
+	up := ms.positions["user1"]
+	up.byMarket["m1"].yesQty = d(999)
+	ghost := &positionAgg{userID: "user1", marketID: "m9", yesQty: d(1)}
+	up.byMarket["m9"] = ghost
+	up.order = append(up.order, ghost)
+	ms.mu.Unlock()
+
+	positions, err := ms.RebuildUserPositions(ctx, "user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 1 || positions[0].MarketID != "m1" || !positions[0].YesQty.Equal(d(10)) {
+		t.Fatalf("rebuilt positions = %+v, want 10 YES in m1 only", positions)
+	}
+	if got, _ := ms.GetUserPositions(ctx, "user1"); len(got) != 1 || !got[0].YesQty.Equal(d(10)) {
+		t.Errorf("positions after rebuild = %+v, want 10 YES in m1 only", got)
+	}
+
+	if positions, err := ms.RebuildUserPositions(ctx, "nobody"); err != nil || len(positions) != 0 {
+		t.Errorf("rebuild for a user with no trades = %+v, %v", positions, err)
+	}
+}
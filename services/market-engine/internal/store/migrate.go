@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/migrations"
+)
+
+// migrationLockID is the pg_advisory_lock key that serializes migration
+// runs when several replicas start at once.
+const migrationLockID = 0x41544d58 // "ATMX"
+
+// migration is one versioned SQL file.
+type migration struct {
+	version string // file name without .sql, e.g. "001_initial"
+	sql     string
+}
+
+// Migrate applies every embedded migration not yet recorded in
+// schema_migrations, in version order. Each migration runs in its own
+// transaction together with its version record, so a failure leaves
+// earlier migrations applied and the failed one absent. Running it again
+// applies nothing.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	return migrate(ctx, pool, migrations.FS)
+}
+
+func migrate(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) error {
+	pending, err := loadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("migrate: lock: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+		     version    TEXT PRIMARY KEY,
+		     applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		 )`); err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("migrate: read applied versions: %w", err)
+	}
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return fmt.Errorf("migrate: read applied versions: %w", err)
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("migrate: read applied versions: %w", err)
+	}
+
+	for _, m := range pending {
+		if applied[m.version] {
+			continue
+		}
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate %s: %w", m.version, err)
+		}
+		if _, err := tx.Exec(ctx, m.sql); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("migrate %s: %w", m.version, err)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("migrate %s: record version: %w", m.version, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("migrate %s: %w", m.version, err)
+		}
+		slog.Info("migration applied", "version", m.version)
+	}
+	return nil
+}
+
+// loadMigrations reads the *.sql files at the root of fsys, sorted by
+// version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	out := make([]migration, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", name, err)
+		}
+		out = append(out, migration{
+			version: strings.TrimSuffix(path.Base(name), ".sql"),
+			sql:     string(data),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/migrations"
+)
+
+func TestLoadMigrations_SortedByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_products.sql": {Data: []byte("SELECT 10;")},
+		"002_hours.sql":    {Data: []byte("SELECT 2;")},
+		"001_initial.sql":  {Data: []byte("SELECT 1;")},
+		"README.md":        {Data: []byte("not a migration")},
+	}
+	got, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"001_initial", "002_hours", "010_products"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(got), len(want))
+	}
+	for i, m := range got {
+		if m.version != want[i] {
+			t.Errorf("migration %d = %s, want %s", i, m.version, want[i])
+		}
+	}
+	if got[0].sql != "SELECT 1;" {
+		t.Errorf("sql = %q", got[0].sql)
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	got, err := loadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[0].version != "001_initial" {
+		t.Fatalf("expected embedded migrations starting at 001_initial, got %d", len(got))
+	}
+}
+
+// TestMigrate_Idempotent runs the embedded migrations twice against the
+// database in TEST_DATABASE_URL, which should be a scratch database.
+func TestMigrate_Idempotent(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := Migrate(ctx, pool); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+	}
+
+	all, _ := loadMigrations(migrations.FS)
+	var recorded int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&recorded); err != nil {
+		t.Fatal(err)
+	}
+	if recorded != len(all) {
+		t.Errorf("schema_migrations has %d rows, want %d", recorded, len(all))
+	}
+
+	// Money columns are exact NUMERIC.
+	for _, col := range []struct{ table, column string }{
+		{"markets", "q_yes"}, {"markets", "b"}, {"markets", "price_yes"},
+		{"ledger_entries", "quantity"}, {"ledger_entries", "cost"}, {"products", "b"},
+	} {
+		var dataType string
+		err := pool.QueryRow(ctx,
+			`SELECT data_type FROM information_schema.columns WHERE table_name = $1 AND column_name = $2`,
+			col.table, col.column).Scan(&dataType)
+		if err != nil {
+			t.Errorf("%s.%s: %v", col.table, col.column, err)
+			continue
+		}
+		if dataType != "numeric" {
+			t.Errorf("%s.%s is %s, want numeric", col.table, col.column, dataType)
+		}
+	}
+
+	// The CHECK constraints on status and side are in place.
+	var checks int
+	if err := pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM pg_constraint
+		 WHERE contype = 'c' AND conrelid IN ('markets'::regclass, 'ledger_entries'::regclass)`).Scan(&checks); err != nil {
+		t.Fatal(err)
+	}
+	if checks < 2 {
+		t.Errorf("expected the status and side CHECK constraints, found %d", checks)
+	}
+}
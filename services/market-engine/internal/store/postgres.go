@@ -2,18 +2,28 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 
 	"github.com/atmx/market-engine/internal/model"
 )
 
+// pgUniqueViolation is the SQLSTATE for unique_violation.
+const pgUniqueViolation = "23505"
+
 // PostgresStore implements Store using PostgreSQL as the source of truth.
 // All monetary values are stored as NUMERIC for exact decimal precision.
 type PostgresStore struct {
-	pool *pgxpool.Pool
+	pool    pgxConn
+	replica pgxConn // optional read replica; see NewPostgresStoreWithReplica
 }
 
 // NewPostgresStore creates a new PostgreSQL-backed store.
@@ -21,34 +31,79 @@ func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
 	return &PostgresStore{pool: pool}
 }
 
+// execer is satisfied by both *pgxpool.Pool and pgx.Tx.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
 func (s *PostgresStore) CreateMarket(ctx context.Context, m *model.Market) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO markets (id, contract_id, h3_cell_id, q_yes, q_no, b, price_yes, price_no, status, created_at)
-		 VALUES ($1, $2, $3, $4::NUMERIC, $5::NUMERIC, $6::NUMERIC, $7::NUMERIC, $8::NUMERIC, $9, $10)`,
+	return insertMarket(ctx, s.pool, m)
+}
+
+func insertMarket(ctx context.Context, db execer, m *model.Market) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO markets (id, contract_id, h3_cell_id, q_yes, q_no, b, price_yes, price_no, status, created_at,
+		                      trading_open, trading_close, b_start, b_end, min_quantity, max_quantity, product_id,
+		                      outcome, settled_at, hidden_at, liquidity_inputs, initial_q_yes, initial_q_no,
+		                      auto_liquidity, created_by, subsidy_budget, collected)
+		 VALUES ($1, $2, $3, $4::NUMERIC, $5::NUMERIC, $6::NUMERIC, $7::NUMERIC, $8::NUMERIC, $9, $10, $11, $12,
+		         $13::NUMERIC, $14::NUMERIC, $15::NUMERIC, $16::NUMERIC, $17, $18, $19, $20, $21,
+		         $22::NUMERIC, $23::NUMERIC, $24, $25, $26::NUMERIC, $27::NUMERIC)`,
 		m.ID, m.ContractID, m.H3CellID,
 		m.QYes.String(), m.QNo.String(), m.B.String(),
 		m.PriceYes.String(), m.PriceNo.String(),
 		m.Status, m.CreatedAt,
+		m.TradingOpen, m.TradingClose,
+		decimalOrNil(m.BStart), decimalOrNil(m.BEnd),
+		decimalOrNil(m.MinQuantity), decimalOrNil(m.MaxQuantity),
+		m.ProductID,
+		m.Outcome, m.SettledAt, m.HiddenAt, inputsOrNil(m.LiquidityInputs),
+		m.InitialQYes.String(), m.InitialQNo.String(),
+		m.AutoLiquidity, m.CreatedBy, decimalOrNil(m.SubsidyBudget),
+		m.Collected.String(),
 	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: market for contract %s", ErrAlreadyExists, m.ContractID)
+	}
 	return err
 }
 
-func (s *PostgresStore) GetMarket(ctx context.Context, id string) (*model.Market, error) {
-	var m model.Market
-	var qYes, qNo, b, priceYes, priceNo string
+// isUniqueViolation reports whether err is a Postgres unique_violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
 
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, contract_id, h3_cell_id,
+// marketColumns is the SELECT list matching scanMarket.
+const marketColumns = `id, contract_id, h3_cell_id,
 		        q_yes::TEXT, q_no::TEXT, b::TEXT,
 		        price_yes::TEXT, price_no::TEXT,
-		        status, created_at
-		 FROM markets WHERE id = $1`, id).
-		Scan(&m.ID, &m.ContractID, &m.H3CellID,
-			&qYes, &qNo, &b,
-			&priceYes, &priceNo,
-			&m.Status, &m.CreatedAt)
-	if err != nil {
-		return nil, fmt.Errorf("get market %s: %w", id, err)
+		        status, created_at, trading_open, trading_close,
+		        b_start::TEXT, b_end::TEXT, outcome, settled_at,
+		        min_quantity::TEXT, max_quantity::TEXT, hidden_at, product_id::TEXT,
+		        liquidity_inputs, initial_q_yes::TEXT, initial_q_no::TEXT, auto_liquidity,
+		        created_by, subsidy_budget::TEXT, collected::TEXT`
+
+// rowScanner is satisfied by both pgx.Row and pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMarket(row rowScanner) (*model.Market, error) {
+	var m model.Market
+	var qYes, qNo, b, priceYes, priceNo, initialQYes, initialQNo, collected string
+	var bStart, bEnd, minQty, maxQty, subsidyBudget *string
+	var inputs []byte
+
+	if err := row.Scan(&m.ID, &m.ContractID, &m.H3CellID,
+		&qYes, &qNo, &b,
+		&priceYes, &priceNo,
+		&m.Status, &m.CreatedAt, &m.TradingOpen, &m.TradingClose,
+		&bStart, &bEnd, &m.Outcome, &m.SettledAt,
+		&minQty, &maxQty, &m.HiddenAt, &m.ProductID,
+		&inputs, &initialQYes, &initialQNo, &m.AutoLiquidity,
+		&m.CreatedBy, &subsidyBudget, &collected); err != nil {
+		return nil, err
 	}
 
 	m.QYes, _ = decimal.NewFromString(qYes)
@@ -56,44 +111,90 @@ func (s *PostgresStore) GetMarket(ctx context.Context, id string) (*model.Market
 	m.B, _ = decimal.NewFromString(b)
 	m.PriceYes, _ = decimal.NewFromString(priceYes)
 	m.PriceNo, _ = decimal.NewFromString(priceNo)
+	m.BStart = parseDecimalPtr(bStart)
+	m.BEnd = parseDecimalPtr(bEnd)
+	m.MinQuantity = parseDecimalPtr(minQty)
+	m.MaxQuantity = parseDecimalPtr(maxQty)
+	m.SubsidyBudget = parseDecimalPtr(subsidyBudget)
+	m.LiquidityInputs = parseInputs(inputs)
+	m.InitialQYes, _ = decimal.NewFromString(initialQYes)
+	m.InitialQNo, _ = decimal.NewFromString(initialQNo)
+	m.Collected, _ = decimal.NewFromString(collected)
 
 	return &m, nil
 }
 
-func (s *PostgresStore) GetMarketByContract(ctx context.Context, contractID string) (*model.Market, error) {
-	var m model.Market
-	var qYes, qNo, b, priceYes, priceNo string
+func (s *PostgresStore) GetMarket(ctx context.Context, id string) (*model.Market, error) {
+	m, err := scanMarket(s.reader(ctx).QueryRow(ctx,
+		`SELECT `+marketColumns+` FROM markets WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get market %s: %w", id, err)
+	}
+	return m, nil
+}
 
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, contract_id, h3_cell_id,
-		        q_yes::TEXT, q_no::TEXT, b::TEXT,
-		        price_yes::TEXT, price_no::TEXT,
-		        status, created_at
-		 FROM markets WHERE contract_id = $1`, contractID).
-		Scan(&m.ID, &m.ContractID, &m.H3CellID,
-			&qYes, &qNo, &b,
-			&priceYes, &priceNo,
-			&m.Status, &m.CreatedAt)
+func (s *PostgresStore) GetMarketByContract(ctx context.Context, contractID string) (*model.Market, error) {
+	m, err := scanMarket(s.reader(ctx).QueryRow(ctx,
+		`SELECT `+marketColumns+` FROM markets WHERE contract_id = $1`, contractID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: market for contract %s", ErrNotFound, contractID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get market by contract %s: %w", contractID, err)
 	}
+	return m, nil
+}
 
-	m.QYes, _ = decimal.NewFromString(qYes)
-	m.QNo, _ = decimal.NewFromString(qNo)
-	m.B, _ = decimal.NewFromString(b)
-	m.PriceYes, _ = decimal.NewFromString(priceYes)
-	m.PriceNo, _ = decimal.NewFromString(priceNo)
+func (s *PostgresStore) GetMarketsByContracts(ctx context.Context, contractIDs []string) ([]model.Market, error) {
+	rows, err := s.reader(ctx).Query(ctx,
+		`SELECT `+marketColumns+` FROM markets WHERE contract_id = ANY($1)`, contractIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return &m, nil
+	var markets []model.Market
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, *m)
+	}
+	return markets, rows.Err()
 }
 
 func (s *PostgresStore) ListMarkets(ctx context.Context) ([]model.Market, error) {
+	rows, err := s.reader(ctx).Query(ctx,
+		`SELECT `+marketColumns+` FROM markets WHERE hidden_at IS NULL ORDER BY created_at DESC, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var markets []model.Market
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, *m)
+	}
+	return markets, rows.Err()
+}
+
+func (s *PostgresStore) ListRecentlyTradedMarkets(ctx context.Context, limit int) ([]model.Market, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, contract_id, h3_cell_id,
-		        q_yes::TEXT, q_no::TEXT, b::TEXT,
-		        price_yes::TEXT, price_no::TEXT,
-		        status, created_at
-		 FROM markets ORDER BY created_at DESC`)
+		`SELECT `+marketColumns+`
+		 FROM markets
+		 JOIN (SELECT market_id, MAX(timestamp) AS last_trade
+		       FROM ledger_entries
+		       GROUP BY market_id) la ON la.market_id = markets.id
+		 ORDER BY la.last_trade DESC
+		 LIMIT $1`, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -101,24 +202,93 @@ func (s *PostgresStore) ListMarkets(ctx context.Context) ([]model.Market, error)
 
 	var markets []model.Market
 	for rows.Next() {
-		var m model.Market
-		var qYes, qNo, b, priceYes, priceNo string
-		if err := rows.Scan(&m.ID, &m.ContractID, &m.H3CellID,
-			&qYes, &qNo, &b,
-			&priceYes, &priceNo,
-			&m.Status, &m.CreatedAt); err != nil {
+		m, err := scanMarket(rows)
+		if err != nil {
 			return nil, err
 		}
-		m.QYes, _ = decimal.NewFromString(qYes)
-		m.QNo, _ = decimal.NewFromString(qNo)
-		m.B, _ = decimal.NewFromString(b)
-		m.PriceYes, _ = decimal.NewFromString(priceYes)
-		m.PriceNo, _ = decimal.NewFromString(priceNo)
-		markets = append(markets, m)
+		markets = append(markets, *m)
 	}
 	return markets, rows.Err()
 }
 
+// SearchMarkets filters on the ticker fields in SQL. Tickers are validated
+// as ATMX-{cell}-{type}-{threshold}-{YYYYMMDD} on creation, so the type and
+// date are fixed '-'-separated parts and dates compare correctly as text.
+func (s *PostgresStore) SearchMarkets(ctx context.Context, f MarketFilter) ([]model.Market, error) {
+	where := []string{"h3_cell_id LIKE $1 || '%'"}
+	args := []any{f.H3Prefix}
+	if f.Type != "" {
+		args = append(args, f.Type)
+		where = append(where, fmt.Sprintf("split_part(contract_id, '-', 3) = $%d", len(args)))
+	}
+	if !f.ExpiryBefore.IsZero() {
+		args = append(args, f.ExpiryBefore.Format("20060102"))
+		where = append(where, fmt.Sprintf("split_part(contract_id, '-', 5) < $%d", len(args)))
+	}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if !f.IncludeHidden {
+		where = append(where, "hidden_at IS NULL")
+	}
+
+	rows, err := s.reader(ctx).Query(ctx,
+		`SELECT `+marketColumns+` FROM markets
+		 WHERE `+strings.Join(where, " AND ")+`
+		 ORDER BY created_at DESC, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var markets []model.Market
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, *m)
+	}
+	return markets, rows.Err()
+}
+
+func (s *PostgresStore) CountMarketsByCreator(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM markets
+		 WHERE created_by = $1 AND status = 'open' AND hidden_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+func (s *PostgresStore) ListOpenCells(ctx context.Context, prefix string) ([]model.CellSummary, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.h3_cell_id,
+		        COUNT(DISTINCT m.id) AS market_count,
+		        COALESCE(SUM(ABS(le.quantity)), 0)::TEXT AS volume
+		 FROM markets m
+		 LEFT JOIN ledger_entries le ON le.market_id = m.id
+		 WHERE m.status = 'open' AND m.hidden_at IS NULL AND m.h3_cell_id LIKE $1 || '%'
+		 GROUP BY m.h3_cell_id
+		 ORDER BY m.h3_cell_id`, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []model.CellSummary
+	for rows.Next() {
+		var c model.CellSummary
+		var volumeS string
+		if err := rows.Scan(&c.H3CellID, &c.MarketCount, &volumeS); err != nil {
+			return nil, err
+		}
+		c.Volume, _ = decimal.NewFromString(volumeS)
+		cells = append(cells, c)
+	}
+	return cells, rows.Err()
+}
+
 func (s *PostgresStore) UpdateMarketState(ctx context.Context, id string, qYes, qNo, priceYes, priceNo decimal.Decimal) error {
 	_, err := s.pool.Exec(ctx,
 		`UPDATE markets
@@ -130,21 +300,410 @@ func (s *PostgresStore) UpdateMarketState(ctx context.Context, id string, qYes,
 	return err
 }
 
-func (s *PostgresStore) InsertLedgerEntry(ctx context.Context, e *model.LedgerEntry) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO ledger_entries (id, user_id, market_id, contract_id, side, quantity, price, cost, timestamp)
-		 VALUES ($1, $2, $3, $4, $5, $6::NUMERIC, $7::NUMERIC, $8::NUMERIC, $9)`,
-		e.ID, e.UserID, e.MarketID, e.ContractID, e.Side,
-		e.Quantity.String(), e.Price.String(), e.Cost.String(),
-		e.Timestamp,
+// ApplyTrade writes the entries, any liquidity change and the market
+// update in one transaction. A cancelled ctx aborts the transaction, so it
+// rolls back as a whole.
+func (s *PostgresStore) ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := appendLedgerEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+	if change != nil {
+		if err := updateLiquidity(ctx, tx, change, priceYes, priceNo); err != nil {
+			return err
+		}
+	}
+	tag, err := tx.Exec(ctx,
+		`UPDATE markets
+		 SET q_yes = $2::NUMERIC, q_no = $3::NUMERIC,
+		     price_yes = $4::NUMERIC, price_no = $5::NUMERIC,
+		     collected = $6::NUMERIC
+		 WHERE id = $1`,
+		id, qYes.String(), qNo.String(), priceYes.String(), priceNo.String(), collected.String(),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *PostgresStore) UpdateTradingHours(ctx context.Context, id string, open, close *string) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE markets SET trading_open = $2, trading_close = $3 WHERE id = $1`,
+		id, open, close,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *PostgresStore) UpdateTradeSizeLimits(ctx context.Context, id string, min, max *decimal.Decimal) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE markets SET min_quantity = $2::NUMERIC, max_quantity = $3::NUMERIC WHERE id = $1`,
+		id, decimalOrNil(min), decimalOrNil(max),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *PostgresStore) UpdateLiquidity(ctx context.Context, c *model.LiquidityChange, priceYes, priceNo decimal.Decimal) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateLiquidity(ctx, tx, c, priceYes, priceNo); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// updateLiquidity sets the market's b, inputs and prices from c and
+// records c, in tx.
+func updateLiquidity(ctx context.Context, tx pgx.Tx, c *model.LiquidityChange, priceYes, priceNo decimal.Decimal) error {
+	tag, err := tx.Exec(ctx,
+		`UPDATE markets SET b = $2::NUMERIC, price_yes = $3::NUMERIC, price_no = $4::NUMERIC,
+		                    liquidity_inputs = $5
+		 WHERE id = $1`,
+		c.MarketID, c.NewB.String(), priceYes.String(), priceNo.String(), inputsOrNil(c.Inputs),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: market %s", ErrNotFound, c.MarketID)
+	}
+	return insertLiquidityChange(ctx, tx, c)
+}
+
+func insertLiquidityChange(ctx context.Context, tx pgx.Tx, c *model.LiquidityChange) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO liquidity_changes (market_id, old_b, new_b, changed_at, inputs)
+		 VALUES ($1, $2::NUMERIC, $3::NUMERIC, $4, $5)`,
+		c.MarketID, c.OldB.String(), c.NewB.String(), c.ChangedAt, inputsOrNil(c.Inputs),
 	)
 	return err
 }
 
-func (s *PostgresStore) GetLedgerEntriesByMarket(ctx context.Context, marketID string) ([]model.LedgerEntry, error) {
+func (s *PostgresStore) GetLiquidityChanges(ctx context.Context, marketID string) ([]model.LiquidityChange, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT market_id, old_b::TEXT, new_b::TEXT, changed_at, inputs
+		 FROM liquidity_changes WHERE market_id = $1 ORDER BY changed_at, id`, marketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanLiquidityChanges(rows)
+}
+
+func scanLiquidityChanges(rows pgx.Rows) ([]model.LiquidityChange, error) {
+	var changes []model.LiquidityChange
+	for rows.Next() {
+		var c model.LiquidityChange
+		var oldS, newS string
+		var inputs []byte
+		if err := rows.Scan(&c.MarketID, &oldS, &newS, &c.ChangedAt, &inputs); err != nil {
+			return nil, err
+		}
+		c.OldB, _ = decimal.NewFromString(oldS)
+		c.NewB, _ = decimal.NewFromString(newS)
+		c.Inputs = parseInputs(inputs)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func (s *PostgresStore) HideMarket(ctx context.Context, id string, hiddenAt time.Time) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE markets SET hidden_at = COALESCE(hidden_at, $2) WHERE id = $1`,
+		id, hiddenAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: market %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// SettleMarket closes the market and appends the payout entries in one
+// transaction.
+func (s *PostgresStore) SettleMarket(ctx context.Context, id, outcome string, settledAt time.Time, entries []*model.LedgerEntry) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE markets SET status = 'settled', outcome = $2, settled_at = $3
+		 WHERE id = $1 AND status = 'open'`,
+		id, outcome, settledAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := s.GetMarket(WithPrimaryReads(ctx), id); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrMarketNotOpen, id)
+	}
+	if err := appendLedgerEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UnsettleMarket reopens the market and appends the entries in one
+// transaction.
+func (s *PostgresStore) UnsettleMarket(ctx context.Context, id string, entries []*model.LedgerEntry) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE markets SET status = 'open', outcome = NULL, settled_at = NULL
+		 WHERE id = $1 AND status = 'settled'`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := s.GetMarket(WithPrimaryReads(ctx), id); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrMarketNotSettled, id)
+	}
+	if err := appendLedgerEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *PostgresStore) CreateProduct(ctx context.Context, p *model.Product, markets []*model.Market) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertProduct(ctx, tx, p); err != nil {
+		return err
+	}
+	for _, m := range markets {
+		if err := insertMarket(ctx, tx, m); err != nil {
+			return fmt.Errorf("create market for %s: %w", m.ContractID, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func insertProduct(ctx context.Context, db execer, p *model.Product) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO products (id, type, threshold, date, cells, liquidity_source, b, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7::NUMERIC, $8)`,
+		p.ID, p.Type, p.Threshold, p.Date, p.Cells, p.LiquiditySource, p.B.String(), p.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: product %s", ErrAlreadyExists, p.ID)
+	}
+	return err
+}
+
+// productColumns is the SELECT list matching scanProduct.
+const productColumns = `id, type, threshold, date, cells, liquidity_source, b::TEXT, created_at`
+
+func scanProduct(row rowScanner) (*model.Product, error) {
+	var p model.Product
+	var b string
+	if err := row.Scan(&p.ID, &p.Type, &p.Threshold, &p.Date, &p.Cells, &p.LiquiditySource, &b, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	p.B, _ = decimal.NewFromString(b)
+	return &p, nil
+}
+
+func (s *PostgresStore) GetProduct(ctx context.Context, id string) (*model.Product, error) {
+	p, err := scanProduct(s.pool.QueryRow(ctx,
+		`SELECT `+productColumns+` FROM products WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: product %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get product %s: %w", id, err)
+	}
+	return p, nil
+}
+
+func (s *PostgresStore) ListProductMarkets(ctx context.Context, productID string) ([]model.Market, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, market_id, contract_id, side,
-		        quantity::TEXT, price::TEXT, cost::TEXT, timestamp
+		`SELECT `+marketColumns+` FROM markets WHERE product_id = $1 ORDER BY h3_cell_id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var markets []model.Market
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, *m)
+	}
+	return markets, rows.Err()
+}
+
+func (s *PostgresStore) InsertLedgerEntry(ctx context.Context, e *model.LedgerEntry) error {
+	return s.InsertLedgerEntries(ctx, []*model.LedgerEntry{e})
+}
+
+// ledgerInsertColumns is the number of bind parameters per row in
+// InsertLedgerEntries.
+const ledgerInsertColumns = 15
+
+// maxLedgerInsertRows is the most rows one INSERT can carry within the
+// protocol's limit of 65535 bind parameters.
+const maxLedgerInsertRows = 65535 / ledgerInsertColumns
+
+// InsertLedgerEntries chains and writes entries in one transaction. A
+// batch too large for one INSERT, such as the settlement of a very popular
+// market, is split across statements.
+func (s *PostgresStore) InsertLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := appendLedgerEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// appendLedgerEntries chains entries to their markets' ledgers and writes
+// them, moving each market's ledger_head along. The market rows are
+// locked first so concurrent writers can't fork a chain.
+func appendLedgerEntries(ctx context.Context, tx pgx.Tx, entries []*model.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !seen[e.MarketID] {
+			seen[e.MarketID] = true
+			ids = append(ids, e.MarketID)
+		}
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT id, ledger_head FROM markets WHERE id = ANY($1::UUID[]) ORDER BY id FOR UPDATE`, ids)
+	if err != nil {
+		return err
+	}
+	heads := make(map[string]string, len(ids))
+	for rows.Next() {
+		var id, head string
+		if err := rows.Scan(&id, &head); err != nil {
+			rows.Close()
+			return err
+		}
+		heads[id] = head
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		heads[e.MarketID] = chainEntry(e, heads[e.MarketID])
+	}
+	if err := insertLedgerEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+	return saveLedgerHeads(ctx, tx, heads)
+}
+
+// saveLedgerHeads records the hash each market's chain now ends at.
+func saveLedgerHeads(ctx context.Context, db execer, heads map[string]string) error {
+	for id, head := range heads {
+		if _, err := db.Exec(ctx, `UPDATE markets SET ledger_head = $2 WHERE id = $1`, id, head); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertLedgerEntries writes entries in statements of at most
+// maxLedgerInsertRows rows; db must be a transaction for that to be
+// atomic when there is more than one.
+func insertLedgerEntries(ctx context.Context, db execer, entries []*model.LedgerEntry) error {
+	for len(entries) > maxLedgerInsertRows {
+		if err := insertLedgerRows(ctx, db, entries[:maxLedgerInsertRows]); err != nil {
+			return err
+		}
+		entries = entries[maxLedgerInsertRows:]
+	}
+	return insertLedgerRows(ctx, db, entries)
+}
+
+func insertLedgerRows(ctx context.Context, db execer, entries []*model.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	rows := make([]string, len(entries))
+	args := make([]any, 0, len(entries)*ledgerInsertColumns)
+	for i, e := range entries {
+		n := i * ledgerInsertColumns
+		rows[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d::NUMERIC, $%d::NUMERIC, $%d::NUMERIC, $%d, $%d::NUMERIC, COALESCE(NULLIF($%d, ''), 'trade'), $%d, $%d, $%d, NULLIF($%d, '')::UUID)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13, n+14, n+15)
+		args = append(args,
+			e.ID, e.UserID, e.MarketID, e.ContractID, e.Side,
+			e.Quantity.String(), e.Price.String(), e.Cost.String(),
+			e.Timestamp, e.RealizedPnL.String(), e.Kind,
+			e.PrevHash, e.Hash, metadataOrNil(e.Metadata), e.Reverses,
+		)
+	}
+
+	_, err := db.Exec(ctx,
+		`INSERT INTO ledger_entries (id, user_id, market_id, contract_id, side, quantity, price, cost, timestamp, realized_pnl, kind, prev_hash, hash, metadata, reverses)
+		 VALUES `+strings.Join(rows, ", "), args...)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == "ledger_entries_pkey" {
+		return fmt.Errorf("%w: %s", ErrDuplicateLedgerEntry, pgErr.Detail)
+	}
+	return err
+}
+
+func (s *PostgresStore) GetLedgerEntriesByMarket(ctx context.Context, marketID string) ([]model.LedgerEntry, error) {
+	rows, err := s.reader(ctx).Query(ctx,
+		`SELECT `+ledgerColumns+`
 		 FROM ledger_entries WHERE market_id = $1 ORDER BY timestamp`, marketID)
 	if err != nil {
 		return nil, err
@@ -154,10 +713,32 @@ func (s *PostgresStore) GetLedgerEntriesByMarket(ctx context.Context, marketID s
 	return scanLedgerEntries(rows)
 }
 
+// StreamLedgerEntriesByMarket scans rows one at a time as pgx receives
+// them, so memory use doesn't grow with the market's history.
+func (s *PostgresStore) StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error {
+	rows, err := s.reader(ctx).Query(ctx,
+		`SELECT `+ledgerColumns+`
+		 FROM ledger_entries WHERE market_id = $1 ORDER BY timestamp`, marketID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanLedgerEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (s *PostgresStore) GetLedgerEntriesByUser(ctx context.Context, userID string) ([]model.LedgerEntry, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, market_id, contract_id, side,
-		        quantity::TEXT, price::TEXT, cost::TEXT, timestamp
+		`SELECT `+ledgerColumns+`
 		 FROM ledger_entries WHERE user_id = $1 ORDER BY timestamp`, userID)
 	if err != nil {
 		return nil, err
@@ -167,52 +748,211 @@ func (s *PostgresStore) GetLedgerEntriesByUser(ctx context.Context, userID strin
 	return scanLedgerEntries(rows)
 }
 
+func (s *PostgresStore) GetLedgerEntry(ctx context.Context, id string) (*model.LedgerEntry, error) {
+	e, err := scanLedgerEntry(s.pool.QueryRow(ctx,
+		`SELECT `+ledgerColumns+` FROM ledger_entries WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: ledger entry %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// VerifyChain reads the head and entries in one snapshot, so a trade
+// landing in between can't look like a truncated chain.
+func (s *PostgresStore) VerifyChain(ctx context.Context, marketID string) (*ChainReport, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var head string
+	err = tx.QueryRow(ctx, `SELECT ledger_head FROM markets WHERE id = $1`, marketID).Scan(&head)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: market %s", ErrNotFound, marketID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx,
+		`SELECT `+ledgerColumns+`
+		 FROM ledger_entries WHERE market_id = $1 ORDER BY timestamp`, marketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries, err := scanLedgerEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	return verifyLedgerChain(entries, head), nil
+}
+
+func (s *PostgresStore) AnonymizeUser(ctx context.Context, userID string) (int, error) {
+	if isAnonymousUserID(userID) {
+		return 0, nil
+	}
+	anonID, err := newAnonymousUserID()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE ledger_entries SET user_id = $2 WHERE user_id = $1`,
+		userID, anonID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
 func (s *PostgresStore) GetUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
-	rows, err := s.pool.Query(ctx,
-		`SELECT
+	byUser, err := s.GetPositionsByUsers(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	return byUser[userID], nil
+}
+
+// RebuildUserPositions reads the user's positions from the primary.
+// Positions are aggregated from the ledger on every read, so there is no
+// derived copy to replace.
+func (s *PostgresStore) RebuildUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
+	return s.GetUserPositions(WithPrimaryReads(ctx), userID)
+}
+
+func (s *PostgresStore) GetUserPositionsAsOf(ctx context.Context, userID string, asOf time.Time) ([]model.Position, error) {
+	rows, err := s.reader(ctx).Query(ctx,
+		positionSelect+`WHERE le.user_id = $1 AND le.timestamp <= $2`+positionGroupBy, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []model.Position
+	for rows.Next() {
+		p, err := scanPosition(rows)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, *p)
+	}
+	return positions, rows.Err()
+}
+
+// positionSelect aggregates ledger entries into positions; callers append
+// a WHERE clause on le.* and positionGroupBy.
+const positionSelect = `SELECT
+			le.user_id,
 			le.market_id,
 			m.contract_id,
 			m.h3_cell_id,
 			COALESCE(SUM(CASE WHEN le.side = 'YES' THEN le.quantity ELSE 0 END), 0)::TEXT AS yes_qty,
 			COALESCE(SUM(CASE WHEN le.side = 'NO'  THEN le.quantity ELSE 0 END), 0)::TEXT AS no_qty,
 			COALESCE(SUM(le.cost), 0)::TEXT AS cost_basis,
-			m.price_yes::TEXT AS price_yes
+			COALESCE(SUM(le.realized_pnl), 0)::TEXT AS realized_pnl,
+			COALESCE(SUM(CASE WHEN le.side = 'YES' THEN le.cost + le.realized_pnl ELSE 0 END), 0)::TEXT AS yes_cost_basis,
+			COALESCE(SUM(CASE WHEN le.side = 'NO'  THEN le.cost + le.realized_pnl ELSE 0 END), 0)::TEXT AS no_cost_basis,
+			m.price_yes::TEXT AS price_yes,
+			m.status,
+			m.outcome
 		 FROM ledger_entries le
 		 JOIN markets m ON m.id = le.market_id
-		 WHERE le.user_id = $1
-		 GROUP BY le.market_id, m.contract_id, m.h3_cell_id, m.price_yes`, userID)
+		 `
+
+const positionGroupBy = `
+		 GROUP BY le.user_id, le.market_id, m.contract_id, m.h3_cell_id, m.price_yes, m.status, m.outcome`
+
+// GetPositionsByUsers aggregates positions for all requested users in a
+// single grouped query rather than one round trip per user.
+func (s *PostgresStore) GetPositionsByUsers(ctx context.Context, userIDs []string) (map[string][]model.Position, error) {
+	rows, err := s.reader(ctx).Query(ctx,
+		positionSelect+`WHERE le.user_id = ANY($1)`+positionGroupBy, userIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	one := decimal.NewFromInt(1)
-	var positions []model.Position
-
+	positions := make(map[string][]model.Position)
 	for rows.Next() {
-		var p model.Position
-		var yesQtyS, noQtyS, costBasisS, priceYesS string
-
-		if err := rows.Scan(&p.MarketID, &p.ContractID, &p.H3CellID,
-			&yesQtyS, &noQtyS, &costBasisS, &priceYesS); err != nil {
+		p, err := scanPosition(rows)
+		if err != nil {
 			return nil, err
 		}
+		positions[p.UserID] = append(positions[p.UserID], *p)
+	}
 
-		p.UserID = userID
-		p.YesQty, _ = decimal.NewFromString(yesQtyS)
-		p.NoQty, _ = decimal.NewFromString(noQtyS)
-		p.CostBasis, _ = decimal.NewFromString(costBasisS)
-		priceYes, _ := decimal.NewFromString(priceYesS)
-		priceNo := one.Sub(priceYes)
+	return positions, rows.Err()
+}
 
-		p.NetQty = p.YesQty.Sub(p.NoQty)
-		p.CurrentValue = priceYes.Mul(p.YesQty).Add(priceNo.Mul(p.NoQty))
-		p.UnrealizedPnL = p.CurrentValue.Sub(p.CostBasis)
+func (s *PostgresStore) GetUserMarketPosition(ctx context.Context, userID, marketID string) (*model.Position, error) {
+	p, err := scanPosition(s.reader(ctx).QueryRow(ctx,
+		positionSelect+`WHERE le.user_id = $1 AND le.market_id = $2`+positionGroupBy,
+		userID, marketID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: position for %s in %s", ErrNotFound, userID, marketID)
+	}
+	return p, err
+}
 
-		positions = append(positions, p)
+// scanPosition reads one positionSelect row and marks it to market, or to
+// the payout if the market has settled.
+func scanPosition(row rowScanner) (*model.Position, error) {
+	var p model.Position
+	var yesQtyS, noQtyS, costBasisS, realizedS, yesBasisS, noBasisS, priceYesS string
+	var m model.Market
+
+	if err := row.Scan(&p.UserID, &p.MarketID, &p.ContractID, &p.H3CellID,
+		&yesQtyS, &noQtyS, &costBasisS, &realizedS, &yesBasisS, &noBasisS, &priceYesS, &m.Status, &m.Outcome); err != nil {
+		return nil, err
 	}
 
-	return positions, rows.Err()
+	p.YesQty, _ = decimal.NewFromString(yesQtyS)
+	p.NoQty, _ = decimal.NewFromString(noQtyS)
+	p.CostBasis, _ = decimal.NewFromString(costBasisS)
+	p.RealizedPnL, _ = decimal.NewFromString(realizedS)
+	p.YesCostBasis, _ = decimal.NewFromString(yesBasisS)
+	p.NoCostBasis, _ = decimal.NewFromString(noBasisS)
+	m.PriceYes, _ = decimal.NewFromString(priceYesS)
+	priceYes := m.MarkPriceYes()
+	priceNo := decimal.NewFromInt(1).Sub(priceYes)
+
+	p.NetQty = p.YesQty.Sub(p.NoQty)
+	p.CurrentValue = priceYes.Mul(p.YesQty).Add(priceNo.Mul(p.NoQty))
+	p.UnrealizedPnL = p.CurrentValue.Sub(p.CostBasis)
+	return &p, nil
+}
+
+func (s *PostgresStore) GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	rows, err := s.reader(ctx).Query(ctx,
+		`SELECT user_id,
+		        SUM(realized_pnl)::TEXT AS realized_pnl,
+		        COUNT(*) FILTER (WHERE kind = 'trade') AS trade_count
+		 FROM ledger_entries
+		 WHERE timestamp >= $1
+		 GROUP BY user_id
+		 ORDER BY SUM(realized_pnl) DESC, trade_count DESC, user_id
+		 LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var board []model.LeaderboardEntry
+	for rows.Next() {
+		var e model.LeaderboardEntry
+		var realizedS string
+		if err := rows.Scan(&e.UserID, &realizedS, &e.TradeCount); err != nil {
+			return nil, err
+		}
+		e.RealizedPnL, _ = decimal.NewFromString(realizedS)
+		e.Rank = len(board) + 1
+		board = append(board, e)
+	}
+	return board, rows.Err()
 }
 
 func (s *PostgresStore) GetUserCellExposures(ctx context.Context, userID string) (map[string]decimal.Decimal, error) {
@@ -224,7 +964,10 @@ func (s *PostgresStore) GetUserCellExposures(ctx context.Context, userID string)
 		 FROM ledger_entries le
 		 JOIN markets m ON m.id = le.market_id
 		 WHERE le.user_id = $1
-		 GROUP BY m.h3_cell_id`, userID)
+		 GROUP BY m.h3_cell_id
+		 HAVING SUM(CASE WHEN le.side = 'YES' THEN le.quantity
+		                 WHEN le.side = 'NO'  THEN -le.quantity
+		                 ELSE 0 END) <> 0`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +986,35 @@ func (s *PostgresStore) GetUserCellExposures(ctx context.Context, userID string)
 	return exposures, rows.Err()
 }
 
+func (s *PostgresStore) GetCellExposures(ctx context.Context) (map[string]decimal.Decimal, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT h3_cell_id, SUM(ABS(net))::TEXT
+		 FROM (SELECT le.user_id, m.h3_cell_id,
+		              SUM(CASE WHEN le.side = 'YES' THEN le.quantity
+		                       WHEN le.side = 'NO'  THEN -le.quantity
+		                       ELSE 0 END) AS net
+		       FROM ledger_entries le
+		       JOIN markets m ON m.id = le.market_id
+		       GROUP BY le.user_id, m.h3_cell_id) per_user
+		 WHERE net <> 0
+		 GROUP BY h3_cell_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exposures := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var cellID, expStr string
+		if err := rows.Scan(&cellID, &expStr); err != nil {
+			return nil, err
+		}
+		exp, _ := decimal.NewFromString(expStr)
+		exposures[cellID] = exp
+	}
+	return exposures, rows.Err()
+}
+
 // scanLedgerEntries reads pgx rows into LedgerEntry slices.
 type pgxRows interface {
 	Next() bool
@@ -253,19 +1025,296 @@ type pgxRows interface {
 func scanLedgerEntries(rows pgxRows) ([]model.LedgerEntry, error) {
 	var entries []model.LedgerEntry
 	for rows.Next() {
-		var e model.LedgerEntry
-		var qtyS, priceS, costS string
+		e, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ledgerColumns is the select list scanLedgerEntry reads.
+const ledgerColumns = `id, user_id, market_id, contract_id, side,
+		        quantity::TEXT, price::TEXT, cost::TEXT, timestamp, realized_pnl::TEXT, kind,
+		        prev_hash, hash, metadata, COALESCE(reverses::TEXT, '')`
+
+// scanLedgerEntry reads the current row of a ledger entry SELECT.
+func scanLedgerEntry(row rowScanner) (model.LedgerEntry, error) {
+	var e model.LedgerEntry
+	var qtyS, priceS, costS, realizedS string
+	var meta []byte
+
+	if err := row.Scan(&e.ID, &e.UserID, &e.MarketID, &e.ContractID, &e.Side,
+		&qtyS, &priceS, &costS, &e.Timestamp, &realizedS, &e.Kind,
+		&e.PrevHash, &e.Hash, &meta, &e.Reverses); err != nil {
+		return e, err
+	}
+	if meta != nil {
+		json.Unmarshal(meta, &e.Metadata)
+	}
+
+	e.Quantity, _ = decimal.NewFromString(qtyS)
+	e.Price, _ = decimal.NewFromString(priceS)
+	e.Cost, _ = decimal.NewFromString(costS)
+	e.RealizedPnL, _ = decimal.NewFromString(realizedS)
+	return e, nil
+}
+
+// decimalOrNil converts an optional decimal to a NUMERIC parameter.
+func decimalOrNil(v *decimal.Decimal) *string {
+	if v == nil {
+		return nil
+	}
+	str := v.String()
+	return &str
+}
+
+// inputsOrNil encodes optional liquidity inputs as a JSONB parameter.
+func inputsOrNil(in *model.LiquidityInputs) []byte {
+	if in == nil {
+		return nil
+	}
+	data, _ := json.Marshal(in)
+	return data
+}
+
+// metadataOrNil encodes ledger entry metadata or audit event parameters
+// as a JSONB parameter; none is stored as NULL.
+func metadataOrNil(m map[string]string) []byte {
+	if len(m) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(m)
+	return data
+}
+
+// parseInputs decodes a liquidity_inputs column; NULL gives nil.
+func parseInputs(data []byte) *model.LiquidityInputs {
+	if data == nil {
+		return nil
+	}
+	var in model.LiquidityInputs
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil
+	}
+	return &in
+}
+
+// parseDecimalPtr converts a nullable NUMERIC::TEXT column to a decimal.
+func parseDecimalPtr(v *string) *decimal.Decimal {
+	if v == nil {
+		return nil
+	}
+	dv, err := decimal.NewFromString(*v)
+	if err != nil {
+		return nil
+	}
+	return &dv
+}
+
+func (s *PostgresStore) InsertObservation(ctx context.Context, o *model.Observation) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO observations (h3_cell_id, type, date, value, unit, source, recorded_at)
+		 VALUES ($1, $2, $3::DATE, $4::NUMERIC, $5, $6, $7)`,
+		o.H3CellID, o.Type, o.Date, o.Value.String(), o.Unit, o.Source, o.RecordedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == "observations_pkey" {
+		return fmt.Errorf("%w: %s %s %s", ErrDuplicateObservation, o.H3CellID, o.Type, o.Date)
+	}
+	return err
+}
+
+func (s *PostgresStore) GetObservation(ctx context.Context, h3CellID, obsType, date string) (*model.Observation, error) {
+	var o model.Observation
+	var valueS string
+	err := s.pool.QueryRow(ctx,
+		`SELECT h3_cell_id, type, date::TEXT, value::TEXT, unit, source, recorded_at
+		 FROM observations WHERE h3_cell_id = $1 AND type = $2 AND date = $3::DATE`,
+		h3CellID, obsType, date,
+	).Scan(&o.H3CellID, &o.Type, &o.Date, &valueS, &o.Unit, &o.Source, &o.RecordedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s %s %s", ErrObservationNotFound, h3CellID, obsType, date)
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.Value, _ = decimal.NewFromString(valueS)
+	return &o, nil
+}
+
+func (s *PostgresStore) InsertAuditEvent(ctx context.Context, e *model.AuditEvent) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO audit_log (id, actor, action, target, params, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		e.ID, e.Actor, e.Action, e.Target, metadataOrNil(e.Params), e.Timestamp,
+	)
+	return err
+}
+
+func (s *PostgresStore) ListAuditEvents(ctx context.Context, target string, limit int) ([]model.AuditEvent, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id::TEXT, actor, action, target, params, created_at
+		 FROM audit_log
+		 WHERE $1 = '' OR target = $1
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $2`,
+		target, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.AuditEvent
+	for rows.Next() {
+		var e model.AuditEvent
+		var params []byte
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Target, &params, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		if params != nil {
+			json.Unmarshal(params, &e.Params)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Snapshot reads everything in one repeatable-read transaction, so the
+// export is consistent while trading continues. The ledger and liquidity
+// changes are ordered by time.
+func (s *PostgresStore) Snapshot(ctx context.Context) (*model.Snapshot, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	snap := &model.Snapshot{Products: []model.Product{}, Markets: []model.Market{}}
+
+	rows, err := tx.Query(ctx, `SELECT `+productColumns+` FROM products ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.Products = append(snap.Products, *p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if err := rows.Scan(&e.ID, &e.UserID, &e.MarketID, &e.ContractID, &e.Side,
-			&qtyS, &priceS, &costS, &e.Timestamp); err != nil {
+	rows, err = tx.Query(ctx, `SELECT `+marketColumns+` FROM markets ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			rows.Close()
 			return nil, err
 		}
+		snap.Markets = append(snap.Markets, *m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		e.Quantity, _ = decimal.NewFromString(qtyS)
-		e.Price, _ = decimal.NewFromString(priceS)
-		e.Cost, _ = decimal.NewFromString(costS)
+	rows, err = tx.Query(ctx,
+		`SELECT `+ledgerColumns+`
+		 FROM ledger_entries ORDER BY timestamp, id`)
+	if err != nil {
+		return nil, err
+	}
+	snap.Ledger, err = scanLedgerEntries(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if snap.Ledger == nil {
+		snap.Ledger = []model.LedgerEntry{}
+	}
 
-		entries = append(entries, e)
+	rows, err = tx.Query(ctx,
+		`SELECT market_id, old_b::TEXT, new_b::TEXT, changed_at, inputs
+		 FROM liquidity_changes ORDER BY changed_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if snap.LiquidityChanges, err = scanLiquidityChanges(rows); err != nil {
+		return nil, err
+	}
+	if snap.LiquidityChanges == nil {
+		snap.LiquidityChanges = []model.LiquidityChange{}
+	}
+	return snap, nil
+}
+
+// Restore truncates and loads in one transaction; any failure rolls the
+// store back to its state before the call.
+func (s *PostgresStore) Restore(ctx context.Context, snap *model.Snapshot, force bool) error {
+	if err := checkSnapshot(snap); err != nil {
+		return err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if force {
+		if _, err := tx.Exec(ctx, `TRUNCATE ledger_entries, liquidity_changes, markets, products`); err != nil {
+			return err
+		}
+	} else {
+		var nonEmpty bool
+		if err := tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM products) OR EXISTS (SELECT 1 FROM markets)
+			     OR EXISTS (SELECT 1 FROM ledger_entries)`,
+		).Scan(&nonEmpty); err != nil {
+			return err
+		}
+		if nonEmpty {
+			return ErrStoreNotEmpty
+		}
+	}
+
+	for i := range snap.Products {
+		if err := insertProduct(ctx, tx, &snap.Products[i]); err != nil {
+			return fmt.Errorf("restore product %s: %w", snap.Products[i].ID, err)
+		}
+	}
+	for i := range snap.Markets {
+		if err := insertMarket(ctx, tx, &snap.Markets[i]); err != nil {
+			return fmt.Errorf("restore market %s: %w", snap.Markets[i].ID, err)
+		}
+	}
+	entries := make([]*model.LedgerEntry, len(snap.Ledger))
+	for i := range snap.Ledger {
+		entries[i] = &snap.Ledger[i]
+	}
+	// Entries keep the snapshot's hashes, so tampering with a snapshot shows
+	// up in VerifyChain after the restore.
+	if err := insertLedgerEntries(ctx, tx, entries); err != nil {
+		return fmt.Errorf("restore ledger: %w", err)
+	}
+	if err := saveLedgerHeads(ctx, tx, chainHeads(snap.Ledger)); err != nil {
+		return fmt.Errorf("restore ledger heads: %w", err)
+	}
+	for i := range snap.LiquidityChanges {
+		if err := insertLiquidityChange(ctx, tx, &snap.LiquidityChanges[i]); err != nil {
+			return fmt.Errorf("restore liquidity change for %s: %w", snap.LiquidityChanges[i].MarketID, err)
+		}
 	}
-	return entries, nil
+	return tx.Commit(ctx)
 }
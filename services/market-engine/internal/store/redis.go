@@ -17,16 +17,30 @@ import (
 // check Redis first then fall back to the primary.
 type CachedStore struct {
 	primary Store
-	rdb     *redis.Client
-	ttl     time.Duration
+	rdb     redis.Cmdable
+	ttls    CacheTTLs
 }
 
-// NewCachedStore creates a cached wrapper around a primary store.
-func NewCachedStore(primary Store, rdb *redis.Client, ttl time.Duration) *CachedStore {
+// CacheTTLs sets the expiry of each cache key type. Contract→ID mappings
+// never change, while positions change on every trade.
+type CacheTTLs struct {
+	Market    time.Duration // market:{id}
+	Contract  time.Duration // contract:{ticker} → market ID
+	Positions time.Duration // positions:{userID}
+}
+
+// NewCachedStore creates a cached wrapper around a primary store, using
+// ttl for every key type.
+func NewCachedStore(primary Store, rdb redis.Cmdable, ttl time.Duration) *CachedStore {
+	return NewCachedStoreWithTTLs(primary, rdb, CacheTTLs{Market: ttl, Contract: ttl, Positions: ttl})
+}
+
+// NewCachedStoreWithTTLs creates a cached wrapper with a TTL per key type.
+func NewCachedStoreWithTTLs(primary Store, rdb redis.Cmdable, ttls CacheTTLs) *CachedStore {
 	return &CachedStore{
 		primary: primary,
 		rdb:     rdb,
-		ttl:     ttl,
+		ttls:    ttls,
 	}
 }
 
@@ -40,6 +54,24 @@ func (s *CachedStore) CreateMarket(ctx context.Context, m *model.Market) error {
 	return nil
 }
 
+func (s *CachedStore) CreateProduct(ctx context.Context, p *model.Product, markets []*model.Market) error {
+	if err := s.primary.CreateProduct(ctx, p, markets); err != nil {
+		return err
+	}
+	for _, m := range markets {
+		s.cacheMarket(ctx, m)
+	}
+	return nil
+}
+
+func (s *CachedStore) GetProduct(ctx context.Context, id string) (*model.Product, error) {
+	return s.primary.GetProduct(ctx, id)
+}
+
+func (s *CachedStore) ListProductMarkets(ctx context.Context, productID string) ([]model.Market, error) {
+	return s.primary.ListProductMarkets(ctx, productID)
+}
+
 func (s *CachedStore) UpdateMarketState(ctx context.Context, id string, qYes, qNo, priceYes, priceNo decimal.Decimal) error {
 	if err := s.primary.UpdateMarketState(ctx, id, qYes, qNo, priceYes, priceNo); err != nil {
 		return err
@@ -49,6 +81,70 @@ func (s *CachedStore) UpdateMarketState(ctx context.Context, id string, qYes, qN
 	return nil
 }
 
+func (s *CachedStore) UpdateTradingHours(ctx context.Context, id string, open, close *string) error {
+	if err := s.primary.UpdateTradingHours(ctx, id, open, close); err != nil {
+		return err
+	}
+	s.rdb.Del(ctx, marketKey(id))
+	return nil
+}
+
+func (s *CachedStore) UpdateTradeSizeLimits(ctx context.Context, id string, min, max *decimal.Decimal) error {
+	if err := s.primary.UpdateTradeSizeLimits(ctx, id, min, max); err != nil {
+		return err
+	}
+	s.rdb.Del(ctx, marketKey(id))
+	return nil
+}
+
+func (s *CachedStore) UpdateLiquidity(ctx context.Context, change *model.LiquidityChange, priceYes, priceNo decimal.Decimal) error {
+	if err := s.primary.UpdateLiquidity(ctx, change, priceYes, priceNo); err != nil {
+		return err
+	}
+	s.rdb.Del(ctx, marketKey(change.MarketID))
+	return nil
+}
+
+func (s *CachedStore) GetLiquidityChanges(ctx context.Context, marketID string) ([]model.LiquidityChange, error) {
+	return s.primary.GetLiquidityChanges(ctx, marketID)
+}
+
+func (s *CachedStore) HideMarket(ctx context.Context, id string, hiddenAt time.Time) error {
+	if err := s.primary.HideMarket(ctx, id, hiddenAt); err != nil {
+		return err
+	}
+	s.rdb.Del(ctx, marketKey(id))
+	return nil
+}
+
+func (s *CachedStore) SettleMarket(ctx context.Context, id, outcome string, settledAt time.Time, entries []*model.LedgerEntry) error {
+	if err := s.primary.SettleMarket(ctx, id, outcome, settledAt, entries); err != nil {
+		return err
+	}
+	// Committed: invalidate even if the caller has gone away.
+	ctx = context.WithoutCancel(ctx)
+	keys := []string{marketKey(id)}
+	for _, e := range entries {
+		keys = append(keys, positionsKey(e.UserID))
+	}
+	s.rdb.Del(ctx, keys...)
+	return nil
+}
+
+func (s *CachedStore) UnsettleMarket(ctx context.Context, id string, entries []*model.LedgerEntry) error {
+	if err := s.primary.UnsettleMarket(ctx, id, entries); err != nil {
+		return err
+	}
+	// Committed: invalidate even if the caller has gone away.
+	ctx = context.WithoutCancel(ctx)
+	keys := []string{marketKey(id)}
+	for _, e := range entries {
+		keys = append(keys, positionsKey(e.UserID))
+	}
+	s.rdb.Del(ctx, keys...)
+	return nil
+}
+
 func (s *CachedStore) InsertLedgerEntry(ctx context.Context, entry *model.LedgerEntry) error {
 	if err := s.primary.InsertLedgerEntry(ctx, entry); err != nil {
 		return err
@@ -58,6 +154,49 @@ func (s *CachedStore) InsertLedgerEntry(ctx context.Context, entry *model.Ledger
 	return nil
 }
 
+func (s *CachedStore) ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error {
+	if err := s.primary.ApplyTrade(ctx, entries, id, qYes, qNo, priceYes, priceNo, collected, change); err != nil {
+		return err
+	}
+	// The trade is committed: invalidate even if the caller has gone away,
+	// or the cache would keep serving the old market.
+	ctx = context.WithoutCancel(ctx)
+	keys := []string{marketKey(id)}
+	for _, e := range entries {
+		keys = append(keys, positionsKey(e.UserID))
+	}
+	s.rdb.Del(ctx, keys...)
+	return nil
+}
+
+func (s *CachedStore) VerifyChain(ctx context.Context, marketID string) (*ChainReport, error) {
+	return s.primary.VerifyChain(ctx, marketID)
+}
+
+func (s *CachedStore) AnonymizeUser(ctx context.Context, userID string) (int, error) {
+	n, err := s.primary.AnonymizeUser(ctx, userID)
+	if err != nil {
+		return n, err
+	}
+	// Drop the cached positions so they stop being served under userID.
+	s.rdb.Del(context.WithoutCancel(ctx), positionsKey(userID))
+	return n, nil
+}
+
+func (s *CachedStore) InsertLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error {
+	if err := s.primary.InsertLedgerEntries(ctx, entries); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, positionsKey(e.UserID))
+	}
+	if len(keys) > 0 {
+		s.rdb.Del(ctx, keys...)
+	}
+	return nil
+}
+
 // --- Read-through (check cache first) ---
 
 func (s *CachedStore) GetMarket(ctx context.Context, id string) (*model.Market, error) {
@@ -70,8 +209,9 @@ func (s *CachedStore) GetMarket(ctx context.Context, id string) (*model.Market,
 		}
 	}
 
-	// Cache miss: read from primary.
-	m, err := s.primary.GetMarket(ctx, id)
+	// Cache miss: read from primary. Never fill from a replica, whose
+	// lag would be served to writers until the entry expires.
+	m, err := s.primary.GetMarket(WithPrimaryReads(ctx), id)
 	if err != nil {
 		return nil, err
 	}
@@ -88,17 +228,23 @@ func (s *CachedStore) GetMarketByContract(ctx context.Context, contractID string
 	}
 
 	// Cache miss.
-	m, err := s.primary.GetMarketByContract(ctx, contractID)
+	m, err := s.primary.GetMarketByContract(WithPrimaryReads(ctx), contractID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache both the market and the contract→ID mapping.
 	s.cacheMarket(ctx, m)
-	s.rdb.Set(ctx, contractKey(contractID), m.ID, s.ttl)
+	s.rdb.Set(ctx, contractKey(contractID), m.ID, s.ttls.Contract)
 	return m, nil
 }
 
+// GetMarketsByContracts reads from the primary: one query for the batch
+// is cheaper than a cache lookup per contract, and prices are fresh.
+func (s *CachedStore) GetMarketsByContracts(ctx context.Context, contractIDs []string) ([]model.Market, error) {
+	return s.primary.GetMarketsByContracts(ctx, contractIDs)
+}
+
 func (s *CachedStore) GetUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
 	// Try cache.
 	data, err := s.rdb.Get(ctx, positionsKey(userID)).Bytes()
@@ -110,13 +256,32 @@ func (s *CachedStore) GetUserPositions(ctx context.Context, userID string) ([]mo
 	}
 
 	// Cache miss.
-	positions, err := s.primary.GetUserPositions(ctx, userID)
+	positions, err := s.primary.GetUserPositions(WithPrimaryReads(ctx), userID)
 	if err != nil {
 		return nil, err
 	}
 
 	if data, err := json.Marshal(positions); err == nil {
-		s.rdb.Set(ctx, positionsKey(userID), data, s.ttl)
+		s.rdb.Set(ctx, positionsKey(userID), data, s.ttls.Positions)
+	}
+	return positions, nil
+}
+
+// RebuildUserPositions rebuilds the user's positions on the primary and
+// overwrites the cached copy with them, repairing a stale or corrupt
+// entry without waiting for it to expire.
+func (s *CachedStore) RebuildUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
+	positions, err := s.primary.RebuildUserPositions(WithPrimaryReads(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(positions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rdb.Set(ctx, positionsKey(userID), data, s.ttls.Positions).Err(); err != nil {
+		return nil, err
 	}
 	return positions, nil
 }
@@ -127,26 +292,134 @@ func (s *CachedStore) ListMarkets(ctx context.Context) ([]model.Market, error) {
 	return s.primary.ListMarkets(ctx)
 }
 
+func (s *CachedStore) ListRecentlyTradedMarkets(ctx context.Context, limit int) ([]model.Market, error) {
+	return s.primary.ListRecentlyTradedMarkets(ctx, limit)
+}
+
+func (s *CachedStore) SearchMarkets(ctx context.Context, filter MarketFilter) ([]model.Market, error) {
+	return s.primary.SearchMarkets(ctx, filter)
+}
+
+func (s *CachedStore) CountMarketsByCreator(ctx context.Context, userID string) (int, error) {
+	return s.primary.CountMarketsByCreator(ctx, userID)
+}
+
+func (s *CachedStore) ListOpenCells(ctx context.Context, prefix string) ([]model.CellSummary, error) {
+	return s.primary.ListOpenCells(ctx, prefix)
+}
+
 func (s *CachedStore) GetLedgerEntriesByMarket(ctx context.Context, marketID string) ([]model.LedgerEntry, error) {
 	return s.primary.GetLedgerEntriesByMarket(ctx, marketID)
 }
 
+func (s *CachedStore) StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error {
+	return s.primary.StreamLedgerEntriesByMarket(ctx, marketID, fn)
+}
+
 func (s *CachedStore) GetLedgerEntriesByUser(ctx context.Context, userID string) ([]model.LedgerEntry, error) {
 	return s.primary.GetLedgerEntriesByUser(ctx, userID)
 }
 
+func (s *CachedStore) GetLedgerEntry(ctx context.Context, id string) (*model.LedgerEntry, error) {
+	return s.primary.GetLedgerEntry(ctx, id)
+}
+
+// GetUserPositionsAsOf isn't cached: historical queries are rare and keyed
+// by an arbitrary time.
+func (s *CachedStore) GetUserPositionsAsOf(ctx context.Context, userID string, asOf time.Time) ([]model.Position, error) {
+	return s.primary.GetUserPositionsAsOf(ctx, userID, asOf)
+}
+
+func (s *CachedStore) GetUserMarketPosition(ctx context.Context, userID, marketID string) (*model.Position, error) {
+	return s.primary.GetUserMarketPosition(ctx, userID, marketID)
+}
+
+func (s *CachedStore) GetPositionsByUsers(ctx context.Context, userIDs []string) (map[string][]model.Position, error) {
+	return s.primary.GetPositionsByUsers(ctx, userIDs)
+}
+
+func (s *CachedStore) GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	return s.primary.GetLeaderboard(ctx, since, limit)
+}
+
 func (s *CachedStore) GetUserCellExposures(ctx context.Context, userID string) (map[string]decimal.Decimal, error) {
 	return s.primary.GetUserCellExposures(ctx, userID)
 }
 
+func (s *CachedStore) GetCellExposures(ctx context.Context) (map[string]decimal.Decimal, error) {
+	return s.primary.GetCellExposures(ctx)
+}
+
 // --- Cache helpers ---
 
 func (s *CachedStore) cacheMarket(ctx context.Context, m *model.Market) {
 	if data, err := json.Marshal(m); err == nil {
-		s.rdb.Set(ctx, marketKey(m.ID), data, s.ttl)
+		s.rdb.Set(ctx, marketKey(m.ID), data, s.ttls.Market)
 	}
 }
 
 func marketKey(id string) string      { return fmt.Sprintf("market:%s", id) }
 func contractKey(id string) string    { return fmt.Sprintf("contract:%s", id) }
 func positionsKey(uid string) string  { return fmt.Sprintf("positions:%s", uid) }
+
+func (s *CachedStore) InsertObservation(ctx context.Context, obs *model.Observation) error {
+	return s.primary.InsertObservation(ctx, obs)
+}
+
+func (s *CachedStore) GetObservation(ctx context.Context, h3CellID, obsType, date string) (*model.Observation, error) {
+	return s.primary.GetObservation(ctx, h3CellID, obsType, date)
+}
+
+func (s *CachedStore) InsertAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	return s.primary.InsertAuditEvent(ctx, event)
+}
+
+func (s *CachedStore) ListAuditEvents(ctx context.Context, target string, limit int) ([]model.AuditEvent, error) {
+	return s.primary.ListAuditEvents(ctx, target, limit)
+}
+
+func (s *CachedStore) Snapshot(ctx context.Context) (*model.Snapshot, error) {
+	return s.primary.Snapshot(ctx)
+}
+
+// Restore drops the cached markets, contract lookups and positions of both
+// the replaced state and the restored one, so no stale entry survives.
+func (s *CachedStore) Restore(ctx context.Context, snap *model.Snapshot, force bool) error {
+	var old *model.Snapshot
+	if force {
+		var err error
+		if old, err = s.primary.Snapshot(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.primary.Restore(ctx, snap, force); err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	keys := make(map[string]struct{})
+	for _, sn := range []*model.Snapshot{old, snap} {
+		if sn == nil {
+			continue
+		}
+		for _, m := range sn.Markets {
+			keys[marketKey(m.ID)] = struct{}{}
+			keys[contractKey(m.ContractID)] = struct{}{}
+		}
+		for _, e := range sn.Ledger {
+			keys[positionsKey(e.UserID)] = struct{}{}
+		}
+	}
+	batch := make([]string, 0, 500)
+	for k := range keys {
+		batch = append(batch, k)
+		if len(batch) == cap(batch) {
+			s.rdb.Del(ctx, batch...)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		s.rdb.Del(ctx, batch...)
+	}
+	return nil
+}
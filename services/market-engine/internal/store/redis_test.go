@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// fakeCacheRedis implements the subset of redis.Cmdable used by
+// CachedStore, recording the TTL each key was set with. The first
+// pingFailures pings fail, emulating a Redis that isn't reachable yet.
+type fakeCacheRedis struct {
+	redis.Cmdable
+
+	mu           sync.Mutex
+	vals         map[string]string
+	ttls         map[string]time.Duration
+	pingFailures int
+	pings        int
+}
+
+func newFakeCacheRedis() *fakeCacheRedis {
+	return &fakeCacheRedis{vals: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (f *fakeCacheRedis) Ping(ctx context.Context) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pings++
+	if f.pings <= f.pingFailures {
+		return redis.NewStatusResult("", errors.New("connection refused"))
+	}
+	return redis.NewStatusResult("PONG", nil)
+}
+
+func (f *fakeCacheRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.vals[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (f *fakeCacheRedis) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		f.vals[key] = string(v)
+	default:
+		f.vals[key] = fmt.Sprint(v)
+	}
+	f.ttls[key] = ttl
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeCacheRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.vals, k)
+		delete(f.ttls, k)
+	}
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+func (f *fakeCacheRedis) has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.vals[key]
+	return ok
+}
+
+func TestCachedStore_TTLPerKeyType(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+	m := &model.Market{ID: "m1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", Status: "open", B: d(100)}
+	if err := ms.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	ms.InsertLedgerEntry(ctx, &model.LedgerEntry{ID: "e1", UserID: "user1", MarketID: "m1", Side: "YES", Quantity: d(1)})
+
+	rdb := newFakeCacheRedis()
+	ttls := CacheTTLs{Market: 30 * time.Second, Contract: time.Hour, Positions: 5 * time.Second}
+	cs := NewCachedStoreWithTTLs(ms, rdb, ttls)
+
+	if _, err := cs.GetMarketByContract(ctx, m.ContractID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.GetUserPositions(ctx, "user1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]time.Duration{
+		marketKey("m1"):           ttls.Market,
+		contractKey(m.ContractID): ttls.Contract,
+		positionsKey("user1"):     ttls.Positions,
+	} {
+		got, ok := rdb.ttls[key]
+		if !ok {
+			t.Errorf("%s was not cached", key)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s TTL = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestCachedStore_SingleTTLDefault(t *testing.T) {
+	ms := NewMemoryStore()
+	ctx := context.Background()
+	m := &model.Market{ID: "m1", ContractID: "c1", Status: "open", B: d(100)}
+	ms.CreateMarket(ctx, m)
+
+	rdb := newFakeCacheRedis()
+	cs := NewCachedStore(ms, rdb, 42*time.Second)
+	cs.GetMarketByContract(ctx, "c1")
+	cs.GetUserPositions(ctx, "user1")
+
+	for _, key := range []string{marketKey("m1"), contractKey("c1"), positionsKey("user1")} {
+		if got := rdb.ttls[key]; got != 42*time.Second {
+			t.Errorf("%s TTL = %v, want 42s", key, got)
+		}
+	}
+}
+
+func TestCachedStore_RebuildUserPositionsOverwritesCache(t *testing.T) {
+	ms := seedMemoryStore(t)
+	ctx := context.Background()
+	rdb := newFakeCacheRedis()
+	cs := NewCachedStore(ms, rdb, time.Minute)
+
+	// A corrupt cache entry is served until it expires.
+	rdb.Set(ctx, positionsKey("user1"), []byte(`[{"market_id":"m1","yes_qty":"999"}]`), time.Minute)
+	if got, _ := cs.GetUserPositions(ctx, "user1"); len(got) != 1 || !got[0].YesQty.Equal(d(999)) {
+		t.Fatalf("positions before rebuild = %+v, want the corrupt cache entry", got)
+	}
+
+	positions, err := cs.RebuildUserPositions(ctx, "user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 1 || !positions[0].YesQty.Equal(d(10)) {
+		t.Fatalf("rebuilt positions = %+v, want 10 YES", positions)
+	}
+	if got, _ := cs.GetUserPositions(ctx, "user1"); len(got) != 1 || !got[0].YesQty.Equal(d(10)) {
+		t.Errorf("positions after rebuild = %+v, want 10 YES from the cache", got)
+	}
+	if !rdb.has(positionsKey("user1")) {
+		t.Error("rebuild left the positions uncached")
+	}
+}
@@ -0,0 +1,56 @@
+// Read replica routing for PostgresStore.
+
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxConn is the part of *pgxpool.Pool PostgresStore queries through.
+type pgxConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+}
+
+// NewPostgresStoreWithReplica creates a PostgreSQL-backed store that
+// sends writes to primary and may serve market, history and position
+// reads from replica. Reads use the replica only when the context allows
+// it (see WithReplicaReads): a replica lags the primary, so anything that
+// prices, settles or limits trades must keep reading the primary. A nil
+// replica means every query goes to primary.
+func NewPostgresStoreWithReplica(primary, replica *pgxpool.Pool) *PostgresStore {
+	s := NewPostgresStore(primary)
+	if replica != nil {
+		s.replica = replica
+	}
+	return s
+}
+
+type replicaReadsKey struct{}
+
+// WithReplicaReads marks ctx as tolerating replication lag, so reads made
+// with it may be served by a read replica. Use it for read-only requests.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// WithPrimaryReads undoes WithReplicaReads, e.g. for a read whose result
+// is cached and later served to writers.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, false)
+}
+
+// reader returns the connection reads with ctx should use.
+func (s *PostgresStore) reader(ctx context.Context) pgxConn {
+	if ok, _ := ctx.Value(replicaReadsKey{}).(bool); ok && s.replica != nil {
+		return s.replica
+	}
+	return s.pool
+}
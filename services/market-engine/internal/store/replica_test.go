@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+var errRecorded = errors.New("recorded")
+
+// recordingConn counts the statements sent to it and fails each one, so
+// tests can see which pool a store method used without a database.
+type recordingConn struct{ calls int }
+
+func (c *recordingConn) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	c.calls++
+	return pgconn.CommandTag{}, errRecorded
+}
+
+func (c *recordingConn) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	c.calls++
+	return nil, errRecorded
+}
+
+func (c *recordingConn) QueryRow(context.Context, string, ...any) pgx.Row {
+	c.calls++
+	return errRow{}
+}
+
+func (c *recordingConn) Begin(context.Context) (pgx.Tx, error) {
+	c.calls++
+	return nil, errRecorded
+}
+
+func (c *recordingConn) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	c.calls++
+	return nil, errRecorded
+}
+
+type errRow struct{}
+
+func (errRow) Scan(...any) error { return errRecorded }
+
+func TestPostgresStore_ReplicaRouting(t *testing.T) {
+	primary, replica := &recordingConn{}, &recordingConn{}
+	s := &PostgresStore{pool: primary, replica: replica}
+	ctx := context.Background()
+	readCtx := WithReplicaReads(ctx)
+
+	reads := map[string]func(context.Context) error{
+		"GetMarket": func(ctx context.Context) error {
+			_, err := s.GetMarket(ctx, "m1")
+			return err
+		},
+		"ListMarkets": func(ctx context.Context) error {
+			_, err := s.ListMarkets(ctx)
+			return err
+		},
+		"GetLedgerEntriesByMarket": func(ctx context.Context) error {
+			_, err := s.GetLedgerEntriesByMarket(ctx, "m1")
+			return err
+		},
+		"GetUserPositions": func(ctx context.Context) error {
+			_, err := s.GetUserPositions(ctx, "user1")
+			return err
+		},
+	}
+	for name, read := range reads {
+		*primary, *replica = recordingConn{}, recordingConn{}
+		read(readCtx)
+		if replica.calls != 1 || primary.calls != 0 {
+			t.Errorf("%s with replica reads: replica %d, primary %d calls; want the replica", name, replica.calls, primary.calls)
+		}
+
+		*primary, *replica = recordingConn{}, recordingConn{}
+		read(ctx)
+		if primary.calls != 1 || replica.calls != 0 {
+			t.Errorf("%s without replica reads: primary %d, replica %d calls; want the primary", name, primary.calls, replica.calls)
+		}
+
+		*primary, *replica = recordingConn{}, recordingConn{}
+		read(WithPrimaryReads(readCtx))
+		if primary.calls != 1 || replica.calls != 0 {
+			t.Errorf("%s with primary reads: primary %d, replica %d calls; want the primary", name, primary.calls, replica.calls)
+		}
+	}
+
+	// Writes go to the primary even from a read-only context.
+	*primary, *replica = recordingConn{}, recordingConn{}
+	s.CreateMarket(readCtx, &model.Market{ID: "m1", CreatedAt: time.Now()})
+	s.UpdateMarketState(readCtx, "m1", d(1), d(0), d(0.5), d(0.5))
+	if primary.calls != 2 || replica.calls != 0 {
+		t.Errorf("writes: primary %d, replica %d calls; want both on the primary", primary.calls, replica.calls)
+	}
+}
+
+func TestNewPostgresStoreWithReplica_NilFallsBackToPrimary(t *testing.T) {
+	// pgxpool.New doesn't connect until the pool is used.
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	s := NewPostgresStoreWithReplica(pool, nil)
+	if s.replica != nil {
+		t.Fatalf("replica = %v, want nil", s.replica)
+	}
+	if got := s.reader(WithReplicaReads(context.Background())); got != pgxConn(pool) {
+		t.Errorf("reader = %v, want the primary pool", got)
+	}
+}
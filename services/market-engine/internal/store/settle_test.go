@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testSettleMarketAtomic checks a settlement whose payouts fail to insert
+// leaves the market open with no payouts, so it can be retried, and that a
+// successful one closes the market and chains its payouts.
+func testSettleMarketAtomic(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	m := &model.Market{
+		ID:         uuid.New().String(),
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+		H3CellID:   "872a1070b",
+		B:          d(100),
+		PriceYes:   d(0.5),
+		PriceNo:    d(0.5),
+		Status:     "open",
+		CreatedAt:  now,
+	}
+	if err := st.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	trade := &model.LedgerEntry{
+		ID: uuid.New().String(), UserID: "user1", MarketID: m.ID, ContractID: m.ContractID,
+		Side: "YES", Quantity: d(10), Price: d(0.6), Cost: d(6), Timestamp: now,
+	}
+	if err := st.InsertLedgerEntry(ctx, trade); err != nil {
+		t.Fatal(err)
+	}
+
+	payout := func(id string) *model.LedgerEntry {
+		return &model.LedgerEntry{
+			ID: id, UserID: "user1", MarketID: m.ID, ContractID: m.ContractID,
+			Side: "YES", Quantity: d(-10), Price: d(1), Cost: d(-10), RealizedPnL: d(4),
+			Timestamp: now.Add(time.Second), Kind: model.EntryKindSettlement,
+		}
+	}
+
+	// Reusing the trade's ID makes the payout insert fail part way.
+	failing := []*model.LedgerEntry{payout(uuid.New().String()), payout(trade.ID)}
+	if err := st.SettleMarket(ctx, m.ID, "YES", now, failing); !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Fatalf("failing payouts: err = %v, want ErrDuplicateLedgerEntry", err)
+	}
+	got, err := st.GetMarket(WithPrimaryReads(ctx), m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "open" || got.Outcome != nil {
+		t.Errorf("after failed settle: status %s, outcome %v; want open with none", got.Status, got.Outcome)
+	}
+	if entries, _ := st.GetLedgerEntriesByMarket(ctx, m.ID); len(entries) != 1 {
+		t.Errorf("after failed settle: %d ledger entries, want only the trade", len(entries))
+	}
+
+	// The retry goes through.
+	if err := st.SettleMarket(ctx, m.ID, "YES", now, []*model.LedgerEntry{payout(uuid.New().String())}); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got, _ := st.GetMarket(WithPrimaryReads(ctx), m.ID); got.Status != "settled" {
+		t.Errorf("after retry: status %s, want settled", got.Status)
+	}
+	if entries, _ := st.GetLedgerEntriesByMarket(ctx, m.ID); len(entries) != 2 || entries[1].Kind != model.EntryKindSettlement {
+		t.Errorf("after retry: ledger = %+v, want the trade then its payout", entries)
+	}
+	if report, err := st.VerifyChain(ctx, m.ID); err != nil || !report.OK {
+		t.Errorf("chain after settle: %+v, %v", report, err)
+	}
+	if err := st.SettleMarket(ctx, m.ID, "NO", now, nil); !errors.Is(err, ErrMarketNotOpen) {
+		t.Errorf("second settle: err = %v, want ErrMarketNotOpen", err)
+	}
+}
+
+func TestMemoryStore_SettleMarketAtomic(t *testing.T) {
+	testSettleMarketAtomic(t, NewMemoryStore())
+}
+
+// TestPostgresStore_SettleMarketAtomic runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_SettleMarketAtomic(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testSettleMarketAtomic(t, NewPostgresStore(pool))
+}
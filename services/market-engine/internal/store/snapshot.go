@@ -0,0 +1,50 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// checkSnapshot reports the first inconsistency in snap that would leave a
+// restored store broken: a duplicate ID or contract, a market in a product
+// the snapshot lacks, or a ledger entry or liquidity change for a market
+// it lacks.
+func checkSnapshot(snap *model.Snapshot) error {
+	products := make(map[string]bool, len(snap.Products))
+	for _, p := range snap.Products {
+		if products[p.ID] {
+			return fmt.Errorf("%w: duplicate product %s", ErrInvalidSnapshot, p.ID)
+		}
+		products[p.ID] = true
+	}
+
+	markets := make(map[string]bool, len(snap.Markets))
+	contracts := make(map[string]bool, len(snap.Markets))
+	for _, m := range snap.Markets {
+		if markets[m.ID] || contracts[m.ContractID] {
+			return fmt.Errorf("%w: duplicate market %s (%s)", ErrInvalidSnapshot, m.ID, m.ContractID)
+		}
+		markets[m.ID], contracts[m.ContractID] = true, true
+		if m.ProductID != nil && !products[*m.ProductID] {
+			return fmt.Errorf("%w: market %s is in missing product %s", ErrInvalidSnapshot, m.ID, *m.ProductID)
+		}
+	}
+
+	entries := make(map[string]bool, len(snap.Ledger))
+	for _, e := range snap.Ledger {
+		if entries[e.ID] {
+			return fmt.Errorf("%w: duplicate ledger entry %s", ErrInvalidSnapshot, e.ID)
+		}
+		entries[e.ID] = true
+		if !markets[e.MarketID] {
+			return fmt.Errorf("%w: ledger entry %s is for missing market %s", ErrInvalidSnapshot, e.ID, e.MarketID)
+		}
+	}
+	for _, c := range snap.LiquidityChanges {
+		if !markets[c.MarketID] {
+			return fmt.Errorf("%w: liquidity change for missing market %s", ErrInvalidSnapshot, c.MarketID)
+		}
+	}
+	return nil
+}
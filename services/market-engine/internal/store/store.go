@@ -5,11 +5,83 @@ package store
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/atmx/market-engine/internal/model"
 	"github.com/shopspring/decimal"
 )
 
+// ErrDuplicateLedgerEntry is returned by InsertLedgerEntry when an entry
+// with the same ID already exists. Callers that pre-set IDs for
+// idempotency can treat it as "already recorded".
+var ErrDuplicateLedgerEntry = errors.New("store: duplicate ledger entry ID")
+
+// ErrDuplicateObservation is returned by InsertObservation when an
+// observation for the same cell, type and date is already on record.
+var ErrDuplicateObservation = errors.New("store: observation already recorded")
+
+// ErrAlreadyExists is returned (wrapped) by CreateMarket and CreateProduct
+// when the product, or a market for the same contract, already exists.
+var ErrAlreadyExists = errors.New("store: already exists")
+
+// ErrNotFound is returned (wrapped) by every implementation when the
+// requested row does not exist, so callers can tell a missing market from
+// a failing backend with errors.Is.
+var ErrNotFound = errors.New("store: not found")
+
+// ErrObservationNotFound is returned by GetObservation when no matching
+// observation exists. It wraps ErrNotFound.
+var ErrObservationNotFound = fmt.Errorf("%w: observation", ErrNotFound)
+
+// ErrMarketNotOpen is returned by SettleMarket when the market has
+// already settled.
+var ErrMarketNotOpen = errors.New("store: market is not open")
+
+// ErrMarketNotSettled is returned by UnsettleMarket when the market is
+// not settled.
+var ErrMarketNotSettled = errors.New("store: market is not settled")
+
+// ErrStoreNotEmpty is returned by Restore when the store already holds
+// products, markets or ledger entries and force is not set.
+var ErrStoreNotEmpty = errors.New("store: not empty")
+
+// ErrInvalidSnapshot is returned (wrapped) by Restore for a snapshot that
+// is inconsistent on its own, such as an entry for a market it lacks.
+var ErrInvalidSnapshot = errors.New("store: invalid snapshot")
+
+// AnonymousUserPrefix starts every user ID written by AnonymizeUser.
+const AnonymousUserPrefix = "anon-"
+
+// isAnonymousUserID reports whether id was written by AnonymizeUser.
+func isAnonymousUserID(id string) bool {
+	return strings.HasPrefix(id, AnonymousUserPrefix)
+}
+
+// newAnonymousUserID returns a random user ID for AnonymizeUser.
+func newAnonymousUserID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return AnonymousUserPrefix + hex.EncodeToString(b[:]), nil
+}
+
+// MarketFilter selects markets by contract attributes. Zero-valued fields
+// are not applied.
+type MarketFilter struct {
+	Type         string    // contract type, e.g. "PRECIP"
+	H3Prefix     string    // H3 cell ID prefix
+	ExpiryBefore time.Time // contract date strictly before this day
+	Status       string    // "open" or "settled"
+
+	IncludeHidden bool // also return soft-deleted markets
+}
+
 // Store is the persistence interface. PostgreSQL is the source of truth;
 // Redis provides a read-through cache layer.
 type Store interface {
@@ -24,28 +96,198 @@ type Store interface {
 	// GetMarketByContract retrieves a market by its contract ticker.
 	GetMarketByContract(ctx context.Context, contractID string) (*model.Market, error)
 
-	// ListMarkets returns all markets.
+	// GetMarketsByContracts retrieves the markets for many contract tickers
+	// in one round trip. Tickers without a market are left out, so the
+	// result may be shorter than contractIDs.
+	GetMarketsByContracts(ctx context.Context, contractIDs []string) ([]model.Market, error)
+
+	// ListMarkets returns all markets that are not hidden, newest first.
+	// Markets created at the same time are ordered by ID, so the order is
+	// the same on every call.
 	ListMarkets(ctx context.Context) ([]model.Market, error)
 
+	// ListRecentlyTradedMarkets returns up to limit markets ordered by their
+	// latest ledger entry, most recent first. Untraded markets are omitted.
+	ListRecentlyTradedMarkets(ctx context.Context, limit int) ([]model.Market, error)
+
+	// SearchMarkets returns markets matching filter, newest first.
+	SearchMarkets(ctx context.Context, filter MarketFilter) ([]model.Market, error)
+
+	// CountMarketsByCreator returns how many open, visible markets userID
+	// created. It reads the primary, since it guards market creation.
+	CountMarketsByCreator(ctx context.Context, userID string) (int, error)
+
+	// ListOpenCells returns the distinct H3 cells with at least one open,
+	// visible market whose ID starts with prefix ("" = all), ordered by cell ID.
+	ListOpenCells(ctx context.Context, prefix string) ([]model.CellSummary, error)
+
 	// UpdateMarketState updates quantities and prices after a trade.
 	UpdateMarketState(ctx context.Context, id string, qYes, qNo, priceYes, priceNo decimal.Decimal) error
 
+	// ApplyTrade records entries and moves market id to the given state,
+	// quantities, prices and collected cash (see model.Market.Collected),
+	// atomically: both happen or neither does, including when ctx is
+	// cancelled part way. A non-nil change is the b the trade was priced
+	// at; it is applied and recorded as by UpdateLiquidity in the same
+	// transaction. It returns ErrDuplicateLedgerEntry, changing nothing, if
+	// any entry ID is already in the ledger.
+	ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error
+
+	// UpdateTradingHours sets a market's daily trading window; nil clears it.
+	UpdateTradingHours(ctx context.Context, id string, open, close *string) error
+
+	// UpdateTradeSizeLimits sets a market's per-trade |quantity| bounds;
+	// nil clears a bound.
+	UpdateTradeSizeLimits(ctx context.Context, id string, min, max *decimal.Decimal) error
+
+	// UpdateLiquidity sets a market's b to change.NewB and its liquidity
+	// inputs to change.Inputs, with the prices recomputed for it, and
+	// records change, atomically.
+	UpdateLiquidity(ctx context.Context, change *model.LiquidityChange, priceYes, priceNo decimal.Decimal) error
+
+	// GetLiquidityChanges returns a market's b changes, oldest first.
+	GetLiquidityChanges(ctx context.Context, marketID string) ([]model.LiquidityChange, error)
+
+	// HideMarket soft-deletes a market at hiddenAt. Hiding an already
+	// hidden market is a no-op that keeps the original timestamp.
+	HideMarket(ctx context.Context, id string, hiddenAt time.Time) error
+
+	// SettleMarket marks an open market settled with outcome at settledAt
+	// and appends entries (its settlement payouts) in one transaction. It
+	// returns ErrMarketNotOpen, changing nothing, if the market is already
+	// settled.
+	SettleMarket(ctx context.Context, id, outcome string, settledAt time.Time, entries []*model.LedgerEntry) error
+
+	// UnsettleMarket reopens a settled market, clearing its outcome, and
+	// appends entries (the reversals of its settlement entries) in one
+	// transaction. It returns ErrMarketNotSettled, changing nothing, if
+	// the market is not settled.
+	UnsettleMarket(ctx context.Context, id string, entries []*model.LedgerEntry) error
+
+	// --- Products ---
+
+	// CreateProduct persists product and its member markets atomically:
+	// if any market can't be created (e.g. its contract already has a
+	// market), nothing is.
+	CreateProduct(ctx context.Context, product *model.Product, markets []*model.Market) error
+
+	// GetProduct retrieves a product by its ID.
+	GetProduct(ctx context.Context, id string) (*model.Product, error)
+
+	// ListProductMarkets returns a product's member markets, hidden ones
+	// included, ordered by H3 cell.
+	ListProductMarkets(ctx context.Context, productID string) ([]model.Market, error)
+
 	// --- Immutable ledger ---
 
-	// InsertLedgerEntry appends an immutable trade record.
+	// InsertLedgerEntry appends an immutable trade record, chained to its
+	// market's previous entry (PrevHash and Hash are set by the store; the
+	// same applies to every method that writes entries, except Restore,
+	// which keeps the snapshot's). It returns ErrDuplicateLedgerEntry if
+	// entry.ID is already in the ledger.
 	InsertLedgerEntry(ctx context.Context, entry *model.LedgerEntry) error
 
+	// InsertLedgerEntries appends entries in order, all or nothing. It
+	// returns ErrDuplicateLedgerEntry if any ID is already in the ledger or
+	// repeated within entries.
+	InsertLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error
+
 	// GetLedgerEntriesByMarket returns all trades for a market.
 	GetLedgerEntriesByMarket(ctx context.Context, marketID string) ([]model.LedgerEntry, error)
 
+	// StreamLedgerEntriesByMarket calls fn with each of a market's ledger
+	// entries in timestamp order, without loading them all first. It stops
+	// at and returns the first error from fn.
+	StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error
+
 	// GetLedgerEntriesByUser returns all trades for a user.
 	GetLedgerEntriesByUser(ctx context.Context, userID string) ([]model.LedgerEntry, error)
 
+	// GetLedgerEntry returns the ledger entry with id, or ErrNotFound.
+	GetLedgerEntry(ctx context.Context, id string) (*model.LedgerEntry, error)
+
+	// VerifyChain checks a market's ledger hash chain (see HashLedgerEntry)
+	// and reports every entry that was altered, removed from or inserted
+	// into it. It returns ErrNotFound if the market doesn't exist.
+	VerifyChain(ctx context.Context, marketID string) (*ChainReport, error)
+
+	// AnonymizeUser replaces userID on every one of the user's ledger
+	// entries with one fresh anonymous ID (see AnonymousUserPrefix) and
+	// returns how many entries it rewrote. Nothing else about the entries
+	// changes. The anonymous ID is random and not returned, so it can't be
+	// traced back to userID. A user with no entries, or an ID that is
+	// already anonymous, is a no-op.
+	AnonymizeUser(ctx context.Context, userID string) (int, error)
+
 	// --- Position queries ---
 
 	// GetUserPositions computes aggregate positions from the ledger.
 	GetUserPositions(ctx context.Context, userID string) ([]model.Position, error)
 
+	// GetUserPositionsAsOf computes positions from only the user's ledger
+	// entries with timestamp <= asOf. Like GetUserPositions it marks them
+	// to each market's current price; callers wanting a historical mark
+	// re-value them.
+	GetUserPositionsAsOf(ctx context.Context, userID string, asOf time.Time) ([]model.Position, error)
+
+	// GetPositionsByUsers computes positions for many users in one query,
+	// keyed by user ID. Users with no trades are absent from the map.
+	GetPositionsByUsers(ctx context.Context, userIDs []string) (map[string][]model.Position, error)
+
+	// GetUserMarketPosition computes one user's position in one market, or
+	// returns ErrNotFound if the user has never traded it.
+	GetUserMarketPosition(ctx context.Context, userID, marketID string) (*model.Position, error)
+
+	// RebuildUserPositions recomputes the user's positions from the ledger
+	// on the primary, ignoring any derived copy (a position index or
+	// cache), replaces that copy with the result and returns it. It repairs
+	// a copy that has drifted from the ledger.
+	RebuildUserPositions(ctx context.Context, userID string) ([]model.Position, error)
+
+	// GetLeaderboard ranks users by realized P&L over ledger entries at or
+	// after since (zero = all time), returning at most limit rows.
+	GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]model.LeaderboardEntry, error)
+
 	// GetUserCellExposures returns net directional exposure per H3 cell.
+	// Cells whose trades net to exactly zero are left out, so they don't
+	// count toward the correlated-cell limit.
 	GetUserCellExposures(ctx context.Context, userID string) (map[string]decimal.Decimal, error)
+
+	// GetCellExposures returns, per H3 cell, every user's absolute net
+	// exposure there summed: Σ over users of |YES - NO|. Cells where no
+	// user holds a net position are omitted.
+	GetCellExposures(ctx context.Context) (map[string]decimal.Decimal, error)
+
+	// --- Settlement reference data ---
+
+	// InsertObservation records an observed value. It returns
+	// ErrDuplicateObservation if the cell, type and date already have one.
+	InsertObservation(ctx context.Context, obs *model.Observation) error
+
+	// GetObservation returns the observation for a cell, type and date
+	// (YYYY-MM-DD), or ErrObservationNotFound.
+	GetObservation(ctx context.Context, h3CellID, obsType, date string) (*model.Observation, error)
+
+	// --- Admin audit trail ---
+
+	// InsertAuditEvent records an admin action.
+	InsertAuditEvent(ctx context.Context, event *model.AuditEvent) error
+
+	// ListAuditEvents returns up to limit audit events for target, newest
+	// first. An empty target lists events for every target.
+	ListAuditEvents(ctx context.Context, target string, limit int) ([]model.AuditEvent, error)
+
+	// --- Disaster recovery ---
+
+	// Snapshot exports every product, market, ledger entry and liquidity
+	// change as of one point in time. Version and CreatedAt are left for the caller to set.
+	Snapshot(ctx context.Context) (*model.Snapshot, error)
+
+	// Restore loads snap in one transaction. It returns ErrStoreNotEmpty
+	// if the store holds any product, market or ledger entry, unless force
+	// is set, in which case those and the liquidity change history are
+	// replaced; observations and the audit log are kept. A snapshot whose
+	// markets or entries reference products or markets it doesn't contain
+	// is rejected with ErrInvalidSnapshot, changing nothing.
+	Restore(ctx context.Context, snap *model.Snapshot, force bool) error
 }
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// testUnsettleMarket checks a settled market reopens with its reversal
+// entries chained into the ledger, and that an open market is refused.
+func testUnsettleMarket(t *testing.T, st Store) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	m := &model.Market{
+		ID:         uuid.New().String(),
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-" + uuid.New().String(),
+		H3CellID:   "872a1070b",
+		B:          d(100),
+		PriceYes:   d(0.5),
+		PriceNo:    d(0.5),
+		Status:     "open",
+		CreatedAt:  now,
+	}
+	if err := st.CreateMarket(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UnsettleMarket(ctx, m.ID, nil); !errors.Is(err, ErrMarketNotSettled) {
+		t.Errorf("open market: err = %v, want ErrMarketNotSettled", err)
+	}
+
+	settlement := &model.LedgerEntry{
+		ID: uuid.New().String(), UserID: "user1", MarketID: m.ID, ContractID: m.ContractID,
+		Side: "YES", Quantity: d(-10), Price: d(1), Cost: d(-10), RealizedPnL: d(4),
+		Timestamp: now, Kind: model.EntryKindSettlement,
+	}
+	if err := st.SettleMarket(ctx, m.ID, "YES", now, []*model.LedgerEntry{settlement}); err != nil {
+		t.Fatal(err)
+	}
+
+	reversal := &model.LedgerEntry{
+		ID: uuid.New().String(), UserID: "user1", MarketID: m.ID, ContractID: m.ContractID,
+		Side: "YES", Quantity: d(10), Price: d(1), Cost: d(10), RealizedPnL: d(-4),
+		Timestamp: now.Add(time.Second), Kind: model.EntryKindSettlementReversal, Reverses: settlement.ID,
+	}
+	if err := st.UnsettleMarket(ctx, m.ID, []*model.LedgerEntry{reversal}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.GetMarket(WithPrimaryReads(ctx), m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "open" || got.Outcome != nil || got.SettledAt != nil {
+		t.Errorf("market = %s, outcome %v, settled_at %v; want open with neither", got.Status, got.Outcome, got.SettledAt)
+	}
+	entries, err := st.GetLedgerEntriesByMarket(ctx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(entries); n != 2 || entries[1].Reverses != settlement.ID || entries[1].Kind != model.EntryKindSettlementReversal {
+		t.Errorf("ledger = %+v, want the settlement then its reversal", entries)
+	}
+	if report, err := st.VerifyChain(ctx, m.ID); err != nil || !report.OK {
+		t.Errorf("chain after unsettle: %+v, %v", report, err)
+	}
+}
+
+func TestMemoryStore_UnsettleMarket(t *testing.T) {
+	testUnsettleMarket(t, NewMemoryStore())
+}
+
+// TestPostgresStore_UnsettleMarket runs against the database in
+// TEST_DATABASE_URL, which should be a scratch database.
+func TestPostgresStore_UnsettleMarket(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+	testUnsettleMarket(t, NewPostgresStore(pool))
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Backoff bounds for WarmAsync while Redis is unreachable.
+var (
+	warmRetryMin = time.Second
+	warmRetryMax = 30 * time.Second
+)
+
+// Warm loads the n most recently traded markets, and their contract→ID
+// mappings, into Redis so the first reads after a cold start don't all fall
+// through to the primary. It returns the number of markets cached.
+func (s *CachedStore) Warm(ctx context.Context, n int) (int, error) {
+	if err := s.rdb.Ping(ctx).Err(); err != nil {
+		return 0, fmt.Errorf("redis unavailable: %w", err)
+	}
+
+	markets, err := s.primary.ListRecentlyTradedMarkets(ctx, n)
+	if err != nil {
+		return 0, fmt.Errorf("list recently traded markets: %w", err)
+	}
+	for i := range markets {
+		m := &markets[i]
+		s.cacheMarket(ctx, m)
+		s.rdb.Set(ctx, contractKey(m.ContractID), m.ID, s.ttls.Contract)
+	}
+	return len(markets), nil
+}
+
+// WarmAsync runs Warm in the background, retrying with exponential backoff
+// until it succeeds or ctx is done, so a Redis that comes up after the
+// server doesn't delay startup. The returned channel is closed when the
+// warmer exits.
+func (s *CachedStore) WarmAsync(ctx context.Context, n int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		delay := warmRetryMin
+		for {
+			start := time.Now()
+			cached, err := s.Warm(ctx, n)
+			if err == nil {
+				slog.Info("redis cache warmed", "markets", cached, "duration", time.Since(start))
+				return
+			}
+			slog.Warn("redis cache warm failed, retrying", "err", err, "retry_in", delay)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, warmRetryMax)
+		}
+	}()
+	return done
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// seedTradedMarkets creates n markets m0..m{n-1}; market mi's only trade
+// is i minutes after base, so higher indexes are more recently active.
+func seedTradedMarkets(t *testing.T, ms *MemoryStore, n int) {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("m%d", i)
+		if err := ms.CreateMarket(ctx, &model.Market{ID: id, ContractID: "contract-" + id, Status: "open", B: d(100)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ms.InsertLedgerEntry(ctx, &model.LedgerEntry{
+			ID: "e-" + id, UserID: "user1", MarketID: id, Side: "YES", Quantity: d(1),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A market nobody has traded is never warmed.
+	if err := ms.CreateMarket(ctx, &model.Market{ID: "idle", ContractID: "contract-idle", Status: "open", B: d(100)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachedStore_WarmLoadsMostRecentlyTraded(t *testing.T) {
+	ms := NewMemoryStore()
+	seedTradedMarkets(t, ms, 5)
+	rdb := newFakeCacheRedis()
+	cs := NewCachedStore(ms, rdb, time.Minute)
+
+	n, err := cs.Warm(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 markets warmed, got %d", n)
+	}
+
+	for _, id := range []string{"m4", "m3", "m2"} {
+		if !rdb.has(marketKey(id)) || !rdb.has(contractKey("contract-"+id)) {
+			t.Errorf("expected %s to be cached", id)
+		}
+	}
+	for _, id := range []string{"m1", "m0", "idle"} {
+		if rdb.has(marketKey(id)) {
+			t.Errorf("expected %s not to be cached", id)
+		}
+	}
+
+	var cached model.Market
+	if err := json.Unmarshal([]byte(rdb.vals[marketKey("m4")]), &cached); err != nil || cached.ContractID != "contract-m4" {
+		t.Errorf("cached market does not decode: %v %+v", err, cached)
+	}
+}
+
+func TestCachedStore_WarmAsyncRetriesUntilRedisIsUp(t *testing.T) {
+	defer func(lo, hi time.Duration) { warmRetryMin, warmRetryMax = lo, hi }(warmRetryMin, warmRetryMax)
+	warmRetryMin, warmRetryMax = time.Millisecond, 4*time.Millisecond
+
+	ms := NewMemoryStore()
+	seedTradedMarkets(t, ms, 2)
+	rdb := newFakeCacheRedis()
+	rdb.pingFailures = 3
+	cs := NewCachedStore(ms, rdb, time.Minute)
+
+	select {
+	case <-cs.WarmAsync(context.Background(), 10):
+	case <-time.After(2 * time.Second):
+		t.Fatal("warmer did not finish")
+	}
+	if rdb.pings != 4 {
+		t.Errorf("expected 4 pings (3 failed, 1 ok), got %d", rdb.pings)
+	}
+	if !rdb.has(marketKey("m0")) || !rdb.has(marketKey("m1")) {
+		t.Error("expected both traded markets cached after reconnect")
+	}
+}
+
+func TestCachedStore_WarmAsyncStopsOnCancel(t *testing.T) {
+	ms := NewMemoryStore()
+	rdb := newFakeCacheRedis()
+	rdb.pingFailures = 1 << 30
+	cs := NewCachedStore(ms, rdb, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := cs.WarmAsync(ctx, 10)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("warmer did not stop after cancel")
+	}
+}
@@ -0,0 +1,583 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ErrWriteBehindClosed is returned by WriteBehindStore.InsertLedgerEntry
+// after Close has been called.
+var ErrWriteBehindClosed = errors.New("store: write-behind queue closed")
+
+// WriteBehindConfig sizes the ledger write-behind queue.
+type WriteBehindConfig struct {
+	// QueueSize is how many entries may wait to be written before
+	// InsertLedgerEntry blocks (backpressure).
+	QueueSize int
+	// BatchSize caps the entries written per primary insert.
+	BatchSize int
+}
+
+// DefaultWriteBehindConfig is used for zero-valued config fields.
+var DefaultWriteBehindConfig = WriteBehindConfig{QueueSize: 1024, BatchSize: 100}
+
+// Backoff bounds for retrying a failed batch write.
+var (
+	writeBehindRetryMin = 50 * time.Millisecond
+	writeBehindRetryMax = 5 * time.Second
+)
+
+// WriteBehindStore wraps a Store so InsertLedgerEntry only enqueues the
+// entry; a single background writer drains the queue in order and writes
+// it to the primary in multi-row batches. Everything else passes straight
+// through.
+//
+// Reads stay consistent with the queue: ledger entry lists include
+// entries not yet written, and aggregate reads (positions, exposures,
+// leaderboard, cells) first wait for the relevant pending entries to be
+// written.
+type WriteBehindStore struct {
+	Store
+
+	cfg   WriteBehindConfig
+	queue chan *model.LedgerEntry
+	done  chan struct{}
+
+	sendMu    sync.Mutex // serializes enqueuers
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	closed   bool
+	pending  []model.LedgerEntry // enqueued, not yet written; oldest first
+	ids      map[string]struct{} // IDs in pending
+	users    map[string]int      // pending entries per user
+	enqueued uint64              // sequence of the last enqueued entry
+	written  uint64              // sequence of the last written entry
+	progress chan struct{}       // closed and replaced whenever written advances
+}
+
+// NewWriteBehindStore starts the background writer for primary. Call
+// Close on shutdown to flush the queue.
+func NewWriteBehindStore(primary Store, cfg WriteBehindConfig) *WriteBehindStore {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultWriteBehindConfig.QueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultWriteBehindConfig.BatchSize
+	}
+	s := &WriteBehindStore{
+		Store:    primary,
+		cfg:      cfg,
+		queue:    make(chan *model.LedgerEntry, cfg.QueueSize),
+		done:     make(chan struct{}),
+		ids:      make(map[string]struct{}),
+		users:    make(map[string]int),
+		progress: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// InsertLedgerEntry enqueues entry for the background writer. It blocks
+// while the queue is full, until ctx is done. A duplicate of an entry
+// still in the queue or already written is rejected with
+// ErrDuplicateLedgerEntry, so a retried trade can't be queued twice.
+func (s *WriteBehindStore) InsertLedgerEntry(ctx context.Context, entry *model.LedgerEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stored := storedEntry(entry)
+
+	// One sender at a time, so queue order matches pending order.
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if err := s.checkNew(ctx, stored.ID); err != nil {
+		return err
+	}
+	return s.enqueue(ctx, stored)
+}
+
+// storedEntry copies entry as it will be written, defaulting its kind.
+func storedEntry(entry *model.LedgerEntry) model.LedgerEntry {
+	stored := *entry
+	if stored.Kind == "" {
+		stored.Kind = model.EntryKindTrade
+	}
+	return stored
+}
+
+// checkNew reports whether an entry with id may be queued: the store is
+// open and the ID is neither queued nor written. The caller holds sendMu.
+func (s *WriteBehindStore) checkNew(ctx context.Context, id string) error {
+	s.mu.Lock()
+	closed := s.closed
+	_, dup := s.ids[id]
+	s.mu.Unlock()
+	if closed {
+		return ErrWriteBehindClosed
+	}
+	if dup {
+		return fmt.Errorf("%w: %s", ErrDuplicateLedgerEntry, id)
+	}
+
+	// Not queued, but it may have been written already: the writer only
+	// retires an entry once the primary has it, and sendMu keeps anyone
+	// else from queuing the ID meanwhile.
+	if _, err := s.Store.GetLedgerEntry(ctx, id); err == nil {
+		return fmt.Errorf("%w: %s", ErrDuplicateLedgerEntry, id)
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// enqueue tracks stored as pending and sends it to the writer, blocking
+// while the queue is full until ctx is done. The caller holds sendMu, so
+// Close can't close the queue under it.
+func (s *WriteBehindStore) enqueue(ctx context.Context, stored model.LedgerEntry) error {
+	// Track before sending: the writer may retire it as soon as it's queued.
+	s.mu.Lock()
+	s.track(stored)
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- &stored:
+	case <-ctx.Done():
+		s.mu.Lock()
+		s.pending = s.pending[:len(s.pending)-1]
+		s.untrack(stored)
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	s.enqueued++
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *WriteBehindStore) track(e model.LedgerEntry) {
+	s.pending = append(s.pending, e)
+	s.ids[e.ID] = struct{}{}
+	s.users[e.UserID]++
+}
+
+// untrack drops e's ID and user count; the caller removes it from pending.
+func (s *WriteBehindStore) untrack(e model.LedgerEntry) {
+	delete(s.ids, e.ID)
+	s.users[e.UserID]--
+	if s.users[e.UserID] == 0 {
+		delete(s.users, e.UserID)
+	}
+}
+
+// InsertLedgerEntries enqueues each entry in order.
+func (s *WriteBehindStore) InsertLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error {
+	for _, e := range entries {
+		if err := s.InsertLedgerEntry(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTrade checks the entries can be queued, moves the market and any
+// change to b in one primary write, and only then queues the entries.
+// The ledger write is deferred, so this can't be one transaction; instead
+// everything that can fail happens before the entries are queued. A
+// rejected entry or a failed market write leaves nothing queued, and
+// once ctx has been checked the rest runs regardless of cancellation, so
+// a client going away can't leave the market moved without its entries.
+func (s *WriteBehindStore) ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if seen[e.ID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateLedgerEntry, e.ID)
+		}
+		seen[e.ID] = true
+		if err := s.checkNew(ctx, e.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.Store.ApplyTrade(ctx, nil, id, qYes, qNo, priceYes, priceNo, collected, change); err != nil {
+		return err
+	}
+	// ctx can't be cancelled and Close waits for sendMu before closing the
+	// queue, so these only wait for room.
+	for _, e := range entries {
+		if err := s.enqueue(ctx, storedEntry(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SettleMarket flushes the queue, so the payouts chain after every trade
+// they close, then settles the market in the primary directly.
+func (s *WriteBehindStore) SettleMarket(ctx context.Context, id, outcome string, settledAt time.Time, entries []*model.LedgerEntry) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+	return s.Store.SettleMarket(ctx, id, outcome, settledAt, entries)
+}
+
+// UnsettleMarket flushes the queue, so the settlement entries being
+// reversed are in the primary, then reopens the market there directly.
+func (s *WriteBehindStore) UnsettleMarket(ctx context.Context, id string, entries []*model.LedgerEntry) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+	return s.Store.UnsettleMarket(ctx, id, entries)
+}
+
+// Flush blocks until every entry enqueued before the call is written.
+func (s *WriteBehindStore) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	target := s.enqueued
+	s.mu.Unlock()
+	return s.waitWritten(ctx, target)
+}
+
+func (s *WriteBehindStore) waitWritten(ctx context.Context, target uint64) error {
+	for {
+		s.mu.Lock()
+		written, progress := s.written, s.progress
+		s.mu.Unlock()
+		if written >= target {
+			return nil
+		}
+		select {
+		case <-progress:
+		case <-s.done:
+			s.mu.Lock()
+			written = s.written
+			s.mu.Unlock()
+			if written >= target {
+				return nil
+			}
+			return ErrWriteBehindClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// flushUsers flushes the queue only if it holds entries for any of users.
+func (s *WriteBehindStore) flushUsers(ctx context.Context, users ...string) error {
+	s.mu.Lock()
+	queued := false
+	for _, u := range users {
+		if s.users[u] > 0 {
+			queued = true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if !queued {
+		return nil
+	}
+	return s.Flush(ctx)
+}
+
+// Close stops accepting entries and waits until the queue is written or
+// ctx is done. It returns an error if entries were left unwritten.
+func (s *WriteBehindStore) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	// Wait out an in-flight send before closing the channel under it.
+	s.sendMu.Lock()
+	s.closeOnce.Do(func() { close(s.queue) })
+	s.sendMu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		left := len(s.pending)
+		s.mu.Unlock()
+		return fmt.Errorf("write-behind close: %d ledger entries unwritten: %w", left, ctx.Err())
+	}
+}
+
+// --- Background writer ---
+
+// run drains the queue in order. Whatever has accumulated while the
+// previous batch was being written goes out as the next batch, so batches
+// grow with load without adding latency when idle.
+func (s *WriteBehindStore) run() {
+	defer close(s.done)
+
+	batch := make([]*model.LedgerEntry, 0, s.cfg.BatchSize)
+	for e := range s.queue {
+		batch = append(batch[:0], e)
+	fill:
+		for len(batch) < s.cfg.BatchSize {
+			select {
+			case next, ok := <-s.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		s.write(batch)
+	}
+}
+
+// write persists batch, retrying with backoff until it succeeds. If the
+// batch holds a duplicate it falls back to one insert per entry so only
+// the duplicate is dropped.
+func (s *WriteBehindStore) write(batch []*model.LedgerEntry) {
+	ctx := context.Background()
+	delay := writeBehindRetryMin
+	for {
+		err := s.Store.InsertLedgerEntries(ctx, batch)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, ErrDuplicateLedgerEntry) {
+			s.writeEach(batch)
+			break
+		}
+		slog.Error("ledger write-behind batch failed, retrying",
+			"entries", len(batch), "err", err, "retry_in", delay)
+		time.Sleep(delay)
+		delay = min(delay*2, writeBehindRetryMax)
+	}
+	s.markWritten(len(batch))
+}
+
+func (s *WriteBehindStore) writeEach(batch []*model.LedgerEntry) {
+	ctx := context.Background()
+	for _, e := range batch {
+		delay := writeBehindRetryMin
+		for {
+			err := s.Store.InsertLedgerEntry(ctx, e)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, ErrDuplicateLedgerEntry) {
+				slog.Error("ledger write-behind dropped duplicate entry",
+					"id", e.ID, "user", e.UserID, "market", e.MarketID)
+				break
+			}
+			slog.Error("ledger write-behind insert failed, retrying",
+				"id", e.ID, "err", err, "retry_in", delay)
+			time.Sleep(delay)
+			delay = min(delay*2, writeBehindRetryMax)
+		}
+	}
+}
+
+// markWritten retires the n oldest pending entries and wakes waiters.
+func (s *WriteBehindStore) markWritten(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.pending[:n] {
+		s.untrack(e)
+	}
+	s.pending = append(s.pending[:0:0], s.pending[n:]...)
+	s.written += uint64(n)
+	close(s.progress)
+	s.progress = make(chan struct{})
+}
+
+// --- Reads that must see queued entries ---
+
+// pendingWhere returns a copy of the queued entries matching match.
+func (s *WriteBehindStore) pendingWhere(match func(model.LedgerEntry) bool) []model.LedgerEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []model.LedgerEntry
+	for _, e := range s.pending {
+		if match(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// mergePending appends queued entries to written ones. pending must be
+// snapshotted before the primary read: an entry written in between then
+// shows up in both and is deduplicated, rather than in neither.
+func mergePending(written, pending []model.LedgerEntry) []model.LedgerEntry {
+	if len(pending) == 0 {
+		return written
+	}
+	seen := make(map[string]struct{}, len(written))
+	for _, e := range written {
+		seen[e.ID] = struct{}{}
+	}
+	for _, e := range pending {
+		if _, ok := seen[e.ID]; !ok {
+			written = append(written, e)
+		}
+	}
+	return written
+}
+
+func (s *WriteBehindStore) GetLedgerEntriesByMarket(ctx context.Context, marketID string) ([]model.LedgerEntry, error) {
+	pending := s.pendingWhere(func(e model.LedgerEntry) bool { return e.MarketID == marketID })
+	written, err := s.Store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+	return mergePending(written, pending), nil
+}
+
+// StreamLedgerEntriesByMarket flushes the queue first if it holds entries
+// for the market, so the stream comes from the underlying store in order.
+func (s *WriteBehindStore) StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error {
+	if len(s.pendingWhere(func(e model.LedgerEntry) bool { return e.MarketID == marketID })) > 0 {
+		if err := s.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return s.Store.StreamLedgerEntriesByMarket(ctx, marketID, fn)
+}
+
+func (s *WriteBehindStore) GetLedgerEntriesByUser(ctx context.Context, userID string) ([]model.LedgerEntry, error) {
+	pending := s.pendingWhere(func(e model.LedgerEntry) bool { return e.UserID == userID })
+	written, err := s.Store.GetLedgerEntriesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return mergePending(written, pending), nil
+}
+
+// GetLedgerEntry finds queued entries too, so a retried trade sees its
+// original before the flush.
+func (s *WriteBehindStore) GetLedgerEntry(ctx context.Context, id string) (*model.LedgerEntry, error) {
+	if pending := s.pendingWhere(func(e model.LedgerEntry) bool { return e.ID == id }); len(pending) > 0 {
+		return &pending[0], nil
+	}
+	return s.Store.GetLedgerEntry(ctx, id)
+}
+
+func (s *WriteBehindStore) VerifyChain(ctx context.Context, marketID string) (*ChainReport, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.VerifyChain(ctx, marketID)
+}
+
+// AnonymizeUser writes out the user's queued entries first so none land
+// under userID afterwards.
+func (s *WriteBehindStore) AnonymizeUser(ctx context.Context, userID string) (int, error) {
+	if err := s.flushUsers(ctx, userID); err != nil {
+		return 0, err
+	}
+	return s.Store.AnonymizeUser(ctx, userID)
+}
+
+func (s *WriteBehindStore) GetUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
+	if err := s.flushUsers(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Store.GetUserPositions(ctx, userID)
+}
+
+func (s *WriteBehindStore) GetUserPositionsAsOf(ctx context.Context, userID string, asOf time.Time) ([]model.Position, error) {
+	if err := s.flushUsers(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Store.GetUserPositionsAsOf(ctx, userID, asOf)
+}
+
+func (s *WriteBehindStore) GetUserMarketPosition(ctx context.Context, userID, marketID string) (*model.Position, error) {
+	if err := s.flushUsers(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Store.GetUserMarketPosition(ctx, userID, marketID)
+}
+
+func (s *WriteBehindStore) RebuildUserPositions(ctx context.Context, userID string) ([]model.Position, error) {
+	if err := s.flushUsers(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Store.RebuildUserPositions(ctx, userID)
+}
+
+func (s *WriteBehindStore) GetPositionsByUsers(ctx context.Context, userIDs []string) (map[string][]model.Position, error) {
+	if err := s.flushUsers(ctx, userIDs...); err != nil {
+		return nil, err
+	}
+	return s.Store.GetPositionsByUsers(ctx, userIDs)
+}
+
+func (s *WriteBehindStore) GetUserCellExposures(ctx context.Context, userID string) (map[string]decimal.Decimal, error) {
+	if err := s.flushUsers(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Store.GetUserCellExposures(ctx, userID)
+}
+
+func (s *WriteBehindStore) GetCellExposures(ctx context.Context) (map[string]decimal.Decimal, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.GetCellExposures(ctx)
+}
+
+func (s *WriteBehindStore) GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.GetLeaderboard(ctx, since, limit)
+}
+
+func (s *WriteBehindStore) ListOpenCells(ctx context.Context, prefix string) ([]model.CellSummary, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.ListOpenCells(ctx, prefix)
+}
+
+func (s *WriteBehindStore) ListRecentlyTradedMarkets(ctx context.Context, limit int) ([]model.Market, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.ListRecentlyTradedMarkets(ctx, limit)
+}
+
+// Snapshot flushes the queue first so the export includes every entry
+// accepted so far.
+func (s *WriteBehindStore) Snapshot(ctx context.Context) (*model.Snapshot, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.Snapshot(ctx)
+}
+
+// Restore flushes the queue first, so queued entries can't be written on
+// top of the restored ledger.
+func (s *WriteBehindStore) Restore(ctx context.Context, snap *model.Snapshot, force bool) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+	return s.Store.Restore(ctx, snap, force)
+}
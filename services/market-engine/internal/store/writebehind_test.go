@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// gatedStore holds batch writes until the gate is opened, so tests can
+// build up a queue, and records each batch's size.
+type gatedStore struct {
+	*MemoryStore
+
+	gate     chan struct{}
+	openOnce sync.Once
+	mu       sync.Mutex
+	batches  []int
+}
+
+func newGatedStore() *gatedStore {
+	return &gatedStore{MemoryStore: NewMemoryStore(), gate: make(chan struct{})}
+}
+
+func (g *gatedStore) open() { g.openOnce.Do(func() { close(g.gate) }) }
+
+func (g *gatedStore) InsertLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error {
+	<-g.gate
+	g.mu.Lock()
+	g.batches = append(g.batches, len(entries))
+	g.mu.Unlock()
+	return g.MemoryStore.InsertLedgerEntries(ctx, entries)
+}
+
+func wbEntry(i int, user string) *model.LedgerEntry {
+	return &model.LedgerEntry{
+		ID: fmt.Sprintf("e%03d", i), UserID: user, MarketID: "m1", ContractID: "c1",
+		Side: "YES", Quantity: d(1), Price: d(0.5), Cost: d(0.5),
+		Timestamp: time.Date(2025, 8, 1, 0, 0, i, 0, time.UTC),
+	}
+}
+
+func TestWriteBehind_PreservesOrder(t *testing.T) {
+	primary := newGatedStore()
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{QueueSize: 500, BatchSize: 100})
+	ctx := context.Background()
+
+	const n = 250
+	for i := 0; i < n; i++ {
+		if err := wb.InsertLedgerEntry(ctx, wbEntry(i, "user1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	primary.open()
+	if err := wb.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	written, _ := primary.MemoryStore.GetLedgerEntriesByUser(ctx, "user1")
+	if len(written) != n {
+		t.Fatalf("expected %d entries written, got %d", n, len(written))
+	}
+	for i, e := range written {
+		if want := fmt.Sprintf("e%03d", i); e.ID != want {
+			t.Fatalf("entry %d is %s, want %s", i, e.ID, want)
+		}
+	}
+	for _, size := range primary.batches {
+		if size > 100 {
+			t.Errorf("batch of %d exceeds BatchSize", size)
+		}
+	}
+	if len(primary.batches) < 3 {
+		t.Errorf("expected the backlog to be batched, got batches %v", primary.batches)
+	}
+}
+
+func TestWriteBehind_CloseFlushesEverything(t *testing.T) {
+	primary := newGatedStore()
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{QueueSize: 100, BatchSize: 7})
+	ctx := context.Background()
+
+	const n = 60
+	for i := 0; i < n; i++ {
+		if err := wb.InsertLedgerEntry(ctx, wbEntry(i, fmt.Sprintf("user%d", i%4))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- wb.Close(ctx) }()
+	primary.open()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return")
+	}
+
+	total := 0
+	for u := 0; u < 4; u++ {
+		written, _ := primary.MemoryStore.GetLedgerEntriesByUser(ctx, fmt.Sprintf("user%d", u))
+		total += len(written)
+	}
+	if total != n {
+		t.Errorf("expected all %d entries written on close, got %d", n, total)
+	}
+
+	if err := wb.InsertLedgerEntry(ctx, wbEntry(n, "user0")); !errors.Is(err, ErrWriteBehindClosed) {
+		t.Errorf("expected ErrWriteBehindClosed after Close, got %v", err)
+	}
+}
+
+func TestWriteBehind_ReadsSeeQueuedEntries(t *testing.T) {
+	primary := newGatedStore()
+	ctx := context.Background()
+	if err := primary.CreateMarket(ctx, &model.Market{ID: "m1", ContractID: "c1", Status: "open", PriceYes: d(0.5)}); err != nil {
+		t.Fatal(err)
+	}
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{})
+	defer func() {
+		primary.open()
+		wb.Close(ctx)
+	}()
+
+	wb.InsertLedgerEntry(ctx, wbEntry(0, "user1"))
+	wb.InsertLedgerEntry(ctx, wbEntry(1, "user1"))
+
+	entries, err := wb.GetLedgerEntriesByUser(ctx, "user1")
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 queued entries in the user's ledger, got %d (%v)", len(entries), err)
+	}
+	if entries, _ := wb.GetLedgerEntriesByMarket(ctx, "m1"); len(entries) != 2 {
+		t.Errorf("expected 2 queued entries in the market's ledger, got %d", len(entries))
+	}
+
+	// Aggregates wait for the writer rather than miss queued entries.
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := wb.GetUserPositions(short, "user1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected positions to wait for the queue, got %v", err)
+	}
+	// A user with nothing queued doesn't wait.
+	if _, err := wb.GetUserPositions(ctx, "user2"); err != nil {
+		t.Errorf("expected no wait for user2, got %v", err)
+	}
+
+	primary.open()
+	positions, err := wb.GetUserPositions(ctx, "user1")
+	if err != nil || len(positions) != 1 || !positions[0].YesQty.Equal(d(2)) {
+		t.Errorf("expected a 2-share position after flush, got %+v (%v)", positions, err)
+	}
+}
+
+func TestWriteBehind_StreamWaitsForQueuedEntries(t *testing.T) {
+	primary := newGatedStore()
+	ctx := context.Background()
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{})
+	defer func() {
+		primary.open()
+		wb.Close(ctx)
+	}()
+
+	wb.InsertLedgerEntry(ctx, wbEntry(0, "user1"))
+	wb.InsertLedgerEntry(ctx, wbEntry(1, "user2"))
+
+	var got []string
+	collect := func(e model.LedgerEntry) error {
+		got = append(got, e.ID)
+		return nil
+	}
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := wb.StreamLedgerEntriesByMarket(short, "m1", collect); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the stream to wait for the queue, got %v", err)
+	}
+
+	primary.open()
+	if err := wb.StreamLedgerEntriesByMarket(ctx, "m1", collect); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "e000" || got[1] != "e001" {
+		t.Errorf("expected e000, e001 in order, got %v", got)
+	}
+}
+
+func TestWriteBehind_Backpressure(t *testing.T) {
+	primary := newGatedStore()
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{QueueSize: 1, BatchSize: 1})
+	ctx := context.Background()
+	defer func() {
+		primary.open()
+		wb.Close(ctx)
+	}()
+
+	// The writer takes the first entry and blocks on the gate; the second
+	// fills the queue.
+	wb.InsertLedgerEntry(ctx, wbEntry(0, "user1"))
+	deadline := time.Now().Add(time.Second)
+	for len(wb.queue) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := wb.InsertLedgerEntry(ctx, wbEntry(1, "user1")); err != nil {
+		t.Fatal(err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := wb.InsertLedgerEntry(short, wbEntry(2, "user1")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a full queue to block until the deadline, got %v", err)
+	}
+
+	// The rejected entry was not kept.
+	primary.open()
+	wb.Flush(ctx)
+	if written, _ := primary.MemoryStore.GetLedgerEntriesByUser(ctx, "user1"); len(written) != 2 {
+		t.Errorf("expected 2 entries written, got %d", len(written))
+	}
+}
+
+func TestWriteBehind_DuplicateDroppedOthersWritten(t *testing.T) {
+	primary := newGatedStore()
+	ctx := context.Background()
+	if err := primary.MemoryStore.InsertLedgerEntry(ctx, wbEntry(1, "user1")); err != nil {
+		t.Fatal(err)
+	}
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{BatchSize: 10})
+
+	for i := 0; i < 3; i++ {
+		wb.InsertLedgerEntry(ctx, wbEntry(i, "user1"))
+	}
+	// Still queued, or already in the primary: rejected up front.
+	if err := wb.InsertLedgerEntry(ctx, wbEntry(0, "user1")); !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Errorf("expected ErrDuplicateLedgerEntry for a queued ID, got %v", err)
+	}
+	if err := wb.InsertLedgerEntry(ctx, wbEntry(1, "user1")); !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Errorf("expected ErrDuplicateLedgerEntry for a written ID, got %v", err)
+	}
+
+	primary.open()
+	if err := wb.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if written, _ := primary.MemoryStore.GetLedgerEntriesByUser(ctx, "user1"); len(written) != 3 {
+		t.Errorf("expected e001 once plus e000 and e002, got %d entries", len(written))
+	}
+}
+
+func TestWriteBehind_ApplyTradeRejectsWrittenDuplicate(t *testing.T) {
+	primary := newGatedStore()
+	primary.open()
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{})
+	ctx := context.Background()
+	defer wb.Close(ctx)
+
+	market := &model.Market{ID: "m1", ContractID: "c1", B: d(100), PriceYes: d(0.5), PriceNo: d(0.5), Status: "open"}
+	if err := primary.MemoryStore.CreateMarket(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.ApplyTrade(ctx, []*model.LedgerEntry{wbEntry(1, "user1")}, "m1", d(1), d(0), d(0.6), d(0.4), d(0), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A retry of the same trade after the flush must not move the market
+	// again.
+	err := wb.ApplyTrade(ctx, []*model.LedgerEntry{wbEntry(1, "user1")}, "m1", d(2), d(0), d(0.7), d(0.3), d(0), nil)
+	if !errors.Is(err, ErrDuplicateLedgerEntry) {
+		t.Fatalf("expected ErrDuplicateLedgerEntry, got %v", err)
+	}
+	m, _ := wb.GetMarket(ctx, "m1")
+	if !m.QYes.Equal(d(1)) || !m.PriceYes.Equal(d(0.6)) {
+		t.Errorf("duplicate moved the market to q_yes=%s price=%s", m.QYes, m.PriceYes)
+	}
+	if entries, _ := wb.GetLedgerEntriesByMarket(ctx, "m1"); len(entries) != 1 {
+		t.Errorf("expected 1 ledger entry, got %d", len(entries))
+	}
+}
+
+// failingMarketStore fails every market state write.
+type failingMarketStore struct {
+	*MemoryStore
+}
+
+var errMarketWrite = errors.New("market write failed")
+
+func (f *failingMarketStore) ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error {
+	return errMarketWrite
+}
+
+func (f *failingMarketStore) UpdateMarketState(ctx context.Context, id string, qYes, qNo, priceYes, priceNo decimal.Decimal) error {
+	return errMarketWrite
+}
+
+func TestWriteBehind_ApplyTradeFailedMarketWriteQueuesNothing(t *testing.T) {
+	primary := &failingMarketStore{MemoryStore: NewMemoryStore()}
+	wb := NewWriteBehindStore(primary, WriteBehindConfig{})
+	ctx := context.Background()
+
+	market := &model.Market{ID: "m1", ContractID: "c1", B: d(100), PriceYes: d(0.5), PriceNo: d(0.5), Status: "open"}
+	if err := primary.MemoryStore.CreateMarket(ctx, market); err != nil {
+		t.Fatal(err)
+	}
+	change := &model.LiquidityChange{MarketID: "m1", OldB: d(100), NewB: d(150), ChangedAt: time.Now()}
+	err := wb.ApplyTrade(ctx, []*model.LedgerEntry{wbEntry(1, "user1")}, "m1", d(1), d(0), d(0.6), d(0.4), d(0), change)
+	if !errors.Is(err, errMarketWrite) {
+		t.Fatalf("expected the market write error, got %v", err)
+	}
+	if err := wb.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := primary.MemoryStore.GetLedgerEntriesByMarket(ctx, "m1"); len(entries) != 0 {
+		t.Errorf("a trade the market never applied left %d ledger entries", len(entries))
+	}
+	if changes, _ := primary.MemoryStore.GetLiquidityChanges(ctx, "m1"); len(changes) != 0 {
+		t.Errorf("a trade the market never applied changed b: %+v", changes)
+	}
+}
@@ -0,0 +1,66 @@
+// Access control for admin-only endpoints.
+
+package trade
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// DefaultAdminActor is the actor authenticated by RequireAdmin's single
+// shared token.
+const DefaultAdminActor = "admin"
+
+type adminActorKey struct{}
+
+// RequireAdmin gates handlers behind an "Authorization: Bearer <token>"
+// header matching token, authenticating the request as DefaultAdminActor.
+// With an empty token the admin API is disabled and every request is
+// refused.
+func RequireAdmin(token string) func(http.Handler) http.Handler {
+	if token == "" {
+		return RequireAdminTokens(nil)
+	}
+	return RequireAdminTokens(map[string]string{DefaultAdminActor: token})
+}
+
+// RequireAdminTokens is RequireAdmin with one token per actor, keyed by
+// actor name. The actor whose token the request presents is what
+// AdminActor returns and what the audit log records. With no tokens the
+// admin API is disabled.
+func RequireAdminTokens(tokens map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(tokens) == 0 {
+				writeError(w, "admin API is disabled", http.StatusForbidden)
+				return
+			}
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			actor := ""
+			if ok {
+				// Compare against every token so the time taken doesn't
+				// reveal which one matched.
+				for name, token := range tokens {
+					if token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+						actor = name
+					}
+				}
+			}
+			if actor == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, "admin credentials required", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminActorKey{}, actor)))
+		})
+	}
+}
+
+// AdminActor returns the actor RequireAdmin authenticated the request
+// with ctx as, or "" for a request that didn't pass through it.
+func AdminActor(ctx context.Context) string {
+	actor, _ := ctx.Value(adminActorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,42 @@
+// Erasing a user's identity from the ledger.
+
+package trade
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AnonymizeResponse is the JSON body returned from
+// POST /api/v1/admin/users/{userID}/anonymize.
+type AnonymizeResponse struct {
+	Entries int `json:"entries"` // ledger entries rewritten; 0 on a repeat
+}
+
+// AnonymizeUser handles POST /api/v1/admin/users/{userID}/anonymize
+// Erasure request: the user's ledger entries move to a random anonymous
+// ID that isn't disclosed, so positions and history are no longer
+// retrievable under userID. Entry IDs, quantities, costs and prices are
+// untouched, so market state, reconcile and verify are unaffected.
+// Repeating the call is a no-op.
+func (s *Service) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	n, err := s.store.AnonymizeUser(r.Context(), userID)
+	if err != nil {
+		s.internalError(w, r, "failed to anonymize user", err)
+		return
+	}
+
+	// Deliberately not logging or auditing userID: either would keep what
+	// was erased.
+	slog.Info("user anonymized", "entries", n)
+	s.audit(r, AuditActionAnonymize, "users", map[string]string{"entries": strconv.Itoa(n)})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AnonymizeResponse{Entries: n})
+}
@@ -0,0 +1,115 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func anonymize(t *testing.T, router http.Handler, userID string) trade.AnonymizeResponse {
+	t.Helper()
+	w, resp := serveJSON[trade.AnonymizeResponse](t, router, adminRequest("POST", "/api/v1/admin/users/"+userID+"/anonymize", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("anonymize: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	return resp
+}
+
+func TestAnonymizeUser_ErasesIdentityKeepsMarket(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	ctx := context.Background()
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "alice", ContractID: contractID, Side: "YES", Quantity: d(30)},
+		{UserID: "bob", ContractID: contractID, Side: "NO", Quantity: d(15)},
+		{UserID: "alice", ContractID: contractID, Side: "YES", Quantity: d(-10)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+	before, _ := ms.GetMarket(ctx, market.ID)
+	entriesBefore, _ := ms.GetLedgerEntriesByMarket(ctx, market.ID)
+
+	if resp := anonymize(t, router, "alice"); resp.Entries != 2 {
+		t.Errorf("entries = %d, want 2", resp.Entries)
+	}
+
+	if positions, _ := ms.GetUserPositions(ctx, "alice"); len(positions) != 0 {
+		t.Errorf("alice still has positions: %+v", positions)
+	}
+	if entries, _ := ms.GetLedgerEntriesByUser(ctx, "alice"); len(entries) != 0 {
+		t.Errorf("alice still has ledger entries: %+v", entries)
+	}
+	if _, err := ms.GetUserMarketPosition(ctx, "alice", market.ID); err == nil {
+		t.Error("expected alice's market position to be gone")
+	}
+	if positions, _ := ms.GetUserPositions(ctx, "bob"); len(positions) != 1 {
+		t.Errorf("bob's positions were affected: %+v", positions)
+	}
+
+	// The market and every entry except its user ID are unchanged.
+	after, _ := ms.GetMarket(ctx, market.ID)
+	if !after.QYes.Equal(before.QYes) || !after.QNo.Equal(before.QNo) || !after.PriceYes.Equal(before.PriceYes) {
+		t.Errorf("market state changed: %+v -> %+v", before, after)
+	}
+	entriesAfter, _ := ms.GetLedgerEntriesByMarket(ctx, market.ID)
+	if len(entriesAfter) != len(entriesBefore) {
+		t.Fatalf("ledger length changed: %d -> %d", len(entriesBefore), len(entriesAfter))
+	}
+	anonID := ""
+	for i, e := range entriesAfter {
+		b := entriesBefore[i]
+		if e.ID != b.ID || !e.Quantity.Equal(b.Quantity) || !e.Cost.Equal(b.Cost) || !e.Price.Equal(b.Price) {
+			t.Errorf("entry %d changed: %+v -> %+v", i, b, e)
+		}
+		if b.UserID != "alice" {
+			if e.UserID != b.UserID {
+				t.Errorf("entry %d: user %q rewritten to %q", i, b.UserID, e.UserID)
+			}
+			continue
+		}
+		if !strings.HasPrefix(e.UserID, store.AnonymousUserPrefix) || strings.Contains(e.UserID, "alice") {
+			t.Errorf("entry %d: user ID %q is not anonymous", i, e.UserID)
+		}
+		if anonID != "" && e.UserID != anonID {
+			t.Errorf("entries got different anonymous IDs %q and %q", anonID, e.UserID)
+		}
+		anonID = e.UserID
+	}
+
+	// The anonymous user still holds the position, so aggregates hold.
+	if positions, _ := ms.GetUserPositions(ctx, anonID); len(positions) != 1 || !positions[0].YesQty.Equal(d(20)) {
+		t.Errorf("anonymous positions = %+v, want 20 YES", positions)
+	}
+	if w, resp := postVerify(t, router, market.ID); w.Code != http.StatusOK || !resp.OK {
+		t.Errorf("verify after anonymizing: %d %s", w.Code, w.Body.String())
+	}
+
+	// Repeating the call, or passing the anonymous ID, changes nothing.
+	if resp := anonymize(t, router, "alice"); resp.Entries != 0 {
+		t.Errorf("repeat: entries = %d, want 0", resp.Entries)
+	}
+	if resp := anonymize(t, router, anonID); resp.Entries != 0 {
+		t.Errorf("anonymous ID: entries = %d, want 0", resp.Entries)
+	}
+	if entries, _ := ms.GetLedgerEntriesByUser(ctx, anonID); len(entries) != 2 {
+		t.Errorf("anonymous ID lost entries: %d", len(entries))
+	}
+}
+
+func TestAnonymizeUser_RequiresAdmin(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/users/alice/anonymize", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
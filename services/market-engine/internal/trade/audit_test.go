@@ -0,0 +1,148 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func getAuditLog(t *testing.T, router chi.Router, query string) trade.AuditLogResponse {
+	t.Helper()
+	w, resp := serveJSON[trade.AuditLogResponse](t, router, adminRequest("GET", "/api/v1/admin/audit"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("audit log: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	return resp
+}
+
+// settleAs settles with actor's admin token from testAdminTokens.
+func settleAs(t *testing.T, router chi.Router, actor, marketID, outcome string) {
+	t.Helper()
+	body, _ := json.Marshal(trade.SettleRequest{Outcome: outcome})
+	req := adminRequest("POST", "/api/v1/markets/"+marketID+"/settle", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminTokens[actor])
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("settle: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuditLog_SettlementRecorded(t *testing.T) {
+	now := time.Date(2025, 8, 16, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t, trade.WithAuditLog(), trade.WithClock(func() time.Time { return now }))
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	other := seedMarket(t, ms, "ATMX-872a10711-PRECIP-25MM-20250815", "872a10711", 100)
+
+	settleAs(t, router, "ops@atmx", market.ID, "YES")
+	settleAs(t, router, "ops@atmx", other.ID, "NO")
+
+	resp := getAuditLog(t, router, "?target="+market.ID)
+	if resp.Target != market.ID || len(resp.Events) != 1 {
+		t.Fatalf("got %+v, want one event for %s", resp, market.ID)
+	}
+	e := resp.Events[0]
+	if e.Action != trade.AuditActionSettle || e.Actor != "ops@atmx" || e.Target != market.ID || !e.Timestamp.Equal(now) {
+		t.Errorf("event = %+v, want settle of %s by ops@atmx at %s", e, market.ID, now)
+	}
+	if e.Params["outcome"] != "YES" || e.ID == "" {
+		t.Errorf("params = %v, id = %q; want outcome YES and an ID", e.Params, e.ID)
+	}
+
+	// Without a target every action is listed, newest first.
+	all := getAuditLog(t, router, "")
+	if len(all.Events) != 2 || all.Events[0].Target != other.ID {
+		t.Errorf("unfiltered log = %+v, want both settlements, %s first", all.Events, other.ID)
+	}
+	if got := getAuditLog(t, router, "?limit=1"); len(got.Events) != 1 {
+		t.Errorf("limit=1 returned %d events", len(got.Events))
+	}
+}
+
+func TestAuditLog_Disabled(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	settleAs(t, router, "ops@atmx", market.ID, "YES")
+	if resp := getAuditLog(t, router, ""); len(resp.Events) != 0 {
+		t.Errorf("audit log off, yet recorded %+v", resp.Events)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/audit", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without admin token: expected 401, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/admin/audit?limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("limit=0: expected 400, got %d", w.Code)
+	}
+}
+
+func TestAuditLog_ActorFromToken(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithAuditLog())
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// A claimed identity doesn't change who the token says acted.
+	body, _ := json.Marshal(trade.SettleRequest{Outcome: "YES"})
+	req := adminRequest("POST", "/api/v1/markets/"+market.ID+"/settle", bytes.NewReader(body))
+	req.Header.Set("X-Actor", "ops@atmx")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("settle: %d %s", w.Code, w.Body.String())
+	}
+
+	resp := getAuditLog(t, router, "?target="+market.ID)
+	if len(resp.Events) != 1 || resp.Events[0].Actor != trade.DefaultAdminActor {
+		t.Errorf("events = %+v, want one by %s", resp.Events, trade.DefaultAdminActor)
+	}
+}
+
+func TestAuditLog_MarketSettingsRecorded(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithAuditLog())
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	if w, _ := patchTradingHours(t, router, market.ID, `{"trading_open":"09:30","trading_close":"16:00"}`); w.Code != http.StatusOK {
+		t.Fatalf("trading hours: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := patchTradeSize(t, router, market.ID, `{"min_quantity":"1","max_quantity":"500"}`); w.Code != http.StatusOK {
+		t.Fatalf("trade size: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := patchTradeSize(t, router, market.ID, `{"max_quantity":"250"}`); w.Code != http.StatusOK {
+		t.Fatalf("trade size: %d %s", w.Code, w.Body.String())
+	}
+
+	events := getAuditLog(t, router, "?target="+market.ID).Events
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	want := []struct {
+		action string
+		params map[string]string
+	}{
+		{trade.AuditActionTradeSize, map[string]string{
+			"old_min_quantity": "1", "old_max_quantity": "500", "min_quantity": "", "max_quantity": "250",
+		}},
+		{trade.AuditActionTradeSize, map[string]string{
+			"old_min_quantity": "", "old_max_quantity": "", "min_quantity": "1", "max_quantity": "500",
+		}},
+		{trade.AuditActionTradingHours, map[string]string{
+			"old_trading_open": "", "old_trading_close": "", "trading_open": "09:30", "trading_close": "16:00",
+		}},
+	}
+	for i, e := range events {
+		if e.Action != want[i].action || e.Actor != trade.DefaultAdminActor || !maps.Equal(e.Params, want[i].params) {
+			t.Errorf("event %d = %s by %s %v, want %s %v", i, e.Action, e.Actor, e.Params, want[i].action, want[i].params)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Volume-driven liquidity adjustment.
+
+package trade
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// AutoLiquidityPolicy adjusts b on markets that opt in (Market.AutoLiquidity)
+// from the volume they traded in the trailing Window: each trade raises b
+// by Step while that volume is at least HighVolume, dampening the price
+// impact of heavy flow, and lowers it by Step while the volume is below
+// LowVolume. Between the two b is left alone. b stays within [MinB, MaxB].
+type AutoLiquidityPolicy struct {
+	Window     time.Duration   // how far back volume is measured
+	HighVolume decimal.Decimal // volume at or above which b rises
+	LowVolume  decimal.Decimal // volume below which b falls; 0 = never
+	Step       decimal.Decimal // fractional change per trade, e.g. 0.1
+	MinB       decimal.Decimal
+	MaxB       decimal.Decimal
+}
+
+// Validate checks the policy is usable.
+func (p AutoLiquidityPolicy) Validate() error {
+	switch {
+	case p.Window <= 0:
+		return errors.New("auto liquidity: window must be positive")
+	case !p.HighVolume.IsPositive():
+		return errors.New("auto liquidity: high volume must be positive")
+	case p.LowVolume.IsNegative() || p.LowVolume.GreaterThanOrEqual(p.HighVolume):
+		return errors.New("auto liquidity: low volume must be at least 0 and below high volume")
+	case !p.Step.IsPositive() || p.Step.GreaterThanOrEqual(decimal.NewFromInt(1)):
+		return errors.New("auto liquidity: step must be between 0 and 1")
+	case !p.MinB.IsPositive() || p.MaxB.LessThan(p.MinB):
+		return errors.New("auto liquidity: min b must be positive and at most max b")
+	}
+	return nil
+}
+
+// WithAutoLiquidity turns on volume-driven b adjustment for markets created
+// with auto_liquidity. Without it the flag has no effect.
+func WithAutoLiquidity(p AutoLiquidityPolicy) Option {
+	return func(s *Service) {
+		s.autoLiquidity = &p
+		s.liquidityVolume = &volumeTracker{markets: make(map[string]*marketVolume)}
+	}
+}
+
+// volumeTracker keeps each auto-liquidity market's trades in the policy
+// window, so a trade doesn't read the market's whole ledger to measure
+// volume. A market's window is loaded from the ledger the first time it is
+// needed and then extended by every trade this instance executes. A trade
+// from another instance moves the market to quantities the tracker didn't
+// record, and the window is loaded again.
+type volumeTracker struct {
+	mu      sync.Mutex
+	markets map[string]*marketVolume
+}
+
+// marketVolume is one market's window: its trades, oldest first, and the
+// quantities they left the market at. Callers hold the market's lock.
+type marketVolume struct {
+	qYes, qNo decimal.Decimal
+	trades    []volumeTrade
+}
+
+type volumeTrade struct {
+	at  time.Time
+	qty decimal.Decimal // |quantity|
+}
+
+// market returns marketID's window, or nil if it isn't loaded.
+func (vt *volumeTracker) market(marketID string) *marketVolume {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.markets[marketID]
+}
+
+func (vt *volumeTracker) set(marketID string, mv *marketVolume) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.markets[marketID] = mv
+}
+
+// windowVolume returns the quantity m traded after since. Callers hold
+// m's lock.
+func (s *Service) windowVolume(ctx context.Context, m *model.Market, since time.Time) (decimal.Decimal, error) {
+	mv := s.liquidityVolume.market(m.ID)
+	if mv == nil || !mv.qYes.Equal(m.QYes) || !mv.qNo.Equal(m.QNo) {
+		mv = &marketVolume{qYes: m.QYes, qNo: m.QNo}
+		err := s.store.StreamLedgerEntriesByMarket(ctx, m.ID, func(e model.LedgerEntry) error {
+			if e.IsTrade() && e.Timestamp.After(since) {
+				mv.trades = append(mv.trades, volumeTrade{at: e.Timestamp, qty: e.Quantity.Abs()})
+			}
+			return nil
+		})
+		if err != nil {
+			return decimal.Zero, err
+		}
+		s.liquidityVolume.set(m.ID, mv)
+	}
+
+	i := 0
+	for i < len(mv.trades) && !mv.trades[i].at.After(since) {
+		i++
+	}
+	mv.trades = mv.trades[i:]
+	volume := decimal.Zero
+	for _, t := range mv.trades {
+		volume = volume.Add(t.qty)
+	}
+	return volume, nil
+}
+
+// recordVolume adds entries, just applied to marketID and leaving it at
+// qYes/qNo, to the market's window if it is loaded. Callers hold the
+// market's lock.
+func (s *Service) recordVolume(marketID string, entries []*model.LedgerEntry, qYes, qNo decimal.Decimal) {
+	if s.liquidityVolume == nil {
+		return
+	}
+	mv := s.liquidityVolume.market(marketID)
+	if mv == nil {
+		return
+	}
+	for _, e := range entries {
+		mv.trades = append(mv.trades, volumeTrade{at: e.Timestamp, qty: e.Quantity.Abs()})
+	}
+	mv.qYes, mv.qNo = qYes, qNo
+}
+
+// nextB returns the b the policy moves b to given the window's volume.
+func (p AutoLiquidityPolicy) nextB(b, volume decimal.Decimal) decimal.Decimal {
+	one := decimal.NewFromInt(1)
+	switch {
+	case volume.GreaterThanOrEqual(p.HighVolume):
+		b = b.Mul(one.Add(p.Step))
+	case volume.LessThan(p.LowVolume):
+		b = b.Mul(one.Sub(p.Step))
+	}
+	return decimal.Min(decimal.Max(b, p.MinB), p.MaxB).Round(lmsr.PriceScale)
+}
+
+// liquidityAdjustment returns the change the auto-liquidity policy makes to
+// m's b ahead of a trade, or nil when there is none: no policy, a market
+// that hasn't opted in or follows a schedule, or b already where the
+// policy puts it. The change takes effect at now, so the trade about to
+// execute is priced, and later verified, at the new b; it is stored with
+// the trade by ApplyTrade. Callers hold m's lock.
+func (s *Service) liquidityAdjustment(ctx context.Context, m *model.Market) (*model.LiquidityChange, error) {
+	p := s.autoLiquidity
+	if p == nil || !m.AutoLiquidity || m.BStart != nil {
+		return nil, nil
+	}
+	now := s.now().UTC()
+
+	volume, err := s.windowVolume(ctx, m, now.Add(-p.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	newB := p.nextB(m.B, volume)
+	if newB.Equal(m.B) {
+		return nil, nil
+	}
+	return &model.LiquidityChange{MarketID: m.ID, OldB: m.B, NewB: newB, ChangedAt: now}, nil
+}
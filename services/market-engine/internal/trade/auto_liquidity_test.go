@@ -0,0 +1,275 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+var testAutoLiquidity = trade.AutoLiquidityPolicy{
+	Window:     time.Hour,
+	HighVolume: d(50),
+	Step:       d(0.1),
+	MinB:       d(50),
+	MaxB:       d(150),
+}
+
+// autoLiquidityEnv is a test env with the auto-liquidity policy and a clock
+// the test moves through the returned pointer.
+func autoLiquidityEnv(t *testing.T, policy trade.AutoLiquidityPolicy) (*store.MemoryStore, chi.Router, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t,
+		trade.WithAutoLiquidity(policy),
+		trade.WithClock(func() time.Time { return now }),
+	)
+	return ms, router, &now
+}
+
+func createAutoLiquidityMarket(t *testing.T, router http.Handler, auto bool) model.Market {
+	t.Helper()
+	w := postCreateMarket(t, router, trade.CreateMarketRequest{
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", B: d(100), AutoLiquidity: auto,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create market: %d %s", w.Code, w.Body.String())
+	}
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	return m
+}
+
+// tradeEvery buys qty YES per trade n times, a second apart, and returns
+// the market's b after each.
+func tradeEvery(t *testing.T, ms *store.MemoryStore, router chi.Router, now *time.Time, m model.Market, qty float64, n int) []decimal.Decimal {
+	t.Helper()
+	var bs []decimal.Decimal
+	for i := 0; i < n; i++ {
+		*now = now.Add(time.Second)
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(qty)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("trade %d: %d %s", i, w.Code, w.Body.String())
+		}
+		got, _ := ms.GetMarket(context.Background(), m.ID)
+		bs = append(bs, got.B)
+	}
+	return bs
+}
+
+func TestAutoLiquidity_HighVolumeRaisesB(t *testing.T) {
+	ms, router, now := autoLiquidityEnv(t, testAutoLiquidity)
+	m := createAutoLiquidityMarket(t, router, true)
+
+	bs := tradeEvery(t, ms, router, now, m, 20, 10)
+
+	// 20, 40 traded: below 50. From the fourth trade on, b rises 10% per
+	// trade until it reaches MaxB.
+	want := []float64{100, 100, 100, 110, 121, 133.1, 146.41, 150, 150, 150}
+	for i, b := range bs {
+		if !b.Equal(d(want[i])) {
+			t.Errorf("after trade %d: b = %s, want %v", i, b, want[i])
+		}
+	}
+
+	changes, _ := ms.GetLiquidityChanges(context.Background(), m.ID)
+	if len(changes) != 5 {
+		t.Errorf("recorded %d liquidity changes, want 5", len(changes))
+	}
+	if w, resp := postVerify(t, router, m.ID); w.Code != http.StatusOK || !resp.OK {
+		t.Errorf("verify after auto adjustments: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAutoLiquidity_LowVolumeLeavesBUnchanged(t *testing.T) {
+	ms, router, now := autoLiquidityEnv(t, testAutoLiquidity)
+	m := createAutoLiquidityMarket(t, router, true)
+
+	// 5 shares every 10 minutes keeps the hour's volume at 30 at most.
+	for i := 0; i < 8; i++ {
+		*now = now.Add(10 * time.Minute)
+		for _, b := range tradeEvery(t, ms, router, now, m, 5, 1) {
+			if !b.Equal(d(100)) {
+				t.Fatalf("trade %d: b = %s, want 100", i, b)
+			}
+		}
+	}
+	if changes, _ := ms.GetLiquidityChanges(context.Background(), m.ID); len(changes) != 0 {
+		t.Errorf("recorded %d liquidity changes, want none", len(changes))
+	}
+}
+
+func TestAutoLiquidity_RequiresMarketFlag(t *testing.T) {
+	ms, router, now := autoLiquidityEnv(t, testAutoLiquidity)
+	m := createAutoLiquidityMarket(t, router, false)
+
+	for i, b := range tradeEvery(t, ms, router, now, m, 20, 6) {
+		if !b.Equal(d(100)) {
+			t.Errorf("after trade %d: b = %s, want 100 without auto_liquidity", i, b)
+		}
+	}
+}
+
+func TestAutoLiquidity_QuietMarketFallsToMinB(t *testing.T) {
+	policy := testAutoLiquidity
+	policy.LowVolume = d(10)
+	ms, router, now := autoLiquidityEnv(t, policy)
+	m := createAutoLiquidityMarket(t, router, true)
+
+	// Half an hour apart, each trade sees at most two earlier ones in the
+	// window: 4 shares, below LowVolume.
+	var bs []decimal.Decimal
+	for i := 0; i < 10; i++ {
+		*now = now.Add(30 * time.Minute)
+		bs = append(bs, tradeEvery(t, ms, router, now, m, 2, 1)...)
+	}
+	if !bs[0].Equal(d(90)) {
+		t.Errorf("after the first trade: b = %s, want 90", bs[0])
+	}
+	if last := bs[len(bs)-1]; !last.Equal(d(50)) {
+		t.Errorf("after sustained low volume: b = %s, want MinB 50", last)
+	}
+}
+
+func TestAutoLiquidityPolicy_Validate(t *testing.T) {
+	if err := testAutoLiquidity.Validate(); err != nil {
+		t.Errorf("valid policy: %v", err)
+	}
+	for name, mutate := range map[string]func(*trade.AutoLiquidityPolicy){
+		"zero window":      func(p *trade.AutoLiquidityPolicy) { p.Window = 0 },
+		"low above high":   func(p *trade.AutoLiquidityPolicy) { p.LowVolume = d(60) },
+		"step of 1":        func(p *trade.AutoLiquidityPolicy) { p.Step = d(1) },
+		"max below min":    func(p *trade.AutoLiquidityPolicy) { p.MaxB = d(10) },
+		"zero high volume": func(p *trade.AutoLiquidityPolicy) { p.HighVolume = d(0) },
+	} {
+		p := testAutoLiquidity
+		mutate(&p)
+		if p.Validate() == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// autoLiquidityMarket seeds an auto-liquidity market at b = 100 in ms.
+func autoLiquidityMarket(t *testing.T, ms *store.MemoryStore) *model.Market {
+	t.Helper()
+	m := &model.Market{
+		ID: "auto-market", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", H3CellID: "872a1070b",
+		B: d(100), PriceYes: d(0.5), PriceNo: d(0.5), Status: "open", AutoLiquidity: true,
+	}
+	if err := ms.CreateMarket(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestAutoLiquidity_FailedTradeKeepsB(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	ms := store.NewMemoryStore()
+	cs := &cancellingStore{MemoryStore: ms}
+	svc := trade.NewService(cs, correlation.NewPositionLimiter(d(1000), d(5000), 5), nil,
+		trade.WithAutoLiquidity(testAutoLiquidity), trade.WithClock(func() time.Time { return now }))
+	router := chi.NewRouter()
+	router.Post("/api/v1/trade", svc.ExecuteTrade)
+	m := autoLiquidityMarket(t, ms)
+	ctx := context.Background()
+	req := trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(20)}
+
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Second)
+		if w := doTrade(t, router, req); w.Code != http.StatusOK {
+			t.Fatalf("trade %d: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	// 60 traded: the next trade raises b, but its write fails.
+	now = now.Add(time.Second)
+	body, _ := json.Marshal(req)
+	reqCtx, cancel := context.WithCancel(ctx)
+	cs.cancel = cancel
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/trade", bytes.NewReader(body)).WithContext(reqCtx))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a cancelled trade, got %d: %s", w.Code, w.Body.String())
+	}
+	got, _ := ms.GetMarket(ctx, m.ID)
+	changes, _ := ms.GetLiquidityChanges(ctx, m.ID)
+	if !got.B.Equal(d(100)) || len(changes) != 0 {
+		t.Fatalf("failed trade left b = %s with %d liquidity changes, want 100 and none", got.B, len(changes))
+	}
+
+	cs.cancel = nil
+	if w := doTrade(t, router, req); w.Code != http.StatusOK {
+		t.Fatalf("retry: %d %s", w.Code, w.Body.String())
+	}
+	got, _ = ms.GetMarket(ctx, m.ID)
+	changes, _ = ms.GetLiquidityChanges(ctx, m.ID)
+	if !got.B.Equal(d(110)) || len(changes) != 1 {
+		t.Errorf("after retry: b = %s with %d liquidity changes, want 110 and one", got.B, len(changes))
+	}
+}
+
+// streamCountingStore counts reads of a market's whole ledger.
+type streamCountingStore struct {
+	*store.MemoryStore
+	streams int
+}
+
+func (s *streamCountingStore) StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error {
+	s.streams++
+	return s.MemoryStore.StreamLedgerEntriesByMarket(ctx, marketID, fn)
+}
+
+func TestAutoLiquidity_VolumeKeptWithoutReadingLedger(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	ms := store.NewMemoryStore()
+	cs := &streamCountingStore{MemoryStore: ms}
+	svc := trade.NewService(cs, correlation.NewPositionLimiter(d(1000), d(5000), 5), nil,
+		trade.WithAutoLiquidity(testAutoLiquidity), trade.WithClock(func() time.Time { return now }))
+	router := chi.NewRouter()
+	router.Post("/api/v1/trade", svc.ExecuteTrade)
+	m := autoLiquidityMarket(t, ms)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Second)
+		if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(20)}); w.Code != http.StatusOK {
+			t.Fatalf("trade %d: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+	if cs.streams != 1 {
+		t.Errorf("read the ledger %d times over two trades, want once", cs.streams)
+	}
+
+	// A trade written elsewhere moves the market past what the window
+	// recorded, so its volume is read back from the ledger: 60 in all.
+	now = now.Add(time.Second)
+	other := &model.LedgerEntry{
+		ID: "elsewhere", UserID: "u2", MarketID: m.ID, ContractID: m.ContractID,
+		Side: "YES", Quantity: d(20), Price: d(0.6), Cost: d(12), Timestamp: now,
+	}
+	if err := ms.ApplyTrade(ctx, []*model.LedgerEntry{other}, m.ID, d(60), d(0), d(0.6), d(0.4), d(0), nil); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(1)}); w.Code != http.StatusOK {
+		t.Fatalf("trade after external write: %d %s", w.Code, w.Body.String())
+	}
+	if got, _ := ms.GetMarket(ctx, m.ID); !got.B.Equal(d(110)) {
+		t.Errorf("b = %s, want 110 once the external trade is counted", got.B)
+	}
+	if cs.streams != 2 {
+		t.Errorf("read the ledger %d times, want a second read after the external trade", cs.streams)
+	}
+}
@@ -0,0 +1,153 @@
+// Declarative request rules and JSON body binding.
+
+package trade
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+)
+
+// binder is a request body that bind can decode and check. normalize puts
+// fields in canonical form before the rules run; Validate reports every
+// rule that fails.
+type binder interface {
+	normalize()
+	Validate() []FieldError
+}
+
+// MaxRequestBodyBytes caps the JSON body decodeJSON reads.
+const MaxRequestBodyBytes = 1 << 20
+
+// decodeJSON decodes a request's JSON body into v, the one way every POST
+// handler reads its body. The body must be a single JSON value of at most
+// MaxRequestBodyBytes with no fields v doesn't have, so a misspelt
+// optional field is an error rather than silently ignored. Decimal fields
+// take JSON numbers or strings alike, parsed from their text without
+// passing through float64. It writes a 413 for an oversized body or a 400
+// for any other problem and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBodyBytes))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("trailing data after the JSON value")
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &tooLarge):
+		writeError(w, "request body too large", http.StatusRequestEntityTooLarge)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		writeError(w, "invalid request body: "+strings.TrimPrefix(err.Error(), "json: "), http.StatusBadRequest)
+	default:
+		writeError(w, "invalid request body", http.StatusBadRequest)
+	}
+	return false
+}
+
+// bind decodes the JSON body into req (see decodeJSON), normalizes it and
+// validates it. It writes a 4xx for a body that can't be decoded or a 422
+// listing the failed rules, and returns ok=false; errs is nil for a body
+// that can't be decoded.
+func bind(w http.ResponseWriter, r *http.Request, req binder) (errs []FieldError, ok bool) {
+	if !decodeJSON(w, r, req) {
+		return nil, false
+	}
+	req.normalize()
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return errs, false
+	}
+	return nil, true
+}
+
+// rule checks one field; message is empty when the field is valid.
+type rule struct {
+	field   string
+	message string
+}
+
+// check runs the rules and returns the failures in order. Only the first
+// failing rule per field is reported.
+func check(rules ...rule) []FieldError {
+	var errs []FieldError
+	failed := make(map[string]bool)
+	for _, r := range rules {
+		if r.message == "" || failed[r.field] {
+			continue
+		}
+		failed[r.field] = true
+		errs = append(errs, FieldError{r.field, r.message})
+	}
+	return errs
+}
+
+// fails builds a rule that fails with message when bad is true.
+func fails(field string, bad bool, message string) rule {
+	if !bad {
+		return rule{field: field}
+	}
+	return rule{field, message}
+}
+
+// required fails when v is empty.
+func required(field, v string) rule {
+	return fails(field, v == "", field+" is required")
+}
+
+// oneOf fails when v is not one of allowed; message names the choices.
+func oneOf(field, v, message string, allowed ...string) rule {
+	for _, a := range allowed {
+		if v == a {
+			return rule{field: field}
+		}
+	}
+	return rule{field, message}
+}
+
+// ticker fails when v is not a valid contract ticker.
+func ticker(field, v string) rule {
+	if _, err := contract.ParseTicker(v); err != nil {
+		return rule{field, err.Error()}
+	}
+	return rule{field: field}
+}
+
+// nonZero fails when v is zero.
+func nonZero(field string, v decimal.Decimal) rule {
+	return fails(field, v.IsZero(), field+" must be non-zero")
+}
+
+// quantity fails unless v is a non-zero trade size of at most
+// lmsr.MaxQuantity in magnitude, checked before any LMSR math.
+func quantity(field string, v decimal.Decimal) rule {
+	if v.Abs().GreaterThan(lmsr.MaxQuantity) {
+		return rule{field, field + " must be at most " + lmsr.MaxQuantity.String() + " in magnitude"}
+	}
+	return nonZero(field, v)
+}
+
+// positive fails unless v > 0.
+func positive(field string, v decimal.Decimal) rule {
+	return fails(field, !v.IsPositive(), field+" must be positive")
+}
+
+// liquidity fails if v is above lmsr.MaxLiquidity.
+func liquidity(field string, v decimal.Decimal) rule {
+	return fails(field, v.GreaterThan(lmsr.MaxLiquidity), field+" must be at most "+lmsr.MaxLiquidity.String())
+}
+
+// probability fails unless 0 < v < 1, the range of a usable price limit.
+func probability(field string, v decimal.Decimal) rule {
+	return fails(field, !validProbability(v), field+" must be between 0 and 1")
+}
@@ -0,0 +1,141 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestBind_TradeRules(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	valid := trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(5)}
+
+	tests := []struct {
+		name  string
+		edit  func(*trade.TradeRequest)
+		field string
+		msg   string
+	}{
+		{"user required", func(r *trade.TradeRequest) { r.UserID = "" }, "user_id", "user_id is required"},
+		{"contract required", func(r *trade.TradeRequest) { r.ContractID = "" }, "contract_id", "contract_id is required"},
+		{"contract ticker", func(r *trade.TradeRequest) { r.ContractID = "ATMX-bad" }, "contract_id", ""},
+		{"side enum", func(r *trade.TradeRequest) { r.Side = "MAYBE" }, "side", "side must be YES or NO"},
+		{"quantity non-zero", func(r *trade.TradeRequest) { r.Quantity = d(0) }, "quantity", "quantity must be non-zero"},
+		{"max price range", func(r *trade.TradeRequest) { r.MaxFillPrice = price(1) }, "max_fill_price", "max_fill_price must be between 0 and 1"},
+		{"max price buys only", func(r *trade.TradeRequest) { r.Quantity, r.MaxFillPrice = d(-5), price(0.5) }, "max_fill_price", "applies to buys"},
+		{"min price range", func(r *trade.TradeRequest) { r.Quantity, r.MinFillPrice = d(-5), price(0) }, "min_fill_price", "min_fill_price must be between 0 and 1"},
+		{"min price sells only", func(r *trade.TradeRequest) { r.MinFillPrice = price(0.5) }, "min_fill_price", "applies to sells"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.edit(&req)
+			fields := fieldsOf(t, doTrade(t, router, req))
+			if len(fields) != 1 {
+				t.Fatalf("expected one field error, got %v", fields)
+			}
+			if got, ok := fields[tt.field]; !ok || !strings.Contains(got, tt.msg) {
+				t.Errorf("%s = %q, want it to contain %q", tt.field, got, tt.msg)
+			}
+		})
+	}
+
+	// A missing contract is reported once, not also as a bad ticker.
+	fields := fieldsOf(t, doTrade(t, router, trade.TradeRequest{UserID: "u1", Side: "NO", Quantity: d(1)}))
+	if fields["contract_id"] != "contract_id is required" {
+		t.Errorf("contract_id = %q, want the required message", fields["contract_id"])
+	}
+
+	if w := doTrade(t, router, valid); w.Code != http.StatusOK {
+		t.Errorf("valid trade: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBind_CreateMarketRules(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	post := func(req trade.CreateMarketRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+		return w
+	}
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+
+	tests := []struct {
+		name  string
+		req   trade.CreateMarketRequest
+		field string
+	}{
+		{"contract required", trade.CreateMarketRequest{}, "contract_id"},
+		{"schedule pairing", trade.CreateMarketRequest{ContractID: contractID, BStart: price(50)}, "b_end"},
+		{"b_start positive", trade.CreateMarketRequest{ContractID: contractID, BStart: price(0), BEnd: price(50)}, "b_start"},
+		{"b_end positive", trade.CreateMarketRequest{ContractID: contractID, BStart: price(50), BEnd: price(-1)}, "b_end"},
+		{"b at most max", trade.CreateMarketRequest{ContractID: contractID, B: d(2e6)}, "b"},
+		{"b_end at most max", trade.CreateMarketRequest{ContractID: contractID, BStart: price(50), BEnd: price(2e6)}, "b_end"},
+		{"subsidy budget positive", trade.CreateMarketRequest{ContractID: contractID, SubsidyBudget: price(0)}, "subsidy_budget"},
+		{"auto liquidity without schedule", trade.CreateMarketRequest{ContractID: contractID, BStart: price(50), BEnd: price(200), AutoLiquidity: true}, "auto_liquidity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := fieldsOf(t, post(tt.req))
+			if _, ok := fields[tt.field]; !ok || len(fields) != 1 {
+				t.Errorf("expected only a %s error, got %v", tt.field, fields)
+			}
+		})
+	}
+
+	if w := post(trade.CreateMarketRequest{ContractID: contractID, BStart: price(50), BEnd: price(200)}); w.Code != http.StatusCreated {
+		t.Errorf("valid market: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDecode_UnknownFieldOnEveryPost(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for _, route := range []struct{ method, path string }{
+		{"POST", "/api/v1/trade"},
+		{"POST", "/api/v1/markets"},
+		{"POST", "/api/v1/products"},
+		{"POST", "/api/v1/prices"},
+		{"POST", "/api/v1/portfolios"},
+		{"POST", "/api/v1/margin/estimate"},
+		{"POST", "/api/v1/observations"},
+		{"POST", "/api/v1/markets/" + market.ID + "/reliquify"},
+		{"POST", "/api/v1/markets/" + market.ID + "/settle"},
+		{"PATCH", "/api/v1/markets/" + market.ID + "/trading-hours"},
+		{"PATCH", "/api/v1/markets/" + market.ID + "/trade-size"},
+	} {
+		w := httptest.NewRecorder()
+		// Admin credentials, so admin routes reach the decoder.
+		router.ServeHTTP(w, adminRequest(route.method, route.path, strings.NewReader(`{"no_such_field": 1}`)))
+		var resp map[string]string
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusBadRequest || resp["error"] != `invalid request body: unknown field "no_such_field"` {
+			t.Errorf("%s %s: got %d %s", route.method, route.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestBind_MalformedBody(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	for _, path := range []string{"/api/v1/trade", "/api/v1/markets", "/api/v1/products"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(`{"quantity": "lots"`)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, w.Code)
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["error"] != "invalid request body" {
+			t.Errorf("%s: unexpected body %s", path, w.Body.String())
+		}
+	}
+}
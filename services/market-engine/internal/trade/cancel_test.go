@@ -0,0 +1,85 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// cancellingStore cancels the request's context as the trade is committed,
+// as if the client disconnected between deciding to trade and the writes.
+type cancellingStore struct {
+	*store.MemoryStore
+	cancel context.CancelFunc
+}
+
+func (s *cancellingStore) ApplyTrade(ctx context.Context, entries []*model.LedgerEntry, id string, qYes, qNo, priceYes, priceNo, collected decimal.Decimal, change *model.LiquidityChange) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return s.MemoryStore.ApplyTrade(ctx, entries, id, qYes, qNo, priceYes, priceNo, collected, change)
+}
+
+func TestExecuteTrade_CancelledMidTradeWritesNothing(t *testing.T) {
+	ms := store.NewMemoryStore()
+	cs := &cancellingStore{MemoryStore: ms}
+	svc := trade.NewService(cs, correlation.NewPositionLimiter(d(1000), d(5000), 5), nil)
+	router := chi.NewRouter()
+	router.Post("/api/v1/trade", svc.ExecuteTrade)
+	router.Post("/api/v1/portfolio/{userID}/markets/{marketID}/close", svc.ClosePosition)
+
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	ctx := context.Background()
+
+	body, _ := json.Marshal(trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10)})
+	reqCtx, cancel := context.WithCancel(ctx)
+	cs.cancel = cancel
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/trade", bytes.NewReader(body)).WithContext(reqCtx))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a cancelled trade, got %d: %s", w.Code, w.Body.String())
+	}
+
+	m, _ := ms.GetMarket(ctx, market.ID)
+	entries, _ := ms.GetLedgerEntriesByMarket(ctx, market.ID)
+	if !m.QYes.IsZero() || !m.PriceYes.Equal(d(0.5)) || len(entries) != 0 {
+		t.Fatalf("expected an untouched market and ledger, got q_yes=%s price=%s entries=%d", m.QYes, m.PriceYes, len(entries))
+	}
+
+	// The same trade goes through once the client stays connected.
+	cs.cancel = nil
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10)}); w.Code != http.StatusOK {
+		t.Fatalf("retry failed: %d %s", w.Code, w.Body.String())
+	}
+
+	// Closing is committed as one unit too.
+	reqCtx, cancel = context.WithCancel(ctx)
+	cs.cancel = cancel
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/portfolio/u1/markets/"+market.ID+"/close", nil).WithContext(reqCtx))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a cancelled close, got %d: %s", w.Code, w.Body.String())
+	}
+
+	m, _ = ms.GetMarket(ctx, market.ID)
+	entries, _ = ms.GetLedgerEntriesByMarket(ctx, market.ID)
+	if !m.QYes.Equal(d(10)) || len(entries) != 1 {
+		t.Errorf("expected only the first trade applied, got q_yes=%s entries=%d", m.QYes, len(entries))
+	}
+	pos, err := ms.GetUserMarketPosition(ctx, "u1", market.ID)
+	if err != nil || !pos.YesQty.Equal(m.QYes) {
+		t.Errorf("position %v (%v) disagrees with market q_yes %s", pos, err, m.QYes)
+	}
+}
@@ -0,0 +1,228 @@
+// Empirical price correlation between H3 cells.
+
+package trade
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+const (
+	defaultCorrelationWindow   = 7 * 24 * time.Hour
+	defaultCorrelationInterval = time.Hour
+
+	// maxCorrelationCells bounds the cells per request, and so the
+	// pairs computed, at 20 (190 pairs).
+	maxCorrelationCells = 20
+
+	// minCorrelationOverlap is the fewest intervals in which both cells
+	// must have moved for their correlation to be reported.
+	minCorrelationOverlap = 3
+)
+
+// CellCorrelationResponse is the JSON body returned from
+// GET /api/v1/cells/correlation.
+type CellCorrelationResponse struct {
+	Cells    []string          `json:"cells"`
+	Window   string            `json:"window"`
+	Interval string            `json:"interval"`
+	Since    time.Time         `json:"since"`
+	Pairs    []CellCorrelation `json:"pairs"`
+}
+
+// CellCorrelation is the price-change correlation of one pair of cells.
+type CellCorrelation struct {
+	CellA string `json:"cell_a"`
+	CellB string `json:"cell_b"`
+	// Correlation is the Pearson coefficient of the two cells' price
+	// changes over the intervals in which both moved; null when there are
+	// fewer than minCorrelationOverlap of them or either cell's changes
+	// are constant. Computed in float64 from prices with lmsr.PriceScale
+	// places, it is accurate to about 1e-9.
+	Correlation  *float64 `json:"correlation"`
+	Observations int      `json:"observations"` // intervals in which both cells moved
+}
+
+// GetCellCorrelation handles
+// GET /api/v1/cells/correlation?cells=a,b,c&window=7d&interval=1h
+// Measures how the cells' markets have actually moved together, to set
+// against the geographic correlation the position limits assume. The
+// window is cut into intervals; a cell's change in an interval is the sum,
+// over its markets, of the YES-equivalent fill price's move since the
+// market's previous fill (NO fills count as 1 - price). Each pair of cells
+// gets the Pearson correlation of their changes over the intervals where
+// both moved.
+func (s *Service) GetCellCorrelation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var cells []string
+	seen := make(map[string]bool)
+	for _, c := range strings.Split(q.Get("cells"), ",") {
+		if c = strings.TrimSpace(c); c != "" && !seen[c] {
+			seen[c] = true
+			cells = append(cells, c)
+		}
+	}
+	if len(cells) < 2 || len(cells) > maxCorrelationCells {
+		writeError(w, "cells must list 2 to 20 H3 cell IDs", http.StatusBadRequest)
+		return
+	}
+	for _, c := range cells {
+		if !cellPrefixRegex.MatchString(c) {
+			writeError(w, "cell IDs must be lowercase hex", http.StatusBadRequest)
+			return
+		}
+	}
+
+	window, interval := defaultCorrelationWindow, defaultCorrelationInterval
+	for name, v := range map[string]*time.Duration{"window": &window, "interval": &interval} {
+		if spec := q.Get(name); spec != "" {
+			dur, err := parsePeriod(spec)
+			if err != nil || dur <= 0 {
+				writeError(w, name+" must be a positive duration (e.g. 7d, 1h)", http.StatusBadRequest)
+				return
+			}
+			*v = dur
+		}
+	}
+	if interval > window {
+		writeError(w, "interval must not be longer than window", http.StatusBadRequest)
+		return
+	}
+	since := s.now().Add(-window)
+
+	changes := make(map[string]map[int64]float64, len(cells))
+	for _, cell := range cells {
+		c, err := s.cellPriceChanges(r, cell, since, interval)
+		if err != nil {
+			s.internalError(w, r, "failed to load cell price history", err)
+			return
+		}
+		changes[cell] = c
+	}
+
+	pairs := []CellCorrelation{}
+	for i, a := range cells {
+		for _, b := range cells[i+1:] {
+			corr, n := correlate(changes[a], changes[b])
+			pairs = append(pairs, CellCorrelation{CellA: a, CellB: b, Correlation: corr, Observations: n})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CellCorrelationResponse{
+		Cells:    cells,
+		Window:   window.String(),
+		Interval: interval.String(),
+		Since:    since.UTC(),
+		Pairs:    pairs,
+	})
+}
+
+// cellPriceChanges returns the cell's price change per interval since
+// since, keyed by interval index, leaving out intervals where it didn't
+// move. A market's first fill in the window moves from its last fill
+// before it, if any.
+func (s *Service) cellPriceChanges(r *http.Request, cell string, since time.Time, interval time.Duration) (map[int64]float64, error) {
+	ctx := r.Context()
+	markets, err := s.store.SearchMarkets(ctx, store.MarketFilter{H3Prefix: cell, IncludeHidden: true})
+	if err != nil {
+		return nil, err
+	}
+
+	one := decimal.NewFromInt(1)
+	sums := make(map[int64]decimal.Decimal)
+	for _, m := range markets {
+		if m.H3CellID != cell {
+			continue
+		}
+		// The last fill in each interval, and the one before the window.
+		var prev *decimal.Decimal
+		last := make(map[int64]decimal.Decimal)
+		var order []int64
+		err := s.store.StreamLedgerEntriesByMarket(ctx, m.ID, func(e model.LedgerEntry) error {
+			if !e.IsTrade() {
+				return nil
+			}
+			p := e.Price
+			if e.Side == "NO" {
+				p = one.Sub(p)
+			}
+			if e.Timestamp.Before(since) {
+				prev = &p
+				return nil
+			}
+			k := int64(e.Timestamp.Sub(since) / interval)
+			if _, ok := last[k]; !ok {
+				order = append(order, k)
+			}
+			last[k] = p
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range order {
+			if prev != nil {
+				sums[k] = sums[k].Add(last[k].Sub(*prev))
+			}
+			p := last[k]
+			prev = &p
+		}
+	}
+
+	changes := make(map[int64]float64, len(sums))
+	for k, d := range sums {
+		if !d.IsZero() {
+			changes[k] = d.InexactFloat64()
+		}
+	}
+	return changes, nil
+}
+
+// correlate returns the Pearson correlation of a and b over the intervals
+// both contain, and how many that is. The coefficient is nil when there
+// are too few intervals or either side doesn't vary.
+func correlate(a, b map[int64]float64) (*float64, int) {
+	var xs, ys []float64
+	for k, x := range a {
+		if y, ok := b[k]; ok {
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+	}
+	n := len(xs)
+	if n < minCorrelationOverlap {
+		return nil, n
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil, n
+	}
+	// Rounding can push a perfect correlation just past ±1.
+	c := math.Max(-1, math.Min(1, cov/math.Sqrt(varX*varY)))
+	return &c, n
+}
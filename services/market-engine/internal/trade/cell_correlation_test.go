@@ -0,0 +1,142 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// correlationEnv returns a test env whose clock stands at now.
+func correlationEnv(t *testing.T, now time.Time) (*store.MemoryStore, chi.Router) {
+	t.Helper()
+	_, ms, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+	return ms, router
+}
+
+// insertFills records a side fill at each price on market, an hour apart
+// ending an hour before now.
+func insertFills(t *testing.T, ms *store.MemoryStore, market *model.Market, side string, now time.Time, prices []float64) {
+	t.Helper()
+	for i, p := range prices {
+		e := model.LedgerEntry{
+			ID:         fmt.Sprintf("%s-%d", market.ID, i),
+			UserID:     "user1",
+			MarketID:   market.ID,
+			ContractID: market.ContractID,
+			Side:       side,
+			Quantity:   d(1),
+			Price:      d(p),
+			Timestamp:  now.Add(-time.Duration(len(prices)-i) * time.Hour),
+		}
+		if err := ms.InsertLedgerEntry(context.Background(), &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var correlationSeries = []float64{0.50, 0.52, 0.49, 0.55, 0.61, 0.58, 0.60, 0.54}
+
+func TestGetCellCorrelation_CoMovingCells(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	ms, router := correlationEnv(t, now)
+	a := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	b := seedMarket(t, ms, "ATMX-872a10711-PRECIP-25MM-20250815", "872a10711", 100)
+	c := seedMarket(t, ms, "ATMX-88283082b-PRECIP-25MM-20250815", "88283082b", 100)
+
+	// b moves exactly like a at twice the size; c mirrors a through NO fills
+	// at the same prices.
+	doubled := make([]float64, len(correlationSeries))
+	for i, p := range correlationSeries {
+		doubled[i] = 2*p - 0.5
+	}
+	insertFills(t, ms, a, "YES", now, correlationSeries)
+	insertFills(t, ms, b, "YES", now, doubled)
+	insertFills(t, ms, c, "NO", now, correlationSeries)
+
+	w, resp := getJSON[trade.CellCorrelationResponse](t, router, "/api/v1/cells/correlation?cells=872a1070b,872a10711,88283082b&window=1d")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Pairs) != 3 {
+		t.Fatalf("expected 3 pairs, got %+v", resp.Pairs)
+	}
+
+	want := map[string]float64{
+		"872a1070b/872a10711": 1,
+		"872a1070b/88283082b": -1,
+		"872a10711/88283082b": -1,
+	}
+	for _, p := range resp.Pairs {
+		key := p.CellA + "/" + p.CellB
+		if p.Correlation == nil {
+			t.Errorf("%s: correlation is null", key)
+			continue
+		}
+		if math.Abs(*p.Correlation-want[key]) > 1e-9 {
+			t.Errorf("%s: correlation = %v, want %v", key, *p.Correlation, want[key])
+		}
+		// Eight fills make seven moves.
+		if p.Observations != 7 {
+			t.Errorf("%s: observations = %d, want 7", key, p.Observations)
+		}
+	}
+}
+
+func TestGetCellCorrelation_InsufficientOverlapIsNull(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	ms, router := correlationEnv(t, now)
+	a := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	b := seedMarket(t, ms, "ATMX-872a10711-PRECIP-25MM-20250815", "872a10711", 100)
+	seedMarket(t, ms, "ATMX-88283082b-PRECIP-25MM-20250815", "88283082b", 100)
+
+	insertFills(t, ms, a, "YES", now, correlationSeries)
+	insertFills(t, ms, b, "YES", now, correlationSeries[:3])
+
+	// 88283082b has no trades and 89abc0001 no markets at all.
+	w, resp := getJSON[trade.CellCorrelationResponse](t, router, "/api/v1/cells/correlation?cells=872a1070b,872a10711,88283082b,89abc0001")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Pairs) != 6 {
+		t.Fatalf("expected 6 pairs, got %d", len(resp.Pairs))
+	}
+	for _, p := range resp.Pairs {
+		if p.Correlation != nil {
+			t.Errorf("%s/%s: correlation = %v, want null", p.CellA, p.CellB, *p.Correlation)
+		}
+	}
+	// b's three fills overlap a's last three hours in only two moves.
+	if p := resp.Pairs[0]; p.Observations != 2 {
+		t.Errorf("observations = %d, want 2", p.Observations)
+	}
+	if !json.Valid(w.Body.Bytes()) {
+		t.Errorf("response is not valid JSON: %s", w.Body.String())
+	}
+}
+
+func TestGetCellCorrelation_BadParams(t *testing.T) {
+	_, router := correlationEnv(t, time.Now())
+	for _, q := range []string{
+		"",
+		"?cells=872a1070b",
+		"?cells=872a1070b,872a1070b",
+		"?cells=872a1070b,ZZZ",
+		"?cells=872a1070b,872a10711&window=soon",
+		"?cells=872a1070b,872a10711&window=-1h",
+		"?cells=872a1070b,872a10711&window=1h&interval=2h",
+	} {
+		if w, _ := getJSON[trade.CellCorrelationResponse](t, router, "/api/v1/cells/correlation"+q); w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", q, w.Code)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// H3 cells with open markets, for map rendering.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+var cellPrefixRegex = regexp.MustCompile(`^[0-9a-f]*$`)
+
+// ListCells handles GET /api/v1/cells?prefix=
+// Returns every distinct H3 cell with at least one open market, with the
+// number of open markets and their aggregate traded volume. An optional
+// hex prefix scopes the result to a region of the H3 index.
+func (s *Service) ListCells(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if !cellPrefixRegex.MatchString(prefix) {
+		writeError(w, "prefix must be lowercase hex", http.StatusBadRequest)
+		return
+	}
+
+	cells, err := s.store.ListOpenCells(r.Context(), prefix)
+	if err != nil {
+		s.internalError(w, r, "failed to list cells", err)
+		return
+	}
+	if cells == nil {
+		cells = []model.CellSummary{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cells)
+}
@@ -0,0 +1,63 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestListCells_DistinctOpenCells(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	seedMarket(t, ms, "ATMX-872a1070b-TEMP-90F-20250815", "872a1070b", 100)
+	seedMarket(t, ms, "ATMX-872a10711-PRECIP-25MM-20250815", "872a10711", 100)
+	seedMarket(t, ms, "ATMX-88283082b-WIND-30MPH-20250815", "88283082b", 100)
+	// Settled markets don't make a cell visible.
+	ms.CreateMarket(context.Background(), &model.Market{
+		ID: "settled", ContractID: "ATMX-89abc0001-SNOW-10CM-20250815", H3CellID: "89abc0001",
+		B: d(100), Status: "settled",
+	})
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", Side: "YES", Quantity: d(10)},
+		{UserID: "u2", ContractID: "ATMX-872a1070b-TEMP-90F-20250815", Side: "NO", Quantity: d(5)},
+		{UserID: "u1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", Side: "YES", Quantity: d(-4)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, cells := getJSON[[]model.CellSummary](t, router, "/api/v1/cells")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := []model.CellSummary{
+		{H3CellID: "872a1070b", MarketCount: 2, Volume: d(19)},
+		{H3CellID: "872a10711", MarketCount: 1, Volume: d(0)},
+		{H3CellID: "88283082b", MarketCount: 1, Volume: d(0)},
+	}
+	if len(cells) != len(want) {
+		t.Fatalf("expected %d cells, got %+v", len(want), cells)
+	}
+	for i, c := range cells {
+		if c.H3CellID != want[i].H3CellID || c.MarketCount != want[i].MarketCount || !c.Volume.Equal(want[i].Volume) {
+			t.Errorf("cell %d: expected %+v, got %+v", i, want[i], c)
+		}
+	}
+
+	_, cells = getJSON[[]model.CellSummary](t, router, "/api/v1/cells?prefix=872a")
+	if len(cells) != 2 || cells[0].H3CellID != "872a1070b" || cells[1].H3CellID != "872a10711" {
+		t.Errorf("expected the two 872a cells, got %+v", cells)
+	}
+}
+
+func TestListCells_InvalidPrefix(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	if w, _ := getJSON[[]model.CellSummary](t, router, "/api/v1/cells?prefix=87%25"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-hex prefix, got %d", w.Code)
+	}
+}
@@ -0,0 +1,137 @@
+// Per-market price-band circuit breaker.
+
+package trade
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ErrCodeCircuitBreaker is returned while a market's circuit breaker has
+// halted trading.
+const ErrCodeCircuitBreaker = "circuit_breaker"
+
+// WithCircuitBreaker halts trading in a market for cooldown once its YES
+// price has moved more than maxMovePct percent (e.g. 20 for 20%) within
+// window. The move is the range of YES prices seen in the window — the
+// fills of its ledger entries and the current price — relative to the
+// lowest. Breaker state is kept per instance.
+func WithCircuitBreaker(maxMovePct decimal.Decimal, window, cooldown time.Duration) Option {
+	return func(s *Service) {
+		s.breaker = &circuitBreaker{
+			maxMove:  maxMovePct.Div(decimal.NewFromInt(100)),
+			window:   window,
+			cooldown: cooldown,
+			halts:    make(map[string]time.Time),
+		}
+	}
+}
+
+// circuitBreaker tracks which markets are halted and until when.
+type circuitBreaker struct {
+	maxMove  decimal.Decimal // as a fraction of the lowest price
+	window   time.Duration
+	cooldown time.Duration
+
+	mu    sync.Mutex
+	halts map[string]time.Time // market ID → end of its latest halt
+}
+
+// haltedUntil returns the end of market's current halt, if it is halted
+// at now.
+func (cb *circuitBreaker) haltedUntil(marketID string, now time.Time) (time.Time, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	until, ok := cb.halts[marketID]
+	return until, ok && now.Before(until)
+}
+
+// since returns where market's price window starts at now: window ago, or
+// the end of its last halt if later, so moves that caused a halt don't
+// trip the breaker again once it resets.
+func (cb *circuitBreaker) since(marketID string, now time.Time) time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	start := now.Add(-cb.window)
+	if until, ok := cb.halts[marketID]; ok && until.After(start) {
+		start = until
+	}
+	return start
+}
+
+// trip halts market from now for the cooldown and returns when it ends.
+func (cb *circuitBreaker) trip(marketID string, now time.Time) time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	until := now.Add(cb.cooldown)
+	cb.halts[marketID] = until
+	return until
+}
+
+// priceMove returns the range of YES prices in entries and current,
+// relative to the lowest. NO fills count at their YES equivalent.
+func priceMove(entries []model.LedgerEntry, current decimal.Decimal) decimal.Decimal {
+	lo, hi := current, current
+	one := decimal.NewFromInt(1)
+	for _, e := range entries {
+		p := e.Price
+		if e.Side == "NO" {
+			p = one.Sub(p)
+		}
+		lo, hi = decimal.Min(lo, p), decimal.Max(hi, p)
+	}
+	if !lo.IsPositive() {
+		return decimal.Zero
+	}
+	return hi.Sub(lo).Div(lo)
+}
+
+// checkCircuitBreaker writes a coded 409 and returns false if trading in m
+// is halted, tripping the breaker first if the market's recent price move
+// exceeds the band. It returns true when no breaker is configured.
+func (s *Service) checkCircuitBreaker(w http.ResponseWriter, r *http.Request, m *model.Market) bool {
+	cb := s.breaker
+	if cb == nil {
+		return true
+	}
+	now := s.now()
+
+	until, halted := cb.haltedUntil(m.ID, now)
+	if !halted {
+		since := cb.since(m.ID, now)
+		var recent []model.LedgerEntry
+		err := s.store.StreamLedgerEntriesByMarket(r.Context(), m.ID, func(e model.LedgerEntry) error {
+			if e.IsTrade() && !e.Timestamp.Before(since) {
+				recent = append(recent, e)
+			}
+			return nil
+		})
+		if err != nil {
+			s.internalError(w, r, "failed to check circuit breaker", err)
+			return false
+		}
+		move := priceMove(recent, m.MarkPriceYes())
+		if !move.GreaterThan(cb.maxMove) {
+			return true
+		}
+		until = cb.trip(m.ID, now)
+		slog.Warn("circuit breaker tripped",
+			"market", m.ID, "move", move.StringFixed(4), "until", until.UTC().Format(time.RFC3339))
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(until.Sub(now).Seconds()))))
+	writeCodedError(w, ErrCodeCircuitBreaker,
+		fmt.Sprintf("trading halted until %s: price moved more than %s%% within %s",
+			until.UTC().Format(time.RFC3339), cb.maxMove.Mul(decimal.NewFromInt(100)).String(), cb.window),
+		http.StatusConflict)
+	return false
+}
@@ -0,0 +1,87 @@
+package trade_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// circuitEnv returns a router with a 10% / 1m / 5m circuit breaker whose
+// clock is controlled via *now, and a contract to trade on it.
+func circuitEnv(t *testing.T, now *time.Time) (chi.Router, string) {
+	t.Helper()
+	_, ms, router := newTestEnv(t,
+		trade.WithClock(func() time.Time { return *now }),
+		trade.WithCircuitBreaker(d(10), time.Minute, 5*time.Minute),
+	)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	return router, contractID
+}
+
+func TestCircuitBreaker_TripsHaltsAndResets(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	router, contractID := circuitEnv(t, &now)
+	buy := func(qty float64) (int, string) {
+		t.Helper()
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(qty)})
+		var body struct{ Code string }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.Code
+	}
+
+	// A small move stays inside the band.
+	if code, _ := buy(1); code != http.StatusOK {
+		t.Fatalf("small trade: expected 200, got %d", code)
+	}
+	// The breaker is checked before executing, so the trade that makes the
+	// big move (0.50 → 0.73) goes through...
+	now = now.Add(10 * time.Second)
+	if code, _ := buy(100); code != http.StatusOK {
+		t.Fatalf("large trade: expected 200, got %d", code)
+	}
+	// ...and the next one trips it.
+	now = now.Add(10 * time.Second)
+	if code, errCode := buy(1); code != http.StatusConflict || errCode != trade.ErrCodeCircuitBreaker {
+		t.Fatalf("after rapid move: expected 409 %s, got %d %q", trade.ErrCodeCircuitBreaker, code, errCode)
+	}
+
+	// Still halted near the end of the cooldown, for either side.
+	now = now.Add(5*time.Minute - time.Second)
+	w := doTrade(t, router, trade.TradeRequest{UserID: "u2", ContractID: contractID, Side: "NO", Quantity: d(1)})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("during cooldown: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	// Resets after the cooldown; the move that tripped it doesn't count again.
+	now = now.Add(time.Second)
+	if code, _ := buy(1); code != http.StatusOK {
+		t.Fatalf("after cooldown: expected 200, got %d", code)
+	}
+	now = now.Add(time.Second)
+	if code, _ := buy(1); code != http.StatusOK {
+		t.Fatalf("after reset: expected 200, got %d", code)
+	}
+}
+
+func TestCircuitBreaker_SlowMoveDoesNotTrip(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	router, contractID := circuitEnv(t, &now)
+
+	// The same total move as above, but each step lands in its own window.
+	for i := range 10 {
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("trade %d: expected 200, got %d: %s", i, w.Code, w.Body.String())
+		}
+		now = now.Add(2 * time.Minute)
+	}
+}
@@ -0,0 +1,201 @@
+// Position close-out ("sell to close") handling.
+
+package trade
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// ClosePositionResponse is the JSON body returned from the close endpoint.
+type ClosePositionResponse struct {
+	UserID      string          `json:"user_id"`
+	MarketID    string          `json:"market_id"`
+	ContractID  string          `json:"contract_id"`
+	Legs        []TradeResponse `json:"legs"`
+	Proceeds    decimal.Decimal `json:"proceeds"`     // cash returned to the trader
+	CostBasis   decimal.Decimal `json:"cost_basis"`   // cost basis before closing
+	RealizedPnL decimal.Decimal `json:"realized_pnl"` // Σ legs' realized P&L
+}
+
+// ClosePosition handles POST /api/v1/portfolio/{userID}/markets/{marketID}/close
+// Unwinds the user's YES and NO holdings in one market with the opposite
+// LMSR trades, leaving the position flat.
+//
+// Each side is a separate ledger entry. The unwind is all-or-nothing: if any
+// leg would push the price beyond the LMSR bounds or outside the market's
+// trade size bounds, nothing is written and the request is rejected so the
+// trader can reduce size with /trade instead. The market-wide checks, the
+// circuit breaker, the subsidy budget and auto-liquidity apply as they do
+// to a trade. Position limits are not checked since flattening cannot add
+// exposure in this market.
+func (s *Service) ClosePosition(w http.ResponseWriter, r *http.Request) {
+	tradeStart := time.Now()
+	userID := chi.URLParam(r, "userID")
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	held, unlock, ok := s.lockMarket(w, r, marketID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	req := TradeRequest{UserID: userID, ContractID: market.ContractID}
+	ex, ok := s.beginExecution(w, r, req, market)
+	if !ok {
+		return
+	}
+
+	pos, err := s.store.GetUserMarketPosition(store.WithPrimaryReads(ctx), userID, market.ID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "no position in market")
+		return
+	}
+	if pos.YesQty.IsZero() && pos.NoQty.IsZero() {
+		writeError(w, "position is already flat", http.StatusConflict)
+		return
+	}
+
+	mm := ex.mm
+
+	// Price every leg before writing anything so a bound violation on the
+	// second leg cannot leave the first one half-applied.
+	type closeLeg struct {
+		side string
+		qty  decimal.Decimal
+		tradeLeg
+	}
+	var legs []closeLeg
+	qYes, qNo := market.QYes, market.QNo
+	cost := decimal.Zero
+	for _, side := range []string{"YES", "NO"} {
+		held := pos.YesQty
+		if side == "NO" {
+			held = pos.NoQty
+		}
+		if held.IsZero() {
+			continue
+		}
+		legReq := req
+		legReq.Side, legReq.Quantity = side, held.Neg()
+		if !checkTradeSize(w, market, legReq.Quantity) {
+			logTradeRejection(legReq, ErrCodeTradeSizeOutOfRange)
+			return
+		}
+		leg, err := priceLeg(mm, qYes, qNo, side, held.Neg())
+		if err != nil {
+			logTradeRejection(legReq, RejectPriceBound, "err", err)
+			writeError(w, "cannot close position: "+err.Error(), http.StatusConflict)
+			return
+		}
+		legs = append(legs, closeLeg{side: side, qty: held.Neg(), tradeLeg: leg})
+		qYes, qNo = leg.newQYes, leg.newQNo
+		cost = cost.Add(leg.cost)
+	}
+	if loss, ok := checkSubsidyBudget(w, market, qYes, qNo, cost); !ok {
+		logTradeRejection(req, ErrCodeSubsidyBudgetExceeded, "worst_case_loss", loss.String(), "budget", market.SubsidyBudget.String())
+		return
+	}
+
+	newPriceYes := mm.Price(qYes, qNo)
+	newPriceNo := mm.PriceNo(qYes, qNo)
+
+	// Each leg closes one side, so each books against the position as it
+	// stood before the close.
+	entries := make([]*model.LedgerEntry, len(legs))
+	for i, leg := range legs {
+		realized, err := s.positionRealizedPnL(ctx, pos, leg.side, leg.qty, leg.cost)
+		if err != nil {
+			s.internalError(w, r, "failed to load trade history", err)
+			return
+		}
+		entries[i] = &model.LedgerEntry{
+			ID:         s.ids.NewID(),
+			UserID:     userID,
+			MarketID:   market.ID,
+			ContractID: market.ContractID,
+			Side:       leg.side,
+			Quantity:   leg.qty,
+			Price:      leg.fillPrice,
+			Cost:       leg.cost,
+			Timestamp:  s.now().UTC(),
+
+			RealizedPnL: realized,
+		}
+	}
+	if err := s.applyExecution(held, ex, entries, qYes, qNo, newPriceYes, newPriceNo); err != nil {
+		s.writeApplyError(w, r, err)
+		return
+	}
+
+	resp := ClosePositionResponse{
+		UserID:      userID,
+		MarketID:    market.ID,
+		ContractID:  market.ContractID,
+		Legs:        make([]TradeResponse, 0, len(legs)),
+		Proceeds:    decimal.Zero,
+		CostBasis:   pos.CostBasis,
+		RealizedPnL: decimal.Zero,
+	}
+
+	for i, leg := range legs {
+		entry := entries[i]
+		s.emitTradeExecuted(ctx, entry, market, newPriceYes, newPriceNo)
+		resp.Proceeds = resp.Proceeds.Sub(leg.cost)
+		resp.RealizedPnL = resp.RealizedPnL.Add(entry.RealizedPnL)
+		resp.Legs = append(resp.Legs, TradeResponse{
+			TradeID:     entry.ID,
+			UserID:      userID,
+			ContractID:  market.ContractID,
+			Side:        leg.side,
+			Quantity:    leg.qty,
+			FillPrice:   leg.fillPrice,
+			Cost:        leg.cost,
+			RealizedPnL: entry.RealizedPnL,
+		})
+
+		metrics.TradesTotal.WithLabelValues(leg.side).Inc()
+		metrics.TradeLatency.WithLabelValues(leg.side).Observe(time.Since(tradeStart).Seconds())
+		metrics.MarketVolume.WithLabelValues(market.ID, leg.side).Add(leg.qty.Abs().InexactFloat64())
+
+		if s.wsHub != nil {
+			s.wsHub.Broadcast(WSMessage{
+				Type:       "trade_executed",
+				MarketID:   market.ID,
+				ContractID: market.ContractID,
+				H3CellID:   market.H3CellID,
+				PriceYes:   newPriceYes.String(),
+				PriceNo:    newPriceNo.String(),
+				Side:       leg.side,
+				Quantity:   leg.qty.String(),
+			})
+		}
+	}
+	slog.Info("position closed",
+		"user", userID,
+		"market", market.ID,
+		"contract", market.ContractID,
+		"legs", len(legs),
+		"proceeds", resp.Proceeds.String(),
+		"realized_pnl", resp.RealizedPnL.String(),
+		"new_price_yes", newPriceYes.String(),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
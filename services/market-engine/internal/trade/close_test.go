@@ -0,0 +1,292 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func doClose(t *testing.T, router chi.Router, userID, marketID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/v1/portfolio/"+userID+"/markets/"+marketID+"/close", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestClosePosition_FlattensAndRealizes(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for _, req := range []trade.TradeRequest{
+		{UserID: "user1", ContractID: market.ContractID, Side: "YES", Quantity: d(40)},
+		{UserID: "user1", ContractID: market.ContractID, Side: "NO", Quantity: d(15)},
+		{UserID: "user2", ContractID: market.ContractID, Side: "YES", Quantity: d(20)},
+	} {
+		if w := doTrade(t, router, req); w.Code != http.StatusOK {
+			t.Fatalf("seed trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	ctx := context.Background()
+	before, _ := ms.GetMarket(ctx, market.ID)
+	positions, _ := ms.GetUserPositions(ctx, "user1")
+	if len(positions) != 1 {
+		t.Fatalf("expected 1 position before close, got %d", len(positions))
+	}
+	costBasis := positions[0].CostBasis
+
+	w := doClose(t, router, "user1", market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp trade.ClosePositionResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if len(resp.Legs) != 2 {
+		t.Fatalf("expected 2 legs (YES and NO), got %d", len(resp.Legs))
+	}
+
+	// Net zero after closing.
+	positions, _ = ms.GetUserPositions(ctx, "user1")
+	if len(positions) != 1 {
+		t.Fatalf("expected 1 position after close, got %d", len(positions))
+	}
+	p := positions[0]
+	if !p.YesQty.IsZero() || !p.NoQty.IsZero() || !p.NetQty.IsZero() {
+		t.Errorf("expected flat position, got yes=%s no=%s net=%s", p.YesQty, p.NoQty, p.NetQty)
+	}
+
+	// Proceeds equal the LMSR value of unwinding both sides at execution.
+	mm, _ := lmsr.NewMarketMaker(before.B)
+	sellYes := mm.TradeCost(before.QYes, before.QNo, d(-40))
+	sellNo := mm.TradeCostNo(before.QYes.Sub(d(40)), before.QNo, d(-15))
+	expectedProceeds := sellYes.Add(sellNo).Neg()
+
+	tolerance := d(0.0000001)
+	if resp.Proceeds.Sub(expectedProceeds).Abs().GreaterThan(tolerance) {
+		t.Errorf("proceeds=%s, expected %s", resp.Proceeds, expectedProceeds)
+	}
+	if !resp.CostBasis.Equal(costBasis) {
+		t.Errorf("cost_basis=%s, expected %s", resp.CostBasis, costBasis)
+	}
+	legsPnL := resp.Legs[0].RealizedPnL.Add(resp.Legs[1].RealizedPnL)
+	if !resp.RealizedPnL.Equal(legsPnL) {
+		t.Errorf("realized_pnl=%s, expected the legs' sum %s", resp.RealizedPnL, legsPnL)
+	}
+	// Nothing was realized before the close, so a flat position's
+	// remaining P&L is exactly what the close realized.
+	if !p.UnrealizedPnL.Equal(resp.RealizedPnL) {
+		t.Errorf("flat position pnl=%s, expected realized %s", p.UnrealizedPnL, resp.RealizedPnL)
+	}
+
+	// Other users are untouched.
+	other, _ := ms.GetUserPositions(ctx, "user2")
+	if len(other) != 1 || !other[0].YesQty.Equal(d(20)) {
+		t.Errorf("user2 position should be unchanged, got %+v", other)
+	}
+
+	entries, _ := ms.GetLedgerEntriesByUser(ctx, "user1")
+	if len(entries) != 4 {
+		t.Errorf("expected 4 ledger entries (2 trades + 2 close legs), got %d", len(entries))
+	}
+}
+
+func TestClosePosition_RealizedMatchesPortfolioAfterPartialClose(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// Buy 40, then sell 15 with /trade: that sale realizes P&L and its
+	// proceeds already sit in the position's cost basis.
+	for _, req := range []trade.TradeRequest{
+		{UserID: "user1", ContractID: market.ContractID, Side: "YES", Quantity: d(40)},
+		{UserID: "user2", ContractID: market.ContractID, Side: "YES", Quantity: d(30)},
+		{UserID: "user1", ContractID: market.ContractID, Side: "YES", Quantity: d(-15)},
+	} {
+		if w := doTrade(t, router, req); w.Code != http.StatusOK {
+			t.Fatalf("seed trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+	_, before := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1")
+	if len(before.Positions) != 1 || before.Positions[0].RealizedPnL.IsZero() {
+		t.Fatalf("expected P&L realized by the partial sale, got %+v", before.Positions)
+	}
+
+	w := doClose(t, router, "user1", market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.ClosePositionResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	_, after := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1")
+	booked := after.Positions[0].RealizedPnL.Sub(before.Positions[0].RealizedPnL)
+	if !resp.RealizedPnL.Equal(booked) {
+		t.Errorf("close realized_pnl=%s, portfolio booked %s", resp.RealizedPnL, booked)
+	}
+	if resp.RealizedPnL.Equal(resp.Proceeds.Sub(resp.CostBasis)) {
+		t.Errorf("realized_pnl=%s counts the earlier sale's proceeds again", resp.RealizedPnL)
+	}
+}
+
+func TestClosePosition_NoPosition(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w := doClose(t, router, "nobody", market.ID)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestClosePosition_AlreadyFlat(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: market.ContractID, Side: "YES", Quantity: d(10),
+	})
+	if w := doClose(t, router, "user1", market.ID); w.Code != http.StatusOK {
+		t.Fatalf("first close failed: %d %s", w.Code, w.Body.String())
+	}
+
+	w := doClose(t, router, "user1", market.ID)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 closing a flat position, got %d", w.Code)
+	}
+}
+
+func TestClosePosition_PriceBoundRejected(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// user1 is long YES; user2 then buys a lot of NO, pushing YES near the
+	// floor so dumping user1's YES would breach MinPrice.
+	doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: market.ContractID, Side: "YES", Quantity: d(300),
+	})
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "user2", ContractID: market.ContractID, Side: "NO", Quantity: d(900),
+	}); w.Code != http.StatusOK {
+		t.Fatalf("seed NO trade failed: %d %s", w.Code, w.Body.String())
+	}
+
+	before, _ := ms.GetMarket(context.Background(), market.ID)
+
+	w := doClose(t, router, "user1", market.ID)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for price bound, got %d: %s", w.Code, w.Body.String())
+	}
+
+	after, _ := ms.GetMarket(context.Background(), market.ID)
+	if !after.QYes.Equal(before.QYes) || !after.QNo.Equal(before.QNo) {
+		t.Error("rejected close must not change market state")
+	}
+}
+
+// assertCloseRejected checks userID's close was refused with code and left
+// their ledger at want entries.
+func assertCloseRejected(t *testing.T, ms *store.MemoryStore, w *httptest.ResponseRecorder, userID, code string, want int) {
+	t.Helper()
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != code {
+		t.Errorf("code = %q, want %q", body["code"], code)
+	}
+	if entries, _ := ms.GetLedgerEntriesByUser(context.Background(), userID); len(entries) != want {
+		t.Errorf("rejected close wrote entries: %d, want %d", len(entries), want)
+	}
+}
+
+func TestClosePosition_TradeSizeBounds(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	for _, qty := range []float64{40, 20} {
+		doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(qty)})
+	}
+	if w, _ := patchTradeSize(t, router, market.ID, `{"max_quantity":"50"}`); w.Code != http.StatusOK {
+		t.Fatalf("set trade size: %d %s", w.Code, w.Body.String())
+	}
+
+	// Selling the 60 held in one leg exceeds the maximum of 50.
+	assertCloseRejected(t, ms, doClose(t, router, "u1", market.ID), "u1", trade.ErrCodeTradeSizeOutOfRange, 2)
+}
+
+func TestClosePosition_CircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t,
+		trade.WithClock(func() time.Time { return now }),
+		trade.WithCircuitBreaker(d(10), time.Minute, 5*time.Minute),
+	)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// 0.50 → 0.73 within the minute: the next write trips the breaker.
+	for _, qty := range []float64{1, 100} {
+		now = now.Add(10 * time.Second)
+		doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(qty)})
+	}
+	now = now.Add(10 * time.Second)
+	assertCloseRejected(t, ms, doClose(t, router, "u1", market.ID), "u1", trade.ErrCodeCircuitBreaker, 2)
+}
+
+func TestClosePosition_SubsidyBudget(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	m := createBudgetedMarket(t, router)
+
+	// u2's NO hedges the maker against u1's YES: at (70, 40) the
+	// worst-case loss is about 13.9, at (70, 0) about 29.
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(30)},
+		{UserID: "u2", ContractID: m.ContractID, Side: "NO", Quantity: d(40)},
+		{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(40)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("seed trade: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	assertCloseRejected(t, ms, doClose(t, router, "u2", m.ID), "u2", trade.ErrCodeSubsidyBudgetExceeded, 1)
+	if got, _ := ms.GetMarket(context.Background(), m.ID); !got.QNo.Equal(d(40)) {
+		t.Errorf("rejected close moved q_no to %s", got.QNo)
+	}
+
+	// Closing u1's YES lowers the maker's risk and goes through.
+	if w := doClose(t, router, "u1", m.ID); w.Code != http.StatusOK {
+		t.Errorf("risk-reducing close: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestClosePosition_AutoLiquidity(t *testing.T) {
+	ms, router, now := autoLiquidityEnv(t, testAutoLiquidity)
+	m := createAutoLiquidityMarket(t, router, true)
+	tradeEvery(t, ms, router, now, m, 20, 3)
+
+	// 60 traded in the window: the close is priced, and b stored, at 110.
+	*now = now.Add(time.Second)
+	w := doClose(t, router, "u1", m.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("close: %d %s", w.Code, w.Body.String())
+	}
+	got, _ := ms.GetMarket(context.Background(), m.ID)
+	changes, _ := ms.GetLiquidityChanges(context.Background(), m.ID)
+	if !got.B.Equal(d(110)) || len(changes) != 1 {
+		t.Errorf("after close: b = %s with %d liquidity changes, want 110 and one", got.B, len(changes))
+	}
+	if w, resp := postVerify(t, router, m.ID); w.Code != http.StatusOK || !resp.OK {
+		t.Errorf("verify after close: %d %s", w.Code, w.Body.String())
+	}
+}
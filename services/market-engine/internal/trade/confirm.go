@@ -0,0 +1,241 @@
+// Two-step confirmation for large trades.
+
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+// Error codes for trades executed with a confirm token.
+const (
+	ErrCodeConfirmTokenInvalid  = "confirm_token_invalid"
+	ErrCodeConfirmTokenMismatch = "confirm_token_mismatch"
+	ErrCodeMarketMoved          = "market_moved"
+)
+
+// Confirmation defaults: a quote is good for DefaultQuoteTTL, and executes
+// while the fill price is within DefaultQuoteTolerance of the quoted one.
+var (
+	DefaultQuoteTTL       = 30 * time.Second
+	DefaultQuoteTolerance = decimal.NewFromFloat(0.01)
+)
+
+// ErrQuoteNotFound is returned by a QuoteStore for a token that was never
+// issued, has expired or has already been used.
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// PendingQuote is a quoted trade awaiting confirmation.
+type PendingQuote struct {
+	UserID     string          `json:"user_id"`
+	ContractID string          `json:"contract_id"`
+	Side       string          `json:"side"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	FillPrice  decimal.Decimal `json:"fill_price"`
+	Cost       decimal.Decimal `json:"cost"`
+	QuotedAt   time.Time       `json:"quoted_at"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
+
+// matches reports whether req is the trade q was quoted for.
+func (q PendingQuote) matches(req TradeRequest) bool {
+	return q.UserID == req.UserID && q.ContractID == req.ContractID &&
+		q.Side == req.Side && q.Quantity.Equal(req.Quantity)
+}
+
+// TradeQuote is the JSON body returned from POST /trade when a trade needs
+// confirming. Sending the same trade again with ConfirmToken executes it.
+type TradeQuote struct {
+	ConfirmToken string `json:"confirm_token"`
+	PendingQuote
+}
+
+// QuoteStore holds pending quotes until they are confirmed or expire.
+type QuoteStore interface {
+	// Put stores q under token until q.ExpiresAt.
+	Put(ctx context.Context, token string, q PendingQuote) error
+	// Take removes and returns the quote for token, or ErrQuoteNotFound.
+	Take(ctx context.Context, token string) (PendingQuote, error)
+}
+
+// WithTradeConfirmation requires trades whose notional (|cost|) is at
+// least notional to be confirmed. A quote is valid for ttl and executes
+// while the fill price has moved by at most tolerance. A zero notional
+// only quotes trades that ask for it with confirm: false.
+func WithTradeConfirmation(notional decimal.Decimal, ttl time.Duration, tolerance decimal.Decimal) Option {
+	return func(s *Service) {
+		s.confirmNotional = notional
+		s.quoteTTL = ttl
+		s.quoteTolerance = tolerance
+	}
+}
+
+// WithQuoteStore replaces the default in-process quote store, e.g. with a
+// RedisQuoteStore so any instance can execute a quote.
+func WithQuoteStore(qs QuoteStore) Option {
+	return func(s *Service) { s.quotes = qs }
+}
+
+// needsConfirm reports whether req must be quoted before it executes.
+func (s *Service) needsConfirm(req TradeRequest, cost decimal.Decimal) bool {
+	if req.Confirm != nil && !*req.Confirm {
+		return true
+	}
+	return s.confirmNotional.IsPositive() && cost.Abs().GreaterThanOrEqual(s.confirmNotional)
+}
+
+// confirmTrade runs the confirmation step for a priced trade. Without a
+// token, a trade that needs confirming is quoted: it writes a 202 with a
+// TradeQuote and returns false. With a token it checks the quote is live,
+// is for this trade and that the fill price hasn't moved beyond the
+// tolerance, writing a coded error and returning false if not. Tokens are
+// single-use, including when the check fails.
+func (s *Service) confirmTrade(w http.ResponseWriter, r *http.Request, req TradeRequest, leg tradeLeg) bool {
+	ctx := r.Context()
+	now := s.now().UTC()
+
+	if req.ConfirmToken == "" {
+		if !s.needsConfirm(req, leg.cost) {
+			return true
+		}
+		quote := TradeQuote{
+			ConfirmToken: uuid.New().String(),
+			PendingQuote: PendingQuote{
+				UserID:     req.UserID,
+				ContractID: req.ContractID,
+				Side:       req.Side,
+				Quantity:   req.Quantity,
+				FillPrice:  leg.fillPrice,
+				Cost:       leg.cost,
+				QuotedAt:   now,
+				ExpiresAt:  now.Add(s.quoteTTL),
+			},
+		}
+		if err := s.quotes.Put(ctx, quote.ConfirmToken, quote.PendingQuote); err != nil {
+			s.internalError(w, r, "failed to store quote", err)
+			return false
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(quote)
+		return false
+	}
+
+	q, err := s.quotes.Take(ctx, req.ConfirmToken)
+	if errors.Is(err, ErrQuoteNotFound) || (err == nil && !now.Before(q.ExpiresAt)) {
+		logTradeRejection(req, ErrCodeConfirmTokenInvalid)
+		writeCodedError(w, ErrCodeConfirmTokenInvalid,
+			"confirm token is unknown, expired or already used; request a new quote", http.StatusGone)
+		return false
+	}
+	if err != nil {
+		s.internalError(w, r, "failed to load quote", err)
+		return false
+	}
+	if !q.matches(req) {
+		logTradeRejection(req, ErrCodeConfirmTokenMismatch)
+		writeCodedError(w, ErrCodeConfirmTokenMismatch,
+			"confirm token was issued for a different trade", http.StatusConflict)
+		return false
+	}
+	if moved := leg.fillPrice.Sub(q.FillPrice).Abs(); moved.GreaterThan(s.quoteTolerance) {
+		logTradeRejection(req, ErrCodeMarketMoved,
+			"quoted", q.FillPrice.String(), "fill_price", leg.fillPrice.String())
+		writeCodedError(w, ErrCodeMarketMoved,
+			"fill price moved from "+q.FillPrice.String()+" to "+leg.fillPrice.String()+
+				", beyond the tolerance of "+s.quoteTolerance.String()+"; request a new quote",
+			http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// --- In-process quote store ---
+
+// localQuoteStore keeps quotes in memory, for a single instance.
+type localQuoteStore struct {
+	mu     sync.Mutex
+	quotes map[string]PendingQuote
+}
+
+// NewLocalQuoteStore returns an in-process QuoteStore. Quotes can only be
+// confirmed on the instance that issued them; use NewRedisQuoteStore when
+// running multiple instances.
+func NewLocalQuoteStore() QuoteStore {
+	return &localQuoteStore{quotes: make(map[string]PendingQuote)}
+}
+
+func (l *localQuoteStore) Put(ctx context.Context, token string, q PendingQuote) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Drop quotes that had expired by the time this one was issued, so
+	// abandoned quotes don't accumulate.
+	for t, old := range l.quotes {
+		if !q.QuotedAt.Before(old.ExpiresAt) {
+			delete(l.quotes, t)
+		}
+	}
+	l.quotes[token] = q
+	return nil
+}
+
+func (l *localQuoteStore) Take(ctx context.Context, token string) (PendingQuote, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q, ok := l.quotes[token]
+	if !ok {
+		return PendingQuote{}, ErrQuoteNotFound
+	}
+	delete(l.quotes, token)
+	return q, nil
+}
+
+// --- Redis quote store ---
+
+// RedisQuoteStore keeps quotes in Redis as JSON, expiring with the quote.
+type RedisQuoteStore struct {
+	rdb redis.Cmdable
+}
+
+// NewRedisQuoteStore creates a Redis-backed QuoteStore.
+func NewRedisQuoteStore(rdb redis.Cmdable) *RedisQuoteStore {
+	return &RedisQuoteStore{rdb: rdb}
+}
+
+func (s *RedisQuoteStore) Put(ctx context.Context, token string, q PendingQuote) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	ttl := q.ExpiresAt.Sub(q.QuotedAt)
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+	return s.rdb.Set(ctx, quoteKey(token), data, ttl).Err()
+}
+
+// Take uses GETDEL, so two instances can't both execute one quote.
+func (s *RedisQuoteStore) Take(ctx context.Context, token string) (PendingQuote, error) {
+	data, err := s.rdb.GetDel(ctx, quoteKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return PendingQuote{}, ErrQuoteNotFound
+	}
+	if err != nil {
+		return PendingQuote{}, err
+	}
+	var q PendingQuote
+	if err := json.Unmarshal(data, &q); err != nil {
+		return PendingQuote{}, err
+	}
+	return q, nil
+}
+
+func quoteKey(token string) string { return "quote:trade:" + token }
@@ -0,0 +1,161 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// confirmEnv requires confirmation for trades costing 20 or more, with
+// one-minute quotes, a 0.01 tolerance and a settable clock.
+func confirmEnv(t *testing.T) (*store.MemoryStore, chi.Router, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 8, 14, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t,
+		trade.WithClock(func() time.Time { return now }),
+		trade.WithTradeConfirmation(d(20), time.Minute, d(0.01)),
+	)
+	return ms, router, &now
+}
+
+// quoteTrade sends req and expects it to be quoted rather than executed.
+func quoteTrade(t *testing.T, router chi.Router, req trade.TradeRequest) trade.TradeQuote {
+	t.Helper()
+	w := doTrade(t, router, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 quote, got %d: %s", w.Code, w.Body.String())
+	}
+	var q trade.TradeQuote
+	if err := json.Unmarshal(w.Body.Bytes(), &q); err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func assertCode(t *testing.T, body []byte, want string) {
+	t.Helper()
+	var resp map[string]string
+	json.Unmarshal(body, &resp)
+	if resp["code"] != want {
+		t.Errorf("code = %q, want %q (body %s)", resp["code"], want, body)
+	}
+}
+
+func TestConfirm_QuoteThenExecute(t *testing.T) {
+	ms, router, _ := confirmEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	req := trade.TradeRequest{UserID: "whale", ContractID: contractID, Side: "YES", Quantity: d(50)}
+
+	quote := quoteTrade(t, router, req)
+	if quote.ConfirmToken == "" || !quote.ExpiresAt.Equal(quote.QuotedAt.Add(time.Minute)) {
+		t.Errorf("unexpected quote %+v", quote)
+	}
+	if !quote.Cost.GreaterThanOrEqual(d(20)) || !quote.Quantity.Equal(d(50)) {
+		t.Errorf("quote cost %s for %s shares", quote.Cost, quote.Quantity)
+	}
+	if after, _ := ms.GetMarket(context.Background(), market.ID); !after.QYes.IsZero() {
+		t.Fatal("quoting moved the market")
+	}
+
+	req.ConfirmToken = quote.ConfirmToken
+	w := doTrade(t, router, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirm: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.FillPrice.Equal(quote.FillPrice) || !resp.Cost.Equal(quote.Cost) {
+		t.Errorf("executed at %s (cost %s), quoted %s (cost %s)", resp.FillPrice, resp.Cost, quote.FillPrice, quote.Cost)
+	}
+
+	// Tokens are single-use.
+	w = doTrade(t, router, req)
+	if w.Code != http.StatusGone {
+		t.Fatalf("reused token: expected 410, got %d: %s", w.Code, w.Body.String())
+	}
+	assertCode(t, w.Body.Bytes(), trade.ErrCodeConfirmTokenInvalid)
+}
+
+func TestConfirm_SmallTradesAndExplicitQuotes(t *testing.T) {
+	ms, router, _ := confirmEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// Below the notional, trades execute in one step.
+	small := trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(5)}
+	if w := doTrade(t, router, small); w.Code != http.StatusOK {
+		t.Fatalf("small trade: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// confirm: false asks for a quote whatever the size.
+	no := false
+	small.Confirm = &no
+	quote := quoteTrade(t, router, small)
+
+	// A token can't be combined with confirm: false.
+	small.ConfirmToken = quote.ConfirmToken
+	fields := fieldsOf(t, doTrade(t, router, small))
+	if _, ok := fields["confirm_token"]; !ok {
+		t.Errorf("expected a confirm_token error, got %v", fields)
+	}
+
+	// A token only executes the trade it was quoted for.
+	mismatch := trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(6), ConfirmToken: quote.ConfirmToken}
+	w := doTrade(t, router, mismatch)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("mismatch: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	assertCode(t, w.Body.Bytes(), trade.ErrCodeConfirmTokenMismatch)
+}
+
+func TestConfirm_ExpiredToken(t *testing.T) {
+	ms, router, now := confirmEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	req := trade.TradeRequest{UserID: "whale", ContractID: contractID, Side: "YES", Quantity: d(50)}
+
+	req.ConfirmToken = quoteTrade(t, router, req).ConfirmToken
+	*now = now.Add(time.Minute)
+
+	w := doTrade(t, router, req)
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected 410, got %d: %s", w.Code, w.Body.String())
+	}
+	assertCode(t, w.Body.Bytes(), trade.ErrCodeConfirmTokenInvalid)
+	if after, _ := ms.GetMarket(context.Background(), market.ID); !after.QYes.IsZero() {
+		t.Error("expired quote executed")
+	}
+}
+
+func TestConfirm_MarketMoved(t *testing.T) {
+	ms, router, _ := confirmEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	req := trade.TradeRequest{UserID: "whale", ContractID: contractID, Side: "YES", Quantity: d(50)}
+	quote := quoteTrade(t, router, req)
+
+	// Another user buys YES before the confirm, raising the fill price
+	// well beyond the 0.01 tolerance.
+	other := trade.TradeRequest{UserID: "u2", ContractID: contractID, Side: "YES", Quantity: d(10)}
+	if w := doTrade(t, router, other); w.Code != http.StatusOK {
+		t.Fatalf("intervening trade: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req.ConfirmToken = quote.ConfirmToken
+	w := doTrade(t, router, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	assertCode(t, w.Body.Bytes(), trade.ErrCodeMarketMoved)
+	if entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), market.ID); len(entries) != 1 {
+		t.Errorf("expected only the intervening trade, got %d entries", len(entries))
+	}
+}
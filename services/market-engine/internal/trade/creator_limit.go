@@ -0,0 +1,52 @@
+// Per-creator limit on open markets.
+
+package trade
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// ErrCodeOpenMarketLimit is the error code for a market creation refused
+// because its creator already has the maximum number of open markets.
+const ErrCodeOpenMarketLimit = "open_market_limit"
+
+// WithMaxOpenMarketsPerCreator refuses market creation when the request's
+// created_by user already has n open, visible markets, so no one user can
+// flood the exchange with markets. Settled and hidden markets don't count,
+// nor do requests without created_by (operator tooling). Zero, the
+// default, disables the limit.
+func WithMaxOpenMarketsPerCreator(n int) Option {
+	return func(s *Service) { s.maxOpenMarketsPerCreator = n }
+}
+
+// reserveMarketCreation checks creator is below the open market limit,
+// writing an error and returning ok=false if not. The check holds a
+// per-creator lock so concurrent requests can't both pass it; callers must
+// call the returned release once the market is stored (or not).
+func (s *Service) reserveMarketCreation(w http.ResponseWriter, r *http.Request, creator string) (release func(), ok bool) {
+	if s.maxOpenMarketsPerCreator <= 0 || creator == "" {
+		return func() {}, true
+	}
+
+	_, unlock, ok := s.lockMarket(w, r, "creator:"+creator)
+	if !ok {
+		return nil, false
+	}
+	n, err := s.store.CountMarketsByCreator(r.Context(), creator)
+	if err != nil {
+		unlock()
+		s.internalError(w, r, "failed to count creator's markets", err, "user", creator)
+		return nil, false
+	}
+	if n >= s.maxOpenMarketsPerCreator {
+		unlock()
+		slog.Info("market creation rejected", "user", creator, "open_markets", n)
+		writeCodedError(w, ErrCodeOpenMarketLimit,
+			"user "+creator+" already has "+strconv.Itoa(n)+" open markets, the maximum",
+			http.StatusConflict)
+		return nil, false
+	}
+	return unlock, true
+}
@@ -0,0 +1,101 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// createMarketBy creates the nth market (a distinct contract date) as user.
+func createMarketBy(t *testing.T, router http.Handler, user string, n int) (*model.Market, *http.Response) {
+	t.Helper()
+	w := postCreateMarket(t, router, trade.CreateMarketRequest{
+		ContractID: fmt.Sprintf("ATMX-872a1070b-PRECIP-25MM-202508%02d", n+1),
+		B:          d(100),
+		CreatedBy:  user,
+	})
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	return &m, w.Result()
+}
+
+func TestCreateMarket_OpenMarketLimitPerCreator(t *testing.T) {
+	_, _, router := newTestEnv(t, trade.WithMaxOpenMarketsPerCreator(2))
+
+	for i := 0; i < 2; i++ {
+		m, resp := createMarketBy(t, router, "alice", i)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("market %d: expected 201, got %d", i, resp.StatusCode)
+		}
+		if m.CreatedBy != "alice" {
+			t.Errorf("created_by = %q, want alice", m.CreatedBy)
+		}
+	}
+
+	w := postCreateMarket(t, router, trade.CreateMarketRequest{
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250803", B: d(100), CreatedBy: "alice",
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("third market: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != trade.ErrCodeOpenMarketLimit {
+		t.Errorf("code = %q, want %q", body["code"], trade.ErrCodeOpenMarketLimit)
+	}
+
+	// The limit is per creator, and operator requests carry none.
+	if _, resp := createMarketBy(t, router, "bob", 3); resp.StatusCode != http.StatusCreated {
+		t.Errorf("another creator: expected 201, got %d", resp.StatusCode)
+	}
+	if _, resp := createMarketBy(t, router, "", 4); resp.StatusCode != http.StatusCreated {
+		t.Errorf("no creator: expected 201, got %d", resp.StatusCode)
+	}
+}
+
+func TestCreateMarket_SettledAndHiddenMarketsFreeTheLimit(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithMaxOpenMarketsPerCreator(1))
+	ctx := context.Background()
+
+	first, resp := createMarketBy(t, router, "alice", 0)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if _, resp := createMarketBy(t, router, "alice", 1); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("at the limit: expected 409, got %d", resp.StatusCode)
+	}
+
+	if err := ms.SettleMarket(ctx, first.ID, "YES", time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+	second, resp := createMarketBy(t, router, "alice", 1)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("after settlement: expected 201, got %d", resp.StatusCode)
+	}
+
+	if err := ms.HideMarket(ctx, second.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, resp := createMarketBy(t, router, "alice", 2); resp.StatusCode != http.StatusCreated {
+		t.Errorf("after hiding: expected 201, got %d", resp.StatusCode)
+	}
+
+	if n, _ := ms.CountMarketsByCreator(ctx, "alice"); n != 1 {
+		t.Errorf("CountMarketsByCreator = %d, want 1", n)
+	}
+}
+
+func TestCreateMarket_NoLimitByDefault(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	for i := 0; i < 5; i++ {
+		if _, resp := createMarketBy(t, router, "alice", i); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("market %d: expected 201, got %d", i, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// decodeBody runs decodeJSON on body into v, returning the response
+// status it wrote (200 when it accepted the body) and error message.
+func decodeBody(body string, v any) (int, string) {
+	w := httptest.NewRecorder()
+	if decodeJSON(w, httptest.NewRequest("POST", "/", strings.NewReader(body)), v) {
+		return http.StatusOK, ""
+	}
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp["error"]
+}
+
+func TestDecodeJSON_Decimals(t *testing.T) {
+	tests := []struct {
+		name string
+		json string // the quantity as it appears in the body
+		want string // the decimal it must parse to, exactly
+	}{
+		{"integer number", `10`, "10"},
+		{"integer string", `"10"`, "10"},
+		{"fraction number", `0.1`, "0.1"},
+		{"fraction string", `"0.1"`, "0.1"},
+		{"negative number", `-5.25`, "-5.25"},
+		{"negative string", `"-5.25"`, "-5.25"},
+		// Parsed from the text, so float64 rounding never creeps in.
+		{"float64-inexact number", `0.30000000000000001`, "0.30000000000000001"},
+		{"many places string", `"123.456789012345678901"`, "123.456789012345678901"},
+		{"scientific number", `1.5e2`, "150"},
+		{"scientific string", `"1.5e2"`, "150"},
+		{"negative exponent number", `25E-3`, "0.025"},
+		{"negative exponent string", `"-25e-3"`, "-0.025"},
+		{"trailing zeros", `"2.500"`, "2.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decimal.RequireFromString(tt.want)
+
+			var trade TradeRequest
+			if code, msg := decodeBody(`{"quantity": `+tt.json+`}`, &trade); code != http.StatusOK {
+				t.Fatalf("trade: %d %s", code, msg)
+			}
+			if !trade.Quantity.Equal(want) {
+				t.Errorf("quantity = %s, want %s", trade.Quantity, want)
+			}
+
+			var market CreateMarketRequest
+			if code, msg := decodeBody(`{"b": `+tt.json+`, "b_start": `+tt.json+`}`, &market); code != http.StatusOK {
+				t.Fatalf("market: %d %s", code, msg)
+			}
+			if !market.B.Equal(want) || market.BStart == nil || !market.BStart.Equal(want) {
+				t.Errorf("b = %s, b_start = %v, want %s", market.B, market.BStart, want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantMsg  string
+	}{
+		{"malformed", `{"quantity": 1`, http.StatusBadRequest, "invalid request body"},
+		{"not a decimal", `{"quantity": "lots"}`, http.StatusBadRequest, "invalid request body"},
+		{"boolean decimal", `{"quantity": true}`, http.StatusBadRequest, "invalid request body"},
+		{"hex decimal", `{"quantity": "0x10"}`, http.StatusBadRequest, "invalid request body"},
+		{"unknown field", `{"quantity": 1, "qty": 1}`, http.StatusBadRequest, `invalid request body: unknown field "qty"`},
+		{"trailing value", `{"quantity": 1} {"quantity": 2}`, http.StatusBadRequest, "invalid request body"},
+		{"empty", ``, http.StatusBadRequest, "invalid request body"},
+		{"too large", `{"user_id": "` + strings.Repeat("x", MaxRequestBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, "request body too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req TradeRequest
+			code, msg := decodeBody(tt.body, &req)
+			if code != tt.wantCode || msg != tt.wantMsg {
+				t.Errorf("got %d %q, want %d %q", code, msg, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON_NullDecimalIsZero(t *testing.T) {
+	var req TradeRequest
+	if code, msg := decodeBody(`{"quantity": null, "max_fill_price": null}`, &req); code != http.StatusOK {
+		t.Fatalf("%d %s", code, msg)
+	}
+	if !req.Quantity.IsZero() || req.MaxFillPrice != nil {
+		t.Errorf("quantity = %s, max_fill_price = %v; want 0 and unset", req.Quantity, req.MaxFillPrice)
+	}
+	// Validation, not decoding, turns a zero quantity away.
+	if errs := req.Validate(); !hasField(errs, "quantity") {
+		t.Errorf("validate = %v, want a quantity error", errs)
+	}
+}
+
+func hasField(errs []FieldError, field string) bool {
+	for _, e := range errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Default liquidity per contract type.
+
+package trade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+)
+
+// DefaultB is the liquidity b for new markets that don't specify one and
+// whose contract type has no default of its own.
+var DefaultB = decimal.NewFromInt(100)
+
+// DefaultLiquidityByType holds the built-in per-type defaults. Wind is
+// gusty and hard to forecast, so it gets deeper liquidity to soften price
+// impact; temperature forecasts are tight, so it gets less. Types not
+// listed use DefaultB.
+var DefaultLiquidityByType = map[string]decimal.Decimal{
+	contract.TypeTemp: decimal.NewFromInt(75),
+	contract.TypeWind: decimal.NewFromInt(150),
+}
+
+// WithDefaultLiquidity replaces the per-type default b used when a market
+// is created without one. Types missing from byType use DefaultB.
+func WithDefaultLiquidity(byType map[string]decimal.Decimal) Option {
+	return func(s *Service) { s.defaultLiquidity = byType }
+}
+
+// defaultB returns the b for a new market of contractType that didn't ask
+// for one.
+func (s *Service) defaultB(contractType string) decimal.Decimal {
+	if b, ok := s.defaultLiquidity[contractType]; ok {
+		return b
+	}
+	return DefaultB
+}
+
+// ParseDefaultLiquidity parses per-type defaults written as
+// "TYPE=b,TYPE=b", e.g. "WIND=250,TEMP=60". Types are case-insensitive
+// and every b must be positive. An empty string yields an empty map.
+func ParseDefaultLiquidity(spec string) (map[string]decimal.Decimal, error) {
+	out := make(map[string]decimal.Decimal)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		typ, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("default liquidity %q: want TYPE=b", pair)
+		}
+		typ = strings.ToUpper(strings.TrimSpace(typ))
+		if !contract.ValidType(typ) {
+			return nil, fmt.Errorf("default liquidity %q: unknown contract type %s", pair, typ)
+		}
+		b, err := decimal.NewFromString(strings.TrimSpace(val))
+		if err != nil || !b.IsPositive() {
+			return nil, fmt.Errorf("default liquidity %q: b must be a positive number", pair)
+		}
+		out[typ] = b
+	}
+	return out, nil
+}
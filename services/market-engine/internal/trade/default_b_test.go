@@ -0,0 +1,98 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// createdB creates a market for contractID without a b and returns the b
+// it was given.
+func createdB(t *testing.T, router chi.Router, contractID string) decimal.Decimal {
+	t.Helper()
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: contractID})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create %s: %d %s", contractID, w.Code, w.Body.String())
+	}
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	return m.B
+}
+
+func TestCreateMarket_DefaultBByType(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	tests := []struct {
+		contractID string
+		want       decimal.Decimal
+	}{
+		{"ATMX-872a1070b-TEMP-90F-20250815", trade.DefaultLiquidityByType["TEMP"]},
+		{"ATMX-872a1070b-WIND-30MPH-20250815", trade.DefaultLiquidityByType["WIND"]},
+		{"ATMX-872a1070b-PRECIP-25MM-20250815", trade.DefaultB},
+	}
+	for _, tt := range tests {
+		if got := createdB(t, router, tt.contractID); !got.Equal(tt.want) {
+			t.Errorf("%s: b = %s, want %s", tt.contractID, got, tt.want)
+		}
+	}
+	if !trade.DefaultLiquidityByType["WIND"].GreaterThan(trade.DefaultLiquidityByType["TEMP"]) {
+		t.Error("expected WIND to default deeper than TEMP")
+	}
+
+	// An explicit b always wins.
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: "ATMX-872a1070b-WIND-40MPH-20250815", B: d(42)})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	if !m.B.Equal(d(42)) {
+		t.Errorf("explicit b = %s, want 42", m.B)
+	}
+}
+
+func TestCreateMarket_ConfiguredDefaultLiquidity(t *testing.T) {
+	_, _, router := newTestEnv(t, trade.WithDefaultLiquidity(map[string]decimal.Decimal{"WIND": d(300)}))
+
+	if got := createdB(t, router, "ATMX-872a1070b-WIND-30MPH-20250815"); !got.Equal(d(300)) {
+		t.Errorf("WIND b = %s, want 300", got)
+	}
+	// TEMP has no entry in the configured map: global default.
+	if got := createdB(t, router, "ATMX-872a1070b-TEMP-90F-20250815"); !got.Equal(trade.DefaultB) {
+		t.Errorf("TEMP b = %s, want %s", got, trade.DefaultB)
+	}
+
+	// Products without a liquidity source use the same defaults.
+	w, resp := postProduct(t, router, trade.CreateProductRequest{Type: "WIND", Threshold: "30MPH", Date: "20250816", Cells: []string{"872a1070b"}})
+	if w.Code != http.StatusCreated || !resp.Product.B.Equal(d(300)) {
+		t.Errorf("product b = %s (%d), want 300", resp.Product.B, w.Code)
+	}
+}
+
+func TestParseDefaultLiquidity(t *testing.T) {
+	got, err := trade.ParseDefaultLiquidity(" wind=250, TEMP = 60 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got["WIND"].Equal(d(250)) || !got["TEMP"].Equal(d(60)) {
+		t.Errorf("unexpected map %v", got)
+	}
+	if got, err := trade.ParseDefaultLiquidity(""); err != nil || len(got) != 0 {
+		t.Errorf("empty spec: %v, %v", got, err)
+	}
+
+	for _, bad := range []string{"WIND", "HAIL=100", "WIND=0", "WIND=lots"} {
+		if _, err := trade.ParseDefaultLiquidity(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// LMSR market depth (order-book analogue).
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+)
+
+const (
+	defaultDepthLevels = 10
+	maxDepthLevels     = 100
+)
+
+// DepthLevel is one rung of the synthetic order book: the cumulative
+// quantity traded from the current state and what it costs.
+type DepthLevel struct {
+	Quantity decimal.Decimal `json:"quantity"`  // cumulative shares from current state
+	Price    decimal.Decimal `json:"price"`     // marginal YES price after this quantity
+	AvgPrice decimal.Decimal `json:"avg_price"` // average fill price for the whole quantity
+	Total    decimal.Decimal `json:"total"`     // cumulative cost (asks) or proceeds (bids)
+}
+
+// DepthResponse is the JSON body returned from the depth endpoint.
+// Levels are for the YES outcome; the NO book is its mirror image (1 - p).
+type DepthResponse struct {
+	MarketID string          `json:"market_id"`
+	PriceYes decimal.Decimal `json:"price_yes"`
+	Step     decimal.Decimal `json:"step"`
+	Asks     []DepthLevel    `json:"asks"` // buying YES, best (lowest) first
+	Bids     []DepthLevel    `json:"bids"` // selling YES, best (highest) first
+}
+
+// GetDepth handles GET /api/v1/markets/{marketID}/depth?levels=10&step=10
+// Walks the LMSR cost curve in fixed share increments on each side of the
+// current price. A side stops early at the first level that would breach
+// the LMSR price bounds, so every returned level is actually tradable.
+func (s *Service) GetDepth(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+
+	levels := defaultDepthLevels
+	if v := r.URL.Query().Get("levels"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDepthLevels {
+			writeError(w, "levels must be an integer between 1 and "+strconv.Itoa(maxDepthLevels), http.StatusBadRequest)
+			return
+		}
+		levels = n
+	}
+
+	step := decimal.NewFromInt(10)
+	if v := r.URL.Query().Get("step"); v != "" {
+		st, err := decimal.NewFromString(v)
+		if err != nil || !st.IsPositive() {
+			writeError(w, "step must be a positive number", http.StatusBadRequest)
+			return
+		}
+		if st.GreaterThan(lmsr.MaxQuantity) {
+			writeError(w, "step must be at most "+lmsr.MaxQuantity.String(), http.StatusBadRequest)
+			return
+		}
+		step = st
+	}
+	// The deepest level trades step·levels shares, which must stay within
+	// what a trade may, like any quote.
+	if step.Mul(decimal.NewFromInt(int64(levels))).GreaterThan(lmsr.MaxQuantity) {
+		writeError(w, "step * levels must be at most "+lmsr.MaxQuantity.String(), http.StatusBadRequest)
+		return
+	}
+
+	market, err := s.store.GetMarket(r.Context(), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	mm, err := s.marketMaker(market)
+	if err != nil {
+		s.internalError(w, r, "internal error: invalid market configuration", err)
+		return
+	}
+
+	resp := DepthResponse{
+		MarketID: market.ID,
+		PriceYes: mm.Price(market.QYes, market.QNo),
+		Step:     step,
+		Asks:     depthSide(mm, market.QYes, market.QNo, step, levels),
+		Bids:     depthSide(mm, market.QYes, market.QNo, step.Neg(), levels),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// depthSide computes up to n levels of cumulative YES trades of size step
+// (negative step = sells), stopping at the first level outside price bounds.
+func depthSide(mm *lmsr.MarketMaker, qYes, qNo, step decimal.Decimal, n int) []DepthLevel {
+	out := make([]DepthLevel, 0, n)
+	for i := 1; i <= n; i++ {
+		delta := step.Mul(decimal.NewFromInt(int64(i)))
+		if mm.ValidateTrade(qYes, qNo, delta) != nil {
+			break
+		}
+		out = append(out, DepthLevel{
+			Quantity: delta.Abs(),
+			Price:    mm.Price(qYes.Add(delta), qNo),
+			AvgPrice: mm.FillPrice(qYes, qNo, delta),
+			Total:    mm.TradeCost(qYes, qNo, delta).Abs(),
+		})
+	}
+	return out
+}
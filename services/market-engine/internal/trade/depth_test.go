@@ -0,0 +1,91 @@
+package trade_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetDepth_Convexity(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w, resp := getJSON[trade.DepthResponse](t, router, "/api/v1/markets/"+market.ID+"/depth?levels=5&step=10")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Asks) != 5 || len(resp.Bids) != 5 {
+		t.Fatalf("expected 5 levels per side, got asks=%d bids=%d", len(resp.Asks), len(resp.Bids))
+	}
+
+	for i, lvl := range resp.Asks {
+		if !lvl.Quantity.Equal(d(float64(10 * (i + 1)))) {
+			t.Errorf("ask %d: expected cumulative quantity %d, got %s", i, 10*(i+1), lvl.Quantity)
+		}
+		if lvl.AvgPrice.LessThanOrEqual(resp.PriceYes) {
+			t.Errorf("ask %d: avg price %s should exceed mid %s", i, lvl.AvgPrice, resp.PriceYes)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := resp.Asks[i-1]
+		if !lvl.Price.GreaterThan(prev.Price) || !lvl.AvgPrice.GreaterThan(prev.AvgPrice) {
+			t.Errorf("ask %d should be worse (higher) than ask %d: %s/%s vs %s/%s",
+				i, i-1, lvl.Price, lvl.AvgPrice, prev.Price, prev.AvgPrice)
+		}
+		if !lvl.Total.GreaterThan(prev.Total) {
+			t.Errorf("ask %d cumulative cost should grow", i)
+		}
+	}
+
+	for i, lvl := range resp.Bids {
+		if lvl.AvgPrice.GreaterThanOrEqual(resp.PriceYes) {
+			t.Errorf("bid %d: avg price %s should be below mid %s", i, lvl.AvgPrice, resp.PriceYes)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := resp.Bids[i-1]
+		if !lvl.Price.LessThan(prev.Price) || !lvl.AvgPrice.LessThan(prev.AvgPrice) {
+			t.Errorf("bid %d should be worse (lower) than bid %d: %s/%s vs %s/%s",
+				i, i-1, lvl.Price, lvl.AvgPrice, prev.Price, prev.AvgPrice)
+		}
+	}
+}
+
+func TestGetDepth_StopsAtPriceBound(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// With b=100 the price bound is reached after ~690 shares, so 100-share
+	// steps yield 6 levels on each side rather than the 20 requested.
+	_, resp := getJSON[trade.DepthResponse](t, router, "/api/v1/markets/"+market.ID+"/depth?levels=20&step=100")
+	if len(resp.Asks) != 6 || len(resp.Bids) != 6 {
+		t.Errorf("expected 6 tradable levels per side, got asks=%d bids=%d", len(resp.Asks), len(resp.Bids))
+	}
+}
+
+func TestGetDepth_InvalidParams(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for _, q := range []string{
+		"?levels=0", "?levels=abc", "?levels=1000", "?step=-5", "?step=x",
+		// Above lmsr.MaxQuantity (1e12): the step itself, or the deepest level.
+		"?step=2e12", "?levels=100&step=20000000000",
+	} {
+		if w, _ := getJSON[trade.DepthResponse](t, router, "/api/v1/markets/"+market.ID+"/depth"+q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+
+	// Exactly lmsr.MaxQuantity at the deepest level is allowed.
+	if w, _ := getJSON[trade.DepthResponse](t, router, "/api/v1/markets/"+market.ID+"/depth?levels=100&step=10000000000"); w.Code != http.StatusOK {
+		t.Errorf("levels·step = MaxQuantity: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w, _ := getJSON[trade.DepthResponse](t, router, "/api/v1/markets/missing/depth"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown market, got %d", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+// Internal error reporting.
+
+package trade
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ErrorVerbosity controls how much of an internal (5xx) error reaches the
+// client. Client errors (4xx) are always reported in full.
+type ErrorVerbosity int
+
+const (
+	// ErrorsProduction returns a generic message and the request ID, and
+	// only logs the underlying error. It is the default.
+	ErrorsProduction ErrorVerbosity = iota
+	// ErrorsDevelopment also returns the underlying error as "detail".
+	ErrorsDevelopment
+)
+
+// WithErrorVerbosity sets how much internal error detail is returned.
+func WithErrorVerbosity(v ErrorVerbosity) Option {
+	return func(s *Service) { s.errorVerbosity = v }
+}
+
+// internalError logs err, with any extra attrs, against the request and
+// writes a 500 with message. The body carries the request ID (when the
+// RequestID middleware is installed) so a report can be matched to the log
+// line; err itself is only included in development mode, since store
+// errors can carry SQL, hostnames and other detail clients shouldn't see.
+func (s *Service) internalError(w http.ResponseWriter, r *http.Request, message string, err error, attrs ...any) {
+	reqID := middleware.GetReqID(r.Context())
+	slog.Error(message, append([]any{"path", r.URL.Path, "request_id", reqID, "err", err}, attrs...)...)
+
+	body := map[string]string{"error": message}
+	if reqID != "" {
+		body["request_id"] = reqID
+	}
+	if s.errorVerbosity == ErrorsDevelopment && err != nil {
+		body["detail"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(body)
+}
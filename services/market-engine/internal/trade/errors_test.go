@@ -0,0 +1,131 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// errBackend looks like the kind of error a real backend returns.
+var errBackend = errors.New(`dial tcp 10.0.3.7:5432: connect: connection refused (SELECT id FROM markets)`)
+
+// failingStore fails to list or create markets.
+type failingStore struct {
+	*store.MemoryStore
+}
+
+func (failingStore) ListMarkets(context.Context) ([]model.Market, error) {
+	return nil, errBackend
+}
+
+func (failingStore) CreateMarket(context.Context, *model.Market) error {
+	return errBackend
+}
+
+func failingEnv(opts ...trade.Option) chi.Router {
+	limiter := correlation.NewPositionLimiter(d(1000), d(5000), 5)
+	svc := trade.NewService(failingStore{store.NewMemoryStore()}, limiter, nil, opts...)
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Get("/api/v1/markets", svc.ListMarkets)
+	r.Post("/api/v1/markets", svc.CreateMarket)
+	return r
+}
+
+func errorBody(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestInternalError_ProductionHidesDetail(t *testing.T) {
+	logs := captureLogs(t)
+	router := failingEnv()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets", nil))
+	body := errorBody(t, w)
+
+	if body["error"] != "failed to list markets" {
+		t.Errorf("error = %q", body["error"])
+	}
+	if body["request_id"] == "" {
+		t.Error("expected a request_id")
+	}
+	if _, ok := body["detail"]; ok || strings.Contains(w.Body.String(), "10.0.3.7") {
+		t.Errorf("backend error leaked: %s", w.Body.String())
+	}
+
+	// The detail is logged under the same request ID.
+	recs := logRecords(t, logs, "failed to list markets")
+	if len(recs) != 1 || recs[0]["request_id"] != body["request_id"] || recs[0]["err"] != errBackend.Error() {
+		t.Errorf("unexpected log records %v", recs)
+	}
+}
+
+func TestInternalError_DevelopmentIncludesDetail(t *testing.T) {
+	captureLogs(t)
+	router := failingEnv(trade.WithErrorVerbosity(trade.ErrorsDevelopment))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets", nil))
+	body := errorBody(t, w)
+
+	if body["error"] != "failed to list markets" || body["request_id"] == "" {
+		t.Errorf("unexpected body %v", body)
+	}
+	if body["detail"] != errBackend.Error() {
+		t.Errorf("detail = %q, want %q", body["detail"], errBackend.Error())
+	}
+}
+
+func TestCreateMarket_StoreFailureIsNotConflict(t *testing.T) {
+	captureLogs(t)
+	router := failingEnv()
+
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	if resp := errorBody(t, w); resp["error"] != "failed to create market" {
+		t.Errorf("error = %q", resp["error"])
+	}
+}
+
+func TestCreateMarket_DuplicateStaysDetailed(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: contractID})
+
+	var w *httptest.ResponseRecorder
+	for range 2 {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	}
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if want := "market for contract " + contractID + " already exists"; resp["error"] != want {
+		t.Errorf("error = %q, want %q", resp["error"], want)
+	}
+}
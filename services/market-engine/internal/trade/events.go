@@ -0,0 +1,57 @@
+// Publishing domain events downstream.
+
+package trade
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/events"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// emitTimeout bounds a single publish.
+const emitTimeout = 2 * time.Second
+
+// emit publishes an event once the write it describes has succeeded. The
+// write stands either way, so a failed publish is logged rather than
+// failing the request, and a client that has gone away doesn't stop it.
+func (s *Service) emit(ctx context.Context, typ string, data any) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), emitTimeout)
+	defer cancel()
+
+	ev := events.Event{ID: s.ids.NewID(), Type: typ, Time: s.now().UTC(), Data: data}
+	if err := s.emitter.Emit(ctx, ev); err != nil {
+		slog.Error("event emit failed", "type", typ, "id", ev.ID, "err", err)
+	}
+}
+
+func (s *Service) emitMarketCreated(ctx context.Context, m *model.Market) {
+	s.emit(ctx, events.TypeMarketCreated, events.MarketCreated{
+		MarketID:   m.ID,
+		ContractID: m.ContractID,
+		H3CellID:   m.H3CellID,
+		B:          m.B,
+		ProductID:  m.ProductID,
+	})
+}
+
+func (s *Service) emitTradeExecuted(ctx context.Context, e *model.LedgerEntry, m *model.Market, priceYes, priceNo decimal.Decimal) {
+	s.emit(ctx, events.TypeTradeExecuted, events.TradeExecuted{
+		TradeID:     e.ID,
+		UserID:      e.UserID,
+		MarketID:    e.MarketID,
+		ContractID:  e.ContractID,
+		H3CellID:    m.H3CellID,
+		Side:        e.Side,
+		Quantity:    e.Quantity,
+		FillPrice:   e.Price,
+		Cost:        e.Cost,
+		RealizedPnL: e.RealizedPnL,
+		PriceYes:    priceYes,
+		PriceNo:     priceNo,
+	})
+}
@@ -0,0 +1,71 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/events"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestEvents_PublishedAfterWrites(t *testing.T) {
+	rec := &events.Recorder{}
+	_, _, router := newTestEnv(t, trade.WithEmitter(rec))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: contractID, B: d(100)})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create failed: %d %s", w.Code, w.Body.String())
+	}
+	var market model.Market
+	json.Unmarshal(w.Body.Bytes(), &market)
+
+	tw := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10)})
+	if tw.Code != http.StatusOK {
+		t.Fatalf("trade failed: %d %s", tw.Code, tw.Body.String())
+	}
+	var tr trade.TradeResponse
+	json.Unmarshal(tw.Body.Bytes(), &tr)
+
+	// A rejected trade writes nothing and publishes nothing.
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(5000)})
+
+	if sw, _ := postSettle(t, router, market.ID, "YES"); sw.Code != http.StatusOK {
+		t.Fatalf("settle failed: %d %s", sw.Code, sw.Body.String())
+	}
+
+	got := rec.Events()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(got), got)
+	}
+	for i, want := range []string{events.TypeMarketCreated, events.TypeTradeExecuted, events.TypeMarketSettled} {
+		if got[i].Type != want || got[i].ID == "" || got[i].Time.IsZero() {
+			t.Errorf("event %d = %s (id %q), want %s", i, got[i].Type, got[i].ID, want)
+		}
+	}
+
+	created := got[0].Data.(events.MarketCreated)
+	if created.MarketID != market.ID || created.ContractID != contractID || !created.B.Equal(d(100)) {
+		t.Errorf("unexpected market.created payload: %+v", created)
+	}
+
+	executed := got[1].Data.(events.TradeExecuted)
+	if executed.TradeID != tr.TradeID || executed.UserID != "u1" || executed.Side != "YES" ||
+		!executed.Quantity.Equal(d(10)) || !executed.Cost.Equal(tr.Cost) || !executed.FillPrice.Equal(tr.FillPrice) {
+		t.Errorf("unexpected trade.executed payload: %+v", executed)
+	}
+	if !executed.PriceYes.GreaterThan(d(0.5)) || executed.H3CellID != "872a1070b" {
+		t.Errorf("expected post-trade prices and cell, got %+v", executed)
+	}
+
+	settled := got[2].Data.(events.MarketSettled)
+	if settled.MarketID != market.ID || settled.Outcome != "YES" || settled.Users != 1 || !settled.TotalPayout.Equal(d(10)) {
+		t.Errorf("unexpected market.settled payload: %+v", settled)
+	}
+}
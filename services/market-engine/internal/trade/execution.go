@@ -0,0 +1,97 @@
+// Checks and writes shared by trades and position closes.
+
+package trade
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// execution is a write to one market's quantities in progress, by a trade
+// or a close: the market as re-read under its lock, the market maker that
+// prices it, and any change the auto-liquidity policy makes to b, which is
+// stored with the write.
+type execution struct {
+	market    *model.Market
+	mm        *lmsr.MarketMaker
+	liqChange *model.LiquidityChange
+}
+
+// beginExecution checks market, re-read under its lock, can be traded: it
+// is open, visible, within its trading hours and not halted by the circuit
+// breaker. It prices the market at the b the auto-liquidity policy calls
+// for. On failure it has written the response and logged the rejection
+// against req.
+func (s *Service) beginExecution(w http.ResponseWriter, r *http.Request, req TradeRequest, market *model.Market) (*execution, bool) {
+	if market.Status != "open" {
+		logTradeRejection(req, RejectMarketClosed, "status", market.Status)
+		writeError(w, "market is not open for trading", http.StatusConflict)
+		return nil, false
+	}
+	if market.HiddenAt != nil {
+		logTradeRejection(req, RejectMarketHidden)
+		writeError(w, "market has been removed", http.StatusConflict)
+		return nil, false
+	}
+	if !s.checkTradingHours(w, market) {
+		logTradeRejection(req, ErrCodeOutsideTradingHours)
+		return nil, false
+	}
+
+	// Auto-liquidity markets are priced at the b their recent volume
+	// calls for; the change is stored only if the write goes ahead.
+	liqChange, err := s.liquidityAdjustment(r.Context(), market)
+	if err != nil {
+		s.internalError(w, r, "failed to adjust liquidity", err)
+		return nil, false
+	}
+	if liqChange != nil {
+		market.B = liqChange.NewB
+	}
+
+	mm, err := s.marketMaker(market)
+	if err != nil {
+		s.internalError(w, r, "internal error: invalid market configuration", err)
+		return nil, false
+	}
+
+	if !s.checkCircuitBreaker(w, r, market) {
+		logTradeRejection(req, ErrCodeCircuitBreaker)
+		return nil, false
+	}
+	return &execution{market: market, mm: mm, liqChange: liqChange}, true
+}
+
+// applyExecution writes entries and moves the market to the given state, together
+// with any liquidity change and the entries' costs added to the market's
+// collected cash, as one ApplyTrade. ctx is the market lock's
+// held context, so a lock lost before the write commits aborts it with
+// ErrLockLost.
+func (s *Service) applyExecution(ctx context.Context, ex *execution, entries []*model.LedgerEntry, qYes, qNo, priceYes, priceNo decimal.Decimal) error {
+	collected := ex.market.Collected
+	for _, e := range entries {
+		collected = collected.Add(e.Cost)
+	}
+	if err := s.store.ApplyTrade(ctx, entries, ex.market.ID, qYes, qNo, priceYes, priceNo, collected, ex.liqChange); err != nil {
+		if ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrLockLost) {
+			return ErrLockLost
+		}
+		return err
+	}
+	if c := ex.liqChange; c != nil {
+		slog.Info("market liquidity auto-adjusted",
+			"market", c.MarketID,
+			"old_b", c.OldB.String(),
+			"new_b", c.NewB.String(),
+		)
+	}
+	s.recordVolume(ex.market.ID, entries, qYes, qNo)
+	return nil
+}
@@ -0,0 +1,95 @@
+// Market-wide exposure by cell and correlated group.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// WithSystemicThreshold flags correlated groups whose exposure summed
+// across all users exceeds threshold in the exposure report. Zero, the
+// default, flags nothing.
+func WithSystemicThreshold(threshold decimal.Decimal) Option {
+	return func(s *Service) { s.systemicThreshold = threshold }
+}
+
+// CellExposure is the absolute net exposure of every user in one cell,
+// summed.
+type CellExposure struct {
+	H3CellID string          `json:"h3_cell_id"`
+	Group    string          `json:"group"`
+	Exposure decimal.Decimal `json:"exposure"`
+}
+
+// GroupExposure sums CellExposure over a correlated group: the cells the
+// position limiter treats as correlated (a shared H3 prefix).
+type GroupExposure struct {
+	Group    string          `json:"group"`
+	Exposure decimal.Decimal `json:"exposure"`
+	Cells    int             `json:"cells"`
+	Flagged  bool            `json:"flagged"` // exposure > threshold
+}
+
+// ExposureReport is the JSON body returned from GET /api/v1/admin/exposure.
+// Groups and cells are ordered by exposure, largest first.
+type ExposureReport struct {
+	Threshold decimal.Decimal `json:"threshold"`
+	Groups    []GroupExposure `json:"groups"`
+	Cells     []CellExposure  `json:"cells"`
+}
+
+// GetSystemExposure handles GET /api/v1/admin/exposure
+// Reports where exposure is concentrated across all users, per cell and
+// per correlated group, flagging groups over the systemic threshold. Per-
+// user limits can each hold while many users pile into the same area.
+func (s *Service) GetSystemExposure(w http.ResponseWriter, r *http.Request) {
+	byCell, err := s.store.GetCellExposures(r.Context())
+	if err != nil {
+		s.internalError(w, r, "failed to load exposures", err)
+		return
+	}
+
+	report := ExposureReport{
+		Threshold: s.systemicThreshold,
+		Groups:    []GroupExposure{},
+		Cells:     make([]CellExposure, 0, len(byCell)),
+	}
+	groups := make(map[string]*GroupExposure)
+	for cell, exp := range byCell {
+		key := s.limiter.Group(cell)
+		report.Cells = append(report.Cells, CellExposure{H3CellID: cell, Group: key, Exposure: exp})
+		g, ok := groups[key]
+		if !ok {
+			g = &GroupExposure{Group: key}
+			groups[key] = g
+		}
+		g.Exposure = g.Exposure.Add(exp)
+		g.Cells++
+	}
+	for _, g := range groups {
+		g.Flagged = s.systemicThreshold.IsPositive() && g.Exposure.GreaterThan(s.systemicThreshold)
+		report.Groups = append(report.Groups, *g)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if c := a.Exposure.Cmp(b.Exposure); c != 0 {
+			return c > 0
+		}
+		return a.Group < b.Group
+	})
+	sort.Slice(report.Cells, func(i, j int) bool {
+		a, b := report.Cells[i], report.Cells[j]
+		if c := a.Exposure.Cmp(b.Exposure); c != 0 {
+			return c > 0
+		}
+		return a.H3CellID < b.H3CellID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,79 @@
+package trade_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetSystemExposure_AggregatesAcrossUsers(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithSystemicThreshold(d(150)))
+
+	// The test limiter correlates cells sharing a 5-character prefix:
+	// the first two cells are group 872a1, the third group 8729a.
+	cells := []string{"872a1070b", "872a10711", "8729a0001"}
+	for _, cell := range cells {
+		seedMarket(t, ms, "ATMX-"+cell+"-PRECIP-25MM-20250815", cell, 100)
+	}
+	trades := []struct {
+		user, cell, side string
+		qty              float64
+	}{
+		{"alice", cells[0], "YES", 100},
+		{"alice", cells[0], "NO", 20}, // nets alice to 80 in the cell
+		{"bob", cells[0], "NO", 40},   // opposite direction still adds
+		{"carol", cells[1], "YES", 50},
+		{"dave", cells[2], "YES", 30},
+	}
+	for _, tr := range trades {
+		req := trade.TradeRequest{UserID: tr.user, ContractID: "ATMX-" + tr.cell + "-PRECIP-25MM-20250815", Side: tr.side, Quantity: d(tr.qty)}
+		if w := doTrade(t, router, req); w.Code != http.StatusOK {
+			t.Fatalf("%+v: expected 200, got %d: %s", tr, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/admin/exposure", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report trade.ExposureReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCells := []trade.CellExposure{
+		{H3CellID: cells[0], Group: "872a1", Exposure: d(120)},
+		{H3CellID: cells[1], Group: "872a1", Exposure: d(50)},
+		{H3CellID: cells[2], Group: "8729a", Exposure: d(30)},
+	}
+	if len(report.Cells) != len(wantCells) {
+		t.Fatalf("expected %d cells, got %+v", len(wantCells), report.Cells)
+	}
+	for i, want := range wantCells {
+		got := report.Cells[i]
+		if got.H3CellID != want.H3CellID || got.Group != want.Group || !got.Exposure.Equal(want.Exposure) {
+			t.Errorf("cell %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	wantGroups := []trade.GroupExposure{
+		{Group: "872a1", Exposure: d(170), Cells: 2, Flagged: true},
+		{Group: "8729a", Exposure: d(30), Cells: 1, Flagged: false},
+	}
+	if len(report.Groups) != len(wantGroups) {
+		t.Fatalf("expected %d groups, got %+v", len(wantGroups), report.Groups)
+	}
+	for i, want := range wantGroups {
+		got := report.Groups[i]
+		if got.Group != want.Group || !got.Exposure.Equal(want.Exposure) || got.Cells != want.Cells || got.Flagged != want.Flagged {
+			t.Errorf("group %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if !report.Threshold.Equal(d(150)) {
+		t.Errorf("threshold = %s, want 150", report.Threshold)
+	}
+}
@@ -0,0 +1,53 @@
+// Soft-deleting (hiding) markets.
+
+package trade
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/metrics"
+)
+
+// HideMarket handles DELETE /api/v1/markets/{marketID} (admin).
+// Soft-deletes a test or mistaken market: it drops out of listings, cells
+// and search (unless include_hidden=true) and stops accepting trades, but
+// stays readable by ID with its ledger intact. Repeating the call is a
+// no-op.
+func (s *Service) HideMarket(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	// Hide under the market lock so an in-flight trade either lands first
+	// or sees the hidden market.
+	_, unlock, ok := s.lockMarket(w, r, marketID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if market.HiddenAt != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := s.store.HideMarket(ctx, marketID, s.now().UTC()); err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if market.Status == "open" {
+		metrics.ActiveMarkets.Dec()
+	}
+
+	slog.Info("market hidden", "market", marketID, "contract", market.ContractID)
+	s.audit(r, AuditActionHide, marketID, map[string]string{"contract": market.ContractID})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package trade_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func hideMarket(t *testing.T, router http.Handler, marketID string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("DELETE", "/api/v1/markets/"+marketID, nil))
+	return w
+}
+
+func marketIDs(markets []model.Market) map[string]bool {
+	ids := make(map[string]bool, len(markets))
+	for _, m := range markets {
+		ids[m.ID] = true
+	}
+	return ids
+}
+
+func TestHideMarket_RemovedFromListingsButReadable(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	hidden := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	kept := seedMarket(t, ms, "ATMX-872a1070b-TEMP-90F-20250815", "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: hidden.ContractID, Side: "YES", Quantity: d(5)})
+
+	if w := hideMarket(t, router, hidden.ID); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+
+	_, listed := getJSON[[]model.Market](t, router, "/api/v1/markets")
+	if ids := marketIDs(listed); ids[hidden.ID] || !ids[kept.ID] {
+		t.Errorf("listing should contain only the visible market, got %v", ids)
+	}
+
+	_, found := getJSON[[]model.Market](t, router, "/api/v1/markets/search?h3_prefix=872a")
+	if marketIDs(found)[hidden.ID] {
+		t.Error("hidden market returned by search")
+	}
+	_, found = getJSON[[]model.Market](t, router, "/api/v1/markets/search?h3_prefix=872a&include_hidden=true")
+	if !marketIDs(found)[hidden.ID] {
+		t.Error("include_hidden=true should return the hidden market")
+	}
+
+	// Still retrievable by ID, with its history.
+	w, got := getJSON[model.Market](t, router, "/api/v1/markets/"+hidden.ID)
+	if w.Code != http.StatusOK || got.HiddenAt == nil {
+		t.Errorf("expected hidden market by ID (200 with hidden_at), got %d %+v", w.Code, got)
+	}
+	_, history := getJSON[[]model.LedgerEntry](t, router, "/api/v1/markets/"+hidden.ID+"/history")
+	if len(history) != 1 {
+		t.Errorf("expected ledger preserved (1 entry), got %d", len(history))
+	}
+
+	// Hiding again is a no-op.
+	if w := hideMarket(t, router, hidden.ID); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204 on repeat, got %d", w.Code)
+	}
+}
+
+func TestHideMarket_TradingRejected(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(5)})
+	hideMarket(t, router, market.ID)
+
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(1)}); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 trading a hidden market, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/portfolio/u1/markets/"+market.ID+"/close", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 closing in a hidden market, got %d", w.Code)
+	}
+}
+
+func TestHideMarket_NotFound(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	if w := hideMarket(t, router, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestHideMarket_RequiresAdmin(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/markets/"+market.ID, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without admin token: expected 401, got %d", w.Code)
+	}
+	_, m := getJSON[model.Market](t, router, "/api/v1/markets/"+market.ID)
+	if m.HiddenAt != nil {
+		t.Error("unauthenticated request hid the market")
+	}
+}
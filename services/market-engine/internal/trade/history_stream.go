@@ -0,0 +1,67 @@
+// Market history as newline-delimited JSON.
+
+package trade
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// historyStreamFlushEvery is how many lines are written between flushes.
+const historyStreamFlushEvery = 100
+
+// historyStreamWriteTimeout is how long the stream may take to write the
+// lines up to its next flush. The deadline is pushed back at every flush,
+// so a long history isn't cut off by the server's WriteTimeout while a
+// stalled client still is.
+const historyStreamWriteTimeout = 10 * time.Second
+
+// StreamMarketHistory handles GET /api/v1/markets/{marketID}/history/stream
+// Writes the market's ledger entries as application/x-ndjson, one JSON
+// object per line, oldest first, reading them from the store as it goes
+// rather than loading the whole history. Once the first line is sent the
+// status is fixed, so a later failure ends the stream early and is logged.
+// The route must be mounted outside any request timeout middleware; the
+// handler extends its own write deadline as it goes.
+func (s *Service) StreamMarketHistory(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	if _, err := s.store.GetMarket(ctx, marketID); err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	rc := http.NewResponseController(w)
+	// Best effort, like Flush: writers without deadlines keep the server's.
+	rc.SetWriteDeadline(time.Now().Add(historyStreamWriteTimeout))
+	enc := json.NewEncoder(w) // Encode terminates each entry with '\n'
+	n := 0
+	err := s.store.StreamLedgerEntriesByMarket(ctx, marketID, func(e model.LedgerEntry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		n++
+		if n%historyStreamFlushEvery == 0 {
+			rc.Flush() // best effort; unsupported writers just buffer
+			rc.SetWriteDeadline(time.Now().Add(historyStreamWriteTimeout))
+		}
+		return nil
+	})
+	if err != nil {
+		if n == 0 {
+			s.internalError(w, r, "failed to get market history", err)
+			return
+		}
+		slog.Warn("history stream ended early", "market", marketID, "lines", n, "err", err)
+		return
+	}
+	rc.Flush()
+}
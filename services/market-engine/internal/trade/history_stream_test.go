@@ -0,0 +1,134 @@
+package trade_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// slowStreamStore reads each ledger entry after a delay, like a large
+// history coming off a busy database.
+type slowStreamStore struct {
+	*store.MemoryStore
+	delay time.Duration
+}
+
+func (s slowStreamStore) StreamLedgerEntriesByMarket(ctx context.Context, marketID string, fn func(model.LedgerEntry) error) error {
+	return s.MemoryStore.StreamLedgerEntriesByMarket(ctx, marketID, func(e model.LedgerEntry) error {
+		time.Sleep(s.delay)
+		return fn(e)
+	})
+}
+
+func TestStreamMarketHistory_NDJSONInOrder(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// Enough trades to cross a flush boundary.
+	const trades = 120
+	for i := 0; i < trades; i++ {
+		side := "YES"
+		if i%3 == 0 {
+			side = "NO"
+		}
+		if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: side, Quantity: d(1)}); w.Code != http.StatusOK {
+			t.Fatalf("trade %d failed: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets/"+market.ID+"/history/stream", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("content type = %q", ct)
+	}
+
+	hw := httptest.NewRecorder()
+	router.ServeHTTP(hw, httptest.NewRequest("GET", "/api/v1/markets/"+market.ID+"/history", nil))
+	var want []model.LedgerEntry
+	json.Unmarshal(hw.Body.Bytes(), &want)
+
+	sc := bufio.NewScanner(w.Body)
+	i := 0
+	for sc.Scan() {
+		var e model.LedgerEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %d does not parse on its own: %v: %s", i+1, err, sc.Text())
+		}
+		if i >= len(want) || e.ID != want[i].ID || !e.Cost.Equal(want[i].Cost) {
+			t.Fatalf("line %d = %+v, want entry %d of the history", i+1, e, i)
+		}
+		i++
+	}
+	if i != trades || len(want) != trades {
+		t.Errorf("streamed %d lines, history has %d, want %d", i, len(want), trades)
+	}
+}
+
+func TestStreamMarketHistory_Empty(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets/"+market.ID+"/history/stream", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("expected an empty 200, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets/nope/history/stream", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown market, got %d", w.Code)
+	}
+}
+
+func TestStreamMarketHistory_OutlastsServerWriteTimeout(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	const trades = 250
+	for i := 0; i < trades; i++ {
+		if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(1)}); w.Code != http.StatusOK {
+			t.Fatalf("trade %d failed: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	// The stream takes well over the server's WriteTimeout in total, but
+	// never long between flushes.
+	limiter := correlation.NewPositionLimiter(d(1000), d(5000), 5)
+	svc := trade.NewService(slowStreamStore{MemoryStore: ms, delay: time.Millisecond}, limiter, nil)
+	r := chi.NewRouter()
+	r.Get("/api/v1/markets/{marketID}/history/stream", svc.StreamMarketHistory)
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/markets/" + market.ID + "/history/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	n := 0
+	for sc.Scan() {
+		n++
+	}
+	if n != trades {
+		t.Errorf("streamed %d lines before the connection closed, want %d (err %v)", n, trades, sc.Err())
+	}
+}
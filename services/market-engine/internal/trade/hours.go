@@ -0,0 +1,165 @@
+// Per-market daily trading windows.
+
+package trade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ErrCodeOutsideTradingHours is returned when a trade arrives outside the
+// market's trading window.
+const ErrCodeOutsideTradingHours = "outside_trading_hours"
+
+// TradingHoursRequest is the JSON body for PATCH .../trading-hours.
+// Both fields set configures the window; both null makes the market
+// always-open.
+type TradingHoursRequest struct {
+	TradingOpen  *string `json:"trading_open"`
+	TradingClose *string `json:"trading_close"`
+}
+
+// TradingHoursResponse describes a market's trading window.
+type TradingHoursResponse struct {
+	MarketID     string  `json:"market_id"`
+	TradingOpen  *string `json:"trading_open"`
+	TradingClose *string `json:"trading_close"`
+	OpenNow      bool    `json:"open_now"`
+}
+
+// Validate reports every problem with a trading-hours request.
+func (req TradingHoursRequest) Validate() []FieldError {
+	var errs []FieldError
+	if (req.TradingOpen == nil) != (req.TradingClose == nil) {
+		errs = append(errs, FieldError{"trading_open", "trading_open and trading_close must be set or cleared together"})
+		return errs
+	}
+	if req.TradingOpen == nil {
+		return nil
+	}
+	open, okOpen := parseClock(*req.TradingOpen)
+	if !okOpen {
+		errs = append(errs, FieldError{"trading_open", "trading_open must be HH:MM (UTC)"})
+	}
+	close, okClose := parseClock(*req.TradingClose)
+	if !okClose {
+		errs = append(errs, FieldError{"trading_close", "trading_close must be HH:MM (UTC)"})
+	}
+	if okOpen && okClose && open == close {
+		errs = append(errs, FieldError{"trading_close", "trading_close must differ from trading_open"})
+	}
+	return errs
+}
+
+// parseClock parses a zero-padded "HH:MM" time of day into seconds since
+// midnight.
+func parseClock(v string) (int, bool) {
+	if len(v) != len("15:04") {
+		return 0, false
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*3600 + t.Minute()*60, true
+}
+
+// withinTradingHours reports whether the market accepts trades at t.
+// Markets without a window (or with an unparseable one) are always open.
+func withinTradingHours(m *model.Market, t time.Time) bool {
+	if m.TradingOpen == nil || m.TradingClose == nil {
+		return true
+	}
+	open, ok1 := parseClock(*m.TradingOpen)
+	close, ok2 := parseClock(*m.TradingClose)
+	if !ok1 || !ok2 {
+		return true
+	}
+
+	t = t.UTC()
+	secs := t.Hour()*3600 + t.Minute()*60 + t.Second()
+	if open < close {
+		return secs >= open && secs < close
+	}
+	// Window spans midnight, e.g. 22:00–06:00.
+	return secs >= open || secs < close
+}
+
+// checkTradingHours writes a coded 409 and returns false if the market's
+// trading window is closed at the service clock's current time.
+func (s *Service) checkTradingHours(w http.ResponseWriter, m *model.Market) bool {
+	if withinTradingHours(m, s.now()) {
+		return true
+	}
+	writeCodedError(w, ErrCodeOutsideTradingHours,
+		fmt.Sprintf("market trades %s–%s UTC", *m.TradingOpen, *m.TradingClose),
+		http.StatusConflict)
+	return false
+}
+
+// GetTradingHours handles GET /api/v1/markets/{marketID}/trading-hours
+func (s *Service) GetTradingHours(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+
+	market, err := s.store.GetMarket(r.Context(), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	s.writeTradingHours(w, market)
+}
+
+// UpdateTradingHours handles PATCH /api/v1/markets/{marketID}/trading-hours (admin)
+func (s *Service) UpdateTradingHours(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	var req TradingHoursRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	old, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if err := s.store.UpdateTradingHours(ctx, marketID, req.TradingOpen, req.TradingClose); err != nil {
+		s.internalError(w, r, "failed to update trading hours", err)
+		return
+	}
+	s.audit(r, AuditActionTradingHours, marketID, map[string]string{
+		"old_trading_open":  auditSetting(old.TradingOpen),
+		"old_trading_close": auditSetting(old.TradingClose),
+		"trading_open":      auditSetting(req.TradingOpen),
+		"trading_close":     auditSetting(req.TradingClose),
+	})
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market", err)
+		return
+	}
+	s.writeTradingHours(w, market)
+}
+
+func (s *Service) writeTradingHours(w http.ResponseWriter, m *model.Market) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TradingHoursResponse{
+		MarketID:     m.ID,
+		TradingOpen:  m.TradingOpen,
+		TradingClose: m.TradingClose,
+		OpenNow:      withinTradingHours(m, s.now()),
+	})
+}
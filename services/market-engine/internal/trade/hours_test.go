@@ -0,0 +1,140 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func patchTradingHours(t *testing.T, router http.Handler, marketID string, body string) (*httptest.ResponseRecorder, trade.TradingHoursResponse) {
+	t.Helper()
+	return serveJSON[trade.TradingHoursResponse](t, router, adminRequest("PATCH", "/api/v1/markets/"+marketID+"/trading-hours", bytes.NewBufferString(body)))
+}
+
+// tradingHoursEnv returns a router whose clock is controlled via *now.
+func tradingHoursEnv(t *testing.T, now *time.Time) (chi.Router, string, string) {
+	t.Helper()
+	_, ms, router := newTestEnv(t, trade.WithClock(func() time.Time { return *now }))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	return router, market.ID, contractID
+}
+
+func TestTradingHours_Boundaries(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	router, marketID, contractID := tradingHoursEnv(t, &now)
+
+	if w, _ := patchTradingHours(t, router, marketID, `{"trading_open":"09:00","trading_close":"17:00"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	cases := []struct {
+		at   time.Time
+		open bool
+	}{
+		{time.Date(2025, 8, 1, 8, 59, 59, 0, time.UTC), false}, // just before open
+		{time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC), true},    // open is inclusive
+		{time.Date(2025, 8, 1, 16, 59, 59, 0, time.UTC), true}, // just before close
+		{time.Date(2025, 8, 1, 17, 0, 0, 0, time.UTC), false},  // close is exclusive
+	}
+	for _, tc := range cases {
+		now = tc.at
+		w := doTrade(t, router, trade.TradeRequest{
+			UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(1),
+		})
+		if tc.open {
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: expected 200, got %d: %s", tc.at.Format("15:04:05"), w.Code, w.Body.String())
+			}
+			continue
+		}
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: expected 409, got %d", tc.at.Format("15:04:05"), w.Code)
+			continue
+		}
+		var body map[string]string
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body["code"] != trade.ErrCodeOutsideTradingHours {
+			t.Errorf("%s: expected code %q, got %v", tc.at.Format("15:04:05"), trade.ErrCodeOutsideTradingHours, body)
+		}
+	}
+}
+
+func TestTradingHours_WindowSpansMidnight(t *testing.T) {
+	now := time.Date(2025, 8, 1, 23, 30, 0, 0, time.UTC)
+	router, marketID, contractID := tradingHoursEnv(t, &now)
+	patchTradingHours(t, router, marketID, `{"trading_open":"22:00","trading_close":"06:00"}`)
+
+	for _, tc := range []struct {
+		at   time.Time
+		want int
+	}{
+		{time.Date(2025, 8, 1, 23, 30, 0, 0, time.UTC), http.StatusOK},
+		{time.Date(2025, 8, 2, 5, 59, 59, 0, time.UTC), http.StatusOK},
+		{time.Date(2025, 8, 2, 6, 0, 0, 0, time.UTC), http.StatusConflict},
+	} {
+		now = tc.at
+		w := doTrade(t, router, trade.TradeRequest{
+			UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(1),
+		})
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.at.Format(time.RFC3339), tc.want, w.Code)
+		}
+	}
+}
+
+func TestTradingHours_NoWindowAlwaysOpen(t *testing.T) {
+	now := time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC)
+	router, marketID, contractID := tradingHoursEnv(t, &now)
+
+	req := httptest.NewRequest("GET", "/api/v1/markets/"+marketID+"/trading-hours", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp trade.TradingHoursResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.TradingOpen != nil || resp.TradingClose != nil || !resp.OpenNow {
+		t.Errorf("expected no window and open_now, got %s", w.Body.String())
+	}
+
+	// Set then clear the window.
+	patchTradingHours(t, router, marketID, `{"trading_open":"09:00","trading_close":"17:00"}`)
+	_, resp = patchTradingHours(t, router, marketID, `{"trading_open":null,"trading_close":null}`)
+	if resp.TradingOpen != nil || !resp.OpenNow {
+		t.Errorf("expected cleared window, got %+v", resp)
+	}
+
+	w = doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(1),
+	})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with no window, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTradingHours_InvalidWindow(t *testing.T) {
+	now := time.Now()
+	router, marketID, _ := tradingHoursEnv(t, &now)
+
+	for _, body := range []string{
+		`{"trading_open":"09:00"}`,
+		`{"trading_open":"9:00","trading_close":"17:00"}`,
+		`{"trading_open":"09:00","trading_close":"24:00"}`,
+		`{"trading_open":"09:00","trading_close":"09:00"}`,
+	} {
+		w, _ := patchTradingHours(t, router, marketID, body)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", body, w.Code)
+		}
+	}
+
+	if w, _ := patchTradingHours(t, router, "missing", `{"trading_open":"09:00","trading_close":"17:00"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown market, got %d", w.Code)
+	}
+}
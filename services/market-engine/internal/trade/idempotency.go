@@ -0,0 +1,78 @@
+// Idempotent trade submission.
+
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// IdempotencyKeyHeader lets a client safely retry POST /trade: requests
+// from the same user with the same key execute at most once.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ErrCodeIdempotencyKeyReused is returned when a key is replayed with a
+// different trade than the one it first executed.
+const ErrCodeIdempotencyKeyReused = "idempotency_key_reused"
+
+// idempotencyNamespace scopes derived ledger entry IDs (UUIDv5).
+var idempotencyNamespace = uuid.MustParse("6f1c1f5e-3c1a-4f7e-9a43-5d0f1b7c2e11")
+
+// idempotentEntryID derives a stable ledger entry ID from a user's
+// idempotency key, so the ledger primary key enforces at-most-once.
+func idempotentEntryID(userID, key string) string {
+	return uuid.NewSHA1(idempotencyNamespace, []byte(userID+"\x00"+key)).String()
+}
+
+// originalTrade looks up the entry a retry with this idempotency key
+// would collide with. found is false if the key hasn't been used.
+func (s *Service) originalTrade(ctx context.Context, entryID string) (orig *model.LedgerEntry, found bool, err error) {
+	orig, err = s.store.GetLedgerEntry(ctx, entryID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return orig, true, nil
+}
+
+// replayIdempotentTrade answers a retried request with the original trade.
+func (s *Service) replayIdempotentTrade(w http.ResponseWriter, r *http.Request, req TradeRequest, requested decimal.Decimal, orig *model.LedgerEntry) {
+	// A partial fill may have been cut to less than the request, and a
+	// retry can be cut differently as the price has moved since.
+	sameSize := orig.Quantity.Equal(requested)
+	if req.AllowPartial {
+		sameSize = orig.Quantity.Sign() == requested.Sign() && orig.Quantity.Abs().LessThanOrEqual(requested.Abs())
+	}
+	if orig.ContractID != req.ContractID || orig.Side != req.Side || !sameSize {
+		writeCodedError(w, ErrCodeIdempotencyKeyReused,
+			"idempotency key was already used for a different trade", http.StatusConflict)
+		return
+	}
+
+	resp := TradeResponse{
+		TradeID:     orig.ID,
+		UserID:      orig.UserID,
+		ContractID:  orig.ContractID,
+		Side:        orig.Side,
+		Quantity:    orig.Quantity,
+		FillPrice:   orig.Price,
+		Cost:        orig.Cost,
+		RealizedPnL: orig.RealizedPnL,
+		Position:    s.positionSummary(r.Context(), orig.UserID, orig.MarketID),
+		Partial:     !orig.Quantity.Equal(requested),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	json.NewEncoder(w).Encode(resp)
+}
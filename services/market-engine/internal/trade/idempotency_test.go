@@ -0,0 +1,126 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func doTradeWithKey(t *testing.T, router chi.Router, req trade.TradeRequest, key string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest("POST", "/api/v1/trade", bytes.NewReader(body))
+	httpReq.Header.Set(trade.IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+	return w
+}
+
+func TestExecuteTrade_IdempotencyKeyReplays(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	req := trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10)}
+
+	w1 := doTradeWithKey(t, router, req, "retry-1")
+	if w1.Code != http.StatusOK {
+		t.Fatalf("first attempt: expected 200, got %d: %s", w1.Code, w1.Body.String())
+	}
+	after1, _ := ms.GetMarket(context.Background(), market.ID)
+
+	w2 := doTradeWithKey(t, router, req, "retry-1")
+	if w2.Code != http.StatusOK {
+		t.Fatalf("retry: expected 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+	if w2.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected retry to be marked as replayed")
+	}
+
+	var r1, r2 trade.TradeResponse
+	json.Unmarshal(w1.Body.Bytes(), &r1)
+	json.Unmarshal(w2.Body.Bytes(), &r2)
+	if r1.TradeID != r2.TradeID || !r1.Cost.Equal(r2.Cost) || !r1.FillPrice.Equal(r2.FillPrice) {
+		t.Errorf("replay should return the original trade: %+v vs %+v", r1, r2)
+	}
+
+	entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), market.ID)
+	if len(entries) != 1 {
+		t.Errorf("expected exactly one ledger entry, got %d", len(entries))
+	}
+	after2, _ := ms.GetMarket(context.Background(), market.ID)
+	if !after2.QYes.Equal(after1.QYes) {
+		t.Errorf("retry moved the market: q_yes %s → %s", after1.QYes, after2.QYes)
+	}
+
+	// A different key is a new trade.
+	if w := doTradeWithKey(t, router, req, "retry-2"); w.Code != http.StatusOK {
+		t.Fatalf("new key: expected 200, got %d", w.Code)
+	}
+	entries, _ = ms.GetLedgerEntriesByMarket(context.Background(), market.ID)
+	if len(entries) != 2 {
+		t.Errorf("expected a second ledger entry for a new key, got %d", len(entries))
+	}
+}
+
+func TestExecuteTrade_IdempotencyKeyReplaysTradeThatFilledLimit(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	// High b keeps the price well inside its bounds at the 1000 share
+	// per-cell limit.
+	market := seedMarket(t, ms, contractID, "872a1070b", 10000)
+	req := trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(1000)}
+
+	w1 := doTradeWithKey(t, router, req, "fill-limit")
+	if w1.Code != http.StatusOK {
+		t.Fatalf("first attempt: expected 200, got %d: %s", w1.Code, w1.Body.String())
+	}
+	if w := doTradeWithKey(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(1)}, "over"); w.Code != http.StatusConflict {
+		t.Fatalf("limit should be full: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The retry would breach the limit its own fill reached; it must be
+	// answered with that fill rather than rejected.
+	w2 := doTradeWithKey(t, router, req, "fill-limit")
+	if w2.Code != http.StatusOK || w2.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry: expected a replayed 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+	var r1, r2 trade.TradeResponse
+	json.Unmarshal(w1.Body.Bytes(), &r1)
+	json.Unmarshal(w2.Body.Bytes(), &r2)
+	if r1.TradeID != r2.TradeID || !r1.Cost.Equal(r2.Cost) {
+		t.Errorf("replay should return the original trade: %+v vs %+v", r1, r2)
+	}
+	if entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), market.ID); len(entries) != 1 {
+		t.Errorf("expected exactly one ledger entry, got %d", len(entries))
+	}
+}
+
+func TestExecuteTrade_IdempotencyKeyReusedForDifferentTrade(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	doTradeWithKey(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10)}, "k")
+	w := doTradeWithKey(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(20)}, "k")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != trade.ErrCodeIdempotencyKeyReused {
+		t.Errorf("expected code %q, got %v", trade.ErrCodeIdempotencyKeyReused, body)
+	}
+
+	// The same key from a different user is independent.
+	w = doTradeWithKey(t, router, trade.TradeRequest{UserID: "user2", ContractID: contractID, Side: "YES", Quantity: d(20)}, "k")
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for another user's key, got %d", w.Code)
+	}
+}
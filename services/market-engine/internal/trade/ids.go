@@ -0,0 +1,47 @@
+// ID generation for markets, products, ledger entries and events.
+
+package trade
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator issues IDs for the markets, products, ledger entries and
+// events the service creates. IDs must be unique across instances sharing
+// a store; a time-ordered scheme such as ULID also keeps ledger entries
+// sorted by creation when ordered by ID.
+//
+// Confirm tokens and lock tokens are secrets, not IDs, and always use
+// random UUIDs.
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDGenerator issues random (version 4) UUIDs. It is the default.
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) NewID() string { return uuid.New().String() }
+
+// SequentialIDs issues prefix1, prefix2, … so tests can predict IDs. It is
+// only unique within one process.
+type SequentialIDs struct {
+	prefix string
+	n      atomic.Uint64
+}
+
+// NewSequentialIDs returns a SequentialIDs starting at prefix1.
+func NewSequentialIDs(prefix string) *SequentialIDs {
+	return &SequentialIDs{prefix: prefix}
+}
+
+func (g *SequentialIDs) NewID() string {
+	return g.prefix + strconv.FormatUint(g.n.Add(1), 10)
+}
+
+// WithIDGenerator replaces the default UUIDGenerator.
+func WithIDGenerator(g IDGenerator) Option {
+	return func(s *Service) { s.ids = g }
+}
@@ -0,0 +1,35 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestIDGenerator_DeterministicTradeIDs(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithIDGenerator(trade.NewSequentialIDs("id-")))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// Each trade takes one ID for its ledger entry and the next for its
+	// trade_executed event.
+	for _, want := range []string{"id-1", "id-3"} {
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(5)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp trade.TradeResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.TradeID != want {
+			t.Errorf("trade ID = %q, want %q", resp.TradeID, want)
+		}
+	}
+
+	entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), market.ID)
+	if len(entries) != 2 || entries[0].ID != "id-1" || entries[1].ID != "id-3" {
+		t.Errorf("unexpected ledger IDs %v", entries)
+	}
+}
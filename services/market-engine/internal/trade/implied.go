@@ -0,0 +1,104 @@
+// Smoothed implied probability from recent fills.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+)
+
+const defaultImpliedWindow = 30 * time.Minute
+
+// ImpliedResponse is the JSON body returned from the implied endpoint.
+type ImpliedResponse struct {
+	MarketID   string          `json:"market_id"`
+	Window     string          `json:"window"`
+	PriceYes   decimal.Decimal `json:"price_yes"`   // instantaneous LMSR price
+	ImpliedYes decimal.Decimal `json:"implied_yes"` // volume-weighted YES fill price
+	Volume     decimal.Decimal `json:"volume"`      // Σ|quantity| in the window
+	TradeCount int             `json:"trade_count"`
+	Fallback   bool            `json:"fallback"` // true when no trades: implied = instantaneous
+}
+
+// GetImplied handles GET /api/v1/markets/{marketID}/implied?window=30m
+// Returns the volume-weighted average fill price over the window, expressed
+// as a YES probability (NO fills count as 1 - price), alongside the current
+// instantaneous price. A single large trade moves the instantaneous price
+// but is averaged against the rest of the window's flow here.
+func (s *Service) GetImplied(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	window := defaultImpliedWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil || dur <= 0 {
+			writeError(w, "window must be a positive duration (e.g. 30m, 2h)", http.StatusBadRequest)
+			return
+		}
+		window = dur
+	}
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	entries, err := s.store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market history", err)
+		return
+	}
+
+	mm, err := s.marketMaker(market)
+	if err != nil {
+		s.internalError(w, r, "internal error: invalid market configuration", err)
+		return
+	}
+	priceYes := mm.Price(market.QYes, market.QNo)
+
+	since := s.now().Add(-window)
+	one := decimal.NewFromInt(1)
+	volume := decimal.Zero
+	weighted := decimal.Zero
+	count := 0
+	for _, e := range entries {
+		// Settlement payouts and their reversals aren't trades: their
+		// price is the outcome, not what anyone paid.
+		if !e.IsTrade() || e.Timestamp.Before(since) {
+			continue
+		}
+		yesPrice := e.Price
+		if e.Side == "NO" {
+			yesPrice = one.Sub(e.Price)
+		}
+		qty := e.Quantity.Abs()
+		volume = volume.Add(qty)
+		weighted = weighted.Add(yesPrice.Mul(qty))
+		count++
+	}
+
+	resp := ImpliedResponse{
+		MarketID:   market.ID,
+		Window:     window.String(),
+		PriceYes:   priceYes,
+		ImpliedYes: priceYes,
+		Volume:     volume,
+		TradeCount: count,
+		Fallback:   true,
+	}
+	if volume.IsPositive() {
+		resp.ImpliedYes = weighted.DivRound(volume, lmsr.PriceScale)
+		resp.Fallback = false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,90 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetImplied_VolumeWeighted(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	ctx := context.Background()
+	for i, e := range []model.LedgerEntry{
+		// Outside a 30m window: ignored.
+		{Side: "YES", Quantity: d(100), Price: d(0.9), Timestamp: now.Add(-time.Hour)},
+		// YES fill at 0.60 for 30 shares.
+		{Side: "YES", Quantity: d(30), Price: d(0.6), Timestamp: now.Add(-20 * time.Minute)},
+		// NO fill at 0.45 → YES-implied 0.55, for 10 shares (a sell counts by size).
+		{Side: "NO", Quantity: d(-10), Price: d(0.45), Timestamp: now.Add(-5 * time.Minute)},
+		// A settlement payout is at the outcome price, not a trade: ignored.
+		{Side: "YES", Quantity: d(-30), Price: d(1), Timestamp: now.Add(-time.Minute), Kind: model.EntryKindSettlement},
+	} {
+		e.ID = string(rune('a' + i))
+		e.UserID = "user1"
+		e.MarketID = market.ID
+		e.ContractID = market.ContractID
+		ms.InsertLedgerEntry(ctx, &e)
+	}
+
+	w, resp := getJSON[trade.ImpliedResponse](t, router, "/api/v1/markets/"+market.ID+"/implied?window=30m")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// (0.60*30 + 0.55*10) / 40 = 0.5875
+	if !resp.ImpliedYes.Equal(d(0.5875)) {
+		t.Errorf("expected implied_yes=0.5875, got %s", resp.ImpliedYes)
+	}
+	if resp.TradeCount != 2 || !resp.Volume.Equal(d(40)) {
+		t.Errorf("expected 2 trades / volume 40 in window, got %d / %s", resp.TradeCount, resp.Volume)
+	}
+	if resp.Fallback {
+		t.Error("fallback should be false when trades exist in window")
+	}
+	if !resp.PriceYes.Equal(d(0.5)) {
+		t.Errorf("expected instantaneous price 0.5, got %s", resp.PriceYes)
+	}
+
+	// A wider window includes the older trade.
+	_, resp = getJSON[trade.ImpliedResponse](t, router, "/api/v1/markets/"+market.ID+"/implied?window=2h")
+	if resp.TradeCount != 3 {
+		t.Errorf("expected 3 trades in 2h window, got %d", resp.TradeCount)
+	}
+}
+
+func TestGetImplied_NoTradesFallsBack(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w, resp := getJSON[trade.ImpliedResponse](t, router, "/api/v1/markets/"+market.ID+"/implied")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.Fallback {
+		t.Error("expected fallback=true with no trades")
+	}
+	if !resp.ImpliedYes.Equal(resp.PriceYes) {
+		t.Errorf("fallback implied %s should equal instantaneous %s", resp.ImpliedYes, resp.PriceYes)
+	}
+	if resp.Window != "30m0s" {
+		t.Errorf("expected default window 30m0s, got %s", resp.Window)
+	}
+}
+
+func TestGetImplied_InvalidWindow(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for _, q := range []string{"?window=abc", "?window=-5m", "?window=0s"} {
+		if w, _ := getJSON[trade.ImpliedResponse](t, router, "/api/v1/markets/"+market.ID+"/implied"+q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
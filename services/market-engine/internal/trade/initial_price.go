@@ -0,0 +1,24 @@
+// Opening a market at a prior price.
+
+package trade
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+)
+
+// seedQuantities returns the quantities that open a market priced by mm
+// at YES price p, clamped into [lmsr.MinPrice, lmsr.MaxPrice]. Only the
+// favoured side is seeded, so both quantities stay non-negative.
+func seedQuantities(mm *lmsr.MarketMaker, p decimal.Decimal) (qYes, qNo decimal.Decimal, err error) {
+	p = decimal.Min(decimal.Max(p, lmsr.MinPrice), lmsr.MaxPrice)
+	delta, err := mm.SharesForTargetPrice(decimal.Zero, decimal.Zero, p)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if delta.IsNegative() {
+		return decimal.Zero, delta.Neg(), nil
+	}
+	return delta, decimal.Zero, nil
+}
@@ -0,0 +1,123 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postCreateMarket(t *testing.T, router http.Handler, req trade.CreateMarketRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func createWithPrior(t *testing.T, router http.Handler, prior float64) model.Market {
+	t.Helper()
+	p := d(prior)
+	w := postCreateMarket(t, router, trade.CreateMarketRequest{
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", B: d(100), InitialPriceYes: &p,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var market model.Market
+	json.Unmarshal(w.Body.Bytes(), &market)
+	return market
+}
+
+func TestCreateMarket_InitialPriceYes(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := createWithPrior(t, router, 0.7)
+
+	if diff := market.PriceYes.Sub(d(0.7)).Abs(); diff.GreaterThan(d(1e-6)) {
+		t.Errorf("price_yes = %s, want ~0.7", market.PriceYes)
+	}
+	if sum := market.PriceYes.Add(market.PriceNo); !sum.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("prices sum to %s, want 1", sum)
+	}
+	if !market.QYes.IsPositive() || !market.QNo.IsZero() {
+		t.Errorf("expected only YES seeded, got q=(%s,%s)", market.QYes, market.QNo)
+	}
+	stored, _ := ms.GetMarket(context.Background(), market.ID)
+	if !stored.InitialQYes.Equal(market.QYes) || !stored.InitialQNo.IsZero() {
+		t.Errorf("stored seed (%s,%s), want (%s,0)", stored.InitialQYes, stored.InitialQNo, market.QYes)
+	}
+}
+
+func TestCreateMarket_InitialPriceYesBelowHalfSeedsNo(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	market := createWithPrior(t, router, 0.2)
+
+	if diff := market.PriceYes.Sub(d(0.2)).Abs(); diff.GreaterThan(d(1e-6)) {
+		t.Errorf("price_yes = %s, want ~0.2", market.PriceYes)
+	}
+	if !market.QYes.IsZero() || !market.QNo.IsPositive() {
+		t.Errorf("expected only NO seeded, got q=(%s,%s)", market.QYes, market.QNo)
+	}
+}
+
+func TestCreateMarket_InitialPriceYesClampedToBounds(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	market := createWithPrior(t, router, 0.99999)
+
+	if market.PriceYes.GreaterThan(lmsr.MaxPrice) {
+		t.Errorf("price_yes = %s, want at most %s", market.PriceYes, lmsr.MaxPrice)
+	}
+	if diff := market.PriceYes.Sub(lmsr.MaxPrice).Abs(); diff.GreaterThan(d(1e-6)) {
+		t.Errorf("price_yes = %s, want ~%s", market.PriceYes, lmsr.MaxPrice)
+	}
+}
+
+func TestCreateMarket_InitialPriceYesOutOfRange(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	for _, p := range []float64{0, 1, 1.2, -0.1} {
+		p := d(p)
+		w := postCreateMarket(t, router, trade.CreateMarketRequest{
+			ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", InitialPriceYes: &p,
+		})
+		if _, ok := fieldsOf(t, w)["initial_price_yes"]; !ok {
+			t.Errorf("prior %s: expected an initial_price_yes error, got %s", p, w.Body.String())
+		}
+	}
+}
+
+func TestCreateMarket_SeededMarketVerifiesAndReconciles(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	market := createWithPrior(t, router, 0.7)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(20)},
+		{UserID: "u2", ContractID: market.ContractID, Side: "NO", Quantity: d(5)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, resp := postVerify(t, router, market.ID)
+	if w.Code != http.StatusOK || !resp.OK || !resp.StateMatches {
+		t.Errorf("expected a clean replay from the seed, got %d %s", w.Code, w.Body.String())
+	}
+
+	w, oi := getJSON[trade.OpenInterestResponse](t, router, "/api/v1/markets/"+market.ID+"/open-interest")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !oi.YesShares.Equal(d(20)) || !oi.NoShares.Equal(d(5)) || !oi.Reconciled {
+		t.Errorf("open interest %+v, want 20 YES / 5 NO reconciled", oi)
+	}
+}
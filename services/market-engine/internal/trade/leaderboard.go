@@ -0,0 +1,86 @@
+// Realized P&L leaderboard.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+const (
+	defaultLeaderboardLimit = 20
+	maxLeaderboardLimit     = 100
+)
+
+// LeaderboardResponse is the JSON body returned from the leaderboard endpoint.
+type LeaderboardResponse struct {
+	Period  string                   `json:"period"`
+	Since   *time.Time               `json:"since"` // nil for all-time
+	Entries []model.LeaderboardEntry `json:"entries"`
+}
+
+// GetLeaderboard handles GET /api/v1/leaderboard?limit=20&period=7d
+// Ranks users by realized P&L booked at or after now-period (period "all"
+// or omitted = all time). Users with no ledger activity in the period are
+// not listed.
+func (s *Service) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := defaultLeaderboardLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLeaderboardLimit {
+			writeError(w, "limit must be an integer between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	period := q.Get("period")
+	if period == "" {
+		period = "all"
+	}
+	var since time.Time
+	if period != "all" {
+		dur, err := parsePeriod(period)
+		if err != nil || dur <= 0 {
+			writeError(w, "period must be a positive duration (e.g. 7d, 24h) or all", http.StatusBadRequest)
+			return
+		}
+		since = s.now().Add(-dur)
+	}
+
+	entries, err := s.store.GetLeaderboard(r.Context(), since, limit)
+	if err != nil {
+		s.internalError(w, r, "failed to load leaderboard", err)
+		return
+	}
+	if entries == nil {
+		entries = []model.LeaderboardEntry{}
+	}
+
+	resp := LeaderboardResponse{Period: period, Entries: entries}
+	if !since.IsZero() {
+		resp.Since = &since
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parsePeriod extends time.ParseDuration with a whole-day "Nd" form.
+func parsePeriod(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(v)
+}
@@ -0,0 +1,104 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetLeaderboard_OrderingAndPeriod(t *testing.T) {
+	now := time.Date(2025, 8, 10, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+	ctx := context.Background()
+	for i, e := range []model.LedgerEntry{
+		{UserID: "alice", RealizedPnL: d(5), Timestamp: now.Add(-time.Hour)},
+		{UserID: "alice", RealizedPnL: d(-1), Timestamp: now.Add(-2 * time.Hour)},
+		{UserID: "bob", RealizedPnL: d(10), Timestamp: weekAgo}, // exactly on the boundary: included
+		{UserID: "carol", RealizedPnL: d(4), Timestamp: now.Add(-time.Hour)},
+		{UserID: "dave", RealizedPnL: d(4), Timestamp: now.Add(-time.Hour)},
+		{UserID: "dave", RealizedPnL: d(0), Timestamp: now.Add(-time.Hour)},
+		{UserID: "erin", RealizedPnL: d(50), Timestamp: weekAgo.Add(-time.Second)}, // just outside
+	} {
+		e.ID = string(rune('a' + i))
+		e.MarketID = market.ID
+		e.ContractID = market.ContractID
+		e.Side = "YES"
+		ms.InsertLedgerEntry(ctx, &e)
+	}
+
+	w, resp := getJSON[trade.LeaderboardResponse](t, router, "/api/v1/leaderboard?period=7d")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// bob 10, alice 4 (2 trades) ties carol 4 (1 trade) and dave 4 (2 trades);
+	// ties break by trade count, then user ID.
+	want := []struct {
+		user   string
+		pnl    float64
+		trades int
+	}{{"bob", 10, 1}, {"alice", 4, 2}, {"dave", 4, 2}, {"carol", 4, 1}}
+	if len(resp.Entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), resp.Entries)
+	}
+	for i, e := range resp.Entries {
+		if e.Rank != i+1 || e.UserID != want[i].user || !e.RealizedPnL.Equal(d(want[i].pnl)) || e.TradeCount != want[i].trades {
+			t.Errorf("rank %d: expected %+v, got %+v", i+1, want[i], e)
+		}
+	}
+
+	// All-time includes erin at the top; limit truncates.
+	_, resp = getJSON[trade.LeaderboardResponse](t, router, "/api/v1/leaderboard?limit=2")
+	if len(resp.Entries) != 2 || resp.Entries[0].UserID != "erin" || resp.Since != nil {
+		t.Errorf("expected erin first in all-time top 2, got %+v", resp)
+	}
+}
+
+func TestGetLeaderboard_InvalidParams(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	for _, q := range []string{"?limit=0", "?limit=101", "?limit=x", "?period=-7d", "?period=week"} {
+		if w, _ := getJSON[trade.LeaderboardResponse](t, router, "/api/v1/leaderboard"+q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestExecuteTrade_BooksRealizedPnL(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	var buy, sell trade.TradeResponse
+	w := doTrade(t, router, trade.TradeRequest{UserID: "alice", ContractID: contractID, Side: "YES", Quantity: d(20)})
+	json.Unmarshal(w.Body.Bytes(), &buy)
+	if !buy.RealizedPnL.IsZero() {
+		t.Errorf("opening trade should realize nothing, got %s", buy.RealizedPnL)
+	}
+
+	// Someone else pushes the price up; alice sells half into it.
+	doTrade(t, router, trade.TradeRequest{UserID: "bob", ContractID: contractID, Side: "YES", Quantity: d(30)})
+	w = doTrade(t, router, trade.TradeRequest{UserID: "alice", ContractID: contractID, Side: "YES", Quantity: d(-10)})
+	json.Unmarshal(w.Body.Bytes(), &sell)
+
+	// realized = proceeds - half the original cost.
+	want := sell.Cost.Neg().Sub(buy.Cost.Div(d(2)))
+	if !sell.RealizedPnL.Sub(want).Abs().LessThan(d(0.0000001)) {
+		t.Errorf("expected realized %s, got %s", want, sell.RealizedPnL)
+	}
+	if !sell.RealizedPnL.IsPositive() {
+		t.Errorf("selling into a higher price should realize a gain, got %s", sell.RealizedPnL)
+	}
+
+	_, resp := getJSON[trade.LeaderboardResponse](t, router, "/api/v1/leaderboard")
+	if len(resp.Entries) != 2 || resp.Entries[0].UserID != "alice" || !resp.Entries[0].RealizedPnL.Equal(sell.RealizedPnL) {
+		t.Errorf("expected alice to lead with %s, got %+v", sell.RealizedPnL, resp.Entries)
+	}
+}
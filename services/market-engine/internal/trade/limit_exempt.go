@@ -0,0 +1,40 @@
+// Position limit exemptions for liquidity providers.
+
+package trade
+
+import (
+	"log/slog"
+
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// WithLimitExemptUsers lets the given accounts, such as designated
+// market makers, trade past the per-cell and correlated position limits.
+// Every bypass is logged and counted in metrics.PositionLimitBypasses.
+// Other users are unaffected.
+func WithLimitExemptUsers(userIDs ...string) Option {
+	return func(s *Service) {
+		if s.limitExempt == nil {
+			s.limitExempt = make(map[string]bool, len(userIDs))
+		}
+		for _, id := range userIDs {
+			if id != "" {
+				s.limitExempt[id] = true
+			}
+		}
+	}
+}
+
+// logLimitBypass records that an exempt user's trade skipped the position
+// limit check.
+func logLimitBypass(req TradeRequest, m *model.Market) {
+	metrics.PositionLimitBypasses.Inc()
+	slog.Info("position limit bypassed",
+		"user", req.UserID,
+		"contract", req.ContractID,
+		"h3_cell", m.H3CellID,
+		"side", req.Side,
+		"quantity", req.Quantity.String(),
+	)
+}
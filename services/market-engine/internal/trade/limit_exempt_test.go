@@ -0,0 +1,39 @@
+package trade_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestExecuteTrade_LimitExemptUserExceedsPerCellLimit(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithLimitExemptUsers("maker"))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	// High b so 1500 shares stay inside the price bounds.
+	seedMarket(t, ms, contractID, "872a1070b", 10000)
+
+	before := testutil.ToFloat64(metrics.PositionLimitBypasses)
+	logs := captureLogs(t)
+
+	// 1500 is past the per-cell limit of 1000.
+	w := doTrade(t, router, trade.TradeRequest{UserID: "maker", ContractID: contractID, Side: "YES", Quantity: d(1500)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("exempt user: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(1500)})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("normal user: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	records := logRecords(t, logs, "position limit bypassed")
+	if len(records) != 1 || records[0]["user"] != "maker" || records[0]["quantity"] != "1500" {
+		t.Errorf("expected one bypass record for maker, got %v", records)
+	}
+	if got := testutil.ToFloat64(metrics.PositionLimitBypasses); got != before+1 {
+		t.Errorf("bypass metric = %v, want %v", got, before+1)
+	}
+}
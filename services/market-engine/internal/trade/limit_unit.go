@@ -0,0 +1,71 @@
+// Position limits measured in shares or notional.
+
+package trade
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// limitExposures returns the user's net exposure per cell and the trade's
+// signed exposure delta, both in the position limiter's unit. In shares
+// the delta is the trade quantity (+YES / -NO). In notional every position
+// is valued at its market's current price (see correlation.Notional), and
+// the delta is the change in the traded market's notional.
+func (s *Service) limitExposures(ctx context.Context, req TradeRequest, market *model.Market) (map[string]decimal.Decimal, decimal.Decimal, error) {
+	delta := req.Quantity
+	if req.Side == "NO" {
+		delta = req.Quantity.Neg()
+	}
+	if s.limiter.Unit != correlation.UnitNotional {
+		exposures, err := s.store.GetUserCellExposures(ctx, req.UserID)
+		return exposures, delta, err
+	}
+
+	positions, err := s.store.GetUserPositions(ctx, req.UserID)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	contractIDs := make([]string, 0, len(positions))
+	for _, p := range positions {
+		if p.MarketID != market.ID {
+			contractIDs = append(contractIDs, p.ContractID)
+		}
+	}
+	markets, err := s.store.GetMarketsByContracts(ctx, contractIDs)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	// The traded market was read under its lock; use that state.
+	priceYes := map[string]decimal.Decimal{market.ID: market.MarkPriceYes()}
+	for _, m := range markets {
+		if m.ID != market.ID {
+			priceYes[m.ID] = m.MarkPriceYes()
+		}
+	}
+
+	exposures := make(map[string]decimal.Decimal)
+	held := decimal.Zero
+	for _, p := range positions {
+		if p.MarketID == market.ID {
+			held = p.NetQty
+		}
+		if p.H3CellID != "" {
+			exposures[p.H3CellID] = exposures[p.H3CellID].Add(correlation.Notional(p.NetQty, priceYes[p.MarketID]))
+		}
+	}
+	// Leave out cells that net to zero, as GetUserCellExposures does.
+	for cell, e := range exposures {
+		if e.IsZero() {
+			delete(exposures, cell)
+		}
+	}
+
+	price := market.MarkPriceYes()
+	delta = correlation.Notional(held.Add(delta), price).Sub(correlation.Notional(held, price))
+	return exposures, delta, nil
+}
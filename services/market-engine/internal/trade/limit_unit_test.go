@@ -0,0 +1,96 @@
+package trade_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// seedMarketAt seeds a deep market (b = 100000) priced at priceYes, so
+// small trades barely move it.
+func seedMarketAt(t *testing.T, ms *store.MemoryStore, contractID, h3Cell string, priceYes float64) {
+	t.Helper()
+	b := 100000.0
+	market := &model.Market{
+		ID:          "test-market-" + contractID,
+		ContractID:  contractID,
+		H3CellID:    h3Cell,
+		QYes:        d(b * math.Log(priceYes/(1-priceYes))).Round(8),
+		QNo:         decimal.Zero,
+		B:           d(b),
+		PriceYes:    d(priceYes),
+		PriceNo:     d(1 - priceYes),
+		Status:      "open",
+		CreatedAt:   time.Now().UTC(),
+		InitialQYes: d(b * math.Log(priceYes/(1-priceYes))).Round(8),
+	}
+	if err := ms.CreateMarket(context.Background(), market); err != nil {
+		t.Fatalf("failed to seed market: %v", err)
+	}
+}
+
+func TestExecuteTrade_NotionalLimitsWeighSharesByPrice(t *testing.T) {
+	even := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	likely := "ATMX-882a1070b-PRECIP-25MM-20250815"
+	ms := store.NewMemoryStore()
+	seedMarketAt(t, ms, even, "872a1070b", 0.5)
+	seedMarketAt(t, ms, likely, "882a1070b", 0.95)
+	limiter := correlation.NewPositionLimiter(d(100), d(5000), 5)
+	limiter.Unit = correlation.UnitNotional
+	svc := trade.NewService(ms, limiter, nil)
+	router := chi.NewRouter()
+	router.Post("/api/v1/trade", svc.ExecuteTrade)
+
+	// 150 shares is over a 100 share limit, but ~75 notional at 0.5 and
+	// ~142.5 near 1.
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: even, Side: "YES", Quantity: d(150),
+	}); w.Code != http.StatusOK {
+		t.Errorf("150 shares at 0.5: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: likely, Side: "YES", Quantity: d(150),
+	}); w.Code != http.StatusConflict {
+		t.Errorf("150 shares at 0.95: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	// NO near 1 is cheap: 150 NO shares at 0.05 is ~7.5 notional.
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: likely, Side: "NO", Quantity: d(150),
+	}); w.Code != http.StatusOK {
+		t.Errorf("150 NO shares at 0.05: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExecuteTrade_NotionalLimitsCountHeldPositionsAtPrice(t *testing.T) {
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	ms := store.NewMemoryStore()
+	seedMarketAt(t, ms, contractID, "872a1070b", 0.8)
+	limiter := correlation.NewPositionLimiter(d(100), d(5000), 5)
+	limiter.Unit = correlation.UnitNotional
+	svc := trade.NewService(ms, limiter, nil)
+	router := chi.NewRouter()
+	router.Post("/api/v1/trade", svc.ExecuteTrade)
+
+	// 100 shares at 0.8 hold ~80 of the 100 notional; 20 more reach ~96,
+	// 10 beyond that is over.
+	for _, tc := range []struct {
+		qty  float64
+		code int
+	}{{100, http.StatusOK}, {20, http.StatusOK}, {10, http.StatusConflict}} {
+		if w := doTrade(t, router, trade.TradeRequest{
+			UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(tc.qty),
+		}); w.Code != tc.code {
+			t.Errorf("buy %v: expected %d, got %d: %s", tc.qty, tc.code, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Time-varying liquidity schedules.
+
+package trade
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// contractExpiry is when a market's liquidity schedule reaches BEnd: the
+// end (UTC) of the contract's observation date.
+func contractExpiry(m *model.Market) (time.Time, bool) {
+	c, err := contract.ParseTicker(m.ContractID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return c.ExpiryDate.Add(24 * time.Hour), true
+}
+
+// effectiveB returns the LMSR liquidity parameter in force for m at t.
+// Without a schedule this is m.B. With one, b moves linearly from BStart
+// at creation to BEnd at expiry and holds at either end outside that span.
+func effectiveB(m *model.Market, t time.Time) decimal.Decimal {
+	if m.BStart == nil || m.BEnd == nil {
+		return m.B
+	}
+	expiry, ok := contractExpiry(m)
+	if !ok {
+		return m.B
+	}
+
+	total := expiry.Sub(m.CreatedAt)
+	elapsed := t.Sub(m.CreatedAt)
+	switch {
+	case total <= 0 || elapsed >= total:
+		return *m.BEnd
+	case elapsed <= 0:
+		return *m.BStart
+	}
+
+	frac := decimal.NewFromInt(int64(elapsed)).Div(decimal.NewFromInt(int64(total)))
+	return m.BStart.Add(m.BEnd.Sub(*m.BStart).Mul(frac)).Round(lmsr.PriceScale)
+}
+
+// marketMaker builds the LMSR market maker for m using the liquidity in
+// force at the service clock's current time.
+func (s *Service) marketMaker(m *model.Market) (*lmsr.MarketMaker, error) {
+	return lmsr.NewMarketMaker(effectiveB(m, s.now()))
+}
+
+// liquidityAt returns the b in force for m at any time, accounting for
+// manual changes (oldest first) as well as its schedule. Trades before the
+// first change saw that change's OldB.
+func liquidityAt(m *model.Market, changes []model.LiquidityChange) func(time.Time) decimal.Decimal {
+	return func(t time.Time) decimal.Decimal {
+		if len(changes) == 0 {
+			return effectiveB(m, t)
+		}
+		b := changes[0].OldB
+		for _, c := range changes {
+			if c.ChangedAt.After(t) {
+				break
+			}
+			b = c.NewB
+		}
+		return b
+	}
+}
@@ -0,0 +1,74 @@
+// Provenance of a market's liquidity parameter.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// Liquidity sources reported by the liquidity-source endpoint.
+const (
+	LiquiditySourceForecast = "forecast" // derived from an NWS forecast
+	LiquiditySourceManual   = "manual"   // set directly, or a type default
+)
+
+// LiquiditySourceResponse is the JSON body returned from the
+// liquidity-source endpoint. Forecast and BaseVolume are set only for a
+// forecast-derived b.
+type LiquiditySourceResponse struct {
+	MarketID   string                    `json:"market_id"`
+	Source     string                    `json:"source"`
+	Forecast   *contract.NWSForecastData `json:"forecast,omitempty"`
+	BaseVolume *decimal.Decimal          `json:"base_volume,omitempty"`
+	B          decimal.Decimal           `json:"b"`
+}
+
+// GetLiquiditySource handles GET /api/v1/markets/{marketID}/liquidity-source
+// Reports where the market's current b came from: the NWS percentiles and
+// base volume it was derived from (at creation or the latest reliquify),
+// or "manual" when b was set directly.
+func (s *Service) GetLiquiditySource(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+
+	market, err := s.store.GetMarket(r.Context(), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	resp := LiquiditySourceResponse{MarketID: market.ID, Source: LiquiditySourceManual, B: market.B}
+	if in := market.LiquidityInputs; in != nil {
+		resp.Source = LiquiditySourceForecast
+		resp.Forecast = &contract.NWSForecastData{
+			Percentile10: in.Percentile10,
+			Percentile25: in.Percentile25,
+			Percentile50: in.Percentile50,
+			Percentile75: in.Percentile75,
+			Percentile90: in.Percentile90,
+		}
+		resp.BaseVolume = &in.BaseVolume
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// liquidityInputs records the forecast and base volume a b is derived
+// from, for storing with the market.
+func liquidityInputs(nws contract.NWSForecastData, baseVolume decimal.Decimal) *model.LiquidityInputs {
+	return &model.LiquidityInputs{
+		Percentile10: nws.Percentile10,
+		Percentile25: nws.Percentile25,
+		Percentile50: nws.Percentile50,
+		Percentile75: nws.Percentile75,
+		Percentile90: nws.Percentile90,
+		BaseVolume:   baseVolume,
+	}
+}
@@ -0,0 +1,86 @@
+package trade_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func getLiquiditySource(t *testing.T, router chi.Router, marketID string) trade.LiquiditySourceResponse {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/markets/"+marketID+"/liquidity-source", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.LiquiditySourceResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestLiquiditySource_ForecastMarket(t *testing.T) {
+	_, _, router := newTestEnv(t, beforeExpiry(lmsr.ExpiryHorizon))
+	nws := contract.NWSForecastData{
+		Percentile10: d(2), Percentile25: d(10), Percentile50: d(25), Percentile75: d(40), Percentile90: d(60),
+	}
+	w, created := postProduct(t, router, trade.CreateProductRequest{
+		Type: "PRECIP", Threshold: "25MM", Date: "20250815", Cells: []string{"872a1070b"},
+		LiquiditySource: trade.ProductLiquidity{Forecast: &nws, BaseVolume: d(80)},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	got := getLiquiditySource(t, router, created.Markets[0].ID)
+	if got.Source != trade.LiquiditySourceForecast {
+		t.Fatalf("source = %q, want %q", got.Source, trade.LiquiditySourceForecast)
+	}
+	if got.Forecast == nil || !got.Forecast.Percentile10.Equal(nws.Percentile10) ||
+		!got.Forecast.Percentile50.Equal(nws.Percentile50) || !got.Forecast.Percentile90.Equal(nws.Percentile90) {
+		t.Errorf("forecast = %+v, want %+v", got.Forecast, nws)
+	}
+	if got.BaseVolume == nil || !got.BaseVolume.Equal(d(80)) {
+		t.Errorf("base_volume = %v, want 80", got.BaseVolume)
+	}
+	// IQR/median = 30/25 → b = 96 with base volume 80.
+	if !got.B.Equal(d(96)) {
+		t.Errorf("b = %s, want 96", got.B)
+	}
+}
+
+func TestLiquiditySource_ManualMarket(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	got := getLiquiditySource(t, router, market.ID)
+	if got.Source != trade.LiquiditySourceManual || got.Forecast != nil || got.BaseVolume != nil || !got.B.Equal(d(100)) {
+		t.Errorf("got %+v, want manual b = 100", got)
+	}
+
+	// Reliquifying from a forecast makes it forecast-derived.
+	if w, _ := postReliquify(t, router, market.ID, forecast(10, 25, 40)); w.Code != http.StatusOK {
+		t.Fatalf("reliquify: %d %s", w.Code, w.Body.String())
+	}
+	got = getLiquiditySource(t, router, market.ID)
+	if got.Source != trade.LiquiditySourceForecast || got.BaseVolume == nil || !got.BaseVolume.Equal(trade.DefaultBaseVolume) {
+		t.Errorf("got %+v after reliquify, want forecast with the default base volume", got)
+	}
+}
+
+func TestLiquiditySource_UnknownMarket(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	req := httptest.NewRequest("GET", "/api/v1/markets/nope/liquidity-source", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestLiquiditySchedule_EffectiveBOverLifetime(t *testing.T) {
+	created := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(2025, 8, 16, 0, 0, 0, 0, time.UTC) // end of 2025-08-15
+	now := created
+	_, _, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	bStart, bEnd := d(50), d(500)
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: contractID, BStart: &bStart, BEnd: &bEnd})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create failed: %d %s", w.Code, w.Body.String())
+	}
+	var market model.Market
+	json.Unmarshal(w.Body.Bytes(), &market)
+	if market.BStart == nil || !market.BStart.Equal(bStart) || !market.B.Equal(bStart) {
+		t.Fatalf("expected b = b_start = 50 stored, got %+v", market)
+	}
+
+	tradeCost := func() trade.TradeResponse {
+		t.Helper()
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+		var resp trade.TradeResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// At creation the market trades with b_start.
+	first := tradeCost()
+	mmStart, _ := lmsr.NewMarketMaker(bStart)
+	if want := mmStart.TradeCost(d(0), d(0), d(10)); !first.Cost.Equal(want) {
+		t.Errorf("at creation: expected cost %s (b=50), got %s", want, first.Cost)
+	}
+
+	// One second before expiry b is within rounding of b_end.
+	now = expiry.Add(-time.Second)
+	second := tradeCost()
+	mmEnd, _ := lmsr.NewMarketMaker(bEnd)
+	want := mmEnd.TradeCost(d(10), d(0), d(10))
+	if second.Cost.Sub(want).Abs().GreaterThan(d(0.0001)) {
+		t.Errorf("near expiry: expected cost ≈ %s (b=500), got %s", want, second.Cost)
+	}
+	if !second.Cost.LessThan(first.Cost) {
+		t.Errorf("deeper liquidity should make the second (higher-q) batch cheaper: first=%s second=%s", first.Cost, second.Cost)
+	}
+
+	// The stored price is recomputed with the effective b.
+	req := httptest.NewRequest("GET", "/api/v1/markets/"+market.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var after model.Market
+	json.Unmarshal(w.Body.Bytes(), &after)
+	if got, want := after.PriceYes, mmEnd.Price(d(20), d(0)); got.Sub(want).Abs().GreaterThan(d(0.0001)) {
+		t.Errorf("expected price_yes ≈ %s with b_end, got %s", want, got)
+	}
+
+	// Verification replays each trade with the b in force at its timestamp.
+	_, vresp := postVerify(t, router, market.ID)
+	if !vresp.OK {
+		t.Errorf("expected scheduled market to verify cleanly, got divergences=%+v violations=%v",
+			vresp.Divergences, vresp.Violations)
+	}
+}
+
+func TestCreateMarket_LiquidityScheduleValidation(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	neg := decimal.NewFromInt(-1)
+	pos := decimal.NewFromInt(100)
+	for name, req := range map[string]trade.CreateMarketRequest{
+		"only start": {BStart: &pos},
+		"negative":   {BStart: &neg, BEnd: &pos},
+	} {
+		req.ContractID = "ATMX-872a1070b-PRECIP-25MM-20250815"
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Per-market locking for serialized trade execution.
+
+package trade
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockLost is the cause a held lock's context is cancelled with when
+// the lock stops being held before it is released.
+var ErrLockLost = errors.New("lock lost")
+
+// Locker serializes writes to a single market. Trades on different markets
+// may proceed concurrently; trades on the same market never interleave.
+type Locker interface {
+	// Lock blocks until the lock for key is held or ctx is done. The
+	// returned context is ctx for as long as the lock is held: it is
+	// cancelled with cause ErrLockLost if the lock is lost, and when it is
+	// released. Writes made under the lock should use it. The returned
+	// function releases the lock and must be called exactly once.
+	Lock(ctx context.Context, key string) (held context.Context, unlock func(), err error)
+}
+
+// --- In-process locker ---
+
+// localLocker is the single-instance fallback: one channel-based mutex per
+// key, so waiting for a lock still respects ctx cancellation.
+type localLocker struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	ch   chan struct{} // holds one token while locked
+	refs int           // holders + waiters; entry is dropped at zero
+}
+
+// NewLocalLocker returns an in-process per-key Locker. It only serializes
+// within one process; use NewRedisLocker when running multiple instances.
+func NewLocalLocker() Locker {
+	return &localLocker{locks: make(map[string]*keyLock)}
+}
+
+func (l *localLocker) Lock(ctx context.Context, key string) (context.Context, func(), error) {
+	l.mu.Lock()
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	select {
+	case kl.ch <- struct{}{}:
+	case <-ctx.Done():
+		l.release(key, kl)
+		return nil, nil, ctx.Err()
+	}
+
+	// An in-process lock is held until it is released.
+	held, cancel := context.WithCancel(ctx)
+	return held, func() {
+		cancel()
+		<-kl.ch
+		l.release(key, kl)
+	}, nil
+}
+
+func (l *localLocker) release(key string, kl *keyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kl.refs--
+	if kl.refs == 0 {
+		delete(l.locks, key)
+	}
+}
+
+// --- Redis locker ---
+
+// Lua scripts only touch the key when it still holds our token, so a lock
+// that expired and was re-acquired by another instance is never renewed or
+// released by the previous owner.
+const (
+	renewLockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+
+	releaseLockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+)
+
+// RedisLocker is a distributed Locker based on SET NX with a TTL.
+// While held, the lock is renewed every ttl/3 so a long-running trade does
+// not lose it; if the holder dies, the lock expires after ttl. A renewal
+// that fails, or finds the key gone or owned by someone else, cancels the
+// held context with ErrLockLost.
+type RedisLocker struct {
+	rdb   redis.Cmdable
+	ttl   time.Duration
+	retry time.Duration
+}
+
+// NewRedisLocker creates a Redis-backed Locker with the given lock TTL.
+func NewRedisLocker(rdb redis.Cmdable, ttl time.Duration) *RedisLocker {
+	return &RedisLocker{
+		rdb:   rdb,
+		ttl:   ttl,
+		retry: 10 * time.Millisecond,
+	}
+}
+
+func (l *RedisLocker) Lock(ctx context.Context, key string) (context.Context, func(), error) {
+	lockKey := lockKey(key)
+	token := uuid.New().String()
+
+	for {
+		ok, err := l.rdb.SetNX(ctx, lockKey, token, l.ttl).Result()
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, nil, ctxErr
+			}
+			return nil, nil, err
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-time.After(l.retry):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	held, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go l.renew(lockKey, token, cancel, stop, done)
+
+	return held, func() {
+		close(stop)
+		<-done
+		cancel(nil)
+		// The request context may already be cancelled; release anyway.
+		releaseCtx, cancel := context.WithTimeout(context.Background(), l.ttl)
+		defer cancel()
+		l.rdb.Eval(releaseCtx, releaseLockScript, []string{lockKey}, token)
+	}, nil
+}
+
+// renew extends the lock TTL until stop is closed, or until a renewal
+// fails, when it calls lost with ErrLockLost and stops.
+func (l *RedisLocker) renew(lockKey, token string, lost context.CancelCauseFunc, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+			n, err := l.rdb.Eval(ctx, renewLockScript, []string{lockKey}, token, l.ttl.Milliseconds()).Int64()
+			cancel()
+			if err != nil || n == 0 {
+				// Once the renewal misses, another instance may take the
+				// lock at any moment; stop trusting it.
+				slog.Warn("market lock lost", "key", lockKey, "err", err)
+				lost(ErrLockLost)
+				return
+			}
+		}
+	}
+}
+
+func lockKey(key string) string { return "lock:market:" + key }
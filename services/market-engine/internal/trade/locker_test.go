@@ -0,0 +1,240 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeLockRedis implements the subset of redis.Cmdable used by RedisLocker,
+// emulating key expiry with wall-clock deadlines.
+type fakeLockRedis struct {
+	redis.Cmdable
+
+	mu      sync.Mutex
+	vals    map[string]string
+	expires map[string]time.Time
+	renews  int
+}
+
+func newFakeLockRedis() *fakeLockRedis {
+	return &fakeLockRedis{vals: make(map[string]string), expires: make(map[string]time.Time)}
+}
+
+func (f *fakeLockRedis) get(key string) (string, bool) {
+	v, ok := f.vals[key]
+	if ok && time.Now().After(f.expires[key]) {
+		delete(f.vals, key)
+		delete(f.expires, key)
+		return "", false
+	}
+	return v, ok
+}
+
+func (f *fakeLockRedis) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) *redis.BoolCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.get(key); ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	f.vals[key] = value.(string)
+	f.expires[key] = time.Now().Add(ttl)
+	return redis.NewBoolResult(true, nil)
+}
+
+func (f *fakeLockRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.get(keys[0])
+	if !ok || v != args[0].(string) {
+		return redis.NewCmdResult(int64(0), nil)
+	}
+	switch script {
+	case renewLockScript:
+		f.renews++
+		f.expires[keys[0]] = time.Now().Add(time.Duration(args[1].(int64)) * time.Millisecond)
+	case releaseLockScript:
+		delete(f.vals, keys[0])
+		delete(f.expires, keys[0])
+	}
+	return redis.NewCmdResult(int64(1), nil)
+}
+
+// assertMutualExclusion runs concurrent critical sections on one key and
+// fails if any two overlap.
+func assertMutualExclusion(t *testing.T, l Locker, key string) {
+	t.Helper()
+	var inside, maxInside int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, unlock, err := l.Lock(context.Background(), key)
+			if err != nil {
+				t.Errorf("lock failed: %v", err)
+				return
+			}
+			n := atomic.AddInt32(&inside, 1)
+			for {
+				m := atomic.LoadInt32(&maxInside)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInside, m, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&inside, -1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if maxInside != 1 {
+		t.Errorf("expected at most 1 holder at a time, saw %d", maxInside)
+	}
+}
+
+func TestLocalLocker_SameKeyContention(t *testing.T) {
+	assertMutualExclusion(t, NewLocalLocker(), "market-1")
+}
+
+func TestLocalLocker_DifferentKeysConcurrent(t *testing.T) {
+	l := NewLocalLocker()
+	_, unlockA, err := l.Lock(context.Background(), "market-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlockA()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, unlockB, err := l.Lock(ctx, "market-b")
+	if err != nil {
+		t.Fatalf("unrelated market should not block: %v", err)
+	}
+	unlockB()
+}
+
+func TestLocalLocker_WaitRespectsContext(t *testing.T) {
+	l := NewLocalLocker()
+	_, unlock, _ := l.Lock(context.Background(), "market-1")
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := l.Lock(ctx, "market-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded while contended, got %v", err)
+	}
+}
+
+func TestRedisLocker_SameKeyContention(t *testing.T) {
+	l := NewRedisLocker(newFakeLockRedis(), time.Second)
+	l.retry = time.Millisecond
+	assertMutualExclusion(t, l, "market-1")
+}
+
+func TestRedisLocker_ReleasedOnUnlock(t *testing.T) {
+	rdb := newFakeLockRedis()
+	l := NewRedisLocker(rdb, time.Second)
+
+	_, unlock, err := l.Lock(context.Background(), "market-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	if _, held := rdb.get(lockKey("market-1")); held {
+		t.Error("lock key should be deleted after unlock")
+	}
+}
+
+func TestRedisLocker_RenewsWhileHeld(t *testing.T) {
+	rdb := newFakeLockRedis()
+	l := NewRedisLocker(rdb, 60*time.Millisecond)
+	l.retry = time.Millisecond
+
+	_, unlock, err := l.Lock(context.Background(), "market-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold well past the TTL; renewal must keep a second instance out.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, _, err := l.Lock(ctx, "market-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock should still be held via renewal, got %v", err)
+	}
+	unlock()
+
+	rdb.mu.Lock()
+	renews := rdb.renews
+	rdb.mu.Unlock()
+	if renews == 0 {
+		t.Error("expected the lock to be renewed at least once")
+	}
+}
+
+func TestRedisLocker_ExpiresIfHolderDies(t *testing.T) {
+	rdb := newFakeLockRedis()
+	// Simulate a crashed instance: key set without a renewing holder.
+	rdb.SetNX(context.Background(), lockKey("market-1"), "dead-owner", 20*time.Millisecond)
+
+	l := NewRedisLocker(rdb, time.Second)
+	l.retry = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, unlock, err := l.Lock(ctx, "market-1")
+	if err != nil {
+		t.Fatalf("expected lock after expiry, got %v", err)
+	}
+	unlock()
+}
+
+func TestRedisLocker_KeyDeletedWhileHeldCancelsContext(t *testing.T) {
+	rdb := newFakeLockRedis()
+	l := NewRedisLocker(rdb, 30*time.Millisecond)
+
+	held, unlock, err := l.Lock(context.Background(), "market-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	if held.Err() != nil {
+		t.Fatal("held context should be live while the lock is held")
+	}
+
+	// Another instance's view of the world: the key is gone, e.g. flushed
+	// or expired during a stall. The next renewal must notice.
+	rdb.mu.Lock()
+	delete(rdb.vals, lockKey("market-1"))
+	delete(rdb.expires, lockKey("market-1"))
+	rdb.mu.Unlock()
+
+	select {
+	case <-held.Done():
+	case <-time.After(time.Second):
+		t.Fatal("held context was not cancelled after the lock key was deleted")
+	}
+	if cause := context.Cause(held); !errors.Is(cause, ErrLockLost) {
+		t.Errorf("cause = %v, want ErrLockLost", cause)
+	}
+}
+
+func TestRedisLocker_UnlockCancelsContext(t *testing.T) {
+	l := NewRedisLocker(newFakeLockRedis(), time.Second)
+	held, unlock, err := l.Lock(context.Background(), "market-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if held.Err() == nil {
+		t.Error("held context should be done after unlock")
+	}
+	if errors.Is(context.Cause(held), ErrLockLost) {
+		t.Error("a released lock was not lost")
+	}
+}
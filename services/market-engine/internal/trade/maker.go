@@ -0,0 +1,82 @@
+// Market-maker inventory and exposure report.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+// MakerReport is the JSON body returned from the maker endpoint.
+type MakerReport struct {
+	MarketID string          `json:"market_id"`
+	B        decimal.Decimal `json:"b"`       // liquidity parameter in force now
+	Subsidy  decimal.Decimal `json:"subsidy"` // b·ln2: the maker's worst-case loss from a fresh market
+	// Inventory is traders' net YES holding: QYes - QNo, less any seed. Positive means the
+	// maker is short YES, negative that it is short NO.
+	Inventory decimal.Decimal `json:"inventory"`
+	ShortSide string          `json:"short_side,omitempty"` // "YES", "NO", or empty when flat
+	// Collected is the net cash traders have paid the maker (trade ledger
+	// costs; sells count negative).
+	Collected decimal.Decimal `json:"collected"`
+	// PnLIfYes and PnLIfNo are the maker's profit if the market resolved
+	// now: Collected less the traded shares it must redeem.
+	PnLIfYes decimal.Decimal `json:"pnl_if_yes"`
+	PnLIfNo  decimal.Decimal `json:"pnl_if_no"`
+	// Exposure is the worse of the two outcomes, as a loss (≥ 0).
+	Exposure decimal.Decimal `json:"exposure"`
+	// SubsidyBudget is the cap on Exposure trades are held to, if the
+	// market has one.
+	SubsidyBudget *decimal.Decimal `json:"subsidy_budget,omitempty"`
+}
+
+// GetMakerReport handles GET /api/v1/markets/{marketID}/maker
+// Reports how lopsided the maker's inventory is and what each resolution
+// would cost it. Collected is the sum of the trades' costs, kept on the
+// market, rather than derived from the cost function, so liquidity changes
+// during the market's life are priced at the b each trade actually saw.
+func (s *Service) GetMakerReport(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	market, err := s.store.GetMarket(r.Context(), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	mm, err := s.marketMaker(market)
+	if err != nil {
+		s.internalError(w, r, "invalid market parameters", err)
+		return
+	}
+
+	collected := market.Collected
+	yes, no := market.TradedQuantities()
+	inventory := yes.Sub(no)
+	shortSide := ""
+	switch inventory.Sign() {
+	case 1:
+		shortSide = "YES"
+	case -1:
+		shortSide = "NO"
+	}
+
+	resp := MakerReport{
+		MarketID:  market.ID,
+		B:         mm.B(),
+		Subsidy:   mm.MaxLoss(),
+		Inventory: inventory,
+		ShortSide: shortSide,
+		Collected: collected,
+		PnLIfYes:  collected.Sub(yes),
+		PnLIfNo:   collected.Sub(no),
+		Exposure:  makerWorstCaseLoss(yes, no, collected),
+
+		SubsidyBudget: market.SubsidyBudget,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
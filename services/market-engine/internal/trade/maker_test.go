@@ -0,0 +1,76 @@
+package trade_test
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestMakerReport_YesHeavyMarketIsShortYes(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(60)},
+		{UserID: "u2", ContractID: contractID, Side: "NO", Quantity: d(10)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, rep := getJSON[trade.MakerReport](t, router, "/api/v1/markets/"+market.ID+"/maker")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if !rep.Inventory.Equal(d(50)) || rep.ShortSide != "YES" {
+		t.Fatalf("expected inventory 50 short YES, got %s %q", rep.Inventory, rep.ShortSide)
+	}
+
+	// With b = 100 and q = (60, 10), the maker collected C(q) - C(0) and
+	// owes 60 if YES wins, 10 if NO does.
+	b := 100.0
+	collected := b*math.Log(math.Exp(60/b)+math.Exp(10/b)) - b*math.Log(2)
+	if got := rep.Collected.InexactFloat64(); math.Abs(got-collected) > 1e-3 {
+		t.Errorf("collected %.4f, want %.4f", got, collected)
+	}
+	if got := rep.PnLIfYes.InexactFloat64(); math.Abs(got-(collected-60)) > 1e-3 {
+		t.Errorf("pnl if YES %.4f, want %.4f", got, collected-60)
+	}
+	if got := rep.PnLIfNo.InexactFloat64(); math.Abs(got-(collected-10)) > 1e-3 {
+		t.Errorf("pnl if NO %.4f, want %.4f", got, collected-10)
+	}
+	if !rep.PnLIfYes.IsNegative() || !rep.PnLIfNo.IsPositive() {
+		t.Errorf("expected a loss if YES wins and a gain if NO does, got %s / %s", rep.PnLIfYes, rep.PnLIfNo)
+	}
+	if !rep.Exposure.Equal(rep.PnLIfYes.Neg()) {
+		t.Errorf("exposure %s, want %s", rep.Exposure, rep.PnLIfYes.Neg())
+	}
+	if rep.Exposure.GreaterThan(rep.Subsidy) {
+		t.Errorf("exposure %s exceeds subsidy %s", rep.Exposure, rep.Subsidy)
+	}
+}
+
+func TestMakerReport_FreshMarketIsFlat(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w, rep := getJSON[trade.MakerReport](t, router, "/api/v1/markets/"+market.ID+"/maker")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !rep.Inventory.IsZero() || rep.ShortSide != "" || !rep.Exposure.IsZero() {
+		t.Errorf("expected a flat maker, got %+v", rep)
+	}
+	if got := rep.Subsidy.InexactFloat64(); math.Abs(got-100*math.Ln2) > 1e-6 {
+		t.Errorf("subsidy %.6f, want b·ln2", got)
+	}
+
+	if w, _ := getJSON[trade.MakerReport](t, router, "/api/v1/markets/missing/maker"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown market, got %d", w.Code)
+	}
+}
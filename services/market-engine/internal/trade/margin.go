@@ -0,0 +1,52 @@
+// Margin: worst-case loss of binary positions.
+
+package trade
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// payoutPerShare is what one winning share pays at settlement.
+var payoutPerShare = decimal.NewFromInt(1)
+
+// outcomeLoss is the loss on p if the market settles to outcome: the cash
+// paid in minus the payout, where only the winning side's shares pay.
+// Negative means a profit.
+func outcomeLoss(p model.Position, outcome string) decimal.Decimal {
+	winning := p.NoQty
+	if outcome == "YES" {
+		winning = p.YesQty
+	}
+	return p.CostBasis.Sub(winning.Mul(payoutPerShare))
+}
+
+// positionMaxLoss is the margin p needs: its loss under the worse of the
+// two outcomes, or zero if it profits either way.
+func positionMaxLoss(p model.Position) decimal.Decimal {
+	worst := decimal.Max(outcomeLoss(p, "YES"), outcomeLoss(p, "NO"))
+	if worst.IsNegative() {
+		return decimal.Zero
+	}
+	return worst
+}
+
+// totalMargin is the margin a set of positions needs: each position's
+// worst-case loss across settlement outcomes, summed.
+func totalMargin(positions []model.Position) decimal.Decimal {
+	total := decimal.Zero
+	for _, p := range positions {
+		total = total.Add(positionMaxLoss(p))
+	}
+	return total
+}
+
+// marginUtilization is margin as a percentage of the service's margin
+// limit, rounded to 2 places; zero when no limit is set.
+func (s *Service) marginUtilization(margin decimal.Decimal) decimal.Decimal {
+	if !s.marginLimit.IsPositive() {
+		return decimal.Zero
+	}
+	return margin.Mul(decimal.NewFromInt(100)).DivRound(s.marginLimit, 2)
+}
@@ -0,0 +1,157 @@
+// Collateral estimates for prospective portfolios.
+
+package trade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// MaxMarginEstimatePositions caps the intended positions per estimate.
+const MaxMarginEstimatePositions = 100
+
+// IntendedPosition is one trade a margin estimate prices.
+type IntendedPosition struct {
+	ContractID string          `json:"contract_id"`
+	Side       string          `json:"side"`
+	Quantity   decimal.Decimal `json:"quantity"`
+}
+
+// MarginEstimateRequest is the JSON body for POST /api/v1/margin/estimate.
+type MarginEstimateRequest struct {
+	Positions []IntendedPosition `json:"positions"`
+}
+
+// normalize puts every contract ticker in canonical form.
+func (req *MarginEstimateRequest) normalize() {
+	for i := range req.Positions {
+		req.Positions[i].ContractID = contract.NormalizeTicker(req.Positions[i].ContractID)
+	}
+}
+
+// Validate reports every problem with a margin estimate request.
+func (req MarginEstimateRequest) Validate() []FieldError {
+	rules := []rule{
+		fails("positions", len(req.Positions) == 0, "positions must not be empty"),
+		fails("positions", len(req.Positions) > MaxMarginEstimatePositions,
+			fmt.Sprintf("at most %d positions per request", MaxMarginEstimatePositions)),
+	}
+	for i, p := range req.Positions {
+		field := func(name string) string { return fmt.Sprintf("positions[%d].%s", i, name) }
+		rules = append(rules,
+			required(field("contract_id"), p.ContractID),
+			ticker(field("contract_id"), p.ContractID),
+			oneOf(field("side"), p.Side, "side must be YES or NO", "YES", "NO"),
+			quantity(field("quantity"), p.Quantity),
+		)
+	}
+	return check(rules...)
+}
+
+// MarketMarginEstimate is the position the intended trades would build in
+// one market and the margin it needs.
+type MarketMarginEstimate struct {
+	ContractID string          `json:"contract_id"`
+	MarketID   string          `json:"market_id"`
+	YesQty     decimal.Decimal `json:"yes_qty"`
+	NoQty      decimal.Decimal `json:"no_qty"`
+	Cost       decimal.Decimal `json:"cost"`   // net cash the trades would pay
+	Margin     decimal.Decimal `json:"margin"` // worst-case loss at settlement
+}
+
+// MarginEstimateResponse is the JSON body returned from
+// POST /api/v1/margin/estimate.
+type MarginEstimateResponse struct {
+	TotalMargin       decimal.Decimal        `json:"total_margin"`
+	MarginUtilization decimal.Decimal        `json:"margin_utilization"` // % of the margin limit
+	Markets           []MarketMarginEstimate `json:"markets"`
+}
+
+// EstimateMargin handles POST /api/v1/margin/estimate
+// Prices the intended trades against each market's current state, as if
+// executed in order, and returns the collateral the resulting positions
+// would need: the worst-case loss across settlement outcomes, as for a
+// portfolio. Trades in the same market net against each other, so a hedge
+// lowers the estimate. Nothing is traded or written, and existing
+// positions are not included.
+func (s *Service) EstimateMargin(w http.ResponseWriter, r *http.Request) {
+	var req MarginEstimateRequest
+	if _, ok := bind(w, r, &req); !ok {
+		return
+	}
+	ctx := r.Context()
+
+	type book struct {
+		market    *model.Market
+		qYes, qNo decimal.Decimal
+		position  model.Position
+	}
+	var books []*book
+	byContract := make(map[string]*book)
+	for i, p := range req.Positions {
+		b, ok := byContract[p.ContractID]
+		if !ok {
+			market, err := s.store.GetMarketByContract(ctx, p.ContractID)
+			if err != nil {
+				s.writeLookupError(w, r, err, "market not found for contract: "+p.ContractID)
+				return
+			}
+			if market.Status != "open" || market.HiddenAt != nil {
+				writeError(w, "market for contract "+p.ContractID+" is not open for trading", http.StatusConflict)
+				return
+			}
+			b = &book{
+				market:   market,
+				qYes:     market.QYes,
+				qNo:      market.QNo,
+				position: model.Position{MarketID: market.ID, ContractID: market.ContractID},
+			}
+			byContract[p.ContractID] = b
+			books = append(books, b)
+		}
+
+		mm, err := s.marketMaker(b.market)
+		if err != nil {
+			s.internalError(w, r, "internal error: invalid market configuration", err)
+			return
+		}
+		leg, err := priceLeg(mm, b.qYes, b.qNo, p.Side, p.Quantity)
+		if err != nil {
+			writeError(w, fmt.Sprintf("positions[%d]: %v", i, err), http.StatusUnprocessableEntity)
+			return
+		}
+		b.qYes, b.qNo = leg.newQYes, leg.newQNo
+		if p.Side == "YES" {
+			b.position.YesQty = b.position.YesQty.Add(p.Quantity)
+		} else {
+			b.position.NoQty = b.position.NoQty.Add(p.Quantity)
+		}
+		b.position.CostBasis = b.position.CostBasis.Add(leg.cost)
+	}
+
+	resp := MarginEstimateResponse{Markets: make([]MarketMarginEstimate, 0, len(books))}
+	positions := make([]model.Position, 0, len(books))
+	for _, b := range books {
+		p := b.position
+		positions = append(positions, p)
+		resp.Markets = append(resp.Markets, MarketMarginEstimate{
+			ContractID: p.ContractID,
+			MarketID:   p.MarketID,
+			YesQty:     p.YesQty,
+			NoQty:      p.NoQty,
+			Cost:       p.CostBasis,
+			Margin:     positionMaxLoss(p),
+		})
+	}
+	resp.TotalMargin = totalMargin(positions)
+	resp.MarginUtilization = s.marginUtilization(resp.TotalMargin)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,108 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func estimateMargin(t *testing.T, router chi.Router, positions []trade.IntendedPosition) (*httptest.ResponseRecorder, trade.MarginEstimateResponse) {
+	t.Helper()
+	body, _ := json.Marshal(trade.MarginEstimateRequest{Positions: positions})
+	return serveJSON[trade.MarginEstimateResponse](t, router, httptest.NewRequest("POST", "/api/v1/margin/estimate", bytes.NewReader(body)))
+}
+
+func assertNear(t *testing.T, what string, got decimal.Decimal, want float64) {
+	t.Helper()
+	if got.Sub(d(want)).Abs().GreaterThan(d(1e-6)) {
+		t.Errorf("%s = %s, want %.8f", what, got, want)
+	}
+}
+
+func TestEstimateMargin_HandComputed(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractA := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	contractB := "ATMX-872a10711-PRECIP-25MM-20250815"
+	marketA := seedMarket(t, ms, contractA, "872a1070b", 100)
+	seedMarket(t, ms, contractB, "872a10711", 100)
+
+	// With b = 100 from q = (0, 0), buying q shares of one side costs
+	// 100·ln((1 + e^(q/100)) / 2):
+	//   A YES 10 → 5.12494795, lost if NO wins.
+	//   B NO 20  → 10.49916888, lost if YES wins.
+	w, resp := estimateMargin(t, router, []trade.IntendedPosition{
+		{ContractID: contractA, Side: "YES", Quantity: d(10)},
+		{ContractID: contractB, Side: "NO", Quantity: d(20)},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	assertNear(t, "unhedged total", resp.TotalMargin, 5.12494795+10.49916888)
+	if len(resp.Markets) != 2 || resp.Markets[0].MarketID != marketA.ID {
+		t.Fatalf("unexpected markets %+v", resp.Markets)
+	}
+	assertNear(t, "A margin", resp.Markets[0].Margin, 5.12494795)
+	assertNear(t, "B margin", resp.Markets[1].Margin, 10.49916888)
+
+	// Partly hedging A with 4 NO, priced after the YES buy:
+	// 100·ln((e^0.1 + e^0.04) / (e^0.1 + 1)) = 1.92004530. YES wins pays
+	// 10 and NO wins pays 4, so A's worst case is 7.04499325 - 4.
+	_, resp = estimateMargin(t, router, []trade.IntendedPosition{
+		{ContractID: contractA, Side: "YES", Quantity: d(10)},
+		{ContractID: contractA, Side: "NO", Quantity: d(4)},
+		{ContractID: contractB, Side: "NO", Quantity: d(20)},
+	})
+	assertNear(t, "partly hedged A", resp.Markets[0].Margin, 3.04499325)
+	assertNear(t, "partly hedged total", resp.TotalMargin, 3.04499325+10.49916888)
+
+	// Fully hedging A (10 NO, 4.87505205) costs 100·ln(e^0.1) = 10 for a
+	// sure payout of 10: A needs no collateral and only B's remains.
+	_, resp = estimateMargin(t, router, []trade.IntendedPosition{
+		{ContractID: contractA, Side: "YES", Quantity: d(10)},
+		{ContractID: contractB, Side: "NO", Quantity: d(20)},
+		{ContractID: contractA, Side: "NO", Quantity: d(10)},
+	})
+	assertNear(t, "hedged A cost", resp.Markets[0].Cost, 10)
+	assertNear(t, "hedged A margin", resp.Markets[0].Margin, 0)
+	assertNear(t, "hedged total", resp.TotalMargin, 10.49916888)
+
+	// The estimate trades nothing.
+	m, _ := ms.GetMarket(context.Background(), marketA.ID)
+	if !m.QYes.IsZero() || !m.QNo.IsZero() {
+		t.Errorf("estimate moved the market: q = (%s, %s)", m.QYes, m.QNo)
+	}
+	if entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), marketA.ID); len(entries) != 0 {
+		t.Errorf("estimate wrote %d ledger entries", len(entries))
+	}
+}
+
+func TestEstimateMargin_Rejections(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	tests := []struct {
+		name      string
+		positions []trade.IntendedPosition
+		want      int
+	}{
+		{"empty", nil, http.StatusUnprocessableEntity},
+		{"bad side", []trade.IntendedPosition{{ContractID: contractID, Side: "MAYBE", Quantity: d(1)}}, http.StatusUnprocessableEntity},
+		{"zero quantity", []trade.IntendedPosition{{ContractID: contractID, Side: "YES"}}, http.StatusUnprocessableEntity},
+		{"unknown market", []trade.IntendedPosition{{ContractID: "ATMX-8729a0001-PRECIP-25MM-20250815", Side: "YES", Quantity: d(1)}}, http.StatusNotFound},
+		{"out of price bounds", []trade.IntendedPosition{{ContractID: contractID, Side: "YES", Quantity: d(1e6)}}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if w, _ := estimateMargin(t, router, tt.positions); w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
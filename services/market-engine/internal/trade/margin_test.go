@@ -0,0 +1,54 @@
+package trade
+
+import (
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+func TestPositionMaxLoss_HandComputed(t *testing.T) {
+	tests := []struct {
+		name       string
+		yes, no    float64
+		costBasis  float64
+		wantMargin float64
+	}{
+		// Lose the 6 paid if NO wins; YES wins pays 10.
+		{"long YES", 10, 0, 6, 6},
+		// Lose the 3 paid if YES wins.
+		{"long NO", 0, 10, 3, 3},
+		// YES wins: 7.2 - 10 = -2.8 (profit); NO wins: 7.2 - 4 = 3.2.
+		{"mixed YES and NO", 10, 4, 7.2, 3.2},
+		// Either outcome pays 10 against 10.5 paid.
+		{"hedged at a loss", 10, 10, 10.5, 0.5},
+		// Either outcome pays 10 against 9.8 paid: no loss possible.
+		{"hedged at a profit", 10, 10, 9.8, 0},
+		// Flat after selling for more than was paid.
+		{"closed at a profit", 0, 0, -1.5, 0},
+		// Flat after selling for less than was paid: the loss is locked in.
+		{"closed at a loss", 0, 0, 0.75, 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := model.Position{YesQty: dec(tt.yes), NoQty: dec(tt.no), CostBasis: dec(tt.costBasis)}
+			if got := positionMaxLoss(p); !got.Equal(dec(tt.wantMargin)) {
+				t.Errorf("max loss = %s, want %v", got, tt.wantMargin)
+			}
+		})
+	}
+}
+
+func TestBuildPortfolio_MarginUtilization(t *testing.T) {
+	s := &Service{marginLimit: dec(30)}
+	positions := []model.Position{
+		{MarketID: "m1", YesQty: dec(10), NoQty: dec(4), CostBasis: dec(7.2)},  // 3.2
+		{MarketID: "m2", YesQty: dec(10), CostBasis: dec(6)},                   // 6
+		{MarketID: "m3", YesQty: dec(10), NoQty: dec(10), CostBasis: dec(9.8)}, // 0
+	}
+
+	// 9.2 / 30 = 30.666…% → 30.67.
+	got := s.buildPortfolio("u1", positions).MarginUtilization
+	if !got.Equal(dec(30.67)) {
+		t.Errorf("margin utilization = %s, want 30.67", got)
+	}
+}
@@ -0,0 +1,42 @@
+// Resolving the market a trade executes against.
+
+package trade
+
+import (
+	"net/http"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// tradeMarket finds the market req trades against: the exact market when
+// market_id is given, else the one listed under contract_id. With both, the
+// market's contract must be contract_id, so a stale or mistyped ID can't
+// trade the wrong contract; req.ContractID is filled in from the market
+// when only market_id is given. It writes the error response and returns
+// false when the market can't be used.
+func (s *Service) tradeMarket(w http.ResponseWriter, r *http.Request, req *TradeRequest) (*model.Market, bool) {
+	if req.MarketID == "" {
+		found, err := s.store.GetMarketByContract(r.Context(), req.ContractID)
+		if err != nil {
+			s.writeLookupError(w, r, err, "market not found for contract: "+req.ContractID)
+			return nil, false
+		}
+		return found, true
+	}
+
+	found, err := s.store.GetMarket(r.Context(), req.MarketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found: "+req.MarketID)
+		return nil, false
+	}
+	if req.ContractID != "" && req.ContractID != found.ContractID {
+		logTradeRejection(*req, RejectInvalidRequest, "market_id", req.MarketID)
+		writeValidationErrors(w, []FieldError{{
+			Field:   "contract_id",
+			Message: "contract_id does not match market " + req.MarketID + " (" + found.ContractID + ")",
+		}})
+		return nil, false
+	}
+	req.ContractID = found.ContractID
+	return found, true
+}
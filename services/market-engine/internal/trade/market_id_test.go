@@ -0,0 +1,83 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestExecuteTrade_ByMarketID(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for name, contractID := range map[string]string{
+		"market_id only":  "",
+		"matching ticker": market.ContractID,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := doTrade(t, router, trade.TradeRequest{
+				UserID: "u-" + name, MarketID: market.ID, ContractID: contractID, Side: "YES", Quantity: d(10),
+			})
+			if w.Code != http.StatusOK {
+				t.Fatalf("trade: %d %s", w.Code, w.Body.String())
+			}
+			var resp trade.TradeResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if resp.ContractID != market.ContractID || !resp.Position.YesQty.Equal(d(10)) {
+				t.Errorf("response contract %q, yes_qty %s; want %q, 10", resp.ContractID, resp.Position.YesQty, market.ContractID)
+			}
+		})
+	}
+
+	history, err := ms.GetLedgerEntriesByMarket(context.Background(), market.ID)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("history: %v, %d entries", err, len(history))
+	}
+	for _, e := range history {
+		if e.ContractID != market.ContractID {
+			t.Errorf("ledger contract = %q, want %q", e.ContractID, market.ContractID)
+		}
+	}
+}
+
+func TestExecuteTrade_MarketIDContractMismatch(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	precip := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	temp := seedMarket(t, ms, "ATMX-872a1070b-TEMP-90F-20250815", "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", MarketID: precip.ID, ContractID: temp.ContractID, Side: "YES", Quantity: d(10),
+	})
+	if msg := fieldsOf(t, w)["contract_id"]; !strings.Contains(msg, precip.ContractID) {
+		t.Errorf("contract_id error = %q, want it to name %s", msg, precip.ContractID)
+	}
+	for _, m := range []string{precip.ID, temp.ID} {
+		if history, _ := ms.GetLedgerEntriesByMarket(context.Background(), m); len(history) != 0 {
+			t.Errorf("market %s has %d ledger entries, want none", m, len(history))
+		}
+	}
+}
+
+func TestExecuteTrade_MarketIDValidation(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{UserID: "u1", MarketID: "no-such-market", Side: "YES", Quantity: d(10)})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown market_id: %d, want 404", w.Code)
+	}
+
+	w = doTrade(t, router, trade.TradeRequest{UserID: "u1", Side: "YES", Quantity: d(10)})
+	if _, ok := fieldsOf(t, w)["contract_id"]; !ok {
+		t.Error("no contract_id or market_id: want a contract_id error")
+	}
+
+	w = doTrade(t, router, trade.TradeRequest{UserID: "u1", MarketID: "m1", ContractID: "not-a-ticker", Side: "YES", Quantity: d(10)})
+	if _, ok := fieldsOf(t, w)["contract_id"]; !ok {
+		t.Error("market_id with a malformed contract_id: want a contract_id error")
+	}
+}
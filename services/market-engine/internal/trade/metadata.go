@@ -0,0 +1,36 @@
+// Caller-supplied tags on trades.
+
+package trade
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Limits on TradeRequest.Metadata. It is stored with the ledger entry
+// and returned in every history response, so it is kept small.
+const (
+	MaxMetadataKeys     = 16
+	MaxMetadataKeyLen   = 64
+	MaxMetadataValueLen = 256
+)
+
+// metadata fails if m has more than MaxMetadataKeys keys, an empty key,
+// or a key or value over its length limit.
+func metadata(field string, m map[string]string) rule {
+	if len(m) > MaxMetadataKeys {
+		return rule{field, fmt.Sprintf("%s may have at most %d keys", field, MaxMetadataKeys)}
+	}
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		switch {
+		case k == "":
+			return rule{field, field + " keys must be non-empty"}
+		case len(k) > MaxMetadataKeyLen:
+			return rule{field, fmt.Sprintf("%s keys may be at most %d bytes", field, MaxMetadataKeyLen)}
+		case len(m[k]) > MaxMetadataValueLen:
+			return rule{field, fmt.Sprintf("%s value for %q may be at most %d bytes", field, k, MaxMetadataValueLen)}
+		}
+	}
+	return rule{field: field}
+}
@@ -0,0 +1,76 @@
+package trade_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestExecuteTrade_MetadataInHistory(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	tags := map[string]string{"source": "api", "strategy": "s-42"}
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10), Metadata: tags},
+		{UserID: "user2", ContractID: contractID, Side: "NO", Quantity: d(5)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/markets/"+market.ID+"/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var history []model.LedgerEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil || len(history) != 2 {
+		t.Fatalf("history: %v %s", err, w.Body.String())
+	}
+	if !maps.Equal(history[0].Metadata, tags) {
+		t.Errorf("metadata = %v, want %v", history[0].Metadata, tags)
+	}
+	if strings.Count(w.Body.String(), `"metadata"`) != 1 {
+		t.Errorf("expected metadata only on the tagged entry: %s", w.Body.String())
+	}
+}
+
+func TestExecuteTrade_MetadataLimits(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	tooMany := map[string]string{}
+	for i := 0; i <= trade.MaxMetadataKeys; i++ {
+		tooMany[fmt.Sprintf("k%d", i)] = "v"
+	}
+	for name, meta := range map[string]map[string]string{
+		"too many keys":  tooMany,
+		"empty key":      {"": "v"},
+		"key too long":   {strings.Repeat("k", trade.MaxMetadataKeyLen+1): "v"},
+		"value too long": {"source": strings.Repeat("v", trade.MaxMetadataValueLen+1)},
+	} {
+		w := doTrade(t, router, trade.TradeRequest{
+			UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10), Metadata: meta,
+		})
+		if _, ok := fieldsOf(t, w)["metadata"]; !ok {
+			t.Errorf("%s: expected a metadata error, got %s", name, w.Body.String())
+		}
+	}
+
+	// At the limits is fine.
+	atLimit := map[string]string{strings.Repeat("k", trade.MaxMetadataKeyLen): strings.Repeat("v", trade.MaxMetadataValueLen)}
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10), Metadata: atLimit,
+	}); w.Code != http.StatusOK {
+		t.Errorf("expected 200 at the limits, got %d: %s", w.Code, w.Body.String())
+	}
+}
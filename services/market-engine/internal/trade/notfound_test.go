@@ -0,0 +1,72 @@
+package trade_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// lookupStub fails every market lookup with err.
+type lookupStub struct {
+	*store.MemoryStore
+	err error
+}
+
+func (s lookupStub) GetMarket(context.Context, string) (*model.Market, error) {
+	return nil, s.err
+}
+
+func (s lookupStub) GetMarketByContract(context.Context, string) (*model.Market, error) {
+	return nil, s.err
+}
+
+func lookupStubRouter(err error) chi.Router {
+	st := lookupStub{MemoryStore: store.NewMemoryStore(), err: err}
+	svc := trade.NewService(st, correlation.NewPositionLimiter(d(1000), d(5000), 5), nil)
+	r := chi.NewRouter()
+	r.Get("/api/v1/markets/{marketID}", svc.GetMarket)
+	r.Get("/api/v1/markets/{marketID}/price", svc.GetPrice)
+	r.Post("/api/v1/trade", svc.ExecuteTrade)
+	return r
+}
+
+func TestStoreErrors_NotFoundVersusFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", fmt.Errorf("%w: market m1", store.ErrNotFound), http.StatusNotFound},
+		{"backend failure", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := lookupStubRouter(tc.err)
+
+			for _, path := range []string{"/api/v1/markets/m1", "/api/v1/markets/m1/price"} {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+				if w.Code != tc.want {
+					t.Errorf("GET %s: expected %d, got %d", path, tc.want, w.Code)
+				}
+			}
+
+			w := doTrade(t, router, trade.TradeRequest{
+				UserID: "u1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", Side: "YES", Quantity: d(1),
+			})
+			if w.Code != tc.want {
+				t.Errorf("POST /trade: expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
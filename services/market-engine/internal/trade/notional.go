@@ -0,0 +1,34 @@
+// Minimum trade notional.
+
+package trade
+
+import (
+	"net/http"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrCodeBelowMinNotional is returned when a trade's |cost| is below the
+// configured minimum notional.
+const ErrCodeBelowMinNotional = "below_min_notional"
+
+// WithMinNotional rejects trades whose absolute cost is below min, e.g. so
+// every trade covers its fees. Unlike a market's min_quantity this depends
+// on price: the same quantity can pass near 0.5 and fail near a bound,
+// where a share of the cheap side costs next to nothing. Zero, the
+// default, allows any cost. Closing a position is never rejected.
+func WithMinNotional(min decimal.Decimal) Option {
+	return func(s *Service) { s.minNotional = min }
+}
+
+// checkMinNotional writes a coded 409 and returns false if |cost| is below
+// the minimum notional.
+func (s *Service) checkMinNotional(w http.ResponseWriter, cost decimal.Decimal) bool {
+	if !s.minNotional.IsPositive() || cost.Abs().GreaterThanOrEqual(s.minNotional) {
+		return true
+	}
+	writeCodedError(w, ErrCodeBelowMinNotional,
+		"trade cost "+cost.Abs().StringFixed(4)+" is below the minimum notional "+s.minNotional.String(),
+		http.StatusConflict)
+	return false
+}
@@ -0,0 +1,79 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestMinNotional_SmallTradeAtMidRejected(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithMinNotional(d(5)))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// 5 YES from 0.50 costs ~2.53.
+	w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(5)})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != trade.ErrCodeBelowMinNotional {
+		t.Errorf("expected code %q, got %v", trade.ErrCodeBelowMinNotional, body)
+	}
+	after, _ := ms.GetMarket(context.Background(), market.ID)
+	if !after.QYes.IsZero() {
+		t.Errorf("market moved despite rejection: q_yes=%s", after.QYes)
+	}
+
+	// 20 YES costs ~10.25.
+	w = doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(20)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Sells are checked on |cost|: selling 5 back returns ~2.6.
+	w = doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(-5)})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected small sell to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMinNotional_DependsOnPrice(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithMinNotional(d(5)))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// q_yes - q_no = 100·ln(19) puts YES at 0.95.
+	qYes := decimal.RequireFromString("294.4439")
+	ms.UpdateMarketState(context.Background(), market.ID, qYes, decimal.Zero, d(0.95), d(0.05))
+
+	// 20 shares would clear the minimum at mid, but 20 NO near the bound
+	// cost ~1.1.
+	w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "NO", Quantity: d(20)})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for cheap side, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The expensive side costs ~19 for the same quantity.
+	w = doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(20)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for expensive side, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMinNotional_ZeroAllowsAnyCost(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(0.01)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,142 @@
+// Observation ingestion for settlement reference data.
+
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// ErrCodeObservationExists is returned when an observation for the same
+// cell, type and date is already on record.
+const ErrCodeObservationExists = "observation_exists"
+
+// observationDateLayout is the wire and storage format of observation dates.
+const observationDateLayout = "2006-01-02"
+
+// ObservationRequest is the JSON body for POST /api/v1/observations.
+type ObservationRequest struct {
+	H3Cell string          `json:"h3_cell"`
+	Type   string          `json:"type"`
+	Date   string          `json:"date"` // YYYY-MM-DD
+	Value  decimal.Decimal `json:"value"`
+	Unit   string          `json:"unit"`
+	Source string          `json:"source"`
+}
+
+// Validate reports every problem with an observation request. The unit
+// must be one the contract type accepts for thresholds, so the observation
+// can be compared with a contract's threshold.
+func (req ObservationRequest) Validate() []FieldError {
+	var errs []FieldError
+	if req.H3Cell == "" {
+		errs = append(errs, FieldError{"h3_cell", "h3_cell is required"})
+	} else if !cellPrefixRegex.MatchString(req.H3Cell) {
+		errs = append(errs, FieldError{"h3_cell", "h3_cell must be lowercase hex"})
+	}
+	if !contract.ValidType(req.Type) {
+		errs = append(errs, FieldError{"type", "type must be one of PRECIP, TEMP, WIND, SNOW"})
+	} else if !contract.ValidUnit(req.Type, req.Unit) {
+		errs = append(errs, FieldError{"unit", "unit " + req.Unit + " is not valid for type " + req.Type})
+	}
+	if _, err := time.Parse(observationDateLayout, req.Date); err != nil {
+		errs = append(errs, FieldError{"date", "date must be YYYY-MM-DD"})
+	}
+	if req.Value.IsNegative() && req.Type != contract.TypeTemp {
+		errs = append(errs, FieldError{"value", "value must be non-negative"})
+	}
+	if req.Source == "" {
+		errs = append(errs, FieldError{"source", "source is required"})
+	}
+	return errs
+}
+
+// CreateObservation handles POST /api/v1/observations (admin)
+// Records the observed value for a cell, type and date. Observations are
+// write-once: a second one for the same key is rejected with 409 so a
+// settled outcome cannot be silently changed underneath the ledger.
+func (s *Service) CreateObservation(w http.ResponseWriter, r *http.Request) {
+	var req ObservationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	obs := &model.Observation{
+		H3CellID:   req.H3Cell,
+		Type:       req.Type,
+		Date:       req.Date,
+		Value:      req.Value,
+		Unit:       req.Unit,
+		Source:     req.Source,
+		RecordedAt: s.now().UTC(),
+	}
+	if err := s.store.InsertObservation(r.Context(), obs); err != nil {
+		if errors.Is(err, store.ErrDuplicateObservation) {
+			writeCodedError(w, ErrCodeObservationExists, "observation already recorded", http.StatusConflict)
+			return
+		}
+		s.internalError(w, r, "failed to record observation", err)
+		return
+	}
+
+	slog.Info("observation recorded",
+		"h3_cell", obs.H3CellID,
+		"type", obs.Type,
+		"date", obs.Date,
+		"value", obs.Value.String(),
+		"unit", obs.Unit,
+		"source", obs.Source,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(obs)
+}
+
+// GetObservation handles GET /api/v1/observations?cell=&type=&date=
+func (s *Service) GetObservation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cell, obsType, date := q.Get("cell"), q.Get("type"), q.Get("date")
+	if cell == "" || obsType == "" || date == "" {
+		writeError(w, "cell, type and date are required", http.StatusBadRequest)
+		return
+	}
+
+	obs, err := s.store.GetObservation(r.Context(), cell, obsType, date)
+	if err != nil {
+		if errors.Is(err, store.ErrObservationNotFound) {
+			writeError(w, "observation not found", http.StatusNotFound)
+			return
+		}
+		s.internalError(w, r, "failed to load observation", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obs)
+}
+
+// SettlementObservation returns the observation a market settles against:
+// the one for its contract's cell and type on the contract date. The
+// observation's unit may differ from the contract's threshold unit.
+func (s *Service) SettlementObservation(ctx context.Context, m *model.Market) (*model.Observation, error) {
+	c, err := contract.ParseTicker(m.ContractID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetObservation(ctx, c.H3CellID, c.Type, c.ExpiryDate.Format(observationDateLayout))
+}
@@ -0,0 +1,142 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postObservation(t *testing.T, router http.Handler, req trade.ObservationRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	r := adminRequest("POST", "/api/v1/observations", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func precipObservation() trade.ObservationRequest {
+	return trade.ObservationRequest{
+		H3Cell: "872a1070b", Type: "PRECIP", Date: "2025-08-15",
+		Value: d(31.2), Unit: "MM", Source: "ASOS:KMIA",
+	}
+}
+
+func TestObservation_InsertAndGet(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	if w := postObservation(t, router, precipObservation()); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/observations?cell=872a1070b&type=PRECIP&date=2025-08-15", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var obs model.Observation
+	json.Unmarshal(w.Body.Bytes(), &obs)
+	if !obs.Value.Equal(d(31.2)) || obs.Unit != "MM" || obs.Source != "ASOS:KMIA" {
+		t.Errorf("unexpected observation: %+v", obs)
+	}
+	if obs.RecordedAt.IsZero() {
+		t.Error("recorded_at should be set")
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/observations?cell=872a1070b&type=TEMP&date=2025-08-15", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing observation, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/observations?cell=872a1070b", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without type and date, got %d", w.Code)
+	}
+}
+
+func TestObservation_DuplicateRejected(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	postObservation(t, router, precipObservation())
+
+	again := precipObservation()
+	again.Value = d(12)
+	w := postObservation(t, router, again)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != trade.ErrCodeObservationExists {
+		t.Errorf("code = %q, want %q", body["code"], trade.ErrCodeObservationExists)
+	}
+}
+
+func TestObservation_Validation(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	tests := []struct {
+		name  string
+		mod   func(*trade.ObservationRequest)
+		field string
+	}{
+		{"unit wrong for type", func(r *trade.ObservationRequest) { r.Unit = "F" }, "unit"},
+		{"unknown type", func(r *trade.ObservationRequest) { r.Type = "HAIL" }, "type"},
+		{"bad date", func(r *trade.ObservationRequest) { r.Date = "20250815" }, "date"},
+		{"non-hex cell", func(r *trade.ObservationRequest) { r.H3Cell = "KMIA" }, "h3_cell"},
+		{"negative precip", func(r *trade.ObservationRequest) { r.Value = d(-1) }, "value"},
+		{"missing source", func(r *trade.ObservationRequest) { r.Source = "" }, "source"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := precipObservation()
+			tc.mod(&req)
+			w := postObservation(t, router, req)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp trade.ValidationErrorResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != tc.field {
+				t.Errorf("expected one %s error, got %+v", tc.field, resp.Errors)
+			}
+		})
+	}
+
+	// Sub-zero temperatures are valid observations.
+	temp := trade.ObservationRequest{
+		H3Cell: "872a1070b", Type: "TEMP", Date: "2025-01-15", Value: d(-12.5), Unit: "C", Source: "ASOS:KMIA",
+	}
+	if w := postObservation(t, router, temp); w.Code != http.StatusCreated {
+		t.Errorf("expected 201 for negative TEMP, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSettlementObservation_FindsContractObservation(t *testing.T) {
+	svc, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	if _, err := svc.SettlementObservation(context.Background(), market); err == nil {
+		t.Fatal("expected an error before the observation is recorded")
+	}
+
+	postObservation(t, router, precipObservation())
+	obs, err := svc.SettlementObservation(context.Background(), market)
+	if err != nil {
+		t.Fatalf("settlement lookup: %v", err)
+	}
+	if !obs.Value.Equal(d(31.2)) || obs.Date != "2025-08-15" {
+		t.Errorf("unexpected observation: %+v", obs)
+	}
+}
@@ -0,0 +1,88 @@
+// Market open interest.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+// OpenInterestResponse is the JSON body returned from the open-interest
+// endpoint.
+type OpenInterestResponse struct {
+	MarketID   string          `json:"market_id"`
+	ContractID string          `json:"contract_id"`
+	YesShares  decimal.Decimal `json:"yes_shares"` // outstanding YES shares (QYes less the seed)
+	NoShares   decimal.Decimal `json:"no_shares"`  // outstanding NO shares (QNo less the seed)
+	// MakerNet is the market maker's net YES position: it is short every
+	// share traders hold, so a YES outcome costs it YesShares and a NO
+	// outcome NoShares. Equal to NoShares - YesShares.
+	MakerNet decimal.Decimal `json:"maker_net"`
+	// Holders is the number of users with a non-flat position.
+	Holders int `json:"holders"`
+	// Reconciled is true when the shares traded in the ledger sum to the
+	// market's stored quantities.
+	Reconciled bool `json:"reconciled"`
+}
+
+// GetOpenInterest handles GET /api/v1/markets/{marketID}/open-interest
+// Open interest is read from the market's QYes/QNo, which every trade
+// updates, less any quantities the market was seeded with, and
+// cross-checked against the ledger. Settlement entries are
+// left out of the check: they pay holders out without moving quantities.
+func (s *Service) GetOpenInterest(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	entries, err := s.store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market history", err)
+		return
+	}
+
+	tradedYes, tradedNo := decimal.Zero, decimal.Zero
+	held := make(map[string]map[string]decimal.Decimal)
+	for _, e := range entries {
+		if e.IsTrade() {
+			if e.Side == "YES" {
+				tradedYes = tradedYes.Add(e.Quantity)
+			} else {
+				tradedNo = tradedNo.Add(e.Quantity)
+			}
+		}
+		if held[e.UserID] == nil {
+			held[e.UserID] = map[string]decimal.Decimal{}
+		}
+		held[e.UserID][e.Side] = held[e.UserID][e.Side].Add(e.Quantity)
+	}
+
+	holders := 0
+	for _, sides := range held {
+		if !sides["YES"].IsZero() || !sides["NO"].IsZero() {
+			holders++
+		}
+	}
+
+	yes, no := market.TradedQuantities()
+	resp := OpenInterestResponse{
+		MarketID:   market.ID,
+		ContractID: market.ContractID,
+		YesShares:  yes,
+		NoShares:   no,
+		MakerNet:   no.Sub(yes),
+		Holders:    holders,
+		Reconciled: tradedYes.Equal(yes) && tradedNo.Equal(no),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,65 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestOpenInterest_MatchesMarketAndPositions(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(30)},
+		{UserID: "u2", ContractID: contractID, Side: "NO", Quantity: d(12)},
+		{UserID: "u3", ContractID: contractID, Side: "YES", Quantity: d(8)},
+		{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(-5)},
+		{UserID: "u3", ContractID: contractID, Side: "YES", Quantity: d(-8)}, // u3 is flat again
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, oi := getJSON[trade.OpenInterestResponse](t, router, "/api/v1/markets/"+market.ID+"/open-interest")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	ctx := context.Background()
+	stored, _ := ms.GetMarket(ctx, market.ID)
+	if !oi.YesShares.Equal(stored.QYes) || !oi.NoShares.Equal(stored.QNo) {
+		t.Errorf("open interest %s/%s, market q %s/%s", oi.YesShares, oi.NoShares, stored.QYes, stored.QNo)
+	}
+	if !oi.YesShares.Equal(d(25)) || !oi.NoShares.Equal(d(12)) || !oi.MakerNet.Equal(d(-13)) {
+		t.Errorf("got yes=%s no=%s maker_net=%s, want 25/12/-13", oi.YesShares, oi.NoShares, oi.MakerNet)
+	}
+	if oi.Holders != 2 || !oi.Reconciled {
+		t.Errorf("expected 2 holders and reconciled, got %+v", oi)
+	}
+
+	// Reconciles with the sum of every user's position.
+	sumYes, sumNo := decimal.Zero, decimal.Zero
+	for _, u := range []string{"u1", "u2", "u3"} {
+		if p, err := ms.GetUserMarketPosition(ctx, u, market.ID); err == nil {
+			sumYes, sumNo = sumYes.Add(p.YesQty), sumNo.Add(p.NoQty)
+		}
+	}
+	if !sumYes.Equal(oi.YesShares) || !sumNo.Equal(oi.NoShares) {
+		t.Errorf("positions sum to %s/%s, open interest %s/%s", sumYes, sumNo, oi.YesShares, oi.NoShares)
+	}
+}
+
+func TestOpenInterest_NotFound(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	w, _ := getJSON[map[string]string](t, router, "/api/v1/markets/missing/open-interest")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+// Realized P&L booking (average-cost or FIFO method).
+
+package trade
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// CostBasisMethod selects how the cost of shares closed by a trade is
+// measured when booking its realized P&L.
+type CostBasisMethod int
+
+const (
+	// CostBasisAverage closes shares at the position's average cost. It
+	// is the default.
+	CostBasisAverage CostBasisMethod = iota
+	// CostBasisFIFO closes the oldest lots first, at what they cost.
+	CostBasisFIFO
+)
+
+// WithCostBasisMethod sets the method used to book realized P&L on
+// trades, closes and settlements. It applies to entries written from then
+// on; entries already in the ledger keep the P&L they were booked with.
+func WithCostBasisMethod(m CostBasisMethod) Option {
+	return func(s *Service) { s.costBasis = m }
+}
+
+// sideBook is a user's open position in one side of one market: shares
+// (signed; negative = short) and the cash paid for them (signed like
+// shares). Under CostBasisFIFO it also keeps the lots making up the
+// position, oldest first. The zero value is an empty average-cost book.
+type sideBook struct {
+	method CostBasisMethod
+	shares decimal.Decimal
+	basis  decimal.Decimal
+	lots   []lot
+}
+
+// lot is shares opened by one trade and what they cost, both signed.
+type lot struct {
+	shares decimal.Decimal
+	cost   decimal.Decimal
+}
+
+// apply books a trade of qty shares costing cost and returns the P&L it
+// realizes. Only the part of a trade that reduces the open position
+// realizes P&L: proceeds minus the cost of the shares closed, by the
+// book's method. Any excess (a sell larger than a long, or vice versa)
+// opens a new position at the trade's own average price.
+func (b *sideBook) apply(qty, cost decimal.Decimal) decimal.Decimal {
+	if qty.IsZero() {
+		return decimal.Zero
+	}
+	if b.shares.IsZero() || b.shares.Sign() == qty.Sign() {
+		b.open(qty, cost)
+		return decimal.Zero
+	}
+
+	closed := decimal.Min(qty.Abs(), b.shares.Abs())
+	closingCost := cost.Mul(closed).DivRound(qty.Abs(), lmsr.PriceScale)
+	var released decimal.Decimal
+	if b.method == CostBasisFIFO {
+		released = b.closeLots(closed)
+	} else {
+		released = b.basis.Mul(closed).DivRound(b.shares.Abs(), lmsr.PriceScale)
+	}
+	realized := closingCost.Neg().Sub(released)
+
+	b.shares = b.shares.Sub(closed.Mul(decimal.NewFromInt(int64(b.shares.Sign()))))
+	b.basis = b.basis.Sub(released)
+	if b.shares.IsZero() {
+		b.basis = decimal.Zero
+		b.lots = nil
+	}
+	if excess := qty.Abs().Sub(closed); excess.IsPositive() {
+		b.open(excess.Mul(decimal.NewFromInt(int64(qty.Sign()))), cost.Sub(closingCost))
+	}
+	return realized
+}
+
+// open adds qty shares costing cost to the position.
+func (b *sideBook) open(qty, cost decimal.Decimal) {
+	b.shares = b.shares.Add(qty)
+	b.basis = b.basis.Add(cost)
+	if b.method == CostBasisFIFO {
+		b.lots = append(b.lots, lot{shares: qty, cost: cost})
+	}
+}
+
+// closeLots removes n shares from the oldest lots and returns their cost.
+// A partly closed lot keeps the rest of its cost for its remaining shares.
+func (b *sideBook) closeLots(n decimal.Decimal) decimal.Decimal {
+	released := decimal.Zero
+	for n.IsPositive() && len(b.lots) > 0 {
+		l := &b.lots[0]
+		if n.GreaterThanOrEqual(l.shares.Abs()) {
+			released = released.Add(l.cost)
+			n = n.Sub(l.shares.Abs())
+			b.lots = b.lots[1:]
+			continue
+		}
+		part := l.cost.Mul(n).DivRound(l.shares.Abs(), lmsr.PriceScale)
+		released = released.Add(part)
+		l.cost = l.cost.Sub(part)
+		l.shares = l.shares.Sub(n.Mul(decimal.NewFromInt(int64(l.shares.Sign()))))
+		n = decimal.Zero
+	}
+	return released
+}
+
+// realizedPnL replays a user's prior entries in one market under method
+// and returns the P&L realized by a new trade of qty shares of side
+// costing cost.
+func realizedPnL(method CostBasisMethod, history []model.LedgerEntry, marketID, side string, qty, cost decimal.Decimal) decimal.Decimal {
+	book := sideBook{method: method}
+	for _, e := range withoutReversed(history) {
+		if e.MarketID == marketID && e.Side == side {
+			book.apply(e.Quantity, e.Cost)
+		}
+	}
+	return book.apply(qty, cost)
+}
+
+// positionRealizedPnL returns the P&L realized by a trade of qty shares of
+// side costing cost against pos, the user's position in the market (nil if
+// they hold none). Average cost only needs the side's running totals from
+// the position; FIFO needs its lots, so it replays the user's ledger.
+func (s *Service) positionRealizedPnL(ctx context.Context, pos *model.Position, side string, qty, cost decimal.Decimal) (decimal.Decimal, error) {
+	if pos == nil {
+		return decimal.Zero, nil
+	}
+	if s.costBasis == CostBasisFIFO {
+		history, err := s.store.GetLedgerEntriesByUser(ctx, pos.UserID)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return realizedPnL(s.costBasis, history, pos.MarketID, side, qty, cost), nil
+	}
+	book := sideBook{shares: pos.YesQty, basis: pos.YesCostBasis}
+	if side == "NO" {
+		book = sideBook{shares: pos.NoQty, basis: pos.NoCostBasis}
+	}
+	return book.apply(qty, cost), nil
+}
@@ -0,0 +1,133 @@
+package trade
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(v float64) decimal.Decimal { return decimal.NewFromFloat(v) }
+
+func TestSideBook_AverageCost(t *testing.T) {
+	var b sideBook
+	// Buy 10 @ 0.40, buy 10 @ 0.60 → average 0.50.
+	b.apply(dec(10), dec(4))
+	b.apply(dec(10), dec(6))
+
+	// Sell 5 for 3.50 proceeds: realized = 3.50 - 5*0.50 = 1.00.
+	if got := b.apply(dec(-5), dec(-3.5)); !got.Equal(dec(1)) {
+		t.Errorf("expected realized 1, got %s", got)
+	}
+	if !b.shares.Equal(dec(15)) || !b.basis.Equal(dec(7.5)) {
+		t.Errorf("expected 15 shares / basis 7.5, got %s / %s", b.shares, b.basis)
+	}
+
+	// Sell the rest at a loss: 15 for 6 → realized = 6 - 7.5 = -1.5.
+	if got := b.apply(dec(-15), dec(-6)); !got.Equal(dec(-1.5)) {
+		t.Errorf("expected realized -1.5, got %s", got)
+	}
+	if !b.shares.IsZero() || !b.basis.IsZero() {
+		t.Errorf("expected flat book, got %s / %s", b.shares, b.basis)
+	}
+}
+
+func TestSideBook_FlipThroughZero(t *testing.T) {
+	var b sideBook
+	b.apply(dec(10), dec(5)) // long 10 @ 0.50
+
+	// Sell 15 for 9: 10 close at 0.60 (realize +1), 5 open short at 0.60.
+	if got := b.apply(dec(-15), dec(-9)); !got.Equal(dec(1)) {
+		t.Errorf("expected realized 1, got %s", got)
+	}
+	if !b.shares.Equal(dec(-5)) || !b.basis.Equal(dec(-3)) {
+		t.Errorf("expected short 5 / basis -3, got %s / %s", b.shares, b.basis)
+	}
+
+	// Cover the short for 2: realized = 3 - 2 = 1.
+	if got := b.apply(dec(5), dec(2)); !got.Equal(dec(1)) {
+		t.Errorf("expected realized 1 on cover, got %s", got)
+	}
+}
+
+func TestSideBook_BuysRealizeNothing(t *testing.T) {
+	var b sideBook
+	for i := 0; i < 3; i++ {
+		if got := b.apply(dec(10), dec(5)); !got.IsZero() {
+			t.Errorf("buy realized %s", got)
+		}
+	}
+}
+
+func TestSideBook_FIFOVersusAverage(t *testing.T) {
+	// Buy 10 @ 0.40, buy 10 @ 0.60, then sell 10 for 7 and the other 10
+	// for 5.
+	//   average: both sells close at 0.50 → 7-5 = 2, then 5-5 = 0.
+	//   FIFO:    the first sell closes the 0.40 lot → 7-4 = 3, the
+	//            second the 0.60 lot → 5-6 = -1.
+	// Both total 2: the method only moves P&L between the sells.
+	tests := []struct {
+		method CostBasisMethod
+		want   [2]float64
+	}{
+		{CostBasisAverage, [2]float64{2, 0}},
+		{CostBasisFIFO, [2]float64{3, -1}},
+	}
+	for _, tt := range tests {
+		b := sideBook{method: tt.method}
+		b.apply(dec(10), dec(4))
+		b.apply(dec(10), dec(6))
+		for i, proceeds := range []float64{7, 5} {
+			if got := b.apply(dec(-10), dec(-proceeds)); !got.Equal(dec(tt.want[i])) {
+				t.Errorf("method %d sell %d: realized %s, want %v", tt.method, i+1, got, tt.want[i])
+			}
+		}
+		if !b.shares.IsZero() || !b.basis.IsZero() || len(b.lots) != 0 {
+			t.Errorf("method %d: expected flat book, got %s / %s / %d lots", tt.method, b.shares, b.basis, len(b.lots))
+		}
+	}
+}
+
+func TestSideBook_FIFOPartialLot(t *testing.T) {
+	b := sideBook{method: CostBasisFIFO}
+	b.apply(dec(10), dec(4)) // 0.40
+	b.apply(dec(10), dec(6)) // 0.60
+
+	// Sell 15 for 9: all of the 0.40 lot and half the 0.60 lot, costing
+	// 4 + 3 = 7 → realized 2, leaving 5 shares costing 3.
+	if got := b.apply(dec(-15), dec(-9)); !got.Equal(dec(2)) {
+		t.Errorf("expected realized 2, got %s", got)
+	}
+	if !b.shares.Equal(dec(5)) || !b.basis.Equal(dec(3)) || len(b.lots) != 1 {
+		t.Errorf("expected 5 shares / basis 3 in one lot, got %s / %s / %d lots", b.shares, b.basis, len(b.lots))
+	}
+
+	// Sell 10 for 4: closes the last 5 (4·5/10 = 2 against 3 → -1) and
+	// opens a short of 5 at 0.40.
+	if got := b.apply(dec(-10), dec(-4)); !got.Equal(dec(-1)) {
+		t.Errorf("expected realized -1, got %s", got)
+	}
+	if !b.shares.Equal(dec(-5)) || !b.basis.Equal(dec(-2)) || len(b.lots) != 1 {
+		t.Errorf("expected short 5 / basis -2 in one lot, got %s / %s / %d lots", b.shares, b.basis, len(b.lots))
+	}
+}
+
+func TestSideBook_BasisIsCostPlusRealized(t *testing.T) {
+	// A position row carries Σ(cost + realized) per side rather than the
+	// trades themselves; a book seeded from it must book the same P&L as
+	// one that replayed every trade.
+	trades := [][2]float64{{10, 4}, {10, 6}, {-5, -3.5}, {-20, -9}, {3, 1.5}, {8, 3}}
+	var replayed sideBook
+	shares, basis := decimal.Zero, decimal.Zero
+	for i, tr := range trades {
+		seeded := sideBook{shares: shares, basis: basis}
+		want := replayed.apply(dec(tr[0]), dec(tr[1]))
+		if got := seeded.apply(dec(tr[0]), dec(tr[1])); !got.Equal(want) {
+			t.Errorf("trade %d: seeded book realized %s, replay %s", i, got, want)
+		}
+		shares = shares.Add(dec(tr[0]))
+		basis = basis.Add(dec(tr[1])).Add(want)
+		if !replayed.shares.Equal(shares) || !replayed.basis.Equal(basis) {
+			t.Errorf("trade %d: replayed book %s / %s, running totals %s / %s", i, replayed.shares, replayed.basis, shares, basis)
+		}
+	}
+}
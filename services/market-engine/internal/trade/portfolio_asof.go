@@ -0,0 +1,89 @@
+// Portfolios as of a past time.
+
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+)
+
+// getPortfolioAsOf serves GET /api/v1/portfolio/{userID}?as_of=<rfc3339>:
+// the portfolio built from only the ledger entries at or before as_of,
+// with each position marked to its market's price at that time. An as_of
+// before the user's first trade gives an empty portfolio.
+func (s *Service) getPortfolioAsOf(w http.ResponseWriter, r *http.Request, userID, spec string) {
+	asOf, err := time.Parse(time.RFC3339, spec)
+	if err != nil {
+		writeError(w, "as_of must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	positions, err := s.store.GetUserPositionsAsOf(ctx, userID, asOf)
+	if err != nil {
+		s.internalError(w, r, "failed to load positions", err)
+		return
+	}
+	for i := range positions {
+		p := &positions[i]
+		priceYes, err := s.priceYesAsOf(ctx, p.MarketID, asOf)
+		if err != nil {
+			s.internalError(w, r, "failed to reconstruct market price", err, "market", p.MarketID)
+			return
+		}
+		p.CurrentValue = priceYes.Mul(p.YesQty).Add(decimal.NewFromInt(1).Sub(priceYes).Mul(p.NoQty))
+		p.UnrealizedPnL = p.CurrentValue.Sub(p.CostBasis)
+	}
+
+	portfolio := s.buildPortfolio(userID, positions)
+	portfolio.AsOf = &asOf
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(portfolio)
+}
+
+// priceYesAsOf reconstructs a market's YES mark at asOf from its ledger:
+// the payout if it had settled by then, otherwise the LMSR price of its
+// seed quantities plus those traded up to asOf, at the b in force at that
+// time.
+func (s *Service) priceYesAsOf(ctx context.Context, marketID string, asOf time.Time) (decimal.Decimal, error) {
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if market.SettledAt != nil && !market.SettledAt.After(asOf) {
+		return market.MarkPriceYes(), nil
+	}
+
+	entries, err := s.store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	changes, err := s.store.GetLiquidityChanges(ctx, marketID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	qYes, qNo := market.InitialQYes, market.InitialQNo
+	for _, e := range entries {
+		if !e.IsTrade() || e.Timestamp.After(asOf) {
+			continue
+		}
+		if e.Side == "YES" {
+			qYes = qYes.Add(e.Quantity)
+		} else {
+			qNo = qNo.Add(e.Quantity)
+		}
+	}
+	mm, err := lmsr.NewMarketMaker(liquidityAt(market, changes)(asOf))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return mm.Price(qYes, qNo), nil
+}
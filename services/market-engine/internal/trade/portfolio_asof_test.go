@@ -0,0 +1,112 @@
+package trade_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetPortfolio_AsOf(t *testing.T) {
+	start := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	_, ms, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	trades := []trade.TradeRequest{
+		{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10)},
+		{UserID: "user1", ContractID: contractID, Side: "NO", Quantity: d(5)},
+		{UserID: "user2", ContractID: contractID, Side: "YES", Quantity: d(30)},
+	}
+	for i, tr := range trades {
+		now = start.Add(time.Duration(i+1) * time.Hour)
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade %d: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	// Between the first and second trades user1 held 10 YES, bought for
+	// 100·ln((1 + e^0.1) / 2) = 5.12494795 with the market at q = (10, 0):
+	// p = 1 / (1 + e^-0.1) = 0.52497919, not today's price.
+	asOf := start.Add(90 * time.Minute).Format(time.RFC3339)
+	w, p := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1?as_of="+asOf)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if p.AsOf == nil || p.AsOf.Format(time.RFC3339) != asOf {
+		t.Errorf("as_of = %v, want %s", p.AsOf, asOf)
+	}
+	if len(p.Positions) != 1 {
+		t.Fatalf("expected 1 position, got %d", len(p.Positions))
+	}
+	pos := p.Positions[0]
+	if !pos.YesQty.Equal(d(10)) || !pos.NoQty.IsZero() {
+		t.Errorf("position = %s YES / %s NO, want 10 / 0", pos.YesQty, pos.NoQty)
+	}
+	assertNear(t, "cost basis", pos.CostBasis, 5.12494795)
+	assertNear(t, "current value", pos.CurrentValue, 5.2497919)
+	assertNear(t, "unrealized P&L", p.TotalPnL, 5.2497919-5.12494795)
+
+	// Without as_of, both of user1's trades count and the mark is current.
+	_, current := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1")
+	if current.AsOf != nil {
+		t.Errorf("current portfolio has as_of %v", current.AsOf)
+	}
+	if len(current.Positions) != 1 || !current.Positions[0].NoQty.Equal(d(5)) {
+		t.Fatalf("unexpected current positions %+v", current.Positions)
+	}
+	if current.Positions[0].CurrentValue.Equal(pos.CurrentValue) {
+		t.Error("current portfolio should be marked at today's price")
+	}
+}
+
+func TestGetPortfolio_AsOfSeededMarket(t *testing.T) {
+	start := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	_, _, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+	market := createWithPrior(t, router, 0.7)
+
+	now = start.Add(time.Hour)
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: market.ContractID, Side: "YES", Quantity: d(10)}); w.Code != http.StatusOK {
+		t.Fatalf("trade: %d %s", w.Code, w.Body.String())
+	}
+	now = start.Add(2 * time.Hour)
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "user2", ContractID: market.ContractID, Side: "NO", Quantity: d(40)}); w.Code != http.StatusOK {
+		t.Fatalf("trade: %d %s", w.Code, w.Body.String())
+	}
+
+	// Between the trades the market stood at its seed plus user1's 10 YES.
+	mm, _ := lmsr.NewMarketMaker(d(100))
+	want := mm.Price(market.InitialQYes.Add(d(10)), market.InitialQNo)
+	asOf := start.Add(90 * time.Minute).Format(time.RFC3339)
+	_, p := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1?as_of="+asOf)
+	if len(p.Positions) != 1 {
+		t.Fatalf("expected 1 position, got %d", len(p.Positions))
+	}
+	if got := p.Positions[0].CurrentValue; !got.Equal(want.Mul(d(10))) {
+		t.Errorf("current value = %s, want 10 × %s from the seeded quantities", got, want)
+	}
+}
+
+func TestGetPortfolio_AsOfBeforeFirstTrade(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10)})
+
+	w, p := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1?as_of=2000-01-01T00:00:00Z")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(p.Positions) != 0 || !p.TotalPnL.IsZero() || !p.TotalExposure.IsZero() {
+		t.Errorf("expected an empty portfolio, got %s", w.Body.String())
+	}
+
+	if w, _ := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1?as_of=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad as_of: expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,66 @@
+// Bulk portfolio lookup for leaderboards.
+
+package trade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// MaxBulkPortfolioUsers caps the number of users per bulk portfolio request.
+const MaxBulkPortfolioUsers = 100
+
+// BulkPortfolioRequest is the JSON body for POST /api/v1/portfolios.
+type BulkPortfolioRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// Validate reports every problem with a bulk portfolio request.
+func (req BulkPortfolioRequest) Validate() []FieldError {
+	var errs []FieldError
+	switch {
+	case len(req.UserIDs) == 0:
+		errs = append(errs, FieldError{"user_ids", "user_ids must not be empty"})
+	case len(req.UserIDs) > MaxBulkPortfolioUsers:
+		errs = append(errs, FieldError{"user_ids", fmt.Sprintf("at most %d user_ids per request", MaxBulkPortfolioUsers)})
+	}
+	for _, id := range req.UserIDs {
+		if id == "" {
+			errs = append(errs, FieldError{"user_ids", "user_ids must not contain empty IDs"})
+			break
+		}
+	}
+	return errs
+}
+
+// GetPortfolios handles POST /api/v1/portfolios
+// Returns a userID → Portfolio map computed from one batched position
+// query, so a leaderboard doesn't need a request per user. Users without
+// trades get an empty portfolio, matching GET /portfolio/{userID}.
+func (s *Service) GetPortfolios(w http.ResponseWriter, r *http.Request) {
+	var req BulkPortfolioRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	byUser, err := s.store.GetPositionsByUsers(r.Context(), req.UserIDs)
+	if err != nil {
+		s.internalError(w, r, "failed to load positions", err)
+		return
+	}
+
+	portfolios := make(map[string]model.Portfolio, len(req.UserIDs))
+	for _, id := range req.UserIDs {
+		portfolios[id] = s.buildPortfolio(id, byUser[id])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(portfolios)
+}
@@ -0,0 +1,110 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postPortfolios(t *testing.T, router http.Handler, req trade.BulkPortfolioRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest("POST", "/api/v1/portfolios", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+	return w
+}
+
+func TestGetPortfolios_MatchesIndividualCalls(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	precip := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	temp := "ATMX-872a1070b-TEMP-90F-20250815"
+	seedMarket(t, ms, precip, "872a1070b", 100)
+	seedMarket(t, ms, temp, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "alice", ContractID: precip, Side: "YES", Quantity: d(20)},
+		{UserID: "bob", ContractID: precip, Side: "NO", Quantity: d(10)},
+		{UserID: "alice", ContractID: temp, Side: "NO", Quantity: d(5)},
+		{UserID: "bob", ContractID: temp, Side: "YES", Quantity: d(15)},
+		{UserID: "alice", ContractID: precip, Side: "YES", Quantity: d(-5)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	users := []string{"alice", "bob", "carol"} // carol has no trades
+	w := postPortfolios(t, router, trade.BulkPortfolioRequest{UserIDs: users})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var batched map[string]model.Portfolio
+	if err := json.Unmarshal(w.Body.Bytes(), &batched); err != nil {
+		t.Fatal(err)
+	}
+	if len(batched) != len(users) {
+		t.Fatalf("expected %d portfolios, got %d", len(users), len(batched))
+	}
+
+	for _, u := range users {
+		req := httptest.NewRequest("GET", "/api/v1/portfolio/"+u, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var single model.Portfolio
+		json.Unmarshal(w.Body.Bytes(), &single)
+
+		got := batched[u]
+		// Position order is not significant.
+		if !samePositions(got.Positions, single.Positions) {
+			t.Errorf("%s: positions differ\nbatched: %+v\nsingle:  %+v", u, got.Positions, single.Positions)
+		}
+		got.Positions, single.Positions = nil, nil
+		if !reflect.DeepEqual(got, single) {
+			t.Errorf("%s: portfolio differs\nbatched: %+v\nsingle:  %+v", u, got, single)
+		}
+	}
+}
+
+func samePositions(a, b []model.Position) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	byMarket := make(map[string]model.Position, len(a))
+	for _, p := range a {
+		byMarket[p.MarketID] = p
+	}
+	for _, p := range b {
+		if !reflect.DeepEqual(byMarket[p.MarketID], p) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetPortfolios_Validation(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	tooMany := make([]string, trade.MaxBulkPortfolioUsers+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("user%d", i)
+	}
+
+	for name, ids := range map[string][]string{
+		"empty":    {},
+		"too many": tooMany,
+		"blank id": {"alice", ""},
+	} {
+		w := postPortfolios(t, router, trade.BulkPortfolioRequest{UserIDs: ids})
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", name, w.Code)
+		}
+	}
+}
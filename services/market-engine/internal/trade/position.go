@@ -0,0 +1,27 @@
+// Single-market position lookup.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetPosition handles GET /api/v1/portfolio/{userID}/markets/{marketID}
+// Returns the user's position in one market, for trade UIs that don't need
+// the whole portfolio. 404 if the user has never traded the market.
+func (s *Service) GetPosition(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	marketID := chi.URLParam(r, "marketID")
+
+	position, err := s.store.GetUserMarketPosition(r.Context(), userID, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "no position in market")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(position)
+}
@@ -0,0 +1,143 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetPosition_Existing(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	precip := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	seedMarket(t, ms, "ATMX-872a1070b-TEMP-90F-20250815", "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u1", ContractID: precip.ContractID, Side: "YES", Quantity: d(10)},
+		{UserID: "u1", ContractID: precip.ContractID, Side: "NO", Quantity: d(4)},
+		{UserID: "u1", ContractID: "ATMX-872a1070b-TEMP-90F-20250815", Side: "YES", Quantity: d(7)},
+		{UserID: "u2", ContractID: precip.ContractID, Side: "YES", Quantity: d(3)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, p := getJSON[model.Position](t, router, "/api/v1/portfolio/u1/markets/"+precip.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if p.MarketID != precip.ID || p.UserID != "u1" {
+		t.Errorf("wrong position: %+v", p)
+	}
+	// Only u1's trades in this market count.
+	if !p.YesQty.Equal(d(10)) || !p.NoQty.Equal(d(4)) || !p.NetQty.Equal(d(6)) {
+		t.Errorf("yes=%s no=%s net=%s, want 10/4/6", p.YesQty, p.NoQty, p.NetQty)
+	}
+
+	// Matches the same market's entry in the full portfolio.
+	req := httptest.NewRequest("GET", "/api/v1/portfolio/u1", nil)
+	pw := httptest.NewRecorder()
+	router.ServeHTTP(pw, req)
+	var portfolio model.Portfolio
+	json.Unmarshal(pw.Body.Bytes(), &portfolio)
+	for _, pp := range portfolio.Positions {
+		if pp.MarketID == precip.ID && !pp.CostBasis.Equal(p.CostBasis) {
+			t.Errorf("cost basis %s differs from portfolio %s", p.CostBasis, pp.CostBasis)
+		}
+	}
+}
+
+func TestGetPosition_NoPosition(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "u2", ContractID: market.ContractID, Side: "YES", Quantity: d(3)})
+
+	if w, _ := getJSON[model.Position](t, router, "/api/v1/portfolio/u1/markets/"+market.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a user with no position, got %d", w.Code)
+	}
+}
+
+// staleRedis is a cache that never forgets: Del is dropped, as when a
+// portfolio read races a trade and re-caches the pre-trade positions
+// after the trade's invalidation.
+type staleRedis struct {
+	redis.Cmdable
+
+	mu   sync.Mutex
+	vals map[string]string
+}
+
+func (f *staleRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.vals[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (f *staleRedis) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vals[key] = fmt.Sprintf("%s", value)
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *staleRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	return redis.NewIntResult(0, nil)
+}
+
+func TestExecuteTrade_PositionIgnoresStaleCache(t *testing.T) {
+	ms := store.NewMemoryStore()
+	cached := store.NewCachedStore(ms, &staleRedis{vals: make(map[string]string)}, time.Minute)
+	svc := trade.NewService(cached, correlation.NewPositionLimiter(d(1000), d(5000), 5), nil)
+	r := chi.NewRouter()
+	r.Post("/api/v1/trade", svc.ExecuteTrade)
+	r.Get("/api/v1/portfolio/{userID}", svc.GetPortfolio)
+
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	buy := trade.TradeRequest{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(10)}
+	if w := doTrade(t, r, buy); w.Code != http.StatusOK {
+		t.Fatalf("first trade: %d %s", w.Code, w.Body.String())
+	}
+
+	// Cache the 10-share position; the next trade's invalidation is lost.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/portfolio/u1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("portfolio: %d %s", w.Code, w.Body.String())
+	}
+
+	buy.Quantity = d(5)
+	w = doTrade(t, r, buy)
+	if w.Code != http.StatusOK {
+		t.Fatalf("second trade: %d %s", w.Code, w.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.Position.YesQty.Equal(d(15)) {
+		t.Errorf("response yes_qty = %s, want 15 (including this trade)", resp.Position.YesQty)
+	}
+
+	positions, err := cached.GetUserPositions(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 1 || !positions[0].YesQty.Equal(d(10)) {
+		t.Fatalf("cached positions = %+v, want the stale 10-share position", positions)
+	}
+}
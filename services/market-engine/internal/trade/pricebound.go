@@ -0,0 +1,104 @@
+// Sizing guidance for trades rejected at the LMSR price bounds.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ErrCodePriceBoundExceeded is returned when a trade would push the price
+// past lmsr.MinPrice or lmsr.MaxPrice.
+const ErrCodePriceBoundExceeded = "price_bound_exceeded"
+
+// DefaultSuggestedQuantityScale is the decimal places max_allowed_quantity
+// is given to unless WithSuggestedQuantityScale says otherwise.
+const DefaultSuggestedQuantityScale int32 = 2
+
+// PriceBoundError is the 409 body for a trade rejected at the price
+// bounds. MaxAllowedQuantity is the largest trade of the same side and
+// direction that stays within them; it is omitted when there is none, e.g.
+// when the price is already at the bound.
+type PriceBoundError struct {
+	Error              string           `json:"error"`
+	Code               string           `json:"code"`
+	MaxAllowedQuantity *decimal.Decimal `json:"max_allowed_quantity,omitempty"`
+}
+
+// WithSuggestedQuantityScale sets the decimal places max_allowed_quantity
+// is truncated to, e.g. 0 for whole shares.
+func WithSuggestedQuantityScale(places int32) Option {
+	return func(s *Service) {
+		s.suggestedQtyScale = places
+	}
+}
+
+// maxAllowedQuantity returns the largest quantity of side, in the
+// direction of qty (buy or sell), that can trade at (qYes, qNo) without
+// breaching the price bounds, or nil if none can. It comes from the LMSR
+// inverse at the bound the trade heads towards, truncated to the
+// configured scale.
+func (s *Service) maxAllowedQuantity(mm *lmsr.MarketMaker, qYes, qNo decimal.Decimal, side string, qty decimal.Decimal) *decimal.Decimal {
+	first, second := qYes, qNo
+	if side == "NO" {
+		first, second = qNo, qYes
+	}
+	target := lmsr.MaxPrice
+	if qty.IsNegative() {
+		target = lmsr.MinPrice
+	}
+	max, err := mm.SharesForTargetPrice(first, second, target)
+	if err != nil {
+		return nil
+	}
+	max = max.Truncate(s.suggestedQtyScale)
+
+	// The inverse goes through float64, so it can land a hair past the
+	// bound; step back a unit at a time until the bound check agrees.
+	unit := decimal.New(1, -s.suggestedQtyScale)
+	if qty.IsNegative() {
+		unit = unit.Neg()
+	}
+	for range 3 {
+		if max.Sign() != qty.Sign() {
+			return nil
+		}
+		if _, err := priceLeg(mm, qYes, qNo, side, max); err == nil {
+			return &max
+		}
+		max = max.Sub(unit)
+	}
+	return nil
+}
+
+// partialQuantity returns the quantity an allow_partial trade fills: all
+// of req.Quantity if it stays within the price bounds, otherwise the most
+// that does (see maxAllowedQuantity), with partial set. If none can trade
+// the full quantity is returned, to be rejected as usual.
+func (s *Service) partialQuantity(mm *lmsr.MarketMaker, market *model.Market, req TradeRequest) (qty decimal.Decimal, partial bool) {
+	if _, err := priceLeg(mm, market.QYes, market.QNo, req.Side, req.Quantity); err == nil {
+		return req.Quantity, false
+	}
+	max := s.maxAllowedQuantity(mm, market.QYes, market.QNo, req.Side, req.Quantity)
+	if max == nil || max.IsZero() {
+		return req.Quantity, false
+	}
+	return *max, true
+}
+
+// writePriceBoundError writes the coded 409 for a price bound rejection,
+// suggesting maxQty as a size that would succeed.
+func writePriceBoundError(w http.ResponseWriter, err error, maxQty *decimal.Decimal) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(PriceBoundError{
+		Error:              err.Error(),
+		Code:               ErrCodePriceBoundExceeded,
+		MaxAllowedQuantity: maxQty,
+	})
+}
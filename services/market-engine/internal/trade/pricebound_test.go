@@ -0,0 +1,160 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestExecuteTrade_PriceBoundSuggestsMaxQuantity(t *testing.T) {
+	for _, side := range []string{"YES", "NO"} {
+		t.Run(side, func(t *testing.T) {
+			_, ms, router := newTestEnv(t)
+			contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+			market := seedMarket(t, ms, contractID, "872a1070b", 100)
+			req := trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: side, Quantity: d(900)}
+
+			w := doTrade(t, router, req)
+			if w.Code != http.StatusConflict {
+				t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+			}
+			var rejected trade.PriceBoundError
+			json.Unmarshal(w.Body.Bytes(), &rejected)
+			if rejected.Code != trade.ErrCodePriceBoundExceeded || rejected.MaxAllowedQuantity == nil {
+				t.Fatalf("expected a coded rejection with max_allowed_quantity, got %s", w.Body.String())
+			}
+			// From 50/50 the bound is b·ln(999) = 690.675... shares away,
+			// truncated to two places.
+			if !rejected.MaxAllowedQuantity.Equal(d(690.67)) {
+				t.Errorf("max_allowed_quantity = %s, want 690.67", rejected.MaxAllowedQuantity)
+			}
+
+			req.Quantity = *rejected.MaxAllowedQuantity
+			if w := doTrade(t, router, req); w.Code != http.StatusOK {
+				t.Fatalf("retry with suggested quantity: expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			m, _ := ms.GetMarket(context.Background(), market.ID)
+			mm, _ := lmsr.NewMarketMaker(m.B)
+			price := mm.Price(m.QYes, m.QNo)
+			if side == "NO" {
+				price = mm.PriceNo(m.QYes, m.QNo)
+			}
+			if price.GreaterThan(lmsr.MaxPrice) || price.LessThan(d(0.9989)) {
+				t.Errorf("%s price after retry = %s, want just inside %s", side, price, lmsr.MaxPrice)
+			}
+		})
+	}
+}
+
+func TestExecuteTrade_PriceBoundSuggestion(t *testing.T) {
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	suggestion := func(t *testing.T, opts []trade.Option, trades ...decimal.Decimal) *decimal.Decimal {
+		t.Helper()
+		_, ms, router := newTestEnv(t, opts...)
+		seedMarket(t, ms, contractID, "872a1070b", 100)
+		w := doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: trades[0]})
+		for _, qty := range trades[1:] {
+			w = doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: qty})
+		}
+		if w.Code != http.StatusConflict {
+			t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+		}
+		var rejected trade.PriceBoundError
+		json.Unmarshal(w.Body.Bytes(), &rejected)
+		return rejected.MaxAllowedQuantity
+	}
+
+	// Whole shares when configured.
+	if got := suggestion(t, []trade.Option{trade.WithSuggestedQuantityScale(0)}, d(900)); got == nil || !got.Equal(d(690)) {
+		t.Errorf("scale 0: max_allowed_quantity = %v, want 690", got)
+	}
+	// Sells are sized against the lower bound and suggested as negative.
+	if got := suggestion(t, nil, d(-900)); got == nil || !got.Equal(d(-690.67)) {
+		t.Errorf("sell: max_allowed_quantity = %v, want -690.67", got)
+	}
+	// With the price already within a hundredth of a share of the bound,
+	// no buy fits and the suggestion is left out.
+	if got := suggestion(t, nil, d(690.675), d(1)); got != nil {
+		t.Errorf("at bound: max_allowed_quantity = %s, want none", got)
+	}
+}
+
+func TestExecuteTrade_AllowPartialFillsToBound(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(900), AllowPartial: true,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.Partial || !resp.Quantity.Equal(d(690.67)) {
+		t.Errorf("got quantity %s partial %v, want 690.67 partial", resp.Quantity, resp.Partial)
+	}
+	if !resp.Position.YesQty.Equal(d(690.67)) {
+		t.Errorf("position yes_qty = %s, want 690.67", resp.Position.YesQty)
+	}
+	m, _ := ms.GetMarket(context.Background(), market.ID)
+	if m.PriceYes.GreaterThan(lmsr.MaxPrice) || m.PriceYes.LessThan(d(0.9989)) {
+		t.Errorf("price after partial fill = %s, want just inside %s", m.PriceYes, lmsr.MaxPrice)
+	}
+
+	// At the bound nothing fits, so it is rejected as without allow_partial.
+	w = doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10), AllowPartial: true,
+	})
+	if w.Code != http.StatusConflict {
+		t.Errorf("at the bound: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExecuteTrade_AllowPartialWithinBoundsFillsInFull(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: contractID, Side: "NO", Quantity: d(50), AllowPartial: true,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Partial || !resp.Quantity.Equal(d(50)) {
+		t.Errorf("got quantity %s partial %v, want 50 in full", resp.Quantity, resp.Partial)
+	}
+}
+
+func TestExecuteTrade_AllowPartialIdempotentRetry(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	req := trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(600), AllowPartial: true}
+
+	// The first fill takes the price most of the way to the bound, so the
+	// retry would be cut short; it replays the original instead.
+	first := doTradeWithKey(t, router, req, "k1")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := doTradeWithKey(t, router, req, "k1")
+	if retry.Code != http.StatusOK || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected a replay, got %d: %s", retry.Code, retry.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(retry.Body.Bytes(), &resp)
+	if !resp.Quantity.Equal(d(600)) || resp.Partial {
+		t.Errorf("replayed quantity %s partial %v, want the original 600 in full", resp.Quantity, resp.Partial)
+	}
+}
@@ -0,0 +1,88 @@
+// Bulk price lookup for dashboards.
+
+package trade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// MaxBulkPriceContracts caps the number of contracts per bulk price request.
+const MaxBulkPriceContracts = 500
+
+// BulkPriceRequest is the JSON body for POST /api/v1/prices.
+type BulkPriceRequest struct {
+	ContractIDs []string `json:"contract_ids"`
+}
+
+// Validate reports every problem with a bulk price request.
+func (req BulkPriceRequest) Validate() []FieldError {
+	var errs []FieldError
+	switch {
+	case len(req.ContractIDs) == 0:
+		errs = append(errs, FieldError{"contract_ids", "contract_ids must not be empty"})
+	case len(req.ContractIDs) > MaxBulkPriceContracts:
+		errs = append(errs, FieldError{"contract_ids", fmt.Sprintf("at most %d contract_ids per request", MaxBulkPriceContracts)})
+	}
+	for _, id := range req.ContractIDs {
+		if id == "" {
+			errs = append(errs, FieldError{"contract_ids", "contract_ids must not contain empty IDs"})
+			break
+		}
+	}
+	return errs
+}
+
+// GetPrices handles GET /api/v1/prices?contract_ids=a,b,c and
+// POST /api/v1/prices (for lists too long for a query string).
+// Returns a contractID → PriceResponse map from one store query, so a
+// dashboard doesn't need a request per market. Contracts without a market
+// are omitted rather than failing the batch.
+func (s *Service) GetPrices(w http.ResponseWriter, r *http.Request) {
+	var req BulkPriceRequest
+	if r.Method == http.MethodPost {
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+	} else if raw := r.URL.Query().Get("contract_ids"); raw != "" {
+		req.ContractIDs = strings.Split(raw, ",")
+	}
+	for i := range req.ContractIDs {
+		req.ContractIDs[i] = contract.NormalizeTicker(req.ContractIDs[i])
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	markets, err := s.store.GetMarketsByContracts(r.Context(), req.ContractIDs)
+	if err != nil {
+		s.internalError(w, r, "failed to load markets", err)
+		return
+	}
+
+	prices := make(map[string]PriceResponse, len(markets))
+	for i := range markets {
+		prices[markets[i].ContractID] = priceResponse(&markets[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prices)
+}
+
+// priceResponse renders a market's current prices as returned by the
+// single and bulk price endpoints.
+func priceResponse(market *model.Market) PriceResponse {
+	return PriceResponse{
+		Yes:    market.PriceYes.String(),
+		No:     market.PriceNo.String(),
+		Spread: decimal.NewFromInt(1).Sub(market.PriceYes).Sub(market.PriceNo).String(),
+	}
+}
@@ -0,0 +1,99 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func getPrices(t *testing.T, router chi.Router, ids ...string) map[string]trade.PriceResponse {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/prices?contract_ids="+strings.Join(ids, ","), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var prices map[string]trade.PriceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &prices); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return prices
+}
+
+func TestGetPrices_MatchesSinglePrice(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	ids := []string{
+		"ATMX-872a1070b-PRECIP-25MM-20250815",
+		"ATMX-872a1070c-PRECIP-25MM-20250815",
+		"ATMX-872a1070d-PRECIP-25MM-20250815",
+	}
+	seedMarket(t, ms, ids[0], "872a1070b", 100)
+	seedMarket(t, ms, ids[1], "872a1070c", 100)
+	seedMarket(t, ms, ids[2], "872a1070d", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: ids[1], Side: "YES", Quantity: d(40)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+	}
+
+	unknown := "ATMX-872a1070e-PRECIP-25MM-20250815"
+	prices := getPrices(t, router, append(ids, unknown)...)
+	if len(prices) != len(ids) {
+		t.Fatalf("expected %d prices, got %d: %v", len(ids), len(prices), prices)
+	}
+	if _, ok := prices[unknown]; ok {
+		t.Errorf("unknown contract %s should be omitted", unknown)
+	}
+
+	for _, id := range ids {
+		req := httptest.NewRequest("GET", "/api/v1/markets/test-market-"+id+"/price", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var single trade.PriceResponse
+		json.Unmarshal(w.Body.Bytes(), &single)
+		if prices[id] != single {
+			t.Errorf("%s: bulk %+v, single %+v", id, prices[id], single)
+		}
+	}
+	if prices[ids[1]].Yes == prices[ids[0]].Yes {
+		t.Errorf("traded market should have moved: %+v", prices[ids[1]])
+	}
+}
+
+func TestGetPrices_PostAndNormalizesTickers(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	id := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, id, "872a1070b", 100)
+
+	body, _ := json.Marshal(trade.BulkPriceRequest{ContractIDs: []string{" " + strings.ToLower(id) + " "}})
+	req := httptest.NewRequest("POST", "/api/v1/prices", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var prices map[string]trade.PriceResponse
+	json.Unmarshal(w.Body.Bytes(), &prices)
+	if _, ok := prices[id]; !ok {
+		t.Errorf("expected price for %s, got %v", id, prices)
+	}
+}
+
+func TestGetPrices_RequiresContractIDs(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/prices", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if fields := fieldsOf(t, w); fields["contract_ids"] == "" {
+		t.Errorf("expected contract_ids error, got %v", fields)
+	}
+}
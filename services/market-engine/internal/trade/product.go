@@ -0,0 +1,296 @@
+// Products: one contract template materialized across cells.
+
+package trade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// MaxProductCells caps how many markets one product may materialize.
+const MaxProductCells = 500
+
+// ProductLiquidity says how a product's markets get their b: a fixed B, or
+// one derived from an NWS forecast via contract.DeriveLiquidityAt for the
+// product's date. Neither set means the default for the product's
+// contract type.
+type ProductLiquidity struct {
+	B          decimal.Decimal           `json:"b"`
+	Forecast   *contract.NWSForecastData `json:"forecast,omitempty"`
+	BaseVolume decimal.Decimal           `json:"base_volume"` // forecast only; 0 → DefaultBaseVolume
+}
+
+// CreateProductRequest is the JSON body for POST /products. Each cell
+// becomes the market for ATMX-{cell}-{type}-{threshold}-{date}.
+type CreateProductRequest struct {
+	Type            string           `json:"type"`
+	Threshold       string           `json:"threshold"` // e.g. "25MM"
+	Date            string           `json:"date"`      // YYYYMMDD
+	Cells           []string         `json:"cells"`
+	LiquiditySource ProductLiquidity `json:"liquidity_source"`
+}
+
+// normalize puts the ticker segments in canonical form, as
+// contract.NormalizeTicker does for whole tickers.
+func (req *CreateProductRequest) normalize() {
+	req.Type = strings.ToUpper(strings.TrimSpace(req.Type))
+	req.Threshold = strings.ToUpper(strings.TrimSpace(req.Threshold))
+	req.Date = strings.TrimSpace(req.Date)
+	for i, cell := range req.Cells {
+		req.Cells[i] = strings.TrimSpace(cell)
+	}
+}
+
+// ticker returns the contract ticker for one of the product's cells.
+func (req CreateProductRequest) ticker(cell string) string {
+	return fmt.Sprintf("ATMX-%s-%s-%s-%s", cell, req.Type, req.Threshold, req.Date)
+}
+
+// Validate reports every problem with a product creation request.
+func (req CreateProductRequest) Validate() []FieldError {
+	var errs []FieldError
+	if !contract.ValidType(req.Type) {
+		errs = append(errs, FieldError{"type", "type must be one of PRECIP, TEMP, WIND, SNOW"})
+	}
+	if req.Threshold == "" {
+		errs = append(errs, FieldError{"threshold", "threshold is required"})
+	}
+	if req.Date == "" {
+		errs = append(errs, FieldError{"date", "date is required"})
+	}
+	switch {
+	case len(req.Cells) == 0:
+		errs = append(errs, FieldError{"cells", "at least one cell is required"})
+	case len(req.Cells) > MaxProductCells:
+		errs = append(errs, FieldError{"cells", fmt.Sprintf("at most %d cells per product", MaxProductCells)})
+	}
+	// Check the tickers only once the shared fields are sound, so a bad
+	// threshold is reported once rather than per cell.
+	if len(errs) == 0 {
+		seen := make(map[string]bool, len(req.Cells))
+		for _, cell := range req.Cells {
+			if seen[cell] {
+				errs = append(errs, FieldError{"cells", "duplicate cell " + cell})
+				continue
+			}
+			seen[cell] = true
+			if _, err := contract.ParseTicker(req.ticker(cell)); err != nil {
+				errs = append(errs, FieldError{"cells", err.Error()})
+				break
+			}
+		}
+	}
+
+	liq := req.LiquiditySource
+	if liq.B.IsNegative() {
+		errs = append(errs, FieldError{"liquidity_source.b", "b must be positive"})
+	}
+	if liq.Forecast != nil {
+		if liq.B.IsPositive() {
+			errs = append(errs, FieldError{"liquidity_source", "set b or forecast, not both"})
+		}
+		if liq.Forecast.Percentile75.LessThan(liq.Forecast.Percentile25) {
+			errs = append(errs, FieldError{"liquidity_source.forecast.percentile_75", "percentile_75 must be >= percentile_25"})
+		}
+		if liq.BaseVolume.IsNegative() {
+			errs = append(errs, FieldError{"liquidity_source.base_volume", "base_volume must be positive"})
+		}
+	}
+	return errs
+}
+
+// resolve returns the b every member market is created with, the source
+// it came from and, for a forecast, the inputs it was derived from;
+// fallback is used when neither b nor a forecast is given, and a
+// forecast's b is scaled for the time from now to expiry and clamped into
+// bounds.
+func (liq ProductLiquidity) resolve(fallback decimal.Decimal, bounds lmsr.LiquidityBounds, expiry, now time.Time) (decimal.Decimal, string, *model.LiquidityInputs, error) {
+	if liq.Forecast == nil {
+		if liq.B.IsPositive() {
+			return liq.B, "fixed", nil, nil
+		}
+		return fallback, "fixed", nil, nil
+	}
+	base := liq.BaseVolume
+	if base.IsZero() {
+		base = DefaultBaseVolume
+	}
+	b, err := contract.DeriveLiquidityAt(*liq.Forecast, base, bounds, expiry, now)
+	return b, "forecast", liquidityInputs(*liq.Forecast, base), err
+}
+
+// ProductResponse is the JSON body returned from the product endpoints.
+type ProductResponse struct {
+	Product model.Product  `json:"product"`
+	Markets []model.Market `json:"markets"`
+	Stats   ProductStats   `json:"stats"`
+}
+
+// ProductStats aggregates a product's member markets.
+type ProductStats struct {
+	MarketCount  int             `json:"market_count"`
+	OpenCount    int             `json:"open_count"`
+	SettledCount int             `json:"settled_count"`
+	Volume       decimal.Decimal `json:"volume"`         // Σ|quantity| traded across members
+	MeanPriceYes decimal.Decimal `json:"mean_price_yes"` // over open members; 0 if none
+}
+
+// CreateProduct handles POST /api/v1/products
+// Materializes one market per cell, all linked by the new product's ID.
+// Creation is all or nothing: if any cell's contract already has a market
+// the request fails with 409 and nothing is created.
+func (s *Service) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req CreateProductRequest
+	if _, ok := bind(w, r, &req); !ok {
+		return
+	}
+
+	// Validate checked every cell's ticker, and they share the date.
+	ticker, err := contract.ParseTicker(req.ticker(req.Cells[0]))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := s.now().UTC()
+	b, source, inputs, err := req.LiquiditySource.resolve(s.defaultB(req.Type), s.forecastBounds, ticker.ExpiryDate, now)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := lmsr.NewMarketMaker(b); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	product := &model.Product{
+		ID:              s.ids.NewID(),
+		Type:            req.Type,
+		Threshold:       req.Threshold,
+		Date:            req.Date,
+		Cells:           req.Cells,
+		LiquiditySource: source,
+		B:               b,
+		CreatedAt:       now,
+	}
+
+	half := decimal.NewFromFloat(0.5)
+	markets := make([]*model.Market, 0, len(req.Cells))
+	for _, cell := range req.Cells {
+		markets = append(markets, &model.Market{
+			ID:         s.ids.NewID(),
+			ContractID: req.ticker(cell),
+			H3CellID:   cell,
+			QYes:       decimal.Zero,
+			QNo:        decimal.Zero,
+			B:          b,
+			PriceYes:   half,
+			PriceNo:    half,
+			Status:     "open",
+			CreatedAt:  now,
+			ProductID:  &product.ID,
+
+			LiquidityInputs: inputs,
+		})
+	}
+
+	if err := s.store.CreateProduct(r.Context(), product, markets); err != nil {
+		if errors.Is(err, store.ErrAlreadyExists) {
+			writeError(w, "product "+product.ID+" or one of its markets already exists", http.StatusConflict)
+			return
+		}
+		s.internalError(w, r, "failed to create product", err)
+		return
+	}
+
+	metrics.ActiveMarkets.Add(float64(len(markets)))
+	for _, m := range markets {
+		s.emitMarketCreated(r.Context(), m)
+	}
+
+	slog.Info("product created",
+		"id", product.ID,
+		"type", product.Type,
+		"threshold", product.Threshold,
+		"date", product.Date,
+		"markets", len(markets),
+		"b", b.String(),
+	)
+
+	resp := ProductResponse{Product: *product, Markets: make([]model.Market, 0, len(markets))}
+	for _, m := range markets {
+		resp.Markets = append(resp.Markets, *m)
+	}
+	resp.Stats = ProductStats{
+		MarketCount:  len(markets),
+		OpenCount:    len(markets),
+		Volume:       decimal.Zero,
+		MeanPriceYes: half,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetProduct handles GET /api/v1/products/{productID}
+// Returns the product, its member markets and aggregate stats.
+func (s *Service) GetProduct(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productID")
+	ctx := r.Context()
+
+	product, err := s.store.GetProduct(ctx, productID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "product not found")
+		return
+	}
+	markets, err := s.store.ListProductMarkets(ctx, productID)
+	if err != nil {
+		s.internalError(w, r, "failed to load product markets", err)
+		return
+	}
+	if markets == nil {
+		markets = []model.Market{}
+	}
+
+	stats := ProductStats{MarketCount: len(markets), Volume: decimal.Zero, MeanPriceYes: decimal.Zero}
+	sumPrice := decimal.Zero
+	for _, m := range markets {
+		switch m.Status {
+		case "open":
+			stats.OpenCount++
+			sumPrice = sumPrice.Add(m.PriceYes)
+		case "settled":
+			stats.SettledCount++
+		}
+		entries, err := s.store.GetLedgerEntriesByMarket(ctx, m.ID)
+		if err != nil {
+			s.internalError(w, r, "failed to load market history", err)
+			return
+		}
+		for _, e := range entries {
+			if e.IsTrade() {
+				stats.Volume = stats.Volume.Add(e.Quantity.Abs())
+			}
+		}
+	}
+	if stats.OpenCount > 0 {
+		stats.MeanPriceYes = sumPrice.Div(decimal.NewFromInt(int64(stats.OpenCount))).Round(lmsr.PriceScale)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProductResponse{Product: *product, Markets: markets, Stats: stats})
+}
@@ -0,0 +1,128 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postProduct(t *testing.T, router http.Handler, req trade.CreateProductRequest) (*httptest.ResponseRecorder, trade.ProductResponse) {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest("POST", "/api/v1/products", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return serveJSON[trade.ProductResponse](t, router, r)
+}
+
+func TestProduct_MaterializesOneMarketPerCell(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	cells := []string{"872a1070b", "872a1070c", "872a10711"}
+
+	w, created := postProduct(t, router, trade.CreateProductRequest{
+		Type: "WIND", Threshold: "64MPH", Date: "20250915", Cells: cells,
+		LiquiditySource: trade.ProductLiquidity{B: d(250)},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if created.Product.ID == "" || created.Product.LiquiditySource != "fixed" || !created.Product.B.Equal(d(250)) {
+		t.Fatalf("unexpected product: %+v", created.Product)
+	}
+
+	ctx := context.Background()
+	for _, cell := range cells {
+		ticker := "ATMX-" + cell + "-WIND-64MPH-20250915"
+		m, err := ms.GetMarketByContract(ctx, ticker)
+		if err != nil {
+			t.Fatalf("no market for %s: %v", ticker, err)
+		}
+		if m.ProductID == nil || *m.ProductID != created.Product.ID {
+			t.Errorf("%s: product_id %v, want %s", ticker, m.ProductID, created.Product.ID)
+		}
+		if m.H3CellID != cell || !m.B.Equal(d(250)) || m.Status != "open" {
+			t.Errorf("%s: unexpected market %+v", ticker, m)
+		}
+	}
+
+	// Trade one member, then read the product back.
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: "ATMX-872a1070c-WIND-64MPH-20250915", Side: "YES", Quantity: d(10)})
+
+	w, got := getJSON[trade.ProductResponse](t, router, "/api/v1/products/"+created.Product.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(got.Markets) != 3 {
+		t.Fatalf("expected 3 member markets, got %d", len(got.Markets))
+	}
+	for i, m := range got.Markets {
+		if m.H3CellID != cells[i] {
+			t.Errorf("member %d is cell %s, want %s", i, m.H3CellID, cells[i])
+		}
+	}
+	if got.Stats.MarketCount != 3 || got.Stats.OpenCount != 3 || !got.Stats.Volume.Equal(d(10)) {
+		t.Errorf("unexpected stats: %+v", got.Stats)
+	}
+	if !got.Stats.MeanPriceYes.GreaterThan(d(0.5)) {
+		t.Errorf("expected the traded member to lift the mean price, got %s", got.Stats.MeanPriceYes)
+	}
+}
+
+func TestProduct_ForecastLiquidity(t *testing.T) {
+	_, _, router := newTestEnv(t, beforeExpiry(lmsr.ExpiryHorizon))
+	w, created := postProduct(t, router, trade.CreateProductRequest{
+		Type: "PRECIP", Threshold: "25MM", Date: "20250815", Cells: []string{"872a1070b"},
+		LiquiditySource: trade.ProductLiquidity{Forecast: &contract.NWSForecastData{
+			Percentile25: d(10), Percentile50: d(25), Percentile75: d(40),
+		}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	// IQR/median = 30/25 → b = 120 with the default base volume.
+	if created.Product.LiquiditySource != "forecast" || !created.Product.B.Equal(d(120)) {
+		t.Errorf("expected forecast-derived b = 120, got %s %s", created.Product.LiquiditySource, created.Product.B)
+	}
+}
+
+func TestProduct_ExistingContractCreatesNothing(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	seedMarket(t, ms, "ATMX-872a1070c-PRECIP-25MM-20250815", "872a1070c", 100)
+
+	w, _ := postProduct(t, router, trade.CreateProductRequest{
+		Type: "PRECIP", Threshold: "25MM", Date: "20250815", Cells: []string{"872a1070b", "872a1070c"},
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := ms.GetMarketByContract(context.Background(), "ATMX-872a1070b-PRECIP-25MM-20250815"); err == nil {
+		t.Error("expected no market for the first cell after a failed product")
+	}
+}
+
+func TestProduct_Validation(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	for name, req := range map[string]trade.CreateProductRequest{
+		"bad type":       {Type: "HAIL", Threshold: "1IN", Date: "20250815", Cells: []string{"872a1070b"}},
+		"bad unit":       {Type: "PRECIP", Threshold: "25F", Date: "20250815", Cells: []string{"872a1070b"}},
+		"no cells":       {Type: "PRECIP", Threshold: "25MM", Date: "20250815"},
+		"duplicate cell": {Type: "PRECIP", Threshold: "25MM", Date: "20250815", Cells: []string{"872a1070b", "872a1070b"}},
+		"b and forecast": {Type: "PRECIP", Threshold: "25MM", Date: "20250815", Cells: []string{"872a1070b"},
+			LiquiditySource: trade.ProductLiquidity{B: d(100), Forecast: &contract.NWSForecastData{}}},
+	} {
+		if w, _ := postProduct(t, router, req); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+
+	w, _ := getJSON[map[string]any](t, router, "/api/v1/products/missing")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown product, got %d", w.Code)
+	}
+}
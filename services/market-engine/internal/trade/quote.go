@@ -0,0 +1,114 @@
+// Two-sided LMSR quotes.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+)
+
+// QuoteResponse is the JSON body returned from the quote endpoint: what
+// buying and selling the same quantity of one side would fill at now.
+//
+// Spread is BuyFill - SellFill. LMSR prices are path-dependent, so it is
+// the per-share cost of a round trip (buy then sell straight back) rather
+// than a spread set by the maker, and it narrows as b grows.
+type QuoteResponse struct {
+	MarketID     string          `json:"market_id"`
+	Side         string          `json:"side"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Price        decimal.Decimal `json:"price"` // current marginal price of Side
+	BuyFill      decimal.Decimal `json:"buy_fill"`
+	BuyCost      decimal.Decimal `json:"buy_cost"`
+	SellFill     decimal.Decimal `json:"sell_fill"`
+	SellProceeds decimal.Decimal `json:"sell_proceeds"`
+	Spread       decimal.Decimal `json:"spread"`
+}
+
+// GetQuote handles GET /api/v1/markets/{marketID}/quote?qty=10&side=YES
+// Prices buying and selling qty shares of side (YES by default) from the
+// current state without trading. A quantity whose buy or sell would take
+// the price out of LMSR bounds is rejected with 422.
+//
+// include_pending=true, pricing in resting orders, is refused with 501
+// until resting orders exist; false is the LMSR-only quote.
+func (s *Service) GetQuote(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+
+	if v := r.URL.Query().Get("include_pending"); v != "" {
+		pending, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, "include_pending must be true or false", http.StatusBadRequest)
+			return
+		}
+		if pending {
+			writeError(w, "include_pending is not supported until resting orders exist", http.StatusNotImplemented)
+			return
+		}
+	}
+
+	qty, err := decimal.NewFromString(r.URL.Query().Get("qty"))
+	if err != nil || !qty.IsPositive() {
+		writeError(w, "qty must be a positive number", http.StatusBadRequest)
+		return
+	}
+	if qty.GreaterThan(lmsr.MaxQuantity) {
+		writeError(w, "qty must be at most "+lmsr.MaxQuantity.String(), http.StatusBadRequest)
+		return
+	}
+	side := r.URL.Query().Get("side")
+	if side == "" {
+		side = "YES"
+	}
+	if side != "YES" && side != "NO" {
+		writeError(w, "side must be YES or NO", http.StatusBadRequest)
+		return
+	}
+
+	market, err := s.store.GetMarket(r.Context(), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	mm, err := s.marketMaker(market)
+	if err != nil {
+		s.internalError(w, r, "internal error: invalid market configuration", err)
+		return
+	}
+
+	buy, err := priceLeg(mm, market.QYes, market.QNo, side, qty)
+	if err != nil {
+		writeError(w, "cannot quote buy: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	sell, err := priceLeg(mm, market.QYes, market.QNo, side, qty.Neg())
+	if err != nil {
+		writeError(w, "cannot quote sell: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	price := mm.Price(market.QYes, market.QNo)
+	if side == "NO" {
+		price = mm.PriceNo(market.QYes, market.QNo)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(QuoteResponse{
+		MarketID:     market.ID,
+		Side:         side,
+		Quantity:     qty,
+		Price:        price,
+		BuyFill:      buy.fillPrice,
+		BuyCost:      buy.cost,
+		SellFill:     sell.fillPrice,
+		SellProceeds: sell.cost.Neg(),
+		Spread:       buy.fillPrice.Sub(sell.fillPrice),
+	})
+}
@@ -0,0 +1,101 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestGetQuote_BuyCostsMoreThanSellPays(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for _, side := range []string{"YES", "NO"} {
+		w, q := getJSON[trade.QuoteResponse](t, router, "/api/v1/markets/"+market.ID+"/quote?qty=10&side="+side)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", side, w.Code, w.Body.String())
+		}
+		if q.Side != side || !q.Quantity.Equal(d(10)) {
+			t.Errorf("%s: echoed side %s quantity %s", side, q.Side, q.Quantity)
+		}
+		if !q.BuyCost.GreaterThan(q.SellProceeds) {
+			t.Errorf("%s: buy cost %s should exceed sell proceeds %s", side, q.BuyCost, q.SellProceeds)
+		}
+		if !q.BuyFill.GreaterThan(q.Price) || !q.SellFill.LessThan(q.Price) {
+			t.Errorf("%s: fills %s/%s should straddle price %s", side, q.BuyFill, q.SellFill, q.Price)
+		}
+		if !q.Spread.Equal(q.BuyFill.Sub(q.SellFill)) || !q.Spread.IsPositive() {
+			t.Errorf("%s: spread %s", side, q.Spread)
+		}
+	}
+
+	// Quoting doesn't trade.
+	after, _ := ms.GetMarket(context.Background(), market.ID)
+	if !after.QYes.IsZero() || !after.QNo.IsZero() {
+		t.Errorf("quote moved the market to %s/%s", after.QYes, after.QNo)
+	}
+}
+
+func TestGetQuote_SpreadShrinksWithLiquidity(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+
+	var prev trade.QuoteResponse
+	for i, b := range []float64{50, 100, 500, 5000} {
+		contractID := "ATMX-872a1070b-PRECIP-25MM-2025081" + string(rune('1'+i))
+		market := seedMarket(t, ms, contractID, "872a1070b", b)
+		w, q := getJSON[trade.QuoteResponse](t, router, "/api/v1/markets/"+market.ID+"/quote?qty=10")
+		if w.Code != http.StatusOK {
+			t.Fatalf("b=%v: expected 200, got %d: %s", b, w.Code, w.Body.String())
+		}
+		if i > 0 && !q.Spread.LessThan(prev.Spread) {
+			t.Errorf("b=%v: spread %s should be below %s", b, q.Spread, prev.Spread)
+		}
+		prev = q
+	}
+}
+
+func TestGetQuote_Validation(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 10)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?qty=0", http.StatusBadRequest},
+		{"?qty=abc", http.StatusBadRequest},
+		{"?qty=10&side=MAYBE", http.StatusBadRequest},
+		{"?qty=1000", http.StatusUnprocessableEntity}, // beyond the price bounds at b=10
+	}
+	for _, tt := range tests {
+		if w, _ := getJSON[trade.QuoteResponse](t, router, "/api/v1/markets/"+market.ID+"/quote"+tt.query); w.Code != tt.want {
+			t.Errorf("%q: expected %d, got %d: %s", tt.query, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if w, _ := getJSON[trade.QuoteResponse](t, router, "/api/v1/markets/no-such-market/quote?qty=10"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown market: expected 404, got %d", w.Code)
+	}
+}
+
+func TestGetQuote_IncludePendingNotSupported(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	path := "/api/v1/markets/" + market.ID + "/quote?qty=10&include_pending="
+
+	// There are no resting orders to price in, so asking for them is an
+	// error rather than a plain LMSR quote.
+	w, _ := getJSON[trade.QuoteResponse](t, router, path+"true")
+	if w.Code != http.StatusNotImplemented || !strings.Contains(w.Body.String(), "resting orders") {
+		t.Errorf("include_pending=true: expected 501 naming resting orders, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, q := getJSON[trade.QuoteResponse](t, router, path+"false"); w.Code != http.StatusOK || !q.Quantity.Equal(d(10)) {
+		t.Errorf("include_pending=false: expected the LMSR quote, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, _ := getJSON[trade.QuoteResponse](t, router, path+"maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("include_pending=maybe: expected 400, got %d", w.Code)
+	}
+}
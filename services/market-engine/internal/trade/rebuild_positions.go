@@ -0,0 +1,45 @@
+// Repairing a user's derived positions.
+
+package trade
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// RebuildPositionsResponse is the JSON body returned from
+// POST /api/v1/admin/users/{userID}/rebuild-positions.
+type RebuildPositionsResponse struct {
+	UserID    string           `json:"user_id"`
+	Positions []model.Position `json:"positions"`
+}
+
+// RebuildPositions handles POST /api/v1/admin/users/{userID}/rebuild-positions
+// Recomputes the user's positions from the ledger on the primary and
+// overwrites the position index or cache with them, for when that copy has
+// drifted. It returns the recomputed positions. The ledger is unchanged,
+// so repeating the call is harmless.
+func (s *Service) RebuildPositions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	positions, err := s.store.RebuildUserPositions(r.Context(), userID)
+	if err != nil {
+		s.internalError(w, r, "failed to rebuild positions", err)
+		return
+	}
+	if positions == nil {
+		positions = []model.Position{}
+	}
+
+	slog.Info("positions rebuilt", "user", userID, "positions", len(positions))
+	s.audit(r, AuditActionRebuildPositions, userID, map[string]string{"positions": strconv.Itoa(len(positions))})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RebuildPositionsResponse{UserID: userID, Positions: positions})
+}
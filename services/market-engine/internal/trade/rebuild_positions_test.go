@@ -0,0 +1,70 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestRebuildPositions_RestoresCorruptCache(t *testing.T) {
+	ms := store.NewMemoryStore()
+	rdb := &staleRedis{vals: make(map[string]string)}
+	svc := trade.NewService(store.NewCachedStore(ms, rdb, time.Minute), correlation.NewPositionLimiter(d(1000), d(5000), 5), nil)
+	r := chi.NewRouter()
+	r.Post("/api/v1/trade", svc.ExecuteTrade)
+	r.Get("/api/v1/portfolio/{userID}", svc.GetPortfolio)
+	r.With(trade.RequireAdmin(testAdminToken)).Post("/api/v1/admin/users/{userID}/rebuild-positions", svc.RebuildPositions)
+
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	if w := doTrade(t, r, trade.TradeRequest{UserID: "u1", ContractID: market.ContractID, Side: "YES", Quantity: d(10)}); w.Code != http.StatusOK {
+		t.Fatalf("trade: %d %s", w.Code, w.Body.String())
+	}
+
+	portfolio := func() model.Portfolio {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/portfolio/u1", nil))
+		var p model.Portfolio
+		json.Unmarshal(w.Body.Bytes(), &p)
+		return p
+	}
+
+	rdb.Set(context.Background(), "positions:u1", []byte(`[{"market_id":"`+market.ID+`","yes_qty":"999","net_qty":"999"}]`), 0)
+	if p := portfolio(); len(p.Positions) != 1 || !p.Positions[0].YesQty.Equal(d(999)) {
+		t.Fatalf("portfolio before rebuild = %+v, want the corrupt cache entry", p.Positions)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, adminRequest("POST", "/api/v1/admin/users/u1/rebuild-positions", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rebuild: %d %s", w.Code, w.Body.String())
+	}
+	var resp trade.RebuildPositionsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.UserID != "u1" || len(resp.Positions) != 1 || !resp.Positions[0].YesQty.Equal(d(10)) {
+		t.Errorf("rebuild response = %+v, want 10 YES for u1", resp)
+	}
+
+	if p := portfolio(); len(p.Positions) != 1 || !p.Positions[0].YesQty.Equal(d(10)) {
+		t.Errorf("portfolio after rebuild = %+v, want 10 YES", p.Positions)
+	}
+}
+
+func TestRebuildPositions_RequiresAdmin(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/users/u1/rebuild-positions", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+// Logging and metrics for rejected trades.
+
+package trade
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/metrics"
+)
+
+// Rejection reasons logged for trades turned away by ExecuteTrade, and
+// the reason label on metrics.TradeRejections. Checks that already return
+// an error code (trading hours, trade size, circuit breaker) log under
+// that code.
+const (
+	RejectInvalidRequest  = "invalid_request"
+	RejectMarketClosed    = "market_closed"
+	RejectMarketHidden    = "market_hidden"
+	RejectPerCellLimit    = "per_cell"
+	RejectCorrelatedLimit = "correlated"
+	RejectCorrelatedCells = "correlated_cells"
+	RejectPriceBound      = "price_bound"
+	RejectSlippage        = "slippage"
+)
+
+// positionLimitReason maps a limiter error to its rejection reason.
+func positionLimitReason(err error) string {
+	switch {
+	case errors.Is(err, correlation.ErrCorrelatedLimitExceeded):
+		return RejectCorrelatedLimit
+	case errors.Is(err, correlation.ErrCorrelatedCellsExceeded):
+		return RejectCorrelatedCells
+	}
+	return RejectPerCellLimit
+}
+
+// logTradeRejection records a rejected trade with its reason so operators
+// can see why volume is being turned away. attrs add reason-specific
+// detail as slog key/value pairs.
+func logTradeRejection(req TradeRequest, reason string, attrs ...any) {
+	metrics.TradeRejections.WithLabelValues(reason).Inc()
+	slog.Warn("trade rejected", append([]any{
+		"user", req.UserID,
+		"contract", req.ContractID,
+		"side", req.Side,
+		"quantity", req.Quantity.String(),
+		"reason", reason,
+	}, attrs...)...)
+}
@@ -0,0 +1,136 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// captureLogs routes the default slog logger to a JSON buffer for the
+// duration of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// logRecords decodes every record with the given message.
+func logRecords(t *testing.T, buf *bytes.Buffer, msg string) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		if rec["msg"] == msg {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+func TestTradeRejection_PositionLimitLogged(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	before := testutil.ToFloat64(metrics.TradeRejections.WithLabelValues(trade.RejectPerCellLimit))
+	logs := captureLogs(t)
+
+	// Exceeds the per-cell limit of 1000.
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(1001),
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	records := logRecords(t, logs, "trade rejected")
+	if len(records) != 1 {
+		t.Fatalf("expected one rejection log, got %d: %s", len(records), logs.String())
+	}
+	rec := records[0]
+	want := map[string]any{
+		"level":    "WARN",
+		"user":     "u1",
+		"contract": contractID,
+		"side":     "YES",
+		"quantity": "1001",
+		"reason":   trade.RejectPerCellLimit,
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %v, want %v", k, rec[k], v)
+		}
+	}
+	if rec["err"] == nil {
+		t.Error("expected the limiter error in the log")
+	}
+
+	if got := testutil.ToFloat64(metrics.TradeRejections.WithLabelValues(trade.RejectPerCellLimit)); got != before+1 {
+		t.Errorf("rejection metric = %v, want %v", got, before+1)
+	}
+}
+
+func TestTradeRejection_SuccessNotLogged(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	logs := captureLogs(t)
+
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(5)}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if records := logRecords(t, logs, "trade rejected"); len(records) != 0 {
+		t.Errorf("expected no rejection logs, got %v", records)
+	}
+}
+
+func TestTradeRejection_MetricPartitionedByReason(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	perCell := metrics.TradeRejections.WithLabelValues(trade.RejectPerCellLimit)
+	slippage := metrics.TradeRejections.WithLabelValues(trade.RejectSlippage)
+	beforeCell, beforeSlip := testutil.ToFloat64(perCell), testutil.ToFloat64(slippage)
+
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(1001)}); w.Code != http.StatusConflict {
+		t.Fatalf("expected a per-cell rejection, got %d", w.Code)
+	}
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(50), MaxFillPrice: price(0.51),
+	}); w.Code != http.StatusConflict {
+		t.Fatalf("expected a slippage rejection, got %d", w.Code)
+	}
+
+	if got := testutil.ToFloat64(perCell); got != beforeCell+1 {
+		t.Errorf("per_cell = %v, want %v", got, beforeCell+1)
+	}
+	if got := testutil.ToFloat64(slippage); got != beforeSlip+1 {
+		t.Errorf("slippage = %v, want %v", got, beforeSlip+1)
+	}
+
+	// Both series are exposed on the scrape endpoint.
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, reason := range []string{trade.RejectPerCellLimit, trade.RejectSlippage} {
+		series := `atmx_trade_rejections_total{reason="` + reason + `"}`
+		if !strings.Contains(w.Body.String(), series) {
+			t.Errorf("scrape is missing %s", series)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Re-deriving a market's liquidity from fresh NWS data.
+
+package trade
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// DefaultBaseVolume scales forecast uncertainty into b when a reliquify
+// request doesn't specify one.
+var DefaultBaseVolume = decimal.NewFromInt(100)
+
+// WithForecastLiquidityBounds sets the range b derived from an NWS
+// forecast is clamped into, for reliquify and forecast-backed products.
+// The default is lmsr.DefaultLiquidityBounds.
+func WithForecastLiquidityBounds(bounds lmsr.LiquidityBounds) Option {
+	return func(s *Service) { s.forecastBounds = bounds }
+}
+
+// ReliquifyRequest is the JSON body for POST /markets/{marketID}/reliquify:
+// the latest NWS percentiles and an optional base volume.
+type ReliquifyRequest struct {
+	contract.NWSForecastData
+	BaseVolume decimal.Decimal `json:"base_volume"` // 0 → DefaultBaseVolume
+}
+
+// Validate reports every problem with a reliquify request.
+func (req ReliquifyRequest) Validate() []FieldError {
+	var errs []FieldError
+	if req.Percentile75.LessThan(req.Percentile25) {
+		errs = append(errs, FieldError{"percentile_75", "percentile_75 must be >= percentile_25"})
+	}
+	if req.Percentile50.IsNegative() {
+		errs = append(errs, FieldError{"percentile_50", "percentile_50 must be non-negative"})
+	}
+	if req.BaseVolume.IsNegative() {
+		errs = append(errs, FieldError{"base_volume", "base_volume must be positive"})
+	}
+	return errs
+}
+
+// ReliquifyResponse is the JSON body returned from the reliquify endpoint.
+type ReliquifyResponse struct {
+	MarketID  string          `json:"market_id"`
+	OldB      decimal.Decimal `json:"old_b"`
+	NewB      decimal.Decimal `json:"new_b"`
+	PriceYes  decimal.Decimal `json:"price_yes"`
+	PriceNo   decimal.Decimal `json:"price_no"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// Reliquify handles POST /api/v1/markets/{marketID}/reliquify (admin)
+// Recomputes b from the supplied forecast via contract.DeriveLiquidityAt,
+// scaled for the time left to the contract's expiry, and reprices the
+// market at its existing quantities. The change is recorded
+// so the ledger can still be verified against the b each trade saw.
+// Markets with a liquidity schedule are rejected: their b is managed by
+// the schedule.
+func (s *Service) Reliquify(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	var req ReliquifyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	base := req.BaseVolume
+	if base.IsZero() {
+		base = DefaultBaseVolume
+	}
+
+	_, unlock, ok := s.lockMarket(w, r, marketID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if market.Status != "open" {
+		writeError(w, "market is already settled", http.StatusConflict)
+		return
+	}
+	if market.BStart != nil {
+		writeError(w, "market liquidity follows a schedule", http.StatusConflict)
+		return
+	}
+
+	ticker, err := contract.ParseTicker(market.ContractID)
+	if err != nil {
+		s.internalError(w, r, "invalid market contract", err)
+		return
+	}
+	newB, err := contract.DeriveLiquidityAt(req.NWSForecastData, base, s.forecastBounds, ticker.ExpiryDate, s.now())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mm, err := lmsr.NewMarketMaker(newB)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	change := &model.LiquidityChange{
+		MarketID:  market.ID,
+		OldB:      market.B,
+		NewB:      newB,
+		ChangedAt: s.now().UTC(),
+		Inputs:    liquidityInputs(req.NWSForecastData, base),
+	}
+	priceYes := mm.Price(market.QYes, market.QNo)
+	priceNo := mm.PriceNo(market.QYes, market.QNo)
+	if err := s.store.UpdateLiquidity(ctx, change, priceYes, priceNo); err != nil {
+		s.internalError(w, r, "failed to update liquidity", err)
+		return
+	}
+
+	slog.Info("market reliquified",
+		"market", market.ID,
+		"old_b", change.OldB.String(),
+		"new_b", change.NewB.String(),
+		"price_yes", priceYes.String(),
+	)
+	s.audit(r, AuditActionReliquify, market.ID, map[string]string{
+		"old_b": change.OldB.String(),
+		"new_b": change.NewB.String(),
+	})
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(WSMessage{
+			Type:       "liquidity_updated",
+			MarketID:   market.ID,
+			ContractID: market.ContractID,
+			H3CellID:   market.H3CellID,
+			PriceYes:   priceYes.String(),
+			PriceNo:    priceNo.String(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReliquifyResponse{
+		MarketID:  market.ID,
+		OldB:      change.OldB,
+		NewB:      change.NewB,
+		PriceYes:  priceYes,
+		PriceNo:   priceNo,
+		ChangedAt: change.ChangedAt,
+	})
+}
@@ -0,0 +1,124 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postReliquify(t *testing.T, router http.Handler, marketID string, req trade.ReliquifyRequest) (*httptest.ResponseRecorder, trade.ReliquifyResponse) {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	r := adminRequest("POST", "/api/v1/markets/"+marketID+"/reliquify", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return serveJSON[trade.ReliquifyResponse](t, router, r)
+}
+
+func forecast(p25, p50, p75 float64) trade.ReliquifyRequest {
+	return trade.ReliquifyRequest{NWSForecastData: contract.NWSForecastData{
+		Percentile25: d(p25), Percentile50: d(p50), Percentile75: d(p75),
+	}}
+}
+
+// beforeExpiry sets the clock to the given time before the test contracts'
+// 20250815 expiry. A week or more out, forecast-derived b is unscaled.
+func beforeExpiry(ahead time.Duration) trade.Option {
+	expiry := time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+	return trade.WithClock(func() time.Time { return expiry.Add(-ahead) })
+}
+
+func TestReliquify_ForecastWidthMovesB(t *testing.T) {
+	_, ms, router := newTestEnv(t, beforeExpiry(lmsr.ExpiryHorizon))
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(20)})
+
+	// Wide CI: IQR/median = 30/25 → b = 120.
+	w, resp := postReliquify(t, router, market.ID, forecast(10, 25, 40))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.OldB.Equal(d(100)) || !resp.NewB.GreaterThan(d(100)) {
+		t.Errorf("wide forecast: b %s → %s, want an increase", resp.OldB, resp.NewB)
+	}
+
+	// Prices are recomputed from the existing quantities with the new b.
+	mm, _ := lmsr.NewMarketMaker(resp.NewB)
+	m, _ := ms.GetMarket(context.Background(), market.ID)
+	if !m.B.Equal(resp.NewB) || !m.PriceYes.Equal(mm.Price(d(20), d(0))) {
+		t.Errorf("market b=%s price_yes=%s, want b=%s price_yes=%s", m.B, m.PriceYes, resp.NewB, mm.Price(d(20), d(0)))
+	}
+
+	// Narrow CI: 10/25 → b = 40.
+	_, resp = postReliquify(t, router, market.ID, forecast(20, 25, 30))
+	if !resp.NewB.LessThan(resp.OldB) {
+		t.Errorf("narrow forecast: b %s → %s, want a decrease", resp.OldB, resp.NewB)
+	}
+
+	changes, _ := ms.GetLiquidityChanges(context.Background(), market.ID)
+	if len(changes) != 2 || changes[0].ChangedAt.IsZero() {
+		t.Fatalf("expected 2 timestamped liquidity changes, got %+v", changes)
+	}
+}
+
+func TestReliquify_NearExpiryScalesB(t *testing.T) {
+	_, ms, router := newTestEnv(t, beforeExpiry(24*time.Hour))
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// IQR/median = 30/25 gives b = 120 a week out; a day out it is scaled
+	// by sqrt(1/7).
+	w, resp := postReliquify(t, router, market.ID, forecast(10, 25, 40))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := d(120).Mul(lmsr.ExpiryFactor(time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 8, 14, 0, 0, 0, 0, time.UTC)))
+	if !resp.NewB.Equal(want) {
+		t.Errorf("b = %s, want %s", resp.NewB, want)
+	}
+}
+
+func TestReliquify_LedgerStillVerifies(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(20)})
+	postReliquify(t, router, market.ID, forecast(10, 25, 40))
+	doTrade(t, router, trade.TradeRequest{UserID: "u2", ContractID: contractID, Side: "NO", Quantity: d(15)})
+
+	if w, resp := postVerify(t, router, market.ID); w.Code != http.StatusOK || !resp.OK {
+		t.Errorf("verify after reliquify: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReliquify_Rejections(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	if w, _ := postReliquify(t, router, market.ID, forecast(30, 25, 20)); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("inverted percentiles: expected 422, got %d", w.Code)
+	}
+	if w, _ := postReliquify(t, router, "missing", forecast(10, 25, 40)); w.Code != http.StatusNotFound {
+		t.Errorf("unknown market: expected 404, got %d", w.Code)
+	}
+
+	body, _ := json.Marshal(forecast(10, 25, 40))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets/"+market.ID+"/reliquify", bytes.NewReader(body)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without admin token: expected 401, got %d", w.Code)
+	}
+
+	ms.SettleMarket(context.Background(), market.ID, "YES", market.CreatedAt, nil)
+	if w, _ := postReliquify(t, router, market.ID, forecast(10, 25, 40)); w.Code != http.StatusConflict {
+		t.Errorf("settled market: expected 409, got %d", w.Code)
+	}
+}
@@ -0,0 +1,23 @@
+// Serving read-only requests from a read replica.
+
+package trade
+
+import (
+	"net/http"
+
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// ReplicaReads lets GET and HEAD requests read from the store's read
+// replica, if it has one (see store.NewPostgresStoreWithReplica). Those
+// handlers only read, so replication lag can make a response slightly
+// stale but never feeds a write; trades, settlement and every other
+// write path keep reading the primary.
+func ReplicaReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			r = r.WithContext(store.WithReplicaReads(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
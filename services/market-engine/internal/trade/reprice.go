@@ -0,0 +1,105 @@
+// Re-deriving stored prices from stored quantities.
+
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+// RepriceTolerance is the largest difference between a stored price and
+// the LMSR price for the stored quantities that RepriceMarket leaves alone.
+var RepriceTolerance = decimal.New(1, -6)
+
+// RepriceResult is the JSON body returned from the reprice endpoint.
+type RepriceResult struct {
+	MarketID    string          `json:"market_id"`
+	OldPriceYes decimal.Decimal `json:"old_price_yes"`
+	OldPriceNo  decimal.Decimal `json:"old_price_no"`
+	PriceYes    decimal.Decimal `json:"price_yes"`
+	PriceNo     decimal.Decimal `json:"price_no"`
+	Drift       decimal.Decimal `json:"drift"`   // max |stored - recomputed|
+	Updated     bool            `json:"updated"` // false if within tolerance
+}
+
+// RepriceMarket recomputes a market's prices from its stored QYes/QNo and
+// effective b, and persists them if either drifts from the stored price
+// by more than RepriceTolerance. It is for markets whose quantities were
+// imported without prices; unlike verify it trusts the stored quantities
+// rather than replaying the ledger.
+func (s *Service) RepriceMarket(ctx context.Context, marketID string) (*RepriceResult, error) {
+	_, unlock, err := s.locker.Lock(ctx, marketID)
+	if err != nil {
+		return nil, fmt.Errorf("lock market %s: %w", marketID, err)
+	}
+	defer unlock()
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+	mm, err := s.marketMaker(market)
+	if err != nil {
+		return nil, fmt.Errorf("market %s: %w", marketID, err)
+	}
+
+	res := &RepriceResult{
+		MarketID:    market.ID,
+		OldPriceYes: market.PriceYes,
+		OldPriceNo:  market.PriceNo,
+		PriceYes:    mm.Price(market.QYes, market.QNo),
+		PriceNo:     mm.PriceNo(market.QYes, market.QNo),
+	}
+	res.Drift = decimal.Max(res.PriceYes.Sub(res.OldPriceYes).Abs(), res.PriceNo.Sub(res.OldPriceNo).Abs())
+	if res.Drift.LessThanOrEqual(RepriceTolerance) {
+		return res, nil
+	}
+
+	if err := s.store.UpdateMarketState(ctx, market.ID, market.QYes, market.QNo, res.PriceYes, res.PriceNo); err != nil {
+		return nil, fmt.Errorf("update market %s: %w", marketID, err)
+	}
+	res.Updated = true
+
+	slog.Warn("market repriced",
+		"market", market.ID,
+		"old_price_yes", res.OldPriceYes.String(),
+		"price_yes", res.PriceYes.String(),
+		"drift", res.Drift.String(),
+	)
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(WSMessage{
+			Type:       "market_repriced",
+			MarketID:   market.ID,
+			ContractID: market.ContractID,
+			H3CellID:   market.H3CellID,
+			PriceYes:   res.PriceYes.String(),
+			PriceNo:    res.PriceNo.String(),
+		})
+	}
+	return res, nil
+}
+
+// Reprice handles POST /api/v1/markets/{marketID}/reprice (admin).
+func (s *Service) Reprice(w http.ResponseWriter, r *http.Request) {
+	res, err := s.RepriceMarket(r.Context(), chi.URLParam(r, "marketID"))
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if res.Updated {
+		s.audit(r, AuditActionReprice, res.MarketID, map[string]string{
+			"old_price_yes": res.OldPriceYes.String(),
+			"price_yes":     res.PriceYes.String(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,72 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postReprice(t *testing.T, router http.Handler, marketID string) (*httptest.ResponseRecorder, trade.RepriceResult) {
+	t.Helper()
+	return serveJSON[trade.RepriceResult](t, router, adminRequest("POST", "/api/v1/markets/"+marketID+"/reprice", nil))
+}
+
+func TestReprice_CorrectsImportedPrices(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// Simulate an import that set quantities but left the prices at 0.5.
+	ctx := context.Background()
+	if err := ms.UpdateMarketState(ctx, market.ID, d(50), d(0), d(0.5), d(0.5)); err != nil {
+		t.Fatal(err)
+	}
+
+	w, res := postReprice(t, router, market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !res.Updated {
+		t.Fatalf("expected prices to be updated, got %+v", res)
+	}
+
+	mm, _ := lmsr.NewMarketMaker(d(100))
+	wantYes := mm.Price(d(50), d(0))
+	stored, _ := ms.GetMarket(ctx, market.ID)
+	if !stored.PriceYes.Equal(wantYes) || !stored.PriceNo.Equal(mm.PriceNo(d(50), d(0))) {
+		t.Errorf("stored prices %s/%s, want %s", stored.PriceYes, stored.PriceNo, wantYes)
+	}
+	if !stored.QYes.Equal(d(50)) || !stored.QNo.IsZero() {
+		t.Errorf("quantities changed: %s/%s", stored.QYes, stored.QNo)
+	}
+	if !res.OldPriceYes.Equal(d(0.5)) || !res.Drift.Equal(wantYes.Sub(d(0.5))) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestReprice_ConsistentPricesUntouched(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(20)})
+
+	w, res := postReprice(t, router, market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if res.Updated {
+		t.Errorf("expected no update for consistent prices, got drift %s", res.Drift)
+	}
+
+	if w, _ := postReprice(t, router, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown market, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets/"+market.ID+"/reprice", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without admin token: expected 401, got %d", w.Code)
+	}
+}
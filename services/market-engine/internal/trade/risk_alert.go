@@ -0,0 +1,84 @@
+// Alerts when a trade pushes a user's correlated
+// exposure past a utilization threshold.
+
+package trade
+
+import (
+	"log/slog"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// DefaultRiskAlertThresholds are the correlated-limit utilizations (as
+// fractions of MaxCorrelated) that trigger a risk alert when crossed.
+var DefaultRiskAlertThresholds = []decimal.Decimal{
+	decimal.NewFromFloat(0.80),
+	decimal.NewFromFloat(0.95),
+}
+
+// WithRiskAlertThresholds replaces the utilization thresholds that trigger
+// risk alerts. No thresholds disables the alerts.
+func WithRiskAlertThresholds(thresholds ...decimal.Decimal) Option {
+	return func(s *Service) {
+		ts := append([]decimal.Decimal(nil), thresholds...)
+		sort.Slice(ts, func(i, j int) bool { return ts[i].LessThan(ts[j]) })
+		s.riskThresholds = ts
+	}
+}
+
+// crossedThreshold returns the highest threshold t with before < t <= after,
+// if any. A trade that stays above a threshold, or moves down through it,
+// crosses nothing, so each upward crossing alerts once.
+func crossedThreshold(thresholds []decimal.Decimal, before, after decimal.Decimal) (decimal.Decimal, bool) {
+	for i := len(thresholds) - 1; i >= 0; i-- {
+		t := thresholds[i]
+		if before.LessThan(t) && after.GreaterThanOrEqual(t) {
+			return t, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// checkRiskAlert compares the user's correlated-group utilization around
+// the traded cell before and after a trade, given the pre-trade exposures
+// and the trade's signed exposure delta. Crossing a threshold is logged
+// and sent to the user's WebSocket connections.
+func (s *Service) checkRiskAlert(userID string, market *model.Market, exposures map[string]decimal.Decimal, delta decimal.Decimal) {
+	if len(s.riskThresholds) == 0 {
+		return
+	}
+	before := s.limiter.Utilization(market.H3CellID, exposures)
+
+	after := make(map[string]decimal.Decimal, len(exposures)+1)
+	for cell, e := range exposures {
+		after[cell] = e
+	}
+	after[market.H3CellID] = after[market.H3CellID].Add(delta)
+	util := s.limiter.Utilization(market.H3CellID, after)
+
+	threshold, crossed := crossedThreshold(s.riskThresholds, before, util)
+	if !crossed {
+		return
+	}
+	slog.Warn("risk alert",
+		"user", userID,
+		"market", market.ID,
+		"h3_cell", market.H3CellID,
+		"utilization", util.String(),
+		"threshold", threshold.String(),
+	)
+	if s.wsHub != nil {
+		s.wsHub.SendToUser(userID, WSMessage{
+			Type:        "risk_alert",
+			MarketID:    market.ID,
+			ContractID:  market.ContractID,
+			H3CellID:    market.H3CellID,
+			UserID:      userID,
+			Utilization: util.String(),
+			Threshold:   threshold.String(),
+		})
+	}
+}
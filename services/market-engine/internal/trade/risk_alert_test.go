@@ -0,0 +1,112 @@
+package trade_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// riskAlerts forwards the risk_alert messages received on conn.
+func riskAlerts(conn *websocket.Conn) <-chan trade.WSMessage {
+	ch := make(chan trade.WSMessage, 16)
+	go func() {
+		for {
+			var msg trade.WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type == "risk_alert" {
+				ch <- msg
+			}
+		}
+	}()
+	return ch
+}
+
+// drain returns what arrives on ch until it has been quiet for a while.
+func drain(ch <-chan trade.WSMessage) []trade.WSMessage {
+	var out []trade.WSMessage
+	for {
+		select {
+		case msg := <-ch:
+			out = append(out, msg)
+		case <-time.After(200 * time.Millisecond):
+			return out
+		}
+	}
+}
+
+func TestRiskAlert_FiresOncePerCrossing(t *testing.T) {
+	ms := store.NewMemoryStore()
+	hub := trade.NewWSHub()
+	go hub.Run()
+	// MaxCorrelated 5000: the 80% threshold is 4000 across correlated cells.
+	svc := trade.NewService(ms, correlation.NewPositionLimiter(d(1000), d(5000), 5), hub)
+	router := chi.NewRouter()
+	router.Post("/api/v1/trade", svc.ExecuteTrade)
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	mine, _, err := websocket.DefaultDialer.Dial(wsURL+"?user_id=u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mine.Close()
+	other, _, err := websocket.DefaultDialer.Dial(wsURL+"?user_id=u2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	time.Sleep(50 * time.Millisecond) // let the hub register the clients
+	mineAlerts, otherAlerts := riskAlerts(mine), riskAlerts(other)
+
+	cells := []string{"872a1070b", "872a1070c", "872a1070d", "872a1070e", "872a1070f"}
+	for _, cell := range cells {
+		seedMarket(t, ms, "ATMX-"+cell+"-PRECIP-25MM-20250815", cell, 100000)
+	}
+	logs := captureLogs(t)
+	buy := func(cell string, qty float64) {
+		t.Helper()
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: "ATMX-" + cell + "-PRECIP-25MM-20250815", Side: "YES", Quantity: d(qty)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("trade in %s failed: %d %s", cell, w.Code, w.Body.String())
+		}
+	}
+
+	// 20%, 40%, 60%, then 80%: only the last trade crosses a threshold.
+	for _, cell := range cells[:4] {
+		buy(cell, 1000)
+	}
+	// 82%: still above 80%, nothing new crossed.
+	buy(cells[4], 100)
+
+	alerts := drain(mineAlerts)
+	if len(alerts) != 1 {
+		t.Fatalf("expected exactly one alert, got %+v", alerts)
+	}
+	if a := alerts[0]; a.UserID != "u1" || a.Threshold != "0.8" || a.Utilization != "0.8" || a.H3CellID != "872a1070e" {
+		t.Errorf("unexpected alert %+v", a)
+	}
+	if records := logRecords(t, logs, "risk alert"); len(records) != 1 {
+		t.Errorf("expected one risk alert log, got %d", len(records))
+	}
+	if got := drain(otherAlerts); len(got) != 0 {
+		t.Errorf("another user's connection received %+v", got)
+	}
+
+	// 82% → 96% crosses 95% once.
+	buy(cells[4], 700)
+	if alerts := drain(mineAlerts); len(alerts) != 1 || alerts[0].Threshold != "0.95" {
+		t.Errorf("expected one 95%% alert, got %+v", alerts)
+	}
+}
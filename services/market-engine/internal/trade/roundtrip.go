@@ -0,0 +1,134 @@
+// Round-trip cost quotes for multi-market strategies.
+
+package trade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// MaxRoundTripLegs caps the legs per round-trip quote.
+const MaxRoundTripLegs = 100
+
+// RoundTripQuoteRequest is the JSON body for POST /api/v1/quote/roundtrip.
+type RoundTripQuoteRequest struct {
+	Legs []IntendedPosition `json:"legs"`
+}
+
+// normalize puts every contract ticker in canonical form.
+func (req *RoundTripQuoteRequest) normalize() {
+	for i := range req.Legs {
+		req.Legs[i].ContractID = contract.NormalizeTicker(req.Legs[i].ContractID)
+	}
+}
+
+// Validate reports every problem with a round-trip quote request.
+func (req RoundTripQuoteRequest) Validate() []FieldError {
+	rules := []rule{
+		fails("legs", len(req.Legs) == 0, "legs must not be empty"),
+		fails("legs", len(req.Legs) > MaxRoundTripLegs,
+			fmt.Sprintf("at most %d legs per request", MaxRoundTripLegs)),
+	}
+	for i, l := range req.Legs {
+		field := func(name string) string { return fmt.Sprintf("legs[%d].%s", i, name) }
+		rules = append(rules,
+			required(field("contract_id"), l.ContractID),
+			ticker(field("contract_id"), l.ContractID),
+			oneOf(field("side"), l.Side, "side must be YES or NO", "YES", "NO"),
+			quantity(field("quantity"), l.Quantity),
+		)
+	}
+	return check(rules...)
+}
+
+// RoundTripLeg is the cost of entering and exiting one intended trade.
+type RoundTripLeg struct {
+	ContractID    string          `json:"contract_id"`
+	MarketID      string          `json:"market_id"`
+	Side          string          `json:"side"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	EntryCost     decimal.Decimal `json:"entry_cost"`     // cash paid to enter (negative for a sell)
+	ExitProceeds  decimal.Decimal `json:"exit_proceeds"`  // cash received to exit
+	RoundTripCost decimal.Decimal `json:"roundtrip_cost"` // EntryCost - ExitProceeds
+}
+
+// RoundTripQuoteResponse is the JSON body returned from
+// POST /api/v1/quote/roundtrip.
+type RoundTripQuoteResponse struct {
+	EntryCost     decimal.Decimal `json:"entry_cost"`
+	ExitProceeds  decimal.Decimal `json:"exit_proceeds"`
+	RoundTripCost decimal.Decimal `json:"roundtrip_cost"`
+	Legs          []RoundTripLeg  `json:"legs"`
+}
+
+// QuoteRoundTrip handles POST /api/v1/quote/roundtrip
+// Prices entering each leg and exiting it straight away, both against the
+// market's current state as GetQuote prices a buy and a sell, so the net
+// is the maker's edge on the basket. Legs are priced independently, even
+// when several share a market. A leg whose entry or exit would take the
+// price out of LMSR bounds is rejected with 422. Nothing is traded.
+func (s *Service) QuoteRoundTrip(w http.ResponseWriter, r *http.Request) {
+	var req RoundTripQuoteRequest
+	if _, ok := bind(w, r, &req); !ok {
+		return
+	}
+	ctx := r.Context()
+
+	resp := RoundTripQuoteResponse{Legs: make([]RoundTripLeg, 0, len(req.Legs))}
+	markets := make(map[string]*model.Market)
+	for i, l := range req.Legs {
+		market, ok := markets[l.ContractID]
+		if !ok {
+			var err error
+			market, err = s.store.GetMarketByContract(ctx, l.ContractID)
+			if err != nil {
+				s.writeLookupError(w, r, err, "market not found for contract: "+l.ContractID)
+				return
+			}
+			if market.Status != "open" || market.HiddenAt != nil {
+				writeError(w, "market for contract "+l.ContractID+" is not open for trading", http.StatusConflict)
+				return
+			}
+			markets[l.ContractID] = market
+		}
+
+		mm, err := s.marketMaker(market)
+		if err != nil {
+			s.internalError(w, r, "internal error: invalid market configuration", err)
+			return
+		}
+		entry, err := priceLeg(mm, market.QYes, market.QNo, l.Side, l.Quantity)
+		if err != nil {
+			writeError(w, fmt.Sprintf("legs[%d]: cannot quote entry: %v", i, err), http.StatusUnprocessableEntity)
+			return
+		}
+		exit, err := priceLeg(mm, market.QYes, market.QNo, l.Side, l.Quantity.Neg())
+		if err != nil {
+			writeError(w, fmt.Sprintf("legs[%d]: cannot quote exit: %v", i, err), http.StatusUnprocessableEntity)
+			return
+		}
+
+		leg := RoundTripLeg{
+			ContractID:   market.ContractID,
+			MarketID:     market.ID,
+			Side:         l.Side,
+			Quantity:     l.Quantity,
+			EntryCost:    entry.cost,
+			ExitProceeds: exit.cost.Neg(),
+		}
+		leg.RoundTripCost = leg.EntryCost.Sub(leg.ExitProceeds)
+		resp.Legs = append(resp.Legs, leg)
+		resp.EntryCost = resp.EntryCost.Add(leg.EntryCost)
+		resp.ExitProceeds = resp.ExitProceeds.Add(leg.ExitProceeds)
+	}
+	resp.RoundTripCost = resp.EntryCost.Sub(resp.ExitProceeds)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
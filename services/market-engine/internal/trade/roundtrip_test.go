@@ -0,0 +1,83 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func quoteRoundTrip(t *testing.T, router chi.Router, legs []trade.IntendedPosition) (*httptest.ResponseRecorder, trade.RoundTripQuoteResponse) {
+	t.Helper()
+	body, _ := json.Marshal(trade.RoundTripQuoteRequest{Legs: legs})
+	return serveJSON[trade.RoundTripQuoteResponse](t, router, httptest.NewRequest("POST", "/api/v1/quote/roundtrip", bytes.NewReader(body)))
+}
+
+func TestQuoteRoundTrip_SingleLeg(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// With b = 100 from q = (0, 0), buying 10 YES costs
+	// 100·ln((e^0.1 + 1) / 2) = 5.12494795 and selling 10 YES pays
+	// -100·ln((e^-0.1 + 1) / 2) = 4.87505205.
+	w, resp := quoteRoundTrip(t, router, []trade.IntendedPosition{
+		{ContractID: contractID, Side: "YES", Quantity: d(10)},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Legs) != 1 || resp.Legs[0].MarketID != market.ID {
+		t.Fatalf("unexpected legs %+v", resp.Legs)
+	}
+	leg := resp.Legs[0]
+	assertNear(t, "entry cost", leg.EntryCost, 5.12494795)
+	assertNear(t, "exit proceeds", leg.ExitProceeds, 4.87505205)
+	if !leg.RoundTripCost.Equal(leg.EntryCost.Sub(leg.ExitProceeds)) || !leg.RoundTripCost.IsPositive() {
+		t.Errorf("round-trip cost = %s, want %s - %s", leg.RoundTripCost, leg.EntryCost, leg.ExitProceeds)
+	}
+	if !resp.EntryCost.Equal(leg.EntryCost) || !resp.ExitProceeds.Equal(leg.ExitProceeds) || !resp.RoundTripCost.Equal(leg.RoundTripCost) {
+		t.Errorf("totals %s/%s/%s do not match the only leg", resp.EntryCost, resp.ExitProceeds, resp.RoundTripCost)
+	}
+
+	// The same numbers as the market's two-sided quote.
+	_, q := getJSON[trade.QuoteResponse](t, router, "/api/v1/markets/"+market.ID+"/quote?qty=10&side=YES")
+	if !leg.EntryCost.Equal(q.BuyCost) || !leg.ExitProceeds.Equal(q.SellProceeds) {
+		t.Errorf("leg %s/%s, quote %s/%s", leg.EntryCost, leg.ExitProceeds, q.BuyCost, q.SellProceeds)
+	}
+
+	// Quoting doesn't trade.
+	after, _ := ms.GetMarket(context.Background(), market.ID)
+	if !after.QYes.IsZero() || !after.QNo.IsZero() {
+		t.Errorf("quote moved the market to %s/%s", after.QYes, after.QNo)
+	}
+}
+
+func TestQuoteRoundTrip_Rejections(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	tests := []struct {
+		name string
+		legs []trade.IntendedPosition
+		want int
+	}{
+		{"empty", nil, http.StatusUnprocessableEntity},
+		{"bad side", []trade.IntendedPosition{{ContractID: contractID, Side: "MAYBE", Quantity: d(1)}}, http.StatusUnprocessableEntity},
+		{"zero quantity", []trade.IntendedPosition{{ContractID: contractID, Side: "YES"}}, http.StatusUnprocessableEntity},
+		{"unknown market", []trade.IntendedPosition{{ContractID: "ATMX-8729a0001-PRECIP-25MM-20250815", Side: "YES", Quantity: d(1)}}, http.StatusNotFound},
+		{"out of price bounds", []trade.IntendedPosition{{ContractID: contractID, Side: "YES", Quantity: d(1e6)}}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if w, _ := quoteRoundTrip(t, router, tt.legs); w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Market search by contract attributes.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/atmx/market-engine/internal/contract"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// SearchMarkets handles GET /api/v1/markets/search
+// Query parameters (all optional, combined with AND):
+//
+//	type=PRECIP           contract type
+//	h3_prefix=872a1       H3 cell ID prefix (lowercase hex)
+//	expiry_before=YYYYMMDD contract date strictly before this day
+//	status=open|settled
+//	include_hidden=true   also return hidden (soft-deleted) markets
+//
+// Results are newest first.
+func (s *Service) SearchMarkets(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := store.MarketFilter{
+		Type:     q.Get("type"),
+		H3Prefix: q.Get("h3_prefix"),
+		Status:   q.Get("status"),
+
+		IncludeHidden: q.Get("include_hidden") == "true",
+	}
+
+	if f.Type != "" && !contract.ValidType(f.Type) {
+		writeError(w, "type must be one of PRECIP, TEMP, WIND, SNOW", http.StatusBadRequest)
+		return
+	}
+	if !cellPrefixRegex.MatchString(f.H3Prefix) {
+		writeError(w, "h3_prefix must be lowercase hex", http.StatusBadRequest)
+		return
+	}
+	if f.Status != "" && f.Status != "open" && f.Status != "settled" {
+		writeError(w, "status must be open or settled", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("expiry_before"); v != "" {
+		t, err := time.Parse("20060102", v)
+		if err != nil {
+			writeError(w, "expiry_before must be YYYYMMDD", http.StatusBadRequest)
+			return
+		}
+		f.ExpiryBefore = t
+	}
+
+	markets, err := s.store.SearchMarkets(r.Context(), f)
+	if err != nil {
+		s.internalError(w, r, "failed to search markets", err)
+		return
+	}
+	if markets == nil {
+		markets = []model.Market{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(markets)
+}
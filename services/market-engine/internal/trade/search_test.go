@@ -0,0 +1,86 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+func searchMarkets(t *testing.T, router http.Handler, query string) (*httptest.ResponseRecorder, []string) {
+	t.Helper()
+	w, markets := getJSON[[]model.Market](t, router, "/api/v1/markets/search"+query)
+	ids := make([]string, 0, len(markets))
+	for _, m := range markets {
+		ids = append(ids, m.ContractID)
+	}
+	return w, ids
+}
+
+func seedSearchMarkets(t *testing.T) http.Handler {
+	t.Helper()
+	_, ms, router := newTestEnv(t)
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	seedMarket(t, ms, "ATMX-872a10711-PRECIP-10MM-20250920", "872a10711", 100) // expires too late
+	seedMarket(t, ms, "ATMX-872a1070b-TEMP-90F-20250815", "872a1070b", 100)    // wrong type
+	seedMarket(t, ms, "ATMX-88283082b-PRECIP-25MM-20250815", "88283082b", 100) // wrong region
+	ms.CreateMarket(context.Background(), &model.Market{                       // settled
+		ID: "settled", ContractID: "ATMX-872a10722-PRECIP-5MM-20250810", H3CellID: "872a10722",
+		B: d(100), Status: "settled",
+	})
+	return router
+}
+
+func TestSearchMarkets_CombinedFilters(t *testing.T) {
+	router := seedSearchMarkets(t)
+
+	w, ids := searchMarkets(t, router, "?type=PRECIP&h3_prefix=872a1&expiry_before=20250901&status=open")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(ids) != 1 || ids[0] != "ATMX-872a1070b-PRECIP-25MM-20250815" {
+		t.Errorf("got %v, want only the open PRECIP market near 872a1 expiring before September", ids)
+	}
+
+	// Dropping the status filter brings back the settled market.
+	_, ids = searchMarkets(t, router, "?type=PRECIP&h3_prefix=872a1&expiry_before=20250901")
+	if len(ids) != 2 {
+		t.Errorf("expected 2 markets without status filter, got %v", ids)
+	}
+
+	// expiry_before is exclusive.
+	_, ids = searchMarkets(t, router, "?type=PRECIP&expiry_before=20250815")
+	if len(ids) != 1 || ids[0] != "ATMX-872a10722-PRECIP-5MM-20250810" {
+		t.Errorf("expected only the 20250810 market, got %v", ids)
+	}
+
+	// No filters returns everything.
+	_, ids = searchMarkets(t, router, "")
+	if len(ids) != 5 {
+		t.Errorf("expected all 5 markets, got %v", ids)
+	}
+}
+
+func TestSearchMarkets_NoMatchesIsEmptyArray(t *testing.T) {
+	router := seedSearchMarkets(t)
+	w, _ := searchMarkets(t, router, "?type=SNOW")
+	if w.Code != http.StatusOK || w.Body.String() != "[]\n" {
+		t.Errorf("expected 200 with [], got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestSearchMarkets_InvalidParams(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	for _, q := range []string{
+		"?type=HAIL",
+		"?h3_prefix=ZZ",
+		"?expiry_before=2025-09-01",
+		"?status=closed",
+	} {
+		if w, _ := searchMarkets(t, router, q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
@@ -5,44 +5,144 @@
 package trade
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
 	"github.com/atmx/market-engine/internal/contract"
 	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/events"
 	"github.com/atmx/market-engine/internal/lmsr"
 	"github.com/atmx/market-engine/internal/metrics"
 	"github.com/atmx/market-engine/internal/model"
 	"github.com/atmx/market-engine/internal/store"
 )
 
-// Service handles market operations. Uses a mutex for serialized trade
-// execution (single-instance). For horizontal scaling, replace with
-// distributed locking or database-level optimistic concurrency.
+// Service handles market operations. Writes to a market are serialized by
+// a per-market Locker: in-process by default, or Redis-backed (WithLocker)
+// so multiple instances can run while unrelated markets trade concurrently.
 type Service struct {
 	store       store.Store
 	limiter     *correlation.PositionLimiter
 	marginLimit decimal.Decimal
-	mu          sync.Mutex
-	wsHub       *WSHub // optional WebSocket hub for real-time broadcasts
+	locker      Locker
+	wsHub       *WSHub           // optional WebSocket hub for real-time broadcasts
+	emitter     events.Emitter   // downstream event stream; events.Nop by default
+	now         func() time.Time // injectable clock for tests
+
+	defaultLiquidity map[string]decimal.Decimal // contract type → b when a market gives none
+	riskThresholds   []decimal.Decimal          // ascending utilizations that trigger risk alerts
+	forecastBounds   lmsr.LiquidityBounds       // range b derived from a forecast is clamped into
+
+	quotes          QuoteStore      // pending quotes for trades awaiting confirmation
+	confirmNotional decimal.Decimal // |cost| at which a trade must be confirmed; 0 = only on request
+	quoteTTL        time.Duration
+	quoteTolerance  decimal.Decimal // max fill price move between quote and execution
+
+	errorVerbosity ErrorVerbosity // how much of a 5xx error reaches the client
+
+	ids IDGenerator // IDs for markets, products, ledger entries and events
+
+	costBasis CostBasisMethod // how realized P&L is booked
+
+	systemicThreshold decimal.Decimal // group exposure across all users that is flagged; 0 = none
+
+	breaker *circuitBreaker // price-band trading halts; nil = disabled
+
+	suggestedQtyScale int32 // decimal places of max_allowed_quantity on price bound rejections
+
+	limitExempt map[string]bool // users allowed past position limits
+
+	minNotional decimal.Decimal // smallest |cost| a trade may have; 0 = no minimum
+
+	autoLiquidity *AutoLiquidityPolicy // volume-driven b for opted-in markets; nil = disabled
+
+	maxOpenMarketsPerCreator int // open markets one created_by user may hold; 0 = unlimited
+
+	auditLog bool // record admin actions in the store's audit log
+
+	liquidityVolume *volumeTracker // trailing trade volume of auto-liquidity markets
+}
+
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// WithLocker replaces the default in-process per-market locker.
+func WithLocker(l Locker) Option {
+	return func(s *Service) { s.locker = l }
+}
+
+// WithClock overrides the wall clock used for timestamps and time windows.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) { s.now = now }
+}
+
+// WithEmitter publishes trade, market creation and settlement events to e.
+func WithEmitter(e events.Emitter) Option {
+	return func(s *Service) { s.emitter = e }
 }
 
 // NewService creates a new trade service.
 // Pass nil for hub if WebSocket broadcasting is not needed.
-func NewService(st store.Store, limiter *correlation.PositionLimiter, hub *WSHub) *Service {
-	return &Service{
+func NewService(st store.Store, limiter *correlation.PositionLimiter, hub *WSHub, opts ...Option) *Service {
+	s := &Service{
 		store:       st,
 		limiter:     limiter,
 		marginLimit: decimal.NewFromInt(10000), // default margin limit
+		locker:      NewLocalLocker(),
 		wsHub:       hub,
+		emitter:     events.Nop{},
+		now:         time.Now,
+
+		defaultLiquidity: DefaultLiquidityByType,
+		riskThresholds:   DefaultRiskAlertThresholds,
+		forecastBounds:   lmsr.DefaultLiquidityBounds,
+
+		quotes:         NewLocalQuoteStore(),
+		quoteTTL:       DefaultQuoteTTL,
+		quoteTolerance: DefaultQuoteTolerance,
+
+		ids: UUIDGenerator{},
+
+		suggestedQtyScale: DefaultSuggestedQuantityScale,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// lockMarket acquires the per-market lock, writing an error response and
+// returning ok=false if it can't be held (cancelled request or lock backend
+// failure). Callers must defer the returned unlock when ok is true, and
+// make their writes with held, which is cancelled if the lock is lost.
+func (s *Service) lockMarket(w http.ResponseWriter, r *http.Request, marketID string) (held context.Context, unlock func(), ok bool) {
+	ctx := r.Context()
+	held, unlock, err := s.locker.Lock(ctx, marketID)
+	if err != nil {
+		if ctx.Err() != nil {
+			writeError(w, "request cancelled", http.StatusServiceUnavailable)
+		} else {
+			slog.Error("market lock failed", "market", marketID, "err", err)
+			writeError(w, "market is temporarily unavailable", http.StatusServiceUnavailable)
+		}
+		return nil, nil, false
 	}
+
+	// The request may have timed out while waiting for the lock; don't
+	// start a trade the client is no longer waiting for.
+	if ctx.Err() != nil {
+		unlock()
+		writeError(w, "request cancelled", http.StatusServiceUnavailable)
+		return nil, nil, false
+	}
+	return held, unlock, true
 }
 
 // --- Request/Response types ---
@@ -51,6 +151,28 @@ func NewService(st store.Store, limiter *correlation.PositionLimiter, hub *WSHub
 type CreateMarketRequest struct {
 	ContractID string          `json:"contract_id"` // ATMX-{h3}-{type}-{threshold}-{date}
 	B          decimal.Decimal `json:"b"`           // liquidity parameter; 0 → default 100
+
+	// Optional liquidity schedule; when set, b starts at BStart and moves
+	// linearly to BEnd at contract expiry (B is ignored).
+	BStart *decimal.Decimal `json:"b_start,omitempty"`
+	BEnd   *decimal.Decimal `json:"b_end,omitempty"`
+
+	// Optional prior probability of YES, e.g. the NWS exceedance
+	// probability; the market opens at this price rather than 0.5.
+	InitialPriceYes *decimal.Decimal `json:"initial_price_yes,omitempty"`
+
+	// AutoLiquidity lets b follow the market's trading volume under the
+	// service's policy (see WithAutoLiquidity). Not with a schedule.
+	AutoLiquidity bool `json:"auto_liquidity,omitempty"`
+
+	// CreatedBy is the user creating the market, identified the same way
+	// as TradeRequest.UserID. Counted against the per-creator open market
+	// limit (see WithMaxOpenMarketsPerCreator); omitted by operators.
+	CreatedBy string `json:"created_by,omitempty"`
+
+	// Optional cap on the maker's worst-case loss in the market (see
+	// Market.SubsidyBudget).
+	SubsidyBudget *decimal.Decimal `json:"subsidy_budget,omitempty"`
 }
 
 // TradeRequest is the JSON body for POST /trade.
@@ -59,18 +181,46 @@ type TradeRequest struct {
 	ContractID string          `json:"contract_id"` // ticker symbol
 	Side       string          `json:"side"`         // "YES" or "NO"
 	Quantity   decimal.Decimal `json:"quantity"`      // positive = buy, negative = sell
+
+	// MarketID optionally trades against that exact market instead of the
+	// one listed under ContractID, which may then be omitted; when both
+	// are given they must agree.
+	MarketID string `json:"market_id,omitempty"`
+
+	// Optional slippage guard on the average fill price: a buy is
+	// rejected above MaxFillPrice, a sell below MinFillPrice.
+	MaxFillPrice *decimal.Decimal `json:"max_fill_price,omitempty"`
+	MinFillPrice *decimal.Decimal `json:"min_fill_price,omitempty"`
+
+	// Two-step confirmation: confirm=false only quotes the trade, as do
+	// trades over the confirmation notional; sending the same trade with
+	// the quote's ConfirmToken executes it.
+	Confirm      *bool  `json:"confirm,omitempty"`
+	ConfirmToken string `json:"confirm_token,omitempty"`
+
+	// Optional tags recorded on the ledger entry for attribution, e.g.
+	// {"source": "bot", "strategy": "s-42"}; see MaxMetadataKeys.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// AllowPartial fills as much of a trade that would breach the price
+	// bounds as stays within them, instead of rejecting it.
+	AllowPartial bool `json:"allow_partial,omitempty"`
 }
 
 // TradeResponse is the JSON body returned from POST /trade.
 type TradeResponse struct {
-	TradeID    string          `json:"trade_id"`
-	UserID     string          `json:"user_id"`
-	ContractID string          `json:"contract_id"`
-	Side       string          `json:"side"`
-	Quantity   decimal.Decimal `json:"quantity"`
-	FillPrice  decimal.Decimal `json:"fill_price"`
-	Cost       decimal.Decimal `json:"cost"`
-	Position   PositionSummary `json:"position"`
+	TradeID     string          `json:"trade_id"`
+	UserID      string          `json:"user_id"`
+	ContractID  string          `json:"contract_id"`
+	Side        string          `json:"side"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	FillPrice   decimal.Decimal `json:"fill_price"`
+	Cost        decimal.Decimal `json:"cost"`
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	Position    PositionSummary `json:"position"`
+	// Partial is set when allow_partial cut Quantity short of the request
+	// at the price bound.
+	Partial bool `json:"partial,omitempty"`
 }
 
 // PositionSummary is the position snapshot included in trade responses.
@@ -86,12 +236,10 @@ type PositionSummary struct {
 // CreateMarket handles POST /api/v1/markets
 func (s *Service) CreateMarket(w http.ResponseWriter, r *http.Request) {
 	var req CreateMarketRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, "invalid request body", http.StatusBadRequest)
+	if _, ok := bind(w, r, &req); !ok {
 		return
 	}
 
-	// Validate ticker format.
 	parsed, err := contract.ParseTicker(req.ContractID)
 	if err != nil {
 		writeError(w, err.Error(), http.StatusBadRequest)
@@ -99,43 +247,74 @@ func (s *Service) CreateMarket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	b := req.B
+	if req.BStart != nil {
+		b = *req.BStart
+	}
 	if b.LessThanOrEqual(decimal.Zero) {
-		b = decimal.NewFromInt(100) // default liquidity
+		b = s.defaultB(parsed.Type)
 	}
 
 	// Validate b can construct a market maker.
-	if _, err := lmsr.NewMarketMaker(b); err != nil {
+	mm, err := lmsr.NewMarketMaker(b)
+	if err != nil {
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	half := decimal.NewFromFloat(0.5)
+	qYes, qNo := decimal.Zero, decimal.Zero
+	if req.InitialPriceYes != nil {
+		if qYes, qNo, err = seedQuantities(mm, *req.InitialPriceYes); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	market := &model.Market{
-		ID:         uuid.New().String(),
-		ContractID: req.ContractID,
-		H3CellID:   parsed.H3CellID,
-		QYes:       decimal.Zero,
-		QNo:        decimal.Zero,
-		B:          b,
-		PriceYes:   half,
-		PriceNo:    half,
-		Status:     "open",
-		CreatedAt:  time.Now().UTC(),
+		ID:          s.ids.NewID(),
+		ContractID:  req.ContractID,
+		H3CellID:    parsed.H3CellID,
+		QYes:        qYes,
+		QNo:         qNo,
+		B:           b,
+		PriceYes:    mm.Price(qYes, qNo),
+		PriceNo:     mm.PriceNo(qYes, qNo),
+		Status:      "open",
+		CreatedAt:   s.now().UTC(),
+		BStart:      req.BStart,
+		BEnd:        req.BEnd,
+		InitialQYes: qYes,
+		InitialQNo:  qNo,
+
+		AutoLiquidity: req.AutoLiquidity,
+		CreatedBy:     req.CreatedBy,
+		SubsidyBudget: req.SubsidyBudget,
+	}
+
+	release, ok := s.reserveMarketCreation(w, r, req.CreatedBy)
+	if !ok {
+		return
 	}
+	defer release()
 
 	ctx := r.Context()
 	if err := s.store.CreateMarket(ctx, market); err != nil {
-		writeError(w, err.Error(), http.StatusConflict)
+		if errors.Is(err, store.ErrAlreadyExists) {
+			writeError(w, "market for contract "+market.ContractID+" already exists", http.StatusConflict)
+			return
+		}
+		s.internalError(w, r, "failed to create market", err)
 		return
 	}
 
 	metrics.ActiveMarkets.Inc()
+	s.emitMarketCreated(ctx, market)
 
 	slog.Info("market created",
 		"id", market.ID,
 		"contract", req.ContractID,
 		"h3_cell", parsed.H3CellID,
 		"b", b.String(),
+		"price_yes", market.PriceYes.String(),
 	)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -149,7 +328,7 @@ func (s *Service) GetMarket(w http.ResponseWriter, r *http.Request) {
 
 	market, err := s.store.GetMarket(r.Context(), marketID)
 	if err != nil {
-		writeError(w, "market not found", http.StatusNotFound)
+		s.writeLookupError(w, r, err, "market not found")
 		return
 	}
 
@@ -157,23 +336,28 @@ func (s *Service) GetMarket(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(market)
 }
 
+// PriceResponse is the JSON body returned from the price endpoint. Prices
+// are decimal strings, never JSON numbers, so JavaScript clients don't
+// round them through float64 (independent of decimal.MarshalJSONWithoutQuotes).
+type PriceResponse struct {
+	Yes string `json:"yes"`
+	No  string `json:"no"`
+	// Spread is 1 - yes - no; ~0 apart from rounding to lmsr.PriceScale.
+	Spread string `json:"spread"`
+}
+
 // GetPrice handles GET /api/v1/markets/{marketID}/price
 func (s *Service) GetPrice(w http.ResponseWriter, r *http.Request) {
 	marketID := chi.URLParam(r, "marketID")
 
 	market, err := s.store.GetMarket(r.Context(), marketID)
 	if err != nil {
-		writeError(w, "market not found", http.StatusNotFound)
+		s.writeLookupError(w, r, err, "market not found")
 		return
 	}
 
-	resp := map[string]decimal.Decimal{
-		"yes": market.PriceYes,
-		"no":  market.PriceNo,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(priceResponse(market))
 }
 
 // ExecuteTrade handles POST /api/v1/trade
@@ -181,106 +365,142 @@ func (s *Service) GetPrice(w http.ResponseWriter, r *http.Request) {
 func (s *Service) ExecuteTrade(w http.ResponseWriter, r *http.Request) {
 	tradeStart := time.Now()
 
+	// --- Input validation ---
 	var req TradeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, "invalid request body", http.StatusBadRequest)
+	if errs, ok := bind(w, r, &req); !ok {
+		if len(errs) > 0 {
+			logTradeRejection(req, RejectInvalidRequest, "fields", len(errs))
+		}
 		return
 	}
 
-	// --- Input validation ---
-	if req.UserID == "" {
-		writeError(w, "user_id is required", http.StatusBadRequest)
+	ctx := r.Context()
+
+	// Find the market by ID or contract ticker.
+	found, ok := s.tradeMarket(w, r, &req)
+	if !ok {
 		return
 	}
-	if req.Side != "YES" && req.Side != "NO" {
-		writeError(w, "side must be YES or NO", http.StatusBadRequest)
+
+	// Serialize trade execution on this market.
+	held, unlock, ok := s.lockMarket(w, r, found.ID)
+	if !ok {
 		return
 	}
-	if req.Quantity.IsZero() {
-		writeError(w, "quantity must be non-zero", http.StatusBadRequest)
+	defer unlock()
+
+	// Re-read under the lock: another trade may have moved the market
+	// between the lookup and acquiring the lock.
+	market, err := s.store.GetMarket(ctx, found.ID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market state", err)
 		return
 	}
 
-	ctx := r.Context()
-
-	// Serialize trade execution.
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// A retried request is answered with the trade it already executed,
+	// before any check that trade's own fill could now fail (a position
+	// limit it filled, a market that has since closed). With an
+	// Idempotency-Key the entry ID is derived from it, so a retry that
+	// races past this lookup collides on insert instead of re-trading.
+	idemKey := r.Header.Get(IdempotencyKeyHeader)
+	entryID := s.ids.NewID()
+	if idemKey != "" {
+		entryID = idempotentEntryID(req.UserID, idemKey)
+		orig, found, err := s.originalTrade(ctx, entryID)
+		if err != nil {
+			s.internalError(w, r, "failed to load trade history", err)
+			return
+		}
+		if found {
+			s.replayIdempotentTrade(w, r, req, req.Quantity, orig)
+			return
+		}
+	}
 
-	// Find market by contract ticker.
-	market, err := s.store.GetMarketByContract(ctx, req.ContractID)
-	if err != nil {
-		writeError(w, "market not found for contract: "+req.ContractID, http.StatusNotFound)
+	// Market-wide checks and the b the trade is priced at, shared with
+	// ClosePosition.
+	ex, ok := s.beginExecution(w, r, req, market)
+	if !ok {
 		return
 	}
+	mm := ex.mm
 
-	if market.Status != "open" {
-		writeError(w, "market is not open for trading", http.StatusConflict)
-		return
+	// With allow_partial, a trade past the price bounds is cut down to
+	// what fits before any check that depends on its size.
+	requested := req.Quantity
+	partial := false
+	if req.AllowPartial {
+		req.Quantity, partial = s.partialQuantity(mm, market, req)
 	}
 
-	// Create LMSR market maker for this market's b parameter.
-	mm, err := lmsr.NewMarketMaker(market.B)
-	if err != nil {
-		writeError(w, "internal error: invalid market configuration", http.StatusInternalServerError)
+	if !checkTradeSize(w, market, req.Quantity) {
+		logTradeRejection(req, ErrCodeTradeSizeOutOfRange)
 		return
 	}
 
 	// --- Position limit check ---
-	// Compute exposure delta: YES increases exposure, NO decreases it.
-	exposureDelta := req.Quantity
-	if req.Side == "NO" {
-		exposureDelta = req.Quantity.Neg()
-	}
-
-	exposures, err := s.store.GetUserCellExposures(ctx, req.UserID)
+	// Exposure delta: YES increases exposure, NO decreases it, in shares
+	// or notional per the limiter's unit.
+	exposures, exposureDelta, err := s.limitExposures(ctx, req, market)
 	if err != nil {
-		writeError(w, "failed to check position limits", http.StatusInternalServerError)
+		s.internalError(w, r, "failed to check position limits", err)
 		return
 	}
 
-	if err := s.limiter.CheckLimit(market.H3CellID, exposureDelta, exposures); err != nil {
-		metrics.PositionLimitRejections.Inc()
+	// Allowlisted liquidity providers skip the limits (see
+	// WithLimitExemptUsers); exposures still feed their risk alerts.
+	if s.limitExempt[req.UserID] {
+		logLimitBypass(req, market)
+	} else if err := s.limiter.CheckLimit(market.H3CellID, exposureDelta, exposures); err != nil {
+		logTradeRejection(req, positionLimitReason(err), "err", err)
 		writeError(w, err.Error(), http.StatusConflict)
 		return
 	}
 
 	// --- Price bounds validation + cost computation ---
-	var cost, fillPrice decimal.Decimal
-	var newQYes, newQNo decimal.Decimal
-
-	if req.Side == "YES" {
-		if err := mm.ValidateTrade(market.QYes, market.QNo, req.Quantity); err != nil {
-			writeError(w, err.Error(), http.StatusConflict)
-			return
-		}
-		cost = mm.TradeCost(market.QYes, market.QNo, req.Quantity)
-		fillPrice = mm.FillPrice(market.QYes, market.QNo, req.Quantity)
-		newQYes = market.QYes.Add(req.Quantity)
-		newQNo = market.QNo
-	} else {
-		if err := mm.ValidateTradeNo(market.QYes, market.QNo, req.Quantity); err != nil {
-			writeError(w, err.Error(), http.StatusConflict)
-			return
-		}
-		cost = mm.TradeCostNo(market.QYes, market.QNo, req.Quantity)
-		fillPrice = mm.FillPrice(market.QNo, market.QYes, req.Quantity) // swap for NO
-		newQYes = market.QYes
-		newQNo = market.QNo.Add(req.Quantity)
+	leg, err := priceLeg(mm, market.QYes, market.QNo, req.Side, req.Quantity)
+	if err != nil {
+		maxQty := s.maxAllowedQuantity(mm, market.QYes, market.QNo, req.Side, req.Quantity)
+		logTradeRejection(req, RejectPriceBound, "err", err)
+		writePriceBoundError(w, err, maxQty)
+		return
+	}
+	cost, fillPrice := leg.cost, leg.fillPrice
+	newQYes, newQNo := leg.newQYes, leg.newQNo
+	if !checkSlippage(w, req, fillPrice) {
+		logTradeRejection(req, RejectSlippage, "fill_price", fillPrice.String())
+		return
+	}
+	if !s.checkMinNotional(w, cost) {
+		logTradeRejection(req, ErrCodeBelowMinNotional, "cost", cost.String())
+		return
+	}
+	if loss, ok := checkSubsidyBudget(w, market, newQYes, newQNo, cost); !ok {
+		logTradeRejection(req, ErrCodeSubsidyBudgetExceeded, "worst_case_loss", loss.String(), "budget", market.SubsidyBudget.String())
+		return
+	}
+	if !s.confirmTrade(w, r, req, leg) {
+		return
 	}
 
-	// Update market state.
 	newPriceYes := mm.Price(newQYes, newQNo)
 	newPriceNo := mm.PriceNo(newQYes, newQNo)
 
-	if err := s.store.UpdateMarketState(ctx, market.ID, newQYes, newQNo, newPriceYes, newPriceNo); err != nil {
-		writeError(w, "failed to update market state", http.StatusInternalServerError)
+	// Book realized P&L against the user's position on this side.
+	pos, err := s.store.GetUserMarketPosition(store.WithPrimaryReads(ctx), req.UserID, market.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		s.internalError(w, r, "failed to load position", err)
+		return
+	}
+	realized, err := s.positionRealizedPnL(ctx, pos, req.Side, req.Quantity, cost)
+	if err != nil {
+		s.internalError(w, r, "failed to load trade history", err)
 		return
 	}
 
 	// Create immutable ledger entry.
 	entry := &model.LedgerEntry{
-		ID:         uuid.New().String(),
+		ID:         entryID,
 		UserID:     req.UserID,
 		MarketID:   market.ID,
 		ContractID: req.ContractID,
@@ -288,38 +508,45 @@ func (s *Service) ExecuteTrade(w http.ResponseWriter, r *http.Request) {
 		Quantity:   req.Quantity,
 		Price:      fillPrice,
 		Cost:       cost,
-		Timestamp:  time.Now().UTC(),
-	}
+		Timestamp:  s.now().UTC(),
 
-	if err := s.store.InsertLedgerEntry(ctx, entry); err != nil {
-		writeError(w, "failed to record trade", http.StatusInternalServerError)
-		return
+		RealizedPnL: realized,
+		Metadata:    req.Metadata,
 	}
 
-	// Get updated position for response.
-	positions, _ := s.store.GetUserPositions(ctx, req.UserID)
-	var posSummary PositionSummary
-	for _, p := range positions {
-		if p.MarketID == market.ID {
-			posSummary = PositionSummary{
-				YesQty:        p.YesQty,
-				NoQty:         p.NoQty,
-				CostBasis:     p.CostBasis,
-				UnrealizedPnL: p.UnrealizedPnL,
+	// The ledger entry, market state and any change to b are written
+	// together: a duplicate, a cancelled request or a lost market lock
+	// leaves all untouched.
+	if err := s.applyExecution(held, ex, []*model.LedgerEntry{entry}, newQYes, newQNo, newPriceYes, newPriceNo); err != nil {
+		if errors.Is(err, store.ErrDuplicateLedgerEntry) && idemKey != "" {
+			orig, lerr := s.store.GetLedgerEntry(ctx, entryID)
+			if lerr != nil {
+				s.internalError(w, r, "failed to record trade", lerr)
+				return
 			}
-			break
+			s.replayIdempotentTrade(w, r, req, requested, orig)
+			return
 		}
+		s.writeApplyError(w, r, err)
+		return
 	}
 
+	s.emitTradeExecuted(ctx, entry, market, newPriceYes, newPriceNo)
+
+	// Get updated position for response.
+	posSummary := s.positionSummary(r.Context(), req.UserID, market.ID)
+
 	resp := TradeResponse{
-		TradeID:    entry.ID,
-		UserID:     req.UserID,
-		ContractID: req.ContractID,
-		Side:       req.Side,
-		Quantity:   req.Quantity,
-		FillPrice:  fillPrice,
-		Cost:       cost,
-		Position:   posSummary,
+		TradeID:     entry.ID,
+		UserID:      req.UserID,
+		ContractID:  req.ContractID,
+		Side:        req.Side,
+		Quantity:    req.Quantity,
+		FillPrice:   fillPrice,
+		Cost:        cost,
+		RealizedPnL: realized,
+		Position:    posSummary,
+		Partial:     partial,
 	}
 
 	slog.Info("trade executed",
@@ -331,6 +558,7 @@ func (s *Service) ExecuteTrade(w http.ResponseWriter, r *http.Request) {
 		"cost", cost.String(),
 		"fill_price", fillPrice.String(),
 		"new_price_yes", newPriceYes.String(),
+		"partial", partial,
 	)
 
 	// Broadcast price update via WebSocket.
@@ -347,6 +575,8 @@ func (s *Service) ExecuteTrade(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	s.checkRiskAlert(req.UserID, market, exposures, exposureDelta)
+
 	// Record trade metrics.
 	metrics.TradesTotal.WithLabelValues(req.Side).Inc()
 	metrics.TradeLatency.WithLabelValues(req.Side).Observe(time.Since(tradeStart).Seconds())
@@ -361,7 +591,7 @@ func (s *Service) ExecuteTrade(w http.ResponseWriter, r *http.Request) {
 func (s *Service) ListMarkets(w http.ResponseWriter, r *http.Request) {
 	markets, err := s.store.ListMarkets(r.Context())
 	if err != nil {
-		writeError(w, "failed to list markets", http.StatusInternalServerError)
+		s.internalError(w, r, "failed to list markets", err)
 		return
 	}
 	if markets == nil {
@@ -393,7 +623,7 @@ func (s *Service) GetMarketHistory(w http.ResponseWriter, r *http.Request) {
 
 	entries, err := s.store.GetLedgerEntriesByMarket(r.Context(), marketID)
 	if err != nil {
-		writeError(w, "failed to get market history", http.StatusInternalServerError)
+		s.internalError(w, r, "failed to get market history", err)
 		return
 	}
 	if entries == nil {
@@ -405,20 +635,34 @@ func (s *Service) GetMarketHistory(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetPortfolio handles GET /api/v1/portfolio/{userID}
-// Returns P&L, exposure per cell, and margin utilization.
+// Returns P&L, exposure per cell, and margin utilization. With
+// ?as_of=<rfc3339> it returns the portfolio as it stood at that time.
 func (s *Service) GetPortfolio(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userID")
 	ctx := r.Context()
 
+	if spec := r.URL.Query().Get("as_of"); spec != "" {
+		s.getPortfolioAsOf(w, r, userID, spec)
+		return
+	}
+
 	positions, err := s.store.GetUserPositions(ctx, userID)
 	if err != nil {
-		writeError(w, "failed to load positions", http.StatusInternalServerError)
+		s.internalError(w, r, "failed to load positions", err)
 		return
 	}
 
+	portfolio := s.buildPortfolio(userID, positions)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(portfolio)
+}
+
+// buildPortfolio aggregates a user's positions into P&L, exposure per cell
+// and margin utilization.
+func (s *Service) buildPortfolio(userID string, positions []model.Position) model.Portfolio {
 	totalPnL := decimal.Zero
 	totalExposure := decimal.Zero
-	totalMargin := decimal.Zero
 	exposureByCell := make(map[string]decimal.Decimal)
 
 	for _, p := range positions {
@@ -428,36 +672,75 @@ func (s *Service) GetPortfolio(w http.ResponseWriter, r *http.Request) {
 		if p.H3CellID != "" {
 			exposureByCell[p.H3CellID] = exposureByCell[p.H3CellID].Add(p.NetQty)
 		}
-
-		// Margin = maximum potential loss per position.
-		// For binary contracts: max loss = max(costBasis - yesQty, costBasis - noQty)
-		lossIfYes := p.CostBasis.Sub(p.YesQty)
-		lossIfNo := p.CostBasis.Sub(p.NoQty)
-		maxLoss := lossIfYes
-		if lossIfNo.GreaterThan(maxLoss) {
-			maxLoss = lossIfNo
-		}
-		if maxLoss.IsPositive() {
-			totalMargin = totalMargin.Add(maxLoss)
-		}
 	}
-
-	marginUtilization := decimal.Zero
-	if s.marginLimit.IsPositive() {
-		marginUtilization = totalMargin.Div(s.marginLimit).Mul(decimal.NewFromInt(100)).Round(2)
+	// Offsetting positions in a cell net to zero; leave the cell out, as
+	// GetUserCellExposures does.
+	for cell, e := range exposureByCell {
+		if e.IsZero() {
+			delete(exposureByCell, cell)
+		}
 	}
 
-	portfolio := model.Portfolio{
+	return model.Portfolio{
 		UserID:            userID,
 		Positions:         positions,
 		TotalPnL:          totalPnL,
 		TotalExposure:     totalExposure,
-		MarginUtilization: marginUtilization,
+		MarginUtilization: s.marginUtilization(totalMargin(positions)),
 		ExposureByCell:    exposureByCell,
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(portfolio)
+// positionSummary returns the user's current position in one market for
+// trade responses; a zero summary if it can't be loaded. It must reflect
+// the trade just written, so it reads the one position from the primary:
+// never the cached position list, which a portfolio read racing the
+// trade's invalidation can leave stale, nor a lagging read replica.
+func (s *Service) positionSummary(ctx context.Context, userID, marketID string) PositionSummary {
+	p, err := s.store.GetUserMarketPosition(store.WithPrimaryReads(ctx), userID, marketID)
+	if err != nil {
+		return PositionSummary{}
+	}
+	return PositionSummary{
+		YesQty:        p.YesQty,
+		NoQty:         p.NoQty,
+		CostBasis:     p.CostBasis,
+		UnrealizedPnL: p.UnrealizedPnL,
+	}
+}
+
+// tradeLeg is the LMSR outcome of trading one side of a market.
+type tradeLeg struct {
+	cost      decimal.Decimal
+	fillPrice decimal.Decimal
+	newQYes   decimal.Decimal
+	newQNo    decimal.Decimal
+}
+
+// priceLeg validates price bounds and computes the cost, average fill price
+// and resulting quantities for trading qty shares of side at (qYes, qNo).
+func priceLeg(mm *lmsr.MarketMaker, qYes, qNo decimal.Decimal, side string, qty decimal.Decimal) (tradeLeg, error) {
+	if side == "YES" {
+		if err := mm.ValidateTrade(qYes, qNo, qty); err != nil {
+			return tradeLeg{}, err
+		}
+		return tradeLeg{
+			cost:      mm.TradeCost(qYes, qNo, qty),
+			fillPrice: mm.FillPrice(qYes, qNo, qty),
+			newQYes:   qYes.Add(qty),
+			newQNo:    qNo,
+		}, nil
+	}
+
+	if err := mm.ValidateTradeNo(qYes, qNo, qty); err != nil {
+		return tradeLeg{}, err
+	}
+	return tradeLeg{
+		cost:      mm.TradeCostNo(qYes, qNo, qty),
+		fillPrice: mm.FillPrice(qNo, qYes, qty), // swap for NO
+		newQYes:   qYes,
+		newQNo:    qNo.Add(qty),
+	}, nil
 }
 
 // writeError writes a JSON error response.
@@ -466,3 +749,40 @@ func writeError(w http.ResponseWriter, message string, status int) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
+
+// writeLookupError maps a failed store lookup to a response: 404 with
+// notFoundMsg for store.ErrNotFound, 503 if the request was cancelled, and
+// 500 otherwise so backend failures aren't reported as missing data.
+func (s *Service) writeLookupError(w http.ResponseWriter, r *http.Request, err error, notFoundMsg string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, notFoundMsg, http.StatusNotFound)
+	case r.Context().Err() != nil:
+		writeError(w, "request cancelled", http.StatusServiceUnavailable)
+	default:
+		s.internalError(w, r, "internal error", err)
+	}
+}
+
+// writeApplyError reports a failed ApplyTrade. Nothing was written either
+// way; a cancelled request is told so rather than reported as a failure.
+func (s *Service) writeApplyError(w http.ResponseWriter, r *http.Request, err error) {
+	if r.Context().Err() != nil {
+		writeError(w, "request cancelled", http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, ErrLockLost) {
+		slog.Warn("trade abandoned: market lock lost", "path", r.URL.Path)
+		writeError(w, "market is temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	s.internalError(w, r, "failed to record trade", err)
+}
+
+// writeCodedError writes a JSON error response with a machine-readable code
+// alongside the human-readable message.
+func writeCodedError(w http.ResponseWriter, code, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "code": code})
+}
@@ -23,22 +23,82 @@ func d(f float64) decimal.Decimal {
 }
 
 // newTestEnv creates a test Service with in-memory store and chi router.
-func newTestEnv(t *testing.T) (*trade.Service, *store.MemoryStore, chi.Router) {
+func newTestEnv(t *testing.T, opts ...trade.Option) (*trade.Service, *store.MemoryStore, chi.Router) {
 	t.Helper()
 	ms := store.NewMemoryStore()
 	limiter := correlation.NewPositionLimiter(d(1000), d(5000), 5)
-	svc := trade.NewService(ms, limiter, nil)
+	svc := trade.NewService(ms, limiter, nil, opts...)
+	requireAdmin := trade.RequireAdminTokens(testAdminTokens)
 
 	r := chi.NewRouter()
+	r.Get("/api/v1/cells", svc.ListCells)
+	r.Get("/api/v1/cells/correlation", svc.GetCellCorrelation)
+	r.Get("/api/v1/markets", svc.ListMarkets)
 	r.Post("/api/v1/markets", svc.CreateMarket)
+	r.Get("/api/v1/markets/search", svc.SearchMarkets)
 	r.Get("/api/v1/markets/{marketID}", svc.GetMarket)
+	r.With(requireAdmin).Delete("/api/v1/markets/{marketID}", svc.HideMarket)
 	r.Get("/api/v1/markets/{marketID}/price", svc.GetPrice)
+	r.Get("/api/v1/markets/{marketID}/liquidity-source", svc.GetLiquiditySource)
+	r.Get("/api/v1/prices", svc.GetPrices)
+	r.Post("/api/v1/prices", svc.GetPrices)
+	r.Get("/api/v1/markets/{marketID}/history", svc.GetMarketHistory)
+	r.Get("/api/v1/markets/{marketID}/history/stream", svc.StreamMarketHistory)
+	r.Get("/api/v1/markets/{marketID}/depth", svc.GetDepth)
+	r.Get("/api/v1/markets/{marketID}/quote", svc.GetQuote)
+	r.Post("/api/v1/quote/roundtrip", svc.QuoteRoundTrip)
+	r.Get("/api/v1/markets/{marketID}/implied", svc.GetImplied)
+	r.Get("/api/v1/markets/{marketID}/open-interest", svc.GetOpenInterest)
+	r.Get("/api/v1/markets/{marketID}/maker", svc.GetMakerReport)
+	r.Post("/api/v1/markets/{marketID}/verify", svc.VerifyMarket)
+	r.Get("/api/v1/markets/{marketID}/verify-chain", svc.VerifyChain)
+	r.Get("/api/v1/markets/{marketID}/trading-hours", svc.GetTradingHours)
+	r.With(requireAdmin).Patch("/api/v1/markets/{marketID}/trading-hours", svc.UpdateTradingHours)
+	r.Get("/api/v1/markets/{marketID}/trade-size", svc.GetTradeSize)
+	r.With(requireAdmin).Patch("/api/v1/markets/{marketID}/trade-size", svc.UpdateTradeSize)
+	r.With(requireAdmin).Post("/api/v1/markets/{marketID}/reliquify", svc.Reliquify)
+	r.With(requireAdmin).Post("/api/v1/markets/{marketID}/reprice", svc.Reprice)
+	r.Post("/api/v1/products", svc.CreateProduct)
+	r.Get("/api/v1/products/{productID}", svc.GetProduct)
+	r.Get("/api/v1/markets/{marketID}/settle/preview", svc.PreviewSettlement)
+	r.With(requireAdmin).Post("/api/v1/markets/{marketID}/settle", svc.SettleMarket)
+	r.With(requireAdmin).Post("/api/v1/markets/{marketID}/unsettle", svc.UnsettleMarket)
 	r.Post("/api/v1/trade", svc.ExecuteTrade)
 	r.Get("/api/v1/portfolio/{userID}", svc.GetPortfolio)
+	r.Get("/api/v1/portfolio/{userID}/markets/{marketID}", svc.GetPosition)
+	r.Post("/api/v1/portfolio/{userID}/markets/{marketID}/close", svc.ClosePosition)
+	r.Post("/api/v1/portfolios", svc.GetPortfolios)
+	r.Post("/api/v1/margin/estimate", svc.EstimateMargin)
+	r.Get("/api/v1/leaderboard", svc.GetLeaderboard)
+	r.With(requireAdmin).Post("/api/v1/observations", svc.CreateObservation)
+	r.Get("/api/v1/observations", svc.GetObservation)
+	r.With(requireAdmin).Get("/api/v1/admin/snapshot", svc.Snapshot)
+	r.With(requireAdmin).Post("/api/v1/admin/restore", svc.Restore)
+	r.With(requireAdmin).Get("/api/v1/admin/exposure", svc.GetSystemExposure)
+	r.With(requireAdmin).Post("/api/v1/admin/users/{userID}/anonymize", svc.AnonymizeUser)
+	r.With(requireAdmin).Post("/api/v1/admin/users/{userID}/rebuild-positions", svc.RebuildPositions)
+	r.With(requireAdmin).Get("/api/v1/admin/ws-stats", svc.GetWSStats)
+	r.With(requireAdmin).Get("/api/v1/admin/audit", svc.GetAuditLog)
 
 	return svc, ms, r
 }
 
+// serveJSON serves req and decodes the JSON response body as a T.
+func serveJSON[T any](t *testing.T, router http.Handler, req *http.Request) (*httptest.ResponseRecorder, T) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var v T
+	json.Unmarshal(w.Body.Bytes(), &v)
+	return w, v
+}
+
+// getJSON serves GET path and decodes the JSON response body as a T.
+func getJSON[T any](t *testing.T, router http.Handler, path string) (*httptest.ResponseRecorder, T) {
+	t.Helper()
+	return serveJSON[T](t, router, httptest.NewRequest("GET", path, nil))
+}
+
 // seedMarket creates a test market directly in the store.
 func seedMarket(t *testing.T, ms *store.MemoryStore, contractID, h3Cell string, b float64) *model.Market {
 	t.Helper()
@@ -169,8 +229,8 @@ func TestExecuteTrade_InvalidSide(t *testing.T) {
 		Quantity:   d(10),
 	})
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected 400 for invalid side, got %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for invalid side, got %d", w.Code)
 	}
 }
 
@@ -185,8 +245,8 @@ func TestExecuteTrade_ZeroQuantity(t *testing.T) {
 		Quantity:   decimal.Zero,
 	})
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected 400 for zero quantity, got %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for zero quantity, got %d", w.Code)
 	}
 }
 
@@ -377,6 +437,34 @@ func TestGetPortfolio_WithPositions(t *testing.T) {
 	}
 }
 
+func TestGetPortfolio_OffsetCellLeftOutOfExposure(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+	seedMarket(t, ms, "ATMX-872a1070c-PRECIP-25MM-20250815", "872a1070c", 100)
+
+	// YES and NO of the same size in the second cell net to zero.
+	for _, req := range []trade.TradeRequest{
+		{UserID: "user1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", Side: "YES", Quantity: d(10)},
+		{UserID: "user1", ContractID: "ATMX-872a1070c-PRECIP-25MM-20250815", Side: "YES", Quantity: d(10)},
+		{UserID: "user1", ContractID: "ATMX-872a1070c-PRECIP-25MM-20250815", Side: "NO", Quantity: d(10)},
+	} {
+		if w := doTrade(t, router, req); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, portfolio := getJSON[model.Portfolio](t, router, "/api/v1/portfolio/user1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := portfolio.ExposureByCell["872a1070c"]; ok {
+		t.Errorf("fully offset cell should be absent, got %v", portfolio.ExposureByCell)
+	}
+	if !portfolio.ExposureByCell["872a1070b"].Equal(d(10)) {
+		t.Errorf("exposure_by_cell = %v, want 872a1070b = 10", portfolio.ExposureByCell)
+	}
+}
+
 func TestGetPortfolio_Empty(t *testing.T) {
 	_, _, router := newTestEnv(t)
 
@@ -441,8 +529,8 @@ func TestCreateMarket_InvalidTicker(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected 400 for invalid ticker, got %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for invalid ticker, got %d", w.Code)
 	}
 }
 
@@ -470,3 +558,100 @@ func TestCreateMarket_DefaultB(t *testing.T) {
 		t.Errorf("expected default b=100, got %s", market.B)
 	}
 }
+
+func TestGetPrice_StringsRoundTripExactly(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	m := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// More digits than float64 can hold.
+	yes := decimal.RequireFromString("0.12345678901234567891")
+	no := decimal.RequireFromString("0.87654321098765432109")
+	ms.UpdateMarketState(context.Background(), m.ID, decimal.Zero, decimal.Zero, yes, no)
+
+	req := httptest.NewRequest("GET", "/api/v1/markets/"+m.ID+"/price", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Decode generically: every field must be a JSON string.
+	var raw map[string]any
+	json.Unmarshal(w.Body.Bytes(), &raw)
+	for _, k := range []string{"yes", "no", "spread"} {
+		if _, ok := raw[k].(string); !ok {
+			t.Errorf("%s should be a JSON string, got %T", k, raw[k])
+		}
+	}
+
+	var resp trade.PriceResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if got := decimal.RequireFromString(resp.Yes); !got.Equal(yes) || resp.Yes != yes.String() {
+		t.Errorf("yes = %s, want %s", resp.Yes, yes)
+	}
+	if got := decimal.RequireFromString(resp.No); !got.Equal(no) || resp.No != no.String() {
+		t.Errorf("no = %s, want %s", resp.No, no)
+	}
+	if resp.Spread != "0" {
+		t.Errorf("spread = %s, want 0", resp.Spread)
+	}
+}
+
+func TestExecuteTrade_CancelledContext(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	body, _ := json.Marshal(trade.TradeRequest{
+		UserID:     "user1",
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815",
+		Side:       "YES",
+		Quantity:   d(10),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("POST", "/api/v1/trade", bytes.NewReader(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for cancelled request, got %d: %s", w.Code, w.Body.String())
+	}
+
+	entries, _ := ms.GetLedgerEntriesByUser(context.Background(), "user1")
+	if len(entries) != 0 {
+		t.Errorf("cancelled trade must not be recorded, got %d entries", len(entries))
+	}
+	market, _ := ms.GetMarketByContract(context.Background(), "ATMX-872a1070b-PRECIP-25MM-20250815")
+	if !market.QYes.IsZero() {
+		t.Errorf("cancelled trade must not move the market, got q_yes=%s", market.QYes)
+	}
+}
+
+// lostLocker grants every lock, then reports it lost at once, as a Redis
+// lock whose key vanished mid-hold does at its next renewal.
+type lostLocker struct{}
+
+func (lostLocker) Lock(ctx context.Context, key string) (context.Context, func(), error) {
+	held, cancel := context.WithCancelCause(ctx)
+	cancel(trade.ErrLockLost)
+	return held, func() {}, nil
+}
+
+func TestExecuteTrade_LockLostBeforeWrite(t *testing.T) {
+	_, ms, router := newTestEnv(t, trade.WithLocker(lostLocker{}))
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", Side: "YES", Quantity: d(10)})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when the market lock is lost, got %d: %s", w.Code, w.Body.String())
+	}
+
+	entries, _ := ms.GetLedgerEntriesByUser(context.Background(), "user1")
+	if len(entries) != 0 {
+		t.Errorf("trade without the lock must not be recorded, got %d entries", len(entries))
+	}
+	market, _ := ms.GetMarketByContract(context.Background(), "ATMX-872a1070b-PRECIP-25MM-20250815")
+	if !market.QYes.IsZero() {
+		t.Errorf("trade without the lock must not move the market, got q_yes=%s", market.QYes)
+	}
+}
@@ -0,0 +1,276 @@
+// Market settlement and its dry-run preview.
+
+package trade
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/events"
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// SettleRequest is the JSON body for POST /markets/{marketID}/settle.
+type SettleRequest struct {
+	Outcome string `json:"outcome"` // "YES" or "NO"
+}
+
+// Validate reports every problem with a settle request.
+func (req SettleRequest) Validate() []FieldError {
+	if req.Outcome != "YES" && req.Outcome != "NO" {
+		return []FieldError{{"outcome", "outcome must be YES or NO"}}
+	}
+	return nil
+}
+
+// SettlementPayout is one user's result when a market settles.
+type SettlementPayout struct {
+	UserID string          `json:"user_id"`
+	YesQty decimal.Decimal `json:"yes_qty"` // shares held at settlement
+	NoQty  decimal.Decimal `json:"no_qty"`
+	// Payout is the cash the user receives: winning shares × 1. Negative
+	// for a short position in the winning side.
+	Payout decimal.Decimal `json:"payout"`
+	// RealizedPnL is the payout minus the average-cost basis still open.
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+}
+
+// SettlementSummary is the JSON body returned by both the settle endpoint
+// and its preview.
+type SettlementSummary struct {
+	MarketID         string             `json:"market_id"`
+	ContractID       string             `json:"contract_id"`
+	Outcome          string             `json:"outcome"`
+	Settled          bool               `json:"settled"` // false for a preview
+	SettledAt        *time.Time         `json:"settled_at,omitempty"`
+	TotalPayout      decimal.Decimal    `json:"total_payout"`
+	TotalRealizedPnL decimal.Decimal    `json:"total_realized_pnl"`
+	Payouts          []SettlementPayout `json:"payouts"`
+}
+
+// planSettlement computes the settlement of m under outcome from its
+// ledger, booking P&L by method: the per-user summary and the ledger
+// entries that close every open position at the payout (1 for the winning
+// side, 0 for the losing one). Preview and settle share it so they can't diverge; the entries
+// have no IDs until SettleMarket assigns them.
+func planSettlement(method CostBasisMethod, m *model.Market, history []model.LedgerEntry, outcome string, at time.Time) (SettlementSummary, []*model.LedgerEntry) {
+	books := make(map[string]map[string]*sideBook)
+	for _, e := range withoutReversed(history) {
+		if e.MarketID != m.ID {
+			continue
+		}
+		if books[e.UserID] == nil {
+			books[e.UserID] = map[string]*sideBook{"YES": {method: method}, "NO": {method: method}}
+		}
+		books[e.UserID][e.Side].apply(e.Quantity, e.Cost)
+	}
+
+	users := make([]string, 0, len(books))
+	for u := range books {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+
+	summary := SettlementSummary{
+		MarketID:         m.ID,
+		ContractID:       m.ContractID,
+		Outcome:          outcome,
+		TotalPayout:      decimal.Zero,
+		TotalRealizedPnL: decimal.Zero,
+		Payouts:          []SettlementPayout{},
+	}
+	var entries []*model.LedgerEntry
+
+	for _, u := range users {
+		p := SettlementPayout{
+			UserID:      u,
+			YesQty:      books[u]["YES"].shares,
+			NoQty:       books[u]["NO"].shares,
+			Payout:      decimal.Zero,
+			RealizedPnL: decimal.Zero,
+		}
+		if p.YesQty.IsZero() && p.NoQty.IsZero() {
+			continue
+		}
+
+		for _, side := range []string{"YES", "NO"} {
+			book := books[u][side]
+			if book.shares.IsZero() {
+				continue
+			}
+			price := decimal.Zero
+			if side == outcome {
+				price = decimal.NewFromInt(1)
+			}
+			qty := book.shares.Neg()
+			cost := qty.Mul(price)
+			realized := book.apply(qty, cost)
+
+			p.Payout = p.Payout.Sub(cost)
+			p.RealizedPnL = p.RealizedPnL.Add(realized)
+			entries = append(entries, &model.LedgerEntry{
+				UserID:      u,
+				MarketID:    m.ID,
+				ContractID:  m.ContractID,
+				Side:        side,
+				Quantity:    qty,
+				Price:       price,
+				Cost:        cost,
+				Timestamp:   at,
+				RealizedPnL: realized,
+				Kind:        model.EntryKindSettlement,
+			})
+		}
+
+		summary.TotalPayout = summary.TotalPayout.Add(p.Payout)
+		summary.TotalRealizedPnL = summary.TotalRealizedPnL.Add(p.RealizedPnL)
+		summary.Payouts = append(summary.Payouts, p)
+	}
+	return summary, entries
+}
+
+// PreviewSettlement handles GET /api/v1/markets/{marketID}/settle/preview?outcome=YES
+// Computes each user's payout and realized P&L if the market settled now
+// with the given outcome, without changing any state.
+func (s *Service) PreviewSettlement(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	req := SettleRequest{Outcome: r.URL.Query().Get("outcome")}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if market.Status != "open" {
+		writeError(w, "market is already settled", http.StatusConflict)
+		return
+	}
+
+	history, err := s.store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market history", err)
+		return
+	}
+
+	summary, _ := planSettlement(s.costBasis, market, history, req.Outcome, s.now().UTC())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// SettleMarket handles POST /api/v1/markets/{marketID}/settle (admin)
+// Marks the market settled with the given outcome and appends one
+// settlement ledger entry per user and side closing the position at the
+// payout, booking its realized P&L.
+func (s *Service) SettleMarket(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	var req SettleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	// Settle under the market lock so no trade lands between reading the
+	// ledger and closing the market.
+	_, unlock, ok := s.lockMarket(w, r, marketID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if market.Status != "open" {
+		writeError(w, "market is already settled", http.StatusConflict)
+		return
+	}
+
+	history, err := s.store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market history", err)
+		return
+	}
+
+	now := s.now().UTC()
+	summary, entries := planSettlement(s.costBasis, market, history, req.Outcome, now)
+	for _, e := range entries {
+		e.ID = s.ids.NewID()
+	}
+
+	// The market closes and its payouts are booked in one transaction: a
+	// failure leaves it open with no payouts, so the settle can be retried.
+	if err := s.store.SettleMarket(ctx, marketID, req.Outcome, now, entries); err != nil {
+		if errors.Is(err, store.ErrMarketNotOpen) {
+			writeError(w, "market is already settled", http.StatusConflict)
+			return
+		}
+		s.internalError(w, r, "failed to settle market", err,
+			"market", marketID, "payouts", len(entries))
+		return
+	}
+
+	if market.HiddenAt == nil {
+		metrics.ActiveMarkets.Dec() // hiding already took it off the gauge
+	}
+	summary.Settled = true
+	summary.SettledAt = &now
+
+	s.emit(ctx, events.TypeMarketSettled, events.MarketSettled{
+		MarketID:    market.ID,
+		ContractID:  market.ContractID,
+		H3CellID:    market.H3CellID,
+		Outcome:     req.Outcome,
+		SettledAt:   now,
+		Users:       len(summary.Payouts),
+		TotalPayout: summary.TotalPayout,
+	})
+
+	slog.Info("market settled",
+		"market", marketID,
+		"outcome", req.Outcome,
+		"users", len(summary.Payouts),
+		"total_payout", summary.TotalPayout.String(),
+	)
+	s.audit(r, AuditActionSettle, marketID, map[string]string{
+		"outcome":      req.Outcome,
+		"users":        strconv.Itoa(len(summary.Payouts)),
+		"total_payout": summary.TotalPayout.String(),
+	})
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(WSMessage{
+			Type:       "market_settled",
+			MarketID:   market.ID,
+			ContractID: market.ContractID,
+			H3CellID:   market.H3CellID,
+			Outcome:    req.Outcome,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,178 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postSettle(t *testing.T, router http.Handler, marketID, outcome string) (*httptest.ResponseRecorder, trade.SettlementSummary) {
+	t.Helper()
+	body, _ := json.Marshal(trade.SettleRequest{Outcome: outcome})
+	req := adminRequest("POST", "/api/v1/markets/"+marketID+"/settle", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return serveJSON[trade.SettlementSummary](t, router, req)
+}
+
+// seedSettlementTrades builds a market with a long YES holder, a NO holder,
+// a user holding both sides and a user who has already gone flat.
+func seedSettlementTrades(t *testing.T) (*model.Market, chi.Router, func() []model.LedgerEntry) {
+	t.Helper()
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "alice", ContractID: contractID, Side: "YES", Quantity: d(10)},
+		{UserID: "bob", ContractID: contractID, Side: "NO", Quantity: d(8)},
+		{UserID: "carol", ContractID: contractID, Side: "YES", Quantity: d(4)},
+		{UserID: "carol", ContractID: contractID, Side: "NO", Quantity: d(3)},
+		{UserID: "dave", ContractID: contractID, Side: "YES", Quantity: d(5)},
+		{UserID: "dave", ContractID: contractID, Side: "YES", Quantity: d(-5)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+	ledger := func() []model.LedgerEntry {
+		entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), market.ID)
+		return entries
+	}
+	return market, router, ledger
+}
+
+func TestSettlementPreview_MatchesSettle(t *testing.T) {
+	market, router, ledger := seedSettlementTrades(t)
+	before := len(ledger())
+
+	w, preview := getJSON[trade.SettlementSummary](t, router, "/api/v1/markets/"+market.ID+"/settle/preview?outcome=YES")
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if preview.Settled {
+		t.Error("preview must not report settled")
+	}
+	if n := len(ledger()); n != before {
+		t.Fatalf("preview wrote %d ledger entries", n-before)
+	}
+
+	// dave is flat and gets nothing; the other three are listed in order.
+	var users []string
+	for _, p := range preview.Payouts {
+		users = append(users, p.UserID)
+	}
+	if len(users) != 3 || users[0] != "alice" || users[1] != "bob" || users[2] != "carol" {
+		t.Fatalf("payout users = %v, want [alice bob carol]", users)
+	}
+	// YES wins: alice receives 10, bob 0, carol 4.
+	if !preview.TotalPayout.Equal(d(14)) {
+		t.Errorf("total payout = %s, want 14", preview.TotalPayout)
+	}
+
+	w, settled := postSettle(t, router, market.ID, "YES")
+	if w.Code != http.StatusOK {
+		t.Fatalf("settle: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !settled.Settled || settled.SettledAt == nil {
+		t.Error("settle response should be marked settled")
+	}
+
+	// Totals recomputed from what settle actually wrote match the preview.
+	payout, realized := decimal.Zero, decimal.Zero
+	written := 0
+	for _, e := range ledger() {
+		if e.Kind != model.EntryKindSettlement {
+			continue
+		}
+		written++
+		payout = payout.Sub(e.Cost)
+		realized = realized.Add(e.RealizedPnL)
+	}
+	if written != 4 { // alice YES, bob NO, carol YES + NO
+		t.Errorf("expected 4 settlement entries, got %d", written)
+	}
+	if !payout.Equal(preview.TotalPayout) {
+		t.Errorf("written payout %s != preview %s", payout, preview.TotalPayout)
+	}
+	if !realized.Equal(preview.TotalRealizedPnL) {
+		t.Errorf("written realized P&L %s != preview %s", realized, preview.TotalRealizedPnL)
+	}
+	if !settled.TotalPayout.Equal(preview.TotalPayout) || !settled.TotalRealizedPnL.Equal(preview.TotalRealizedPnL) {
+		t.Errorf("settle summary %+v differs from preview %+v", settled, preview)
+	}
+}
+
+func TestSettleMarket_ClosesPositionsAndMarket(t *testing.T) {
+	market, router, ledger := seedSettlementTrades(t)
+
+	var bobCost decimal.Decimal
+	for _, e := range ledger() {
+		if e.UserID == "bob" {
+			bobCost = bobCost.Add(e.Cost)
+		}
+	}
+
+	if w, _ := postSettle(t, router, market.ID, "YES"); w.Code != http.StatusOK {
+		t.Fatalf("settle: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The losing NO holder realizes the full cost as a loss.
+	for _, e := range ledger() {
+		if e.UserID == "bob" && e.Kind == model.EntryKindSettlement {
+			if !e.Price.IsZero() || !e.Quantity.Equal(d(-8)) {
+				t.Errorf("bob settlement entry = %+v, want -8 NO at 0", e)
+			}
+			if !e.RealizedPnL.Equal(bobCost.Neg()) {
+				t.Errorf("bob realized %s, want %s", e.RealizedPnL, bobCost.Neg())
+			}
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/markets/"+market.ID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	if m.Status != "settled" || m.Outcome == nil || *m.Outcome != "YES" || m.SettledAt == nil {
+		t.Errorf("market not settled: status=%s outcome=%v", m.Status, m.Outcome)
+	}
+
+	// Trading, settling again and previewing are all rejected.
+	tr := trade.TradeRequest{UserID: "alice", ContractID: market.ContractID, Side: "YES", Quantity: d(1)}
+	if w := doTrade(t, router, tr); w.Code != http.StatusConflict {
+		t.Errorf("trade after settlement: expected 409, got %d", w.Code)
+	}
+	if w, _ := postSettle(t, router, market.ID, "NO"); w.Code != http.StatusConflict {
+		t.Errorf("second settle: expected 409, got %d", w.Code)
+	}
+	if w, _ := getJSON[trade.SettlementSummary](t, router, "/api/v1/markets/"+market.ID+"/settle/preview?outcome=YES"); w.Code != http.StatusConflict {
+		t.Errorf("preview after settlement: expected 409, got %d", w.Code)
+	}
+
+	// The ledger still replays cleanly: settlement entries are skipped.
+	if w, resp := postVerify(t, router, market.ID); w.Code != http.StatusOK || !resp.OK {
+		t.Errorf("verify after settlement: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSettleMarket_InvalidOutcome(t *testing.T) {
+	market, router, _ := seedSettlementTrades(t)
+	if w, _ := postSettle(t, router, market.ID, "MAYBE"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("settle: expected 422, got %d", w.Code)
+	}
+	if w, _ := getJSON[trade.SettlementSummary](t, router, "/api/v1/markets/"+market.ID+"/settle/preview?outcome="); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("preview: expected 422, got %d", w.Code)
+	}
+	if w, _ := postSettle(t, router, "no-such-market", "YES"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown market: expected 404, got %d", w.Code)
+	}
+}
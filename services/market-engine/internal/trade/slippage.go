@@ -0,0 +1,32 @@
+// Single-shot slippage guard on trade execution.
+
+package trade
+
+import (
+	"net/http"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrCodeSlippageExceeded is returned when a trade's fill price is worse
+// than the client's max_fill_price (buys) or min_fill_price (sells).
+const ErrCodeSlippageExceeded = "slippage_exceeded"
+
+// checkSlippage writes a coded 409 and returns false if fillPrice breaches
+// the request's price limit. Unlike a resting limit order the trade is
+// simply rejected; the client can re-quote and retry.
+func checkSlippage(w http.ResponseWriter, req TradeRequest, fillPrice decimal.Decimal) bool {
+	if req.MaxFillPrice != nil && fillPrice.GreaterThan(*req.MaxFillPrice) {
+		writeCodedError(w, ErrCodeSlippageExceeded,
+			"fill price "+fillPrice.StringFixed(4)+" exceeds max_fill_price "+req.MaxFillPrice.String(),
+			http.StatusConflict)
+		return false
+	}
+	if req.MinFillPrice != nil && fillPrice.LessThan(*req.MinFillPrice) {
+		writeCodedError(w, ErrCodeSlippageExceeded,
+			"fill price "+fillPrice.StringFixed(4)+" is below min_fill_price "+req.MinFillPrice.String(),
+			http.StatusConflict)
+		return false
+	}
+	return true
+}
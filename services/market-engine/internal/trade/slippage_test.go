@@ -0,0 +1,101 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func price(v float64) *decimal.Decimal {
+	p := decimal.NewFromFloat(v)
+	return &p
+}
+
+func TestSlippage_BuyAboveCapRejected(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// Buying 10 YES at b=100 from 0.50 fills at ~0.5125.
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10), MaxFillPrice: price(0.51),
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != trade.ErrCodeSlippageExceeded {
+		t.Errorf("expected code %q, got %v", trade.ErrCodeSlippageExceeded, body)
+	}
+
+	// Nothing was written.
+	after, _ := ms.GetMarket(context.Background(), market.ID)
+	if !after.QYes.IsZero() {
+		t.Errorf("market moved despite rejection: q_yes=%s", after.QYes)
+	}
+	if entries, _ := ms.GetLedgerEntriesByUser(context.Background(), "u1"); len(entries) != 0 {
+		t.Errorf("expected no ledger entries, got %d", len(entries))
+	}
+}
+
+func TestSlippage_BuyWithinCapFills(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10), MaxFillPrice: price(0.52),
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.FillPrice.GreaterThan(*price(0.52)) {
+		t.Errorf("fill price %s above cap", resp.FillPrice)
+	}
+}
+
+func TestSlippage_SellBelowFloorRejected(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(10)})
+
+	// Selling back from ~0.525 fills at ~0.5125.
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(-10), MinFillPrice: price(0.52),
+	})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doTrade(t, router, trade.TradeRequest{
+		UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(-10), MinFillPrice: price(0.5),
+	})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSlippage_InvalidLimits(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for name, req := range map[string]trade.TradeRequest{
+		"max out of range": {UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(1), MaxFillPrice: price(1.5)},
+		"max on a sell":    {UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(-1), MaxFillPrice: price(0.6)},
+		"min on a buy":     {UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(1), MinFillPrice: price(0.4)},
+	} {
+		if w := doTrade(t, router, req); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", name, w.Code)
+		}
+	}
+}
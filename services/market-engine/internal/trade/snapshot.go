@@ -0,0 +1,139 @@
+// State snapshot and restore for disaster recovery.
+
+package trade
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// RestoreResponse is the JSON body returned from POST /admin/restore.
+type RestoreResponse struct {
+	Products      int `json:"products"`
+	Markets       int `json:"markets"`
+	LedgerEntries int `json:"ledger_entries"`
+
+	LiquidityChanges int `json:"liquidity_changes"`
+}
+
+// Snapshot handles GET /api/v1/admin/snapshot (admin).
+// Streams every product, market, ledger entry and liquidity change as one
+// gzipped JSON model.Snapshot, served as a file download.
+func (s *Service) Snapshot(w http.ResponseWriter, r *http.Request) {
+	snap, err := s.store.Snapshot(r.Context())
+	if err != nil {
+		s.internalError(w, r, "failed to export snapshot", err)
+		return
+	}
+	snap.Version = model.SnapshotVersion
+	snap.CreatedAt = s.now().UTC()
+
+	name := "atmx-snapshot-" + snap.CreatedAt.Format("20060102T150405Z") + ".json.gz"
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(snap); err != nil {
+		slog.Error("snapshot write failed", "err", err)
+		return
+	}
+	gz.Close()
+
+	slog.Info("snapshot exported",
+		"products", len(snap.Products),
+		"markets", len(snap.Markets),
+		"ledger_entries", len(snap.Ledger),
+		"liquidity_changes", len(snap.LiquidityChanges),
+	)
+}
+
+// Restore handles POST /api/v1/admin/restore (admin).
+// Loads a snapshot, gzipped or plain JSON, into an empty store in one
+// transaction. ?force=true replaces a non-empty store's state instead of
+// failing with 409. Trading should be stopped while restoring: trades
+// that land during the restore may be lost.
+func (s *Service) Restore(w http.ResponseWriter, r *http.Request) {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	body, err := decompressed(r.Body)
+	if err != nil {
+		writeError(w, "invalid snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var snap model.Snapshot
+	if err := json.NewDecoder(body).Decode(&snap); err != nil {
+		writeError(w, "invalid snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if snap.Version != model.SnapshotVersion {
+		writeError(w, "unsupported snapshot version "+strconv.Itoa(snap.Version), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.store.Restore(r.Context(), &snap, force); err != nil {
+		switch {
+		case errors.Is(err, store.ErrStoreNotEmpty):
+			writeError(w, "store is not empty; use ?force=true to replace its state", http.StatusConflict)
+		case errors.Is(err, store.ErrInvalidSnapshot):
+			writeError(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			s.internalError(w, r, "failed to restore snapshot", err)
+		}
+		return
+	}
+
+	active := 0
+	for _, m := range snap.Markets {
+		if m.Status == "open" && m.HiddenAt == nil {
+			active++
+		}
+	}
+	metrics.ActiveMarkets.Set(float64(active))
+
+	slog.Warn("snapshot restored",
+		"snapshot_created_at", snap.CreatedAt,
+		"force", force,
+		"products", len(snap.Products),
+		"markets", len(snap.Markets),
+		"ledger_entries", len(snap.Ledger),
+		"liquidity_changes", len(snap.LiquidityChanges),
+	)
+	s.audit(r, AuditActionRestore, "snapshot", map[string]string{
+		"force":               strconv.FormatBool(force),
+		"snapshot_created_at": snap.CreatedAt.UTC().Format(time.RFC3339),
+		"products":            strconv.Itoa(len(snap.Products)),
+		"markets":             strconv.Itoa(len(snap.Markets)),
+		"ledger_entries":      strconv.Itoa(len(snap.Ledger)),
+		"liquidity_changes":   strconv.Itoa(len(snap.LiquidityChanges)),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RestoreResponse{
+		Products:      len(snap.Products),
+		Markets:       len(snap.Markets),
+		LedgerEntries: len(snap.Ledger),
+
+		LiquidityChanges: len(snap.LiquidityChanges),
+	})
+}
+
+// decompressed returns r, transparently gunzipped if it starts with the
+// gzip magic number.
+func decompressed(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
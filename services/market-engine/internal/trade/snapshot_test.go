@@ -0,0 +1,211 @@
+package trade_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+const (
+	testAdminToken = "test-admin-token"
+	testOpsToken   = "test-ops-token"
+)
+
+// testAdminTokens are the admin tokens newTestEnv accepts, by actor.
+var testAdminTokens = map[string]string{
+	trade.DefaultAdminActor: testAdminToken,
+	"ops@atmx":              testOpsToken,
+}
+
+func adminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+// getSnapshot downloads and decodes a snapshot, returning the raw gzip too.
+func getSnapshot(t *testing.T, router chi.Router) ([]byte, model.Snapshot) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/admin/snapshot", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("snapshot: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	raw := w.Body.Bytes()
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("snapshot is not gzipped: %v", err)
+	}
+	var snap model.Snapshot
+	if err := json.NewDecoder(gz).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	return raw, snap
+}
+
+func postRestore(router chi.Router, query string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/admin/restore"+query, bytes.NewReader(body)))
+	return w
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	rain := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	wind := "ATMX-872a1070c-WIND-30MS-20250815"
+	rainMarket := seedMarket(t, ms, rain, "872a1070b", 100)
+	windMarket := seedMarket(t, ms, wind, "872a1070c", 150)
+	if w, _ := postProduct(t, router, trade.CreateProductRequest{
+		Type: "TEMP", Threshold: "35C", Date: "20250816", Cells: []string{"872a10711", "872a10712"},
+	}); w.Code != http.StatusCreated {
+		t.Fatalf("product: %d %s", w.Code, w.Body.String())
+	}
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "alice", ContractID: rain, Side: "YES", Quantity: d(10)},
+		{UserID: "bob", ContractID: rain, Side: "NO", Quantity: d(4)},
+		{UserID: "alice", ContractID: wind, Side: "NO", Quantity: d(7)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade: %d %s", w.Code, w.Body.String())
+		}
+	}
+	if w, _ := postReliquify(t, router, rainMarket.ID, forecast(10, 25, 40)); w.Code != http.StatusOK {
+		t.Fatalf("reliquify: %d %s", w.Code, w.Body.String())
+	}
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "carol", ContractID: rain, Side: "YES", Quantity: d(3)}); w.Code != http.StatusOK {
+		t.Fatalf("trade: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := postSettle(t, router, windMarket.ID, "NO"); w.Code != http.StatusOK {
+		t.Fatalf("settle: %d %s", w.Code, w.Body.String())
+	}
+
+	raw, want := getSnapshot(t, router)
+	if want.Version != model.SnapshotVersion || len(want.Products) != 1 || len(want.Markets) != 4 || len(want.Ledger) != 5 || len(want.LiquidityChanges) != 1 {
+		t.Fatalf("unexpected snapshot: v%d, %d products, %d markets, %d entries, %d liquidity changes",
+			want.Version, len(want.Products), len(want.Markets), len(want.Ledger), len(want.LiquidityChanges))
+	}
+
+	_, _, restored := newTestEnv(t)
+	w := postRestore(restored, "", raw)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.RestoreResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp != (trade.RestoreResponse{Products: 1, Markets: 4, LedgerEntries: 5, LiquidityChanges: 1}) {
+		t.Errorf("restore response = %+v", resp)
+	}
+
+	_, got := getSnapshot(t, restored)
+	for name, pair := range map[string][2]any{
+		"products": {want.Products, got.Products},
+		"markets":  {want.Markets, got.Markets},
+		"ledger":   {want.Ledger, got.Ledger},
+
+		"liquidity changes": {want.LiquidityChanges, got.LiquidityChanges},
+	} {
+		if a, b := mustJSON(t, pair[0]), mustJSON(t, pair[1]); a != b {
+			t.Errorf("%s differ after restore:\n want %s\n  got %s", name, a, b)
+		}
+	}
+
+	// Verification replays the restored entries at the b in force when
+	// each was written.
+	if w, resp := postVerify(t, restored, rainMarket.ID); w.Code != http.StatusOK || !resp.OK || !resp.StateMatches {
+		t.Errorf("verify after restore: %d %s", w.Code, w.Body.String())
+	}
+
+	// The restored store trades on from the restored state.
+	if w := doTrade(t, restored, trade.TradeRequest{UserID: "bob", ContractID: rain, Side: "NO", Quantity: d(-4)}); w.Code != http.StatusOK {
+		t.Errorf("trade after restore: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRestore_NonEmptyStoreNeedsForce(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	doTrade(t, router, trade.TradeRequest{UserID: "alice", ContractID: contractID, Side: "YES", Quantity: d(10)})
+	_, snap := getSnapshot(t, router)
+
+	// Plain JSON is accepted as well as gzip.
+	body := []byte(mustJSON(t, snap))
+	if w := postRestore(router, "", body); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Trade on, then force the snapshot back: the later trade is gone.
+	doTrade(t, router, trade.TradeRequest{UserID: "bob", ContractID: contractID, Side: "YES", Quantity: d(5)})
+	if w := postRestore(router, "?force=true", body); w.Code != http.StatusOK {
+		t.Fatalf("forced restore: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, after := getSnapshot(t, router); mustJSON(t, after.Ledger) != mustJSON(t, snap.Ledger) {
+		t.Errorf("ledger after forced restore = %s", mustJSON(t, after.Ledger))
+	}
+
+	snap.Version = 99
+	if w := postRestore(router, "?force=true", []byte(mustJSON(t, snap))); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown version: expected 422, got %d", w.Code)
+	}
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	// Market routes that change settlement or configuration are admin too.
+	for _, route := range []struct{ method, path string }{
+		{"POST", "/api/v1/markets/" + market.ID + "/settle"},
+		{"PATCH", "/api/v1/markets/" + market.ID + "/trading-hours"},
+		{"PATCH", "/api/v1/markets/" + market.ID + "/trade-size"},
+		{"POST", "/api/v1/observations"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, route.path, strings.NewReader(`{}`)))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without admin token: expected 401, got %d", route.method, route.path, w.Code)
+		}
+	}
+	if m, _ := ms.GetMarket(context.Background(), market.ID); m.Status != "open" {
+		t.Errorf("unauthenticated settle changed status to %s", m.Status)
+	}
+
+	for _, auth := range []string{"", "Bearer wrong", testAdminToken} {
+		req := httptest.NewRequest("GET", "/api/v1/admin/snapshot", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", auth, w.Code)
+		}
+	}
+
+	// With no token configured the admin API is off.
+	w := httptest.NewRecorder()
+	trade.RequireAdmin("")(http.NotFoundHandler()).ServeHTTP(w, adminRequest("GET", "/", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("disabled admin API: expected 403, got %d", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+// Per-market subsidy budget on the maker's worst-case loss.
+
+package trade
+
+import (
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ErrCodeSubsidyBudgetExceeded is the error code for a trade that would
+// push the maker's worst-case loss past the market's subsidy budget.
+const ErrCodeSubsidyBudgetExceeded = "subsidy_budget_exceeded"
+
+// makerWorstCaseLoss is the maker's loss (≥ 0) under the worse outcome
+// when traders hold yes and no shares and have paid collected for them.
+func makerWorstCaseLoss(yes, no, collected decimal.Decimal) decimal.Decimal {
+	loss := decimal.Max(yes, no).Sub(collected)
+	if loss.IsNegative() {
+		return decimal.Zero
+	}
+	return loss
+}
+
+// checkSubsidyBudget writes a coded 409 and returns false if the trade,
+// moving m to (newQYes, newQNo) for cost, would leave the maker's
+// worst-case loss above m's subsidy budget; loss is that worst case. A
+// trade that lowers the loss always passes, so a market already past its
+// budget (say after its b was raised) can still be traded back under it.
+func checkSubsidyBudget(w http.ResponseWriter, m *model.Market, newQYes, newQNo, cost decimal.Decimal) (loss decimal.Decimal, ok bool) {
+	if m.SubsidyBudget == nil {
+		return decimal.Zero, true
+	}
+
+	yes, no := m.TradedQuantities()
+	before := makerWorstCaseLoss(yes, no, m.Collected)
+	after := makerWorstCaseLoss(newQYes.Sub(m.InitialQYes), newQNo.Sub(m.InitialQNo), m.Collected.Add(cost))
+	if after.LessThanOrEqual(*m.SubsidyBudget) || after.LessThanOrEqual(before) {
+		return after, true
+	}
+
+	writeCodedError(w, ErrCodeSubsidyBudgetExceeded,
+		"trade would raise the maker's worst-case loss to "+after.StringFixed(4)+
+			", above the market's subsidy budget "+m.SubsidyBudget.String(),
+		http.StatusConflict)
+	return after, false
+}
@@ -0,0 +1,128 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// createBudgetedMarket creates a b = 100 market (worst-case loss b·ln2 ≈
+// 69.3) whose subsidy budget is 20.
+func createBudgetedMarket(t *testing.T, router http.Handler) model.Market {
+	t.Helper()
+	w := postCreateMarket(t, router, trade.CreateMarketRequest{
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815", B: d(100), SubsidyBudget: price(20),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create market: %d %s", w.Code, w.Body.String())
+	}
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	if m.SubsidyBudget == nil || !m.SubsidyBudget.Equal(d(20)) {
+		t.Fatalf("subsidy_budget = %v, want 20", m.SubsidyBudget)
+	}
+	return m
+}
+
+func TestSubsidyBudget_TradesWithinBudgetSucceed(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	m := createBudgetedMarket(t, router)
+
+	// 30 YES cost 100·ln((e^0.3+1)/2) ≈ 16.1, so YES winning costs the
+	// maker about 13.9.
+	for _, qty := range []float64{10, 20} {
+		w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(qty)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("buy %v YES: expected 200, got %d: %s", qty, w.Code, w.Body.String())
+		}
+	}
+
+	_, rep := getJSON[trade.MakerReport](t, router, "/api/v1/markets/"+m.ID+"/maker")
+	if rep.Exposure.GreaterThan(d(20)) || rep.Exposure.LessThan(d(13)) {
+		t.Errorf("exposure = %s, want about 13.9", rep.Exposure)
+	}
+	if rep.SubsidyBudget == nil || !rep.SubsidyBudget.Equal(d(20)) {
+		t.Errorf("report subsidy_budget = %v, want 20", rep.SubsidyBudget)
+	}
+}
+
+func TestSubsidyBudget_TradePastBudgetRejected(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	m := createBudgetedMarket(t, router)
+
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(30)}); w.Code != http.StatusOK {
+		t.Fatalf("first trade: %d %s", w.Code, w.Body.String())
+	}
+
+	// 70 YES would cost about 41.0 in all, a worst-case loss of about 29.
+	w := doTrade(t, router, trade.TradeRequest{UserID: "u2", ContractID: m.ContractID, Side: "YES", Quantity: d(40)})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != trade.ErrCodeSubsidyBudgetExceeded {
+		t.Errorf("code = %q, want %q", body["code"], trade.ErrCodeSubsidyBudgetExceeded)
+	}
+	if got, _ := ms.GetMarket(context.Background(), m.ID); !got.QYes.Equal(d(30)) {
+		t.Errorf("rejected trade moved q_yes to %s", got.QYes)
+	}
+
+	// Trades that reduce the maker's risk are still accepted.
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "u2", ContractID: m.ContractID, Side: "NO", Quantity: d(10)},
+		{UserID: "u1", ContractID: m.ContractID, Side: "YES", Quantity: d(-5)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Errorf("%s %s: expected 200, got %d: %s", tr.Side, tr.Quantity, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestSubsidyBudget_UnsetIsUnenforced(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(200)}); w.Code != http.StatusOK {
+		t.Errorf("expected 200 without a budget, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSubsidyBudget_CollectedKeptOnMarket(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	m := createBudgetedMarket(t, router)
+
+	// The market's running total matches the ledger it replaces reading.
+	assertCollected := func(when string) {
+		t.Helper()
+		entries, _ := ms.GetLedgerEntriesByMarket(context.Background(), m.ID)
+		want := d(0)
+		for _, e := range entries {
+			want = want.Add(e.Cost)
+		}
+		got, _ := ms.GetMarket(context.Background(), m.ID)
+		if !got.Collected.Equal(want) {
+			t.Errorf("%s: collected = %s, want the ledger's %s", when, got.Collected, want)
+		}
+	}
+
+	for _, tr := range []struct {
+		side string
+		qty  float64
+	}{{"YES", 10}, {"NO", 5}, {"YES", -4}} {
+		if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: m.ContractID, Side: tr.side, Quantity: d(tr.qty)}); w.Code != http.StatusOK {
+			t.Fatalf("%v %s: %d %s", tr.qty, tr.side, w.Code, w.Body.String())
+		}
+	}
+	assertCollected("after trades")
+
+	if w := doClose(t, router, "u1", m.ID); w.Code != http.StatusOK {
+		t.Fatalf("close: %d %s", w.Code, w.Body.String())
+	}
+	assertCollected("after close")
+}
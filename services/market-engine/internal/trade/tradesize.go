@@ -0,0 +1,126 @@
+// Per-market minimum and maximum trade size.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/model"
+)
+
+// ErrCodeTradeSizeOutOfRange is returned when |quantity| is below the
+// market's minimum or above its maximum trade size.
+const ErrCodeTradeSizeOutOfRange = "trade_size_out_of_range"
+
+// TradeSizeRequest is the JSON body for PATCH .../trade-size. Each field
+// replaces the current bound; null clears it.
+type TradeSizeRequest struct {
+	MinQuantity *decimal.Decimal `json:"min_quantity"`
+	MaxQuantity *decimal.Decimal `json:"max_quantity"`
+}
+
+// TradeSizeResponse describes a market's trade size bounds.
+type TradeSizeResponse struct {
+	MarketID    string           `json:"market_id"`
+	MinQuantity *decimal.Decimal `json:"min_quantity"`
+	MaxQuantity *decimal.Decimal `json:"max_quantity"`
+}
+
+// Validate reports every problem with a trade-size request.
+func (req TradeSizeRequest) Validate() []FieldError {
+	var errs []FieldError
+	if req.MinQuantity != nil && !req.MinQuantity.IsPositive() {
+		errs = append(errs, FieldError{"min_quantity", "min_quantity must be positive"})
+	}
+	if req.MaxQuantity != nil && !req.MaxQuantity.IsPositive() {
+		errs = append(errs, FieldError{"max_quantity", "max_quantity must be positive"})
+	}
+	if len(errs) == 0 && req.MinQuantity != nil && req.MaxQuantity != nil &&
+		req.MinQuantity.GreaterThan(*req.MaxQuantity) {
+		errs = append(errs, FieldError{"max_quantity", "max_quantity must be >= min_quantity"})
+	}
+	return errs
+}
+
+// checkTradeSize writes a coded 409 and returns false if |qty| is outside
+// the market's trade size bounds. Sells are checked on their absolute size.
+func checkTradeSize(w http.ResponseWriter, m *model.Market, qty decimal.Decimal) bool {
+	size := qty.Abs()
+	if m.MinQuantity != nil && size.LessThan(*m.MinQuantity) {
+		writeCodedError(w, ErrCodeTradeSizeOutOfRange,
+			"trade size "+size.String()+" is below the market minimum "+m.MinQuantity.String(),
+			http.StatusConflict)
+		return false
+	}
+	if m.MaxQuantity != nil && size.GreaterThan(*m.MaxQuantity) {
+		writeCodedError(w, ErrCodeTradeSizeOutOfRange,
+			"trade size "+size.String()+" exceeds the market maximum "+m.MaxQuantity.String(),
+			http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// GetTradeSize handles GET /api/v1/markets/{marketID}/trade-size
+func (s *Service) GetTradeSize(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+
+	market, err := s.store.GetMarket(r.Context(), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+
+	writeTradeSize(w, market)
+}
+
+// UpdateTradeSize handles PATCH /api/v1/markets/{marketID}/trade-size (admin)
+func (s *Service) UpdateTradeSize(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	var req TradeSizeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	old, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if err := s.store.UpdateTradeSizeLimits(ctx, marketID, req.MinQuantity, req.MaxQuantity); err != nil {
+		s.internalError(w, r, "failed to update trade size limits", err)
+		return
+	}
+	s.audit(r, AuditActionTradeSize, marketID, map[string]string{
+		"old_min_quantity": auditSetting(old.MinQuantity),
+		"old_max_quantity": auditSetting(old.MaxQuantity),
+		"min_quantity":     auditSetting(req.MinQuantity),
+		"max_quantity":     auditSetting(req.MaxQuantity),
+	})
+
+	market, err := s.store.GetMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market", err)
+		return
+	}
+	writeTradeSize(w, market)
+}
+
+func writeTradeSize(w http.ResponseWriter, m *model.Market) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TradeSizeResponse{
+		MarketID:    m.ID,
+		MinQuantity: m.MinQuantity,
+		MaxQuantity: m.MaxQuantity,
+	})
+}
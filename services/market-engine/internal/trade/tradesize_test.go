@@ -0,0 +1,102 @@
+package trade_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func patchTradeSize(t *testing.T, router http.Handler, marketID string, body string) (*httptest.ResponseRecorder, trade.TradeSizeResponse) {
+	t.Helper()
+	return serveJSON[trade.TradeSizeResponse](t, router, adminRequest("PATCH", "/api/v1/markets/"+marketID+"/trade-size", bytes.NewBufferString(body)))
+}
+
+func TestTradeSize_Bounds(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	w, resp := patchTradeSize(t, router, market.ID, `{"min_quantity":"1","max_quantity":"50"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.MinQuantity == nil || !resp.MinQuantity.Equal(d(1)) || resp.MaxQuantity == nil || !resp.MaxQuantity.Equal(d(50)) {
+		t.Fatalf("unexpected bounds: %+v", resp)
+	}
+
+	cases := []struct {
+		name string
+		qty  float64
+		ok   bool
+	}{
+		{"below min", 0.5, false},
+		{"at min", 1, true},
+		{"in range", 20, true},
+		{"at max", 50, true},
+		{"above max", 51, false},
+		{"sell in range", -10, true},
+		{"sell below min", -0.5, false},
+		{"sell above max", -60, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doTrade(t, router, trade.TradeRequest{
+				UserID: "u1", ContractID: contractID, Side: "YES", Quantity: d(tc.qty),
+			})
+			if tc.ok {
+				if w.Code != http.StatusOK {
+					t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+				}
+				return
+			}
+			if w.Code != http.StatusConflict {
+				t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+			}
+			var body map[string]string
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if body["code"] != trade.ErrCodeTradeSizeOutOfRange {
+				t.Errorf("code = %q, want %q", body["code"], trade.ErrCodeTradeSizeOutOfRange)
+			}
+		})
+	}
+}
+
+func TestTradeSize_UnsetBoundsUnenforced(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	// Only a maximum: tiny trades are fine.
+	patchTradeSize(t, router, market.ID, `{"max_quantity":"50"}`)
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "NO", Quantity: d(0.01)}); w.Code != http.StatusOK {
+		t.Errorf("dust trade without a minimum: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Clearing both removes the cap.
+	patchTradeSize(t, router, market.ID, `{"min_quantity":null,"max_quantity":null}`)
+	if w := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: contractID, Side: "NO", Quantity: d(100)}); w.Code != http.StatusOK {
+		t.Errorf("after clearing bounds: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTradeSize_Validation(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	for _, body := range []string{
+		`{"min_quantity":"0"}`,
+		`{"max_quantity":"-5"}`,
+		`{"min_quantity":"10","max_quantity":"5"}`,
+	} {
+		if w, _ := patchTradeSize(t, router, market.ID, body); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", body, w.Code)
+		}
+	}
+	if w, _ := patchTradeSize(t, router, "missing", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown market: expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,143 @@
+// Reversing a mistaken settlement.
+
+package trade
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/store"
+)
+
+// UnsettleResponse is the JSON body returned from the unsettle endpoint.
+type UnsettleResponse struct {
+	Market          *model.Market `json:"market"`           // reopened
+	ReversedOutcome string        `json:"reversed_outcome"` // the outcome undone
+	ReversedEntries int           `json:"reversed_entries"` // settlement entries cancelled
+}
+
+// UnsettleMarket handles POST /api/v1/markets/{marketID}/unsettle (admin)
+// Undoes a settlement made with the wrong outcome: each live settlement
+// entry gets a settlement_reversal entry cancelling it, and the market is
+// reopened with no outcome, in one store transaction. Positions, cost
+// basis and realized P&L return to what they were before settlement, and
+// the market can then be settled again. Refused if anything traded after
+// the settlement, since those trades were priced against a closed market.
+func (s *Service) UnsettleMarket(w http.ResponseWriter, r *http.Request) {
+	marketID := chi.URLParam(r, "marketID")
+	ctx := r.Context()
+
+	_, unlock, ok := s.lockMarket(w, r, marketID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	market, err := s.store.GetMarket(store.WithPrimaryReads(ctx), marketID)
+	if err != nil {
+		s.writeLookupError(w, r, err, "market not found")
+		return
+	}
+	if market.Status != "settled" || market.Outcome == nil || market.SettledAt == nil {
+		writeError(w, "market is not settled", http.StatusConflict)
+		return
+	}
+
+	history, err := s.store.GetLedgerEntriesByMarket(ctx, marketID)
+	if err != nil {
+		s.internalError(w, r, "failed to load market history", err)
+		return
+	}
+	for _, e := range history {
+		if e.IsTrade() && e.Timestamp.After(*market.SettledAt) {
+			writeError(w, "market has trades after its settlement and cannot be unsettled", http.StatusConflict)
+			return
+		}
+	}
+
+	now := s.now().UTC()
+	var entries []*model.LedgerEntry
+	for _, e := range withoutReversed(history) {
+		if e.Kind != model.EntryKindSettlement {
+			continue
+		}
+		entries = append(entries, &model.LedgerEntry{
+			ID:          s.ids.NewID(),
+			UserID:      e.UserID,
+			MarketID:    e.MarketID,
+			ContractID:  e.ContractID,
+			Side:        e.Side,
+			Quantity:    e.Quantity.Neg(),
+			Price:       e.Price,
+			Cost:        e.Cost.Neg(),
+			Timestamp:   now,
+			RealizedPnL: e.RealizedPnL.Neg(),
+			Kind:        model.EntryKindSettlementReversal,
+			Reverses:    e.ID,
+		})
+	}
+
+	if err := s.store.UnsettleMarket(ctx, marketID, entries); err != nil {
+		if errors.Is(err, store.ErrMarketNotSettled) {
+			writeError(w, "market is not settled", http.StatusConflict)
+			return
+		}
+		s.internalError(w, r, "failed to unsettle market", err)
+		return
+	}
+
+	if market.HiddenAt == nil {
+		metrics.ActiveMarkets.Inc()
+	}
+	outcome := *market.Outcome
+	market.Status = "open"
+	market.Outcome = nil
+	market.SettledAt = nil
+
+	slog.Warn("market unsettled",
+		"market", marketID,
+		"reversed_outcome", outcome,
+		"entries", len(entries),
+	)
+	s.audit(r, AuditActionUnsettle, marketID, map[string]string{
+		"reversed_outcome": outcome,
+		"entries":          strconv.Itoa(len(entries)),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UnsettleResponse{
+		Market:          market,
+		ReversedOutcome: outcome,
+		ReversedEntries: len(entries),
+	})
+}
+
+// withoutReversed returns history less settlement reversals and the
+// entries they cancel. Replaying a cost basis must skip both: applying a
+// reversal as a trade would reopen the position at the payout price
+// rather than restore its original lots.
+func withoutReversed(history []model.LedgerEntry) []model.LedgerEntry {
+	reversed := make(map[string]bool)
+	for _, e := range history {
+		if e.Kind == model.EntryKindSettlementReversal {
+			reversed[e.Reverses] = true
+		}
+	}
+	if len(reversed) == 0 {
+		return history
+	}
+	live := make([]model.LedgerEntry, 0, len(history))
+	for _, e := range history {
+		if e.Kind != model.EntryKindSettlementReversal && !reversed[e.ID] {
+			live = append(live, e)
+		}
+	}
+	return live
+}
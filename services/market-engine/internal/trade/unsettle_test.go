@@ -0,0 +1,149 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postUnsettle(t *testing.T, router http.Handler, marketID string) (*httptest.ResponseRecorder, trade.UnsettleResponse) {
+	t.Helper()
+	return serveJSON[trade.UnsettleResponse](t, router, adminRequest("POST", "/api/v1/markets/"+marketID+"/unsettle", nil))
+}
+
+func getMarketState(t *testing.T, router http.Handler, marketID string) model.Market {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets/"+marketID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("get market: %d %s", w.Code, w.Body.String())
+	}
+	var m model.Market
+	json.Unmarshal(w.Body.Bytes(), &m)
+	return m
+}
+
+// settlementPositions returns the seeded users' positions in market.
+func settlementPositions(t *testing.T, router chi.Router, marketID string) map[string]model.Position {
+	t.Helper()
+	positions := make(map[string]model.Position)
+	for _, u := range []string{"alice", "bob", "carol", "dave"} {
+		w, p := getJSON[model.Position](t, router, "/api/v1/portfolio/"+u+"/markets/"+marketID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("position of %s: %d %s", u, w.Code, w.Body.String())
+		}
+		positions[u] = p
+	}
+	return positions
+}
+
+func TestUnsettleMarket_RestoresPreSettlementState(t *testing.T) {
+	market, router, ledger := seedSettlementTrades(t)
+	beforeMarket := getMarketState(t, router, market.ID)
+	beforePositions := settlementPositions(t, router, market.ID)
+	_, preview := getJSON[trade.SettlementSummary](t, router, "/api/v1/markets/"+market.ID+"/settle/preview?outcome=YES")
+
+	// Settled with the wrong outcome, then reversed.
+	if w, _ := postSettle(t, router, market.ID, "NO"); w.Code != http.StatusOK {
+		t.Fatalf("settle: %d %s", w.Code, w.Body.String())
+	}
+	w, resp := postUnsettle(t, router, market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unsettle: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.ReversedOutcome != "NO" || resp.ReversedEntries != 4 {
+		t.Errorf("reversed %q with %d entries, want NO with 4", resp.ReversedOutcome, resp.ReversedEntries)
+	}
+
+	after := getMarketState(t, router, market.ID)
+	if after.Status != "open" || after.Outcome != nil || after.SettledAt != nil {
+		t.Errorf("market status = %s, outcome = %v, settled_at = %v; want open with neither", after.Status, after.Outcome, after.SettledAt)
+	}
+	if !after.QYes.Equal(beforeMarket.QYes) || !after.QNo.Equal(beforeMarket.QNo) || !after.PriceYes.Equal(beforeMarket.PriceYes) {
+		t.Errorf("market state = %s/%s @ %s, want %s/%s @ %s",
+			after.QYes, after.QNo, after.PriceYes, beforeMarket.QYes, beforeMarket.QNo, beforeMarket.PriceYes)
+	}
+	for u, got := range settlementPositions(t, router, market.ID) {
+		want := beforePositions[u]
+		if !got.YesQty.Equal(want.YesQty) || !got.NoQty.Equal(want.NoQty) ||
+			!got.CostBasis.Equal(want.CostBasis) || !got.CurrentValue.Equal(want.CurrentValue) ||
+			!got.RealizedPnL.Equal(want.RealizedPnL) {
+			t.Errorf("%s: position = %+v, want %+v", u, got, want)
+		}
+	}
+
+	// Every reversal cancels one settlement entry.
+	settlements := make(map[string]model.LedgerEntry)
+	var reversals []model.LedgerEntry
+	for _, e := range ledger() {
+		switch e.Kind {
+		case model.EntryKindSettlement:
+			settlements[e.ID] = e
+		case model.EntryKindSettlementReversal:
+			reversals = append(reversals, e)
+		}
+	}
+	for _, rev := range reversals {
+		orig, ok := settlements[rev.Reverses]
+		if !ok || !rev.Quantity.Equal(orig.Quantity.Neg()) || !rev.Cost.Equal(orig.Cost.Neg()) || !rev.RealizedPnL.Equal(orig.RealizedPnL.Neg()) {
+			t.Errorf("reversal %+v does not cancel %+v", rev, orig)
+		}
+	}
+
+	// Settling again books exactly what settling in the first place would.
+	w, resettled := postSettle(t, router, market.ID, "YES")
+	if w.Code != http.StatusOK {
+		t.Fatalf("re-settle: %d %s", w.Code, w.Body.String())
+	}
+	if !resettled.TotalPayout.Equal(preview.TotalPayout) || !resettled.TotalRealizedPnL.Equal(preview.TotalRealizedPnL) {
+		t.Errorf("re-settlement %s / %s, want %s / %s", resettled.TotalPayout, resettled.TotalRealizedPnL,
+			preview.TotalPayout, preview.TotalRealizedPnL)
+	}
+
+	// A second unsettle reverses only the live settlement.
+	if _, resp := postUnsettle(t, router, market.ID); resp.ReversedOutcome != "YES" || resp.ReversedEntries != 4 {
+		t.Errorf("second unsettle reversed %q with %d entries, want YES with 4", resp.ReversedOutcome, resp.ReversedEntries)
+	}
+}
+
+func TestUnsettleMarket_Rejections(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	_, ms, router := newTestEnv(t, trade.WithClock(func() time.Time { return now }))
+	market := seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20250815", "872a1070b", 100)
+
+	if w, _ := postUnsettle(t, router, market.ID); w.Code != http.StatusConflict {
+		t.Errorf("open market: expected 409, got %d", w.Code)
+	}
+	if w, _ := postUnsettle(t, router, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown market: expected 404, got %d", w.Code)
+	}
+
+	if w, _ := postSettle(t, router, market.ID, "YES"); w.Code != http.StatusOK {
+		t.Fatalf("settle: %d %s", w.Code, w.Body.String())
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets/"+market.ID+"/unsettle", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without admin token: expected 401, got %d", w.Code)
+	}
+
+	// A trade recorded after the settlement blocks the reversal.
+	ms.InsertLedgerEntry(context.Background(), &model.LedgerEntry{
+		ID: "late", UserID: "u1", MarketID: market.ID, ContractID: market.ContractID,
+		Side: "YES", Quantity: d(1), Price: d(0.5), Cost: d(0.5), Timestamp: now.Add(time.Minute),
+	})
+	if w, _ := postUnsettle(t, router, market.ID); w.Code != http.StatusConflict {
+		t.Errorf("post-settlement trade: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if m := getMarketState(t, router, market.ID); m.Status != "settled" {
+		t.Errorf("rejected unsettle changed status to %s", m.Status)
+	}
+}
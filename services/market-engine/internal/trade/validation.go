@@ -0,0 +1,98 @@
+// Request validation with per-field error reporting.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/contract"
+)
+
+// FieldError describes a single invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is the 422 body listing every invalid field.
+type ValidationErrorResponse struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Validate reports every problem with a trade request rather than
+// stopping at the first, so clients can fix all fields in one round trip.
+func (req TradeRequest) Validate() []FieldError {
+	rules := []rule{required("user_id", req.UserID)}
+	// contract_id may be left out when market_id names the market.
+	if req.MarketID == "" || req.ContractID != "" {
+		rules = append(rules, required("contract_id", req.ContractID), ticker("contract_id", req.ContractID))
+	}
+	rules = append(rules,
+		oneOf("side", req.Side, "side must be YES or NO", "YES", "NO"),
+		quantity("quantity", req.Quantity),
+		fails("confirm_token", req.ConfirmToken != "" && req.Confirm != nil && !*req.Confirm,
+			"confirm_token executes a quote; omit confirm: false"),
+		metadata("metadata", req.Metadata),
+	)
+	if p := req.MaxFillPrice; p != nil {
+		rules = append(rules,
+			probability("max_fill_price", *p),
+			fails("max_fill_price", req.Quantity.IsNegative(), "max_fill_price applies to buys; use min_fill_price for sells"),
+		)
+	}
+	if p := req.MinFillPrice; p != nil {
+		rules = append(rules,
+			probability("min_fill_price", *p),
+			fails("min_fill_price", req.Quantity.IsPositive(), "min_fill_price applies to sells; use max_fill_price for buys"),
+		)
+	}
+	return check(rules...)
+}
+
+// normalize puts the contract ticker in canonical form.
+func (req *TradeRequest) normalize() {
+	req.ContractID = contract.NormalizeTicker(req.ContractID)
+}
+
+// validProbability reports whether p is a usable price limit, 0 < p < 1.
+func validProbability(p decimal.Decimal) bool {
+	return p.IsPositive() && p.LessThan(decimal.NewFromInt(1))
+}
+
+// Validate reports every problem with a market creation request.
+func (req CreateMarketRequest) Validate() []FieldError {
+	rules := []rule{
+		required("contract_id", req.ContractID),
+		ticker("contract_id", req.ContractID),
+		fails("b_end", (req.BStart == nil) != (req.BEnd == nil), "b_start and b_end must be set together"),
+		fails("auto_liquidity", req.AutoLiquidity && req.BStart != nil, "auto_liquidity can't be combined with a b_start/b_end schedule"),
+		liquidity("b", req.B),
+	}
+	if req.BStart != nil && req.BEnd != nil {
+		rules = append(rules,
+			positive("b_start", *req.BStart), liquidity("b_start", *req.BStart),
+			positive("b_end", *req.BEnd), liquidity("b_end", *req.BEnd))
+	}
+	if p := req.InitialPriceYes; p != nil {
+		rules = append(rules, probability("initial_price_yes", *p))
+	}
+	if b := req.SubsidyBudget; b != nil {
+		rules = append(rules, positive("subsidy_budget", *b))
+	}
+	return check(rules...)
+}
+
+// normalize puts the contract ticker in canonical form.
+func (req *CreateMarketRequest) normalize() {
+	req.ContractID = contract.NormalizeTicker(req.ContractID)
+}
+
+// writeValidationErrors writes a 422 response listing all field errors.
+func writeValidationErrors(w http.ResponseWriter, errs []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(ValidationErrorResponse{Errors: errs})
+}
@@ -0,0 +1,159 @@
+package trade_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/atmx/market-engine/internal/lmsr"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+// fieldsOf returns the set of field names in a 422 validation response.
+func fieldsOf(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp trade.ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid validation body: %v", err)
+	}
+	fields := make(map[string]string)
+	for _, fe := range resp.Errors {
+		if fe.Message == "" {
+			t.Errorf("field %s has empty message", fe.Field)
+		}
+		fields[fe.Field] = fe.Message
+	}
+	return fields
+}
+
+func TestExecuteTrade_ReportsAllFieldErrors(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	w := doTrade(t, router, trade.TradeRequest{
+		ContractID: "not-a-ticker",
+		Side:       "MAYBE",
+		Quantity:   decimal.Zero,
+	})
+
+	fields := fieldsOf(t, w)
+	for _, f := range []string{"user_id", "contract_id", "side", "quantity"} {
+		if _, ok := fields[f]; !ok {
+			t.Errorf("expected error for field %q, got %v", f, fields)
+		}
+	}
+	if len(fields) != 4 {
+		t.Errorf("expected exactly 4 field errors, got %v", fields)
+	}
+}
+
+func TestExecuteTrade_MissingContract(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	w := doTrade(t, router, trade.TradeRequest{
+		UserID:   "user1",
+		Side:     "YES",
+		Quantity: d(10),
+	})
+
+	fields := fieldsOf(t, w)
+	if len(fields) != 1 || fields["contract_id"] == "" {
+		t.Errorf("expected only a contract_id error, got %v", fields)
+	}
+}
+
+func TestCreateMarket_ReportsFieldErrors(t *testing.T) {
+	_, _, router := newTestEnv(t)
+
+	for _, contractID := range []string{"", "ATMX-872a1070b-HAIL-25MM-20250815"} {
+		body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: contractID})
+		req := httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		fields := fieldsOf(t, w)
+		if fields["contract_id"] == "" {
+			t.Errorf("contract %q: expected contract_id error, got %v", contractID, fields)
+		}
+	}
+}
+
+func TestTradeRequest_ValidateValid(t *testing.T) {
+	req := trade.TradeRequest{
+		UserID:     "user1",
+		ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815",
+		Side:       "NO",
+		Quantity:   d(-5),
+	}
+	if errs := req.Validate(); len(errs) != 0 {
+		t.Errorf("expected no errors for valid request, got %v", errs)
+	}
+}
+
+func TestTickerNormalization_CreateAndTrade(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+
+	body, _ := json.Marshal(trade.CreateMarketRequest{ContractID: " atmx-872a1070b-precip-25MM-20250815 "})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	const canonical = "ATMX-872a1070b-PRECIP-25MM-20250815"
+	if _, err := ms.GetMarketByContract(context.Background(), canonical); err != nil {
+		t.Fatalf("market not stored under the canonical ticker: %v", err)
+	}
+
+	tw := doTrade(t, router, trade.TradeRequest{UserID: "u1", ContractID: "atmx-872a1070b-Precip-25mm-20250815\t", Side: "YES", Quantity: d(5)})
+	if tw.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", tw.Code, tw.Body.String())
+	}
+	var resp trade.TradeResponse
+	json.Unmarshal(tw.Body.Bytes(), &resp)
+	if resp.ContractID != canonical {
+		t.Errorf("trade contract = %q, want %q", resp.ContractID, canonical)
+	}
+
+	// The cell is never case-folded.
+	body, _ = json.Marshal(trade.CreateMarketRequest{ContractID: "ATMX-872A1070B-PRECIP-25MM-20250816"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/markets", bytes.NewReader(body)))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for an upper-case cell, got %d", w.Code)
+	}
+}
+
+func TestExecuteTrade_QuantityMagnitudeBound(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	seedMarket(t, ms, contractID, "872a1070b", 100)
+	above := lmsr.MaxQuantity.Add(d(1))
+	huge := decimal.RequireFromString("1e400") // far past float64
+
+	for _, qty := range []decimal.Decimal{above, above.Neg(), huge} {
+		w := doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: qty})
+		if fields := fieldsOf(t, w); len(fields) != 1 || fields["quantity"] == "" {
+			t.Errorf("quantity %s: expected only a quantity error, got %v", qty, fields)
+		}
+	}
+
+	// At the bound the request is valid and reaches the risk checks, which
+	// turn it away as a position, not a malformed quantity.
+	w := doTrade(t, router, trade.TradeRequest{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: lmsr.MaxQuantity})
+	if w.Code != http.StatusConflict {
+		t.Errorf("quantity at the bound: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/markets/test-market-"+contractID+"/quote?qty="+above.String(), nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("quote above the bound: expected 400, got %d", w.Code)
+	}
+}
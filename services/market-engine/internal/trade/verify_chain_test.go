@@ -0,0 +1,59 @@
+package trade_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func TestVerifyChain_DetectsAlteredMiddleEntry(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(30)},
+		{UserID: "user2", ContractID: contractID, Side: "NO", Quantity: d(15)},
+		{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(-10)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, resp := getJSON[trade.VerifyChainResponse](t, router, "/api/v1/markets/"+market.ID+"/verify-chain")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.ChainReport == nil || !resp.OK || resp.Entries != 3 || resp.Head == "" {
+		t.Fatalf("expected an intact chain of 3, got %s", w.Body.String())
+	}
+
+	// Rewrite the middle entry's cost in a snapshot and load it back, as
+	// someone editing a backup might.
+	_, snap := getSnapshot(t, router)
+	if len(snap.Ledger) != 3 {
+		t.Fatalf("snapshot has %d entries, want 3", len(snap.Ledger))
+	}
+	middle := snap.Ledger[1].ID
+	snap.Ledger[1].Cost = snap.Ledger[1].Cost.Div(d(2))
+	if w := postRestore(router, "?force=true", []byte(mustJSON(t, snap))); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+
+	_, resp = getJSON[trade.VerifyChainResponse](t, router, "/api/v1/markets/"+market.ID+"/verify-chain")
+	if resp.OK {
+		t.Fatal("expected verification to fail after tampering")
+	}
+	if len(resp.Breaks) != 1 || resp.Breaks[0].EntryID != middle {
+		t.Errorf("expected one break at %s, got %+v", middle, resp.Breaks)
+	}
+}
+
+func TestVerifyChain_NotFound(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	if w, _ := getJSON[trade.VerifyChainResponse](t, router, "/api/v1/markets/nope/verify-chain"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
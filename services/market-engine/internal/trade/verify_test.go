@@ -0,0 +1,90 @@
+package trade_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atmx/market-engine/internal/model"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func postVerify(t *testing.T, router http.Handler, marketID string) (*httptest.ResponseRecorder, trade.VerifyResponse) {
+	t.Helper()
+	return serveJSON[trade.VerifyResponse](t, router, httptest.NewRequest("POST", "/api/v1/markets/"+marketID+"/verify", nil))
+}
+
+func TestVerifyMarket_CleanLedger(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	for _, tr := range []trade.TradeRequest{
+		{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(30)},
+		{UserID: "user2", ContractID: contractID, Side: "NO", Quantity: d(15)},
+		{UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(-10)},
+	} {
+		if w := doTrade(t, router, tr); w.Code != http.StatusOK {
+			t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	w, resp := postVerify(t, router, market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.OK || !resp.StateMatches || len(resp.Divergences) != 0 {
+		t.Errorf("expected clean replay, got %s", w.Body.String())
+	}
+	if resp.Entries != 3 || !resp.TotalVolume.Equal(d(55)) {
+		t.Errorf("expected 3 entries / volume 55, got %d / %s", resp.Entries, resp.TotalVolume)
+	}
+}
+
+func TestVerifyMarket_FlagsTamperedCost(t *testing.T) {
+	_, ms, router := newTestEnv(t)
+	contractID := "ATMX-872a1070b-PRECIP-25MM-20250815"
+	market := seedMarket(t, ms, contractID, "872a1070b", 100)
+
+	if w := doTrade(t, router, trade.TradeRequest{
+		UserID: "user1", ContractID: contractID, Side: "YES", Quantity: d(10),
+	}); w.Code != http.StatusOK {
+		t.Fatalf("trade failed: %d %s", w.Code, w.Body.String())
+	}
+
+	// A second YES entry whose cost was written at half the LMSR price.
+	ms.InsertLedgerEntry(context.Background(), &model.LedgerEntry{
+		ID: "tampered", UserID: "user2", MarketID: market.ID, ContractID: contractID,
+		Side: "YES", Quantity: d(10), Price: d(0.25), Cost: d(2.5),
+	})
+
+	w, resp := postVerify(t, router, market.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.OK {
+		t.Fatal("expected verification to fail")
+	}
+	flagged := map[string]bool{}
+	for _, div := range resp.Divergences {
+		if div.EntryID != "tampered" {
+			t.Errorf("honest entry flagged: %+v", div)
+		}
+		flagged[div.Field] = true
+	}
+	if !flagged["cost"] || !flagged["price"] {
+		t.Errorf("expected cost and price divergences, got %+v", resp.Divergences)
+	}
+	// The market row was never updated for the injected entry.
+	if resp.StateMatches {
+		t.Error("expected replayed state to disagree with stored market")
+	}
+}
+
+func TestVerifyMarket_NotFound(t *testing.T) {
+	_, _, router := newTestEnv(t)
+	if w, _ := postVerify(t, router, "nope"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
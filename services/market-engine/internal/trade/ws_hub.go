@@ -1,18 +1,37 @@
-// Package trade — WebSocket hub for real-time price broadcasting.
+// WebSocket hub for real-time price broadcasting.
+
 package trade
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
 
+// DefaultReplayBuffer is the number of recent messages the hub keeps for
+// clients resuming after a disconnect.
+const DefaultReplayBuffer = 1024
+
+// DefaultWriteTimeout bounds each write to a client. A client that can't
+// accept a message within it is disconnected.
+const DefaultWriteTimeout = 10 * time.Second
+
 // WSMessage is a JSON message sent to WebSocket clients.
 type WSMessage struct {
+	// Seq increases by one for every broadcast message. Snapshot messages
+	// carry the sequence number they are consistent with, so a client can
+	// resume from it.
+	Seq        uint64 `json:"seq"`
 	Type       string `json:"type"`
 	MarketID   string `json:"market_id"`
 	ContractID string `json:"contract_id"`
@@ -21,26 +40,171 @@ type WSMessage struct {
 	PriceNo    string `json:"price_no,omitempty"`
 	Side       string `json:"side,omitempty"`
 	Quantity   string `json:"quantity,omitempty"`
+	Outcome    string `json:"outcome,omitempty"` // market_settled only
+
+	// Trades is set on coalesced trade_executed messages (see
+	// WithCoalescing): how many trades the message stands for. Its prices
+	// are the latest, and Side and Quantity are left out.
+	Trades int `json:"trades,omitempty"`
+
+	// risk_alert only; sent to the user's own connections.
+	UserID      string `json:"user_id,omitempty"`
+	Utilization string `json:"utilization,omitempty"`
+	Threshold   string `json:"threshold,omitempty"`
+}
+
+// WSClientMessage is a JSON message sent by a WebSocket client.
+//
+// {"action":"resume","after_seq":N} replays every message with Seq > N
+// that is still in the replay buffer. If N is older than the buffer the
+// client receives a full snapshot instead. Replayed messages may overlap
+// live ones, so clients should drop any Seq they have already seen.
+type WSClientMessage struct {
+	Action   string `json:"action"`
+	AfterSeq uint64 `json:"after_seq"`
+}
+
+// SnapshotFunc returns the current state of every market as WSMessages,
+// sent to clients that resume from beyond the replay buffer.
+type SnapshotFunc func(ctx context.Context) ([]WSMessage, error)
+
+// WSHubOption configures a WSHub.
+type WSHubOption func(*WSHub)
+
+// WithReplayBuffer sets how many recent messages are kept for resume.
+func WithReplayBuffer(n int) WSHubOption {
+	return func(h *WSHub) {
+		if n > 0 {
+			h.ring = make([]frame, n)
+		}
+	}
+}
+
+// WithCompression enables permessage-deflate for clients that offer it.
+// It trades CPU for bandwidth, which pays off with many clients receiving
+// high-frequency price ticks.
+func WithCompression(enabled bool) WSHubOption {
+	return func(h *WSHub) { h.compress = enabled }
+}
+
+// WithWriteTimeout sets how long a single write to a client may take
+// before the client is treated as disconnected.
+func WithWriteTimeout(d time.Duration) WSHubOption {
+	return func(h *WSHub) {
+		if d > 0 {
+			h.writeTimeout = d
+		}
+	}
+}
+
+// WithCoalescing makes the hub send at most one trade_executed message per
+// market per interval, carrying the latest prices, instead of one per
+// trade. Other messages about a market first send any update held back
+// for it, so clients still see events in order. Zero, the default, sends
+// every trade.
+func WithCoalescing(interval time.Duration) WSHubOption {
+	return func(h *WSHub) {
+		if interval > 0 {
+			h.coalesce = interval
+		}
+	}
+}
+
+// wsConn is the part of *websocket.Conn the hub writes through.
+type wsConn interface {
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// frame is an encoded broadcast message and the market it concerns, so
+// clients subscribed to one market can be skipped without decoding it.
+type frame struct {
+	marketID string
+	data     wireMessage
+}
+
+// direct is a set of messages addressed to a single client. It goes
+// through the Run loop so that only one goroutine writes to a connection.
+type direct struct {
+	conn wsConn
+	msgs []wireMessage
 }
 
 // WSHub manages WebSocket connections and broadcasts messages to all
 // connected clients when market prices change.
 type WSHub struct {
-	clients    map[*websocket.Conn]bool
-	broadcast  chan []byte
-	register   chan *websocket.Conn
-	unregister chan *websocket.Conn
+	clients    map[wsConn]bool
+	users      map[wsConn]string // user a connection identified as; guarded by mu
+	markets    map[wsConn]string // market a connection is limited to; guarded by mu
+	binary     map[wsConn]bool   // connections that chose MessagePack; guarded by mu
+	broadcast  chan frame
+	direct     chan direct
+	register   chan wsConn
+	unregister chan wsConn
 	mu         sync.RWMutex
+
+	// seqMu guards seq, ring and snapshot. ring[seq%len(ring)] holds the
+	// encoded message for seq.
+	seqMu    sync.Mutex
+	seq      uint64
+	ring     []frame
+	snapshot SnapshotFunc
+
+	compress     bool
+	writeTimeout time.Duration
+	upgrader     websocket.Upgrader
+
+	// pendingMu guards pending and flushScheduled: the latest trade
+	// update per market held back by coalescing.
+	coalesce       time.Duration
+	pendingMu      sync.Mutex
+	pending        map[string]WSMessage
+	flushScheduled bool
+
+	// dropped counts messages discarded because the broadcast or direct
+	// queue was full.
+	dropped atomic.Uint64
 }
 
 // NewWSHub creates a new WebSocket hub.
-func NewWSHub() *WSHub {
-	return &WSHub{
-		clients:    make(map[*websocket.Conn]bool),
-		broadcast:  make(chan []byte, 256),
-		register:   make(chan *websocket.Conn),
-		unregister: make(chan *websocket.Conn),
+func NewWSHub(opts ...WSHubOption) *WSHub {
+	h := &WSHub{
+		clients:      make(map[wsConn]bool),
+		users:        make(map[wsConn]string),
+		markets:      make(map[wsConn]string),
+		binary:       make(map[wsConn]bool),
+		broadcast:    make(chan frame, 256),
+		direct:       make(chan direct, 16),
+		register:     make(chan wsConn),
+		unregister:   make(chan wsConn),
+		ring:         make([]frame, DefaultReplayBuffer),
+		writeTimeout: DefaultWriteTimeout,
+		pending:      make(map[string]WSMessage),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		EnableCompression: h.compress,
+		Subprotocols:      []string{WSFormatMsgpack, WSFormatJSON},
+		CheckOrigin: func(_ *http.Request) bool {
+			return true // Allow all origins during development.
+		},
 	}
+	return h
+}
+
+// SetSnapshot sets the function used to build a full snapshot for clients
+// that resume from beyond the replay buffer. It is a setter rather than an
+// option because the snapshot usually comes from a Service that itself
+// needs the hub.
+func (h *WSHub) SetSnapshot(fn SnapshotFunc) {
+	h.seqMu.Lock()
+	h.snapshot = fn
+	h.seqMu.Unlock()
 }
 
 // Run starts the hub's main event loop. Must be called in a goroutine.
@@ -59,53 +223,301 @@ func (h *WSHub) Run() {
 				delete(h.clients, conn)
 				conn.Close()
 			}
+			delete(h.users, conn)
+			delete(h.markets, conn)
+			delete(h.binary, conn)
 			h.mu.Unlock()
 
-		case msg := <-h.broadcast:
+		case f := <-h.broadcast:
+			// Only Run changes clients, so the set can be read here and
+			// written to without holding the lock.
 			h.mu.RLock()
+			conns := make([]wsConn, 0, len(h.clients))
+			binary := make([]bool, 0, len(h.clients))
 			for conn := range h.clients {
-				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-					conn.Close()
-					delete(h.clients, conn)
+				if wantsMarket(h.markets[conn], f.marketID) {
+					conns = append(conns, conn)
+					binary = append(binary, h.binary[conn])
 				}
 			}
 			h.mu.RUnlock()
+			for i, conn := range conns {
+				if err := h.write(conn, f.data, binary[i]); err != nil {
+					h.drop(conn, err)
+				}
+			}
+
+		case d := <-h.direct:
+			h.mu.RLock()
+			_, ok := h.clients[d.conn]
+			binary := h.binary[d.conn]
+			h.mu.RUnlock()
+			if !ok {
+				continue
+			}
+			for _, msg := range d.msgs {
+				if err := h.write(d.conn, msg, binary); err != nil {
+					h.drop(d.conn, err)
+					break
+				}
+			}
 		}
 	}
 }
 
-// Broadcast sends a message to all connected clients.
+// write sends msg to conn in the connection's format, giving up after the
+// hub's write timeout so a stalled client cannot block every other
+// client's broadcasts.
+func (h *WSHub) write(conn wsConn, msg wireMessage, binary bool) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(h.writeTimeout)); err != nil {
+		return err
+	}
+	if binary {
+		return conn.WriteMessage(websocket.BinaryMessage, msg.binary)
+	}
+	return conn.WriteMessage(websocket.TextMessage, msg.text)
+}
+
+// drop disconnects a client whose write failed. Its read pump sees the
+// close and unregisters it again, which is a no-op.
+func (h *WSHub) drop(conn wsConn, err error) {
+	h.mu.Lock()
+	delete(h.clients, conn)
+	delete(h.users, conn)
+	delete(h.markets, conn)
+	delete(h.binary, conn)
+	total := len(h.clients)
+	h.mu.Unlock()
+	conn.Close()
+
+	var ne net.Error
+	timeout := errors.As(err, &ne) && ne.Timeout()
+	slog.Warn("ws client dropped", "err", err, "timeout", timeout, "total", total)
+}
+
+// Broadcast assigns the next sequence number to msg, records it for
+// resume and sends it to all connected clients. With coalescing on, a
+// trade_executed message is instead held back and merged with the next
+// ones for its market until the interval is up.
 func (h *WSHub) Broadcast(msg WSMessage) {
-	data, err := json.Marshal(msg)
+	if h.coalesce == 0 || msg.MarketID == "" {
+		h.publish(msg)
+		return
+	}
+
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	if msg.Type == "trade_executed" {
+		msg.Trades = h.pending[msg.MarketID].Trades + 1
+		msg.Side, msg.Quantity = "", ""
+		h.pending[msg.MarketID] = msg
+		if !h.flushScheduled {
+			h.flushScheduled = true
+			time.AfterFunc(h.coalesce, h.flushPending)
+		}
+		return
+	}
+	if held, ok := h.pending[msg.MarketID]; ok {
+		delete(h.pending, msg.MarketID)
+		h.publish(held)
+	}
+	h.publish(msg)
+}
+
+// flushPending sends the trade updates held back by coalescing, in market
+// ID order.
+func (h *WSHub) flushPending() {
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	ids := make([]string, 0, len(h.pending))
+	for id := range h.pending {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		h.publish(h.pending[id])
+		delete(h.pending, id)
+	}
+	h.flushScheduled = false
+}
+
+// Drain sends any trade updates held back by coalescing and waits until
+// Run has taken every queued message, or ctx is done. It is called on
+// shutdown so clients see the last prices before the process exits.
+func (h *WSHub) Drain(ctx context.Context) error {
+	h.flushPending()
+
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for len(h.broadcast) > 0 || len(h.direct) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+// publish sequences msg, records it for resume and hands it to Run.
+func (h *WSHub) publish(msg WSMessage) {
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+
+	msg.Seq = h.seq + 1
+	data, err := encodeWS(msg)
 	if err != nil {
 		return
 	}
+	h.seq = msg.Seq
+	f := frame{marketID: msg.MarketID, data: data}
+	h.ring[h.seq%uint64(len(h.ring))] = f
+
 	select {
-	case h.broadcast <- data:
+	case h.broadcast <- f:
 	default:
-		// Drop if buffer full to avoid blocking trade execution.
+		// Drop if buffer full to avoid blocking trade execution. The
+		// message stays in the replay buffer for clients that resume.
+		h.countDrop()
+	}
+}
+
+// SendToUser sends msg only to the connections that identified as userID
+// (see HandleWS). It is not sequenced or kept for resume: msg carries the
+// current sequence number and is dropped if the hub is backed up.
+func (h *WSHub) SendToUser(userID string, msg WSMessage) {
+	if userID == "" {
+		return
+	}
+	h.mu.RLock()
+	var conns []wsConn
+	for conn, u := range h.users {
+		if u == userID {
+			conns = append(conns, conn)
+		}
+	}
+	h.mu.RUnlock()
+	if len(conns) == 0 {
+		return
+	}
+
+	h.seqMu.Lock()
+	msg.Seq = h.seq
+	h.seqMu.Unlock()
+	data, err := encodeWS(msg)
+	if err != nil {
+		return
 	}
+	for _, conn := range conns {
+		select {
+		case h.direct <- direct{conn: conn, msgs: []wireMessage{data}}:
+		default:
+			h.countDrop()
+		}
+	}
+}
+
+// wantsMarket reports whether a client limited to filter (all markets if
+// empty) should receive a message about marketID. Messages that aren't
+// about a market go to everyone.
+func wantsMarket(filter, marketID string) bool {
+	return filter == "" || marketID == "" || marketID == filter
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(_ *http.Request) bool {
-		return true // Allow all origins during development.
-	},
+// resume builds the messages a client limited to market (all if empty)
+// needs to catch up from afterSeq: the buffered messages after it, or a
+// snapshot if some have already been evicted from the buffer.
+func (h *WSHub) resume(ctx context.Context, afterSeq uint64, market string) []wireMessage {
+	h.seqMu.Lock()
+	cur, size, snapshot := h.seq, uint64(len(h.ring)), h.snapshot
+	if afterSeq >= cur {
+		h.seqMu.Unlock()
+		return nil
+	}
+	if cur <= size || afterSeq >= cur-size {
+		out := make([]wireMessage, 0, cur-afterSeq)
+		for seq := afterSeq + 1; seq <= cur; seq++ {
+			if f := h.ring[seq%size]; wantsMarket(market, f.marketID) {
+				out = append(out, f.data)
+			}
+		}
+		h.seqMu.Unlock()
+		return out
+	}
+	h.seqMu.Unlock()
+
+	// Too far behind: send a snapshot stamped with the sequence number read
+	// before taking it. Anything broadcast since is replayable from there.
+	if snapshot == nil {
+		data, _ := encodeWS(WSMessage{Seq: cur, Type: "resync_required"})
+		return []wireMessage{data}
+	}
+	msgs, err := snapshot(ctx)
+	if err != nil {
+		slog.Error("ws snapshot failed", "err", err)
+		data, _ := encodeWS(WSMessage{Seq: cur, Type: "resync_required"})
+		return []wireMessage{data}
+	}
+	out := make([]wireMessage, 0, len(msgs))
+	for _, m := range msgs {
+		if !wantsMarket(market, m.MarketID) {
+			continue
+		}
+		m.Seq = cur
+		if data, err := encodeWS(m); err == nil {
+			out = append(out, data)
+		}
+	}
+	return out
 }
 
 // HandleWS handles WebSocket upgrade requests at GET /api/v1/ws.
+// A client that connects with ?user_id=<id> also receives the messages
+// addressed to that user, such as risk alerts. One that connects with
+// ?format=msgpack, or the "msgpack" subprotocol, receives MessagePack
+// binary frames instead of JSON text frames.
 func (h *WSHub) HandleWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	h.serve(w, r, "")
+}
+
+// HandleMarketWS handles GET /api/v1/markets/{marketID}/ws: a connection
+// that only receives broadcasts about that market, including on resume.
+// It otherwise behaves like HandleWS.
+func (h *WSHub) HandleMarketWS(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, chi.URLParam(r, "marketID"))
+}
+
+// serve upgrades the connection, limited to market unless it is empty,
+// and runs its read pump and pings.
+func (h *WSHub) serve(w http.ResponseWriter, r *http.Request, market string) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		slog.Error("ws upgrade failed", "err", err)
 		return
 	}
+	userID := r.URL.Query().Get("user_id")
+	binary := r.URL.Query().Get("format") == WSFormatMsgpack || conn.Subprotocol() == WSFormatMsgpack
+	if userID != "" || market != "" || binary {
+		h.mu.Lock()
+		if userID != "" {
+			h.users[conn] = userID
+		}
+		if market != "" {
+			h.markets[conn] = market
+		}
+		if binary {
+			h.binary[conn] = true
+		}
+		h.mu.Unlock()
+	}
+	// Only data frames are compressed; ping/pong control frames never are.
+	// A no-op unless compression was negotiated for this connection.
+	conn.EnableWriteCompression(h.compress)
 
 	h.register <- conn
 
-	// Read pump: keep connection alive and detect disconnects.
+	// Read pump: keep connection alive, detect disconnects and handle
+	// resume requests.
 	go func() {
 		defer func() { h.unregister <- conn }()
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -114,9 +526,19 @@ func (h *WSHub) HandleWS(w http.ResponseWriter, r *http.Request) {
 			return nil
 		})
 		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
 				break
 			}
+			var msg WSClientMessage
+			if json.Unmarshal(data, &msg) != nil || msg.Action != "resume" {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if msgs := h.resume(ctx, msg.AfterSeq, market); len(msgs) > 0 {
+				h.direct <- direct{conn: conn, msgs: msgs}
+			}
+			cancel()
 		}
 	}()
 
@@ -131,7 +553,9 @@ func (h *WSHub) HandleWS(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			// WriteControl may run alongside the hub's writes.
+			deadline := time.Now().Add(h.writeTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
 				return
 			}
 		}
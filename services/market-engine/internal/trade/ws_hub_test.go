@@ -0,0 +1,520 @@
+package trade_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/atmx/market-engine/internal/correlation"
+	"github.com/atmx/market-engine/internal/metrics"
+	"github.com/atmx/market-engine/internal/store"
+	"github.com/atmx/market-engine/internal/trade"
+)
+
+func dialHub(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) trade.WSMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg trade.WSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+// broadcastAndDrain broadcasts n trade messages and waits until a live
+// client has received all of them, so later clients only see replays.
+func broadcastAndDrain(t *testing.T, hub *trade.WSHub, srv *httptest.Server, n int) {
+	t.Helper()
+	live := dialHub(t, srv)
+	// Registration is synchronous with the upgrade, but the dial returns
+	// once the handshake completes; give the hub a moment to register.
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < n; i++ {
+		hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1"})
+	}
+	for i := 1; i <= n; i++ {
+		if msg := readWS(t, live); msg.Seq != uint64(i) {
+			t.Fatalf("live message %d has seq %d", i, msg.Seq)
+		}
+	}
+}
+
+func TestWSHub_ResumeWithinBuffer(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithReplayBuffer(8))
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+
+	broadcastAndDrain(t, hub, srv, 5)
+
+	conn := dialHub(t, srv)
+	if err := conn.WriteJSON(trade.WSClientMessage{Action: "resume", AfterSeq: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for want := uint64(3); want <= 5; want++ {
+		msg := readWS(t, conn)
+		if msg.Seq != want || msg.Type != "trade_executed" {
+			t.Fatalf("got seq %d type %q, want seq %d trade_executed", msg.Seq, msg.Type, want)
+		}
+	}
+}
+
+func TestWSHub_ResumeBeyondBufferSendsSnapshot(t *testing.T) {
+	ms := store.NewMemoryStore()
+	seedMarket(t, ms, "ATMX-872a1070b-PRECIP-25MM-20260815", "872a1070bffffff", 100)
+	hub := trade.NewWSHub(trade.WithReplayBuffer(4))
+	svc := trade.NewService(ms, correlation.NewPositionLimiter(d(1000), d(5000), 5), hub)
+	hub.SetSnapshot(svc.WSSnapshot)
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+
+	broadcastAndDrain(t, hub, srv, 10)
+
+	conn := dialHub(t, srv)
+	if err := conn.WriteJSON(trade.WSClientMessage{Action: "resume", AfterSeq: 2}); err != nil {
+		t.Fatal(err)
+	}
+	msg := readWS(t, conn)
+	if msg.Type != "snapshot" {
+		t.Fatalf("type = %q, want snapshot", msg.Type)
+	}
+	if msg.Seq != 10 {
+		t.Errorf("snapshot seq = %d, want 10", msg.Seq)
+	}
+	if msg.MarketID != "test-market-ATMX-872a1070b-PRECIP-25MM-20260815" || msg.PriceYes != "0.5" {
+		t.Errorf("unexpected snapshot: %+v", msg)
+	}
+}
+
+func TestWSHub_ResumeBeyondBufferWithoutSnapshot(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithReplayBuffer(2))
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+
+	broadcastAndDrain(t, hub, srv, 5)
+
+	conn := dialHub(t, srv)
+	conn.WriteJSON(trade.WSClientMessage{Action: "resume", AfterSeq: 0})
+	if msg := readWS(t, conn); msg.Type != "resync_required" || msg.Seq != 5 {
+		t.Fatalf("got %+v, want resync_required at seq 5", msg)
+	}
+}
+
+func TestWSHub_MessagesCarrySeq(t *testing.T) {
+	data, _ := json.Marshal(trade.WSMessage{Seq: 7, Type: "trade_executed"})
+	if !strings.Contains(string(data), `"seq":7`) {
+		t.Errorf("seq missing from %s", data)
+	}
+}
+
+func TestWSHub_CompressedClientReceivesBroadcast(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithCompression(true))
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if ext := resp.Header.Get("Sec-Websocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Fatalf("compression not negotiated, extensions=%q", ext)
+	}
+
+	// Control frames still work alongside compressed data frames.
+	pong := make(chan struct{}, 1)
+	conn.SetPongHandler(func(string) error { pong <- struct{}{}; return nil })
+	if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond) // let the hub register the client
+	hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1", PriceYes: "0.6"})
+	msg := readWS(t, conn) // also drives the pong handler
+	if msg.Type != "trade_executed" || msg.MarketID != "m1" || msg.PriceYes != "0.6" || msg.Seq != 1 {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	select {
+	case <-pong:
+	default:
+		t.Error("no pong received for ping")
+	}
+}
+
+func TestWSHub_MarketStreamOnlyReceivesThatMarket(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithReplayBuffer(8))
+	go hub.Run()
+	r := chi.NewRouter()
+	r.Get("/ws", hub.HandleWS)
+	r.Get("/markets/{marketID}/ws", hub.HandleMarketWS)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	all, _, err := websocket.DefaultDialer.Dial(wsURL+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer all.Close()
+	m1, _, err := websocket.DefaultDialer.Dial(wsURL+"/markets/m1/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer m1.Close()
+	time.Sleep(50 * time.Millisecond) // let the hub register both clients
+
+	for _, market := range []string{"m1", "m2", "m1", "m2"} {
+		hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: market})
+	}
+
+	// The unfiltered client sees everything, so by the time it has read
+	// the last message the hub has sent m1's stream its share.
+	for want := uint64(1); want <= 4; want++ {
+		if msg := readWS(t, all); msg.Seq != want {
+			t.Fatalf("all markets: got seq %d, want %d", msg.Seq, want)
+		}
+	}
+	for _, want := range []uint64{1, 3} {
+		if msg := readWS(t, m1); msg.Seq != want || msg.MarketID != "m1" {
+			t.Fatalf("m1 stream: got %+v, want seq %d for m1", msg, want)
+		}
+	}
+
+	// Resume is filtered the same way.
+	if err := m1.WriteJSON(trade.WSClientMessage{Action: "resume", AfterSeq: 0}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []uint64{1, 3} {
+		if msg := readWS(t, m1); msg.Seq != want || msg.MarketID != "m1" {
+			t.Fatalf("m1 resume: got %+v, want seq %d for m1", msg, want)
+		}
+	}
+
+	// Nothing else is queued for the m1 stream.
+	m1.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, data, err := m1.ReadMessage(); err == nil {
+		t.Errorf("m1 stream received unexpected message %s", data)
+	}
+}
+
+func TestWSHub_CoalescesBurstToLatestPerMarket(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithCoalescing(200 * time.Millisecond))
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+	conn := dialHub(t, srv)
+	time.Sleep(50 * time.Millisecond) // let the hub register the client
+
+	for i := 1; i <= 100; i++ {
+		for _, market := range []string{"m1", "m2"} {
+			hub.Broadcast(trade.WSMessage{
+				Type: "trade_executed", MarketID: market,
+				PriceYes: fmt.Sprintf("0.%03d", i), Side: "YES", Quantity: "1",
+			})
+		}
+	}
+
+	for i, market := range []string{"m1", "m2"} {
+		msg := readWS(t, conn)
+		if msg.MarketID != market || msg.Seq != uint64(i+1) {
+			t.Fatalf("got %+v, want seq %d for %s", msg, i+1, market)
+		}
+		if msg.PriceYes != "0.100" || msg.Trades != 100 || msg.Side != "" || msg.Quantity != "" {
+			t.Errorf("%s: got %+v, want the latest price for 100 trades", market, msg)
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	if _, data, err := conn.ReadMessage(); err == nil {
+		t.Errorf("received more than one message per market: %s", data)
+	}
+}
+
+func TestWSHub_CoalescingKeepsOrderAroundOtherMessages(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithCoalescing(time.Hour))
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+	conn := dialHub(t, srv)
+	time.Sleep(50 * time.Millisecond) // let the hub register the client
+
+	hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1", PriceYes: "0.6"})
+	hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1", PriceYes: "0.7"})
+	hub.Broadcast(trade.WSMessage{Type: "market_settled", MarketID: "m1", Outcome: "YES"})
+
+	// The held-back trade update goes out before the settlement, without
+	// waiting for the interval.
+	if msg := readWS(t, conn); msg.Type != "trade_executed" || msg.PriceYes != "0.7" || msg.Trades != 2 {
+		t.Fatalf("first message = %+v, want the coalesced trade update", msg)
+	}
+	if msg := readWS(t, conn); msg.Type != "market_settled" {
+		t.Fatalf("second message = %+v, want market_settled", msg)
+	}
+}
+
+func TestWSHub_StatsCountsDropsOnFullQueue(t *testing.T) {
+	// Without Run nothing drains the broadcast queue, so it saturates.
+	hub := trade.NewWSHub()
+	before := testutil.ToFloat64(metrics.WebSocketDroppedMessages)
+
+	capacity := hub.Stats().BroadcastCap
+	for i := 0; i < capacity+10; i++ {
+		hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1"})
+	}
+
+	st := hub.Stats()
+	if st.BroadcastQueue != capacity {
+		t.Errorf("broadcast queue = %d, want %d", st.BroadcastQueue, capacity)
+	}
+	if st.Dropped != 10 {
+		t.Errorf("dropped = %d, want 10", st.Dropped)
+	}
+	if st.Seq != uint64(capacity+10) {
+		t.Errorf("seq = %d, want %d: dropped messages are still sequenced", st.Seq, capacity+10)
+	}
+	if got := testutil.ToFloat64(metrics.WebSocketDroppedMessages); got != before+10 {
+		t.Errorf("drop metric = %v, want %v", got, before+10)
+	}
+}
+
+func TestGetWSStats(t *testing.T) {
+	hub := trade.NewWSHub()
+	go hub.Run()
+	r := chi.NewRouter()
+	r.Get("/ws", hub.HandleWS)
+	r.Get("/markets/{marketID}/ws", hub.HandleMarketWS)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	for _, path := range []string{"/ws?user_id=u1", "/ws", "/markets/m1/ws", "/markets/m1/ws", "/markets/m2/ws"} {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+path, nil)
+		if err != nil {
+			t.Fatalf("dial %s: %v", path, err)
+		}
+		defer conn.Close()
+	}
+	time.Sleep(50 * time.Millisecond) // let the hub register the clients
+
+	svc := trade.NewService(store.NewMemoryStore(), correlation.NewPositionLimiter(d(1000), d(5000), 5), hub)
+	router := chi.NewRouter()
+	router.With(trade.RequireAdmin(testAdminToken)).Get("/api/v1/admin/ws-stats", svc.GetWSStats)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/admin/ws-stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var st trade.WSStats
+	json.Unmarshal(w.Body.Bytes(), &st)
+	if st.Clients != 5 || st.Users != 1 || st.AllMarkets != 2 || st.Markets["m1"] != 2 || st.Markets["m2"] != 1 {
+		t.Errorf("unexpected stats %s", w.Body.String())
+	}
+
+	// Without a hub there is nothing to report.
+	_, _, noHub := newTestEnv(t)
+	w = httptest.NewRecorder()
+	noHub.ServeHTTP(w, adminRequest("GET", "/api/v1/admin/ws-stats", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("without a hub: expected 404, got %d", w.Code)
+	}
+}
+
+func TestWSHub_DrainSendsCoalescedUpdates(t *testing.T) {
+	hub := trade.NewWSHub(trade.WithCoalescing(time.Hour))
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+	conn := dialHub(t, srv)
+	time.Sleep(50 * time.Millisecond) // let the hub register the client
+
+	hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1", PriceYes: "0.6"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Drain(ctx); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if msg := readWS(t, conn); msg.Type != "trade_executed" || msg.PriceYes != "0.6" {
+		t.Fatalf("got %+v, want the held-back trade update", msg)
+	}
+}
+
+func TestWSHub_DrainGivesUpWhenContextDone(t *testing.T) {
+	// Without Run nothing takes from the queue.
+	hub := trade.NewWSHub()
+	hub.Broadcast(trade.WSMessage{Type: "trade_executed", MarketID: "m1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := hub.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("drain = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// decodeMsgpack decodes the subset of MessagePack the hub sends: one map
+// of string keys to string or integer values.
+func decodeMsgpack(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+	pos := 0
+	next := func(n int) []byte {
+		if pos+n > len(b) {
+			t.Fatalf("msgpack: truncated at %d", pos)
+		}
+		out := b[pos : pos+n]
+		pos += n
+		return out
+	}
+	value := func() any {
+		c := next(1)[0]
+		switch {
+		case c < 0x80:
+			return uint64(c)
+		case c >= 0xe0:
+			return int64(int8(c))
+		case c&0xe0 == 0xa0:
+			return string(next(int(c & 0x1f)))
+		case c == 0xd9:
+			return string(next(int(next(1)[0])))
+		case c == 0xda:
+			return string(next(int(binary.BigEndian.Uint16(next(2)))))
+		case c == 0xcc:
+			return uint64(next(1)[0])
+		case c == 0xcd:
+			return uint64(binary.BigEndian.Uint16(next(2)))
+		case c == 0xce:
+			return uint64(binary.BigEndian.Uint32(next(4)))
+		case c == 0xcf:
+			return binary.BigEndian.Uint64(next(8))
+		}
+		t.Fatalf("msgpack: unexpected type byte %#x", c)
+		return nil
+	}
+
+	var n int
+	switch c := next(1)[0]; {
+	case c&0xf0 == 0x80:
+		n = int(c & 0x0f)
+	case c == 0xde:
+		n = int(binary.BigEndian.Uint16(next(2)))
+	default:
+		t.Fatalf("msgpack: expected a map, got %#x", c)
+	}
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		key, ok := value().(string)
+		if !ok {
+			t.Fatalf("msgpack: non-string key")
+		}
+		out[key] = value()
+	}
+	if pos != len(b) {
+		t.Fatalf("msgpack: %d trailing bytes", len(b)-pos)
+	}
+	return out
+}
+
+func TestWSHub_MsgpackAndJSONClientsOnOneHub(t *testing.T) {
+	hub := trade.NewWSHub()
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+
+	jsonConn := dialHub(t, srv)
+	queryConn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?format=msgpack", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer queryConn.Close()
+	dialer := websocket.Dialer{Subprotocols: []string{trade.WSFormatMsgpack}}
+	protoConn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer protoConn.Close()
+	if protoConn.Subprotocol() != trade.WSFormatMsgpack {
+		t.Fatalf("subprotocol = %q, want %q", protoConn.Subprotocol(), trade.WSFormatMsgpack)
+	}
+	time.Sleep(50 * time.Millisecond) // let the hub register the clients
+
+	if st := hub.Stats(); st.Clients != 3 || st.Msgpack != 2 {
+		t.Fatalf("stats = %+v, want 3 clients, 2 msgpack", st)
+	}
+
+	hub.Broadcast(trade.WSMessage{
+		Type: "trade_executed", MarketID: "m1", ContractID: "ATMX-872a1070b-PRECIP-25MM-20250815",
+		H3CellID: "872a1070b", PriceYes: "0.55", PriceNo: "0.45", Side: "YES", Quantity: "10",
+	})
+
+	if msg := readWS(t, jsonConn); msg.Seq != 1 || msg.PriceYes != "0.55" || msg.Side != "YES" {
+		t.Errorf("json client got %+v", msg)
+	}
+	for name, conn := range map[string]*websocket.Conn{"query": queryConn, "subprotocol": protoConn} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("%s: read: %v", name, err)
+		}
+		if kind != websocket.BinaryMessage {
+			t.Fatalf("%s: frame type %d, want binary", name, kind)
+		}
+		got := decodeMsgpack(t, data)
+		want := map[string]any{
+			"seq": uint64(1), "type": "trade_executed", "market_id": "m1",
+			"contract_id": "ATMX-872a1070b-PRECIP-25MM-20250815", "h3_cell_id": "872a1070b",
+			"price_yes": "0.55", "price_no": "0.45", "side": "YES", "quantity": "10",
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestWSHub_MsgpackResume(t *testing.T) {
+	hub := trade.NewWSHub()
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWS))
+	defer srv.Close()
+	broadcastAndDrain(t, hub, srv, 3)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?format=msgpack", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	// Client messages are JSON in either format.
+	conn.WriteJSON(trade.WSClientMessage{Action: "resume", AfterSeq: 1})
+	for want := uint64(2); want <= 3; want++ {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if got := decodeMsgpack(t, data); kind != websocket.BinaryMessage || got["seq"] != want {
+			t.Fatalf("replayed %v (frame type %d), want seq %d as binary", got, kind, want)
+		}
+	}
+}
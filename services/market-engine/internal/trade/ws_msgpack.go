@@ -0,0 +1,131 @@
+// MessagePack encoding of WebSocket messages.
+
+package trade
+
+import (
+	"encoding/binary"
+	"encoding/json"
+)
+
+// WebSocket message formats. A client picks one at upgrade with
+// ?format=msgpack or the "msgpack" subprotocol; JSON text frames are the
+// default. Client messages (resume) are JSON in either format.
+const (
+	WSFormatJSON    = "json"
+	WSFormatMsgpack = "msgpack"
+)
+
+// wireMessage is a message encoded once in each format, so every client
+// can be sent its own without re-encoding per connection.
+type wireMessage struct {
+	text   []byte // JSON, sent as a text frame
+	binary []byte // MessagePack, sent as a binary frame
+}
+
+// encodeWS encodes msg in both wire formats.
+func encodeWS(msg WSMessage) (wireMessage, error) {
+	text, err := json.Marshal(msg)
+	if err != nil {
+		return wireMessage{}, err
+	}
+	return wireMessage{text: text, binary: appendMsgpack(nil, msg)}, nil
+}
+
+// appendMsgpack appends msg to b as a MessagePack map with the same keys,
+// and the same fields left out when empty, as its JSON encoding.
+func appendMsgpack(b []byte, msg WSMessage) []byte {
+	type field struct {
+		key, value string
+		omitEmpty  bool
+	}
+	strs := []field{
+		{"type", msg.Type, false},
+		{"market_id", msg.MarketID, false},
+		{"contract_id", msg.ContractID, false},
+		{"h3_cell_id", msg.H3CellID, false},
+		{"price_yes", msg.PriceYes, true},
+		{"price_no", msg.PriceNo, true},
+		{"side", msg.Side, true},
+		{"quantity", msg.Quantity, true},
+		{"outcome", msg.Outcome, true},
+		{"user_id", msg.UserID, true},
+		{"utilization", msg.Utilization, true},
+		{"threshold", msg.Threshold, true},
+	}
+
+	n := 1 // seq
+	for _, f := range strs {
+		if !f.omitEmpty || f.value != "" {
+			n++
+		}
+	}
+	if msg.Trades != 0 {
+		n++
+	}
+
+	b = mpAppendMapHeader(b, n)
+	b = mpAppendString(b, "seq")
+	b = mpAppendUint(b, msg.Seq)
+	for _, f := range strs {
+		if f.omitEmpty && f.value == "" {
+			continue
+		}
+		b = mpAppendString(b, f.key)
+		b = mpAppendString(b, f.value)
+	}
+	if msg.Trades != 0 {
+		b = mpAppendString(b, "trades")
+		b = mpAppendInt(b, int64(msg.Trades))
+	}
+	return b
+}
+
+func mpAppendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+func mpAppendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= 0xff:
+		b = append(b, 0xd9, byte(n))
+	case n <= 0xffff:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func mpAppendUint(b []byte, v uint64) []byte {
+	switch {
+	case v < 0x80:
+		return append(b, byte(v))
+	case v <= 0xff:
+		return append(b, 0xcc, byte(v))
+	case v <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+	}
+}
+
+func mpAppendInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return mpAppendUint(b, uint64(v))
+	}
+	if v >= -32 {
+		return append(b, byte(v)) // negative fixint
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+}
@@ -0,0 +1,30 @@
+// WebSocket snapshot of current market prices.
+
+package trade
+
+import "context"
+
+// WSSnapshot returns a "snapshot" message with the current prices of every
+// open market. It is used as the hub's SnapshotFunc for clients resuming
+// from beyond the replay buffer.
+func (s *Service) WSSnapshot(ctx context.Context) ([]WSMessage, error) {
+	markets, err := s.store.ListMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]WSMessage, 0, len(markets))
+	for _, m := range markets {
+		if m.Status != "open" {
+			continue
+		}
+		msgs = append(msgs, WSMessage{
+			Type:       "snapshot",
+			MarketID:   m.ID,
+			ContractID: m.ContractID,
+			H3CellID:   m.H3CellID,
+			PriceYes:   m.PriceYes.String(),
+			PriceNo:    m.PriceNo.String(),
+		})
+	}
+	return msgs, nil
+}
@@ -0,0 +1,84 @@
+// WebSocket hub introspection for operators.
+
+package trade
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/atmx/market-engine/internal/metrics"
+)
+
+// WSStats is a point-in-time view of a WSHub, returned from
+// GET /api/v1/admin/ws-stats.
+type WSStats struct {
+	Clients        int            `json:"clients"`
+	Users          int            `json:"users"`       // connections identified as a user
+	AllMarkets     int            `json:"all_markets"` // connections subscribed to every market
+	Markets        map[string]int `json:"markets"`     // market ID → connections limited to it
+	Msgpack        int            `json:"msgpack"`     // connections receiving MessagePack
+	BroadcastQueue int            `json:"broadcast_queue"`
+	BroadcastCap   int            `json:"broadcast_cap"`
+	DirectQueue    int            `json:"direct_queue"`
+	DirectCap      int            `json:"direct_cap"`
+	Coalescing     int            `json:"coalescing"` // markets with a trade update held back
+	Dropped        uint64         `json:"dropped"`    // messages discarded on a full queue
+	Seq            uint64         `json:"seq"`
+}
+
+// Stats returns the hub's current connection counts, queue depths and
+// drop count.
+func (h *WSHub) Stats() WSStats {
+	st := WSStats{
+		Markets:        make(map[string]int),
+		BroadcastQueue: len(h.broadcast),
+		BroadcastCap:   cap(h.broadcast),
+		DirectQueue:    len(h.direct),
+		DirectCap:      cap(h.direct),
+		Dropped:        h.dropped.Load(),
+	}
+
+	h.mu.RLock()
+	st.Clients = len(h.clients)
+	for conn := range h.clients {
+		if h.users[conn] != "" {
+			st.Users++
+		}
+		if h.binary[conn] {
+			st.Msgpack++
+		}
+		if m := h.markets[conn]; m != "" {
+			st.Markets[m]++
+		} else {
+			st.AllMarkets++
+		}
+	}
+	h.mu.RUnlock()
+
+	h.pendingMu.Lock()
+	st.Coalescing = len(h.pending)
+	h.pendingMu.Unlock()
+
+	h.seqMu.Lock()
+	st.Seq = h.seq
+	h.seqMu.Unlock()
+	return st
+}
+
+// countDrop records a message discarded on a full queue.
+func (h *WSHub) countDrop() {
+	h.dropped.Add(1)
+	metrics.WebSocketDroppedMessages.Inc()
+}
+
+// GetWSStats handles GET /api/v1/admin/ws-stats
+// Reports the WebSocket hub's clients, subscriptions, queue depths and
+// dropped messages; 404 if the service has no hub.
+func (s *Service) GetWSStats(w http.ResponseWriter, r *http.Request) {
+	if s.wsHub == nil {
+		writeError(w, "websocket hub is not enabled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.wsHub.Stats())
+}
@@ -0,0 +1,94 @@
+package trade
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWSConn records writes. A stuck conn blocks each write until its
+// deadline passes, as a socket whose peer has stopped reading does.
+type fakeWSConn struct {
+	stuck bool
+
+	mu       sync.Mutex
+	deadline time.Time
+	msgs     [][]byte
+	closed   bool
+}
+
+func (c *fakeWSConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeWSConn) WriteMessage(_ int, data []byte) error {
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+	if c.stuck {
+		time.Sleep(time.Until(deadline))
+		return os.ErrDeadlineExceeded
+	}
+	c.mu.Lock()
+	c.msgs = append(c.msgs, data)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeWSConn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeWSConn) received() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.msgs)
+}
+
+func TestWSHub_StuckClientDroppedWithoutStallingHub(t *testing.T) {
+	hub := NewWSHub(WithWriteTimeout(50 * time.Millisecond))
+	go hub.Run()
+
+	stuck := &fakeWSConn{stuck: true}
+	live := &fakeWSConn{}
+	hub.register <- stuck
+	hub.register <- live
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		hub.Broadcast(WSMessage{Type: "trade_executed", MarketID: "m1"})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for live.received() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := live.received(); n != 3 {
+		t.Fatalf("live client received %d of 3 broadcasts", n)
+	}
+	// Only the first broadcast waits on the stuck client.
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("broadcasts took %s; the stuck client stalled the hub", elapsed)
+	}
+
+	hub.mu.RLock()
+	_, stillRegistered := hub.clients[stuck]
+	total := len(hub.clients)
+	hub.mu.RUnlock()
+	if stillRegistered || total != 1 {
+		t.Errorf("expected only the live client to remain, stuck registered=%v total=%d", stillRegistered, total)
+	}
+	stuck.mu.Lock()
+	closed := stuck.closed
+	stuck.mu.Unlock()
+	if !closed {
+		t.Error("expected the stuck client's connection to be closed")
+	}
+}
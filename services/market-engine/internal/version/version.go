@@ -0,0 +1,40 @@
+// Package version reports which build of the market engine is running.
+// The values are set at link time, e.g.
+//
+//	go build -ldflags "-X github.com/atmx/market-engine/internal/version.Version=v1.4.0 \
+//	  -X github.com/atmx/market-engine/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/atmx/market-engine/internal/version.BuildTime=$(date -u +%FT%TZ)" ./cmd/server
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Build information, overridden with -ldflags -X. The defaults mark a
+// development build.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info is the JSON body returned from the version endpoint.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// Get returns the running build's information.
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+}
+
+// Handler serves info as JSON, for GET /api/v1/version.
+func Handler(info Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	}
+}
@@ -0,0 +1,38 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_ServesBuildInfo(t *testing.T) {
+	want := Info{Version: "v1.4.0", Commit: "0123abcd", BuildTime: "2025-08-15T12:00:00Z"}
+
+	w := httptest.NewRecorder()
+	Handler(want).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/version", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got Info
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGet_ReadsLinkTimeValues(t *testing.T) {
+	defer func(v, c, b string) { Version, Commit, BuildTime = v, c, b }(Version, Commit, BuildTime)
+	Version, Commit, BuildTime = "v2.0.0", "feedface", "2025-09-01T00:00:00Z"
+
+	if got := Get(); got != (Info{Version: "v2.0.0", Commit: "feedface", BuildTime: "2025-09-01T00:00:00Z"}) {
+		t.Errorf("Get() = %+v", got)
+	}
+}
@@ -0,0 +1,17 @@
+-- Optional per-market daily trading window ("HH:MM", UTC). Both NULL means
+-- the market trades around the clock.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS trading_open  TEXT;
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS trading_close TEXT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'markets_trading_window_check') THEN
+        ALTER TABLE markets ADD CONSTRAINT markets_trading_window_check CHECK (
+            (trading_open IS NULL AND trading_close IS NULL) OR
+            (trading_open  ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$' AND
+             trading_close ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$' AND
+             trading_open <> trading_close)
+        );
+    END IF;
+END $$;
@@ -0,0 +1,6 @@
+-- Realized P&L booked per ledger entry (average-cost method) at trade time.
+-- Entries written before this migration default to 0.
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS realized_pnl NUMERIC NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_ledger_timestamp_user ON ledger_entries(timestamp, user_id);
@@ -0,0 +1,14 @@
+-- Optional liquidity schedule: effective b interpolates from b_start at
+-- creation to b_end at contract expiry. Both NULL means b is fixed.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS b_start NUMERIC;
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS b_end   NUMERIC;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'markets_liquidity_schedule_check') THEN
+        ALTER TABLE markets ADD CONSTRAINT markets_liquidity_schedule_check CHECK (
+            (b_start IS NULL AND b_end IS NULL) OR (b_start > 0 AND b_end > 0)
+        );
+    END IF;
+END $$;
@@ -0,0 +1,13 @@
+-- Settlement reference data: the official observed value of a weather
+-- variable for one H3 cell and day. At most one observation per key.
+
+CREATE TABLE IF NOT EXISTS observations (
+    h3_cell_id  TEXT NOT NULL,
+    type        TEXT NOT NULL CHECK (type IN ('PRECIP', 'TEMP', 'WIND', 'SNOW')),
+    date        DATE NOT NULL,
+    value       NUMERIC NOT NULL,
+    unit        TEXT NOT NULL,
+    source      TEXT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT observations_pkey PRIMARY KEY (h3_cell_id, type, date)
+);
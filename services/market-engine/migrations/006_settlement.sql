@@ -0,0 +1,25 @@
+-- Settlement: markets record their outcome, and settlement writes one
+-- ledger entry per user and side closing the position at the payout.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS outcome    TEXT;
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS settled_at TIMESTAMPTZ;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'markets_outcome_check') THEN
+        ALTER TABLE markets ADD CONSTRAINT markets_outcome_check CHECK (
+            outcome IS NULL OR outcome IN ('YES', 'NO')
+        );
+    END IF;
+END $$;
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'trade';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'ledger_entries_kind_check') THEN
+        ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check CHECK (
+            kind IN ('trade', 'settlement')
+        );
+    END IF;
+END $$;
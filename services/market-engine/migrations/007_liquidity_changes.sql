@@ -0,0 +1,12 @@
+-- History of manual b changes (reliquify). Verification replays each
+-- ledger entry with the b in force when it was written.
+
+CREATE TABLE IF NOT EXISTS liquidity_changes (
+    id         BIGSERIAL PRIMARY KEY,
+    market_id  UUID NOT NULL REFERENCES markets(id),
+    old_b      NUMERIC NOT NULL CHECK (old_b > 0),
+    new_b      NUMERIC NOT NULL CHECK (new_b > 0),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_liquidity_changes_market ON liquidity_changes(market_id, changed_at);
@@ -0,0 +1,15 @@
+-- Optional per-market bounds on |quantity| per trade. NULL = unenforced.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS min_quantity NUMERIC;
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS max_quantity NUMERIC;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'markets_trade_size_check') THEN
+        ALTER TABLE markets ADD CONSTRAINT markets_trade_size_check CHECK (
+            (min_quantity IS NULL OR min_quantity > 0) AND
+            (max_quantity IS NULL OR max_quantity > 0) AND
+            (min_quantity IS NULL OR max_quantity IS NULL OR min_quantity <= max_quantity)
+        );
+    END IF;
+END $$;
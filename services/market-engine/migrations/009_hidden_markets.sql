@@ -0,0 +1,6 @@
+-- Soft-delete for test and mistaken markets. A hidden market is left out
+-- of listings and closed to trading; its row and ledger are kept.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_markets_visible ON markets(created_at DESC) WHERE hidden_at IS NULL;
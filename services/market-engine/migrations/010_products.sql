@@ -0,0 +1,18 @@
+-- Products: templates that materialize one market per H3 cell for the
+-- same contract type, threshold and date. Member markets point back at
+-- their product.
+
+CREATE TABLE IF NOT EXISTS products (
+    id               UUID PRIMARY KEY,
+    type             TEXT NOT NULL,
+    threshold        TEXT NOT NULL,
+    date             CHAR(8) NOT NULL,
+    cells            TEXT[] NOT NULL,
+    liquidity_source TEXT NOT NULL,
+    b                NUMERIC NOT NULL CHECK (b > 0),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS product_id UUID REFERENCES products(id);
+
+CREATE INDEX IF NOT EXISTS idx_markets_product ON markets(product_id) WHERE product_id IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Ledger hash chain: each entry's hash covers its fields and the hash of
+-- the previous entry in its market, and markets record the latest hash so
+-- a truncated chain is detectable. Entries written before this migration
+-- keep empty hashes and are reported as unchained.
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS hash      TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS ledger_head TEXT NOT NULL DEFAULT '';
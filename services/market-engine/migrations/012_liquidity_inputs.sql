@@ -0,0 +1,7 @@
+-- Liquidity provenance: the NWS forecast percentiles and base volume a
+-- market's b was derived from, as JSON. NULL when b was set directly.
+-- Reliquify records its inputs on the change and replaces the market's.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS liquidity_inputs JSONB;
+
+ALTER TABLE liquidity_changes ADD COLUMN IF NOT EXISTS inputs JSONB;
@@ -0,0 +1,6 @@
+-- Markets opened at a prior price: the quantities a market was seeded with
+-- so its LMSR price starts at the prior rather than 0.5. No trader holds
+-- them; ledger replay starts from them instead of (0, 0).
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS initial_q_yes NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS initial_q_no NUMERIC NOT NULL DEFAULT 0;
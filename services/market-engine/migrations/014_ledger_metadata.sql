@@ -0,0 +1,5 @@
+-- Trade metadata: the caller's tags from the trade request (source,
+-- strategy ID, ...) as a JSON object of strings. NULL when none were given.
+-- Not covered by the ledger hash chain.
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS metadata JSONB;
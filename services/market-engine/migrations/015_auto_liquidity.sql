@@ -0,0 +1,5 @@
+-- Markets opted into volume-driven liquidity: b is raised or lowered on
+-- trades by the service's auto-liquidity policy, each change recorded in
+-- liquidity_changes.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS auto_liquidity BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+-- The user who created each market, for the per-creator open market
+-- limit. Empty for operator- and product-created markets.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_markets_open_by_creator ON markets(created_by)
+    WHERE status = 'open' AND hidden_at IS NULL;
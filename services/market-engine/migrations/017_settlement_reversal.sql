@@ -0,0 +1,9 @@
+-- Unsettling a market appends a settlement_reversal entry for each of its
+-- settlement entries, naming the entry it cancels in reverses.
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS reverses UUID REFERENCES ledger_entries(id);
+
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check CHECK (
+    kind IN ('trade', 'settlement', 'settlement_reversal')
+);
@@ -0,0 +1,3 @@
+-- Per-market cap on the maker's worst-case loss. NULL = unenforced.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS subsidy_budget NUMERIC;
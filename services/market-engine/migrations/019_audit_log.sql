@@ -0,0 +1,13 @@
+-- Admin audit trail: who settled, unsettled, reliquified, hid or
+-- anonymized what, and with which parameters. Independent of the ledger.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         UUID PRIMARY KEY,
+    actor      TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    target     TEXT NOT NULL,
+    params     JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target, created_at DESC);
@@ -0,0 +1,12 @@
+-- Net cash traders have paid the maker per market, kept with the
+-- quantities by each trade so the subsidy budget check doesn't replay the
+-- ledger. Backfilled from the trade entries, which recomputes the same
+-- value if run again.
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS collected NUMERIC NOT NULL DEFAULT 0;
+
+UPDATE markets m
+SET collected = COALESCE((
+    SELECT SUM(e.cost) FROM ledger_entries e
+    WHERE e.market_id = m.id AND e.kind = 'trade'
+), 0);
@@ -0,0 +1,14 @@
+// Package migrations embeds the market engine's SQL schema migrations so
+// the server binary can apply them itself (see store.Migrate).
+//
+// Files are named NNN_description.sql and applied in lexical order. Each
+// must be idempotent (IF NOT EXISTS), since databases set up before the
+// runner existed have them applied without a version record.
+package migrations
+
+import "embed"
+
+// FS holds every *.sql migration in this directory.
+//
+//go:embed *.sql
+var FS embed.FS